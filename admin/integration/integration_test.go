@@ -0,0 +1,276 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/mutualtls"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/testsupport"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var (
+	DEFAULT_TIMEOUT = "5s"
+
+	fakeServer       *testsupport.FakeController
+	serverListenAddr string
+)
+
+var _ = BeforeEach(func() {
+	serverListenAddr = fmt.Sprintf("127.0.0.1:%d", 41000+GinkgoParallelProcess())
+
+	serverTLSConfig, err := mutualtls.NewServerTLSConfig(paths.ServerCertFile, paths.ServerKeyFile, paths.ClientCACertFile)
+	Expect(err).NotTo(HaveOccurred())
+	fakeServer = testsupport.StartServer(serverListenAddr, serverTLSConfig)
+
+	fakeServer.SetHandler("/admin/leases", &testsupport.FakeHandler{
+		ResponseCode: 200,
+		ResponseBody: map[string]interface{}{
+			"leases": []controller.LeaseRecord{
+				{
+					Lease:         controller.Lease{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24", OverlayHardwareAddr: "ee:ee:0a:ff:5a:00"},
+					LastRenewedAt: 1700000000,
+				},
+				{
+					Lease:         controller.Lease{UnderlayIP: "10.0.5.9", OverlaySubnet: "10.250.30.0/24", OverlayHardwareAddr: "ee:ee:0a:fa:1e:00", Pool: "blue"},
+					LastRenewedAt: 1690000000,
+					Expired:       true,
+				},
+			},
+		},
+	})
+	fakeServer.SetHandler("/admin/pools", &testsupport.FakeHandler{
+		ResponseCode: 200,
+		ResponseBody: map[string]interface{}{
+			"pools": []controller.PoolUsage{{
+				Blocks:    controller.SubnetUsage{Total: 255, Active: 1, Expired: 1, Reserved: 1, Free: 252},
+				SingleIPs: controller.SubnetUsage{Total: 255, Quarantined: 2, Free: 253},
+			}},
+		},
+	})
+})
+
+var _ = AfterEach(func() {
+	fakeServer.Stop()
+})
+
+var _ = Describe("silk-admin", func() {
+	Describe("leases list", func() {
+		It("prints a table of the leases", func() {
+			session := runAdmin("leases", "list")
+			Expect(session).To(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say(`UNDERLAY IP\s+OVERLAY SUBNET\s+OVERLAY SUBNET V6\s+POOL\s+LAST RENEWED\s+EXPIRED`))
+			Expect(session.Out).To(gbytes.Say(`10.0.3.1\s+10.255.90.0/24\s+-\s+default\s+2023-11-14T22:13:20Z\s+false`))
+			Expect(session.Out).To(gbytes.Say(`10.0.5.9\s+10.250.30.0/24\s+-\s+blue\s+2023-07-22T04:26:40Z\s+true`))
+		})
+
+		It("filters by pool and expiry", func() {
+			session := runAdmin("leases", "list", "-pool", "default")
+			Expect(session).To(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).To(ContainSubstring("10.0.3.1"))
+			Expect(string(session.Out.Contents())).NotTo(ContainSubstring("10.0.5.9"))
+
+			session = runAdmin("leases", "list", "-expired")
+			Expect(session).To(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).NotTo(ContainSubstring("10.0.3.1"))
+			Expect(string(session.Out.Contents())).To(ContainSubstring("10.0.5.9"))
+		})
+
+		It("prints json with -json", func() {
+			session := runAdmin("-json", "leases", "list", "-expired")
+			Expect(session).To(gexec.Exit(0))
+			Expect(session.Out.Contents()).To(MatchJSON(`{ "leases": [ {
+				"underlay_ip": "10.0.5.9",
+				"overlay_subnet": "10.250.30.0/24",
+				"overlay_hardware_addr": "ee:ee:0a:fa:1e:00",
+				"pool": "blue",
+				"last_renewed_at": 1690000000,
+				"expired": true
+			} ] }`))
+		})
+	})
+
+	Describe("leases show", func() {
+		var requestedQuery string
+
+		BeforeEach(func() {
+			fakeServer.SetHandlerFunc("/admin/leases", func(w http.ResponseWriter, r *http.Request) {
+				requestedQuery = r.URL.RawQuery
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"leases": []controller.LeaseRecord{{
+						Lease:         controller.Lease{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24", OverlayHardwareAddr: "ee:ee:0a:ff:5a:00"},
+						LastRenewedAt: 1700000000,
+					}},
+				})
+			})
+			fakeServer.SetHandler("/admin/reservations", &testsupport.FakeHandler{
+				ResponseCode: 200,
+				ResponseBody: map[string]interface{}{
+					"reservations": []controller.Reservation{{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24"}},
+				},
+			})
+			fakeServer.SetHandler("/admin/leases/events", &testsupport.FakeHandler{
+				ResponseCode: 200,
+				ResponseBody: map[string]interface{}{
+					"events": []controller.LeaseEvent{{Type: "acquired", UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24", Actor: "10.0.3.1", Timestamp: 1700000000}},
+				},
+			})
+		})
+
+		It("prints the lease, its reservation and its events", func() {
+			session := runAdmin("leases", "show", "10.0.3.1")
+			Expect(session).To(gexec.Exit(0))
+			Expect(requestedQuery).To(Equal("underlay_ip=10.0.3.1"))
+			Expect(session.Out).To(gbytes.Say(`overlay subnet:\s+10.255.90.0/24`))
+			Expect(session.Out).To(gbytes.Say(`reservation:\s+10.255.90.0/24 in pool default`))
+			Expect(session.Out).To(gbytes.Say(`2023-11-14T22:13:20Z\s+acquired\s+10.0.3.1\s+10.255.90.0/24\s+default\s+10.0.3.1`))
+		})
+	})
+
+	Describe("leases release", func() {
+		It("asks the controller to release the lease", func() {
+			handler := &testsupport.FakeHandler{ResponseCode: 200, ResponseBody: struct{}{}}
+			fakeServer.SetHandler("/admin/leases/release", handler)
+
+			session := runAdmin("leases", "release", "10.0.3.1")
+			Expect(session).To(gexec.Exit(0))
+			Expect(handler.LastRequestBody).To(MatchJSON(`{ "underlay_ip": "10.0.3.1" }`))
+			Expect(session.Out).To(gbytes.Say("released the lease of 10.0.3.1"))
+		})
+	})
+
+	Describe("reservations add", func() {
+		It("asks the controller to add the reservation", func() {
+			handler := &testsupport.FakeHandler{ResponseCode: 200, ResponseBody: struct{}{}}
+			fakeServer.SetHandler("/admin/reservations/add", handler)
+
+			session := runAdmin("reservations", "add", "-pool", "blue", "10.0.3.1", "10.250.1.0/24")
+			Expect(session).To(gexec.Exit(0))
+			Expect(handler.LastRequestBody).To(MatchJSON(`{ "underlay_ip": "10.0.3.1", "overlay_subnet": "10.250.1.0/24", "pool": "blue" }`))
+		})
+	})
+
+	Describe("reservations remove", func() {
+		It("asks the controller to remove the reservation", func() {
+			handler := &testsupport.FakeHandler{ResponseCode: 200, ResponseBody: struct{}{}}
+			fakeServer.SetHandler("/admin/reservations/remove", handler)
+
+			session := runAdmin("reservations", "remove", "10.0.3.1")
+			Expect(session).To(gexec.Exit(0))
+			Expect(handler.LastRequestBody).To(MatchJSON(`{ "underlay_ip": "10.0.3.1" }`))
+		})
+	})
+
+	Describe("pool usage", func() {
+		It("prints the usage of every pool", func() {
+			session := runAdmin("pool", "usage")
+			Expect(session).To(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say(`POOL\s+SUBNETS\s+TOTAL\s+ACTIVE\s+EXPIRED\s+QUARANTINED\s+RESERVED\s+FREE`))
+			Expect(session.Out).To(gbytes.Say(`default\s+blocks\s+255\s+1\s+1\s+0\s+1\s+252`))
+			Expect(session.Out).To(gbytes.Say(`default\s+single ips\s+255\s+0\s+0\s+2\s+0\s+253`))
+		})
+	})
+
+	Describe("events tail", func() {
+		var (
+			lock    sync.Mutex
+			events  []controller.LeaseEvent
+			queries []string
+			now     int64
+		)
+
+		BeforeEach(func() {
+			now = time.Now().Unix()
+			events = []controller.LeaseEvent{
+				{Type: "acquired", UnderlayIP: "10.0.3.1", Actor: "10.0.3.1", Timestamp: now - 60},
+				{Type: "released", UnderlayIP: "10.0.3.1", Actor: "silk-admin", Timestamp: now},
+			}
+			queries = nil
+			fakeServer.SetHandlerFunc("/admin/leases/events", func(w http.ResponseWriter, r *http.Request) {
+				lock.Lock()
+				defer lock.Unlock()
+				queries = append(queries, r.URL.Query().Get("since"))
+				json.NewEncoder(w).Encode(map[string]interface{}{"events": events})
+			})
+		})
+
+		It("prints the last events", func() {
+			session := runAdmin("events", "tail", "-n", "1")
+			Expect(session).To(gexec.Exit(0))
+			Expect(string(session.Out.Contents())).NotTo(ContainSubstring("acquired"))
+			Expect(session.Out).To(gbytes.Say(`released\s+10.0.3.1`))
+		})
+
+		It("follows new events without repeating the printed ones", func() {
+			cmd := adminCommand("-json", "events", "tail", "-f", "-interval", "100ms")
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			defer session.Kill()
+
+			Eventually(session.Out, DEFAULT_TIMEOUT).Should(gbytes.Say(`"type":"acquired"`))
+			Eventually(session.Out, DEFAULT_TIMEOUT).Should(gbytes.Say(`"type":"released"`))
+
+			lock.Lock()
+			events = []controller.LeaseEvent{
+				{Type: "released", UnderlayIP: "10.0.3.1", Actor: "silk-admin", Timestamp: now},
+				{Type: "acquired", UnderlayIP: "10.0.3.2", Actor: "10.0.3.2", Timestamp: now},
+			}
+			lock.Unlock()
+
+			Eventually(session.Out, DEFAULT_TIMEOUT).Should(gbytes.Say(`"type":"acquired","underlay_ip":"10.0.3.2"`))
+			Consistently(session.Out, "500ms").ShouldNot(gbytes.Say(`"type"`))
+
+			lock.Lock()
+			Expect(queries[len(queries)-1]).To(Equal(strconv.FormatInt(now, 10)))
+			lock.Unlock()
+		})
+	})
+
+	Context("when the controller refuses the client", func() {
+		BeforeEach(func() {
+			fakeServer.SetHandler("/admin/pools", &testsupport.FakeHandler{
+				ResponseCode: http.StatusForbidden,
+				ResponseBody: map[string]string{"error": "client is not an admin"},
+			})
+		})
+
+		It("exits with an error", func() {
+			session := runAdmin("pool", "usage")
+			Expect(session).To(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("get pool usage: http status 403: client is not an admin"))
+		})
+	})
+
+	Context("when the command is unknown", func() {
+		It("exits with an error", func() {
+			session := runAdmin("leases", "frobnicate")
+			Expect(session).To(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("unknown command: leases frobnicate"))
+		})
+	})
+})
+
+func adminCommand(args ...string) *exec.Cmd {
+	return exec.Command(paths.AdminBin, append([]string{
+		"-url", fmt.Sprintf("https://%s", serverListenAddr),
+		"-ca-cert", paths.ServerCACertFile,
+		"-client-cert", paths.ClientCertFile,
+		"-client-key", paths.ClientKeyFile,
+	}, args...)...)
+}
+
+func runAdmin(args ...string) *gexec.Session {
+	session, err := gexec.Start(adminCommand(args...), GinkgoWriter, GinkgoWriter)
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(session, DEFAULT_TIMEOUT).Should(gexec.Exit())
+	return session
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 
+	"code.cloudfoundry.org/silk/controller"
 	"gopkg.in/validator.v2"
 )
 
@@ -30,6 +32,20 @@ type Config struct {
 	LogPrefix                 string `json:"log_prefix" validate:"nonzero"`
 	LogLevel                  string `json:"log_level"`
 	SingleIPOnly              bool   `json:"single_ip_only"`
+	OverlayPool               string `json:"overlay_pool"`
+
+	// AdditionalOverlayNetworks lists the other networks of the overlay
+	// pool, such as those being drained, so that leases in them are routed.
+	AdditionalOverlayNetworks []string `json:"additional_overlay_networks"`
+
+	// WatchTimeoutSeconds is how long the daemon waits on the controller for
+	// a change to the leases before asking again. Zero turns watching off and
+	// leaves leases to be picked up every poll interval.
+	WatchTimeoutSeconds int `json:"watch_timeout_seconds" validate:"min=0"`
+
+	// Host is sent with every acquisition and renewal of the lease, so that
+	// operators can tell which VM holds it.
+	Host *controller.HostMetadata `json:"host"`
 }
 
 func LoadConfig(filePath string) (Config, error) {
@@ -47,5 +63,26 @@ func LoadConfig(filePath string) (Config, error) {
 	if err := validator.Validate(cfg); err != nil {
 		return cfg, fmt.Errorf("invalid config: %s", err)
 	}
+	if _, err := cfg.OverlayNetworks(); err != nil {
+		return cfg, fmt.Errorf("invalid config: %s", err)
+	}
 	return cfg, nil
 }
+
+// OverlayNetworks returns the overlay network followed by the additional
+// overlay networks.
+func (c Config) OverlayNetworks() ([]*net.IPNet, error) {
+	_, overlayNetwork, err := net.ParseCIDR(c.OverlayNetwork)
+	if err != nil {
+		return nil, fmt.Errorf("OverlayNetwork: %s", err)
+	}
+	networks := []*net.IPNet{overlayNetwork}
+	for _, network := range c.AdditionalOverlayNetworks {
+		_, additional, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, fmt.Errorf("AdditionalOverlayNetworks: %s", err)
+		}
+		networks = append(networks, additional)
+	}
+	return networks, nil
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"code.cloudfoundry.org/silk/client/config"
+	"code.cloudfoundry.org/silk/controller"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -92,6 +93,55 @@ var _ = Describe("Config.LoadConfig", func() {
 		})
 	})
 
+	Context("when overlay_pool is specified", func() {
+		It("sets OverlayPool", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["overlay_pool"] = "blue"
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.OverlayPool).To(Equal("blue"))
+		})
+	})
+
+	Context("when additional_overlay_networks are specified", func() {
+		It("returns them after the overlay network", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["additional_overlay_networks"] = []string{"10.254.0.0/16"}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			networks, err := loadedConfig.OverlayNetworks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(networks).To(HaveLen(2))
+			Expect(networks[0].String()).To(Equal("10.255.0.0/16"))
+			Expect(networks[1].String()).To(Equal("10.254.0.0/16"))
+		})
+
+		It("errors when one is not a network", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["additional_overlay_networks"] = []string{"banana"}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			_, err = config.LoadConfig(file.Name())
+			Expect(err).To(MatchError("invalid config: AdditionalOverlayNetworks: invalid CIDR address: banana"))
+		})
+	})
+
 	Context("when vxlan_interface_name is specified", func() {
 		It("sets VxlanInterfaceName", func() {
 			cfg := cloneMap(requiredFields)
@@ -107,4 +157,61 @@ var _ = Describe("Config.LoadConfig", func() {
 			Expect(loadedConfig.VxlanInterfaceName).To(Equal("something"))
 		})
 	})
+
+	Context("when watch_timeout_seconds is specified", func() {
+		It("sets WatchTimeoutSeconds", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["watch_timeout_seconds"] = 30
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.WatchTimeoutSeconds).To(Equal(30))
+		})
+
+		It("errors when it is negative", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["watch_timeout_seconds"] = -1
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			_, err = config.LoadConfig(file.Name())
+			Expect(err).To(MatchError(HavePrefix("invalid config: WatchTimeoutSeconds")))
+		})
+	})
+
+	Context("when host is specified", func() {
+		It("sets Host", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["host"] = map[string]interface{}{
+				"hostname":    "diego-cell-0",
+				"az":          "z2",
+				"deployment":  "cf",
+				"instance_id": "6f1c1a5e",
+				"labels":      map[string]string{"stack": "cflinuxfs4"},
+			}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.Host).To(Equal(&controller.HostMetadata{
+				Hostname:   "diego-cell-0",
+				AZ:         "z2",
+				Deployment: "cf",
+				InstanceID: "6f1c1a5e",
+				Labels:     map[string]string{"stack": "cflinuxfs4"},
+			}))
+		})
+	})
 })
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+// defaultPoolName stands for the pool of the top level network on the
+// command line and in tables.
+const defaultPoolName = "default"
+
+type command struct {
+	client *controller.AdminClient
+	out    *output
+}
+
+func (c *command) run(resource, action string, args []string) error {
+	switch resource + " " + action {
+	case "leases list":
+		return c.leasesList(args)
+	case "leases show":
+		return c.leasesShow(args)
+	case "leases release":
+		return c.leasesRelease(args)
+	case "reservations list":
+		return c.reservationsList(args)
+	case "reservations add":
+		return c.reservationsAdd(args)
+	case "reservations remove":
+		return c.reservationsRemove(args)
+	case "pool usage":
+		return c.poolUsage(args)
+	case "events tail":
+		return c.eventsTail(args)
+	}
+	return fmt.Errorf("unknown command: %s %s", resource, action)
+}
+
+func (c *command) leasesList(args []string) error {
+	flags := flag.NewFlagSet("leases list", flag.ContinueOnError)
+	pool := flags.String("pool", "", "only list the leases of the pool")
+	expired := flags.Bool("expired", false, "only list expired leases")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	records, err := c.client.LeaseRecords("")
+	if err != nil {
+		return fmt.Errorf("get leases: %s", err)
+	}
+	filtered := []controller.LeaseRecord{}
+	for _, record := range records {
+		if *pool != "" && poolName(record.Pool) != *pool {
+			continue
+		}
+		if *expired && !record.Expired {
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+	return c.out.leaseRecords(filtered)
+}
+
+func (c *command) leasesShow(args []string) error {
+	flags := flag.NewFlagSet("leases show", flag.ContinueOnError)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+	underlayIP := flags.Arg(0)
+
+	records, err := c.client.LeaseRecords(underlayIP)
+	if err != nil {
+		return fmt.Errorf("get lease: %s", err)
+	}
+	reservations, err := c.client.Reservations()
+	if err != nil {
+		return fmt.Errorf("get reservations: %s", err)
+	}
+	events, err := c.client.LeaseEvents(controller.LeaseEventFilter{UnderlayIP: underlayIP})
+	if err != nil {
+		return fmt.Errorf("get lease events: %s", err)
+	}
+
+	details := leaseDetails{Events: events}
+	if len(records) > 0 {
+		details.Lease = &records[0]
+	}
+	for i := range reservations {
+		if reservations[i].UnderlayIP == underlayIP {
+			details.Reservation = &reservations[i]
+		}
+	}
+	if details.Lease == nil && details.Reservation == nil && len(events) == 0 {
+		return fmt.Errorf("no lease, reservation or event for underlay ip %s", underlayIP)
+	}
+	return c.out.leaseDetails(details)
+}
+
+func (c *command) leasesRelease(args []string) error {
+	flags := flag.NewFlagSet("leases release", flag.ContinueOnError)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	err := c.client.ReleaseLease(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("release lease: %s", err)
+	}
+	return c.out.message(fmt.Sprintf("released the lease of %s", flags.Arg(0)))
+}
+
+func (c *command) reservationsList(args []string) error {
+	flags := flag.NewFlagSet("reservations list", flag.ContinueOnError)
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	reservations, err := c.client.Reservations()
+	if err != nil {
+		return fmt.Errorf("get reservations: %s", err)
+	}
+	return c.out.reservations(reservations)
+}
+
+func (c *command) reservationsAdd(args []string) error {
+	flags := flag.NewFlagSet("reservations add", flag.ContinueOnError)
+	pool := flags.String("pool", "", "pool of the reservation, the default pool if empty")
+	if err := parse(flags, args, 2); err != nil {
+		return err
+	}
+
+	reservation := controller.Reservation{
+		UnderlayIP:    flags.Arg(0),
+		OverlaySubnet: flags.Arg(1),
+	}
+	if *pool != defaultPoolName {
+		reservation.Pool = *pool
+	}
+	err := c.client.AddReservation(reservation)
+	if err != nil {
+		return fmt.Errorf("add reservation: %s", err)
+	}
+	return c.out.message(fmt.Sprintf("reserved %s for %s", reservation.OverlaySubnet, reservation.UnderlayIP))
+}
+
+func (c *command) reservationsRemove(args []string) error {
+	flags := flag.NewFlagSet("reservations remove", flag.ContinueOnError)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	err := c.client.RemoveReservation(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("remove reservation: %s", err)
+	}
+	return c.out.message(fmt.Sprintf("removed the reservation of %s", flags.Arg(0)))
+}
+
+func (c *command) poolUsage(args []string) error {
+	flags := flag.NewFlagSet("pool usage", flag.ContinueOnError)
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	usage, err := c.client.PoolUsage()
+	if err != nil {
+		return fmt.Errorf("get pool usage: %s", err)
+	}
+	return c.out.poolUsage(usage)
+}
+
+// eventsTail prints the last events, and with -f keeps polling for newer
+// ones. The controller filters events by whole seconds, so each poll asks
+// again for the second of the last event printed and skips the events of that
+// second it has already printed.
+func (c *command) eventsTail(args []string) error {
+	flags := flag.NewFlagSet("events tail", flag.ContinueOnError)
+	underlayIP := flags.String("underlay-ip", "", "only show the events of the underlay ip")
+	since := flags.Duration("since", time.Hour, "how far back to look for events")
+	count := flags.Int("n", 20, "number of events to show")
+	follow := flags.Bool("f", false, "keep polling for new events")
+	interval := flags.Duration("interval", 2*time.Second, "time between polls with -f")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	filter := controller.LeaseEventFilter{
+		UnderlayIP: *underlayIP,
+		Since:      time.Now().Add(-*since).Unix(),
+	}
+	events, err := c.client.LeaseEvents(filter)
+	if err != nil {
+		return fmt.Errorf("get lease events: %s", err)
+	}
+	if len(events) > *count {
+		events = events[len(events)-*count:]
+	}
+	if err := c.out.events(events); err != nil {
+		return err
+	}
+
+	seen := map[controller.LeaseEvent]bool{}
+	for *follow {
+		for _, event := range events {
+			if event.Timestamp > filter.Since {
+				filter.Since = event.Timestamp
+				seen = map[controller.LeaseEvent]bool{}
+			}
+			seen[event] = true
+		}
+
+		time.Sleep(*interval)
+		polled, err := c.client.LeaseEvents(filter)
+		if err != nil {
+			return fmt.Errorf("get lease events: %s", err)
+		}
+		events = events[:0]
+		for _, event := range polled {
+			if !seen[event] {
+				events = append(events, event)
+			}
+		}
+		if err := c.out.events(events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parse(flags *flag.FlagSet, args []string, nArgs int) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != nArgs {
+		return fmt.Errorf("%s takes %d arguments, got %d", flags.Name(), nArgs, flags.NArg())
+	}
+	return nil
+}
+
+func poolName(pool string) string {
+	if pool == controller.DefaultPool {
+		return defaultPoolName
+	}
+	return pool
+}
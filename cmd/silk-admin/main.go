@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/mutualtls"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+const usage = `usage: silk-admin [global flags] <command> [flags] [args]
+
+commands:
+  leases list [-pool name] [-expired]
+  leases show <underlay-ip>
+  leases release <underlay-ip>
+  reservations list
+  reservations add [-pool name] <underlay-ip> <overlay-subnet>
+  reservations remove <underlay-ip>
+  pool usage
+  events tail [-underlay-ip ip] [-since duration] [-n count] [-f] [-interval duration]
+
+global flags:
+`
+
+func main() {
+	if err := mainWithError(os.Args[1:], os.Stdout); err != nil {
+		log.Fatalf("silk-admin error: %s", err)
+	}
+}
+
+func mainWithError(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("silk-admin", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	controllerURL := flags.String("url", "", "url of the silk controller, e.g. https://silk-controller.service.cf.internal:4103")
+	caCertFile := flags.String("ca-cert", "", "path to the ca certificate of the silk controller")
+	clientCertFile := flags.String("client-cert", "", "path to a client certificate with an admin identity")
+	clientKeyFile := flags.String("client-key", "", "path to the key of the client certificate")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of each request to the controller")
+	jsonOutput := flags.Bool("json", false, "print json instead of tables")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *controllerURL == "" || *caCertFile == "" || *clientCertFile == "" || *clientKeyFile == "" {
+		flags.Usage()
+		return errors.New("-url, -ca-cert, -client-cert and -client-key are required")
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errors.New("missing command")
+	}
+
+	tlsConfig, err := mutualtls.NewClientTLSConfig(*clientCertFile, *clientKeyFile, *caCertFile)
+	if err != nil {
+		return fmt.Errorf("create tls config: %s", err)
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+		Timeout: *timeout,
+	}
+
+	logger := lager.NewLogger("silk-admin")
+	cmd := &command{
+		client: controller.NewAdminClient(logger, httpClient, *controllerURL),
+		out:    &output{writer: stdout, json: *jsonOutput},
+	}
+	return cmd.run(flags.Arg(0), flags.Arg(1), flags.Args()[2:])
+}
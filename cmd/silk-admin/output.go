@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type leaseDetails struct {
+	Lease       *controller.LeaseRecord `json:"lease"`
+	Reservation *controller.Reservation `json:"reservation"`
+	Events      []controller.LeaseEvent `json:"events"`
+}
+
+// output prints either tables for people or json for scripts.
+type output struct {
+	writer io.Writer
+	json   bool
+}
+
+func (o *output) leaseRecords(records []controller.LeaseRecord) error {
+	if o.json {
+		return o.encode(struct {
+			Leases []controller.LeaseRecord `json:"leases"`
+		}{records})
+	}
+	return o.table(func(w io.Writer) {
+		fmt.Fprintln(w, "UNDERLAY IP\tOVERLAY SUBNET\tOVERLAY SUBNET V6\tPOOL\tLAST RENEWED\tEXPIRED")
+		for _, record := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n",
+				record.UnderlayIP, record.OverlaySubnet, orDash(record.OverlaySubnetV6),
+				poolName(record.Pool), formatTime(record.LastRenewedAt), record.Expired)
+		}
+	})
+}
+
+func (o *output) leaseDetails(details leaseDetails) error {
+	if o.json {
+		return o.encode(details)
+	}
+	return o.table(func(w io.Writer) {
+		if lease := details.Lease; lease != nil {
+			fmt.Fprintf(w, "underlay ip:\t%s\n", lease.UnderlayIP)
+			fmt.Fprintf(w, "overlay subnet:\t%s\n", lease.OverlaySubnet)
+			fmt.Fprintf(w, "overlay subnet v6:\t%s\n", orDash(lease.OverlaySubnetV6))
+			fmt.Fprintf(w, "overlay hardware addr:\t%s\n", lease.OverlayHardwareAddr)
+			fmt.Fprintf(w, "pool:\t%s\n", poolName(lease.Pool))
+			fmt.Fprintf(w, "last renewed:\t%s\n", formatTime(lease.LastRenewedAt))
+			fmt.Fprintf(w, "expired:\t%t\n", lease.Expired)
+		} else {
+			fmt.Fprintf(w, "lease:\t-\n")
+		}
+		if reservation := details.Reservation; reservation != nil {
+			fmt.Fprintf(w, "reservation:\t%s in pool %s\n", reservation.OverlaySubnet, poolName(reservation.Pool))
+		} else {
+			fmt.Fprintf(w, "reservation:\t-\n")
+		}
+	}, func(w io.Writer) {
+		writeEvents(w, details.Events)
+	})
+}
+
+func (o *output) reservations(reservations []controller.Reservation) error {
+	if o.json {
+		return o.encode(struct {
+			Reservations []controller.Reservation `json:"reservations"`
+		}{reservations})
+	}
+	return o.table(func(w io.Writer) {
+		fmt.Fprintln(w, "UNDERLAY IP\tOVERLAY SUBNET\tPOOL")
+		for _, reservation := range reservations {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", reservation.UnderlayIP, reservation.OverlaySubnet, poolName(reservation.Pool))
+		}
+	})
+}
+
+func (o *output) poolUsage(usage []controller.PoolUsage) error {
+	if o.json {
+		return o.encode(struct {
+			Pools []controller.PoolUsage `json:"pools"`
+		}{usage})
+	}
+	return o.table(func(w io.Writer) {
+		fmt.Fprintln(w, "POOL\tSUBNETS\tTOTAL\tACTIVE\tEXPIRED\tQUARANTINED\tRESERVED\tFREE")
+		for _, pool := range usage {
+			writeSubnetUsage(w, poolName(pool.Pool), "blocks", pool.Blocks)
+			writeSubnetUsage(w, poolName(pool.Pool), "single ips", pool.SingleIPs)
+		}
+	})
+}
+
+// events prints one json object per line, so that a followed tail can be
+// piped into other tools.
+func (o *output) events(events []controller.LeaseEvent) error {
+	if o.json {
+		for _, event := range events {
+			if err := o.encode(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return o.table(func(w io.Writer) {
+		for _, event := range events {
+			writeEvent(w, event)
+		}
+	})
+}
+
+func (o *output) message(message string) error {
+	if o.json {
+		return nil
+	}
+	_, err := fmt.Fprintln(o.writer, message)
+	return err
+}
+
+func (o *output) encode(v interface{}) error {
+	err := json.NewEncoder(o.writer).Encode(v)
+	if err != nil {
+		return fmt.Errorf("encode output: %s", err)
+	}
+	return nil
+}
+
+// table writes each section aligned on its own, separated by a blank line.
+func (o *output) table(sections ...func(io.Writer)) error {
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Fprintln(o.writer)
+		}
+		w := tabwriter.NewWriter(o.writer, 0, 8, 2, ' ', 0)
+		section(w)
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("write output: %s", err)
+		}
+	}
+	return nil
+}
+
+func writeSubnetUsage(w io.Writer, pool, subnets string, usage controller.SubnetUsage) {
+	fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n", pool, subnets,
+		usage.Total, usage.Active, usage.Expired, usage.Quarantined, usage.Reserved, usage.Free)
+}
+
+func writeEvents(w io.Writer, events []controller.LeaseEvent) {
+	fmt.Fprintln(w, "TIME\tTYPE\tUNDERLAY IP\tOVERLAY SUBNET\tPOOL\tACTOR\tREASON")
+	for _, event := range events {
+		writeEvent(w, event)
+	}
+}
+
+func writeEvent(w io.Writer, event controller.LeaseEvent) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		formatTime(event.Timestamp), event.Type, event.UnderlayIP, orDash(event.OverlaySubnet),
+		poolName(event.Pool), event.Actor, orDash(event.Reason))
+}
+
+func formatTime(seconds int64) string {
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -18,12 +19,21 @@ import (
 	"code.cloudfoundry.org/debugserver"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagerflags"
+	"code.cloudfoundry.org/silk/controller"
 	"code.cloudfoundry.org/silk/controller/config"
 	"code.cloudfoundry.org/silk/controller/database"
 	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/leader"
 	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/prometheus_metrics"
+	"code.cloudfoundry.org/silk/controller/ratelimit"
+	"code.cloudfoundry.org/silk/controller/reaper"
 	"code.cloudfoundry.org/silk/controller/server_metrics"
+	"code.cloudfoundry.org/silk/controller/watcher"
 	"github.com/cloudfoundry/dropsonde"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/grouper"
 	"github.com/tedsuo/ifrit/http_server"
@@ -36,6 +46,14 @@ var (
 	logPrefix = "cfnetworking"
 )
 
+const (
+	leaseWatchInterval           = time.Second
+	defaultLeaseWatchHistorySize = 1000
+	maxLeaseWatchTimeout         = time.Minute
+
+	defaultLeaderHeartbeatSeconds = 5
+)
+
 func main() {
 	if err := mainWithError(); err != nil {
 		log.Fatalf("%s.silk-controller error: %s", logPrefix, err)
@@ -69,32 +87,164 @@ func mainWithError() error {
 		return fmt.Errorf("mutual tls config: %s", err)
 	}
 
-	connectionPool, err := db.NewConnectionPool(
-		conf.Database,
-		conf.MaxOpenConnections,
-		conf.MaxIdleConnections,
-		time.Duration(conf.MaxConnectionsLifetimeSeconds)*time.Second,
-		logPrefix,
-		jobPrefix,
-		logger,
-	)
+	var store database.Store
+	var connectionPool *db.ConnWrapper
+	if conf.Database.Type == database.Memory {
+		logger.Info("keeping leases in memory")
+		store = database.NewMemoryStore(database.ClockFunc(time.Now))
+	} else {
+		connectionPool, err = database.NewConnectionPool(
+			conf.Database,
+			conf.MaxOpenConnections,
+			conf.MaxIdleConnections,
+			time.Duration(conf.MaxConnectionsLifetimeSeconds)*time.Second,
+			logPrefix,
+			jobPrefix,
+			logger,
+		)
+		if err != nil {
+			return fmt.Errorf("connecting to database: %s", err)
+		}
+		store = database.NewDatabaseHandler(&database.MigrateAdapter{}, connectionPool)
+	}
+	leaseCache := database.NewLeaseCache(store, time.Duration(conf.LeaseCacheSeconds)*time.Second, database.ClockFunc(time.Now))
+	store = leaseCache
+
+	reaperPolicy, err := reaper.NewPolicy(conf.Reaper.Policy, conf.Reaper.ExpiryMultiple, conf.Reaper.UtilizationThresholdPercent)
+	if err != nil {
+		return fmt.Errorf("creating reaper policy: %s", err)
+	}
+	if reaperPolicy.Name != reaper.PolicyNever && conf.Reaper.IntervalSeconds < 1 {
+		return fmt.Errorf("creating reaper policy: interval_seconds must be at least 1 with the %s policy", reaperPolicy.Name)
+	}
+
+	identity, err := leaderIdentity(conf)
 	if err != nil {
-		return fmt.Errorf("connecting to database: %s", err)
+		return fmt.Errorf("creating leader elector: %s", err)
+	}
+	// without an election every controller leads and runs the background jobs
+	var leadership interface {
+		Leader() string
+		IsLeader() bool
+	} = leader.Sole{Identity: identity}
+	whileLeading := func(runner ifrit.Runner) ifrit.Runner { return runner }
+	var elector *leader.Elector
+	if conf.LeaderElection.Enabled {
+		elector, err = newElector(conf, store, identity, logger.Session("leader-election"))
+		if err != nil {
+			return fmt.Errorf("creating leader elector: %s", err)
+		}
+		leadership = elector
+		whileLeading = func(runner ifrit.Runner) ifrit.Runner { return leader.WhileLeading(elector, runner) }
 	}
 
-	databaseHandler := database.NewDatabaseHandler(&database.MigrateAdapter{}, connectionPool)
-	cidrPool := leaser.NewCIDRPool(conf.Network, conf.SubnetPrefixLength)
-	leaseController := &leaser.LeaseController{
-		DatabaseHandler:            databaseHandler,
-		HardwareAddressGenerator:   &leaser.HardwareAddressGenerator{},
-		LeaseValidator:             &leaser.LeaseValidator{},
-		AcquireSubnetLeaseAttempts: 10,
-		CIDRPool:                   cidrPool,
-		LeaseExpirationSeconds:     conf.LeaseExpirationSeconds,
-		Logger:                     logger,
+	registry := prometheus.NewRegistry()
+	dropsondeSender := &metrics.MetricsSender{
+		Logger: logger.Session("time-metric-emitter"),
+	}
+	metricsSender, err := prometheus_metrics.NewCountingSender(registry, dropsondeSender,
+		"acquireRetry", "leaseReclaimed", "leaseReaped", "reapFailure")
+	if err != nil {
+		return fmt.Errorf("creating prometheus counters: %s", err)
+	}
+	routeMetrics, err := prometheus_metrics.NewRouteMetrics(registry)
+	if err != nil {
+		return fmt.Errorf("creating prometheus route metrics: %s", err)
+	}
+
+	poolRouter := &leaser.PoolRouter{}
+	var defaultPool *leaser.LeaseController
+	var reaperPools []reaper.Pool
+	maxLeaseExpirationSeconds := 0
+	for _, pool := range conf.LeasePools() {
+		poolCIDRs := &leaser.CIDRPools{}
+		for _, cidr := range pool.Networks() {
+			if cidr.State == config.CIDRStateDraining {
+				poolCIDRs.AddDraining(leaser.NewCIDRPool(cidr.Network, pool.SubnetPrefixLength))
+				continue
+			}
+			allocator, err := leaser.NewAllocator(pool.AllocationStrategy)
+			if err != nil {
+				return fmt.Errorf("creating allocator for pool %q: %s", pool.Name, err)
+			}
+			poolCIDRs.AddActive(leaser.NewCIDRPoolWithAllocator(cidr.Network, pool.SubnetPrefixLength, allocator))
+		}
+		for _, excludedRange := range pool.ExcludedRanges {
+			if err := poolCIDRs.Exclude(excludedRange); err != nil {
+				return fmt.Errorf("creating pool %q: %s", pool.Name, err)
+			}
+		}
+		logger.Info("pool-capacity", lager.Data{
+			"pool":                pool.Name,
+			"blocks":              poolCIDRs.BlockPoolSize(),
+			"single_ips":          poolCIDRs.SingleIPPoolSize(),
+			"excluded_blocks":     poolCIDRs.ExcludedBlockCount(),
+			"excluded_single_ips": poolCIDRs.ExcludedSingleIPCount(),
+		})
+		if poolCIDRs.BlockPoolSize() == 0 && poolCIDRs.ExcludedBlockCount() > 0 {
+			return fmt.Errorf("pool %q has no subnets left outside its excluded ranges", pool.Name)
+		}
+		poolStore := leaser.NewTrackedStore(store.ForPool(pool.Name))
+		poolStore.Track(poolCIDRs)
+		leaseController := &leaser.LeaseController{
+			Pool:                       pool.Name,
+			DatabaseHandler:            poolStore,
+			HardwareAddressGenerator:   &leaser.HardwareAddressGenerator{},
+			LeaseValidator:             &leaser.LeaseValidator{},
+			AcquireSubnetLeaseAttempts: 10,
+			CIDRPool:                   poolCIDRs,
+			LeaseExpirationSeconds:     pool.LeaseExpirationSeconds,
+			QuarantineSeconds:          pool.QuarantineSeconds,
+			VNI:                        pool.VNI,
+			Logger:                     logger,
+			MetricSender:               metricsSender,
+		}
+		if len(pool.Topology.Partitions) > 0 {
+			topology := leaser.NewTopology(pool.Topology.Key, poolCIDRs)
+			for _, partition := range pool.Topology.Partitions {
+				allocator, err := leaser.NewAllocator(pool.AllocationStrategy)
+				if err != nil {
+					return fmt.Errorf("creating allocator for pool %q: %s", pool.Name, err)
+				}
+				if err := topology.AddPartition(partition.Value, partition.Network, allocator); err != nil {
+					return fmt.Errorf("creating pool %q: %s", pool.Name, err)
+				}
+			}
+			logger.Info("pool-topology", lager.Data{"pool": pool.Name, "key": pool.Topology.Key, "partitions": pool.Topology.Partitions})
+			leaseController.Topology = topology
+			poolStore.Track(topology)
+		}
+		if pool.NetworkV6 != "" {
+			allocatorV6, err := leaser.NewAllocator(pool.AllocationStrategy)
+			if err != nil {
+				return fmt.Errorf("creating allocator for pool %q: %s", pool.Name, err)
+			}
+			cidrPoolV6 := leaser.NewCIDRPoolWithAllocator(pool.NetworkV6, pool.SubnetPrefixLengthV6, allocatorV6)
+			for _, excludedRange := range pool.ExcludedRanges {
+				if err := cidrPoolV6.Exclude(excludedRange); err != nil {
+					return fmt.Errorf("creating pool %q: %s", pool.Name, err)
+				}
+			}
+			leaseController.CIDRPoolV6 = cidrPoolV6
+			poolStore.Track(cidrPoolV6)
+		}
+		if pool.Name == controller.DefaultPool {
+			defaultPool = leaseController
+		}
+		poolRouter.AddPool(pool.Name, leaseController)
+		reaperPools = append(reaperPools, reaper.Pool{
+			Name:                   pool.Name,
+			DatabaseHandler:        poolStore,
+			CIDRPool:               poolCIDRs,
+			LeaseExpirationSeconds: pool.LeaseExpirationSeconds,
+			QuarantineSeconds:      pool.QuarantineSeconds,
+		})
+		if pool.LeaseExpirationSeconds > maxLeaseExpirationSeconds {
+			maxLeaseExpirationSeconds = pool.LeaseExpirationSeconds
+		}
 	}
 	migrator := &database.Migrator{
-		DatabaseMigrator:              databaseHandler,
+		DatabaseMigrator:              store,
 		MaxMigrationAttempts:          5,
 		MigrationAttemptSleepDuration: time.Second,
 		Logger:                        logger,
@@ -103,46 +253,105 @@ func mainWithError() error {
 		return fmt.Errorf("migrating database: %s", err)
 	}
 
-	metricsSender := &metrics.MetricsSender{
-		Logger: logger.Session("time-metric-emitter"),
-	}
-
 	errorResponse := &httperror.ErrorResponse{
 		MetricsSender: metricsSender,
 	}
 
 	leasesIndex := &handlers.LeasesIndex{
 		Marshaler:       marshal.MarshalFunc(json.Marshal),
-		LeaseRepository: leaseController,
+		LeaseRepository: poolRouter,
 		ErrorResponse:   errorResponse,
 	}
 
+	underlayAuthorizer, err := handlers.NewUnderlayAuthorizer(conf.AdminIdentities, conf.HostUnderlayRanges())
+	if err != nil {
+		return fmt.Errorf("creating underlay authorizer: %s", err)
+	}
+
 	leasesAcquire := &handlers.LeasesAcquire{
 		Marshaler:     marshal.MarshalFunc(json.Marshal),
 		Unmarshaler:   marshal.UnmarshalFunc(json.Unmarshal),
-		LeaseAcquirer: leaseController,
+		LeaseAcquirer: poolRouter,
+		Authorizer:    underlayAuthorizer,
 		ErrorResponse: errorResponse,
 	}
 
 	leasesRelease := &handlers.ReleaseLease{
 		Marshaler:     marshal.MarshalFunc(json.Marshal),
 		Unmarshaler:   marshal.UnmarshalFunc(json.Unmarshal),
-		LeaseReleaser: leaseController,
+		LeaseReleaser: poolRouter,
+		Authorizer:    underlayAuthorizer,
 		ErrorResponse: errorResponse,
 	}
 
 	leasesRenew := &handlers.RenewLease{
+		Marshaler:     marshal.MarshalFunc(json.Marshal),
 		Unmarshaler:   marshal.UnmarshalFunc(json.Unmarshal),
-		LeaseRenewer:  leaseController,
+		LeaseRenewer:  poolRouter,
+		Authorizer:    underlayAuthorizer,
 		ErrorResponse: errorResponse,
 	}
 
+	leaseEvents := &handlers.LeaseEvents{
+		Marshaler:            marshal.MarshalFunc(json.Marshal),
+		LeaseEventRepository: store,
+		ErrorResponse:        errorResponse,
+	}
+
+	leaseWatchHistorySize := conf.LeaseWatchHistorySize
+	if leaseWatchHistorySize == 0 {
+		leaseWatchHistorySize = defaultLeaseWatchHistorySize
+	}
+	leaseWatcher := &watcher.Watcher{
+		Logger:          logger.Session("lease-watcher"),
+		LeaseRepository: poolRouter,
+		Interval:        leaseWatchInterval,
+		HistorySize:     leaseWatchHistorySize,
+	}
+
+	leasesWatch := &handlers.LeasesWatch{
+		Marshaler:     marshal.MarshalFunc(json.Marshal),
+		LeaseWatcher:  leaseWatcher,
+		ErrorResponse: errorResponse,
+		MaxTimeout:    maxLeaseWatchTimeout,
+	}
+
+	reservationsIndex := &handlers.ReservationsIndex{
+		Marshaler:             marshal.MarshalFunc(json.Marshal),
+		ReservationRepository: poolRouter,
+		ErrorResponse:         errorResponse,
+	}
+
+	reservationsAdd := &handlers.ReservationsAdd{
+		Unmarshaler:    marshal.UnmarshalFunc(json.Unmarshal),
+		SubnetReserver: poolRouter,
+		ErrorResponse:  errorResponse,
+	}
+
+	reservationsRemove := &handlers.ReservationsRemove{
+		Unmarshaler:        marshal.UnmarshalFunc(json.Unmarshal),
+		ReservationRemover: poolRouter,
+		ErrorResponse:      errorResponse,
+	}
+
+	leaseRecordsIndex := &handlers.LeaseRecordsIndex{
+		Marshaler:             marshal.MarshalFunc(json.Marshal),
+		LeaseRecordRepository: poolRouter,
+		ErrorResponse:         errorResponse,
+	}
+
+	poolsUsage := &handlers.PoolsUsage{
+		Marshaler:           marshal.MarshalFunc(json.Marshal),
+		PoolUsageRepository: poolRouter,
+		ErrorResponse:       errorResponse,
+	}
+
 	metricsWrap := func(name string, handle http.Handler) http.Handler {
 		metricsWrapper := middleware.MetricWrapper{
 			Name:          name,
-			MetricsSender: metricsSender,
+			MetricsSender: dropsondeSender,
 		}
-		return metricsWrapper.Wrap(handle)
+		return routeMetrics.Wrap(name, metricsWrapper.Wrap(handle))
 	}
 
 	type loggableHandler interface {
@@ -151,19 +360,70 @@ func mainWithError() error {
 	logWrap := func(handler loggableHandler) http.Handler {
 		return handlers.LogWrap(logger, handler.ServeHTTP)
 	}
+	adminWrap := func(handler loggableHandler) loggableHandler {
+		return &handlers.AdminOnly{
+			AdminIdentities: conf.AdminIdentities,
+			Handler:         handler,
+			ErrorResponse:   errorResponse,
+		}
+	}
+
+	routes := rata.Routes{
+		{Name: "leases-index", Method: "GET", Path: "/leases"},
+		{Name: "leases-acquire", Method: "PUT", Path: "/leases/acquire"},
+		{Name: "leases-release", Method: "PUT", Path: "/leases/release"},
+		{Name: "leases-renew", Method: "PUT", Path: "/leases/renew"},
+		{Name: "leases-events", Method: "GET", Path: "/leases/events"},
+		{Name: "leases-watch", Method: "GET", Path: "/leases/watch"},
+		{Name: "reservations-index", Method: "GET", Path: "/reservations"},
+		{Name: "pool-usage", Method: "GET", Path: "/pool"},
+		{Name: "admin-leases-index", Method: "GET", Path: "/admin/leases"},
+		{Name: "admin-leases-release", Method: "PUT", Path: "/admin/leases/release"},
+		{Name: "admin-leases-events", Method: "GET", Path: "/admin/leases/events"},
+		{Name: "admin-reservations-index", Method: "GET", Path: "/admin/reservations"},
+		{Name: "admin-reservations-add", Method: "PUT", Path: "/admin/reservations/add"},
+		{Name: "admin-reservations-remove", Method: "PUT", Path: "/admin/reservations/remove"},
+		{Name: "admin-pools", Method: "GET", Path: "/admin/pools"},
+	}
+	for route := range conf.RateLimits {
+		if _, ok := routes.FindRouteByName(route); !ok {
+			return fmt.Errorf("rate limit for unknown route %q", route)
+		}
+	}
+
+	limitWrap := func(route string, handler loggableHandler) loggableHandler {
+		rateLimit := &handlers.RateLimit{
+			Marshaler: marshal.MarshalFunc(json.Marshal),
+			Handler:   handler,
+		}
+		if limit, ok := conf.RateLimits[route]; ok {
+			rateLimit.Limiter = ratelimit.NewBuckets(limit.RequestsPerSecond, limit.Burst, ratelimit.ClockFunc(time.Now))
+		}
+		if route == "leases-acquire" && conf.MaxInFlightAcquires > 0 {
+			rateLimit.InFlight = ratelimit.NewInFlight(conf.MaxInFlightAcquires)
+		}
+		return rateLimit
+	}
 
 	router, err := rata.NewRouter(
-		rata.Routes{
-			{Name: "leases-index", Method: "GET", Path: "/leases"},
-			{Name: "leases-acquire", Method: "PUT", Path: "/leases/acquire"},
-			{Name: "leases-release", Method: "PUT", Path: "/leases/release"},
-			{Name: "leases-renew", Method: "PUT", Path: "/leases/renew"},
-		},
+		routes,
 		rata.Handlers{
-			"leases-index":   metricsWrap("LeasesIndex", logWrap(leasesIndex)),
-			"leases-acquire": metricsWrap("LeasesAcquire", logWrap(leasesAcquire)),
-			"leases-release": metricsWrap("LeasesRelease", logWrap(leasesRelease)),
-			"leases-renew":   metricsWrap("LeasesRenew", logWrap(leasesRenew)),
+			"leases-index":       metricsWrap("LeasesIndex", logWrap(limitWrap("leases-index", leasesIndex))),
+			"leases-acquire":     metricsWrap("LeasesAcquire", logWrap(limitWrap("leases-acquire", leasesAcquire))),
+			"leases-release":     metricsWrap("LeasesRelease", logWrap(limitWrap("leases-release", leasesRelease))),
+			"leases-renew":       metricsWrap("LeasesRenew", logWrap(limitWrap("leases-renew", leasesRenew))),
+			"leases-events":      metricsWrap("LeaseEvents", logWrap(limitWrap("leases-events", leaseEvents))),
+			"leases-watch":       metricsWrap("LeasesWatch", logWrap(limitWrap("leases-watch", leasesWatch))),
+			"reservations-index": metricsWrap("ReservationsIndex", logWrap(limitWrap("reservations-index", reservationsIndex))),
+			"pool-usage":         metricsWrap("PoolUsage", logWrap(limitWrap("pool-usage", poolsUsage))),
+
+			"admin-leases-index":        metricsWrap("AdminLeasesIndex", logWrap(limitWrap("admin-leases-index", adminWrap(leaseRecordsIndex)))),
+			"admin-leases-release":      metricsWrap("AdminLeasesRelease", logWrap(limitWrap("admin-leases-release", adminWrap(leasesRelease)))),
+			"admin-leases-events":       metricsWrap("AdminLeaseEvents", logWrap(limitWrap("admin-leases-events", adminWrap(leaseEvents)))),
+			"admin-reservations-index":  metricsWrap("AdminReservationsIndex", logWrap(limitWrap("admin-reservations-index", adminWrap(reservationsIndex)))),
+			"admin-reservations-add":    metricsWrap("AdminReservationsAdd", logWrap(limitWrap("admin-reservations-add", adminWrap(reservationsAdd)))),
+			"admin-reservations-remove": metricsWrap("AdminReservationsRemove", logWrap(limitWrap("admin-reservations-remove", adminWrap(reservationsRemove)))),
+			"admin-pools":               metricsWrap("AdminPools", logWrap(limitWrap("admin-pools", adminWrap(poolsUsage)))),
 		},
 	)
 	if err != nil {
@@ -171,7 +431,9 @@ func mainWithError() error {
 	}
 
 	health := &handlers.Health{
-		DatabaseChecker: databaseHandler,
+		DatabaseChecker: store,
+		Leadership:      leadership,
+		Marshaler:       marshal.MarshalFunc(json.Marshal),
 		ErrorResponse:   errorResponse,
 	}
 
@@ -197,20 +459,60 @@ func mainWithError() error {
 	httpServer := http_server.NewTLSServer(mainServerAddress, router, tlsConfig)
 	healthServer := http_server.New(healthServerAddress, healthRouter)
 
-	// Metrics sources
+	// Metrics sources. Every controller emits its own, while the leases are
+	// shared and only emitted by the leader.
 	metricSources := []metrics.MetricSource{
 		metrics.NewUptimeSource(),
-		server_metrics.NewTotalLeasesSource(databaseHandler),
-		server_metrics.NewFreeLeasesSource(databaseHandler, cidrPool),
-		server_metrics.NewStaleLeasesSource(databaseHandler, conf.StalenessThresholdSeconds),
+		server_metrics.NewIsLeaderSource(leadership),
+		server_metrics.NewLeaseCacheHitRatioSource(leaseCache),
+		server_metrics.NewLeaseCacheQueriesSavedSource(leaseCache),
+	}
+	leaseMetricSources := []metrics.MetricSource{
+		server_metrics.NewTotalLeasesSource(store),
+		server_metrics.NewFreeLeasesSource(defaultPool),
+		server_metrics.NewFreeSingleIPLeasesSource(defaultPool),
+		server_metrics.NewStaleLeasesSource(store, conf.StalenessThresholdSeconds),
+	}
+	// the db monitor sources reset their maximums on every read, so
+	// prometheus gets collectors of its own instead
+	registry.MustRegister(prometheus_metrics.NewSourceCollector(logger.Session("prometheus"), append(metricSources, leaseMetricSources...)...))
+	if connectionPool != nil {
+		metricSources = append(metricSources, metrics.NewDBMonitorSource(connectionPool, connectionPool.Monitor)...)
+		registry.MustRegister(prometheus_metrics.NewDBCollectors(connectionPool.DB.DB, connectionPool.Monitor)...)
 	}
-	metricSources = append(metricSources, metrics.NewDBMonitorSource(connectionPool, connectionPool.Monitor)...)
 	metricsEmitter := metrics.NewMetricsEmitter(logger, time.Duration(conf.MetricsEmitSeconds)*time.Second, metricSources...)
-	members := grouper.Members{
+	leaseMetricsEmitter := metrics.NewMetricsEmitter(logger, time.Duration(conf.MetricsEmitSeconds)*time.Second, leaseMetricSources...)
+	registry.MustRegister(prometheus_metrics.NewPoolUsageCollector(logger.Session("prometheus"), poolRouter))
+	registry.MustRegister(prometheus_metrics.NewLeaderCollector(leadership))
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	var members grouper.Members
+	if elector != nil {
+		members = append(members, grouper.Member{Name: "leader-elector", Runner: elector})
+	}
+	members = append(members, grouper.Members{
+		{Name: "lease-watcher", Runner: leaseWatcher},
 		{Name: "http_server", Runner: httpServer},
 		{Name: "health-server", Runner: healthServer},
 		{Name: "debug-server", Runner: debugserver.Runner(debugServerAddress, reconfigurableSink)},
 		{Name: "metrics-emitter", Runner: metricsEmitter},
+		{Name: "lease-metrics-emitter", Runner: whileLeading(leaseMetricsEmitter)},
+	}...)
+	if conf.PrometheusPort > 0 {
+		prometheusMux := http.NewServeMux()
+		prometheusMux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		prometheusServerAddress := fmt.Sprintf("%s:%d", conf.ListenHost, conf.PrometheusPort)
+		members = append(members, grouper.Member{Name: "prometheus-server", Runner: http_server.New(prometheusServerAddress, prometheusMux)})
+	}
+	if reaperPolicy.Name != reaper.PolicyNever {
+		leaseReaper := &reaper.Reaper{
+			Logger:       logger.Session("lease-reaper"),
+			Pools:        reaperPools,
+			Policy:       reaperPolicy,
+			Interval:     time.Duration(conf.Reaper.IntervalSeconds) * time.Second,
+			StartupDelay: time.Duration(reaperPolicy.ExpiryMultiple*maxLeaseExpirationSeconds) * time.Second,
+			MetricSender: metricsSender,
+		}
+		members = append(members, grouper.Member{Name: "lease-reaper", Runner: whileLeading(leaseReaper)})
 	}
 
 	group := grouper.NewOrdered(os.Interrupt, members)
@@ -226,6 +528,43 @@ func mainWithError() error {
 	return nil
 }
 
+// newElector elects the leader among the controllers sharing the store. The
+// leadership ttl defaults to three heartbeats.
+func newElector(conf *config.Config, store database.Store, identity string, logger lager.Logger) (*leader.Elector, error) {
+	heartbeatSeconds := conf.LeaderElection.HeartbeatSeconds
+	if heartbeatSeconds == 0 {
+		heartbeatSeconds = defaultLeaderHeartbeatSeconds
+	}
+	ttlSeconds := conf.LeaderElection.TTLSeconds
+	if ttlSeconds == 0 {
+		ttlSeconds = 3 * heartbeatSeconds
+	}
+	if ttlSeconds <= heartbeatSeconds {
+		return nil, errors.New("ttl_seconds must be greater than heartbeat_seconds")
+	}
+
+	return &leader.Elector{
+		Logger:            logger,
+		Store:             store,
+		Identity:          identity,
+		HeartbeatInterval: time.Duration(heartbeatSeconds) * time.Second,
+		TTLSeconds:        ttlSeconds,
+	}, nil
+}
+
+// leaderIdentity names the controller in the election, by default after its
+// host name and listen port.
+func leaderIdentity(conf *config.Config) (string, error) {
+	if conf.LeaderElection.Identity != "" {
+		return conf.LeaderElection.Identity, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("getting hostname: %s", err)
+	}
+	return fmt.Sprintf("%s:%d", hostname, conf.ListenPort), nil
+}
+
 func getLagerConfig() lagerflags.LagerConfig {
 	lagerConfig := lagerflags.DefaultLagerConfig()
 	lagerConfig.TimeFormat = lagerflags.FormatRFC3339
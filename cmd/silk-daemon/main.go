@@ -40,6 +40,11 @@ var logPrefix = "cfnetworking"
 
 const (
 	jobPrefix = "silk-daemon"
+
+	// convergeEveryCycles is how often the vxlan planner converges while the
+	// leases have not changed, to repair routes and neighbors removed from
+	// the vtep behind its back
+	convergeEveryCycles = 3
 )
 
 func main() {
@@ -98,13 +103,15 @@ func mainWithError() error {
 	}
 
 	client := controller.NewClient(logger, httpClient, cfg.ConnectivityServerURL)
+	client.Pool = cfg.OverlayPool
+	client.Host = cfg.Host
 
 	store := &datastore.Store{
 		Serializer: &serial.Serial{},
 		LockerNew:  filelock.NewLocker,
 	}
 
-	_, overlayNetwork, err := net.ParseCIDR(cfg.OverlayNetwork)
+	overlayNetworks, err := cfg.OverlayNetworks()
 	if err != nil {
 		return fmt.Errorf("parse overlay network CIDR: %s", err) //TODO add test coverage
 	}
@@ -121,10 +128,11 @@ func mainWithError() error {
 			return fmt.Errorf("parse local subnet CIDR: %s", err) //TODO add test coverage
 		}
 
-		if !overlayNetwork.Contains(localSubnet.IP) {
+		if !networksContain(overlayNetworks, localSubnet.IP) {
 			logger.Error("network-contains-lease", fmt.Errorf("discovered lease is not in overlay network"), lager.Data{
-				"lease":   lease,
-				"network": cfg.OverlayNetwork,
+				"lease":               lease,
+				"network":             cfg.OverlayNetwork,
+				"additional_networks": cfg.AdditionalOverlayNetworks,
 			})
 
 			metadata, err := store.ReadAll(cfg.Datastore)
@@ -142,7 +150,7 @@ func mainWithError() error {
 			}
 		}
 
-		err = client.RenewSubnetLease(lease)
+		renewed, err := client.RenewSubnetLease(lease)
 		if err != nil {
 			logger.Error("renew-lease", err, lager.Data{"lease": lease})
 
@@ -159,6 +167,8 @@ func mainWithError() error {
 					return err
 				}
 			}
+		} else {
+			lease = renewed
 		}
 		logger.Info("renewed-lease", lager.Data{"lease": lease})
 	}
@@ -179,30 +189,48 @@ func mainWithError() error {
 		return fmt.Errorf("parse local subnet CIDR: %s", err) //TODO add test coverage
 	}
 
+	localVNI, err := vtepFactory.GetVTEPVNI(cfg.VTEPName)
+	if err != nil {
+		return fmt.Errorf("get vtep vni: %s", err) // not tested
+	}
+
 	vxlanIface, err := net.InterfaceByName(cfg.VTEPName)
 	if err != nil || vxlanIface == nil {
 		return fmt.Errorf("find local VTEP: %s", err) //TODO add test coverage
 	}
 
+	vxlanPlanner := &planner.VXLANPlanner{
+		Logger:           logger,
+		ControllerClient: client,
+		Lease:            lease,
+		Converger: &vtep.Converger{
+			OverlayNetwork:            overlayNetworks[0],
+			AdditionalOverlayNetworks: overlayNetworks[1:],
+			LocalSubnet:               localSubnet,
+			LocalVTEP:                 *vxlanIface,
+			NetlinkAdapter:            &adapter.NetlinkAdapter{},
+			Logger:                    logger,
+			VNI:                       localVNI,
+			DefaultVNI:                cfg.VNI,
+		},
+		VTEPRebuilder: &vtep.Rebuilder{
+			ClientConfig:  cfg,
+			ConfigCreator: vtepConfigCreator,
+			Factory:       vtepFactory,
+			NetAdapter:    &adapter.NetAdapter{},
+		},
+		VNI:        localVNI,
+		DefaultVNI: cfg.VNI,
+		ErrorDetector: planner.NewGracefulDetector(
+			time.Duration(cfg.PartitionToleranceSeconds) * time.Second,
+		),
+		MetricSender:  metricSender,
+		ConvergeEvery: convergeEveryCycles,
+	}
 	vxlanPoller := &poller.Poller{
-		Logger:       logger,
-		PollInterval: time.Duration(cfg.PollInterval) * time.Second,
-		SingleCycleFunc: (&planner.VXLANPlanner{
-			Logger:           logger,
-			ControllerClient: client,
-			Lease:            lease,
-			Converger: &vtep.Converger{
-				OverlayNetwork: overlayNetwork,
-				LocalSubnet:    localSubnet,
-				LocalVTEP:      *vxlanIface,
-				NetlinkAdapter: &adapter.NetlinkAdapter{},
-				Logger:         logger,
-			},
-			ErrorDetector: planner.NewGracefulDetector(
-				time.Duration(cfg.PartitionToleranceSeconds) * time.Second,
-			),
-			MetricSender: metricSender,
-		}).DoCycle,
+		Logger:          logger,
+		PollInterval:    time.Duration(cfg.PollInterval) * time.Second,
+		SingleCycleFunc: vxlanPlanner.DoCycle,
 	}
 
 	uptimeSource := metrics.NewUptimeSource()
@@ -213,6 +241,23 @@ func mainWithError() error {
 		{Name: "debug-server", Runner: debugserver.Runner(debugServerAddress, reconfigurableSink)},
 		{Name: "metrics-emitter", Runner: metricsEmitter},
 	}
+	if cfg.WatchTimeoutSeconds > 0 {
+		// a watch is held open for up to the watch timeout, so its requests
+		// get that much longer than the others before they time out
+		watchClient := controller.NewClient(logger, &http.Client{
+			Transport: httpClient.Transport,
+			Timeout:   time.Duration(cfg.WatchTimeoutSeconds+cfg.ClientTimeoutSeconds) * time.Second,
+		}, cfg.ConnectivityServerURL)
+		watchClient.Pool = cfg.OverlayPool
+
+		vxlanPlanner.LeaseWatcher = watchClient
+		vxlanPlanner.WatchTimeout = time.Duration(cfg.WatchTimeoutSeconds) * time.Second
+		members = append(members, grouper.Member{Name: "lease-watcher", Runner: &poller.Poller{
+			Logger:          logger,
+			PollInterval:    time.Second,
+			SingleCycleFunc: vxlanPlanner.WatchCycle,
+		}})
+	}
 	group := grouper.NewOrdered(os.Interrupt, members)
 	monitor := ifrit.Invoke(sigmon.New(group))
 
@@ -221,19 +266,25 @@ func mainWithError() error {
 }
 
 func acquireLease(logger lager.Logger, client *controller.Client, vtepConfigCreator *vtep.ConfigCreator, vtepFactory *vtep.Factory, cfg config.Config) (controller.Lease, error) {
-	var lease controller.Lease
+	acquire := client.AcquireSubnetLease
 	if cfg.SingleIPOnly {
-		var err error
-		lease, err = client.AcquireSingleOverlayIPLease(cfg.UnderlayIP)
-		if err != nil {
-			return controller.Lease{}, fmt.Errorf("acquire subnet lease: %s", err)
-		}
-	} else {
-		var err error
-		lease, err = client.AcquireSubnetLease(cfg.UnderlayIP)
-		if err != nil {
-			return controller.Lease{}, fmt.Errorf("acquire subnet lease: %s", err)
+		acquire = client.AcquireSingleOverlayIPLease
+	}
+	// a controller over its rate limit is waited out for up to the partition
+	// tolerance, rather than restarting into it again
+	deadline := time.Now().Add(time.Duration(cfg.PartitionToleranceSeconds) * time.Second)
+	lease, err := acquire(cfg.UnderlayIP)
+	for {
+		rateLimited, ok := err.(*controller.RateLimitedError)
+		if !ok || time.Now().Add(rateLimited.RetryAfter).After(deadline) {
+			break
 		}
+		logger.Info("acquire-rate-limited", lager.Data{"error": rateLimited.Message, "retry_after": rateLimited.RetryAfter.String()})
+		time.Sleep(rateLimited.RetryAfter)
+		lease, err = acquire(cfg.UnderlayIP)
+	}
+	if err != nil {
+		return controller.Lease{}, fmt.Errorf("acquire subnet lease: %s", err)
 	}
 	logger.Info("acquired-lease", lager.Data{"lease": lease})
 
@@ -277,6 +328,8 @@ func discoverLocalLease(clientConfig config.Config, vtepFactory *vtep.Factory) (
 	return leaseFromVTEPState(clientConfig, overlayHwAddr, overlayIP), nil
 }
 
+// leaseFromVTEPState leaves out the vni of the vtep: the vni is the pool's,
+// and the controller hands it back with the renewed lease.
 func leaseFromVTEPState(clientConfig config.Config, overlayHwAddr net.HardwareAddr, overlayIP net.IP) controller.Lease {
 	overlaySubnet := &net.IPNet{
 		IP:   overlayIP,
@@ -309,6 +362,15 @@ func deleteAndAcquire(cfg config.Config, logger lager.Logger, client *controller
 	return acquireLease(logger, client, vtepConfigCreator, vtepFactory, cfg)
 }
 
+func networksContain(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func getLagerConfig(level string) lagerflags.LagerConfig {
 	lagerConfig := lagerflags.DefaultLagerConfig()
 	lagerConfig.TimeFormat = lagerflags.FormatRFC3339
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"net/url"
+
+	"code.cloudfoundry.org/cf-networking-helpers/json_client"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+// AdminClient calls the /admin routes of the controller. Its client
+// certificate must be one of the admin identities of the controller.
+type AdminClient struct {
+	JsonClient json_client.JsonClient
+}
+
+func NewAdminClient(logger lager.Logger, httpClient json_client.HttpClient, baseURL string) *AdminClient {
+	return &AdminClient{
+		JsonClient: json_client.New(logger, httpClient, baseURL),
+	}
+}
+
+// LeaseRecords returns the leases of every pool, expired ones included. With
+// an underlay ip only the lease of that ip is returned.
+func (c *AdminClient) LeaseRecords(underlayIP string) ([]LeaseRecord, error) {
+	route := "/admin/leases"
+	if underlayIP != "" {
+		route += "?" + url.Values{"underlay_ip": {underlayIP}}.Encode()
+	}
+
+	var response struct {
+		Leases []LeaseRecord
+	}
+	err := c.JsonClient.Do("GET", route, nil, &response, "")
+	if err != nil {
+		return nil, err
+	}
+	return response.Leases, nil
+}
+
+func (c *AdminClient) ReleaseLease(underlayIP string) error {
+	request := ReleaseLeaseRequest{
+		UnderlayIP: underlayIP,
+	}
+	return c.JsonClient.Do("PUT", "/admin/leases/release", request, nil, "")
+}
+
+func (c *AdminClient) Reservations() ([]Reservation, error) {
+	var response struct {
+		Reservations []Reservation
+	}
+	err := c.JsonClient.Do("GET", "/admin/reservations", nil, &response, "")
+	if err != nil {
+		return nil, err
+	}
+	return response.Reservations, nil
+}
+
+func (c *AdminClient) AddReservation(reservation Reservation) error {
+	return c.JsonClient.Do("PUT", "/admin/reservations/add", reservation, nil, "")
+}
+
+func (c *AdminClient) RemoveReservation(underlayIP string) error {
+	request := RemoveReservationRequest{
+		UnderlayIP: underlayIP,
+	}
+	return c.JsonClient.Do("PUT", "/admin/reservations/remove", request, nil, "")
+}
+
+func (c *AdminClient) PoolUsage() ([]PoolUsage, error) {
+	var response struct {
+		Pools []PoolUsage
+	}
+	err := c.JsonClient.Do("GET", "/admin/pools", nil, &response, "")
+	if err != nil {
+		return nil, err
+	}
+	return response.Pools, nil
+}
+
+func (c *AdminClient) LeaseEvents(filter LeaseEventFilter) ([]LeaseEvent, error) {
+	return getLeaseEvents(c.JsonClient, "/admin/leases/events", filter)
+}
@@ -0,0 +1,190 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/silk/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AdminClient", func() {
+	var (
+		client     *controller.AdminClient
+		jsonClient *fakes.JSONClient
+	)
+
+	BeforeEach(func() {
+		jsonClient = &fakes.JSONClient{}
+		client = &controller.AdminClient{
+			JsonClient: jsonClient,
+		}
+	})
+
+	respondWith := func(body string) {
+		jsonClient.DoStub = func(method, route string, reqData, respData interface{}, token string) error {
+			return json.Unmarshal([]byte(body), respData)
+		}
+	}
+
+	Describe("LeaseRecords", func() {
+		BeforeEach(func() {
+			respondWith(`{ "leases": [
+				{ "underlay_ip": "10.0.3.1", "overlay_subnet": "10.255.90.0/24", "last_renewed_at": 1700000000, "expired": false },
+				{ "underlay_ip": "10.0.5.9", "overlay_subnet": "10.255.30.0/24", "pool": "blue", "last_renewed_at": 1690000000, "expired": true }
+			] }`)
+		})
+
+		It("returns every lease", func() {
+			records, err := client.LeaseRecords("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(Equal([]controller.LeaseRecord{
+				{Lease: controller.Lease{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24"}, LastRenewedAt: 1700000000},
+				{Lease: controller.Lease{UnderlayIP: "10.0.5.9", OverlaySubnet: "10.255.30.0/24", Pool: "blue"}, LastRenewedAt: 1690000000, Expired: true},
+			}))
+
+			method, route, reqData, _, token := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/admin/leases"))
+			Expect(reqData).To(BeNil())
+			Expect(token).To(BeEmpty())
+		})
+
+		It("filters by underlay ip", func() {
+			_, err := client.LeaseRecords("10.0.3.1")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, route, _, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(route).To(Equal("/admin/leases?underlay_ip=10.0.3.1"))
+		})
+
+		Context("when the json client fails", func() {
+			BeforeEach(func() {
+				jsonClient.DoStub = nil
+				jsonClient.DoReturns(errors.New("carrot"))
+			})
+
+			It("returns the error", func() {
+				_, err := client.LeaseRecords("")
+				Expect(err).To(MatchError("carrot"))
+			})
+		})
+	})
+
+	Describe("ReleaseLease", func() {
+		It("releases the lease of the underlay ip", func() {
+			Expect(client.ReleaseLease("10.0.3.1")).To(Succeed())
+
+			method, route, reqData, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("PUT"))
+			Expect(route).To(Equal("/admin/leases/release"))
+			Expect(reqData).To(Equal(controller.ReleaseLeaseRequest{UnderlayIP: "10.0.3.1"}))
+		})
+
+		Context("when the json client fails", func() {
+			BeforeEach(func() {
+				jsonClient.DoReturns(errors.New("carrot"))
+			})
+
+			It("returns the error", func() {
+				Expect(client.ReleaseLease("10.0.3.1")).To(MatchError("carrot"))
+			})
+		})
+	})
+
+	Describe("Reservations", func() {
+		BeforeEach(func() {
+			respondWith(`{ "reservations": [ { "underlay_ip": "10.0.3.1", "overlay_subnet": "10.255.90.0/24", "pool": "blue" } ] }`)
+		})
+
+		It("returns the reservations", func() {
+			reservations, err := client.Reservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(Equal([]controller.Reservation{
+				{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24", Pool: "blue"},
+			}))
+
+			method, route, _, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/admin/reservations"))
+		})
+	})
+
+	Describe("AddReservation", func() {
+		It("adds the reservation", func() {
+			reservation := controller.Reservation{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24"}
+			Expect(client.AddReservation(reservation)).To(Succeed())
+
+			method, route, reqData, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("PUT"))
+			Expect(route).To(Equal("/admin/reservations/add"))
+			Expect(reqData).To(Equal(reservation))
+		})
+	})
+
+	Describe("RemoveReservation", func() {
+		It("removes the reservation of the underlay ip", func() {
+			Expect(client.RemoveReservation("10.0.3.1")).To(Succeed())
+
+			method, route, reqData, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("PUT"))
+			Expect(route).To(Equal("/admin/reservations/remove"))
+			Expect(reqData).To(Equal(controller.RemoveReservationRequest{UnderlayIP: "10.0.3.1"}))
+		})
+	})
+
+	Describe("PoolUsage", func() {
+		BeforeEach(func() {
+			respondWith(`{ "pools": [ {
+				"pool": "default",
+				"blocks": { "total": 255, "active": 2, "expired": 0, "quarantined": 0, "reserved": 1, "free": 252 },
+				"single_ips": { "total": 255, "active": 0, "expired": 0, "quarantined": 0, "reserved": 0, "free": 255 }
+			} ] }`)
+		})
+
+		It("returns the usage of every pool", func() {
+			usage, err := client.PoolUsage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage).To(Equal([]controller.PoolUsage{{
+				Pool:      "default",
+				Blocks:    controller.SubnetUsage{Total: 255, Active: 2, Reserved: 1, Free: 252},
+				SingleIPs: controller.SubnetUsage{Total: 255, Free: 255},
+			}}))
+
+			method, route, _, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/admin/pools"))
+		})
+
+		Context("when the json client fails", func() {
+			BeforeEach(func() {
+				jsonClient.DoStub = nil
+				jsonClient.DoReturns(errors.New("carrot"))
+			})
+
+			It("returns the error", func() {
+				_, err := client.PoolUsage()
+				Expect(err).To(MatchError("carrot"))
+			})
+		})
+	})
+
+	Describe("LeaseEvents", func() {
+		BeforeEach(func() {
+			respondWith(`{ "events": [ { "type": "acquired", "underlay_ip": "10.0.3.1", "timestamp": 1700000000 } ] }`)
+		})
+
+		It("returns the filtered events", func() {
+			events, err := client.LeaseEvents(controller.LeaseEventFilter{UnderlayIP: "10.0.3.1", Since: 1700000000})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(Equal([]controller.LeaseEvent{
+				{Type: "acquired", UnderlayIP: "10.0.3.1", Timestamp: 1700000000},
+			}))
+
+			_, route, _, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(route).To(Equal("/admin/leases/events?since=1700000000&underlay_ip=10.0.3.1"))
+		})
+	})
+})
@@ -3,6 +3,10 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"code.cloudfoundry.org/cf-networking-helpers/json_client"
 	"code.cloudfoundry.org/lager/v3"
@@ -16,12 +20,143 @@ func (n NonRetriableError) Error() string {
 
 type Client struct {
 	JsonClient json_client.JsonClient
+	// RetryAfter, when set, is the http client under JsonClient, from which
+	// a 429 learns how long to wait.
+	RetryAfter *RetryAfter
+	Pool       string
+	// Host is sent with every acquisition and renewal, when set.
+	Host *HostMetadata
 }
 
+const (
+	IPFamilyV4   = "ipv4"
+	IPFamilyV6   = "ipv6"
+	IPFamilyDual = "dual"
+)
+
+// DefaultPool names the pool served from the controller's top level network.
+const DefaultPool = ""
+
 type Lease struct {
 	UnderlayIP          string `json:"underlay_ip"`
 	OverlaySubnet       string `json:"overlay_subnet"`
+	OverlaySubnetV6     string `json:"overlay_subnet_v6,omitempty"`
 	OverlayHardwareAddr string `json:"overlay_hardware_addr"`
+	Pool                string `json:"pool,omitempty"`
+	// VNI is the vxlan segment of the pool of the lease. Zero leaves it to
+	// the configuration of the daemon.
+	VNI int `json:"vni,omitempty"`
+}
+
+type Reservation struct {
+	UnderlayIP    string `json:"underlay_ip"`
+	OverlaySubnet string `json:"overlay_subnet"`
+	Pool          string `json:"pool,omitempty"`
+}
+
+// LeaseRecord is a lease as the controller stores it, with the time its host
+// last renewed it, in seconds since the epoch, and whether it has expired.
+type LeaseRecord struct {
+	Lease
+	LastRenewedAt int64         `json:"last_renewed_at"`
+	Expired       bool          `json:"expired"`
+	Host          *HostMetadata `json:"host,omitempty"`
+}
+
+// HostMetadata describes the host that holds a lease, so that operators can
+// tell which VM a subnet belongs to. Labels are free-form.
+type HostMetadata struct {
+	Hostname   string            `json:"hostname,omitempty"`
+	AZ         string            `json:"az,omitempty"`
+	Deployment string            `json:"deployment,omitempty"`
+	InstanceID string            `json:"instance_id,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// HostLease is a routable lease with the metadata its host last sent, if any.
+type HostLease struct {
+	Lease
+	Host *HostMetadata `json:"host,omitempty"`
+}
+
+// RenewLeaseRequest is a lease to renew, with the metadata of its host.
+type RenewLeaseRequest struct {
+	Lease
+	Host *HostMetadata `json:"host,omitempty"`
+}
+
+// PoolUsage counts the subnets of the active networks of a pool, the blocks
+// and the single overlay ips separately.
+type PoolUsage struct {
+	Pool      string      `json:"pool"`
+	Blocks    SubnetUsage `json:"blocks"`
+	SingleIPs SubnetUsage `json:"single_ips"`
+}
+
+// SubnetUsage splits the Total subnets by what holds them. Acquisitions take
+// Free subnets first, then the ones of Expired leases, then Quarantined ones,
+// so a pool is only full once all three are zero.
+type SubnetUsage struct {
+	Total       int `json:"total"`
+	Active      int `json:"active"`
+	Expired     int `json:"expired"`
+	Quarantined int `json:"quarantined"`
+	Reserved    int `json:"reserved"`
+	Free        int `json:"free"`
+}
+
+// QuarantinedSubnet is a released or reclaimed subnet that is not handed out
+// again before QuarantinedUntil, in seconds since the epoch, unless the pool
+// has nothing else left.
+type QuarantinedSubnet struct {
+	OverlaySubnet    string `json:"overlay_subnet"`
+	Pool             string `json:"pool,omitempty"`
+	QuarantinedUntil int64  `json:"quarantined_until"`
+}
+
+// Lease event types. A lease is reclaimed when it is deleted for having
+// expired, by an acquisition that needs its subnet or by the lease reaper.
+const (
+	LeaseEventAcquired  = "acquired"
+	LeaseEventRenewed   = "renewed"
+	LeaseEventReleased  = "released"
+	LeaseEventReclaimed = "reclaimed"
+)
+
+// LeaseEvent records a change to the lease of an underlay ip. Timestamp is
+// in seconds since the epoch.
+type LeaseEvent struct {
+	Type            string `json:"type"`
+	UnderlayIP      string `json:"underlay_ip"`
+	OverlaySubnet   string `json:"overlay_subnet,omitempty"`
+	OverlaySubnetV6 string `json:"overlay_subnet_v6,omitempty"`
+	Pool            string `json:"pool,omitempty"`
+	Actor           string `json:"actor"`
+	Reason          string `json:"reason,omitempty"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// LeaseEventFilter selects lease events. Empty fields match every event;
+// Since and Until bound the timestamp, inclusively.
+type LeaseEventFilter struct {
+	UnderlayIP    string
+	OverlaySubnet string
+	Since         int64
+	Until         int64
+}
+
+// LeaseChanges are the routable leases added and removed after a revision of
+// the lease table, up to Revision. When Reset is set, Added holds every
+// routable lease and any leases known from before must be forgotten.
+type LeaseChanges struct {
+	Revision int64   `json:"revision"`
+	Reset    bool    `json:"reset,omitempty"`
+	Added    []Lease `json:"added"`
+	Removed  []Lease `json:"removed"`
+}
+
+type RemoveReservationRequest struct {
+	UnderlayIP string `json:"underlay_ip"`
 }
 
 type ReleaseLeaseRequest struct {
@@ -29,13 +164,22 @@ type ReleaseLeaseRequest struct {
 }
 
 type AcquireLeaseRequest struct {
-	UnderlayIP      string `json:"underlay_ip"`
-	SingleOverlayIP bool   `json:"single_overlay_ip"`
+	UnderlayIP      string        `json:"underlay_ip"`
+	SingleOverlayIP bool          `json:"single_overlay_ip"`
+	IPFamily        string        `json:"ip_family,omitempty"`
+	Pool            string        `json:"pool,omitempty"`
+	Host            *HostMetadata `json:"host,omitempty"`
 }
 
+// NewClient returns a client whose GETs are revalidated with the ETag of the
+// previous response, so that unchanged lease lists are not sent again.
+// A 429 is returned as a RateLimitedError, and calls to the route are not
+// sent until its Retry-After has passed.
 func NewClient(logger lager.Logger, httpClient json_client.HttpClient, baseURL string) *Client {
+	retryAfter := &RetryAfter{HttpClient: &ETagCache{HttpClient: httpClient}}
 	return &Client{
-		JsonClient: json_client.New(logger, httpClient, baseURL),
+		JsonClient: json_client.New(logger, retryAfter, baseURL),
+		RetryAfter: retryAfter,
 	}
 }
 
@@ -43,52 +187,192 @@ func (c *Client) GetActiveLeases() ([]Lease, error) {
 	var response struct {
 		Leases []Lease
 	}
-	err := c.JsonClient.Do("GET", "/leases", nil, &response, "")
+	route := "/leases"
+	if c.Pool != DefaultPool {
+		route = fmt.Sprintf("/leases?pool=%s", url.QueryEscape(c.Pool))
+	}
+	err := c.do("GET", route, nil, &response)
 	if err != nil {
 		return nil, err
 	}
 	return response.Leases, nil
 }
 
+// GetHostLeases gets the routable leases with the metadata of their hosts,
+// of the hosts that match the label selector. See ParseLabelSelector.
+func (c *Client) GetHostLeases(selector string) ([]HostLease, error) {
+	query := url.Values{}
+	if c.Pool != DefaultPool {
+		query.Set("pool", c.Pool)
+	}
+	if selector != "" {
+		query.Set("selector", selector)
+	}
+	route := "/leases"
+	if len(query) > 0 {
+		route += "?" + query.Encode()
+	}
+
+	var response struct {
+		Leases []HostLease
+	}
+	err := c.do("GET", route, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Leases, nil
+}
+
+// WatchLeases waits up to timeout for the routable leases to change after the
+// revision, and returns the changes. The http client must allow for requests
+// that take the timeout. A zero revision gets every routable lease, as does a
+// revision from another controller than the one that answers.
+func (c *Client) WatchLeases(since int64, timeout time.Duration) (LeaseChanges, error) {
+	query := url.Values{}
+	query.Set("since", strconv.FormatInt(since, 10))
+	query.Set("timeout", strconv.Itoa(int(timeout/time.Second)))
+	if c.Pool != DefaultPool {
+		query.Set("pool", c.Pool)
+	}
+
+	var response LeaseChanges
+	err := c.do("GET", "/leases/watch?"+query.Encode(), nil, &response)
+	if err != nil {
+		return LeaseChanges{}, err
+	}
+	return response, nil
+}
+
 func (c *Client) AcquireSubnetLease(underlayIP string) (Lease, error) {
-	return c.acquireLease(underlayIP, false)
+	return c.AcquireLease(AcquireLeaseRequest{UnderlayIP: underlayIP, Pool: c.Pool, Host: c.Host})
 }
 
 func (c *Client) AcquireSingleOverlayIPLease(underlayIP string) (Lease, error) {
-	return c.acquireLease(underlayIP, true)
+	return c.AcquireLease(AcquireLeaseRequest{UnderlayIP: underlayIP, SingleOverlayIP: true, Pool: c.Pool, Host: c.Host})
 }
 
-func (c *Client) acquireLease(underlayIP string, singleOverlayIP bool) (Lease, error) {
+func (c *Client) AcquireLease(request AcquireLeaseRequest) (Lease, error) {
 	var response Lease
-	request := AcquireLeaseRequest{
-		UnderlayIP:      underlayIP,
-		SingleOverlayIP: singleOverlayIP,
-	}
-	err := c.JsonClient.Do("PUT", "/leases/acquire", request, &response, "")
+	err := c.do("PUT", "/leases/acquire", request, &response)
 	if err != nil {
 		return Lease{}, err
 	}
 	return response, nil
 }
 
-func (c *Client) RenewSubnetLease(lease Lease) error {
-	err := c.JsonClient.Do("PUT", "/leases/renew", lease, nil, "")
+// RenewSubnetLease returns the lease as the controller renewed it, which
+// carries the vni of its pool. Controllers that answer with an empty body
+// renewed the lease as it was sent.
+func (c *Client) RenewSubnetLease(lease Lease) (Lease, error) {
+	var response Lease
+	err := c.do("PUT", "/leases/renew", RenewLeaseRequest{Lease: lease, Host: c.Host}, &response)
 	if err != nil {
 		httpResponseErr, ok := err.(*json_client.HttpResponseCodeError)
 		if ok && httpResponseErr.StatusCode == http.StatusConflict {
-			return NonRetriableError(fmt.Sprintf("non-retriable: %s", httpResponseErr.Message))
+			return Lease{}, NonRetriableError(fmt.Sprintf("non-retriable: %s", httpResponseErr.Message))
 		}
+		return Lease{}, err
+	}
+	if response.UnderlayIP == "" {
+		return lease, nil
 	}
-	return err
+	return response, nil
 }
 
 func (c *Client) ReleaseSubnetLease(underlayIP string) error {
 	request := ReleaseLeaseRequest{
 		UnderlayIP: underlayIP,
 	}
-	err := c.JsonClient.Do("PUT", "/leases/release", request, nil, "")
+	err := c.do("PUT", "/leases/release", request, nil)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func (c *Client) GetReservations() ([]Reservation, error) {
+	var response struct {
+		Reservations []Reservation
+	}
+	err := c.do("GET", "/reservations", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Reservations, nil
+}
+
+// AddReservation and RemoveReservation call the admin routes, which are only
+// served to clients whose certificate names an admin identity of the
+// controller.
+func (c *Client) AddReservation(reservation Reservation) error {
+	return c.do("PUT", "/admin/reservations/add", reservation, nil)
+}
+
+func (c *Client) RemoveReservation(underlayIP string) error {
+	request := RemoveReservationRequest{
+		UnderlayIP: underlayIP,
+	}
+	return c.do("PUT", "/admin/reservations/remove", request, nil)
+}
+
+func (c *Client) GetPoolUsage() ([]PoolUsage, error) {
+	var response struct {
+		Pools []PoolUsage
+	}
+	err := c.do("GET", "/pool", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Pools, nil
+}
+
+func (c *Client) GetLeaseEvents(filter LeaseEventFilter) ([]LeaseEvent, error) {
+	events, err := getLeaseEvents(c.JsonClient, "/leases/events", filter)
+	return events, c.rateLimited(err, "GET", "/leases/events")
+}
+
+func (c *Client) do(method, route string, reqData, respData interface{}) error {
+	err := c.JsonClient.Do(method, route, reqData, respData, "")
+	return c.rateLimited(err, method, strings.SplitN(route, "?", 2)[0])
+}
+
+// rateLimited turns a 429 into a RateLimitedError.
+func (c *Client) rateLimited(err error, method, path string) error {
+	httpResponseErr, ok := err.(*json_client.HttpResponseCodeError)
+	if !ok || httpResponseErr.StatusCode != http.StatusTooManyRequests {
+		return err
+	}
+	retryAfter := defaultRetryAfter
+	if c.RetryAfter != nil {
+		retryAfter = c.RetryAfter.Wait(method, path)
+	}
+	return &RateLimitedError{Message: httpResponseErr.Message, RetryAfter: retryAfter}
+}
+
+func getLeaseEvents(jsonClient json_client.JsonClient, route string, filter LeaseEventFilter) ([]LeaseEvent, error) {
+	query := url.Values{}
+	if filter.UnderlayIP != "" {
+		query.Set("underlay_ip", filter.UnderlayIP)
+	}
+	if filter.OverlaySubnet != "" {
+		query.Set("overlay_subnet", filter.OverlaySubnet)
+	}
+	if filter.Since != 0 {
+		query.Set("since", strconv.FormatInt(filter.Since, 10))
+	}
+	if filter.Until != 0 {
+		query.Set("until", strconv.FormatInt(filter.Until, 10))
+	}
+	if len(query) > 0 {
+		route += "?" + query.Encode()
+	}
+
+	var response struct {
+		Events []LeaseEvent
+	}
+	err := jsonClient.Do("GET", route, nil, &response, "")
+	if err != nil {
+		return nil, err
+	}
+	return response.Events, nil
+}
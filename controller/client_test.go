@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
 	"code.cloudfoundry.org/cf-networking-helpers/fakes"
 	"code.cloudfoundry.org/cf-networking-helpers/json_client"
@@ -69,6 +70,20 @@ var _ = Describe("Client", func() {
 			))
 		})
 
+		Context("when the client is configured with a pool", func() {
+			BeforeEach(func() {
+				client.Pool = "blue sky"
+			})
+
+			It("only asks for the leases of that pool", func() {
+				_, err := client.GetActiveLeases()
+				Expect(err).NotTo(HaveOccurred())
+
+				_, route, _, _, _ := jsonClient.DoArgsForCall(0)
+				Expect(route).To(Equal("/leases?pool=blue+sky"))
+			})
+		})
+
 		Context("when the json client fails", func() {
 			BeforeEach(func() {
 				jsonClient.DoReturns(errors.New("banana"))
@@ -80,6 +95,120 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("GetHostLeases", func() {
+		BeforeEach(func() {
+			jsonClient.DoStub = func(method, route string, reqData, respData interface{}, token string) error {
+				respBytes := []byte(`
+				{
+					"leases": [
+						{ "underlay_ip": "10.0.3.1", "overlay_subnet": "10.255.90.0/24", "host": { "hostname": "diego-cell-0", "az": "z2", "labels": { "stack": "cflinuxfs4" } } },
+						{ "underlay_ip": "10.0.5.9", "overlay_subnet": "10.253.30.0/24" }
+					]
+				}`)
+				json.Unmarshal(respBytes, respData)
+				return nil
+			}
+		})
+
+		It("gets the leases with their hosts", func() {
+			leases, err := client.GetHostLeases("")
+			Expect(err).NotTo(HaveOccurred())
+
+			method, route, reqData, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/leases"))
+			Expect(reqData).To(BeNil())
+
+			Expect(leases).To(Equal([]controller.HostLease{
+				{
+					Lease: controller.Lease{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24"},
+					Host: &controller.HostMetadata{
+						Hostname: "diego-cell-0",
+						AZ:       "z2",
+						Labels:   map[string]string{"stack": "cflinuxfs4"},
+					},
+				},
+				{
+					Lease: controller.Lease{UnderlayIP: "10.0.5.9", OverlaySubnet: "10.253.30.0/24"},
+				},
+			}))
+		})
+
+		It("asks for the leases of the pool whose hosts match the selector", func() {
+			client.Pool = "blue"
+			_, err := client.GetHostLeases("az=z2,stack")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, route, _, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(route).To(Equal("/leases?pool=blue&selector=az%3Dz2%2Cstack"))
+		})
+
+		Context("when the json client fails", func() {
+			BeforeEach(func() {
+				jsonClient.DoReturns(errors.New("banana"))
+			})
+			It("returns the error", func() {
+				_, err := client.GetHostLeases("")
+				Expect(err).To(MatchError("banana"))
+			})
+		})
+	})
+
+	Describe("WatchLeases", func() {
+		BeforeEach(func() {
+			jsonClient.DoStub = func(method, route string, reqData, respData interface{}, token string) error {
+				respBytes := []byte(`{
+					"revision": 43,
+					"added": [{ "underlay_ip": "10.0.3.1", "overlay_subnet": "10.255.90.0/24" }],
+					"removed": [{ "underlay_ip": "10.0.5.9", "overlay_subnet": "10.253.30.0/24" }]
+				}`)
+				json.Unmarshal(respBytes, respData)
+				return nil
+			}
+		})
+
+		It("returns the changes since the revision", func() {
+			changes, err := client.WatchLeases(42, 30*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal(controller.LeaseChanges{
+				Revision: 43,
+				Added:    []controller.Lease{{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24"}},
+				Removed:  []controller.Lease{{UnderlayIP: "10.0.5.9", OverlaySubnet: "10.253.30.0/24"}},
+			}))
+
+			Expect(jsonClient.DoCallCount()).To(Equal(1))
+			method, route, reqData, _, token := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/leases/watch?since=42&timeout=30"))
+			Expect(reqData).To(BeNil())
+			Expect(token).To(BeEmpty())
+		})
+
+		Context("when the client is configured with a pool", func() {
+			BeforeEach(func() {
+				client.Pool = "blue sky"
+			})
+
+			It("only watches the leases of that pool", func() {
+				_, err := client.WatchLeases(42, 30*time.Second)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, route, _, _, _ := jsonClient.DoArgsForCall(0)
+				Expect(route).To(Equal("/leases/watch?pool=blue+sky&since=42&timeout=30"))
+			})
+		})
+
+		Context("when the json client fails", func() {
+			BeforeEach(func() {
+				jsonClient.DoReturns(errors.New("banana"))
+			})
+			It("returns the error", func() {
+				_, err := client.WatchLeases(42, 30*time.Second)
+				Expect(err).To(MatchError("banana"))
+			})
+		})
+	})
+
 	Describe("AcquireSubnetLease", func() {
 		Context("when acquring a single overlay IP", func() {
 			BeforeEach(func() {
@@ -146,6 +275,77 @@ var _ = Describe("Client", func() {
 
 		})
 
+		Context("when acquiring a dual stack lease", func() {
+			BeforeEach(func() {
+				jsonClient.DoStub = func(method, route string, reqData, respData interface{}, token string) error {
+					respBytes := []byte(`
+				{
+					"underlay_ip": "10.0.3.1",
+					"overlay_subnet": "10.255.90.0/24",
+					"overlay_subnet_v6": "fd00:255:0:5a::/64"
+				}`)
+					json.Unmarshal(respBytes, respData)
+					return nil
+				}
+			})
+
+			It("sends the requested ip family", func() {
+				lease, err := client.AcquireLease(controller.AcquireLeaseRequest{
+					UnderlayIP: "10.0.3.1",
+					IPFamily:   controller.IPFamilyDual,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(jsonClient.DoCallCount()).To(Equal(1))
+				method, route, reqData, _, _ := jsonClient.DoArgsForCall(0)
+				Expect(method).To(Equal("PUT"))
+				Expect(route).To(Equal("/leases/acquire"))
+				Expect(reqData).To(Equal(controller.AcquireLeaseRequest{UnderlayIP: "10.0.3.1", IPFamily: "dual"}))
+
+				Expect(lease).To(Equal(controller.Lease{
+					UnderlayIP:      "10.0.3.1",
+					OverlaySubnet:   "10.255.90.0/24",
+					OverlaySubnetV6: "fd00:255:0:5a::/64",
+				}))
+			})
+		})
+
+		Context("when the client is configured with a pool", func() {
+			BeforeEach(func() {
+				client.Pool = "blue"
+			})
+
+			It("acquires the lease from that pool", func() {
+				_, err := client.AcquireSubnetLease("10.0.3.1")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = client.AcquireSingleOverlayIPLease("10.0.3.1")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, reqData, _, _ := jsonClient.DoArgsForCall(0)
+				Expect(reqData).To(Equal(controller.AcquireLeaseRequest{UnderlayIP: "10.0.3.1", Pool: "blue"}))
+				_, _, reqData, _, _ = jsonClient.DoArgsForCall(1)
+				Expect(reqData).To(Equal(controller.AcquireLeaseRequest{UnderlayIP: "10.0.3.1", SingleOverlayIP: true, Pool: "blue"}))
+			})
+		})
+
+		Context("when the client is configured with host metadata", func() {
+			BeforeEach(func() {
+				client.Host = &controller.HostMetadata{Hostname: "diego-cell-0", Labels: map[string]string{"stack": "cflinuxfs4"}}
+			})
+
+			It("sends it with the request", func() {
+				_, err := client.AcquireSubnetLease("10.0.3.1")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = client.AcquireSingleOverlayIPLease("10.0.3.1")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, reqData, _, _ := jsonClient.DoArgsForCall(0)
+				Expect(reqData).To(Equal(controller.AcquireLeaseRequest{UnderlayIP: "10.0.3.1", Host: client.Host}))
+				_, _, reqData, _, _ = jsonClient.DoArgsForCall(1)
+				Expect(reqData).To(Equal(controller.AcquireLeaseRequest{UnderlayIP: "10.0.3.1", SingleOverlayIP: true, Host: client.Host}))
+			})
+		})
+
 		Context("when the json client fails", func() {
 			BeforeEach(func() {
 				jsonClient.DoReturns(errors.New("carrot"))
@@ -168,17 +368,57 @@ var _ = Describe("Client", func() {
 		})
 
 		It("calls the controller to renew the subnet lease", func() {
-			err := client.RenewSubnetLease(lease)
+			renewed, err := client.RenewSubnetLease(lease)
 			Expect(err).NotTo(HaveOccurred())
+			Expect(renewed).To(Equal(lease))
 
 			Expect(jsonClient.DoCallCount()).To(Equal(1))
 			method, route, reqData, _, token := jsonClient.DoArgsForCall(0)
 			Expect(method).To(Equal("PUT"))
 			Expect(route).To(Equal("/leases/renew"))
-			Expect(reqData).To(Equal(lease))
+			Expect(reqData).To(Equal(controller.RenewLeaseRequest{Lease: lease}))
 			Expect(token).To(BeEmpty())
 		})
 
+		Context("when the controller returns the renewed lease", func() {
+			BeforeEach(func() {
+				jsonClient.DoStub = func(method, route string, reqData, respData interface{}, token string) error {
+					respBytes := []byte(`
+					{
+						"underlay_ip": "10.0.3.1",
+						"overlay_subnet": "10.255.90.0/24",
+						"overlay_hardware_addr": "ee:ee:0a:ff:5a:00",
+						"vni": 42
+					}`)
+					json.Unmarshal(respBytes, respData)
+					return nil
+				}
+			})
+
+			It("returns it with the vni of its pool", func() {
+				renewed, err := client.RenewSubnetLease(lease)
+				Expect(err).NotTo(HaveOccurred())
+
+				expected := lease
+				expected.VNI = 42
+				Expect(renewed).To(Equal(expected))
+			})
+		})
+
+		Context("when the client is configured with host metadata", func() {
+			BeforeEach(func() {
+				client.Host = &controller.HostMetadata{Hostname: "diego-cell-0", AZ: "z2"}
+			})
+
+			It("sends it with the lease", func() {
+				_, err := client.RenewSubnetLease(lease)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, reqData, _, _ := jsonClient.DoArgsForCall(0)
+				Expect(reqData).To(Equal(controller.RenewLeaseRequest{Lease: lease, Host: client.Host}))
+			})
+		})
+
 		Context("when the json client fails due to a HTTP 409 Conflict", func() {
 			BeforeEach(func() {
 				jsonClient.DoReturns(&json_client.HttpResponseCodeError{
@@ -188,7 +428,7 @@ var _ = Describe("Client", func() {
 			})
 
 			It("returns a non-retriable error", func() {
-				err := client.RenewSubnetLease(lease)
+				_, err := client.RenewSubnetLease(lease)
 				Expect(err).NotTo(BeNil())
 				typedErr, ok := err.(controller.NonRetriableError)
 				Expect(ok).To(BeTrue())
@@ -196,13 +436,31 @@ var _ = Describe("Client", func() {
 			})
 		})
 
+		Context("when the json client fails due to a HTTP 429 Too Many Requests", func() {
+			BeforeEach(func() {
+				jsonClient.DoReturns(&json_client.HttpResponseCodeError{
+					StatusCode: http.StatusTooManyRequests,
+					Message:    "cell-0 is over the rate limit",
+				})
+			})
+
+			It("returns a rate limited error", func() {
+				_, err := client.RenewSubnetLease(lease)
+				Expect(err).To(Equal(&controller.RateLimitedError{
+					Message:    "cell-0 is over the rate limit",
+					RetryAfter: time.Second,
+				}))
+				Expect(err).To(MatchError("rate limited, retry after 1s: cell-0 is over the rate limit"))
+			})
+		})
+
 		Context("when the json client returns any other error", func() {
 			BeforeEach(func() {
 				jsonClient.DoReturns(errors.New("no you're a teapot"))
 			})
 
 			It("returns the error", func() {
-				err := client.RenewSubnetLease(lease)
+				_, err := client.RenewSubnetLease(lease)
 				Expect(err).To(MatchError("no you're a teapot"))
 			})
 		})
@@ -245,4 +503,187 @@ var _ = Describe("Client", func() {
 			})
 		})
 	})
+
+	Describe("GetReservations", func() {
+		BeforeEach(func() {
+			jsonClient.DoStub = func(method, route string, reqData, respData interface{}, token string) error {
+				respBytes := []byte(`{ "reservations": [
+					{ "underlay_ip": "10.0.3.1", "overlay_subnet": "10.255.90.0/24" },
+					{ "underlay_ip": "10.0.3.2", "overlay_subnet": "10.250.90.0/24", "pool": "blue" }
+				] }`)
+				json.Unmarshal(respBytes, respData)
+				return nil
+			}
+		})
+
+		It("returns the reservations", func() {
+			reservations, err := client.GetReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(Equal([]controller.Reservation{
+				{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24"},
+				{UnderlayIP: "10.0.3.2", OverlaySubnet: "10.250.90.0/24", Pool: "blue"},
+			}))
+
+			Expect(jsonClient.DoCallCount()).To(Equal(1))
+			method, route, reqData, _, token := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/reservations"))
+			Expect(reqData).To(BeNil())
+			Expect(token).To(BeEmpty())
+		})
+
+		Context("when the json client fails", func() {
+			BeforeEach(func() {
+				jsonClient.DoReturns(errors.New("carrot"))
+			})
+
+			It("returns the error", func() {
+				_, err := client.GetReservations()
+				Expect(err).To(MatchError("carrot"))
+			})
+		})
+	})
+
+	Describe("AddReservation", func() {
+		It("calls the controller to add the reservation", func() {
+			reservation := controller.Reservation{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24"}
+			Expect(client.AddReservation(reservation)).To(Succeed())
+
+			Expect(jsonClient.DoCallCount()).To(Equal(1))
+			method, route, reqData, response, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("PUT"))
+			Expect(route).To(Equal("/admin/reservations/add"))
+			Expect(reqData).To(Equal(reservation))
+			Expect(response).To(BeNil())
+		})
+
+		Context("when the json client returns an error", func() {
+			BeforeEach(func() {
+				jsonClient.DoReturns(errors.New("potato"))
+			})
+
+			It("returns the error", func() {
+				err := client.AddReservation(controller.Reservation{UnderlayIP: "10.0.3.1"})
+				Expect(err).To(MatchError("potato"))
+			})
+		})
+	})
+
+	Describe("RemoveReservation", func() {
+		It("calls the controller to remove the reservation", func() {
+			Expect(client.RemoveReservation("10.0.3.1")).To(Succeed())
+
+			Expect(jsonClient.DoCallCount()).To(Equal(1))
+			method, route, reqData, response, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("PUT"))
+			Expect(route).To(Equal("/admin/reservations/remove"))
+			Expect(reqData).To(Equal(controller.RemoveReservationRequest{UnderlayIP: "10.0.3.1"}))
+			Expect(response).To(BeNil())
+		})
+
+		Context("when the json client returns an error", func() {
+			BeforeEach(func() {
+				jsonClient.DoReturns(errors.New("leek"))
+			})
+
+			It("returns the error", func() {
+				err := client.RemoveReservation("10.0.3.1")
+				Expect(err).To(MatchError("leek"))
+			})
+		})
+	})
+
+	Describe("GetPoolUsage", func() {
+		BeforeEach(func() {
+			jsonClient.DoStub = func(method, route string, reqData, respData interface{}, token string) error {
+				respBytes := []byte(`{ "pools": [ {
+					"blocks": { "total": 255, "active": 2, "expired": 1, "quarantined": 3, "reserved": 1, "free": 248 },
+					"single_ips": { "total": 255, "active": 1, "expired": 0, "quarantined": 0, "reserved": 0, "free": 254 }
+				} ] }`)
+				json.Unmarshal(respBytes, respData)
+				return nil
+			}
+		})
+
+		It("returns the usage of every pool", func() {
+			usage, err := client.GetPoolUsage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage).To(Equal([]controller.PoolUsage{{
+				Blocks:    controller.SubnetUsage{Total: 255, Active: 2, Expired: 1, Quarantined: 3, Reserved: 1, Free: 248},
+				SingleIPs: controller.SubnetUsage{Total: 255, Active: 1, Free: 254},
+			}}))
+
+			Expect(jsonClient.DoCallCount()).To(Equal(1))
+			method, route, reqData, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/pool"))
+			Expect(reqData).To(BeNil())
+		})
+
+		Context("when the json client fails", func() {
+			BeforeEach(func() {
+				jsonClient.DoStub = nil
+				jsonClient.DoReturns(errors.New("carrot"))
+			})
+
+			It("returns the error", func() {
+				_, err := client.GetPoolUsage()
+				Expect(err).To(MatchError("carrot"))
+			})
+		})
+	})
+
+	Describe("GetLeaseEvents", func() {
+		BeforeEach(func() {
+			jsonClient.DoStub = func(method, route string, reqData, respData interface{}, token string) error {
+				respBytes := []byte(`{ "events": [
+					{ "type": "acquired", "underlay_ip": "10.0.3.1", "overlay_subnet": "10.255.90.0/24", "actor": "10.0.3.1", "timestamp": 1700000000 },
+					{ "type": "released", "underlay_ip": "10.0.3.1", "overlay_subnet": "10.255.90.0/24", "actor": "silk-admin", "reason": "released by request", "timestamp": 1700000060 }
+				] }`)
+				json.Unmarshal(respBytes, respData)
+				return nil
+			}
+		})
+
+		It("returns the events", func() {
+			events, err := client.GetLeaseEvents(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(Equal([]controller.LeaseEvent{
+				{Type: "acquired", UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24", Actor: "10.0.3.1", Timestamp: 1700000000},
+				{Type: "released", UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24", Actor: "silk-admin", Reason: "released by request", Timestamp: 1700000060},
+			}))
+
+			Expect(jsonClient.DoCallCount()).To(Equal(1))
+			method, route, reqData, _, token := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/leases/events"))
+			Expect(reqData).To(BeNil())
+			Expect(token).To(BeEmpty())
+		})
+
+		It("passes the filter as query parameters", func() {
+			_, err := client.GetLeaseEvents(controller.LeaseEventFilter{
+				UnderlayIP:    "10.0.3.1",
+				OverlaySubnet: "10.255.90.0/24",
+				Since:         1700000000,
+				Until:         1700000060,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, route, _, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(route).To(Equal("/leases/events?overlay_subnet=10.255.90.0%2F24&since=1700000000&underlay_ip=10.0.3.1&until=1700000060"))
+		})
+
+		Context("when the json client returns an error", func() {
+			BeforeEach(func() {
+				jsonClient.DoStub = nil
+				jsonClient.DoReturns(errors.New("radish"))
+			})
+
+			It("returns the error", func() {
+				_, err := client.GetLeaseEvents(controller.LeaseEventFilter{})
+				Expect(err).To(MatchError("radish"))
+			})
+		})
+	})
 })
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 
 	"code.cloudfoundry.org/cf-networking-helpers/db"
+	"code.cloudfoundry.org/silk/controller/database"
 	"gopkg.in/validator.v2"
 )
 
@@ -17,10 +19,13 @@ type Config struct {
 	CACertFile                    string    `json:"ca_cert_file" validate:"nonzero"`
 	ServerCertFile                string    `json:"server_cert_file" validate:"nonzero"`
 	ServerKeyFile                 string    `json:"server_key_file" validate:"nonzero"`
-	Network                       string    `json:"network" validate:"nonzero"`
+	Network                       string    `json:"network"`
 	SubnetPrefixLength            int       `json:"subnet_prefix_length" validate:"nonzero"`
+	NetworkV6                     string    `json:"network_v6"`
+	SubnetPrefixLengthV6          int       `json:"subnet_prefix_length_v6"`
 	Database                      db.Config `json:"database" validate:"nonzero"`
 	LeaseExpirationSeconds        int       `json:"lease_expiration_seconds" validate:"min=1"`
+	QuarantineSeconds             int       `json:"quarantine_seconds" validate:"min=0"`
 	MetronPort                    int       `json:"metron_port" validate:"min=1"`
 	HealthCheckPort               int       `json:"health_check_port" validate:"min=1"`
 	MetricsEmitSeconds            int       `json:"metrics_emit_seconds" validate:"min=1"`
@@ -29,6 +34,145 @@ type Config struct {
 	MaxIdleConnections            int       `json:"max_idle_connections" validate:"min=0"`
 	MaxOpenConnections            int       `json:"max_open_connections" validate:"min=0"`
 	MaxConnectionsLifetimeSeconds int       `json:"connections_max_lifetime_seconds" validate:"min=0"`
+	AllocationStrategy            string    `json:"allocation_strategy"`
+	CIDRs                         []CIDR    `json:"cidrs"`
+	ExcludedRanges                []string  `json:"excluded_ranges"`
+	Pools                         []Pool    `json:"pools"`
+	Topology                      Topology  `json:"topology"`
+	VNI                           int       `json:"vni" validate:"min=0,max=16777215"`
+	Reaper                        Reaper    `json:"reaper"`
+
+	// AdminIdentities are the client certificate common names or DNS names
+	// allowed to call the /admin routes. With none, no client may.
+	AdminIdentities []string `json:"admin_identities"`
+
+	// HostIdentities limit the leases each client may acquire, renew or
+	// release to those of the underlay ips in its UnderlayRanges. Clients that
+	// are not listed may manage none, and admins any. With none, any client
+	// may manage any lease.
+	HostIdentities []HostIdentity `json:"host_identities"`
+
+	// PrometheusPort serves the metrics in the Prometheus format on /metrics,
+	// over plain http on the listen host. Zero turns it off.
+	PrometheusPort int `json:"prometheus_port" validate:"min=0"`
+
+	// LeaseCacheSeconds is how stale the leases listed from the lease cache
+	// may be, which only sees the writes of other controllers once they are.
+	// Zero turns it off.
+	LeaseCacheSeconds int `json:"lease_cache_seconds" validate:"min=0"`
+
+	// LeaseWatchHistorySize is how many changes to the routable leases each
+	// controller keeps for /leases/watch, 1000 if zero. A client that is
+	// further behind gets every routable lease again. Every controller
+	// numbers the revisions of its own history, so a client that watches
+	// through a load balancer in front of several controllers also gets every
+	// lease again whenever its request reaches another controller than the
+	// last one. Clients that watch should stick to a single controller.
+	LeaseWatchHistorySize int `json:"lease_watch_history_size" validate:"min=0"`
+
+	LeaderElection LeaderElection `json:"leader_election"`
+
+	// RateLimits limit how often each client may call a route, by the name
+	// of the route, such as leases-acquire. Clients are told apart by the
+	// common name or first DNS name of their certificate. Requests over the
+	// limit get a 429 with a Retry-After.
+	RateLimits map[string]RateLimit `json:"rate_limits"`
+
+	// MaxInFlightAcquires caps the acquisitions served at once, across every
+	// client. Zero leaves them uncapped.
+	MaxInFlightAcquires int `json:"max_in_flight_acquires" validate:"min=0"`
+}
+
+// RateLimit is a token bucket that holds up to Burst requests and refills at
+// RequestsPerSecond.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+}
+
+// Reaper configures the periodic deletion of leases that are no longer
+// renewed. See the reaper package for the policies.
+type Reaper struct {
+	Policy                      string `json:"policy"`
+	IntervalSeconds             int    `json:"interval_seconds" validate:"min=0"`
+	ExpiryMultiple              int    `json:"expiry_multiple" validate:"min=0"`
+	UtilizationThresholdPercent int    `json:"utilization_threshold_percent" validate:"min=0,max=100"`
+}
+
+// LeaderElection configures how the controllers sharing a database elect the
+// one that runs the background jobs, the lease reaper and the lease metrics.
+// The leader claims leadership every HeartbeatSeconds and another controller
+// takes over once it has not for TTLSeconds. Zero values fall back to
+// defaults, and an empty Identity to the host name and listen port.
+//
+// The election is off unless Enabled is set, and then every controller runs
+// the background jobs. Turn it on once every controller sharing the database
+// can take part, since one that does not keeps running them alongside the
+// leader.
+type LeaderElection struct {
+	Enabled          bool   `json:"enabled"`
+	Identity         string `json:"identity"`
+	HeartbeatSeconds int    `json:"heartbeat_seconds" validate:"min=0"`
+	TTLSeconds       int    `json:"ttl_seconds" validate:"min=0"`
+}
+
+// HostIdentity maps a client certificate common name or DNS name to the
+// underlay ips, or cidrs of them, of its hosts.
+type HostIdentity struct {
+	Identity       string   `json:"identity" validate:"nonzero"`
+	UnderlayRanges []string `json:"underlay_ranges"`
+}
+
+const (
+	CIDRStateActive   = "active"
+	CIDRStateDraining = "draining"
+)
+
+// CIDR is one of the networks of a pool. New leases are only handed out from
+// active networks, while existing leases in draining networks keep being
+// renewed. An empty State means active.
+type CIDR struct {
+	Network string `json:"network" validate:"nonzero"`
+	State   string `json:"state"`
+}
+
+// Pool is a named overlay network served alongside the top level network,
+// which acts as the unnamed default pool. A zero LeaseExpirationSeconds or
+// QuarantineSeconds, or an empty AllocationStrategy, falls back to the top
+// level value.
+type Pool struct {
+	Name               string `json:"name" validate:"nonzero"`
+	Network            string `json:"network"`
+	CIDRs              []CIDR `json:"cidrs"`
+	SubnetPrefixLength int    `json:"subnet_prefix_length" validate:"nonzero"`
+	// ExcludedRanges are never leased, from the ipv4 and the ipv6 networks.
+	ExcludedRanges         []string `json:"excluded_ranges"`
+	NetworkV6              string   `json:"network_v6"`
+	SubnetPrefixLengthV6   int      `json:"subnet_prefix_length_v6"`
+	LeaseExpirationSeconds int      `json:"lease_expiration_seconds" validate:"min=0"`
+	QuarantineSeconds      int      `json:"quarantine_seconds" validate:"min=0"`
+	AllocationStrategy     string   `json:"allocation_strategy"`
+	Topology               Topology `json:"topology"`
+	// VNI is the vxlan segment of the hosts of the pool, which only reach
+	// the hosts of pools with the same vni. Zero leaves it to the hosts.
+	VNI int `json:"vni" validate:"min=0,max=16777215"`
+}
+
+// Topology carves the blocks of a pool into partitions, so that the blocks of
+// the hosts that share a value of Key, such as az, lie in one range. Key names
+// a field or label of the host metadata the way a label selector does. Hosts
+// without a partition, or whose partition is full, get blocks outside every
+// partition.
+type Topology struct {
+	Key        string      `json:"key"`
+	Partitions []Partition `json:"partitions"`
+}
+
+// Partition is the range of blocks of the hosts with the value. It must lie
+// within an active ipv4 network of the pool.
+type Partition struct {
+	Value   string `json:"value" validate:"nonzero"`
+	Network string `json:"network" validate:"nonzero"`
 }
 
 func (c *Config) WriteToFile(configFilePath string) error {
@@ -53,8 +197,251 @@ func ReadFromFile(configFilePath string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unmarshal config: %s", err)
 	}
-	if err := validator.Validate(conf); err != nil {
+	if err := validator.Validate(withoutServerFields(conf)); err != nil {
+		return nil, fmt.Errorf("invalid config: %s", err)
+	}
+	if conf.Database.Type == database.SQLite && conf.Database.DatabaseName == "" {
+		return nil, fmt.Errorf("invalid config: Database.DatabaseName: the path of the sqlite3 database file is required")
+	}
+	if err := validatePools(conf.LeasePools()); err != nil {
+		return nil, fmt.Errorf("invalid config: %s", err)
+	}
+	if err := validateHostIdentities(conf.HostIdentities); err != nil {
+		return nil, fmt.Errorf("invalid config: %s", err)
+	}
+	if err := validateRateLimits(conf.RateLimits); err != nil {
 		return nil, fmt.Errorf("invalid config: %s", err)
 	}
 	return &conf, nil
 }
+
+// withoutServerFields fills in the user, host and port of a sqlite3 database,
+// which is a local file with no server to connect to, so that validation does
+// not ask for them. A memory database has no timeout either.
+func withoutServerFields(conf Config) Config {
+	switch conf.Database.Type {
+	case database.Memory:
+		conf.Database.Timeout = 1
+		fallthrough
+	case database.SQLite:
+		conf.Database.User = "-"
+		conf.Database.Host = "-"
+		conf.Database.Port = 1
+	}
+	return conf
+}
+
+// LeasePools returns the default pool followed by the named pools.
+func (c *Config) LeasePools() []Pool {
+	pools := []Pool{{
+		Network:                c.Network,
+		CIDRs:                  c.CIDRs,
+		ExcludedRanges:         c.ExcludedRanges,
+		SubnetPrefixLength:     c.SubnetPrefixLength,
+		NetworkV6:              c.NetworkV6,
+		SubnetPrefixLengthV6:   c.SubnetPrefixLengthV6,
+		LeaseExpirationSeconds: c.LeaseExpirationSeconds,
+		QuarantineSeconds:      c.QuarantineSeconds,
+		AllocationStrategy:     c.AllocationStrategy,
+		Topology:               c.Topology,
+		VNI:                    c.VNI,
+	}}
+	for _, pool := range c.Pools {
+		if pool.LeaseExpirationSeconds == 0 {
+			pool.LeaseExpirationSeconds = c.LeaseExpirationSeconds
+		}
+		if pool.QuarantineSeconds == 0 {
+			pool.QuarantineSeconds = c.QuarantineSeconds
+		}
+		if pool.AllocationStrategy == "" {
+			pool.AllocationStrategy = c.AllocationStrategy
+		}
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// HostUnderlayRanges returns the underlay ranges of each host identity.
+func (c *Config) HostUnderlayRanges() map[string][]string {
+	ranges := map[string][]string{}
+	for _, host := range c.HostIdentities {
+		ranges[host.Identity] = append(ranges[host.Identity], host.UnderlayRanges...)
+	}
+	return ranges
+}
+
+// Networks returns the networks of the pool, starting with Network, which is
+// always active, followed by CIDRs.
+func (p Pool) Networks() []CIDR {
+	var networks []CIDR
+	if p.Network != "" {
+		networks = append(networks, CIDR{Network: p.Network, State: CIDRStateActive})
+	}
+	for _, cidr := range p.CIDRs {
+		if cidr.State == "" {
+			cidr.State = CIDRStateActive
+		}
+		networks = append(networks, cidr)
+	}
+	return networks
+}
+
+func containsNetwork(networks []*net.IPNet, subnet *net.IPNet) bool {
+	subnetOnes, subnetBits := subnet.Mask.Size()
+	for _, network := range networks {
+		ones, bits := network.Mask.Size()
+		if bits == subnetBits && ones <= subnetOnes && network.Contains(subnet.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+func validatePools(pools []Pool) error {
+	var networks []*net.IPNet
+	names := map[string]struct{}{}
+	for i, pool := range pools {
+		if _, ok := names[pool.Name]; ok {
+			return fmt.Errorf("Pools: duplicate pool name %q", pool.Name)
+		}
+		names[pool.Name] = struct{}{}
+
+		field := "Network"
+		if i > 0 {
+			field = fmt.Sprintf("Pools[%d].Network", i-1)
+		}
+		if len(pool.Networks()) == 0 {
+			return fmt.Errorf("%s: zero value", field)
+		}
+		active := false
+		for _, cidr := range pool.Networks() {
+			switch cidr.State {
+			case CIDRStateActive:
+				active = true
+			case CIDRStateDraining:
+			default:
+				return fmt.Errorf("CIDRs: unknown state %q for %s", cidr.State, cidr.Network)
+			}
+			_, network, err := net.ParseCIDR(cidr.Network)
+			if err != nil {
+				return fmt.Errorf("Network: %s", err)
+			}
+			networks = append(networks, network)
+		}
+		if !active {
+			return fmt.Errorf("CIDRs: pool %q has no active network", pool.Name)
+		}
+		poolNetworks := networks[len(networks)-len(pool.Networks()):]
+
+		if pool.NetworkV6 != "" {
+			networkV6, err := validateNetworkV6(pool.NetworkV6, pool.SubnetPrefixLengthV6)
+			if err != nil {
+				return err
+			}
+			networks = append(networks, networkV6)
+			poolNetworks = append(poolNetworks, networkV6)
+		}
+
+		for _, excludedRange := range pool.ExcludedRanges {
+			_, excluded, err := net.ParseCIDR(excludedRange)
+			if err != nil {
+				return fmt.Errorf("ExcludedRanges: %s", err)
+			}
+			if !containsNetwork(poolNetworks, excluded) {
+				return fmt.Errorf("ExcludedRanges: %s is not inside the networks of pool %q", excludedRange, pool.Name)
+			}
+		}
+
+		if err := validateTopology(pool); err != nil {
+			return err
+		}
+	}
+
+	for i, a := range networks {
+		for _, b := range networks[i+1:] {
+			if a.Contains(b.IP) || b.Contains(a.IP) {
+				return fmt.Errorf("Pools: networks %s and %s overlap", a, b)
+			}
+		}
+	}
+	return nil
+}
+
+func validateTopology(pool Pool) error {
+	if len(pool.Topology.Partitions) > 0 && pool.Topology.Key == "" {
+		return fmt.Errorf("Topology.Key: pool %q has partitions but no key", pool.Name)
+	}
+	var activeNetworks, partitions []*net.IPNet
+	for _, cidr := range pool.Networks() {
+		if cidr.State == CIDRStateActive {
+			_, network, _ := net.ParseCIDR(cidr.Network)
+			activeNetworks = append(activeNetworks, network)
+		}
+	}
+	values := map[string]struct{}{}
+	for _, partition := range pool.Topology.Partitions {
+		if _, ok := values[partition.Value]; ok {
+			return fmt.Errorf("Topology.Partitions: duplicate value %q in pool %q", partition.Value, pool.Name)
+		}
+		values[partition.Value] = struct{}{}
+
+		_, network, err := net.ParseCIDR(partition.Network)
+		if err != nil {
+			return fmt.Errorf("Topology.Partitions: %s", err)
+		}
+		if !containsNetwork(activeNetworks, network) {
+			return fmt.Errorf("Topology.Partitions: %s is not inside the active networks of pool %q", partition.Network, pool.Name)
+		}
+		if ones, _ := network.Mask.Size(); ones > pool.SubnetPrefixLength {
+			return fmt.Errorf("Topology.Partitions: %s is smaller than a subnet of pool %q", partition.Network, pool.Name)
+		}
+		for _, other := range partitions {
+			if other.Contains(network.IP) || network.Contains(other.IP) {
+				return fmt.Errorf("Topology.Partitions: partitions %s and %s overlap", other, network)
+			}
+		}
+		partitions = append(partitions, network)
+	}
+	return nil
+}
+
+func validateHostIdentities(hosts []HostIdentity) error {
+	for i, host := range hosts {
+		for _, underlayRange := range host.UnderlayRanges {
+			if net.ParseIP(underlayRange) != nil {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(underlayRange); err != nil {
+				return fmt.Errorf("HostIdentities[%d].UnderlayRanges: %q is neither an ip nor a cidr", i, underlayRange)
+			}
+		}
+	}
+	return nil
+}
+
+func validateRateLimits(limits map[string]RateLimit) error {
+	for route, limit := range limits {
+		if limit.RequestsPerSecond <= 0 {
+			return fmt.Errorf("RateLimits[%s].RequestsPerSecond: must be greater than zero", route)
+		}
+		if limit.Burst < 1 {
+			return fmt.Errorf("RateLimits[%s].Burst: must be at least 1", route)
+		}
+	}
+	return nil
+}
+
+func validateNetworkV6(networkV6 string, subnetPrefixLengthV6 int) (*net.IPNet, error) {
+	ip, network, err := net.ParseCIDR(networkV6)
+	if err != nil {
+		return nil, fmt.Errorf("NetworkV6: %s", err)
+	}
+	if ip.To4() != nil {
+		return nil, fmt.Errorf("NetworkV6: %s is not an ipv6 network", networkV6)
+	}
+	ones, bits := network.Mask.Size()
+	if subnetPrefixLengthV6 <= ones || subnetPrefixLengthV6 > bits {
+		return nil, fmt.Errorf("SubnetPrefixLengthV6: must be between %d and %d", ones+1, bits)
+	}
+	return network, nil
+}
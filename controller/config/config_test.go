@@ -65,6 +65,63 @@ var _ = Describe("Config.ReadFromFile", func() {
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	Context("when the database is sqlite3", func() {
+		writeConfig := func(database map[string]interface{}) string {
+			cfg := cloneMap(requiredFields)
+			cfg["database"] = database
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+			return file.Name()
+		}
+
+		It("does not need a user, host or port", func() {
+			conf, err := config.ReadFromFile(writeConfig(map[string]interface{}{
+				"type":          "sqlite3",
+				"timeout":       5,
+				"database_name": "/var/vcap/store/silk-controller/silk.db",
+			}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.Database).To(Equal(db.Config{
+				Type:         "sqlite3",
+				Timeout:      5,
+				DatabaseName: "/var/vcap/store/silk-controller/silk.db",
+			}))
+		})
+
+		It("needs the path of the database file", func() {
+			_, err := config.ReadFromFile(writeConfig(map[string]interface{}{
+				"type":    "sqlite3",
+				"timeout": 5,
+			}))
+			Expect(err).To(MatchError("invalid config: Database.DatabaseName: the path of the sqlite3 database file is required"))
+		})
+
+		It("still needs a timeout", func() {
+			_, err := config.ReadFromFile(writeConfig(map[string]interface{}{
+				"type":          "sqlite3",
+				"database_name": "/var/vcap/store/silk-controller/silk.db",
+			}))
+			Expect(err).To(MatchError(ContainSubstring("Database.Timeout: less than min")))
+		})
+	})
+
+	Context("when the database is memory", func() {
+		It("needs nothing but the type", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["database"] = map[string]interface{}{"type": "memory"}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.Database).To(Equal(db.Config{Type: "memory"}))
+		})
+	})
+
 	DescribeTable("when config file is missing a member",
 		func(missingFlag, errorString string) {
 			cfg := cloneMap(requiredFields)
@@ -114,5 +171,296 @@ var _ = Describe("Config.ReadFromFile", func() {
 		Entry("invalid max_open_connections", "max_open_connections", -2, "MaxOpenConnections: less than min"),
 		Entry("invalid max_idle_connections", "max_idle_connections", -2, "MaxIdleConnections: less than min"),
 		Entry("invalid connections_max_lifetime_seconds", "connections_max_lifetime_seconds", -2, "MaxConnectionsLifetimeSeconds: less than min"),
+		Entry("network_v6 without a prefix length", "network_v6", "fd00:255::/48", "SubnetPrefixLengthV6: must be between 49 and 128"),
+		Entry("network_v6 that is not a cidr", "network_v6", "banana", "NetworkV6: invalid CIDR address: banana"),
+		Entry("network_v6 that is ipv4", "network_v6", "10.255.0.0/16", "NetworkV6: 10.255.0.0/16 is not an ipv6 network"),
+		Entry("negative quarantine_seconds", "quarantine_seconds", -1, "QuarantineSeconds: less than min"),
+		Entry("invalid prometheus_port", "prometheus_port", -1, "PrometheusPort: less than min"),
+		Entry("negative lease_cache_seconds", "lease_cache_seconds", -1, "LeaseCacheSeconds: less than min"),
+		Entry("negative lease_watch_history_size", "lease_watch_history_size", -1, "LeaseWatchHistorySize: less than min"),
+		Entry("invalid reaper interval_seconds", "reaper", map[string]interface{}{"interval_seconds": -1}, "Reaper.IntervalSeconds: less than min"),
+		Entry("invalid reaper utilization_threshold_percent", "reaper", map[string]interface{}{"utilization_threshold_percent": 101}, "Reaper.UtilizationThresholdPercent: greater than max"),
+		Entry("excluded range that is not a cidr", "excluded_ranges", []string{"banana"}, "ExcludedRanges: invalid CIDR address: banana"),
+		Entry("excluded range outside the network", "excluded_ranges", []string{"10.254.0.0/24"}, `ExcludedRanges: 10.254.0.0/24 is not inside the networks of pool ""`),
+		Entry("excluded range larger than the network", "excluded_ranges", []string{"10.0.0.0/8"}, `ExcludedRanges: 10.0.0.0/8 is not inside the networks of pool ""`),
+		Entry("negative vni", "vni", -1, "VNI: less than min"),
+		Entry("negative max_in_flight_acquires", "max_in_flight_acquires", -1, "MaxInFlightAcquires: less than min"),
+		Entry("rate limit without a rate", "rate_limits", map[string]interface{}{"leases-acquire": map[string]interface{}{"burst": 5}}, "RateLimits[leases-acquire].RequestsPerSecond: must be greater than zero"),
+		Entry("rate limit without a burst", "rate_limits", map[string]interface{}{"leases-acquire": map[string]interface{}{"requests_per_second": 0.5}}, "RateLimits[leases-acquire].Burst: must be at least 1"),
+		Entry("host identity without an identity", "host_identities", []map[string]interface{}{{"underlay_ranges": []string{"10.0.16.11"}}}, "HostIdentities[0].Identity: zero value"),
+		Entry("host identity with an invalid underlay range", "host_identities", []map[string]interface{}{{"identity": "cell-0", "underlay_ranges": []string{"10.0.16.0/20", "banana"}}}, `HostIdentities[0].UnderlayRanges: "banana" is neither an ip nor a cidr`),
+		Entry("vni too large for vxlan", "vni", 1<<24, "VNI: greater than max"),
+		Entry("partitions without a topology key", "topology", map[string]interface{}{"partitions": []map[string]string{{"value": "z1", "network": "10.255.16.0/20"}}}, `Topology.Key: pool "" has partitions but no key`),
+		Entry("partition without a value", "topology", map[string]interface{}{"key": "az", "partitions": []map[string]string{{"network": "10.255.16.0/20"}}}, "Topology.Partitions[0].Value: zero value"),
+		Entry("partition that is not a cidr", "topology", map[string]interface{}{"key": "az", "partitions": []map[string]string{{"value": "z1", "network": "banana"}}}, "Topology.Partitions: invalid CIDR address: banana"),
+		Entry("partition outside the network", "topology", map[string]interface{}{"key": "az", "partitions": []map[string]string{{"value": "z1", "network": "10.254.0.0/20"}}}, `Topology.Partitions: 10.254.0.0/20 is not inside the active networks of pool ""`),
+		Entry("partition smaller than a subnet", "topology", map[string]interface{}{"key": "az", "partitions": []map[string]string{{"value": "z1", "network": "10.255.16.0/25"}}}, `Topology.Partitions: 10.255.16.0/25 is smaller than a subnet of pool ""`),
+		Entry("duplicate partition value", "topology", map[string]interface{}{"key": "az", "partitions": []map[string]string{{"value": "z1", "network": "10.255.16.0/20"}, {"value": "z1", "network": "10.255.32.0/20"}}}, `Topology.Partitions: duplicate value "z1" in pool ""`),
+		Entry("overlapping partitions", "topology", map[string]interface{}{"key": "az", "partitions": []map[string]string{{"value": "z1", "network": "10.255.16.0/20"}, {"value": "z2", "network": "10.255.24.0/21"}}}, "Topology.Partitions: partitions 10.255.16.0/20 and 10.255.24.0/21 overlap"),
 	)
+
+	It("reads the reaper settings", func() {
+		cfg := cloneMap(requiredFields)
+		cfg["reaper"] = map[string]interface{}{
+			"policy":                        "above-utilization",
+			"interval_seconds":              30,
+			"expiry_multiple":               3,
+			"utilization_threshold_percent": 80,
+		}
+
+		file, err := ioutil.TempFile(os.TempDir(), "config-")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+		conf, err := config.ReadFromFile(file.Name())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conf.Reaper).To(Equal(config.Reaper{
+			Policy:                      "above-utilization",
+			IntervalSeconds:             30,
+			ExpiryMultiple:              3,
+			UtilizationThresholdPercent: 80,
+		}))
+	})
+
+	Context("when named pools are configured", func() {
+		var cfg map[string]interface{}
+
+		readConfig := func() (*config.Config, error) {
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+			return config.ReadFromFile(file.Name())
+		}
+
+		BeforeEach(func() {
+			cfg = cloneMap(requiredFields)
+			cfg["pools"] = []map[string]interface{}{
+				{"name": "blue", "network": "10.250.0.0/16", "subnet_prefix_length": 24, "lease_expiration_seconds": 60, "vni": 7},
+				{"name": "green", "network": "10.251.0.0/16", "subnet_prefix_length": 26, "allocation_strategy": "maximally-spread", "quarantine_seconds": 30},
+			}
+			cfg["allocation_strategy"] = "lowest-free-first"
+			cfg["quarantine_seconds"] = 300
+			cfg["vni"] = 1
+		})
+
+		It("returns the default pool followed by the named pools", func() {
+			conf, err := readConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.LeasePools()).To(Equal([]config.Pool{
+				{Name: "", Network: "10.255.0.0/16", SubnetPrefixLength: 24, LeaseExpirationSeconds: 12, QuarantineSeconds: 300, AllocationStrategy: "lowest-free-first", VNI: 1},
+				{Name: "blue", Network: "10.250.0.0/16", SubnetPrefixLength: 24, LeaseExpirationSeconds: 60, QuarantineSeconds: 300, AllocationStrategy: "lowest-free-first", VNI: 7},
+				{Name: "green", Network: "10.251.0.0/16", SubnetPrefixLength: 26, LeaseExpirationSeconds: 12, QuarantineSeconds: 30, AllocationStrategy: "maximally-spread"},
+			}))
+		})
+
+		DescribeTable("rejects invalid pools",
+			func(pool map[string]interface{}, errorString string) {
+				cfg["pools"] = []map[string]interface{}{
+					{"name": "blue", "network": "10.250.0.0/16", "subnet_prefix_length": 24},
+					pool,
+				}
+				_, err := readConfig()
+				Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("invalid config: %s", errorString))))
+			},
+			Entry("missing name", map[string]interface{}{"network": "10.251.0.0/16", "subnet_prefix_length": 24}, "Pools[1].Name: zero value"),
+			Entry("missing network", map[string]interface{}{"name": "green", "subnet_prefix_length": 24}, "Pools[1].Network: zero value"),
+			Entry("duplicate name", map[string]interface{}{"name": "blue", "network": "10.251.0.0/16", "subnet_prefix_length": 24}, `Pools: duplicate pool name "blue"`),
+			Entry("overlapping network", map[string]interface{}{"name": "green", "network": "10.255.128.0/17", "subnet_prefix_length": 24}, "Pools: networks 10.255.0.0/16 and 10.255.128.0/17 overlap"),
+			Entry("invalid network", map[string]interface{}{"name": "green", "network": "banana", "subnet_prefix_length": 24}, "Network: invalid CIDR address: banana"),
+			Entry("invalid cidr", map[string]interface{}{"name": "green", "cidrs": []map[string]string{{"network": "banana"}}, "subnet_prefix_length": 24}, "Network: invalid CIDR address: banana"),
+			Entry("unknown cidr state", map[string]interface{}{"name": "green", "cidrs": []map[string]string{{"network": "10.251.0.0/16", "state": "retired"}}, "subnet_prefix_length": 24}, `CIDRs: unknown state "retired" for 10.251.0.0/16`),
+			Entry("no active cidr", map[string]interface{}{"name": "green", "cidrs": []map[string]string{{"network": "10.251.0.0/16", "state": "draining"}}, "subnet_prefix_length": 24}, `CIDRs: pool "green" has no active network`),
+			Entry("overlapping cidr", map[string]interface{}{"name": "green", "network": "10.251.0.0/16", "cidrs": []map[string]string{{"network": "10.250.128.0/17", "state": "draining"}}, "subnet_prefix_length": 24}, "Pools: networks 10.250.0.0/16 and 10.250.128.0/17 overlap"),
+			Entry("excluded range in another pool", map[string]interface{}{"name": "green", "network": "10.251.0.0/16", "excluded_ranges": []string{"10.250.1.0/24"}, "subnet_prefix_length": 24}, `ExcludedRanges: 10.250.1.0/24 is not inside the networks of pool "green"`),
+		)
+	})
+
+	Context("when a pool has several cidrs", func() {
+		It("returns the network first, followed by the cidrs", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["cidrs"] = []map[string]string{
+				{"network": "10.254.0.0/16", "state": "draining"},
+				{"network": "10.253.0.0/16"},
+			}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.LeasePools()[0].Networks()).To(Equal([]config.CIDR{
+				{Network: "10.255.0.0/16", State: "active"},
+				{Network: "10.254.0.0/16", State: "draining"},
+				{Network: "10.253.0.0/16", State: "active"},
+			}))
+		})
+
+		It("does not need the network when the cidrs are configured", func() {
+			cfg := cloneMap(requiredFields)
+			delete(cfg, "network")
+			cfg["cidrs"] = []map[string]string{
+				{"network": "10.255.0.0/16", "state": "draining"},
+				{"network": "10.254.0.0/16", "state": "active"},
+			}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.LeasePools()[0].Networks()).To(Equal([]config.CIDR{
+				{Network: "10.255.0.0/16", State: "draining"},
+				{Network: "10.254.0.0/16", State: "active"},
+			}))
+		})
+	})
+
+	Context("when excluded ranges are configured", func() {
+		It("accepts ranges inside any network of the pool", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["cidrs"] = []map[string]string{{"network": "10.254.0.0/16", "state": "draining"}}
+			cfg["network_v6"] = "fd00:255::/48"
+			cfg["subnet_prefix_length_v6"] = 64
+			cfg["excluded_ranges"] = []string{"10.255.1.0/24", "10.254.0.0/16", "fd00:255:0:1::/64"}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.LeasePools()[0].ExcludedRanges).To(Equal([]string{"10.255.1.0/24", "10.254.0.0/16", "fd00:255:0:1::/64"}))
+		})
+	})
+
+	Context("when a topology is configured", func() {
+		It("passes it to the default pool", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["topology"] = map[string]interface{}{
+				"key": "az",
+				"partitions": []map[string]string{
+					{"value": "z1", "network": "10.255.16.0/20"},
+					{"value": "z2", "network": "10.255.32.0/20"},
+				},
+			}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.LeasePools()[0].Topology).To(Equal(config.Topology{
+				Key: "az",
+				Partitions: []config.Partition{
+					{Value: "z1", Network: "10.255.16.0/20"},
+					{Value: "z2", Network: "10.255.32.0/20"},
+				},
+			}))
+		})
+	})
+
+	Context("when admin identities are configured", func() {
+		It("reads them", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["admin_identities"] = []string{"silk-admin", "ops.example.com"}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.AdminIdentities).To(Equal([]string{"silk-admin", "ops.example.com"}))
+		})
+	})
+
+	Context("when leader election is configured", func() {
+		It("reads it", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["leader_election"] = map[string]interface{}{"enabled": true, "identity": "controller-0", "heartbeat_seconds": 2}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.LeaderElection).To(Equal(config.LeaderElection{Enabled: true, Identity: "controller-0", HeartbeatSeconds: 2}))
+		})
+
+		It("is off by default", func() {
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(requiredFields)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.LeaderElection.Enabled).To(BeFalse())
+		})
+	})
+
+	Context("when rate limits are configured", func() {
+		It("reads them", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["rate_limits"] = map[string]interface{}{
+				"leases-acquire": map[string]interface{}{"requests_per_second": 0.2, "burst": 3},
+			}
+			cfg["max_in_flight_acquires"] = 20
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.RateLimits).To(Equal(map[string]config.RateLimit{
+				"leases-acquire": {RequestsPerSecond: 0.2, Burst: 3},
+			}))
+			Expect(conf.MaxInFlightAcquires).To(Equal(20))
+		})
+	})
+
+	Context("when host identities are configured", func() {
+		It("reads them and merges the ranges of repeated identities", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["host_identities"] = []map[string]interface{}{
+				{"identity": "cell-0.example.com", "underlay_ranges": []string{"10.0.16.11"}},
+				{"identity": "z1-cells", "underlay_ranges": []string{"10.0.32.0/20"}},
+				{"identity": "z1-cells", "underlay_ranges": []string{"fd00:244::/64"}},
+			}
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.HostIdentities).To(HaveLen(3))
+			Expect(conf.HostUnderlayRanges()).To(Equal(map[string][]string{
+				"cell-0.example.com": {"10.0.16.11"},
+				"z1-cells":           {"10.0.32.0/20", "fd00:244::/64"},
+			}))
+		})
+	})
+
+	Context("when an ipv6 network is configured", func() {
+		It("reads the network and prefix length", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["network_v6"] = "fd00:255::/48"
+			cfg["subnet_prefix_length_v6"] = 64
+
+			file, err := ioutil.TempFile(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			conf, err := config.ReadFromFile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.NetworkV6).To(Equal("fd00:255::/48"))
+			Expect(conf.SubnetPrefixLengthV6).To(Equal(64))
+		})
+	})
 })
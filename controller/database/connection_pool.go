@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/db"
+	"code.cloudfoundry.org/cf-networking-helpers/db/monitor"
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// NewConnectionPool connects to the database of the config. A sqlite3
+// database is the file at the path in database_name, created if it does not
+// exist yet; every other type is connected to by db.NewConnectionPool.
+func NewConnectionPool(conf db.Config,
+	maxOpenConnections int, maxIdleConnections int, connMaxLifetime time.Duration,
+	logPrefix string, jobPrefix string, logger lager.Logger,
+) (*db.ConnWrapper, error) {
+	if conf.Type != SQLite {
+		return db.NewConnectionPool(conf, maxOpenConnections, maxIdleConnections, connMaxLifetime, logPrefix, jobPrefix, logger)
+	}
+
+	logger.Info("opening sqlite database", lager.Data{"path": conf.DatabaseName})
+	connectionPool, err := openSQLite(conf)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: db connect: %s", logPrefix, jobPrefix, err)
+	}
+
+	connectionPool.SetMaxOpenConns(maxOpenConnections)
+	connectionPool.SetMaxIdleConns(maxIdleConnections)
+	connectionPool.SetConnMaxLifetime(connMaxLifetime)
+	logger.Info("db connection retrieved", lager.Data{})
+
+	return connectionPool, nil
+}
+
+// openSQLite opens the database in write-ahead logging mode, so that reads do
+// not wait for writes, and begins every transaction by taking the write lock,
+// which stands in for the row locks sqlite3 does not have. A connection
+// waits up to the timeout of the config for the lock.
+func openSQLite(conf db.Config) (*db.ConnWrapper, error) {
+	if conf.DatabaseName == "" {
+		return nil, errors.New("database_name must be the path of the sqlite3 database file")
+	}
+	params := url.Values{}
+	params.Set("_busy_timeout", fmt.Sprintf("%d", (time.Duration(conf.Timeout)*time.Second).Milliseconds()))
+	params.Set("_journal_mode", "WAL")
+	params.Set("_txlock", "immediate")
+
+	nativeDBConn, err := sql.Open(SQLite, fmt.Sprintf("file:%s?%s", conf.DatabaseName, params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database connection: %s", err)
+	}
+	dbConn := sqlx.NewDb(nativeDBConn, SQLite)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(conf.Timeout)*time.Second)
+	defer cancel()
+	if err = dbConn.PingContext(ctx); err != nil {
+		dbConn.Close()
+		return nil, fmt.Errorf("unable to ping: %s", err)
+	}
+
+	return &db.ConnWrapper{
+		DB:      dbConn,
+		Monitor: monitor.New(),
+	}, nil
+}
@@ -2,8 +2,10 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"code.cloudfoundry.org/silk/controller"
 	"github.com/jmoiron/sqlx"
@@ -12,8 +14,20 @@ import (
 
 const postgresTimeNow = "EXTRACT(EPOCH FROM now())::numeric::integer"
 const mysqlTimeNow = "UNIX_TIMESTAMP()"
+const sqliteTimeNow = "CAST(strftime('%s', 'now') AS INTEGER)"
 const MySQL = "mysql"
 const Postgres = "postgres"
+const SQLite = "sqlite3"
+const Memory = "memory"
+
+const leaseColumns = "underlay_ip, overlay_subnet, overlay_subnet_v6, overlay_hwaddr, pool"
+
+// maxLeaseEvents caps the number of events returned by one query.
+const maxLeaseEvents = 1000
+
+// leaderName names the row of the leaders table that the controllers sharing
+// the database elect their leader with.
+const leaderName = "silk-controller"
 
 var RecordNotAffectedError = errors.New("record not affected")
 
@@ -27,6 +41,59 @@ type Db interface {
 	RawConnection() *sqlx.DB
 }
 
+// executor runs queries either directly on the database or inside a
+// transaction.
+type executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Rebind(query string) string
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	DriverName() string
+}
+
+// LeaseStore is the part of a DatabaseHandler that is available inside
+// WithAllocationLock.
+type LeaseStore interface {
+	AddEntry(controller.Lease) error
+	DeleteEntry(string) error
+	DeleteExpiredEntry(string, int) error
+	Generation() (int64, error)
+	LeaseForUnderlayIP(string) (*controller.Lease, error)
+	AllSingleIPSubnets() ([]controller.Lease, error)
+	AllBlockSubnets() ([]controller.Lease, error)
+	AllBlockSubnetsV6() ([]controller.Lease, error)
+	AllReservations() ([]controller.Reservation, error)
+	ReservationForUnderlayIP(string) (*controller.Reservation, error)
+	AddReservation(controller.Reservation) error
+	OldestExpiredBlockSubnet(int) (*controller.Lease, error)
+	OldestExpiredBlockSubnetV6(int) (*controller.Lease, error)
+	OldestExpiredSingleIP(int) (*controller.Lease, error)
+	AddEvent(controller.LeaseEvent) error
+	SetHostForUnderlayIP(string, controller.HostMetadata) error
+	QuarantineSubnet(string, string, int) error
+	QuarantinedSubnets() ([]controller.QuarantinedSubnet, error)
+}
+
+// Store is everything the controller keeps: a DatabaseHandler, or a
+// MemoryStore for controllers that need not keep it across restarts.
+type Store interface {
+	LeaseStore
+	ForPool(string) Store
+	WithAllocationLock(func(LeaseStore) error) error
+	Migrate() (int, error)
+	CheckDatabase() error
+	All() ([]controller.Lease, error)
+	AllActive(int) ([]controller.Lease, error)
+	AllExpired(int) ([]controller.Lease, error)
+	LeaseRecords(int) ([]controller.LeaseRecord, error)
+	RenewLeaseForUnderlayIP(string) error
+	LastRenewedAtForUnderlayIP(string) (int64, error)
+	DeleteReservation(string) error
+	Events(controller.LeaseEventFilter) ([]controller.LeaseEvent, error)
+	ClaimLeadership(string, int) (string, error)
+	ResignLeadership(string) error
+}
+
 //go:generate counterfeiter -o fakes/migrateAdapter.go --fake-name MigrateAdapter . migrateAdapter
 type migrateAdapter interface {
 	Exec(db Db, dialect string, m migrate.MigrationSource, dir migrate.MigrationDirection) (int, error)
@@ -36,6 +103,9 @@ type DatabaseHandler struct {
 	migrator   migrateAdapter
 	migrations *migrate.MemoryMigrationSource
 	db         Db
+	conn       executor
+	pool       *string
+	locked     bool
 }
 
 func NewDatabaseHandler(migrator migrateAdapter, db Db) *DatabaseHandler {
@@ -48,9 +118,59 @@ func NewDatabaseHandler(migrator migrateAdapter, db Db) *DatabaseHandler {
 					Up:   []string{createSubnetTable(db.DriverName())},
 					Down: []string{"DROP TABLE subnets"},
 				},
+				{
+					Id:   "2",
+					Up:   addIPv6Columns(db.DriverName()),
+					Down: dropIPv6Columns(db.DriverName()),
+				},
+				{
+					Id:   "3",
+					Up:   []string{"ALTER TABLE subnets ADD COLUMN pool varchar(255) NOT NULL DEFAULT ''"},
+					Down: []string{"ALTER TABLE subnets DROP COLUMN pool"},
+				},
+				{
+					Id:   "4",
+					Up:   []string{createReservationsTable(db.DriverName())},
+					Down: []string{"DROP TABLE reservations"},
+				},
+				{
+					Id:   "5",
+					Up:   []string{"CREATE TABLE IF NOT EXISTS allocation_locks (pool varchar(255) NOT NULL, PRIMARY KEY (pool));"},
+					Down: []string{"DROP TABLE allocation_locks"},
+				},
+				{
+					Id: "6",
+					Up: []string{
+						createLeaseEventsTable(db.DriverName()),
+						"CREATE INDEX lease_events_underlay_ip ON lease_events (underlay_ip, created_at)",
+						"CREATE INDEX lease_events_overlay_subnet ON lease_events (overlay_subnet, created_at)",
+					},
+					Down: []string{"DROP TABLE lease_events"},
+				},
+				{
+					Id:   "7",
+					Up:   []string{createQuarantinedSubnetsTable(db.DriverName())},
+					Down: []string{"DROP TABLE quarantined_subnets"},
+				},
+				{
+					Id:   "8",
+					Up:   []string{"CREATE TABLE IF NOT EXISTS leaders (name varchar(255) NOT NULL, holder varchar(255) NOT NULL, renewed_at bigint NOT NULL, PRIMARY KEY (name));"},
+					Down: []string{"DROP TABLE leaders"},
+				},
+				{
+					Id:   "9",
+					Up:   []string{"ALTER TABLE subnets ADD COLUMN host text"},
+					Down: []string{"ALTER TABLE subnets DROP COLUMN host"},
+				},
+				{
+					Id:   "10",
+					Up:   []string{"ALTER TABLE allocation_locks ADD COLUMN generation bigint NOT NULL DEFAULT 0"},
+					Down: []string{"ALTER TABLE allocation_locks DROP COLUMN generation"},
+				},
 			},
 		},
-		db: db,
+		db:   db,
+		conn: db,
 	}
 }
 
@@ -59,13 +179,94 @@ func (d *DatabaseHandler) CheckDatabase() error {
 	return d.db.QueryRow("SELECT 1").Scan(&result)
 }
 
-func (d *DatabaseHandler) All() ([]controller.Lease, error) {
-	rows, err := d.db.Query("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets")
+// ForPool returns a handler whose queries over many leases only see the leases
+// of the named pool. Lookups by underlay ip are not scoped, since an underlay ip
+// holds at most one lease across all pools.
+func (d *DatabaseHandler) ForPool(pool string) Store {
+	scoped := *d
+	scoped.pool = &pool
+	return &scoped
+}
+
+// WithAllocationLock runs f in a single transaction that holds the allocation
+// lock of the handler's pool, and commits it if f succeeds. Acquisitions by
+// every controller sharing the database take turns, so f sees all leases
+// committed before it and can add its own without racing another acquisition.
+func (d *DatabaseHandler) WithAllocationLock(f func(LeaseStore) error) error {
+	pool := d.poolName()
+
+	insertLock, err := insertIgnoreForDriver(d.db.DriverName(), "INSERT INTO allocation_locks (pool) VALUES (?)")
 	if err != nil {
-		return nil, fmt.Errorf("selecting all subnets: %s", err)
+		return err
 	}
-	defer rows.Close() // untested
-	leases, err := rowsToLeases(rows)
+	_, err = d.db.Exec(d.db.Rebind(insertLock), pool)
+	if err != nil {
+		return fmt.Errorf("creating allocation lock: %s", err)
+	}
+
+	tx, err := d.db.RawConnection().Beginx()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %s", err)
+	}
+
+	var lockedPool string
+	err = tx.QueryRow(tx.Rebind("SELECT pool FROM allocation_locks WHERE pool = ?"+forUpdateForDriver(d.db.DriverName())), pool).Scan(&lockedPool)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("taking allocation lock: %s", err)
+	}
+
+	locked := *d
+	locked.conn = tx
+	locked.locked = true
+	err = f(&locked)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %s", err)
+	}
+	return nil
+}
+
+// Generation counts the changes to the leases of the handler's pool made by
+// AddEntry, DeleteEntry and DeleteExpiredEntry since its allocation lock was
+// first taken. A controller that keeps the leases of the pool in memory reads
+// them again only when the generation differs from the one it last saw.
+func (d *DatabaseHandler) Generation() (int64, error) {
+	var generation int64
+	err := d.conn.QueryRow(d.conn.Rebind("SELECT generation FROM allocation_locks WHERE pool = ?"), d.poolName()).Scan(&generation)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("selecting generation: %s", err)
+	}
+	return generation, nil
+}
+
+// bumpGeneration counts a change to the leases of the pools that the
+// condition selects.
+func (d *DatabaseHandler) bumpGeneration(condition string, args ...interface{}) error {
+	_, err := d.conn.Exec(d.conn.Rebind("UPDATE allocation_locks SET generation = generation + 1 WHERE "+condition), args...)
+	if err != nil {
+		return fmt.Errorf("updating generation: %s", err)
+	}
+	return nil
+}
+
+func (d *DatabaseHandler) poolName() string {
+	if d.pool == nil {
+		return ""
+	}
+	return *d.pool
+}
+
+func (d *DatabaseHandler) All() ([]controller.Lease, error) {
+	leases, err := d.selectLeases()
 	if err != nil {
 		return nil, fmt.Errorf("selecting all subnets: %s", err)
 	}
@@ -74,12 +275,7 @@ func (d *DatabaseHandler) All() ([]controller.Lease, error) {
 }
 
 func (d *DatabaseHandler) AllSingleIPSubnets() ([]controller.Lease, error) {
-	rows, err := d.db.Query("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE overlay_subnet LIKE '%/32'")
-	if err != nil {
-		return nil, fmt.Errorf("selecting all single ip subnets: %s", err)
-	}
-	defer rows.Close() // untested
-	leases, err := rowsToLeases(rows)
+	leases, err := d.selectLeases("overlay_subnet LIKE '%/32'")
 	if err != nil {
 		return nil, fmt.Errorf("selecting all single ip subnets: %s", err)
 	}
@@ -88,14 +284,18 @@ func (d *DatabaseHandler) AllSingleIPSubnets() ([]controller.Lease, error) {
 }
 
 func (d *DatabaseHandler) AllBlockSubnets() ([]controller.Lease, error) {
-	rows, err := d.db.Query("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE overlay_subnet NOT LIKE '%/32'")
+	leases, err := d.selectLeases("overlay_subnet NOT LIKE '%/32'")
 	if err != nil {
 		return nil, fmt.Errorf("selecting all block subnets: %s", err)
 	}
-	defer rows.Close() // untested
-	leases, err := rowsToLeases(rows)
+
+	return leases, nil
+}
+
+func (d *DatabaseHandler) AllBlockSubnetsV6() ([]controller.Lease, error) {
+	leases, err := d.selectLeases("overlay_subnet_v6 IS NOT NULL")
 	if err != nil {
-		return nil, fmt.Errorf("selecting all block subnets: %s", err)
+		return nil, fmt.Errorf("selecting all ipv6 subnets: %s", err)
 	}
 
 	return leases, nil
@@ -106,12 +306,7 @@ func (d *DatabaseHandler) AllActive(duration int) ([]controller.Lease, error) {
 	if err != nil {
 		return nil, err
 	}
-	rows, err := d.db.Query(fmt.Sprintf("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE last_renewed_at + %d > %s", duration, timestamp))
-	if err != nil {
-		return nil, fmt.Errorf("selecting all active subnets: %s", err)
-	}
-	defer rows.Close() // untested
-	leases, err := rowsToLeases(rows)
+	leases, err := d.selectLeases(fmt.Sprintf("last_renewed_at + %d > %s", duration, timestamp))
 	if err != nil {
 		return nil, fmt.Errorf("selecting all active subnets: %s", err)
 	}
@@ -119,48 +314,123 @@ func (d *DatabaseHandler) AllActive(duration int) ([]controller.Lease, error) {
 	return leases, nil
 }
 
-func (d *DatabaseHandler) OldestExpiredBlockSubnet(expirationTime int) (*controller.Lease, error) {
+// LeaseRecords returns every lease with the time it was last renewed, marking
+// those not renewed within the expiration time as expired.
+func (d *DatabaseHandler) LeaseRecords(expirationTime int) ([]controller.LeaseRecord, error) {
 	timestamp, err := timestampForDriver(d.db.DriverName())
 	if err != nil {
 		return nil, err
 	}
 
-	var underlayIP, overlaySubnet, overlayHWAddr string
-	result := d.db.QueryRow(fmt.Sprintf("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE overlay_subnet NOT LIKE '%%/32' AND last_renewed_at + %d <= %s ORDER BY last_renewed_at ASC LIMIT 1", expirationTime, timestamp))
-	err = result.Scan(&underlayIP, &overlaySubnet, &overlayHWAddr)
+	where, args := d.where()
+	query := fmt.Sprintf("SELECT %s, last_renewed_at, CASE WHEN last_renewed_at + %d <= %s THEN 1 ELSE 0 END, host FROM subnets%s ORDER BY pool, underlay_ip",
+		leaseColumns, expirationTime, timestamp, where)
+	rows, err := d.conn.Query(d.conn.Rebind(query), args...)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+		return nil, fmt.Errorf("selecting lease records: %s", err)
+	}
+	defer rows.Close() // untested
+
+	records := []controller.LeaseRecord{}
+	for rows.Next() {
+		var record controller.LeaseRecord
+		var overlaySubnet, overlaySubnetV6, host sql.NullString
+		var expired int
+		err := rows.Scan(&record.UnderlayIP, &overlaySubnet, &overlaySubnetV6, &record.OverlayHardwareAddr, &record.Pool, &record.LastRenewedAt, &expired, &host)
+		if err != nil {
+			return nil, fmt.Errorf("selecting lease records: parsing result: %s", err)
 		}
-		return nil, fmt.Errorf("scan result: %s", err)
+		record.OverlaySubnet = overlaySubnet.String
+		record.OverlaySubnetV6 = overlaySubnetV6.String
+		record.Expired = expired == 1
+		if host.Valid {
+			record.Host = &controller.HostMetadata{}
+			err = json.Unmarshal([]byte(host.String), record.Host)
+			if err != nil {
+				return nil, fmt.Errorf("selecting lease records: parsing host of %s: %s", record.UnderlayIP, err)
+			}
+		}
+		records = append(records, record)
 	}
-	return &controller.Lease{
-		UnderlayIP:          underlayIP,
-		OverlaySubnet:       overlaySubnet,
-		OverlayHardwareAddr: overlayHWAddr,
-	}, nil
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("selecting lease records: getting next row: %s", err) // untested
+	}
+	return records, nil
+}
+
+// AllExpired returns every lease not renewed within expirationTime seconds,
+// reserved or not.
+func (d *DatabaseHandler) AllExpired(expirationTime int) ([]controller.Lease, error) {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return nil, err
+	}
+	leases, err := d.selectLeases(fmt.Sprintf("last_renewed_at + %d <= %s", expirationTime, timestamp))
+	if err != nil {
+		return nil, fmt.Errorf("selecting all expired subnets: %s", err)
+	}
+
+	return leases, nil
+}
+
+// OldestExpiredBlockSubnet never returns a reserved subnet, so that it stays
+// with the underlay ip it is reserved for.
+func (d *DatabaseHandler) OldestExpiredBlockSubnet(expirationTime int) (*controller.Lease, error) {
+	return d.oldestExpired("overlay_subnet NOT LIKE '%/32' AND overlay_subnet NOT IN (SELECT overlay_subnet FROM reservations)", expirationTime)
+}
+
+func (d *DatabaseHandler) OldestExpiredBlockSubnetV6(expirationTime int) (*controller.Lease, error) {
+	return d.oldestExpired("overlay_subnet_v6 IS NOT NULL", expirationTime)
 }
 
 func (d *DatabaseHandler) OldestExpiredSingleIP(expirationTime int) (*controller.Lease, error) {
+	return d.oldestExpired("overlay_subnet LIKE '%/32'", expirationTime)
+}
+
+func (d *DatabaseHandler) oldestExpired(condition string, expirationTime int) (*controller.Lease, error) {
 	timestamp, err := timestampForDriver(d.db.DriverName())
 	if err != nil {
 		return nil, err
 	}
 
-	var underlayIP, overlaySubnet, overlayHWAddr string
-	result := d.db.QueryRow(fmt.Sprintf("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE overlay_subnet LIKE '%%/32' AND last_renewed_at + %d <= %s ORDER BY last_renewed_at ASC LIMIT 1", expirationTime, timestamp))
-	err = result.Scan(&underlayIP, &overlaySubnet, &overlayHWAddr)
+	where, args := d.where(condition, fmt.Sprintf("last_renewed_at + %d <= %s", expirationTime, timestamp))
+	query := "SELECT " + leaseColumns + " FROM subnets" + where + " ORDER BY last_renewed_at ASC LIMIT 1"
+	if d.locked {
+		// keeps a concurrent renewal from reviving the lease while it is reclaimed
+		query += forUpdateForDriver(d.db.DriverName())
+	}
+	result := d.conn.QueryRow(d.conn.Rebind(query), args...)
+	lease, err := scanLease(result)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("scan result: %s", err)
 	}
-	return &controller.Lease{
-		UnderlayIP:          underlayIP,
-		OverlaySubnet:       overlaySubnet,
-		OverlayHardwareAddr: overlayHWAddr,
-	}, nil
+	return &lease, nil
+}
+
+func (d *DatabaseHandler) selectLeases(conditions ...string) ([]controller.Lease, error) {
+	where, args := d.where(conditions...)
+	rows, err := d.conn.Query(d.conn.Rebind("SELECT "+leaseColumns+" FROM subnets"+where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() // untested
+	return rowsToLeases(rows)
+}
+
+func (d *DatabaseHandler) where(conditions ...string) (string, []interface{}) {
+	var args []interface{}
+	if d.pool != nil {
+		conditions = append(conditions, "pool = ?")
+		args = append(args, *d.pool)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
 }
 
 func (d *DatabaseHandler) Migrate() (int, error) {
@@ -178,16 +448,54 @@ func (d *DatabaseHandler) AddEntry(lease controller.Lease) error {
 		return err
 	}
 
-	_, err = d.db.Exec(d.db.Rebind(fmt.Sprintf("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, %s)", timestamp)), lease.UnderlayIP, lease.OverlaySubnet, lease.OverlayHardwareAddr)
+	_, err = d.conn.Exec(d.conn.Rebind(fmt.Sprintf("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_subnet_v6, overlay_hwaddr, pool, last_renewed_at) VALUES (?, ?, ?, ?, ?, %s)", timestamp)), lease.UnderlayIP, nullableString(lease.OverlaySubnet), nullableString(lease.OverlaySubnetV6), lease.OverlayHardwareAddr, lease.Pool)
 	if err != nil {
 		return fmt.Errorf("adding entry: %s", err)
 	}
-	return nil
+	return d.bumpGeneration("pool = ?", lease.Pool)
 }
 
+// DeleteEntry deletes the lease of the underlay ip, counting the change in the
+// generation of its pool first, while the lease still tells the pool.
 func (d *DatabaseHandler) DeleteEntry(underlayIP string) error {
-	deleteRows, err := d.db.Exec(d.db.Rebind("DELETE FROM subnets WHERE underlay_ip = ?"), underlayIP)
+	err := d.bumpGeneration("pool IN (SELECT pool FROM subnets WHERE underlay_ip = ?)", underlayIP)
+	if err != nil {
+		return err
+	}
+
+	deleteRows, err := d.conn.Exec(d.conn.Rebind("DELETE FROM subnets WHERE underlay_ip = ?"), underlayIP)
+
+	if err != nil {
+		return fmt.Errorf("deleting entry: %s", err)
+	}
+
+	rowsAffected, err := deleteRows.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("parse result: %s", err)
+	}
+
+	if rowsAffected == 0 {
+		return RecordNotAffectedError
+	}
+
+	return nil
+}
+
+// DeleteExpiredEntry deletes the lease of the underlay ip only if it is still
+// expired, so that a lease renewed since it was found expired is kept.
+func (d *DatabaseHandler) DeleteExpiredEntry(underlayIP string, expirationTime int) error {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return err
+	}
+
+	expired := fmt.Sprintf("underlay_ip = ? AND last_renewed_at + %d <= %s", expirationTime, timestamp)
+	err = d.bumpGeneration("pool IN (SELECT pool FROM subnets WHERE "+expired+")", underlayIP)
+	if err != nil {
+		return err
+	}
 
+	deleteRows, err := d.conn.Exec(d.conn.Rebind("DELETE FROM subnets WHERE "+expired), underlayIP)
 	if err != nil {
 		return fmt.Errorf("deleting entry: %s", err)
 	}
@@ -205,20 +513,15 @@ func (d *DatabaseHandler) DeleteEntry(underlayIP string) error {
 }
 
 func (d *DatabaseHandler) LeaseForUnderlayIP(underlayIP string) (*controller.Lease, error) {
-	var overlaySubnet, overlayHWAddr string
-	result := d.db.QueryRow(d.db.Rebind("SELECT overlay_subnet, overlay_hwaddr FROM subnets WHERE underlay_ip = ?"), underlayIP)
-	err := result.Scan(&overlaySubnet, &overlayHWAddr)
+	result := d.conn.QueryRow(d.conn.Rebind("SELECT "+leaseColumns+" FROM subnets WHERE underlay_ip = ?"), underlayIP)
+	lease, err := scanLease(result)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err // test me
 	}
-	return &controller.Lease{
-		UnderlayIP:          underlayIP,
-		OverlaySubnet:       overlaySubnet,
-		OverlayHardwareAddr: overlayHWAddr,
-	}, nil
+	return &lease, nil
 }
 
 func (d *DatabaseHandler) RenewLeaseForUnderlayIP(underlayIP string) error {
@@ -227,16 +530,42 @@ func (d *DatabaseHandler) RenewLeaseForUnderlayIP(underlayIP string) error {
 		return err
 	}
 
-	_, err = d.db.Exec(d.db.Rebind(fmt.Sprintf("UPDATE subnets SET last_renewed_at = %s WHERE underlay_ip = ?", timestamp)), underlayIP)
+	_, err = d.conn.Exec(d.conn.Rebind(fmt.Sprintf("UPDATE subnets SET last_renewed_at = %s WHERE underlay_ip = ?", timestamp)), underlayIP)
 	if err != nil {
 		return fmt.Errorf("renewing lease: %s", err)
 	}
 	return nil
 }
 
+// SetHostForUnderlayIP stores the metadata of the host of the lease. It
+// returns RecordNotAffectedError when there is no lease or it already has
+// that metadata.
+func (d *DatabaseHandler) SetHostForUnderlayIP(underlayIP string, host controller.HostMetadata) error {
+	encoded, err := json.Marshal(host)
+	if err != nil {
+		return fmt.Errorf("encoding host: %s", err)
+	}
+
+	result, err := d.conn.Exec(d.conn.Rebind("UPDATE subnets SET host = ? WHERE underlay_ip = ? AND (host IS NULL OR host <> ?)"), string(encoded), underlayIP, string(encoded))
+	if err != nil {
+		return fmt.Errorf("setting host: %s", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("parse result: %s", err)
+	}
+
+	if rowsAffected == 0 {
+		return RecordNotAffectedError
+	}
+
+	return nil
+}
+
 func (d *DatabaseHandler) LastRenewedAtForUnderlayIP(underlayIP string) (int64, error) {
 	var lastRenewedAt int64
-	result := d.db.QueryRow(d.db.Rebind("SELECT last_renewed_at FROM subnets WHERE underlay_ip = ?"), underlayIP)
+	result := d.conn.QueryRow(d.conn.Rebind("SELECT last_renewed_at FROM subnets WHERE underlay_ip = ?"), underlayIP)
 	err := result.Scan(&lastRenewedAt)
 	if err != nil {
 		return 0, err
@@ -244,19 +573,253 @@ func (d *DatabaseHandler) LastRenewedAtForUnderlayIP(underlayIP string) (int64,
 	return lastRenewedAt, nil
 }
 
+func (d *DatabaseHandler) AddReservation(reservation controller.Reservation) error {
+	_, err := d.conn.Exec(d.conn.Rebind("INSERT INTO reservations (underlay_ip, overlay_subnet, pool) VALUES (?, ?, ?)"), reservation.UnderlayIP, reservation.OverlaySubnet, reservation.Pool)
+	if err != nil {
+		return fmt.Errorf("adding reservation: %s", err)
+	}
+	return nil
+}
+
+func (d *DatabaseHandler) DeleteReservation(underlayIP string) error {
+	deleteRows, err := d.conn.Exec(d.conn.Rebind("DELETE FROM reservations WHERE underlay_ip = ?"), underlayIP)
+	if err != nil {
+		return fmt.Errorf("deleting reservation: %s", err)
+	}
+
+	rowsAffected, err := deleteRows.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("parse result: %s", err)
+	}
+
+	if rowsAffected == 0 {
+		return RecordNotAffectedError
+	}
+
+	return nil
+}
+
+func (d *DatabaseHandler) ReservationForUnderlayIP(underlayIP string) (*controller.Reservation, error) {
+	var reservation controller.Reservation
+	result := d.conn.QueryRow(d.conn.Rebind("SELECT underlay_ip, overlay_subnet, pool FROM reservations WHERE underlay_ip = ?"), underlayIP)
+	err := result.Scan(&reservation.UnderlayIP, &reservation.OverlaySubnet, &reservation.Pool)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan result: %s", err)
+	}
+	return &reservation, nil
+}
+
+// AddEvent records the event with the database's current time, whatever
+// the timestamp of the event.
+func (d *DatabaseHandler) AddEvent(event controller.LeaseEvent) error {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO lease_events (event_type, underlay_ip, overlay_subnet, overlay_subnet_v6, pool, actor, reason, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, %s)", timestamp)
+	_, err = d.conn.Exec(d.conn.Rebind(query), event.Type, event.UnderlayIP, nullableString(event.OverlaySubnet), nullableString(event.OverlaySubnetV6), event.Pool, event.Actor, event.Reason)
+	if err != nil {
+		return fmt.Errorf("adding event: %s", err)
+	}
+	return nil
+}
+
+// Events returns the oldest events that match the filter, up to
+// maxLeaseEvents of them, in the order they happened.
+func (d *DatabaseHandler) Events(filter controller.LeaseEventFilter) ([]controller.LeaseEvent, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.UnderlayIP != "" {
+		conditions = append(conditions, "underlay_ip = ?")
+		args = append(args, filter.UnderlayIP)
+	}
+	if filter.OverlaySubnet != "" {
+		conditions = append(conditions, "(overlay_subnet = ? OR overlay_subnet_v6 = ?)")
+		args = append(args, filter.OverlaySubnet, filter.OverlaySubnet)
+	}
+	if filter.Since != 0 {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.Since)
+	}
+	if filter.Until != 0 {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.Until)
+	}
+	where, poolArgs := d.where(conditions...)
+	args = append(args, poolArgs...)
+
+	query := fmt.Sprintf("SELECT event_type, underlay_ip, overlay_subnet, overlay_subnet_v6, pool, actor, reason, created_at FROM lease_events%s ORDER BY created_at ASC, id ASC LIMIT %d", where, maxLeaseEvents)
+	rows, err := d.conn.Query(d.conn.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("selecting events: %s", err)
+	}
+	defer rows.Close() // untested
+
+	events := []controller.LeaseEvent{}
+	for rows.Next() {
+		var event controller.LeaseEvent
+		var overlaySubnet, overlaySubnetV6 sql.NullString
+		err := rows.Scan(&event.Type, &event.UnderlayIP, &overlaySubnet, &overlaySubnetV6, &event.Pool, &event.Actor, &event.Reason, &event.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("selecting events: parsing result: %s", err)
+		}
+		event.OverlaySubnet = overlaySubnet.String
+		event.OverlaySubnetV6 = overlaySubnetV6.String
+		events = append(events, event)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("selecting events: getting next row: %s", err) // untested
+	}
+	return events, nil
+}
+
+// QuarantineSubnet keeps the subnet of the pool from being handed out for the
+// given number of seconds. Quarantines that have ended are deleted on the way.
+func (d *DatabaseHandler) QuarantineSubnet(subnet, pool string, seconds int) error {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return err
+	}
+
+	_, err = d.conn.Exec(d.conn.Rebind(fmt.Sprintf("DELETE FROM quarantined_subnets WHERE overlay_subnet = ? OR quarantined_until <= %s", timestamp)), subnet)
+	if err != nil {
+		return fmt.Errorf("quarantining subnet: %s", err)
+	}
+	_, err = d.conn.Exec(d.conn.Rebind(fmt.Sprintf("INSERT INTO quarantined_subnets (overlay_subnet, pool, quarantined_until) VALUES (?, ?, %s + %d)", timestamp, seconds)), subnet, pool)
+	if err != nil {
+		return fmt.Errorf("quarantining subnet: %s", err)
+	}
+	return nil
+}
+
+// QuarantinedSubnets returns the subnets whose quarantine has not ended yet,
+// the ones that end first first.
+func (d *DatabaseHandler) QuarantinedSubnets() ([]controller.QuarantinedSubnet, error) {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := d.where(fmt.Sprintf("quarantined_until > %s", timestamp))
+	rows, err := d.conn.Query(d.conn.Rebind("SELECT overlay_subnet, pool, quarantined_until FROM quarantined_subnets"+where+" ORDER BY quarantined_until ASC"), args...)
+	if err != nil {
+		return nil, fmt.Errorf("selecting quarantined subnets: %s", err)
+	}
+	defer rows.Close() // untested
+
+	quarantined := []controller.QuarantinedSubnet{}
+	for rows.Next() {
+		var subnet controller.QuarantinedSubnet
+		err := rows.Scan(&subnet.OverlaySubnet, &subnet.Pool, &subnet.QuarantinedUntil)
+		if err != nil {
+			return nil, fmt.Errorf("selecting quarantined subnets: parsing result: %s", err)
+		}
+		quarantined = append(quarantined, subnet)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("selecting quarantined subnets: getting next row: %s", err) // untested
+	}
+	return quarantined, nil
+}
+
+// ClaimLeadership makes holder the leader if it already is or if the leader
+// has not claimed leadership within the last ttlSeconds, and returns the
+// leader either way. The leader must claim it again before ttlSeconds pass.
+func (d *DatabaseHandler) ClaimLeadership(holder string, ttlSeconds int) (string, error) {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return "", err
+	}
+	insertLeader, err := insertIgnoreForDriver(d.db.DriverName(), "INSERT INTO leaders (name, holder, renewed_at) VALUES (?, ?, 0)")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = d.conn.Exec(d.conn.Rebind(insertLeader), leaderName, holder)
+	if err != nil {
+		return "", fmt.Errorf("claiming leadership: %s", err)
+	}
+	_, err = d.conn.Exec(d.conn.Rebind(fmt.Sprintf("UPDATE leaders SET holder = ?, renewed_at = %s WHERE name = ? AND (holder = ? OR renewed_at + %d <= %s)", timestamp, ttlSeconds, timestamp)), holder, leaderName, holder)
+	if err != nil {
+		return "", fmt.Errorf("claiming leadership: %s", err)
+	}
+
+	var leader string
+	err = d.conn.QueryRow(d.conn.Rebind("SELECT holder FROM leaders WHERE name = ?"), leaderName).Scan(&leader)
+	if err != nil {
+		return "", fmt.Errorf("selecting leader: %s", err)
+	}
+	return leader, nil
+}
+
+// ResignLeadership lets another controller claim leadership right away, if
+// holder is the leader.
+func (d *DatabaseHandler) ResignLeadership(holder string) error {
+	_, err := d.conn.Exec(d.conn.Rebind("UPDATE leaders SET renewed_at = 0 WHERE name = ? AND holder = ?"), leaderName, holder)
+	if err != nil {
+		return fmt.Errorf("resigning leadership: %s", err)
+	}
+	return nil
+}
+
+func (d *DatabaseHandler) AllReservations() ([]controller.Reservation, error) {
+	where, args := d.where()
+	rows, err := d.conn.Query(d.conn.Rebind("SELECT underlay_ip, overlay_subnet, pool FROM reservations"+where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("selecting all reservations: %s", err)
+	}
+	defer rows.Close() // untested
+
+	reservations := []controller.Reservation{}
+	for rows.Next() {
+		var reservation controller.Reservation
+		err := rows.Scan(&reservation.UnderlayIP, &reservation.OverlaySubnet, &reservation.Pool)
+		if err != nil {
+			return nil, fmt.Errorf("selecting all reservations: parsing result: %s", err)
+		}
+		reservations = append(reservations, reservation)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("selecting all reservations: getting next row: %s", err) // untested
+	}
+	return reservations, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLease(row rowScanner) (controller.Lease, error) {
+	var underlayIP, overlayHWAddr, pool string
+	var overlaySubnet, overlaySubnetV6 sql.NullString
+	err := row.Scan(&underlayIP, &overlaySubnet, &overlaySubnetV6, &overlayHWAddr, &pool)
+	if err != nil {
+		return controller.Lease{}, err
+	}
+	return controller.Lease{
+		UnderlayIP:          underlayIP,
+		OverlaySubnet:       overlaySubnet.String,
+		OverlaySubnetV6:     overlaySubnetV6.String,
+		OverlayHardwareAddr: overlayHWAddr,
+		Pool:                pool,
+	}, nil
+}
+
 func rowsToLeases(rows *sql.Rows) ([]controller.Lease, error) {
 	leases := []controller.Lease{}
 	for rows.Next() {
-		var underlayIP, overlaySubnet, overlayHWAddr string
-		err := rows.Scan(&underlayIP, &overlaySubnet, &overlayHWAddr)
+		lease, err := scanLease(rows)
 		if err != nil {
 			return nil, fmt.Errorf("parsing result: %s", err)
 		}
-		leases = append(leases, controller.Lease{
-			UnderlayIP:          underlayIP,
-			OverlaySubnet:       overlaySubnet,
-			OverlayHardwareAddr: overlayHWAddr,
-		})
+		leases = append(leases, lease)
 	}
 	err := rows.Err()
 	if err != nil {
@@ -279,24 +842,220 @@ func createSubnetTable(dbType string) string {
 		");"
 	mysqlId := "id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id)"
 	psqlId := "id SERIAL PRIMARY KEY"
+	sqliteId := "id INTEGER PRIMARY KEY AUTOINCREMENT"
 
 	switch dbType {
 	case Postgres:
 		return fmt.Sprintf(baseCreateTable, psqlId)
 	case MySQL:
 		return fmt.Sprintf(baseCreateTable, mysqlId)
+	case SQLite:
+		return fmt.Sprintf(baseCreateTable, sqliteId)
 	}
 
 	return ""
 }
 
+func createReservationsTable(dbType string) string {
+	baseCreateTable := "CREATE TABLE IF NOT EXISTS reservations (" +
+		"%s" +
+		", underlay_ip varchar(45) NOT NULL" +
+		", overlay_subnet varchar(18) NOT NULL" +
+		", pool varchar(255) NOT NULL DEFAULT ''" +
+		", UNIQUE (underlay_ip)" +
+		", UNIQUE (overlay_subnet)" +
+		");"
+	mysqlId := "id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id)"
+	psqlId := "id SERIAL PRIMARY KEY"
+	sqliteId := "id INTEGER PRIMARY KEY AUTOINCREMENT"
+
+	switch dbType {
+	case Postgres:
+		return fmt.Sprintf(baseCreateTable, psqlId)
+	case MySQL:
+		return fmt.Sprintf(baseCreateTable, mysqlId)
+	case SQLite:
+		return fmt.Sprintf(baseCreateTable, sqliteId)
+	}
+
+	return ""
+}
+
+// addIPv6Columns widens the address columns for IPv6 underlays and lets a lease
+// hold an IPv6 overlay subnet alongside, or instead of, an IPv4 one.
+func addIPv6Columns(dbType string) []string {
+	addColumn := "ALTER TABLE subnets ADD COLUMN overlay_subnet_v6 varchar(43) UNIQUE"
+
+	switch dbType {
+	case Postgres:
+		return []string{
+			"ALTER TABLE subnets ALTER COLUMN underlay_ip TYPE varchar(45)",
+			"ALTER TABLE subnets ALTER COLUMN overlay_subnet DROP NOT NULL",
+			addColumn,
+		}
+	case MySQL:
+		return []string{
+			"ALTER TABLE subnets MODIFY underlay_ip varchar(45) NOT NULL",
+			"ALTER TABLE subnets MODIFY overlay_subnet varchar(18) NULL",
+			addColumn,
+		}
+	case SQLite:
+		// sqlite3 can neither change a column nor add a unique one, so the
+		// table is copied into a new one with the new columns instead
+		return []string{
+			"CREATE TABLE subnets_v6 (" +
+				"id INTEGER PRIMARY KEY AUTOINCREMENT" +
+				", underlay_ip varchar(45) NOT NULL" +
+				", overlay_subnet varchar(18)" +
+				", overlay_hwaddr varchar(17) NOT NULL" +
+				", last_renewed_at bigint NOT NULL" +
+				", overlay_subnet_v6 varchar(43)" +
+				", UNIQUE (underlay_ip)" +
+				", UNIQUE (overlay_subnet)" +
+				", UNIQUE (overlay_hwaddr)" +
+				", UNIQUE (overlay_subnet_v6)" +
+				");",
+			"INSERT INTO subnets_v6 (id, underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) SELECT id, underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at FROM subnets",
+			"DROP TABLE subnets",
+			"ALTER TABLE subnets_v6 RENAME TO subnets",
+		}
+	}
+
+	return nil
+}
+
+// dropIPv6Columns undoes addIPv6Columns. It fails while a lease holds an IPv6
+// underlay ip or no IPv4 overlay subnet.
+func dropIPv6Columns(dbType string) []string {
+	dropColumn := "ALTER TABLE subnets DROP COLUMN overlay_subnet_v6"
+
+	switch dbType {
+	case Postgres:
+		return []string{
+			dropColumn,
+			"ALTER TABLE subnets ALTER COLUMN overlay_subnet SET NOT NULL",
+			"ALTER TABLE subnets ALTER COLUMN underlay_ip TYPE varchar(15)",
+		}
+	case MySQL:
+		return []string{
+			dropColumn,
+			"ALTER TABLE subnets MODIFY overlay_subnet varchar(18) NOT NULL",
+			"ALTER TABLE subnets MODIFY underlay_ip varchar(15) NOT NULL",
+		}
+	case SQLite:
+		return []string{
+			"CREATE TABLE subnets_v4 (" +
+				"id INTEGER PRIMARY KEY AUTOINCREMENT" +
+				", underlay_ip varchar(15) NOT NULL" +
+				", overlay_subnet varchar(18) NOT NULL" +
+				", overlay_hwaddr varchar(17) NOT NULL" +
+				", last_renewed_at bigint NOT NULL" +
+				", UNIQUE (underlay_ip)" +
+				", UNIQUE (overlay_subnet)" +
+				", UNIQUE (overlay_hwaddr)" +
+				");",
+			"INSERT INTO subnets_v4 (id, underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) SELECT id, underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at FROM subnets",
+			"DROP TABLE subnets",
+			"ALTER TABLE subnets_v4 RENAME TO subnets",
+		}
+	}
+
+	return nil
+}
+
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// insertIgnoreForDriver turns an insert into one that does nothing when the
+// row already exists.
+func insertIgnoreForDriver(driverName, insert string) (string, error) {
+	switch driverName {
+	case MySQL:
+		return strings.Replace(insert, "INSERT", "INSERT IGNORE", 1), nil
+	case Postgres:
+		return insert + " ON CONFLICT DO NOTHING", nil
+	case SQLite:
+		return strings.Replace(insert, "INSERT", "INSERT OR IGNORE", 1), nil
+	default:
+		return "", fmt.Errorf("database type %s is not supported", driverName)
+	}
+}
+
 func timestampForDriver(driverName string) (string, error) {
 	switch driverName {
 	case MySQL:
 		return mysqlTimeNow, nil
 	case Postgres:
 		return postgresTimeNow, nil
+	case SQLite:
+		return sqliteTimeNow, nil
 	default:
 		return "", fmt.Errorf("database type %s is not supported", driverName)
 	}
 }
+
+// forUpdateForDriver returns the clause that locks the selected rows until the
+// end of the transaction. sqlite3 has none and needs none, since the
+// transactions of NewConnectionPool begin by taking its write lock.
+func forUpdateForDriver(driverName string) string {
+	if driverName == SQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+func createLeaseEventsTable(dbType string) string {
+	baseCreateTable := "CREATE TABLE IF NOT EXISTS lease_events (" +
+		"%s" +
+		", event_type varchar(16) NOT NULL" +
+		", underlay_ip varchar(45) NOT NULL" +
+		", overlay_subnet varchar(18)" +
+		", overlay_subnet_v6 varchar(43)" +
+		", pool varchar(255) NOT NULL DEFAULT ''" +
+		", actor varchar(255) NOT NULL DEFAULT ''" +
+		", reason varchar(255) NOT NULL DEFAULT ''" +
+		", created_at bigint NOT NULL" +
+		");"
+	mysqlId := "id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id)"
+	psqlId := "id SERIAL PRIMARY KEY"
+	sqliteId := "id INTEGER PRIMARY KEY AUTOINCREMENT"
+
+	switch dbType {
+	case Postgres:
+		return fmt.Sprintf(baseCreateTable, psqlId)
+	case MySQL:
+		return fmt.Sprintf(baseCreateTable, mysqlId)
+	case SQLite:
+		return fmt.Sprintf(baseCreateTable, sqliteId)
+	}
+
+	return ""
+}
+
+func createQuarantinedSubnetsTable(dbType string) string {
+	baseCreateTable := "CREATE TABLE IF NOT EXISTS quarantined_subnets (" +
+		"%s" +
+		", overlay_subnet varchar(43) NOT NULL" +
+		", pool varchar(255) NOT NULL DEFAULT ''" +
+		", quarantined_until bigint NOT NULL" +
+		", UNIQUE (overlay_subnet)" +
+		");"
+	mysqlId := "id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id)"
+	psqlId := "id SERIAL PRIMARY KEY"
+	sqliteId := "id INTEGER PRIMARY KEY AUTOINCREMENT"
+
+	switch dbType {
+	case Postgres:
+		return fmt.Sprintf(baseCreateTable, psqlId)
+	case MySQL:
+		return fmt.Sprintf(baseCreateTable, mysqlId)
+	case SQLite:
+		return fmt.Sprintf(baseCreateTable, sqliteId)
+	}
+
+	return ""
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,6 +16,8 @@ import (
 	"code.cloudfoundry.org/silk/controller"
 	"code.cloudfoundry.org/silk/controller/database"
 	"code.cloudfoundry.org/silk/controller/database/fakes"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	silktestsupport "code.cloudfoundry.org/silk/testsupport"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	migrate "github.com/rubenv/sql-migrate"
@@ -37,12 +40,11 @@ var _ = Describe("DatabaseHandler", func() {
 		mockDb = &fakes.Db{}
 		mockMigrateAdapter = &fakes.MigrateAdapter{}
 
-		dbConfig = testsupport.GetDBConfig()
-		dbConfig.DatabaseName = fmt.Sprintf("test_db_%03d_%x", GinkgoParallelProcess(), rand.Int())
-		testsupport.CreateDatabase(dbConfig)
+		dbConfig = silktestsupport.GetDBConfig(fmt.Sprintf("test_db_%03d_%x", GinkgoParallelProcess(), rand.Int()))
+		silktestsupport.CreateDatabase(dbConfig)
 
 		var err error
-		realDb, err = db.NewConnectionPool(
+		realDb, err = database.NewConnectionPool(
 			dbConfig,
 			200,
 			200,
@@ -83,7 +85,7 @@ var _ = Describe("DatabaseHandler", func() {
 		if realDb != nil {
 			Expect(realDb.Close()).To(Succeed())
 		}
-		testsupport.RemoveDatabase(dbConfig)
+		silktestsupport.RemoveDatabase(dbConfig)
 	})
 
 	Describe("Migrate", func() {
@@ -102,7 +104,8 @@ var _ = Describe("DatabaseHandler", func() {
 			db, dbType, migrations, dir := mockMigrateAdapter.ExecArgsForCall(0)
 			Expect(db).To(Equal(mockDb))
 			Expect(dbType).To(Equal(realDb.DriverName()))
-			if dbType == "postgres" {
+			switch dbType {
+			case "postgres":
 				Expect(migrations).To(Equal(migrate.MemoryMigrationSource{
 					Migrations: []*migrate.Migration{
 						{
@@ -110,9 +113,66 @@ var _ = Describe("DatabaseHandler", func() {
 							Up:   []string{"CREATE TABLE IF NOT EXISTS subnets (id SERIAL PRIMARY KEY, underlay_ip varchar(15) NOT NULL, overlay_subnet varchar(18) NOT NULL, overlay_hwaddr varchar(17) NOT NULL, last_renewed_at bigint NOT NULL, UNIQUE (underlay_ip), UNIQUE (overlay_subnet), UNIQUE (overlay_hwaddr));"},
 							Down: []string{"DROP TABLE subnets"},
 						},
+						{
+							Id: "2",
+							Up: []string{
+								"ALTER TABLE subnets ALTER COLUMN underlay_ip TYPE varchar(45)",
+								"ALTER TABLE subnets ALTER COLUMN overlay_subnet DROP NOT NULL",
+								"ALTER TABLE subnets ADD COLUMN overlay_subnet_v6 varchar(43) UNIQUE",
+							},
+							Down: []string{
+								"ALTER TABLE subnets DROP COLUMN overlay_subnet_v6",
+								"ALTER TABLE subnets ALTER COLUMN overlay_subnet SET NOT NULL",
+								"ALTER TABLE subnets ALTER COLUMN underlay_ip TYPE varchar(15)",
+							},
+						},
+						{
+							Id:   "3",
+							Up:   []string{"ALTER TABLE subnets ADD COLUMN pool varchar(255) NOT NULL DEFAULT ''"},
+							Down: []string{"ALTER TABLE subnets DROP COLUMN pool"},
+						},
+						{
+							Id:   "4",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS reservations (id SERIAL PRIMARY KEY, underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18) NOT NULL, pool varchar(255) NOT NULL DEFAULT '', UNIQUE (underlay_ip), UNIQUE (overlay_subnet));"},
+							Down: []string{"DROP TABLE reservations"},
+						},
+						{
+							Id:   "5",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS allocation_locks (pool varchar(255) NOT NULL, PRIMARY KEY (pool));"},
+							Down: []string{"DROP TABLE allocation_locks"},
+						},
+						{
+							Id: "6",
+							Up: []string{
+								"CREATE TABLE IF NOT EXISTS lease_events (id SERIAL PRIMARY KEY, event_type varchar(16) NOT NULL, underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18), overlay_subnet_v6 varchar(43), pool varchar(255) NOT NULL DEFAULT '', actor varchar(255) NOT NULL DEFAULT '', reason varchar(255) NOT NULL DEFAULT '', created_at bigint NOT NULL);",
+								"CREATE INDEX lease_events_underlay_ip ON lease_events (underlay_ip, created_at)",
+								"CREATE INDEX lease_events_overlay_subnet ON lease_events (overlay_subnet, created_at)",
+							},
+							Down: []string{"DROP TABLE lease_events"},
+						},
+						{
+							Id:   "7",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS quarantined_subnets (id SERIAL PRIMARY KEY, overlay_subnet varchar(43) NOT NULL, pool varchar(255) NOT NULL DEFAULT '', quarantined_until bigint NOT NULL, UNIQUE (overlay_subnet));"},
+							Down: []string{"DROP TABLE quarantined_subnets"},
+						},
+						{
+							Id:   "8",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS leaders (name varchar(255) NOT NULL, holder varchar(255) NOT NULL, renewed_at bigint NOT NULL, PRIMARY KEY (name));"},
+							Down: []string{"DROP TABLE leaders"},
+						},
+						{
+							Id:   "9",
+							Up:   []string{"ALTER TABLE subnets ADD COLUMN host text"},
+							Down: []string{"ALTER TABLE subnets DROP COLUMN host"},
+						},
+						{
+							Id:   "10",
+							Up:   []string{"ALTER TABLE allocation_locks ADD COLUMN generation bigint NOT NULL DEFAULT 0"},
+							Down: []string{"ALTER TABLE allocation_locks DROP COLUMN generation"},
+						},
 					},
 				}))
-			} else {
+			case "mysql":
 				Expect(migrations).To(Equal(migrate.MemoryMigrationSource{
 					Migrations: []*migrate.Migration{
 						{
@@ -120,8 +180,136 @@ var _ = Describe("DatabaseHandler", func() {
 							Up:   []string{"CREATE TABLE IF NOT EXISTS subnets (id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id), underlay_ip varchar(15) NOT NULL, overlay_subnet varchar(18) NOT NULL, overlay_hwaddr varchar(17) NOT NULL, last_renewed_at bigint NOT NULL, UNIQUE (underlay_ip), UNIQUE (overlay_subnet), UNIQUE (overlay_hwaddr));"},
 							Down: []string{"DROP TABLE subnets"},
 						},
+						{
+							Id: "2",
+							Up: []string{
+								"ALTER TABLE subnets MODIFY underlay_ip varchar(45) NOT NULL",
+								"ALTER TABLE subnets MODIFY overlay_subnet varchar(18) NULL",
+								"ALTER TABLE subnets ADD COLUMN overlay_subnet_v6 varchar(43) UNIQUE",
+							},
+							Down: []string{
+								"ALTER TABLE subnets DROP COLUMN overlay_subnet_v6",
+								"ALTER TABLE subnets MODIFY overlay_subnet varchar(18) NOT NULL",
+								"ALTER TABLE subnets MODIFY underlay_ip varchar(15) NOT NULL",
+							},
+						},
+						{
+							Id:   "3",
+							Up:   []string{"ALTER TABLE subnets ADD COLUMN pool varchar(255) NOT NULL DEFAULT ''"},
+							Down: []string{"ALTER TABLE subnets DROP COLUMN pool"},
+						},
+						{
+							Id:   "4",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS reservations (id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id), underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18) NOT NULL, pool varchar(255) NOT NULL DEFAULT '', UNIQUE (underlay_ip), UNIQUE (overlay_subnet));"},
+							Down: []string{"DROP TABLE reservations"},
+						},
+						{
+							Id:   "5",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS allocation_locks (pool varchar(255) NOT NULL, PRIMARY KEY (pool));"},
+							Down: []string{"DROP TABLE allocation_locks"},
+						},
+						{
+							Id: "6",
+							Up: []string{
+								"CREATE TABLE IF NOT EXISTS lease_events (id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id), event_type varchar(16) NOT NULL, underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18), overlay_subnet_v6 varchar(43), pool varchar(255) NOT NULL DEFAULT '', actor varchar(255) NOT NULL DEFAULT '', reason varchar(255) NOT NULL DEFAULT '', created_at bigint NOT NULL);",
+								"CREATE INDEX lease_events_underlay_ip ON lease_events (underlay_ip, created_at)",
+								"CREATE INDEX lease_events_overlay_subnet ON lease_events (overlay_subnet, created_at)",
+							},
+							Down: []string{"DROP TABLE lease_events"},
+						},
+						{
+							Id:   "7",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS quarantined_subnets (id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id), overlay_subnet varchar(43) NOT NULL, pool varchar(255) NOT NULL DEFAULT '', quarantined_until bigint NOT NULL, UNIQUE (overlay_subnet));"},
+							Down: []string{"DROP TABLE quarantined_subnets"},
+						},
+						{
+							Id:   "8",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS leaders (name varchar(255) NOT NULL, holder varchar(255) NOT NULL, renewed_at bigint NOT NULL, PRIMARY KEY (name));"},
+							Down: []string{"DROP TABLE leaders"},
+						},
+						{
+							Id:   "9",
+							Up:   []string{"ALTER TABLE subnets ADD COLUMN host text"},
+							Down: []string{"ALTER TABLE subnets DROP COLUMN host"},
+						},
+						{
+							Id:   "10",
+							Up:   []string{"ALTER TABLE allocation_locks ADD COLUMN generation bigint NOT NULL DEFAULT 0"},
+							Down: []string{"ALTER TABLE allocation_locks DROP COLUMN generation"},
+						},
 					},
 				}))
+			case "sqlite3":
+				Expect(migrations).To(Equal(migrate.MemoryMigrationSource{
+					Migrations: []*migrate.Migration{
+						{
+							Id:   "1",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS subnets (id INTEGER PRIMARY KEY AUTOINCREMENT, underlay_ip varchar(15) NOT NULL, overlay_subnet varchar(18) NOT NULL, overlay_hwaddr varchar(17) NOT NULL, last_renewed_at bigint NOT NULL, UNIQUE (underlay_ip), UNIQUE (overlay_subnet), UNIQUE (overlay_hwaddr));"},
+							Down: []string{"DROP TABLE subnets"},
+						},
+						{
+							Id: "2",
+							Up: []string{
+								"CREATE TABLE subnets_v6 (id INTEGER PRIMARY KEY AUTOINCREMENT, underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18), overlay_hwaddr varchar(17) NOT NULL, last_renewed_at bigint NOT NULL, overlay_subnet_v6 varchar(43), UNIQUE (underlay_ip), UNIQUE (overlay_subnet), UNIQUE (overlay_hwaddr), UNIQUE (overlay_subnet_v6));",
+								"INSERT INTO subnets_v6 (id, underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) SELECT id, underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at FROM subnets",
+								"DROP TABLE subnets",
+								"ALTER TABLE subnets_v6 RENAME TO subnets",
+							},
+							Down: []string{
+								"CREATE TABLE subnets_v4 (id INTEGER PRIMARY KEY AUTOINCREMENT, underlay_ip varchar(15) NOT NULL, overlay_subnet varchar(18) NOT NULL, overlay_hwaddr varchar(17) NOT NULL, last_renewed_at bigint NOT NULL, UNIQUE (underlay_ip), UNIQUE (overlay_subnet), UNIQUE (overlay_hwaddr));",
+								"INSERT INTO subnets_v4 (id, underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) SELECT id, underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at FROM subnets",
+								"DROP TABLE subnets",
+								"ALTER TABLE subnets_v4 RENAME TO subnets",
+							},
+						},
+						{
+							Id:   "3",
+							Up:   []string{"ALTER TABLE subnets ADD COLUMN pool varchar(255) NOT NULL DEFAULT ''"},
+							Down: []string{"ALTER TABLE subnets DROP COLUMN pool"},
+						},
+						{
+							Id:   "4",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS reservations (id INTEGER PRIMARY KEY AUTOINCREMENT, underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18) NOT NULL, pool varchar(255) NOT NULL DEFAULT '', UNIQUE (underlay_ip), UNIQUE (overlay_subnet));"},
+							Down: []string{"DROP TABLE reservations"},
+						},
+						{
+							Id:   "5",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS allocation_locks (pool varchar(255) NOT NULL, PRIMARY KEY (pool));"},
+							Down: []string{"DROP TABLE allocation_locks"},
+						},
+						{
+							Id: "6",
+							Up: []string{
+								"CREATE TABLE IF NOT EXISTS lease_events (id INTEGER PRIMARY KEY AUTOINCREMENT, event_type varchar(16) NOT NULL, underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18), overlay_subnet_v6 varchar(43), pool varchar(255) NOT NULL DEFAULT '', actor varchar(255) NOT NULL DEFAULT '', reason varchar(255) NOT NULL DEFAULT '', created_at bigint NOT NULL);",
+								"CREATE INDEX lease_events_underlay_ip ON lease_events (underlay_ip, created_at)",
+								"CREATE INDEX lease_events_overlay_subnet ON lease_events (overlay_subnet, created_at)",
+							},
+							Down: []string{"DROP TABLE lease_events"},
+						},
+						{
+							Id:   "7",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS quarantined_subnets (id INTEGER PRIMARY KEY AUTOINCREMENT, overlay_subnet varchar(43) NOT NULL, pool varchar(255) NOT NULL DEFAULT '', quarantined_until bigint NOT NULL, UNIQUE (overlay_subnet));"},
+							Down: []string{"DROP TABLE quarantined_subnets"},
+						},
+						{
+							Id:   "8",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS leaders (name varchar(255) NOT NULL, holder varchar(255) NOT NULL, renewed_at bigint NOT NULL, PRIMARY KEY (name));"},
+							Down: []string{"DROP TABLE leaders"},
+						},
+						{
+							Id:   "9",
+							Up:   []string{"ALTER TABLE subnets ADD COLUMN host text"},
+							Down: []string{"ALTER TABLE subnets DROP COLUMN host"},
+						},
+						{
+							Id:   "10",
+							Up:   []string{"ALTER TABLE allocation_locks ADD COLUMN generation bigint NOT NULL DEFAULT 0"},
+							Down: []string{"ALTER TABLE allocation_locks DROP COLUMN generation"},
+						},
+					},
+				}))
+			default:
+				Fail("unexpected database type " + dbType)
 			}
 			Expect(dir).To(Equal(migrate.Up))
 		})
@@ -156,18 +344,18 @@ var _ = Describe("DatabaseHandler", func() {
 		Context("when the database type is postgres", func() {
 			BeforeEach(func() {
 				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
-				mockDb.RebindReturns("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES ($1, $2, $3, EXTRACT(EPOCH FROM now())::numeric::integer)")
+				mockDb.RebindReturns("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_subnet_v6, overlay_hwaddr, pool, last_renewed_at) VALUES ($1, $2, $3, $4, $5, EXTRACT(EPOCH FROM now())::numeric::integer)")
 				mockDb.DriverNameReturns("postgres")
 			})
 			It("adds an entry to the DB", func() {
 				err := databaseHandler.AddEntry(lease)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(mockDb.ExecCallCount()).To(Equal(1))
+				Expect(mockDb.ExecCallCount()).To(Equal(2))
 				query, args := mockDb.ExecArgsForCall(0)
-				Expect(mockDb.RebindArgsForCall(0)).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, EXTRACT(EPOCH FROM now())::numeric::integer)"))
-				Expect(query).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES ($1, $2, $3, EXTRACT(EPOCH FROM now())::numeric::integer)"))
-				Expect(args).To(Equal([]interface{}{"10.244.11.22", "10.255.17.0/24", "ee:ee:0a:ff:11:00"}))
+				Expect(mockDb.RebindArgsForCall(0)).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_subnet_v6, overlay_hwaddr, pool, last_renewed_at) VALUES (?, ?, ?, ?, ?, EXTRACT(EPOCH FROM now())::numeric::integer)"))
+				Expect(query).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_subnet_v6, overlay_hwaddr, pool, last_renewed_at) VALUES ($1, $2, $3, $4, $5, EXTRACT(EPOCH FROM now())::numeric::integer)"))
+				Expect(args).To(Equal([]interface{}{"10.244.11.22", "10.255.17.0/24", nil, "ee:ee:0a:ff:11:00", ""}))
 			})
 		})
 
@@ -175,17 +363,17 @@ var _ = Describe("DatabaseHandler", func() {
 			BeforeEach(func() {
 				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
 				mockDb.DriverNameReturns("mysql")
-				mockDb.RebindReturns("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, UNIX_TIMESTAMP())")
+				mockDb.RebindReturns("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_subnet_v6, overlay_hwaddr, pool, last_renewed_at) VALUES (?, ?, ?, ?, ?, UNIX_TIMESTAMP())")
 			})
 			It("adds an entry to the DB", func() {
 				err := databaseHandler.AddEntry(lease)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(mockDb.ExecCallCount()).To(Equal(1))
+				Expect(mockDb.ExecCallCount()).To(Equal(2))
 				query, args := mockDb.ExecArgsForCall(0)
-				Expect(mockDb.RebindArgsForCall(0)).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, UNIX_TIMESTAMP())"))
-				Expect(query).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, UNIX_TIMESTAMP())"))
-				Expect(args).To(Equal([]interface{}{"10.244.11.22", "10.255.17.0/24", "ee:ee:0a:ff:11:00"}))
+				Expect(mockDb.RebindArgsForCall(0)).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_subnet_v6, overlay_hwaddr, pool, last_renewed_at) VALUES (?, ?, ?, ?, ?, UNIX_TIMESTAMP())"))
+				Expect(query).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_subnet_v6, overlay_hwaddr, pool, last_renewed_at) VALUES (?, ?, ?, ?, ?, UNIX_TIMESTAMP())"))
+				Expect(args).To(Equal([]interface{}{"10.244.11.22", "10.255.17.0/24", nil, "ee:ee:0a:ff:11:00", ""}))
 			})
 		})
 
@@ -211,6 +399,19 @@ var _ = Describe("DatabaseHandler", func() {
 			})
 		})
 
+		Context("when updating the generation fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.DriverNameReturns("mysql")
+				mockDb.ExecReturnsOnCall(1, nil, errors.New("apricot"))
+			})
+			It("returns a sensible error", func() {
+				err := databaseHandler.AddEntry(lease)
+				Expect(err).To(MatchError("updating generation: apricot"))
+				Expect(mockDb.RebindArgsForCall(1)).To(Equal("UPDATE allocation_locks SET generation = generation + 1 WHERE pool = ?"))
+			})
+		})
+
 	})
 
 	Describe("DeleteEntry", func() {
@@ -230,7 +431,8 @@ var _ = Describe("DatabaseHandler", func() {
 		Context("when the database exec returns some other error", func() {
 			BeforeEach(func() {
 				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
-				mockDb.ExecReturns(nil, errors.New("carrot"))
+				mockDb.ExecReturnsOnCall(0, &fakes.SqlResult{}, nil)
+				mockDb.ExecReturnsOnCall(1, nil, errors.New("carrot"))
 				mockDb.RebindReturns("DELETE FROM subnets WHERE underlay_ip = $1")
 				mockDb.DriverNameReturns("postgres")
 
@@ -239,15 +441,29 @@ var _ = Describe("DatabaseHandler", func() {
 				err := databaseHandler.DeleteEntry("some-underlay")
 				Expect(err).To(MatchError("deleting entry: carrot"))
 
-				Expect(mockDb.ExecCallCount()).To(Equal(1))
+				Expect(mockDb.ExecCallCount()).To(Equal(2))
 
-				query, args := mockDb.ExecArgsForCall(0)
-				Expect(mockDb.RebindArgsForCall(0)).To(Equal("DELETE FROM subnets WHERE underlay_ip = ?"))
+				query, args := mockDb.ExecArgsForCall(1)
+				Expect(mockDb.RebindArgsForCall(1)).To(Equal("DELETE FROM subnets WHERE underlay_ip = ?"))
 				Expect(query).To(Equal("DELETE FROM subnets WHERE underlay_ip = $1"))
 				Expect(args).To(Equal([]interface{}{"some-underlay"}))
 			})
 		})
 
+		Context("when updating the generation fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.ExecReturns(nil, errors.New("parsnip"))
+			})
+
+			It("returns an error without deleting the entry", func() {
+				err := databaseHandler.DeleteEntry("10.244.11.22")
+				Expect(err).To(MatchError("updating generation: parsnip"))
+				Expect(mockDb.ExecCallCount()).To(Equal(1))
+				Expect(mockDb.RebindArgsForCall(0)).To(Equal("UPDATE allocation_locks SET generation = generation + 1 WHERE pool IN (SELECT pool FROM subnets WHERE underlay_ip = ?)"))
+			})
+		})
+
 		Context("when the parsing the result fails", func() {
 			BeforeEach(func() {
 				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
@@ -516,6 +732,319 @@ var _ = Describe("DatabaseHandler", func() {
 		})
 	})
 
+	Describe("AllBlockSubnetsV6", func() {
+		var dualStackLease, ipv6Lease controller.Lease
+
+		BeforeEach(func() {
+			dualStackLease = controller.Lease{
+				UnderlayIP:          "10.244.11.30",
+				OverlaySubnet:       "10.255.30.0/24",
+				OverlaySubnetV6:     "fd00:255:0:1e::/64",
+				OverlayHardwareAddr: "ee:ee:0a:ff:1e:00",
+			}
+			ipv6Lease = controller.Lease{
+				UnderlayIP:          "fd00:244::31",
+				OverlaySubnetV6:     "fd00:255:0:1f::/64",
+				OverlayHardwareAddr: "ee:ee:00:00:00:1f",
+			}
+
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			err = databaseHandler.AddEntry(lease)
+			Expect(err).NotTo(HaveOccurred())
+			err = databaseHandler.AddEntry(dualStackLease)
+			Expect(err).NotTo(HaveOccurred())
+			err = databaseHandler.AddEntry(ipv6Lease)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns all leases holding an ipv6 subnet", func() {
+			leases, err := databaseHandler.AllBlockSubnetsV6()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(dualStackLease, ipv6Lease))
+		})
+
+		It("leaves ipv6 only leases out of the ipv4 queries", func() {
+			leases, err := databaseHandler.AllBlockSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease, dualStackLease))
+
+			leases, err = databaseHandler.AllSingleIPSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(BeEmpty())
+		})
+
+		It("returns the ipv6 subnet for the underlay ip", func() {
+			found, err := databaseHandler.LeaseForUnderlayIP("fd00:244::31")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&ipv6Lease))
+		})
+
+		It("gets the oldest lease holding an expired ipv6 subnet", func() {
+			expiredLease, err := databaseHandler.OldestExpiredBlockSubnetV6(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect([]controller.Lease{dualStackLease, ipv6Lease}).To(ContainElement(*expiredLease))
+		})
+
+		Context("when the query fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.QueryReturns(nil, errors.New("strawberry"))
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.AllBlockSubnetsV6()
+				Expect(err).To(MatchError("selecting all ipv6 subnets: strawberry"))
+			})
+		})
+	})
+
+	Describe("as a Store", func() {
+		describeStore(func() database.Store {
+			handler := database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := handler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			return handler
+		})
+	})
+
+	Describe("ForPool", func() {
+		var blueLease, blueSingleIPLease controller.Lease
+
+		BeforeEach(func() {
+			blueLease = controller.Lease{
+				UnderlayIP:          "10.244.11.40",
+				OverlaySubnet:       "10.250.40.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:fa:28:00",
+				Pool:                "blue",
+			}
+			blueSingleIPLease = controller.Lease{
+				UnderlayIP:          "10.244.11.41",
+				OverlaySubnet:       "10.250.0.41/32",
+				OverlayHardwareAddr: "ee:ee:0a:fa:00:29",
+				Pool:                "blue",
+			}
+
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			for _, l := range []controller.Lease{lease, singleIPLease, blueLease, blueSingleIPLease} {
+				Expect(databaseHandler.AddEntry(l)).To(Succeed())
+			}
+		})
+
+		It("only lists the leases of the pool", func() {
+			blue := databaseHandler.ForPool("blue")
+
+			leases, err := blue.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(blueLease, blueSingleIPLease))
+
+			leases, err = blue.AllBlockSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(blueLease))
+
+			leases, err = blue.AllSingleIPSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(blueSingleIPLease))
+
+			leases, err = blue.AllActive(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(blueLease, blueSingleIPLease))
+
+			expiredLease, err := blue.OldestExpiredBlockSubnet(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expiredLease).To(Equal(&blueLease))
+		})
+
+		It("treats leases without a pool as belonging to the default pool", func() {
+			leases, err := databaseHandler.ForPool(controller.DefaultPool).All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease, singleIPLease))
+		})
+
+		It("does not scope lookups by underlay ip", func() {
+			found, err := databaseHandler.ForPool(controller.DefaultPool).LeaseForUnderlayIP("10.244.11.40")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&blueLease))
+		})
+
+		It("leaves the unscoped handler listing every pool", func() {
+			leases, err := databaseHandler.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(HaveLen(4))
+		})
+	})
+
+	Describe("WithAllocationLock", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(lease)).To(Succeed())
+		})
+
+		It("commits the changes when the function succeeds", func() {
+			err := databaseHandler.WithAllocationLock(func(store database.LeaseStore) error {
+				expired, err := store.OldestExpiredBlockSubnet(0)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(expired).To(Equal(&lease))
+				Expect(store.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+				return store.AddEntry(lease2)
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			leases, err := databaseHandler.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease2))
+		})
+
+		It("rolls back the changes and returns the error when the function fails", func() {
+			err := databaseHandler.WithAllocationLock(func(store database.LeaseStore) error {
+				Expect(store.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+				Expect(store.AddEntry(lease2)).To(Succeed())
+				return errors.New("guava")
+			})
+			Expect(err).To(MatchError("guava"))
+
+			leases, err := databaseHandler.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease))
+		})
+
+		It("keeps the scope of the pool", func() {
+			blueLease := controller.Lease{
+				UnderlayIP:          "10.244.11.40",
+				OverlaySubnet:       "10.250.40.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:fa:28:00",
+				Pool:                "blue",
+			}
+			Expect(databaseHandler.AddEntry(blueLease)).To(Succeed())
+
+			err := databaseHandler.ForPool("blue").WithAllocationLock(func(store database.LeaseStore) error {
+				leases, err := store.AllBlockSubnets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(blueLease))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("hands out a distinct subnet to each of many parallel acquisitions", func() {
+			Expect(databaseHandler.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+			cidrPool := leaser.NewCIDRPool("10.255.0.0/16", 25)
+			trackedStore := leaser.NewTrackedStore(databaseHandler)
+			trackedStore.Track(cidrPool)
+			leaseController := &leaser.LeaseController{
+				DatabaseHandler:            trackedStore,
+				HardwareAddressGenerator:   &leaser.HardwareAddressGenerator{},
+				AcquireSubnetLeaseAttempts: 1,
+				CIDRPool:                   cidrPool,
+				LeaseExpirationSeconds:     60,
+				Logger:                     lagertest.NewTestLogger("test"),
+			}
+
+			nHosts := 500
+			subnets := make(chan string, nHosts)
+			var wg sync.WaitGroup
+			for i := 0; i < nHosts; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{
+						UnderlayIP: fmt.Sprintf("10.244.%d.%d", i/256, i%256),
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease).NotTo(BeNil())
+					subnets <- lease.OverlaySubnet
+				}(i)
+			}
+			wg.Wait()
+			close(subnets)
+
+			distinct := map[string]struct{}{}
+			for subnet := range subnets {
+				distinct[subnet] = struct{}{}
+			}
+			Expect(distinct).To(HaveLen(nHosts))
+		})
+	})
+
+	Describe("Reservations", func() {
+		var reservation, blueReservation controller.Reservation
+
+		BeforeEach(func() {
+			reservation = controller.Reservation{UnderlayIP: "10.244.11.50", OverlaySubnet: "10.255.50.0/24"}
+			blueReservation = controller.Reservation{UnderlayIP: "10.244.11.51", OverlaySubnet: "10.250.51.0/24", Pool: "blue"}
+
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddReservation(reservation)).To(Succeed())
+			Expect(databaseHandler.AddReservation(blueReservation)).To(Succeed())
+		})
+
+		It("lists the reservations", func() {
+			reservations, err := databaseHandler.AllReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(ConsistOf(reservation, blueReservation))
+
+			reservations, err = databaseHandler.ForPool("blue").AllReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(ConsistOf(blueReservation))
+		})
+
+		It("looks up the reservation of an underlay ip", func() {
+			found, err := databaseHandler.ReservationForUnderlayIP("10.244.11.51")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&blueReservation))
+
+			found, err = databaseHandler.ReservationForUnderlayIP("10.244.11.99")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeNil())
+		})
+
+		It("deletes the reservation of an underlay ip", func() {
+			Expect(databaseHandler.DeleteReservation("10.244.11.50")).To(Succeed())
+
+			reservations, err := databaseHandler.AllReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(ConsistOf(blueReservation))
+
+			err = databaseHandler.DeleteReservation("10.244.11.50")
+			Expect(err).To(Equal(database.RecordNotAffectedError))
+		})
+
+		It("rejects a second reservation of the same subnet", func() {
+			err := databaseHandler.AddReservation(controller.Reservation{UnderlayIP: "10.244.11.52", OverlaySubnet: "10.255.50.0/24"})
+			Expect(err).To(MatchError(ContainSubstring("adding reservation:")))
+		})
+
+		It("does not reclaim an expired lease on a reserved subnet", func() {
+			Expect(databaseHandler.AddEntry(controller.Lease{
+				UnderlayIP:          "10.244.11.50",
+				OverlaySubnet:       "10.255.50.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:ff:32:00",
+			})).To(Succeed())
+
+			expiredLease, err := databaseHandler.OldestExpiredBlockSubnet(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expiredLease).To(BeNil())
+		})
+
+		Context("when the query fails", func() {
+			BeforeEach(func() {
+				mockDb.QueryReturns(nil, errors.New("strawberry"))
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.AllReservations()
+				Expect(err).To(MatchError("selecting all reservations: strawberry"))
+			})
+		})
+	})
+
 	Describe("AllSingleIPSubnets", func() {
 		BeforeEach(func() {
 			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
@@ -574,6 +1103,310 @@ var _ = Describe("DatabaseHandler", func() {
 		})
 	})
 
+	Describe("AllExpired", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(lease)).To(Succeed())
+			Expect(databaseHandler.AddEntry(singleIPLease)).To(Succeed())
+		})
+
+		It("returns the leases which have not been renewed within the expiration time", func() {
+			leases, err := databaseHandler.AllExpired(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease, singleIPLease))
+
+			leases, err = databaseHandler.AllExpired(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(BeEmpty())
+		})
+
+		It("is scoped to the pool", func() {
+			leases, err := databaseHandler.ForPool("blue").AllExpired(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(BeEmpty())
+		})
+
+		Context("when the query fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.QueryReturns(nil, errors.New("strawberry"))
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.AllExpired(100)
+				Expect(err).To(MatchError("selecting all expired subnets: strawberry"))
+			})
+		})
+	})
+
+	Describe("LeaseRecords", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(lease)).To(Succeed())
+			Expect(databaseHandler.AddEntry(singleIPLease)).To(Succeed())
+		})
+
+		It("returns every lease with when it was last renewed and whether it expired", func() {
+			records, err := databaseHandler.LeaseRecords(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(2))
+			for _, record := range records {
+				Expect(record.Lease).To(BeElementOf(lease, singleIPLease))
+				Expect(record.LastRenewedAt).To(BeNumerically("~", time.Now().Unix(), 5))
+				Expect(record.Expired).To(BeFalse())
+			}
+
+			records, err = databaseHandler.LeaseRecords(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(2))
+			Expect(records[0].Expired).To(BeTrue())
+			Expect(records[1].Expired).To(BeTrue())
+		})
+
+		It("is scoped to the pool", func() {
+			records, err := databaseHandler.ForPool("blue").LeaseRecords(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(BeEmpty())
+		})
+
+		Context("when the query fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.QueryReturns(nil, errors.New("strawberry"))
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.LeaseRecords(100)
+				Expect(err).To(MatchError("selecting lease records: strawberry"))
+			})
+		})
+	})
+
+	Describe("DeleteExpiredEntry", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(lease)).To(Succeed())
+		})
+
+		It("deletes the lease when it is expired", func() {
+			Expect(databaseHandler.DeleteExpiredEntry(lease.UnderlayIP, 0)).To(Succeed())
+
+			found, err := databaseHandler.LeaseForUnderlayIP(lease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeNil())
+		})
+
+		It("keeps the lease and returns a RecordNotAffectedError when it has been renewed", func() {
+			err := databaseHandler.DeleteExpiredEntry(lease.UnderlayIP, 1000)
+			Expect(err).To(Equal(database.RecordNotAffectedError))
+
+			found, err := databaseHandler.LeaseForUnderlayIP(lease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&lease))
+		})
+
+		Context("when the database exec returns an error", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.ExecReturnsOnCall(0, &fakes.SqlResult{}, nil)
+				mockDb.ExecReturnsOnCall(1, nil, errors.New("strawberry"))
+			})
+			It("returns a sensible error", func() {
+				err := databaseHandler.DeleteExpiredEntry(lease.UnderlayIP, 0)
+				Expect(err).To(MatchError("deleting entry: strawberry"))
+			})
+		})
+	})
+
+	Describe("Events", func() {
+		var acquired, released, other controller.LeaseEvent
+
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+
+			acquired = controller.LeaseEvent{Type: controller.LeaseEventAcquired, UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.17.0/24", Actor: "10.244.11.22"}
+			released = controller.LeaseEvent{Type: controller.LeaseEventReleased, UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.17.0/24", Actor: "silk-admin", Reason: "released by request"}
+			other = controller.LeaseEvent{Type: controller.LeaseEventAcquired, UnderlayIP: "10.244.22.33", OverlaySubnetV6: "fd00:0:0:1::/64", Pool: "blue", Actor: "10.244.22.33"}
+			for _, event := range []controller.LeaseEvent{acquired, released, other} {
+				Expect(databaseHandler.AddEvent(event)).To(Succeed())
+			}
+		})
+
+		withoutTimestamps := func(events []controller.LeaseEvent) []controller.LeaseEvent {
+			for i := range events {
+				Expect(events[i].Timestamp).To(BeNumerically(">", 0))
+				events[i].Timestamp = 0
+			}
+			return events
+		}
+
+		It("returns the events in the order they happened", func() {
+			events, err := databaseHandler.Events(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(events)).To(Equal([]controller.LeaseEvent{acquired, released, other}))
+		})
+
+		It("filters by underlay ip", func() {
+			events, err := databaseHandler.Events(controller.LeaseEventFilter{UnderlayIP: "10.244.11.22"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(events)).To(Equal([]controller.LeaseEvent{acquired, released}))
+		})
+
+		It("filters by ipv4 or ipv6 overlay subnet", func() {
+			events, err := databaseHandler.Events(controller.LeaseEventFilter{OverlaySubnet: "fd00:0:0:1::/64"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(events)).To(Equal([]controller.LeaseEvent{other}))
+		})
+
+		It("filters by time", func() {
+			events, err := databaseHandler.Events(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			timestamp := events[0].Timestamp
+
+			events, err = databaseHandler.Events(controller.LeaseEventFilter{Since: timestamp + 1000})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(BeEmpty())
+
+			events, err = databaseHandler.Events(controller.LeaseEventFilter{Since: timestamp - 1000, Until: timestamp + 1000})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(3))
+
+			events, err = databaseHandler.Events(controller.LeaseEventFilter{Until: timestamp - 1000})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(BeEmpty())
+		})
+
+		It("is scoped to the pool", func() {
+			events, err := databaseHandler.ForPool("blue").Events(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(events)).To(Equal([]controller.LeaseEvent{other}))
+		})
+
+		Context("when adding the event fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.ExecReturns(nil, errors.New("strawberry"))
+			})
+			It("returns an error", func() {
+				err := databaseHandler.AddEvent(acquired)
+				Expect(err).To(MatchError("adding event: strawberry"))
+			})
+		})
+
+		Context("when the query fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.QueryReturns(nil, errors.New("strawberry"))
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.Events(controller.LeaseEventFilter{})
+				Expect(err).To(MatchError("selecting events: strawberry"))
+			})
+		})
+	})
+
+	Describe("ClaimLeadership", func() {
+		Context("when the database exec returns an error", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.ExecReturns(nil, errors.New("strawberry"))
+			})
+			It("returns a sensible error", func() {
+				_, err := databaseHandler.ClaimLeadership("controller-0", 15)
+				Expect(err).To(MatchError("claiming leadership: strawberry"))
+
+				err = databaseHandler.ResignLeadership("controller-0")
+				Expect(err).To(MatchError("resigning leadership: strawberry"))
+			})
+		})
+	})
+
+	Describe("Quarantine", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		subnets := func(quarantined []controller.QuarantinedSubnet) []string {
+			var subnets []string
+			for _, q := range quarantined {
+				subnets = append(subnets, q.OverlaySubnet)
+			}
+			return subnets
+		}
+
+		It("returns the subnets whose quarantine has not ended, ending first first", func() {
+			Expect(databaseHandler.QuarantineSubnet("10.255.17.0/24", "", 600)).To(Succeed())
+			Expect(databaseHandler.QuarantineSubnet("fd00:0:0:1::/64", "", 300)).To(Succeed())
+			Expect(databaseHandler.QuarantineSubnet("10.255.93.0/24", "", 0)).To(Succeed())
+
+			quarantined, err := databaseHandler.QuarantinedSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subnets(quarantined)).To(Equal([]string{"fd00:0:0:1::/64", "10.255.17.0/24"}))
+
+			Expect(quarantined[0].QuarantinedUntil).To(BeNumerically("~", time.Now().Unix()+300, 5))
+			Expect(quarantined[1].QuarantinedUntil).To(BeNumerically("~", time.Now().Unix()+600, 5))
+		})
+
+		It("starts the quarantine of a subnet over when it is quarantined again", func() {
+			Expect(databaseHandler.QuarantineSubnet("10.255.17.0/24", "", 600)).To(Succeed())
+			Expect(databaseHandler.QuarantineSubnet("10.255.17.0/24", "", 0)).To(Succeed())
+
+			quarantined, err := databaseHandler.QuarantinedSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quarantined).To(BeEmpty())
+		})
+
+		It("deletes the quarantines that have ended", func() {
+			Expect(databaseHandler.QuarantineSubnet("10.255.17.0/24", "", 0)).To(Succeed())
+			Expect(databaseHandler.QuarantineSubnet("10.255.93.0/24", "", 600)).To(Succeed())
+
+			var count int
+			Expect(realDb.QueryRow("SELECT COUNT(*) FROM quarantined_subnets").Scan(&count)).To(Succeed())
+			Expect(count).To(Equal(1))
+		})
+
+		It("is scoped to the pool", func() {
+			Expect(databaseHandler.QuarantineSubnet("10.255.17.0/24", "", 600)).To(Succeed())
+			Expect(databaseHandler.QuarantineSubnet("10.250.17.0/24", "blue", 600)).To(Succeed())
+
+			quarantined, err := databaseHandler.ForPool("blue").QuarantinedSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quarantined).To(HaveLen(1))
+			Expect(quarantined[0].OverlaySubnet).To(Equal("10.250.17.0/24"))
+			Expect(quarantined[0].Pool).To(Equal("blue"))
+		})
+
+		Context("when quarantining fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.ExecReturns(nil, errors.New("strawberry"))
+			})
+			It("returns an error", func() {
+				err := databaseHandler.QuarantineSubnet("10.255.17.0/24", "", 600)
+				Expect(err).To(MatchError("quarantining subnet: strawberry"))
+			})
+		})
+
+		Context("when the query fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.QueryReturns(nil, errors.New("strawberry"))
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.QuarantinedSubnets()
+				Expect(err).To(MatchError("selecting quarantined subnets: strawberry"))
+			})
+		})
+	})
+
 	Describe("AllActive", func() {
 		BeforeEach(func() {
 			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
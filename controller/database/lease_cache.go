@@ -0,0 +1,196 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+// LeaseCache is a Store that answers All, AllActive and LeaseRecords from an
+// earlier answer of the store it wraps for up to MaxStaleness. Writes made
+// through the cache, from any of its pools, drop every cached answer. Writes by
+// other controllers are seen once the cached answer is MaxStaleness old, and so
+// are renewals, which are too frequent to drop the cache for: a lease that had
+// expired is only active again once it is.
+type LeaseCache struct {
+	Store
+	state *leaseCacheState
+	pool  *string
+}
+
+type leaseCacheState struct {
+	clock        clock
+	maxStaleness time.Duration
+
+	lock       sync.Mutex
+	generation int64
+	answers    map[leaseCacheKey]leaseCacheAnswer
+	hits       int64
+	misses     int64
+}
+
+type leaseCacheKey struct {
+	pool        string
+	scoped      bool
+	query       string
+	expireAfter int
+}
+
+type leaseCacheAnswer struct {
+	leases   []controller.Lease
+	records  []controller.LeaseRecord
+	loadedAt time.Time
+}
+
+func NewLeaseCache(store Store, maxStaleness time.Duration, clock clock) *LeaseCache {
+	return &LeaseCache{
+		Store: store,
+		state: &leaseCacheState{
+			clock:        clock,
+			maxStaleness: maxStaleness,
+			answers:      map[leaseCacheKey]leaseCacheAnswer{},
+		},
+	}
+}
+
+func (c *LeaseCache) ForPool(pool string) Store {
+	return &LeaseCache{
+		Store: c.Store.ForPool(pool),
+		state: c.state,
+		pool:  &pool,
+	}
+}
+
+func (c *LeaseCache) All() ([]controller.Lease, error) {
+	answer, err := c.cached("all", 0, func() (leaseCacheAnswer, error) {
+		leases, err := c.Store.All()
+		return leaseCacheAnswer{leases: leases}, err
+	})
+	return answer.leases, err
+}
+
+func (c *LeaseCache) AllActive(duration int) ([]controller.Lease, error) {
+	answer, err := c.cached("active", duration, func() (leaseCacheAnswer, error) {
+		leases, err := c.Store.AllActive(duration)
+		return leaseCacheAnswer{leases: leases}, err
+	})
+	return answer.leases, err
+}
+
+func (c *LeaseCache) LeaseRecords(expirationTime int) ([]controller.LeaseRecord, error) {
+	answer, err := c.cached("records", expirationTime, func() (leaseCacheAnswer, error) {
+		records, err := c.Store.LeaseRecords(expirationTime)
+		return leaseCacheAnswer{records: records}, err
+	})
+	return answer.records, err
+}
+
+func (c *LeaseCache) AddEntry(lease controller.Lease) error {
+	defer c.invalidate()
+	return c.Store.AddEntry(lease)
+}
+
+func (c *LeaseCache) DeleteEntry(underlayIP string) error {
+	defer c.invalidate()
+	return c.Store.DeleteEntry(underlayIP)
+}
+
+func (c *LeaseCache) DeleteExpiredEntry(underlayIP string, duration int) error {
+	defer c.invalidate()
+	return c.Store.DeleteExpiredEntry(underlayIP, duration)
+}
+
+// SetHostForUnderlayIP only drops the cache when the host changed, since
+// hosts send their metadata with every renewal.
+func (c *LeaseCache) SetHostForUnderlayIP(underlayIP string, host controller.HostMetadata) error {
+	err := c.Store.SetHostForUnderlayIP(underlayIP, host)
+	if err == nil {
+		c.invalidate()
+	}
+	return err
+}
+
+func (c *LeaseCache) WithAllocationLock(f func(LeaseStore) error) error {
+	defer c.invalidate()
+	return c.Store.WithAllocationLock(f)
+}
+
+// Hits is the number of answers served from the cache, each one a query the
+// store did not run.
+func (c *LeaseCache) Hits() int64 {
+	c.state.lock.Lock()
+	defer c.state.lock.Unlock()
+	return c.state.hits
+}
+
+// HitRatio is the share of the answers served from the cache, or 0 before
+// the first.
+func (c *LeaseCache) HitRatio() float64 {
+	c.state.lock.Lock()
+	defer c.state.lock.Unlock()
+	if c.state.hits+c.state.misses == 0 {
+		return 0
+	}
+	return float64(c.state.hits) / float64(c.state.hits+c.state.misses)
+}
+
+func (c *LeaseCache) key(query string, expireAfter int) leaseCacheKey {
+	key := leaseCacheKey{query: query, expireAfter: expireAfter}
+	if c.pool != nil {
+		key.pool = *c.pool
+		key.scoped = true
+	}
+	return key
+}
+
+// cached answers from the cache while the answer is fresh, and otherwise
+// loads it. A loaded answer is only kept if no write went through the cache
+// while it was loaded, since it may predate the write.
+func (c *LeaseCache) cached(query string, expireAfter int, load func() (leaseCacheAnswer, error)) (leaseCacheAnswer, error) {
+	key := c.key(query, expireAfter)
+
+	c.state.lock.Lock()
+	answer, ok := c.state.answers[key]
+	if ok && c.state.clock.Now().Sub(answer.loadedAt) < c.state.maxStaleness {
+		c.state.hits++
+		c.state.lock.Unlock()
+		return answer.copy(), nil
+	}
+	c.state.misses++
+	generation := c.state.generation
+	loadedAt := c.state.clock.Now()
+	c.state.lock.Unlock()
+
+	answer, err := load()
+	if err != nil {
+		return leaseCacheAnswer{}, err
+	}
+
+	c.state.lock.Lock()
+	if c.state.generation == generation {
+		answer.loadedAt = loadedAt
+		c.state.answers[key] = answer.copy()
+	}
+	c.state.lock.Unlock()
+	return answer, nil
+}
+
+func (c *LeaseCache) invalidate() {
+	c.state.lock.Lock()
+	defer c.state.lock.Unlock()
+	c.state.generation++
+	c.state.answers = map[leaseCacheKey]leaseCacheAnswer{}
+}
+
+// copy copies the slices of the answer, which share the hosts of the records
+// since those are never changed.
+func (a leaseCacheAnswer) copy() leaseCacheAnswer {
+	if a.leases != nil {
+		a.leases = append([]controller.Lease{}, a.leases...)
+	}
+	if a.records != nil {
+		a.records = append([]controller.LeaseRecord{}, a.records...)
+	}
+	return a
+}
@@ -0,0 +1,147 @@
+package database_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+	"code.cloudfoundry.org/silk/controller/database/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeaseCache", func() {
+	Describe("as a Store", func() {
+		describeStore(func() database.Store {
+			return database.NewLeaseCache(database.NewMemoryStore(database.ClockFunc(time.Now)), time.Minute, database.ClockFunc(time.Now))
+		})
+	})
+
+	Describe("caching", func() {
+		var (
+			clock  *fakes.Clock
+			store  *database.MemoryStore
+			cache  *database.LeaseCache
+			lease  controller.Lease
+			lease2 controller.Lease
+		)
+
+		advance := func(seconds int) {
+			clock.NowReturns(clock.Now().Add(time.Duration(seconds) * time.Second))
+		}
+
+		BeforeEach(func() {
+			clock = &fakes.Clock{}
+			clock.NowReturns(time.Unix(1700000000, 0))
+			store = database.NewMemoryStore(clock)
+			cache = database.NewLeaseCache(store, 5*time.Second, clock)
+
+			lease = controller.Lease{
+				UnderlayIP:          "10.244.11.22",
+				OverlaySubnet:       "10.255.17.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:ff:11:00",
+			}
+			lease2 = controller.Lease{
+				UnderlayIP:          "10.244.22.33",
+				OverlaySubnet:       "10.255.93.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:ff:5d:0f",
+			}
+			Expect(cache.AddEntry(lease)).To(Succeed())
+		})
+
+		It("answers from the cache until the answer is too stale", func() {
+			Expect(cache.All()).To(ConsistOf(lease))
+			Expect(cache.Hits()).To(BeZero())
+
+			Expect(store.AddEntry(lease2)).To(Succeed())
+			advance(4)
+			Expect(cache.All()).To(ConsistOf(lease))
+			Expect(cache.AllActive(30)).To(ConsistOf(lease, lease2))
+			Expect(cache.AllActive(30)).To(ConsistOf(lease, lease2))
+			Expect(cache.Hits()).To(Equal(int64(2)))
+			Expect(cache.HitRatio()).To(BeNumerically("==", 0.5))
+
+			advance(1)
+			Expect(cache.All()).To(ConsistOf(lease, lease2))
+			Expect(cache.Hits()).To(Equal(int64(2)))
+		})
+
+		It("caches the answers of each pool apart", func() {
+			lease2.Pool = "blue"
+			Expect(cache.ForPool("blue").AddEntry(lease2)).To(Succeed())
+
+			Expect(cache.ForPool("").All()).To(ConsistOf(lease))
+			Expect(cache.ForPool("blue").All()).To(ConsistOf(lease2))
+			Expect(cache.All()).To(ConsistOf(lease, lease2))
+			Expect(cache.Hits()).To(BeZero())
+
+			Expect(cache.ForPool("blue").All()).To(ConsistOf(lease2))
+			Expect(cache.Hits()).To(Equal(int64(1)))
+		})
+
+		It("does not hand out the cached leases to be changed", func() {
+			leases, err := cache.All()
+			Expect(err).NotTo(HaveOccurred())
+			leases[0].UnderlayIP = "10.244.99.99"
+
+			Expect(cache.All()).To(ConsistOf(lease))
+		})
+
+		Describe("writes through the cache", func() {
+			BeforeEach(func() {
+				Expect(cache.All()).To(ConsistOf(lease))
+				Expect(cache.ForPool("").AllActive(30)).To(ConsistOf(lease))
+			})
+
+			It("drops the cached answers of every pool when a lease is added", func() {
+				lease2.Pool = "blue"
+				Expect(cache.ForPool("blue").AddEntry(lease2)).To(Succeed())
+
+				Expect(cache.All()).To(ConsistOf(lease, lease2))
+				Expect(cache.ForPool("").AllActive(30)).To(ConsistOf(lease))
+				Expect(cache.Hits()).To(BeZero())
+			})
+
+			It("drops the cached answers when a lease is deleted", func() {
+				Expect(cache.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+				Expect(cache.All()).To(BeEmpty())
+			})
+
+			It("drops the cached answers when an expired lease is deleted", func() {
+				advance(31)
+				Expect(cache.DeleteExpiredEntry(lease.UnderlayIP, 30)).To(Succeed())
+				Expect(cache.All()).To(BeEmpty())
+			})
+
+			It("drops the cached answers when leases are allocated", func() {
+				err := cache.WithAllocationLock(func(locked database.LeaseStore) error {
+					return locked.AddEntry(lease2)
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cache.All()).To(ConsistOf(lease, lease2))
+			})
+
+			It("drops them when the host of a lease changes, but not when it stays the same", func() {
+				host := controller.HostMetadata{Hostname: "diego-cell-0"}
+				Expect(cache.LeaseRecords(30)).To(HaveLen(1))
+				Expect(cache.SetHostForUnderlayIP(lease.UnderlayIP, host)).To(Succeed())
+
+				records, err := cache.LeaseRecords(30)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(records[0].Host).To(Equal(&host))
+				Expect(cache.Hits()).To(BeZero())
+
+				Expect(cache.SetHostForUnderlayIP(lease.UnderlayIP, host)).To(Equal(database.RecordNotAffectedError))
+				Expect(cache.LeaseRecords(30)).To(HaveLen(1))
+				Expect(cache.Hits()).To(Equal(int64(1)))
+			})
+
+			It("does not drop them when a lease is renewed", func() {
+				Expect(cache.RenewLeaseForUnderlayIP(lease.UnderlayIP)).To(Succeed())
+				Expect(cache.All()).To(ConsistOf(lease))
+				Expect(cache.Hits()).To(Equal(int64(1)))
+			})
+		})
+	})
+})
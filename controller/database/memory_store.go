@@ -0,0 +1,685 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/clock.go --fake-name Clock . clock
+type clock interface {
+	Now() time.Time
+}
+
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// MemoryStore keeps the leases, reservations, events and quarantines in
+// memory, with the same semantics as a DatabaseHandler, for controllers whose
+// state may be lost when they stop. Times come from the clock, in whole
+// seconds like the database's.
+type MemoryStore struct {
+	clock clock
+	state *memoryState
+	pool  *string
+	// locked is set on the store handed to WithAllocationLock, which already
+	// holds the state's lock.
+	locked bool
+}
+
+type memoryState struct {
+	lock         sync.Mutex
+	sequence     int64
+	leases       map[string]memoryLease
+	reservations map[string]controller.Reservation
+	events       []controller.LeaseEvent
+	quarantined  map[string]memoryQuarantine
+
+	// the underlay ips of the leases by overlay subnet and hardware address
+	subnets map[string]string
+	hwaddrs map[string]string
+
+	// the number of changes to the leases of each pool
+	generations map[string]int64
+
+	// while WithAllocationLock runs, undo reverts the changes made so far,
+	// latest last
+	journaling bool
+	undo       []func()
+
+	leader          string
+	leaderRenewedAt int64
+}
+
+type memoryLease struct {
+	lease         controller.Lease
+	lastRenewedAt int64
+	sequence      int64
+	// host is encoded like in the database, so that it is never shared
+	host string
+}
+
+type memoryQuarantine struct {
+	subnet   controller.QuarantinedSubnet
+	sequence int64
+}
+
+func NewMemoryStore(clock clock) *MemoryStore {
+	return &MemoryStore{
+		clock: clock,
+		state: &memoryState{
+			leases:       map[string]memoryLease{},
+			reservations: map[string]controller.Reservation{},
+			quarantined:  map[string]memoryQuarantine{},
+			subnets:      map[string]string{},
+			hwaddrs:      map[string]string{},
+			generations:  map[string]int64{},
+		},
+	}
+}
+
+func (m *MemoryStore) Migrate() (int, error) {
+	return 0, nil
+}
+
+func (m *MemoryStore) CheckDatabase() error {
+	return nil
+}
+
+func (m *MemoryStore) ForPool(pool string) Store {
+	scoped := *m
+	scoped.pool = &pool
+	return &scoped
+}
+
+// WithAllocationLock runs f while no other call can read or change the store,
+// and undoes the changes of f if it fails.
+func (m *MemoryStore) WithAllocationLock(f func(LeaseStore) error) error {
+	m.state.lock.Lock()
+	defer m.state.lock.Unlock()
+
+	sequence := m.state.sequence
+	m.state.journaling = true
+	defer func() {
+		m.state.journaling, m.state.undo = false, nil
+	}()
+
+	locked := *m
+	locked.locked = true
+	err := f(&locked)
+	if err != nil {
+		for i := len(m.state.undo) - 1; i >= 0; i-- {
+			m.state.undo[i]()
+		}
+		m.state.sequence = sequence
+		return err
+	}
+	return nil
+}
+
+func (m *MemoryStore) All() ([]controller.Lease, error) {
+	return m.selectLeases(func(memoryLease) bool { return true }), nil
+}
+
+func (m *MemoryStore) AllSingleIPSubnets() ([]controller.Lease, error) {
+	return m.selectLeases(func(l memoryLease) bool { return isSingleIP(l.lease) }), nil
+}
+
+func (m *MemoryStore) AllBlockSubnets() ([]controller.Lease, error) {
+	return m.selectLeases(func(l memoryLease) bool { return isBlock(l.lease) }), nil
+}
+
+func (m *MemoryStore) AllBlockSubnetsV6() ([]controller.Lease, error) {
+	return m.selectLeases(func(l memoryLease) bool { return l.lease.OverlaySubnetV6 != "" }), nil
+}
+
+func (m *MemoryStore) AllActive(duration int) ([]controller.Lease, error) {
+	now := m.now()
+	return m.selectLeases(func(l memoryLease) bool { return !l.expired(duration, now) }), nil
+}
+
+func (m *MemoryStore) AllExpired(expirationTime int) ([]controller.Lease, error) {
+	now := m.now()
+	return m.selectLeases(func(l memoryLease) bool { return l.expired(expirationTime, now) }), nil
+}
+
+// LeaseRecords returns every lease with the time it was last renewed, marking
+// those not renewed within the expiration time as expired.
+func (m *MemoryStore) LeaseRecords(expirationTime int) ([]controller.LeaseRecord, error) {
+	records := []controller.LeaseRecord{}
+	var err error
+	m.withState(func(state *memoryState) {
+		now := m.now()
+		for _, l := range state.leases {
+			if !m.inPool(l.lease.Pool) {
+				continue
+			}
+			record := controller.LeaseRecord{
+				Lease:         l.lease,
+				LastRenewedAt: l.lastRenewedAt,
+				Expired:       l.expired(expirationTime, now),
+			}
+			if l.host != "" {
+				record.Host = &controller.HostMetadata{}
+				if decodeErr := json.Unmarshal([]byte(l.host), record.Host); decodeErr != nil {
+					err = fmt.Errorf("selecting lease records: parsing host of %s: %s", l.lease.UnderlayIP, decodeErr)
+				}
+			}
+			records = append(records, record)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Pool != records[j].Pool {
+			return records[i].Pool < records[j].Pool
+		}
+		return records[i].UnderlayIP < records[j].UnderlayIP
+	})
+	return records, nil
+}
+
+// OldestExpiredBlockSubnet never returns a reserved subnet, so that it stays
+// with the underlay ip it is reserved for.
+func (m *MemoryStore) OldestExpiredBlockSubnet(expirationTime int) (*controller.Lease, error) {
+	var oldest *controller.Lease
+	m.withState(func(state *memoryState) {
+		reserved := map[string]bool{}
+		for _, reservation := range state.reservations {
+			reserved[reservation.OverlaySubnet] = true
+		}
+		oldest = m.oldestExpired(state, expirationTime, func(l controller.Lease) bool {
+			return isBlock(l) && !reserved[l.OverlaySubnet]
+		})
+	})
+	return oldest, nil
+}
+
+func (m *MemoryStore) OldestExpiredBlockSubnetV6(expirationTime int) (*controller.Lease, error) {
+	var oldest *controller.Lease
+	m.withState(func(state *memoryState) {
+		oldest = m.oldestExpired(state, expirationTime, func(l controller.Lease) bool { return l.OverlaySubnetV6 != "" })
+	})
+	return oldest, nil
+}
+
+func (m *MemoryStore) OldestExpiredSingleIP(expirationTime int) (*controller.Lease, error) {
+	var oldest *controller.Lease
+	m.withState(func(state *memoryState) {
+		oldest = m.oldestExpired(state, expirationTime, isSingleIP)
+	})
+	return oldest, nil
+}
+
+func (m *MemoryStore) AddEntry(lease controller.Lease) error {
+	var err error
+	m.withState(func(state *memoryState) {
+		_, exists := state.leases[lease.UnderlayIP]
+		switch {
+		case exists:
+			err = fmt.Errorf("underlay ip %s already has a lease", lease.UnderlayIP)
+		case lease.OverlaySubnet != "" && state.subnets[lease.OverlaySubnet] != "":
+			err = fmt.Errorf("overlay subnet %s is already leased", lease.OverlaySubnet)
+		case lease.OverlaySubnetV6 != "" && state.subnets[lease.OverlaySubnetV6] != "":
+			err = fmt.Errorf("overlay subnet %s is already leased", lease.OverlaySubnetV6)
+		case state.hwaddrs[lease.OverlayHardwareAddr] != "":
+			err = fmt.Errorf("overlay hardware address %s is already leased", lease.OverlayHardwareAddr)
+		}
+		if err != nil {
+			err = fmt.Errorf("adding entry: %s", err)
+			return
+		}
+		state.sequence++
+		state.putLease(memoryLease{
+			lease:         lease,
+			lastRenewedAt: m.now(),
+			sequence:      state.sequence,
+		})
+		state.bumpGeneration(lease.Pool)
+	})
+	return err
+}
+
+// Generation counts the changes to the leases of the pool, like the one of a
+// DatabaseHandler.
+func (m *MemoryStore) Generation() (int64, error) {
+	var generation int64
+	m.withState(func(state *memoryState) {
+		pool := ""
+		if m.pool != nil {
+			pool = *m.pool
+		}
+		generation = state.generations[pool]
+	})
+	return generation, nil
+}
+
+func (m *MemoryStore) DeleteEntry(underlayIP string) error {
+	return m.deleteEntry(underlayIP, func(memoryLease) bool { return true })
+}
+
+// DeleteExpiredEntry deletes the lease of the underlay ip only if it is still
+// expired, so that a lease renewed since it was found expired is kept.
+func (m *MemoryStore) DeleteExpiredEntry(underlayIP string, expirationTime int) error {
+	now := m.now()
+	return m.deleteEntry(underlayIP, func(l memoryLease) bool { return l.expired(expirationTime, now) })
+}
+
+func (m *MemoryStore) LeaseForUnderlayIP(underlayIP string) (*controller.Lease, error) {
+	var lease *controller.Lease
+	m.withState(func(state *memoryState) {
+		if l, ok := state.leases[underlayIP]; ok {
+			lease = &l.lease
+		}
+	})
+	return lease, nil
+}
+
+func (m *MemoryStore) RenewLeaseForUnderlayIP(underlayIP string) error {
+	m.withState(func(state *memoryState) {
+		if l, ok := state.leases[underlayIP]; ok {
+			l.lastRenewedAt = m.now()
+			state.putLease(l)
+		}
+	})
+	return nil
+}
+
+func (m *MemoryStore) SetHostForUnderlayIP(underlayIP string, host controller.HostMetadata) error {
+	encoded, err := json.Marshal(host)
+	if err != nil {
+		return fmt.Errorf("encoding host: %s", err)
+	}
+
+	affected := false
+	m.withState(func(state *memoryState) {
+		if l, ok := state.leases[underlayIP]; ok && l.host != string(encoded) {
+			l.host = string(encoded)
+			state.putLease(l)
+			affected = true
+		}
+	})
+	if !affected {
+		return RecordNotAffectedError
+	}
+	return nil
+}
+
+func (m *MemoryStore) LastRenewedAtForUnderlayIP(underlayIP string) (int64, error) {
+	var lastRenewedAt int64
+	var ok bool
+	m.withState(func(state *memoryState) {
+		var l memoryLease
+		l, ok = state.leases[underlayIP]
+		lastRenewedAt = l.lastRenewedAt
+	})
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	return lastRenewedAt, nil
+}
+
+func (m *MemoryStore) AddReservation(reservation controller.Reservation) error {
+	var err error
+	m.withState(func(state *memoryState) {
+		for _, existing := range state.reservations {
+			switch {
+			case existing.UnderlayIP == reservation.UnderlayIP:
+				err = fmt.Errorf("adding reservation: underlay ip %s already has a reservation", reservation.UnderlayIP)
+				return
+			case existing.OverlaySubnet == reservation.OverlaySubnet:
+				err = fmt.Errorf("adding reservation: overlay subnet %s is already reserved", reservation.OverlaySubnet)
+				return
+			}
+		}
+		state.putReservation(reservation.UnderlayIP, &reservation)
+	})
+	return err
+}
+
+func (m *MemoryStore) DeleteReservation(underlayIP string) error {
+	var err error
+	m.withState(func(state *memoryState) {
+		if _, ok := state.reservations[underlayIP]; !ok {
+			err = RecordNotAffectedError
+			return
+		}
+		state.putReservation(underlayIP, nil)
+	})
+	return err
+}
+
+func (m *MemoryStore) ReservationForUnderlayIP(underlayIP string) (*controller.Reservation, error) {
+	var reservation *controller.Reservation
+	m.withState(func(state *memoryState) {
+		if r, ok := state.reservations[underlayIP]; ok {
+			reservation = &r
+		}
+	})
+	return reservation, nil
+}
+
+func (m *MemoryStore) AllReservations() ([]controller.Reservation, error) {
+	reservations := []controller.Reservation{}
+	m.withState(func(state *memoryState) {
+		for _, reservation := range state.reservations {
+			if m.inPool(reservation.Pool) {
+				reservations = append(reservations, reservation)
+			}
+		}
+	})
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].UnderlayIP < reservations[j].UnderlayIP
+	})
+	return reservations, nil
+}
+
+// AddEvent records the event with the clock's current time, whatever the
+// timestamp of the event.
+func (m *MemoryStore) AddEvent(event controller.LeaseEvent) error {
+	m.withState(func(state *memoryState) {
+		event.Timestamp = m.now()
+		n := len(state.events)
+		state.events = append(state.events, event)
+		state.journal(func() { state.events = state.events[:n] })
+	})
+	return nil
+}
+
+// Events returns the oldest events that match the filter, up to
+// maxLeaseEvents of them, in the order they happened.
+func (m *MemoryStore) Events(filter controller.LeaseEventFilter) ([]controller.LeaseEvent, error) {
+	events := []controller.LeaseEvent{}
+	m.withState(func(state *memoryState) {
+		for _, event := range state.events {
+			if m.inPool(event.Pool) && matchesEventFilter(event, filter) {
+				events = append(events, event)
+			}
+		}
+	})
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+	if len(events) > maxLeaseEvents {
+		events = events[:maxLeaseEvents]
+	}
+	return events, nil
+}
+
+// QuarantineSubnet keeps the subnet of the pool from being handed out for the
+// given number of seconds. Quarantines that have ended are deleted on the way.
+func (m *MemoryStore) QuarantineSubnet(subnet, pool string, seconds int) error {
+	m.withState(func(state *memoryState) {
+		now := m.now()
+		for key, q := range state.quarantined {
+			if q.subnet.QuarantinedUntil <= now {
+				state.putQuarantine(key, nil)
+			}
+		}
+		state.sequence++
+		state.putQuarantine(subnet, &memoryQuarantine{
+			subnet: controller.QuarantinedSubnet{
+				OverlaySubnet:    subnet,
+				Pool:             pool,
+				QuarantinedUntil: now + int64(seconds),
+			},
+			sequence: state.sequence,
+		})
+	})
+	return nil
+}
+
+// QuarantinedSubnets returns the subnets whose quarantine has not ended yet,
+// the ones that end first first.
+func (m *MemoryStore) QuarantinedSubnets() ([]controller.QuarantinedSubnet, error) {
+	var found []memoryQuarantine
+	m.withState(func(state *memoryState) {
+		now := m.now()
+		for _, q := range state.quarantined {
+			if m.inPool(q.subnet.Pool) && q.subnet.QuarantinedUntil > now {
+				found = append(found, q)
+			}
+		}
+	})
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].subnet.QuarantinedUntil != found[j].subnet.QuarantinedUntil {
+			return found[i].subnet.QuarantinedUntil < found[j].subnet.QuarantinedUntil
+		}
+		return found[i].sequence < found[j].sequence
+	})
+	quarantined := []controller.QuarantinedSubnet{}
+	for _, q := range found {
+		quarantined = append(quarantined, q.subnet)
+	}
+	return quarantined, nil
+}
+
+// ClaimLeadership makes holder the leader if it already is or if the leader
+// has not claimed leadership within the last ttlSeconds, and returns the
+// leader either way.
+func (m *MemoryStore) ClaimLeadership(holder string, ttlSeconds int) (string, error) {
+	var leader string
+	m.withState(func(state *memoryState) {
+		now := m.now()
+		if state.leader == "" || state.leader == holder || state.leaderRenewedAt+int64(ttlSeconds) <= now {
+			state.leader = holder
+			state.leaderRenewedAt = now
+		}
+		leader = state.leader
+	})
+	return leader, nil
+}
+
+func (m *MemoryStore) ResignLeadership(holder string) error {
+	m.withState(func(state *memoryState) {
+		if state.leader == holder {
+			state.leaderRenewedAt = 0
+		}
+	})
+	return nil
+}
+
+func (m *MemoryStore) now() int64 {
+	return m.clock.Now().Unix()
+}
+
+func (m *MemoryStore) withState(f func(*memoryState)) {
+	if !m.locked {
+		m.state.lock.Lock()
+		defer m.state.lock.Unlock()
+	}
+	f(m.state)
+}
+
+func (m *MemoryStore) inPool(pool string) bool {
+	return m.pool == nil || *m.pool == pool
+}
+
+// selectLeases returns the leases of the pool that match, in the order they
+// were added.
+func (m *MemoryStore) selectLeases(match func(memoryLease) bool) []controller.Lease {
+	var selected []memoryLease
+	m.withState(func(state *memoryState) {
+		for _, l := range state.leases {
+			if m.inPool(l.lease.Pool) && match(l) {
+				selected = append(selected, l)
+			}
+		}
+	})
+	sort.Slice(selected, func(i, j int) bool { return selected[i].sequence < selected[j].sequence })
+
+	leases := []controller.Lease{}
+	for _, l := range selected {
+		leases = append(leases, l.lease)
+	}
+	return leases
+}
+
+func (m *MemoryStore) oldestExpired(state *memoryState, expirationTime int, match func(controller.Lease) bool) *controller.Lease {
+	now := m.now()
+	var oldest *memoryLease
+	for _, l := range state.leases {
+		if !m.inPool(l.lease.Pool) || !match(l.lease) || !l.expired(expirationTime, now) {
+			continue
+		}
+		if oldest == nil || l.lastRenewedAt < oldest.lastRenewedAt ||
+			(l.lastRenewedAt == oldest.lastRenewedAt && l.sequence < oldest.sequence) {
+			l := l
+			oldest = &l
+		}
+	}
+	if oldest == nil {
+		return nil
+	}
+	return &oldest.lease
+}
+
+func (m *MemoryStore) deleteEntry(underlayIP string, match func(memoryLease) bool) error {
+	var err error
+	m.withState(func(state *memoryState) {
+		l, ok := state.leases[underlayIP]
+		if !ok || !match(l) {
+			err = RecordNotAffectedError
+			return
+		}
+		state.deleteLease(underlayIP)
+		state.bumpGeneration(l.lease.Pool)
+	})
+	return err
+}
+
+func (l memoryLease) expired(expirationTime int, now int64) bool {
+	return l.lastRenewedAt+int64(expirationTime) <= now
+}
+
+// journal keeps what reverts a change while WithAllocationLock runs.
+func (s *memoryState) journal(undo func()) {
+	if s.journaling {
+		s.undo = append(s.undo, undo)
+	}
+}
+
+// putLease adds the lease or replaces the one of its underlay ip.
+func (s *memoryState) putLease(l memoryLease) {
+	underlayIP := l.lease.UnderlayIP
+	previous, had := s.leases[underlayIP]
+	if had {
+		s.unindex(previous)
+	}
+	s.leases[underlayIP] = l
+	s.index(l)
+
+	s.journal(func() {
+		s.unindex(l)
+		delete(s.leases, underlayIP)
+		if had {
+			s.leases[underlayIP] = previous
+			s.index(previous)
+		}
+	})
+}
+
+func (s *memoryState) deleteLease(underlayIP string) {
+	l, ok := s.leases[underlayIP]
+	if !ok {
+		return
+	}
+	s.unindex(l)
+	delete(s.leases, underlayIP)
+
+	s.journal(func() {
+		s.leases[underlayIP] = l
+		s.index(l)
+	})
+}
+
+func (s *memoryState) index(l memoryLease) {
+	for _, subnet := range []string{l.lease.OverlaySubnet, l.lease.OverlaySubnetV6} {
+		if subnet != "" {
+			s.subnets[subnet] = l.lease.UnderlayIP
+		}
+	}
+	s.hwaddrs[l.lease.OverlayHardwareAddr] = l.lease.UnderlayIP
+}
+
+func (s *memoryState) unindex(l memoryLease) {
+	for _, subnet := range []string{l.lease.OverlaySubnet, l.lease.OverlaySubnetV6} {
+		if subnet != "" && s.subnets[subnet] == l.lease.UnderlayIP {
+			delete(s.subnets, subnet)
+		}
+	}
+	if s.hwaddrs[l.lease.OverlayHardwareAddr] == l.lease.UnderlayIP {
+		delete(s.hwaddrs, l.lease.OverlayHardwareAddr)
+	}
+}
+
+func (s *memoryState) bumpGeneration(pool string) {
+	s.generations[pool]++
+	s.journal(func() { s.generations[pool]-- })
+}
+
+// putReservation sets the reservation of the underlay ip, deleting it if nil.
+func (s *memoryState) putReservation(underlayIP string, reservation *controller.Reservation) {
+	previous, had := s.reservations[underlayIP]
+	if reservation == nil {
+		delete(s.reservations, underlayIP)
+	} else {
+		s.reservations[underlayIP] = *reservation
+	}
+
+	s.journal(func() {
+		delete(s.reservations, underlayIP)
+		if had {
+			s.reservations[underlayIP] = previous
+		}
+	})
+}
+
+// putQuarantine sets the quarantine of the subnet, deleting it if nil.
+func (s *memoryState) putQuarantine(subnet string, quarantine *memoryQuarantine) {
+	previous, had := s.quarantined[subnet]
+	if quarantine == nil {
+		delete(s.quarantined, subnet)
+	} else {
+		s.quarantined[subnet] = *quarantine
+	}
+
+	s.journal(func() {
+		delete(s.quarantined, subnet)
+		if had {
+			s.quarantined[subnet] = previous
+		}
+	})
+}
+
+func isSingleIP(lease controller.Lease) bool {
+	return strings.HasSuffix(lease.OverlaySubnet, "/32")
+}
+
+func isBlock(lease controller.Lease) bool {
+	return lease.OverlaySubnet != "" && !isSingleIP(lease)
+}
+
+func matchesEventFilter(event controller.LeaseEvent, filter controller.LeaseEventFilter) bool {
+	switch {
+	case filter.UnderlayIP != "" && event.UnderlayIP != filter.UnderlayIP:
+		return false
+	case filter.OverlaySubnet != "" && event.OverlaySubnet != filter.OverlaySubnet && event.OverlaySubnetV6 != filter.OverlaySubnet:
+		return false
+	case filter.Since != 0 && event.Timestamp < filter.Since:
+		return false
+	case filter.Until != 0 && event.Timestamp > filter.Until:
+		return false
+	}
+	return true
+}
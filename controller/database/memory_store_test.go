@@ -0,0 +1,181 @@
+package database_test
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+	"code.cloudfoundry.org/silk/controller/database/fakes"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MemoryStore", func() {
+	Describe("as a Store", func() {
+		describeStore(func() database.Store {
+			return database.NewMemoryStore(database.ClockFunc(time.Now))
+		})
+	})
+
+	Describe("with a clock", func() {
+		var (
+			clock  *fakes.Clock
+			store  *database.MemoryStore
+			lease  controller.Lease
+			lease2 controller.Lease
+			start  time.Time
+		)
+
+		advance := func(seconds int) {
+			clock.NowReturns(clock.Now().Add(time.Duration(seconds) * time.Second))
+		}
+
+		BeforeEach(func() {
+			start = time.Unix(1700000000, 0)
+			clock = &fakes.Clock{}
+			clock.NowReturns(start)
+			store = database.NewMemoryStore(clock)
+
+			lease = controller.Lease{
+				UnderlayIP:          "10.244.11.22",
+				OverlaySubnet:       "10.255.17.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:ff:11:00",
+			}
+			lease2 = controller.Lease{
+				UnderlayIP:          "10.244.22.33",
+				OverlaySubnet:       "10.255.93.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:ff:5d:0f",
+			}
+			Expect(store.AddEntry(lease)).To(Succeed())
+			advance(10)
+			Expect(store.AddEntry(lease2)).To(Succeed())
+		})
+
+		It("expires a lease once the expiration time has passed since it was renewed", func() {
+			lastRenewedAt, err := store.LastRenewedAtForUnderlayIP(lease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lastRenewedAt).To(Equal(start.Unix()))
+
+			advance(50)
+			leases, err := store.AllExpired(60)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease))
+			leases, err = store.AllActive(60)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease2))
+
+			Expect(store.RenewLeaseForUnderlayIP(lease.UnderlayIP)).To(Succeed())
+			leases, err = store.AllExpired(60)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(BeEmpty())
+
+			records, err := store.LeaseRecords(60)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records[0].LastRenewedAt).To(Equal(start.Unix() + 60))
+			Expect(records[1].LastRenewedAt).To(Equal(start.Unix() + 10))
+		})
+
+		It("reclaims the lease renewed the longest ago first", func() {
+			advance(100)
+			expired, err := store.OldestExpiredBlockSubnet(60)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expired).To(Equal(&lease))
+
+			Expect(store.RenewLeaseForUnderlayIP(lease.UnderlayIP)).To(Succeed())
+			expired, err = store.OldestExpiredBlockSubnet(60)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expired).To(Equal(&lease2))
+		})
+
+		It("only deletes a lease that is still expired", func() {
+			advance(50)
+			Expect(store.DeleteExpiredEntry(lease2.UnderlayIP, 60)).To(Equal(database.RecordNotAffectedError))
+			Expect(store.DeleteExpiredEntry(lease.UnderlayIP, 60)).To(Succeed())
+		})
+
+		It("stamps events with the time of the clock", func() {
+			Expect(store.AddEvent(controller.LeaseEvent{Type: controller.LeaseEventAcquired, Timestamp: 42})).To(Succeed())
+			advance(5)
+			Expect(store.AddEvent(controller.LeaseEvent{Type: controller.LeaseEventRenewed})).To(Succeed())
+
+			events, err := store.Events(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events[0].Timestamp).To(Equal(start.Unix() + 10))
+			Expect(events[1].Timestamp).To(Equal(start.Unix() + 15))
+
+			events, err = store.Events(controller.LeaseEventFilter{Since: start.Unix() + 11})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Type).To(Equal(controller.LeaseEventRenewed))
+		})
+
+		It("lets another holder claim leadership once the leader has not claimed it for the ttl", func() {
+			Expect(store.ClaimLeadership("controller-0", 15)).To(Equal("controller-0"))
+
+			advance(14)
+			Expect(store.ClaimLeadership("controller-1", 15)).To(Equal("controller-0"))
+
+			advance(1)
+			Expect(store.ClaimLeadership("controller-1", 15)).To(Equal("controller-1"))
+		})
+
+		It("ends a quarantine when its time is up", func() {
+			Expect(store.QuarantineSubnet("10.255.17.0/24", "", 30)).To(Succeed())
+
+			advance(29)
+			quarantined, err := store.QuarantinedSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quarantined).To(Equal([]controller.QuarantinedSubnet{
+				{OverlaySubnet: "10.255.17.0/24", QuarantinedUntil: start.Unix() + 40},
+			}))
+
+			advance(1)
+			quarantined, err = store.QuarantinedSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quarantined).To(BeEmpty())
+		})
+	})
+
+	It("hands out a distinct subnet to each of many parallel acquisitions", func() {
+		cidrPool := leaser.NewCIDRPool("10.255.0.0/16", 25)
+		trackedStore := leaser.NewTrackedStore(database.NewMemoryStore(database.ClockFunc(time.Now)))
+		trackedStore.Track(cidrPool)
+		leaseController := &leaser.LeaseController{
+			DatabaseHandler:            trackedStore,
+			HardwareAddressGenerator:   &leaser.HardwareAddressGenerator{},
+			AcquireSubnetLeaseAttempts: 1,
+			CIDRPool:                   cidrPool,
+			LeaseExpirationSeconds:     60,
+			Logger:                     lagertest.NewTestLogger("test"),
+		}
+
+		nHosts := 500
+		subnets := make(chan string, nHosts)
+		var wg sync.WaitGroup
+		for i := 0; i < nHosts; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{
+					UnderlayIP: fmt.Sprintf("10.244.%d.%d", i/256, i%256),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease).NotTo(BeNil())
+				subnets <- lease.OverlaySubnet
+			}(i)
+		}
+		wg.Wait()
+		close(subnets)
+
+		distinct := map[string]struct{}{}
+		for subnet := range subnets {
+			distinct[subnet] = struct{}{}
+		}
+		Expect(distinct).To(HaveLen(nHosts))
+	})
+})
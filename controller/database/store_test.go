@@ -0,0 +1,574 @@
+package database_test
+
+import (
+	"database/sql"
+	"errors"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// describeStore describes the behavior every database.Store shares, whatever
+// keeps its state. newStore is called before each spec for an empty store.
+func describeStore(newStore func() database.Store) {
+	var (
+		store                     database.Store
+		lease, lease2             controller.Lease
+		singleIPLease, v6Lease    controller.Lease
+		blueLease, blueSingleIP   controller.Lease
+		reservation, blueReserved controller.Reservation
+	)
+
+	BeforeEach(func() {
+		store = newStore()
+
+		lease = controller.Lease{
+			UnderlayIP:          "10.244.11.22",
+			OverlaySubnet:       "10.255.17.0/24",
+			OverlayHardwareAddr: "ee:ee:0a:ff:11:00",
+		}
+		lease2 = controller.Lease{
+			UnderlayIP:          "10.244.22.33",
+			OverlaySubnet:       "10.255.93.0/24",
+			OverlayHardwareAddr: "ee:ee:0a:ff:5d:0f",
+		}
+		singleIPLease = controller.Lease{
+			UnderlayIP:          "10.244.11.26",
+			OverlaySubnet:       "10.255.0.12/32",
+			OverlayHardwareAddr: "ee:ee:0a:ff:11:11",
+		}
+		v6Lease = controller.Lease{
+			UnderlayIP:          "10.244.11.30",
+			OverlaySubnetV6:     "fd00:0:0:1e::/64",
+			OverlayHardwareAddr: "ee:ee:0a:ff:11:1e",
+		}
+		blueLease = controller.Lease{
+			UnderlayIP:          "10.244.11.40",
+			OverlaySubnet:       "10.250.40.0/24",
+			OverlayHardwareAddr: "ee:ee:0a:fa:28:00",
+			Pool:                "blue",
+		}
+		blueSingleIP = controller.Lease{
+			UnderlayIP:          "10.244.11.41",
+			OverlaySubnet:       "10.250.0.41/32",
+			OverlayHardwareAddr: "ee:ee:0a:fa:00:29",
+			Pool:                "blue",
+		}
+		reservation = controller.Reservation{UnderlayIP: "10.244.11.50", OverlaySubnet: "10.255.50.0/24"}
+		blueReserved = controller.Reservation{UnderlayIP: "10.244.11.51", OverlaySubnet: "10.250.51.0/24", Pool: "blue"}
+	})
+
+	addLeases := func(leases ...controller.Lease) {
+		for _, l := range leases {
+			Expect(store.AddEntry(l)).To(Succeed())
+		}
+	}
+
+	It("checks the database", func() {
+		Expect(store.CheckDatabase()).To(Succeed())
+	})
+
+	Describe("leases", func() {
+		BeforeEach(func() {
+			addLeases(lease, singleIPLease, v6Lease)
+		})
+
+		It("looks up the lease of an underlay ip", func() {
+			found, err := store.LeaseForUnderlayIP(lease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&lease))
+
+			found, err = store.LeaseForUnderlayIP("10.244.99.99")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeNil())
+		})
+
+		It("lists the leases by kind of subnet", func() {
+			leases, err := store.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease, singleIPLease, v6Lease))
+
+			leases, err = store.AllBlockSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease))
+
+			leases, err = store.AllSingleIPSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(singleIPLease))
+
+			leases, err = store.AllBlockSubnetsV6()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(v6Lease))
+		})
+
+		It("rejects a lease of an underlay ip, subnet or hardware address that is already leased", func() {
+			sameUnderlayIP := lease2
+			sameUnderlayIP.UnderlayIP = lease.UnderlayIP
+			sameSubnet := lease2
+			sameSubnet.OverlaySubnet = lease.OverlaySubnet
+			sameHardwareAddr := lease2
+			sameHardwareAddr.OverlayHardwareAddr = lease.OverlayHardwareAddr
+			sameSubnetV6 := lease2
+			sameSubnetV6.OverlaySubnetV6 = v6Lease.OverlaySubnetV6
+
+			for _, l := range []controller.Lease{sameUnderlayIP, sameSubnet, sameHardwareAddr, sameSubnetV6} {
+				Expect(store.AddEntry(l)).To(MatchError(ContainSubstring("adding entry:")))
+			}
+
+			leases, err := store.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(HaveLen(3))
+		})
+
+		It("deletes the lease of an underlay ip", func() {
+			Expect(store.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+
+			found, err := store.LeaseForUnderlayIP(lease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeNil())
+
+			Expect(store.DeleteEntry(lease.UnderlayIP)).To(Equal(database.RecordNotAffectedError))
+		})
+
+		It("tells when a lease was last renewed", func() {
+			Expect(store.RenewLeaseForUnderlayIP(lease.UnderlayIP)).To(Succeed())
+
+			lastRenewedAt, err := store.LastRenewedAtForUnderlayIP(lease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lastRenewedAt).To(BeNumerically(">", 0))
+
+			_, err = store.LastRenewedAtForUnderlayIP("10.244.99.99")
+			Expect(err).To(Equal(sql.ErrNoRows))
+
+			Expect(store.RenewLeaseForUnderlayIP("10.244.99.99")).To(Succeed())
+		})
+
+		It("splits the leases into active and expired ones", func() {
+			leases, err := store.AllActive(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease, singleIPLease, v6Lease))
+			leases, err = store.AllExpired(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(BeEmpty())
+
+			leases, err = store.AllActive(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(BeEmpty())
+			leases, err = store.AllExpired(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease, singleIPLease, v6Lease))
+		})
+
+		It("returns the lease records ordered by underlay ip", func() {
+			records, err := store.LeaseRecords(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(3))
+			Expect(records[0].Lease).To(Equal(lease))
+			Expect(records[1].Lease).To(Equal(singleIPLease))
+			Expect(records[2].Lease).To(Equal(v6Lease))
+			for _, record := range records {
+				Expect(record.Expired).To(BeFalse())
+			}
+
+			records, err = store.LeaseRecords(0)
+			Expect(err).NotTo(HaveOccurred())
+			for _, record := range records {
+				Expect(record.Expired).To(BeTrue())
+			}
+		})
+
+		It("keeps the host of a lease until the lease is deleted", func() {
+			host := controller.HostMetadata{
+				Hostname: "diego-cell-0",
+				AZ:       "z2",
+				Labels:   map[string]string{"stack": "cflinuxfs4"},
+			}
+			Expect(store.SetHostForUnderlayIP(lease.UnderlayIP, host)).To(Succeed())
+			Expect(store.SetHostForUnderlayIP(lease.UnderlayIP, host)).To(Equal(database.RecordNotAffectedError))
+			Expect(store.SetHostForUnderlayIP("10.244.99.99", host)).To(Equal(database.RecordNotAffectedError))
+
+			records, err := store.LeaseRecords(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records[0].Host).To(Equal(&host))
+			Expect(records[1].Host).To(BeNil())
+
+			host.AZ = "z3"
+			Expect(store.SetHostForUnderlayIP(lease.UnderlayIP, host)).To(Succeed())
+			records, err = store.LeaseRecords(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records[0].Host.AZ).To(Equal("z3"))
+
+			Expect(store.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+			Expect(store.AddEntry(lease)).To(Succeed())
+			records, err = store.LeaseRecords(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records[0].Host).To(BeNil())
+		})
+
+		It("only deletes a lease for having expired when it has", func() {
+			Expect(store.DeleteExpiredEntry(lease.UnderlayIP, 1000)).To(Equal(database.RecordNotAffectedError))
+			Expect(store.DeleteExpiredEntry(lease.UnderlayIP, 0)).To(Succeed())
+			Expect(store.DeleteExpiredEntry(lease.UnderlayIP, 0)).To(Equal(database.RecordNotAffectedError))
+
+			found, err := store.LeaseForUnderlayIP(lease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeNil())
+		})
+
+		It("finds an expired lease of each kind of subnet", func() {
+			expired, err := store.OldestExpiredBlockSubnet(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expired).To(Equal(&lease))
+
+			expired, err = store.OldestExpiredSingleIP(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expired).To(Equal(&singleIPLease))
+
+			expired, err = store.OldestExpiredBlockSubnetV6(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expired).To(Equal(&v6Lease))
+
+			expired, err = store.OldestExpiredBlockSubnet(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expired).To(BeNil())
+		})
+	})
+
+	Describe("ForPool", func() {
+		BeforeEach(func() {
+			addLeases(lease, singleIPLease, blueLease, blueSingleIP)
+			Expect(store.AddReservation(reservation)).To(Succeed())
+			Expect(store.AddReservation(blueReserved)).To(Succeed())
+		})
+
+		It("only lists the leases and reservations of the pool", func() {
+			blue := store.ForPool("blue")
+
+			leases, err := blue.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(blueLease, blueSingleIP))
+
+			leases, err = blue.AllActive(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(blueLease, blueSingleIP))
+
+			leases, err = blue.AllExpired(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(blueLease, blueSingleIP))
+
+			records, err := blue.LeaseRecords(1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(2))
+
+			expired, err := blue.OldestExpiredBlockSubnet(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expired).To(Equal(&blueLease))
+
+			reservations, err := blue.AllReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(ConsistOf(blueReserved))
+		})
+
+		It("treats leases without a pool as belonging to the default pool", func() {
+			leases, err := store.ForPool(controller.DefaultPool).All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease, singleIPLease))
+		})
+
+		It("does not scope lookups by underlay ip", func() {
+			found, err := store.ForPool(controller.DefaultPool).LeaseForUnderlayIP(blueLease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&blueLease))
+
+			foundReservation, err := store.ForPool(controller.DefaultPool).ReservationForUnderlayIP(blueReserved.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(foundReservation).To(Equal(&blueReserved))
+		})
+
+		It("leaves the unscoped store listing every pool", func() {
+			leases, err := store.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(HaveLen(4))
+		})
+	})
+
+	Describe("reservations", func() {
+		BeforeEach(func() {
+			Expect(store.AddReservation(reservation)).To(Succeed())
+		})
+
+		It("looks up, lists and deletes reservations", func() {
+			found, err := store.ReservationForUnderlayIP(reservation.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&reservation))
+
+			reservations, err := store.AllReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(ConsistOf(reservation))
+
+			Expect(store.DeleteReservation(reservation.UnderlayIP)).To(Succeed())
+			Expect(store.DeleteReservation(reservation.UnderlayIP)).To(Equal(database.RecordNotAffectedError))
+
+			found, err = store.ReservationForUnderlayIP(reservation.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeNil())
+		})
+
+		It("rejects a second reservation of the same underlay ip or subnet", func() {
+			err := store.AddReservation(controller.Reservation{UnderlayIP: reservation.UnderlayIP, OverlaySubnet: "10.255.51.0/24"})
+			Expect(err).To(MatchError(ContainSubstring("adding reservation:")))
+
+			err = store.AddReservation(controller.Reservation{UnderlayIP: "10.244.11.52", OverlaySubnet: reservation.OverlaySubnet})
+			Expect(err).To(MatchError(ContainSubstring("adding reservation:")))
+		})
+
+		It("does not reclaim an expired lease on a reserved subnet, whatever the pool", func() {
+			Expect(store.AddReservation(blueReserved)).To(Succeed())
+			addLeases(controller.Lease{
+				UnderlayIP:          reservation.UnderlayIP,
+				OverlaySubnet:       reservation.OverlaySubnet,
+				OverlayHardwareAddr: "ee:ee:0a:ff:32:00",
+			}, controller.Lease{
+				UnderlayIP:          "10.244.11.60",
+				OverlaySubnet:       blueReserved.OverlaySubnet,
+				OverlayHardwareAddr: "ee:ee:0a:ff:3c:00",
+			})
+
+			expired, err := store.OldestExpiredBlockSubnet(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expired).To(BeNil())
+		})
+	})
+
+	Describe("events", func() {
+		var acquired, released, other controller.LeaseEvent
+
+		BeforeEach(func() {
+			acquired = controller.LeaseEvent{Type: controller.LeaseEventAcquired, UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.17.0/24", Actor: "10.244.11.22"}
+			released = controller.LeaseEvent{Type: controller.LeaseEventReleased, UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.17.0/24", Actor: "silk-admin", Reason: "released by request"}
+			other = controller.LeaseEvent{Type: controller.LeaseEventAcquired, UnderlayIP: "10.244.22.33", OverlaySubnetV6: "fd00:0:0:1::/64", Pool: "blue", Actor: "10.244.22.33"}
+			for _, event := range []controller.LeaseEvent{acquired, released, other} {
+				Expect(store.AddEvent(event)).To(Succeed())
+			}
+		})
+
+		withoutTimestamps := func(events []controller.LeaseEvent) []controller.LeaseEvent {
+			for i := range events {
+				Expect(events[i].Timestamp).To(BeNumerically(">", 0))
+				events[i].Timestamp = 0
+			}
+			return events
+		}
+
+		It("returns the events in the order they happened", func() {
+			events, err := store.Events(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(events)).To(Equal([]controller.LeaseEvent{acquired, released, other}))
+		})
+
+		It("filters by underlay ip and by ipv4 or ipv6 overlay subnet", func() {
+			events, err := store.Events(controller.LeaseEventFilter{UnderlayIP: "10.244.11.22"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(events)).To(Equal([]controller.LeaseEvent{acquired, released}))
+
+			events, err = store.Events(controller.LeaseEventFilter{OverlaySubnet: "fd00:0:0:1::/64"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(events)).To(Equal([]controller.LeaseEvent{other}))
+		})
+
+		It("filters by time, inclusively", func() {
+			events, err := store.Events(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			first, last := events[0].Timestamp, events[2].Timestamp
+
+			events, err = store.Events(controller.LeaseEventFilter{Since: first, Until: last})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(3))
+
+			events, err = store.Events(controller.LeaseEventFilter{Since: last + 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(BeEmpty())
+
+			events, err = store.Events(controller.LeaseEventFilter{Until: first - 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(BeEmpty())
+		})
+
+		It("is scoped to the pool", func() {
+			events, err := store.ForPool("blue").Events(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(withoutTimestamps(events)).To(Equal([]controller.LeaseEvent{other}))
+		})
+	})
+
+	Describe("quarantine", func() {
+		subnets := func(quarantined []controller.QuarantinedSubnet) []string {
+			var subnets []string
+			for _, q := range quarantined {
+				subnets = append(subnets, q.OverlaySubnet)
+			}
+			return subnets
+		}
+
+		It("returns the subnets whose quarantine has not ended, ending first first", func() {
+			Expect(store.QuarantineSubnet("10.255.17.0/24", "", 600)).To(Succeed())
+			Expect(store.QuarantineSubnet("fd00:0:0:1::/64", "", 300)).To(Succeed())
+			Expect(store.QuarantineSubnet("10.255.93.0/24", "", 0)).To(Succeed())
+
+			quarantined, err := store.QuarantinedSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subnets(quarantined)).To(Equal([]string{"fd00:0:0:1::/64", "10.255.17.0/24"}))
+			Expect(quarantined[1].QuarantinedUntil - quarantined[0].QuarantinedUntil).To(BeNumerically("~", 300, 5))
+		})
+
+		It("starts the quarantine of a subnet over when it is quarantined again", func() {
+			Expect(store.QuarantineSubnet("10.255.17.0/24", "", 600)).To(Succeed())
+			Expect(store.QuarantineSubnet("10.255.17.0/24", "", 0)).To(Succeed())
+
+			quarantined, err := store.QuarantinedSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quarantined).To(BeEmpty())
+		})
+
+		It("is scoped to the pool", func() {
+			Expect(store.QuarantineSubnet("10.255.17.0/24", "", 600)).To(Succeed())
+			Expect(store.QuarantineSubnet("10.250.17.0/24", "blue", 600)).To(Succeed())
+
+			quarantined, err := store.ForPool("blue").QuarantinedSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subnets(quarantined)).To(Equal([]string{"10.250.17.0/24"}))
+			Expect(quarantined[0].Pool).To(Equal("blue"))
+		})
+	})
+
+	Describe("leadership", func() {
+		It("keeps the leader until it stops claiming leadership", func() {
+			leader, err := store.ClaimLeadership("controller-0", 1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leader).To(Equal("controller-0"))
+
+			leader, err = store.ClaimLeadership("controller-1", 1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leader).To(Equal("controller-0"))
+
+			leader, err = store.ClaimLeadership("controller-0", 1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leader).To(Equal("controller-0"))
+
+			leader, err = store.ClaimLeadership("controller-1", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leader).To(Equal("controller-1"))
+		})
+
+		It("hands leadership over once the leader resigns", func() {
+			_, err := store.ClaimLeadership("controller-0", 1000)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(store.ResignLeadership("controller-1")).To(Succeed())
+			leader, err := store.ClaimLeadership("controller-1", 1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leader).To(Equal("controller-0"))
+
+			Expect(store.ResignLeadership("controller-0")).To(Succeed())
+			leader, err = store.ClaimLeadership("controller-1", 1000)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leader).To(Equal("controller-1"))
+		})
+	})
+
+	Describe("WithAllocationLock", func() {
+		BeforeEach(func() {
+			addLeases(lease)
+		})
+
+		It("commits the changes when the function succeeds", func() {
+			err := store.WithAllocationLock(func(locked database.LeaseStore) error {
+				expired, err := locked.OldestExpiredBlockSubnet(0)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(expired).To(Equal(&lease))
+				Expect(locked.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+				Expect(locked.AddEvent(controller.LeaseEvent{Type: controller.LeaseEventReclaimed, UnderlayIP: lease.UnderlayIP})).To(Succeed())
+				return locked.AddEntry(lease2)
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			leases, err := store.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease2))
+
+			events, err := store.Events(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+		})
+
+		generation := func(s database.Store) int64 {
+			var found int64
+			Expect(s.WithAllocationLock(func(locked database.LeaseStore) error {
+				var err error
+				found, err = locked.Generation()
+				return err
+			})).To(Succeed())
+			return found
+		}
+
+		It("counts the changes to the leases of each pool", func() {
+			blue := store.ForPool("blue")
+			start, blueStart := generation(store), generation(blue)
+
+			addLeases(lease2)
+			Expect(store.RenewLeaseForUnderlayIP(lease2.UnderlayIP)).To(Succeed())
+			Expect(store.DeleteExpiredEntry(lease2.UnderlayIP, 1000)).To(Equal(database.RecordNotAffectedError))
+			Expect(store.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+			Expect(store.DeleteEntry(lease.UnderlayIP)).To(Equal(database.RecordNotAffectedError))
+			Expect(generation(store)).To(Equal(start + 2))
+			Expect(generation(blue)).To(Equal(blueStart))
+
+			addLeases(blueLease)
+			Expect(store.DeleteExpiredEntry(blueLease.UnderlayIP, 0)).To(Succeed())
+			Expect(generation(blue)).To(Equal(blueStart + 2))
+			Expect(generation(store)).To(Equal(start + 2))
+		})
+
+		It("rolls back the changes and returns the error when the function fails", func() {
+			start := generation(store)
+			err := store.WithAllocationLock(func(locked database.LeaseStore) error {
+				Expect(locked.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+				Expect(locked.AddEntry(lease2)).To(Succeed())
+				Expect(locked.AddEvent(controller.LeaseEvent{Type: controller.LeaseEventAcquired, UnderlayIP: lease2.UnderlayIP})).To(Succeed())
+				Expect(locked.QuarantineSubnet(lease.OverlaySubnet, "", 600)).To(Succeed())
+				return errors.New("guava")
+			})
+			Expect(err).To(MatchError("guava"))
+
+			leases, err := store.All()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease))
+
+			events, err := store.Events(controller.LeaseEventFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(BeEmpty())
+
+			quarantined, err := store.QuarantinedSubnets()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(quarantined).To(BeEmpty())
+
+			Expect(generation(store)).To(Equal(start))
+			Expect(store.AddEntry(lease2)).To(Succeed())
+			Expect(store.AddEntry(controller.Lease{UnderlayIP: "10.244.99.99", OverlaySubnet: lease.OverlaySubnet, OverlayHardwareAddr: "ee:ee:0a:ff:99:99"})).NotTo(Succeed())
+		})
+
+		It("keeps the scope of the pool", func() {
+			addLeases(blueLease)
+
+			err := store.ForPool("blue").WithAllocationLock(func(locked database.LeaseStore) error {
+				leases, err := locked.AllBlockSubnets()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(blueLease))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+}
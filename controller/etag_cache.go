@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"code.cloudfoundry.org/cf-networking-helpers/json_client"
+)
+
+// ETagCache remembers the body of every GET response that carries an ETag and
+// revalidates it on the next GET of the same url with If-None-Match. A 304 is
+// answered from the remembered body, so callers always see a full response.
+type ETagCache struct {
+	HttpClient json_client.HttpClient
+
+	lock      sync.Mutex
+	responses map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+func (e *ETagCache) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return e.HttpClient.Do(req)
+	}
+
+	key := req.URL.String()
+	e.lock.Lock()
+	cached, ok := e.responses[key]
+	e.lock.Unlock()
+	if ok {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := e.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		resp.Body.Close()
+		resp.StatusCode = http.StatusOK
+		resp.Status = http.StatusText(http.StatusOK)
+		resp.Header = cached.header.Clone()
+		resp.Body = io.NopCloser(bytes.NewReader(cached.body))
+		resp.ContentLength = int64(len(cached.body))
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("body read: %s", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		e.lock.Lock()
+		if e.responses == nil {
+			e.responses = map[string]cachedResponse{}
+		}
+		e.responses[key] = cachedResponse{
+			etag:   resp.Header.Get("ETag"),
+			header: resp.Header.Clone(),
+			body:   body,
+		}
+		e.lock.Unlock()
+	case resp.StatusCode == http.StatusOK:
+		e.lock.Lock()
+		delete(e.responses, key)
+		e.lock.Unlock()
+	}
+	return resp, nil
+}
+
+func (e *ETagCache) CloseIdleConnections() {
+	e.HttpClient.CloseIdleConnections()
+}
@@ -0,0 +1,146 @@
+package controller_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ETagCache", func() {
+	var (
+		httpClient *fakes.HTTPClient
+		cache      *controller.ETagCache
+	)
+
+	response := func(code int, etag, body string) *http.Response {
+		resp := &http.Response{
+			StatusCode: code,
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}
+		if etag != "" {
+			resp.Header.Set("ETag", etag)
+		}
+		return resp
+	}
+
+	get := func() *http.Response {
+		req, err := http.NewRequest("GET", "https://controller/leases", nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := cache.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	readBody := func(resp *http.Response) string {
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return string(body)
+	}
+
+	BeforeEach(func() {
+		httpClient = &fakes.HTTPClient{}
+		cache = &controller.ETagCache{HttpClient: httpClient}
+		httpClient.DoReturnsOnCall(0, response(http.StatusOK, `"v1"`, `{"leases":[]}`), nil)
+	})
+
+	It("sends the tag of the last response and answers a 304 from the remembered body", func() {
+		Expect(readBody(get())).To(Equal(`{"leases":[]}`))
+		Expect(httpClient.DoArgsForCall(0).Header.Get("If-None-Match")).To(BeEmpty())
+
+		httpClient.DoReturnsOnCall(1, response(http.StatusNotModified, `"v1"`, ""), nil)
+		resp := get()
+		Expect(httpClient.DoArgsForCall(1).Header.Get("If-None-Match")).To(Equal(`"v1"`))
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(readBody(resp)).To(Equal(`{"leases":[]}`))
+	})
+
+	It("remembers the newest body", func() {
+		get()
+		httpClient.DoReturnsOnCall(1, response(http.StatusOK, `"v2"`, `{"leases":[{}]}`), nil)
+		Expect(readBody(get())).To(Equal(`{"leases":[{}]}`))
+
+		httpClient.DoReturnsOnCall(2, response(http.StatusNotModified, "", ""), nil)
+		Expect(httpClient.DoArgsForCall(1).Header.Get("If-None-Match")).To(Equal(`"v1"`))
+		Expect(readBody(get())).To(Equal(`{"leases":[{}]}`))
+		Expect(httpClient.DoArgsForCall(2).Header.Get("If-None-Match")).To(Equal(`"v2"`))
+	})
+
+	It("forgets the body when a response comes without a tag", func() {
+		get()
+		httpClient.DoReturnsOnCall(1, response(http.StatusOK, "", `{"leases":[]}`), nil)
+		get()
+		httpClient.DoReturnsOnCall(2, response(http.StatusOK, "", `{"leases":[]}`), nil)
+		get()
+		Expect(httpClient.DoArgsForCall(2).Header.Get("If-None-Match")).To(BeEmpty())
+	})
+
+	It("does not tag other methods", func() {
+		get()
+		httpClient.DoReturnsOnCall(1, response(http.StatusOK, "", ""), nil)
+		req, err := http.NewRequest("PUT", "https://controller/leases", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = cache.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(httpClient.DoArgsForCall(1).Header.Get("If-None-Match")).To(BeEmpty())
+	})
+
+	Context("when the request fails", func() {
+		BeforeEach(func() {
+			httpClient.DoReturnsOnCall(0, nil, errors.New("potato"))
+		})
+
+		It("returns the error", func() {
+			req, err := http.NewRequest("GET", "https://controller/leases", nil)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = cache.Do(req)
+			Expect(err).To(MatchError("potato"))
+		})
+	})
+
+	Describe("through a client", func() {
+		var (
+			server   *httptest.Server
+			requests []*http.Request
+		)
+
+		BeforeEach(func() {
+			requests = nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requests = append(requests, req)
+				w.Header().Set("ETag", `"v1"`)
+				if req.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Write([]byte(`{"leases":[{"underlay_ip":"10.0.3.1","overlay_subnet":"10.255.90.0/24"}]}`))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("gets the same leases when the controller answers not modified", func() {
+			client := controller.NewClient(lagertest.NewTestLogger("test"), server.Client(), server.URL)
+
+			first, err := client.GetActiveLeases()
+			Expect(err).NotTo(HaveOccurred())
+			second, err := client.GetActiveLeases()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(second).To(Equal(first))
+			Expect(second).To(Equal([]controller.Lease{{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24"}}))
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[1].Header.Get("If-None-Match")).To(Equal(`"v1"`))
+		})
+	})
+})
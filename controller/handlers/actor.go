@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+)
+
+// requestActor names the client that sent the request, for the lease event
+// history: the common name of its certificate, else its first DNS name, else
+// its address.
+func requestActor(req *http.Request) string {
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		cert := req.TLS.PeerCertificates[0]
+		if cert.Subject.CommonName != "" {
+			return cert.Subject.CommonName
+		}
+		if len(cert.DNSNames) > 0 {
+			return cert.DNSNames[0]
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// requestIdentities returns the names the verified client certificate was
+// issued to: its common name and its DNS names.
+func requestIdentities(req *http.Request) []string {
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	cert := req.TLS.PeerCertificates[0]
+	var identities []string
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	return append(identities, cert.DNSNames...)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+//go:generate counterfeiter -o fakes/loggable_handler.go --fake-name LoggableHandler . loggableHandler
+type loggableHandler interface {
+	ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request)
+}
+
+// AdminOnly passes the request on to Handler only if the client certificate
+// was issued to one of the AdminIdentities, by common name or DNS name.
+type AdminOnly struct {
+	AdminIdentities []string
+	Handler         loggableHandler
+	ErrorResponse   errorResponse
+}
+
+func (a *AdminOnly) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	for _, identity := range requestIdentities(req) {
+		for _, admin := range a.AdminIdentities {
+			if identity == admin {
+				a.Handler.ServeHTTP(logger, w, req)
+				return
+			}
+		}
+	}
+
+	err := fmt.Errorf("%s is not an admin", requestActor(req))
+	a.ErrorResponse.Forbidden(logger.Session("admin-only"), w, err, err.Error())
+}
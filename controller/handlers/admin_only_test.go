@@ -0,0 +1,109 @@
+package handlers_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AdminOnly", func() {
+	var (
+		logger            *lagertest.TestLogger
+		expectedLogger    lager.Logger
+		handler           *handlers.AdminOnly
+		innerHandler      *fakes.LoggableHandler
+		fakeErrorResponse *fakes.ErrorResponse
+		resp              *httptest.ResponseRecorder
+		request           *http.Request
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("admin-only")
+
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		innerHandler = &fakes.LoggableHandler{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+		handler = &handlers.AdminOnly{
+			AdminIdentities: []string{"silk-admin", "ops.example.com"},
+			Handler:         innerHandler,
+			ErrorResponse:   fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+
+		var err error
+		request, err = http.NewRequest("GET", "/admin/leases", nil)
+		Expect(err).NotTo(HaveOccurred())
+		request.RemoteAddr = "10.0.0.1:5555"
+	})
+
+	withCert := func(cert *x509.Certificate) {
+		request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	}
+
+	It("serves a client whose certificate has an admin common name", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "silk-admin"}})
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(innerHandler.ServeHTTPCallCount()).To(Equal(1))
+		l, w, r := innerHandler.ServeHTTPArgsForCall(0)
+		Expect(l).To(Equal(logger))
+		Expect(w).To(Equal(resp))
+		Expect(r).To(Equal(request))
+		Expect(fakeErrorResponse.ForbiddenCallCount()).To(Equal(0))
+	})
+
+	It("serves a client whose certificate has an admin DNS name", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "cell-1"}, DNSNames: []string{"cell-1.example.com", "ops.example.com"}})
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(innerHandler.ServeHTTPCallCount()).To(Equal(1))
+	})
+
+	It("forbids a client whose certificate names no admin", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "cell-1"}})
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(innerHandler.ServeHTTPCallCount()).To(Equal(0))
+		Expect(fakeErrorResponse.ForbiddenCallCount()).To(Equal(1))
+		l, w, err, description := fakeErrorResponse.ForbiddenArgsForCall(0)
+		Expect(l).To(Equal(expectedLogger))
+		Expect(w).To(Equal(resp))
+		Expect(err).To(MatchError("cell-1 is not an admin"))
+		Expect(description).To(Equal("cell-1 is not an admin"))
+	})
+
+	It("forbids a client without a certificate", func() {
+		handler.ServeHTTP(logger, resp, request)
+		Expect(innerHandler.ServeHTTPCallCount()).To(Equal(0))
+		_, _, err, _ := fakeErrorResponse.ForbiddenArgsForCall(0)
+		Expect(err).To(MatchError("10.0.0.1 is not an admin"))
+	})
+
+	Context("when there are no admin identities", func() {
+		BeforeEach(func() {
+			handler.AdminIdentities = nil
+		})
+
+		It("forbids every client", func() {
+			withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "silk-admin"}})
+
+			handler.ServeHTTP(logger, resp, request)
+			Expect(innerHandler.ServeHTTPCallCount()).To(Equal(0))
+			Expect(fakeErrorResponse.ForbiddenCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,105 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type LeaseRecordRepository struct {
+	LeaseRecordsStub        func() ([]controller.LeaseRecord, error)
+	leaseRecordsMutex       sync.RWMutex
+	leaseRecordsArgsForCall []struct {
+	}
+	leaseRecordsReturns struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}
+	leaseRecordsReturnsOnCall map[int]struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LeaseRecordRepository) LeaseRecords() ([]controller.LeaseRecord, error) {
+	fake.leaseRecordsMutex.Lock()
+	ret, specificReturn := fake.leaseRecordsReturnsOnCall[len(fake.leaseRecordsArgsForCall)]
+	fake.leaseRecordsArgsForCall = append(fake.leaseRecordsArgsForCall, struct {
+	}{})
+	stub := fake.LeaseRecordsStub
+	fakeReturns := fake.leaseRecordsReturns
+	fake.recordInvocation("LeaseRecords", []interface{}{})
+	fake.leaseRecordsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *LeaseRecordRepository) LeaseRecordsCallCount() int {
+	fake.leaseRecordsMutex.RLock()
+	defer fake.leaseRecordsMutex.RUnlock()
+	return len(fake.leaseRecordsArgsForCall)
+}
+
+func (fake *LeaseRecordRepository) LeaseRecordsCalls(stub func() ([]controller.LeaseRecord, error)) {
+	fake.leaseRecordsMutex.Lock()
+	defer fake.leaseRecordsMutex.Unlock()
+	fake.LeaseRecordsStub = stub
+}
+
+func (fake *LeaseRecordRepository) LeaseRecordsReturns(result1 []controller.LeaseRecord, result2 error) {
+	fake.leaseRecordsMutex.Lock()
+	defer fake.leaseRecordsMutex.Unlock()
+	fake.LeaseRecordsStub = nil
+	fake.leaseRecordsReturns = struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseRecordRepository) LeaseRecordsReturnsOnCall(i int, result1 []controller.LeaseRecord, result2 error) {
+	fake.leaseRecordsMutex.Lock()
+	defer fake.leaseRecordsMutex.Unlock()
+	fake.LeaseRecordsStub = nil
+	if fake.leaseRecordsReturnsOnCall == nil {
+		fake.leaseRecordsReturnsOnCall = make(map[int]struct {
+			result1 []controller.LeaseRecord
+			result2 error
+		})
+	}
+	fake.leaseRecordsReturnsOnCall[i] = struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseRecordRepository) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.leaseRecordsMutex.RLock()
+	defer fake.leaseRecordsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LeaseRecordRepository) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -8,36 +8,44 @@ import (
 )
 
 type LeaseRenewer struct {
-	RenewSubnetLeaseStub        func(lease controller.Lease) error
+	RenewSubnetLeaseStub        func(string, controller.Lease, *controller.HostMetadata) (*controller.Lease, error)
 	renewSubnetLeaseMutex       sync.RWMutex
 	renewSubnetLeaseArgsForCall []struct {
-		lease controller.Lease
+		arg1 string
+		arg2 controller.Lease
+		arg3 *controller.HostMetadata
 	}
 	renewSubnetLeaseReturns struct {
-		result1 error
+		result1 *controller.Lease
+		result2 error
 	}
 	renewSubnetLeaseReturnsOnCall map[int]struct {
-		result1 error
+		result1 *controller.Lease
+		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *LeaseRenewer) RenewSubnetLease(lease controller.Lease) error {
+func (fake *LeaseRenewer) RenewSubnetLease(arg1 string, arg2 controller.Lease, arg3 *controller.HostMetadata) (*controller.Lease, error) {
 	fake.renewSubnetLeaseMutex.Lock()
 	ret, specificReturn := fake.renewSubnetLeaseReturnsOnCall[len(fake.renewSubnetLeaseArgsForCall)]
 	fake.renewSubnetLeaseArgsForCall = append(fake.renewSubnetLeaseArgsForCall, struct {
-		lease controller.Lease
-	}{lease})
-	fake.recordInvocation("RenewSubnetLease", []interface{}{lease})
+		arg1 string
+		arg2 controller.Lease
+		arg3 *controller.HostMetadata
+	}{arg1, arg2, arg3})
+	stub := fake.RenewSubnetLeaseStub
+	fakeReturns := fake.renewSubnetLeaseReturns
+	fake.recordInvocation("RenewSubnetLease", []interface{}{arg1, arg2, arg3})
 	fake.renewSubnetLeaseMutex.Unlock()
-	if fake.RenewSubnetLeaseStub != nil {
-		return fake.RenewSubnetLeaseStub(lease)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	return fake.renewSubnetLeaseReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *LeaseRenewer) RenewSubnetLeaseCallCount() int {
@@ -46,29 +54,43 @@ func (fake *LeaseRenewer) RenewSubnetLeaseCallCount() int {
 	return len(fake.renewSubnetLeaseArgsForCall)
 }
 
-func (fake *LeaseRenewer) RenewSubnetLeaseArgsForCall(i int) controller.Lease {
+func (fake *LeaseRenewer) RenewSubnetLeaseCalls(stub func(string, controller.Lease, *controller.HostMetadata) (*controller.Lease, error)) {
+	fake.renewSubnetLeaseMutex.Lock()
+	defer fake.renewSubnetLeaseMutex.Unlock()
+	fake.RenewSubnetLeaseStub = stub
+}
+
+func (fake *LeaseRenewer) RenewSubnetLeaseArgsForCall(i int) (string, controller.Lease, *controller.HostMetadata) {
 	fake.renewSubnetLeaseMutex.RLock()
 	defer fake.renewSubnetLeaseMutex.RUnlock()
-	return fake.renewSubnetLeaseArgsForCall[i].lease
+	argsForCall := fake.renewSubnetLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *LeaseRenewer) RenewSubnetLeaseReturns(result1 error) {
+func (fake *LeaseRenewer) RenewSubnetLeaseReturns(result1 *controller.Lease, result2 error) {
+	fake.renewSubnetLeaseMutex.Lock()
+	defer fake.renewSubnetLeaseMutex.Unlock()
 	fake.RenewSubnetLeaseStub = nil
 	fake.renewSubnetLeaseReturns = struct {
-		result1 error
-	}{result1}
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *LeaseRenewer) RenewSubnetLeaseReturnsOnCall(i int, result1 error) {
+func (fake *LeaseRenewer) RenewSubnetLeaseReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.renewSubnetLeaseMutex.Lock()
+	defer fake.renewSubnetLeaseMutex.Unlock()
 	fake.RenewSubnetLeaseStub = nil
 	if fake.renewSubnetLeaseReturnsOnCall == nil {
 		fake.renewSubnetLeaseReturnsOnCall = make(map[int]struct {
-			result1 error
+			result1 *controller.Lease
+			result2 error
 		})
 	}
 	fake.renewSubnetLeaseReturnsOnCall[i] = struct {
-		result1 error
-	}{result1}
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *LeaseRenewer) Invocations() map[string][][]interface{} {
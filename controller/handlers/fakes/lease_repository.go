@@ -8,60 +8,151 @@ import (
 )
 
 type LeaseRepository struct {
-	RoutableLeasesStub        func() ([]controller.Lease, error)
-	routableLeasesMutex       sync.RWMutex
-	routableLeasesArgsForCall []struct{}
-	routableLeasesReturns     struct {
-		result1 []controller.Lease
+	HostLeasesStub        func() ([]controller.HostLease, error)
+	hostLeasesMutex       sync.RWMutex
+	hostLeasesArgsForCall []struct {
+	}
+	hostLeasesReturns struct {
+		result1 []controller.HostLease
+		result2 error
+	}
+	hostLeasesReturnsOnCall map[int]struct {
+		result1 []controller.HostLease
+		result2 error
+	}
+	HostLeasesForPoolStub        func(string) ([]controller.HostLease, error)
+	hostLeasesForPoolMutex       sync.RWMutex
+	hostLeasesForPoolArgsForCall []struct {
+		arg1 string
+	}
+	hostLeasesForPoolReturns struct {
+		result1 []controller.HostLease
 		result2 error
 	}
-	routableLeasesReturnsOnCall map[int]struct {
-		result1 []controller.Lease
+	hostLeasesForPoolReturnsOnCall map[int]struct {
+		result1 []controller.HostLease
 		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *LeaseRepository) RoutableLeases() ([]controller.Lease, error) {
-	fake.routableLeasesMutex.Lock()
-	ret, specificReturn := fake.routableLeasesReturnsOnCall[len(fake.routableLeasesArgsForCall)]
-	fake.routableLeasesArgsForCall = append(fake.routableLeasesArgsForCall, struct{}{})
-	fake.recordInvocation("RoutableLeases", []interface{}{})
-	fake.routableLeasesMutex.Unlock()
-	if fake.RoutableLeasesStub != nil {
-		return fake.RoutableLeasesStub()
+func (fake *LeaseRepository) HostLeases() ([]controller.HostLease, error) {
+	fake.hostLeasesMutex.Lock()
+	ret, specificReturn := fake.hostLeasesReturnsOnCall[len(fake.hostLeasesArgsForCall)]
+	fake.hostLeasesArgsForCall = append(fake.hostLeasesArgsForCall, struct {
+	}{})
+	stub := fake.HostLeasesStub
+	fakeReturns := fake.hostLeasesReturns
+	fake.recordInvocation("HostLeases", []interface{}{})
+	fake.hostLeasesMutex.Unlock()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.routableLeasesReturns.result1, fake.routableLeasesReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *LeaseRepository) HostLeasesCallCount() int {
+	fake.hostLeasesMutex.RLock()
+	defer fake.hostLeasesMutex.RUnlock()
+	return len(fake.hostLeasesArgsForCall)
+}
+
+func (fake *LeaseRepository) HostLeasesCalls(stub func() ([]controller.HostLease, error)) {
+	fake.hostLeasesMutex.Lock()
+	defer fake.hostLeasesMutex.Unlock()
+	fake.HostLeasesStub = stub
+}
+
+func (fake *LeaseRepository) HostLeasesReturns(result1 []controller.HostLease, result2 error) {
+	fake.hostLeasesMutex.Lock()
+	defer fake.hostLeasesMutex.Unlock()
+	fake.HostLeasesStub = nil
+	fake.hostLeasesReturns = struct {
+		result1 []controller.HostLease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseRepository) HostLeasesReturnsOnCall(i int, result1 []controller.HostLease, result2 error) {
+	fake.hostLeasesMutex.Lock()
+	defer fake.hostLeasesMutex.Unlock()
+	fake.HostLeasesStub = nil
+	if fake.hostLeasesReturnsOnCall == nil {
+		fake.hostLeasesReturnsOnCall = make(map[int]struct {
+			result1 []controller.HostLease
+			result2 error
+		})
+	}
+	fake.hostLeasesReturnsOnCall[i] = struct {
+		result1 []controller.HostLease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseRepository) HostLeasesForPool(arg1 string) ([]controller.HostLease, error) {
+	fake.hostLeasesForPoolMutex.Lock()
+	ret, specificReturn := fake.hostLeasesForPoolReturnsOnCall[len(fake.hostLeasesForPoolArgsForCall)]
+	fake.hostLeasesForPoolArgsForCall = append(fake.hostLeasesForPoolArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.HostLeasesForPoolStub
+	fakeReturns := fake.hostLeasesForPoolReturns
+	fake.recordInvocation("HostLeasesForPool", []interface{}{arg1})
+	fake.hostLeasesForPoolMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *LeaseRepository) HostLeasesForPoolCallCount() int {
+	fake.hostLeasesForPoolMutex.RLock()
+	defer fake.hostLeasesForPoolMutex.RUnlock()
+	return len(fake.hostLeasesForPoolArgsForCall)
+}
+
+func (fake *LeaseRepository) HostLeasesForPoolCalls(stub func(string) ([]controller.HostLease, error)) {
+	fake.hostLeasesForPoolMutex.Lock()
+	defer fake.hostLeasesForPoolMutex.Unlock()
+	fake.HostLeasesForPoolStub = stub
 }
 
-func (fake *LeaseRepository) RoutableLeasesCallCount() int {
-	fake.routableLeasesMutex.RLock()
-	defer fake.routableLeasesMutex.RUnlock()
-	return len(fake.routableLeasesArgsForCall)
+func (fake *LeaseRepository) HostLeasesForPoolArgsForCall(i int) string {
+	fake.hostLeasesForPoolMutex.RLock()
+	defer fake.hostLeasesForPoolMutex.RUnlock()
+	argsForCall := fake.hostLeasesForPoolArgsForCall[i]
+	return argsForCall.arg1
 }
 
-func (fake *LeaseRepository) RoutableLeasesReturns(result1 []controller.Lease, result2 error) {
-	fake.RoutableLeasesStub = nil
-	fake.routableLeasesReturns = struct {
-		result1 []controller.Lease
+func (fake *LeaseRepository) HostLeasesForPoolReturns(result1 []controller.HostLease, result2 error) {
+	fake.hostLeasesForPoolMutex.Lock()
+	defer fake.hostLeasesForPoolMutex.Unlock()
+	fake.HostLeasesForPoolStub = nil
+	fake.hostLeasesForPoolReturns = struct {
+		result1 []controller.HostLease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *LeaseRepository) RoutableLeasesReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
-	fake.RoutableLeasesStub = nil
-	if fake.routableLeasesReturnsOnCall == nil {
-		fake.routableLeasesReturnsOnCall = make(map[int]struct {
-			result1 []controller.Lease
+func (fake *LeaseRepository) HostLeasesForPoolReturnsOnCall(i int, result1 []controller.HostLease, result2 error) {
+	fake.hostLeasesForPoolMutex.Lock()
+	defer fake.hostLeasesForPoolMutex.Unlock()
+	fake.HostLeasesForPoolStub = nil
+	if fake.hostLeasesForPoolReturnsOnCall == nil {
+		fake.hostLeasesForPoolReturnsOnCall = make(map[int]struct {
+			result1 []controller.HostLease
 			result2 error
 		})
 	}
-	fake.routableLeasesReturnsOnCall[i] = struct {
-		result1 []controller.Lease
+	fake.hostLeasesForPoolReturnsOnCall[i] = struct {
+		result1 []controller.HostLease
 		result2 error
 	}{result1, result2}
 }
@@ -69,8 +160,10 @@ func (fake *LeaseRepository) RoutableLeasesReturnsOnCall(i int, result1 []contro
 func (fake *LeaseRepository) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.routableLeasesMutex.RLock()
-	defer fake.routableLeasesMutex.RUnlock()
+	fake.hostLeasesMutex.RLock()
+	defer fake.hostLeasesMutex.RUnlock()
+	fake.hostLeasesForPoolMutex.RLock()
+	defer fake.hostLeasesForPoolMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type LeaseWatcher struct {
+	ChangesStub        func(context.Context, int64, *string) (controller.LeaseChanges, error)
+	changesMutex       sync.RWMutex
+	changesArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 *string
+	}
+	changesReturns struct {
+		result1 controller.LeaseChanges
+		result2 error
+	}
+	changesReturnsOnCall map[int]struct {
+		result1 controller.LeaseChanges
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LeaseWatcher) Changes(arg1 context.Context, arg2 int64, arg3 *string) (controller.LeaseChanges, error) {
+	fake.changesMutex.Lock()
+	ret, specificReturn := fake.changesReturnsOnCall[len(fake.changesArgsForCall)]
+	fake.changesArgsForCall = append(fake.changesArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 *string
+	}{arg1, arg2, arg3})
+	stub := fake.ChangesStub
+	fakeReturns := fake.changesReturns
+	fake.recordInvocation("Changes", []interface{}{arg1, arg2, arg3})
+	fake.changesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *LeaseWatcher) ChangesCallCount() int {
+	fake.changesMutex.RLock()
+	defer fake.changesMutex.RUnlock()
+	return len(fake.changesArgsForCall)
+}
+
+func (fake *LeaseWatcher) ChangesCalls(stub func(context.Context, int64, *string) (controller.LeaseChanges, error)) {
+	fake.changesMutex.Lock()
+	defer fake.changesMutex.Unlock()
+	fake.ChangesStub = stub
+}
+
+func (fake *LeaseWatcher) ChangesArgsForCall(i int) (context.Context, int64, *string) {
+	fake.changesMutex.RLock()
+	defer fake.changesMutex.RUnlock()
+	argsForCall := fake.changesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *LeaseWatcher) ChangesReturns(result1 controller.LeaseChanges, result2 error) {
+	fake.changesMutex.Lock()
+	defer fake.changesMutex.Unlock()
+	fake.ChangesStub = nil
+	fake.changesReturns = struct {
+		result1 controller.LeaseChanges
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseWatcher) ChangesReturnsOnCall(i int, result1 controller.LeaseChanges, result2 error) {
+	fake.changesMutex.Lock()
+	defer fake.changesMutex.Unlock()
+	fake.ChangesStub = nil
+	if fake.changesReturnsOnCall == nil {
+		fake.changesReturnsOnCall = make(map[int]struct {
+			result1 controller.LeaseChanges
+			result2 error
+		})
+	}
+	fake.changesReturnsOnCall[i] = struct {
+		result1 controller.LeaseChanges
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseWatcher) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.changesMutex.RLock()
+	defer fake.changesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LeaseWatcher) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,79 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"net/http"
+	"sync"
+
+	lager "code.cloudfoundry.org/lager/v3"
+)
+
+type LoggableHandler struct {
+	ServeHTTPStub        func(lager.Logger, http.ResponseWriter, *http.Request)
+	serveHTTPMutex       sync.RWMutex
+	serveHTTPArgsForCall []struct {
+		arg1 lager.Logger
+		arg2 http.ResponseWriter
+		arg3 *http.Request
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LoggableHandler) ServeHTTP(arg1 lager.Logger, arg2 http.ResponseWriter, arg3 *http.Request) {
+	fake.serveHTTPMutex.Lock()
+	fake.serveHTTPArgsForCall = append(fake.serveHTTPArgsForCall, struct {
+		arg1 lager.Logger
+		arg2 http.ResponseWriter
+		arg3 *http.Request
+	}{arg1, arg2, arg3})
+	stub := fake.ServeHTTPStub
+	fake.recordInvocation("ServeHTTP", []interface{}{arg1, arg2, arg3})
+	fake.serveHTTPMutex.Unlock()
+	if stub != nil {
+		fake.ServeHTTPStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *LoggableHandler) ServeHTTPCallCount() int {
+	fake.serveHTTPMutex.RLock()
+	defer fake.serveHTTPMutex.RUnlock()
+	return len(fake.serveHTTPArgsForCall)
+}
+
+func (fake *LoggableHandler) ServeHTTPCalls(stub func(lager.Logger, http.ResponseWriter, *http.Request)) {
+	fake.serveHTTPMutex.Lock()
+	defer fake.serveHTTPMutex.Unlock()
+	fake.ServeHTTPStub = stub
+}
+
+func (fake *LoggableHandler) ServeHTTPArgsForCall(i int) (lager.Logger, http.ResponseWriter, *http.Request) {
+	fake.serveHTTPMutex.RLock()
+	defer fake.serveHTTPMutex.RUnlock()
+	argsForCall := fake.serveHTTPArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *LoggableHandler) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.serveHTTPMutex.RLock()
+	defer fake.serveHTTPMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LoggableHandler) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
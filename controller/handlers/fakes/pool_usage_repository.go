@@ -0,0 +1,105 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type PoolUsageRepository struct {
+	PoolUsageStub        func() ([]controller.PoolUsage, error)
+	poolUsageMutex       sync.RWMutex
+	poolUsageArgsForCall []struct {
+	}
+	poolUsageReturns struct {
+		result1 []controller.PoolUsage
+		result2 error
+	}
+	poolUsageReturnsOnCall map[int]struct {
+		result1 []controller.PoolUsage
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *PoolUsageRepository) PoolUsage() ([]controller.PoolUsage, error) {
+	fake.poolUsageMutex.Lock()
+	ret, specificReturn := fake.poolUsageReturnsOnCall[len(fake.poolUsageArgsForCall)]
+	fake.poolUsageArgsForCall = append(fake.poolUsageArgsForCall, struct {
+	}{})
+	stub := fake.PoolUsageStub
+	fakeReturns := fake.poolUsageReturns
+	fake.recordInvocation("PoolUsage", []interface{}{})
+	fake.poolUsageMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PoolUsageRepository) PoolUsageCallCount() int {
+	fake.poolUsageMutex.RLock()
+	defer fake.poolUsageMutex.RUnlock()
+	return len(fake.poolUsageArgsForCall)
+}
+
+func (fake *PoolUsageRepository) PoolUsageCalls(stub func() ([]controller.PoolUsage, error)) {
+	fake.poolUsageMutex.Lock()
+	defer fake.poolUsageMutex.Unlock()
+	fake.PoolUsageStub = stub
+}
+
+func (fake *PoolUsageRepository) PoolUsageReturns(result1 []controller.PoolUsage, result2 error) {
+	fake.poolUsageMutex.Lock()
+	defer fake.poolUsageMutex.Unlock()
+	fake.PoolUsageStub = nil
+	fake.poolUsageReturns = struct {
+		result1 []controller.PoolUsage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolUsageRepository) PoolUsageReturnsOnCall(i int, result1 []controller.PoolUsage, result2 error) {
+	fake.poolUsageMutex.Lock()
+	defer fake.poolUsageMutex.Unlock()
+	fake.PoolUsageStub = nil
+	if fake.poolUsageReturnsOnCall == nil {
+		fake.poolUsageReturnsOnCall = make(map[int]struct {
+			result1 []controller.PoolUsage
+			result2 error
+		})
+	}
+	fake.poolUsageReturnsOnCall[i] = struct {
+		result1 []controller.PoolUsage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolUsageRepository) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.poolUsageMutex.RLock()
+	defer fake.poolUsageMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *PoolUsageRepository) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,113 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+	"time"
+)
+
+type RateLimiter struct {
+	TakeStub        func(string) (time.Duration, bool)
+	takeMutex       sync.RWMutex
+	takeArgsForCall []struct {
+		arg1 string
+	}
+	takeReturns struct {
+		result1 time.Duration
+		result2 bool
+	}
+	takeReturnsOnCall map[int]struct {
+		result1 time.Duration
+		result2 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *RateLimiter) Take(arg1 string) (time.Duration, bool) {
+	fake.takeMutex.Lock()
+	ret, specificReturn := fake.takeReturnsOnCall[len(fake.takeArgsForCall)]
+	fake.takeArgsForCall = append(fake.takeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.TakeStub
+	fakeReturns := fake.takeReturns
+	fake.recordInvocation("Take", []interface{}{arg1})
+	fake.takeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *RateLimiter) TakeCallCount() int {
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	return len(fake.takeArgsForCall)
+}
+
+func (fake *RateLimiter) TakeCalls(stub func(string) (time.Duration, bool)) {
+	fake.takeMutex.Lock()
+	defer fake.takeMutex.Unlock()
+	fake.TakeStub = stub
+}
+
+func (fake *RateLimiter) TakeArgsForCall(i int) string {
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	argsForCall := fake.takeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *RateLimiter) TakeReturns(result1 time.Duration, result2 bool) {
+	fake.takeMutex.Lock()
+	defer fake.takeMutex.Unlock()
+	fake.TakeStub = nil
+	fake.takeReturns = struct {
+		result1 time.Duration
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *RateLimiter) TakeReturnsOnCall(i int, result1 time.Duration, result2 bool) {
+	fake.takeMutex.Lock()
+	defer fake.takeMutex.Unlock()
+	fake.TakeStub = nil
+	if fake.takeReturnsOnCall == nil {
+		fake.takeReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+			result2 bool
+		})
+	}
+	fake.takeReturnsOnCall[i] = struct {
+		result1 time.Duration
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *RateLimiter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *RateLimiter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,107 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type ReservationRemover struct {
+	RemoveReservationStub        func(string) error
+	removeReservationMutex       sync.RWMutex
+	removeReservationArgsForCall []struct {
+		arg1 string
+	}
+	removeReservationReturns struct {
+		result1 error
+	}
+	removeReservationReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ReservationRemover) RemoveReservation(arg1 string) error {
+	fake.removeReservationMutex.Lock()
+	ret, specificReturn := fake.removeReservationReturnsOnCall[len(fake.removeReservationArgsForCall)]
+	fake.removeReservationArgsForCall = append(fake.removeReservationArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.RemoveReservationStub
+	fakeReturns := fake.removeReservationReturns
+	fake.recordInvocation("RemoveReservation", []interface{}{arg1})
+	fake.removeReservationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *ReservationRemover) RemoveReservationCallCount() int {
+	fake.removeReservationMutex.RLock()
+	defer fake.removeReservationMutex.RUnlock()
+	return len(fake.removeReservationArgsForCall)
+}
+
+func (fake *ReservationRemover) RemoveReservationCalls(stub func(string) error) {
+	fake.removeReservationMutex.Lock()
+	defer fake.removeReservationMutex.Unlock()
+	fake.RemoveReservationStub = stub
+}
+
+func (fake *ReservationRemover) RemoveReservationArgsForCall(i int) string {
+	fake.removeReservationMutex.RLock()
+	defer fake.removeReservationMutex.RUnlock()
+	argsForCall := fake.removeReservationArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ReservationRemover) RemoveReservationReturns(result1 error) {
+	fake.removeReservationMutex.Lock()
+	defer fake.removeReservationMutex.Unlock()
+	fake.RemoveReservationStub = nil
+	fake.removeReservationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ReservationRemover) RemoveReservationReturnsOnCall(i int, result1 error) {
+	fake.removeReservationMutex.Lock()
+	defer fake.removeReservationMutex.Unlock()
+	fake.RemoveReservationStub = nil
+	if fake.removeReservationReturnsOnCall == nil {
+		fake.removeReservationReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeReservationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ReservationRemover) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.removeReservationMutex.RLock()
+	defer fake.removeReservationMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ReservationRemover) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,109 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type SubnetReserver struct {
+	ReserveSubnetStub        func(controller.Reservation) error
+	reserveSubnetMutex       sync.RWMutex
+	reserveSubnetArgsForCall []struct {
+		arg1 controller.Reservation
+	}
+	reserveSubnetReturns struct {
+		result1 error
+	}
+	reserveSubnetReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *SubnetReserver) ReserveSubnet(arg1 controller.Reservation) error {
+	fake.reserveSubnetMutex.Lock()
+	ret, specificReturn := fake.reserveSubnetReturnsOnCall[len(fake.reserveSubnetArgsForCall)]
+	fake.reserveSubnetArgsForCall = append(fake.reserveSubnetArgsForCall, struct {
+		arg1 controller.Reservation
+	}{arg1})
+	stub := fake.ReserveSubnetStub
+	fakeReturns := fake.reserveSubnetReturns
+	fake.recordInvocation("ReserveSubnet", []interface{}{arg1})
+	fake.reserveSubnetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *SubnetReserver) ReserveSubnetCallCount() int {
+	fake.reserveSubnetMutex.RLock()
+	defer fake.reserveSubnetMutex.RUnlock()
+	return len(fake.reserveSubnetArgsForCall)
+}
+
+func (fake *SubnetReserver) ReserveSubnetCalls(stub func(controller.Reservation) error) {
+	fake.reserveSubnetMutex.Lock()
+	defer fake.reserveSubnetMutex.Unlock()
+	fake.ReserveSubnetStub = stub
+}
+
+func (fake *SubnetReserver) ReserveSubnetArgsForCall(i int) controller.Reservation {
+	fake.reserveSubnetMutex.RLock()
+	defer fake.reserveSubnetMutex.RUnlock()
+	argsForCall := fake.reserveSubnetArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *SubnetReserver) ReserveSubnetReturns(result1 error) {
+	fake.reserveSubnetMutex.Lock()
+	defer fake.reserveSubnetMutex.Unlock()
+	fake.ReserveSubnetStub = nil
+	fake.reserveSubnetReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SubnetReserver) ReserveSubnetReturnsOnCall(i int, result1 error) {
+	fake.reserveSubnetMutex.Lock()
+	defer fake.reserveSubnetMutex.Unlock()
+	fake.ReserveSubnetStub = nil
+	if fake.reserveSubnetReturnsOnCall == nil {
+		fake.reserveSubnetReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.reserveSubnetReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *SubnetReserver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.reserveSubnetMutex.RLock()
+	defer fake.reserveSubnetMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *SubnetReserver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
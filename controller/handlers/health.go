@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
 	"code.cloudfoundry.org/lager/v3"
 )
 
 type Health struct {
 	DatabaseChecker databaseChecker
+	Leadership      leadership
+	Marshaler       marshal.Marshaler
 	ErrorResponse   errorResponse
 }
 
@@ -16,6 +20,12 @@ type databaseChecker interface {
 	CheckDatabase() error
 }
 
+//go:generate counterfeiter -o fakes/leadership.go --fake-name Leadership . leadership
+type leadership interface {
+	Leader() string
+	IsLeader() bool
+}
+
 func (h *Health) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
 	logger = logger.Session("health")
 	err := h.DatabaseChecker.CheckDatabase()
@@ -23,4 +33,16 @@ func (h *Health) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http
 		h.ErrorResponse.InternalServerError(logger, w, err, "check database failed")
 		return
 	}
+
+	response := struct {
+		Leader   string `json:"leader"`
+		IsLeader bool   `json:"is_leader"`
+	}{h.Leadership.Leader(), h.Leadership.IsLeader()}
+	bytes, err := h.Marshaler.Marshal(response)
+	if err != nil {
+		h.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	w.Write(bytes)
 }
@@ -1,10 +1,12 @@
 package handlers_test
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagertest"
 	"code.cloudfoundry.org/silk/controller/handlers"
@@ -21,6 +23,8 @@ var _ = Describe("Health handler", func() {
 		handler             *handlers.Health
 		request             *http.Request
 		fakeDatabaseChecker *fakes.DatabaseChecker
+		fakeLeadership      *fakes.Leadership
+		marshaler           *hfakes.Marshaler
 		fakeErrorResponse   *fakes.ErrorResponse
 		resp                *httptest.ResponseRecorder
 	)
@@ -39,9 +43,15 @@ var _ = Describe("Health handler", func() {
 
 		fakeDatabaseChecker = &fakes.DatabaseChecker{}
 		fakeErrorResponse = &fakes.ErrorResponse{}
+		fakeLeadership = &fakes.Leadership{}
+		fakeLeadership.LeaderReturns("controller-1")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
 
 		handler = &handlers.Health{
 			DatabaseChecker: fakeDatabaseChecker,
+			Leadership:      fakeLeadership,
+			Marshaler:       marshaler,
 			ErrorResponse:   fakeErrorResponse,
 		}
 		resp = httptest.NewRecorder()
@@ -53,6 +63,17 @@ var _ = Describe("Health handler", func() {
 		Expect(resp.Code).To(Equal(http.StatusOK))
 	})
 
+	It("tells which controller leads", func() {
+		handler.ServeHTTP(logger, resp, request)
+		Expect(resp.Body).To(MatchJSON(`{"leader": "controller-1", "is_leader": false}`))
+
+		fakeLeadership.LeaderReturns("controller-0")
+		fakeLeadership.IsLeaderReturns(true)
+		resp = httptest.NewRecorder()
+		handler.ServeHTTP(logger, resp, request)
+		Expect(resp.Body).To(MatchJSON(`{"leader": "controller-0", "is_leader": true}`))
+	})
+
 	Context("when the database returns an error", func() {
 		BeforeEach(func() {
 			fakeDatabaseChecker.CheckDatabaseReturns(errors.New("pineapple"))
@@ -70,4 +91,21 @@ var _ = Describe("Health handler", func() {
 			Expect(description).To(Equal("check database failed"))
 		})
 	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalStub = func(interface{}) ([]byte, error) {
+				return nil, errors.New("grapes")
+			}
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("grapes"))
+			Expect(description).To(Equal("marshal-response: grapes"))
+		})
+	})
 })
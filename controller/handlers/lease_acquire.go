@@ -13,13 +13,14 @@ import (
 
 //go:generate counterfeiter -o fakes/lease_acquirer.go --fake-name LeaseAcquirer . leaseAcquirer
 type leaseAcquirer interface {
-	AcquireSubnetLease(underlayIP string, singleOverlayIP bool) (*controller.Lease, error)
+	AcquireSubnetLease(actor string, request controller.AcquireLeaseRequest) (*controller.Lease, error)
 }
 
 type LeasesAcquire struct {
 	Marshaler     marshal.Marshaler
 	Unmarshaler   marshal.Unmarshaler
 	LeaseAcquirer leaseAcquirer
+	Authorizer    underlayAuthorizer
 	ErrorResponse errorResponse
 }
 
@@ -32,17 +33,20 @@ func (l *LeasesAcquire) ServeHTTP(logger lager.Logger, w http.ResponseWriter, re
 		return
 	}
 
-	var payload struct {
-		UnderlayIP      string `json:"underlay_ip"`
-		SingleOverlayIP bool   `json:"single_overlay_ip"`
-	}
+	var payload controller.AcquireLeaseRequest
 	err = l.Unmarshaler.Unmarshal(bodyBytes, &payload)
 	if err != nil {
 		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("unmarshal-request: %s", err.Error()))
 		return
 	}
 
-	lease, err := l.LeaseAcquirer.AcquireSubnetLease(payload.UnderlayIP, payload.SingleOverlayIP)
+	err = authorize(l.Authorizer, req, payload.UnderlayIP)
+	if err != nil {
+		l.ErrorResponse.Forbidden(logger, w, err, err.Error())
+		return
+	}
+
+	lease, err := l.LeaseAcquirer.AcquireSubnetLease(requestActor(req), payload)
 	if err != nil {
 		l.ErrorResponse.InternalServerError(logger, w, err, err.Error())
 		return
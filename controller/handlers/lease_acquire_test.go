@@ -73,9 +73,11 @@ var _ = Describe("LeasesAcquire", func() {
 
 		handler.ServeHTTP(logger, resp, request)
 		Expect(leaseAcquirer.AcquireSubnetLeaseCallCount()).To(Equal(1))
-		underlayIP, singleOverlayIP := leaseAcquirer.AcquireSubnetLeaseArgsForCall(0)
-		Expect(underlayIP).To(Equal("10.244.16.11"))
-		Expect(singleOverlayIP).To(Equal(false))
+		actor, acquireRequest := leaseAcquirer.AcquireSubnetLeaseArgsForCall(0)
+		Expect(actor).To(Equal("some-host"))
+		Expect(acquireRequest).To(Equal(controller.AcquireLeaseRequest{
+			UnderlayIP: "10.244.16.11",
+		}))
 
 		Expect(resp.Code).To(Equal(http.StatusOK))
 		Expect(resp.Body).To(MatchJSON(expectedResponseJSON))
@@ -98,14 +100,87 @@ var _ = Describe("LeasesAcquire", func() {
 
 		handler.ServeHTTP(logger, resp, request)
 		Expect(leaseAcquirer.AcquireSubnetLeaseCallCount()).To(Equal(1))
-		underlayIP, singleOverlayIP := leaseAcquirer.AcquireSubnetLeaseArgsForCall(0)
-		Expect(underlayIP).To(Equal("10.244.0.12"))
-		Expect(singleOverlayIP).To(Equal(true))
+		_, acquireRequest := leaseAcquirer.AcquireSubnetLeaseArgsForCall(0)
+		Expect(acquireRequest).To(Equal(controller.AcquireLeaseRequest{
+			UnderlayIP:      "10.244.0.12",
+			SingleOverlayIP: true,
+		}))
 
 		Expect(resp.Code).To(Equal(http.StatusOK))
 		Expect(resp.Body).To(MatchJSON(expectedResponseJSON))
 	})
 
+	It("acquires a dual stack lease", func() {
+		lease := &controller.Lease{
+			UnderlayIP:          "10.244.16.11",
+			OverlaySubnet:       "10.255.17.0/24",
+			OverlaySubnetV6:     "fd00:255:0:11::/64",
+			OverlayHardwareAddr: "ee:ee:0a:ff:11:00",
+		}
+		leaseAcquirer.AcquireSubnetLeaseReturns(lease, nil)
+
+		expectedResponseJSON := `{ "underlay_ip": "10.244.16.11", "overlay_subnet": "10.255.17.0/24", "overlay_subnet_v6": "fd00:255:0:11::/64", "overlay_hardware_addr": "ee:ee:0a:ff:11:00" }`
+		requestBody := bytes.NewBuffer([]byte(`{ "underlay_ip": "10.244.16.11", "ip_family": "dual" }`))
+		request, err := http.NewRequest("PUT", "/leases/acquire", requestBody)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(leaseAcquirer.AcquireSubnetLeaseCallCount()).To(Equal(1))
+		_, acquireRequest := leaseAcquirer.AcquireSubnetLeaseArgsForCall(0)
+		Expect(acquireRequest).To(Equal(controller.AcquireLeaseRequest{
+			UnderlayIP: "10.244.16.11",
+			IPFamily:   controller.IPFamilyDual,
+		}))
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(expectedResponseJSON))
+	})
+
+	Context("when there is an authorizer", func() {
+		var (
+			authorizer *fakes.UnderlayAuthorizer
+			request    *http.Request
+		)
+
+		BeforeEach(func() {
+			authorizer = &fakes.UnderlayAuthorizer{}
+			handler.Authorizer = authorizer
+
+			var err error
+			request, err = http.NewRequest("PUT", "/leases/acquire", bytes.NewBufferString(`{ "underlay_ip": "10.244.16.11" }`))
+			Expect(err).NotTo(HaveOccurred())
+			request.RemoteAddr = "some-host:some-port"
+		})
+
+		It("asks it about the underlay ip of the lease", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(authorizer.AuthorizeCallCount()).To(Equal(1))
+			req, underlayIP := authorizer.AuthorizeArgsForCall(0)
+			Expect(req).To(Equal(request))
+			Expect(underlayIP).To(Equal("10.244.16.11"))
+			Expect(leaseAcquirer.AcquireSubnetLeaseCallCount()).To(Equal(1))
+		})
+
+		Context("when the client may not manage the lease", func() {
+			BeforeEach(func() {
+				authorizer.AuthorizeReturns(errors.New("some-host may not manage the lease of 10.244.16.11"))
+			})
+
+			It("returns a 403 without acquiring a lease", func() {
+				handler.ServeHTTP(logger, resp, request)
+
+				Expect(leaseAcquirer.AcquireSubnetLeaseCallCount()).To(Equal(0))
+				Expect(fakeErrorResponse.ForbiddenCallCount()).To(Equal(1))
+				l, w, err, description := fakeErrorResponse.ForbiddenArgsForCall(0)
+				Expect(l).To(Equal(expectedLogger))
+				Expect(w).To(Equal(resp))
+				Expect(err).To(MatchError("some-host may not manage the lease of 10.244.16.11"))
+				Expect(description).To(Equal("some-host may not manage the lease of 10.244.16.11"))
+			})
+		})
+	})
+
 	Context("when there are errors reading the body bytes", func() {
 		var request *http.Request
 		BeforeEach(func() {
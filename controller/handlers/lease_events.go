@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/lease_event_repository.go --fake-name LeaseEventRepository . leaseEventRepository
+type leaseEventRepository interface {
+	Events(filter controller.LeaseEventFilter) ([]controller.LeaseEvent, error)
+}
+
+type LeaseEvents struct {
+	Marshaler            marshal.Marshaler
+	LeaseEventRepository leaseEventRepository
+	ErrorResponse        errorResponse
+}
+
+func (l *LeaseEvents) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("leases-events")
+
+	filter, err := parseLeaseEventFilter(req)
+	if err != nil {
+		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("parse-query: %s", err.Error()))
+		return
+	}
+
+	events, err := l.LeaseEventRepository.Events(filter)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("lease-events: %s", err.Error()))
+		return
+	}
+
+	response := struct {
+		Events []controller.LeaseEvent `json:"events"`
+	}{events}
+	bytes, err := l.Marshaler.Marshal(response)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	w.Write(bytes)
+}
+
+func parseLeaseEventFilter(req *http.Request) (controller.LeaseEventFilter, error) {
+	query := req.URL.Query()
+	filter := controller.LeaseEventFilter{
+		UnderlayIP:    query.Get("underlay_ip"),
+		OverlaySubnet: query.Get("overlay_subnet"),
+	}
+	if filter.UnderlayIP != "" && net.ParseIP(filter.UnderlayIP) == nil {
+		return filter, fmt.Errorf("invalid underlay_ip: %s", filter.UnderlayIP)
+	}
+
+	var err error
+	filter.Since, err = parseEventTime("since", query.Get("since"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Until, err = parseEventTime("until", query.Get("until"))
+	if err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parseEventTime accepts seconds since the epoch or an RFC 3339 time.
+func parseEventTime(name, value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return seconds, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return t.Unix(), nil
+}
@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeaseEvents", func() {
+	var (
+		logger               *lagertest.TestLogger
+		expectedLogger       lager.Logger
+		handler              *handlers.LeaseEvents
+		leaseEventRepository *fakes.LeaseEventRepository
+		resp                 *httptest.ResponseRecorder
+		marshaler            *hfakes.Marshaler
+		fakeErrorResponse    *fakes.ErrorResponse
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("leases-events")
+
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		leaseEventRepository = &fakes.LeaseEventRepository{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+		handler = &handlers.LeaseEvents{
+			Marshaler:            marshaler,
+			LeaseEventRepository: leaseEventRepository,
+			ErrorResponse:        fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+		leaseEventRepository.EventsReturns([]controller.LeaseEvent{
+			{
+				Type:          controller.LeaseEventAcquired,
+				UnderlayIP:    "10.244.5.9",
+				OverlaySubnet: "10.255.16.0/24",
+				Actor:         "silk-daemon",
+				Timestamp:     1700000000,
+			},
+		}, nil)
+	})
+
+	It("returns the lease events matching the query", func() {
+		request, err := http.NewRequest("GET", "/leases/events?underlay_ip=10.244.5.9&overlay_subnet=10.255.16.0/24&since=1690000000&until=2023-11-14T22:13:20Z", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(leaseEventRepository.EventsCallCount()).To(Equal(1))
+		Expect(leaseEventRepository.EventsArgsForCall(0)).To(Equal(controller.LeaseEventFilter{
+			UnderlayIP:    "10.244.5.9",
+			OverlaySubnet: "10.255.16.0/24",
+			Since:         1690000000,
+			Until:         1700000000,
+		}))
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(`{ "events": [
+			{ "type": "acquired", "underlay_ip": "10.244.5.9", "overlay_subnet": "10.255.16.0/24", "actor": "silk-daemon", "timestamp": 1700000000 }
+		] }`))
+	})
+
+	It("returns every event when no query is given", func() {
+		request, err := http.NewRequest("GET", "/leases/events", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(leaseEventRepository.EventsArgsForCall(0)).To(Equal(controller.LeaseEventFilter{}))
+		Expect(resp.Code).To(Equal(http.StatusOK))
+	})
+
+	DescribeTable("when the query is invalid",
+		func(query, description string) {
+			request, err := http.NewRequest("GET", "/leases/events?"+query, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(leaseEventRepository.EventsCallCount()).To(Equal(0))
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+			l, w, _, desc := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(desc).To(Equal(description))
+		},
+		Entry("bad underlay ip", "underlay_ip=banana", "parse-query: invalid underlay_ip: banana"),
+		Entry("bad since", "since=yesterday", "parse-query: invalid since: yesterday"),
+		Entry("bad until", "until=tomorrow", "parse-query: invalid until: tomorrow"),
+	)
+
+	Context("when getting the events fails", func() {
+		BeforeEach(func() {
+			leaseEventRepository.EventsReturns(nil, errors.New("butter"))
+		})
+
+		It("calls the internal server error handler", func() {
+			request, err := http.NewRequest("GET", "/leases/events", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			l, w, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("butter"))
+			Expect(description).To(Equal("lease-events: butter"))
+		})
+	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalStub = func(interface{}) ([]byte, error) {
+				return nil, errors.New("grapes")
+			}
+		})
+
+		It("calls the internal server error handler", func() {
+			request, err := http.NewRequest("GET", "/leases/events", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("grapes"))
+			Expect(description).To(Equal("marshal-response: grapes"))
+		})
+	})
+})
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/lease_record_repository.go --fake-name LeaseRecordRepository . leaseRecordRepository
+type leaseRecordRepository interface {
+	LeaseRecords() ([]controller.LeaseRecord, error)
+}
+
+// LeaseRecordsIndex lists every lease, expired or not, optionally only the one
+// of the underlay_ip given in the query.
+type LeaseRecordsIndex struct {
+	Marshaler             marshal.Marshaler
+	LeaseRecordRepository leaseRecordRepository
+	ErrorResponse         errorResponse
+}
+
+func (l *LeaseRecordsIndex) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("lease-records-index")
+
+	records, err := l.LeaseRecordRepository.LeaseRecords()
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("lease-records: %s", err.Error()))
+		return
+	}
+
+	if underlayIP := req.URL.Query().Get("underlay_ip"); underlayIP != "" {
+		filtered := []controller.LeaseRecord{}
+		for _, record := range records {
+			if record.UnderlayIP == underlayIP {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+
+	response := struct {
+		Leases []controller.LeaseRecord `json:"leases"`
+	}{records}
+	bytes, err := l.Marshaler.Marshal(response)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	w.Write(bytes)
+}
@@ -0,0 +1,141 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeaseRecordsIndex", func() {
+	var (
+		logger                *lagertest.TestLogger
+		expectedLogger        lager.Logger
+		handler               *handlers.LeaseRecordsIndex
+		leaseRecordRepository *fakes.LeaseRecordRepository
+		resp                  *httptest.ResponseRecorder
+		marshaler             *hfakes.Marshaler
+		fakeErrorResponse     *fakes.ErrorResponse
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("lease-records-index")
+
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		leaseRecordRepository = &fakes.LeaseRecordRepository{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+		handler = &handlers.LeaseRecordsIndex{
+			Marshaler:             marshaler,
+			LeaseRecordRepository: leaseRecordRepository,
+			ErrorResponse:         fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+		leaseRecordRepository.LeaseRecordsReturns([]controller.LeaseRecord{
+			{
+				Lease: controller.Lease{
+					UnderlayIP:          "10.244.5.9",
+					OverlaySubnet:       "10.255.16.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:ff:10:00",
+				},
+				LastRenewedAt: 1700000000,
+			},
+			{
+				Lease: controller.Lease{
+					UnderlayIP:          "10.244.22.33",
+					OverlaySubnet:       "10.250.75.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:fa:4b:00",
+					Pool:                "blue",
+				},
+				LastRenewedAt: 1600000000,
+				Expired:       true,
+			},
+		}, nil)
+	})
+
+	It("returns every lease record", func() {
+		request, err := http.NewRequest("GET", "/admin/leases", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(`{ "leases": [
+			{ "underlay_ip": "10.244.5.9", "overlay_subnet": "10.255.16.0/24", "overlay_hardware_addr": "ee:ee:0a:ff:10:00", "last_renewed_at": 1700000000, "expired": false },
+			{ "underlay_ip": "10.244.22.33", "overlay_subnet": "10.250.75.0/24", "overlay_hardware_addr": "ee:ee:0a:fa:4b:00", "pool": "blue", "last_renewed_at": 1600000000, "expired": true }
+		] }`))
+	})
+
+	It("returns only the record of the requested underlay ip", func() {
+		request, err := http.NewRequest("GET", "/admin/leases?underlay_ip=10.244.22.33", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(`{ "leases": [
+			{ "underlay_ip": "10.244.22.33", "overlay_subnet": "10.250.75.0/24", "overlay_hardware_addr": "ee:ee:0a:fa:4b:00", "pool": "blue", "last_renewed_at": 1600000000, "expired": true }
+		] }`))
+	})
+
+	It("returns no records when the underlay ip has no lease", func() {
+		request, err := http.NewRequest("GET", "/admin/leases?underlay_ip=10.244.1.1", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(resp.Body).To(MatchJSON(`{ "leases": [] }`))
+	})
+
+	Context("when getting the lease records fails", func() {
+		BeforeEach(func() {
+			leaseRecordRepository.LeaseRecordsReturns(nil, errors.New("butter"))
+		})
+
+		It("calls the internal server error handler", func() {
+			request, err := http.NewRequest("GET", "/admin/leases", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			l, w, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("butter"))
+			Expect(description).To(Equal("lease-records: butter"))
+		})
+	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalStub = func(interface{}) ([]byte, error) {
+				return nil, errors.New("grapes")
+			}
+		})
+
+		It("calls the internal server error handler", func() {
+			request, err := http.NewRequest("GET", "/admin/leases", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("grapes"))
+			Expect(description).To(Equal("marshal-response: grapes"))
+		})
+	})
+})
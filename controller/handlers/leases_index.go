@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"code.cloudfoundry.org/cf-networking-helpers/marshal"
 	"code.cloudfoundry.org/lager/v3"
@@ -11,7 +17,8 @@ import (
 
 //go:generate counterfeiter -o fakes/lease_repository.go --fake-name LeaseRepository . leaseRepository
 type leaseRepository interface {
-	RoutableLeases() ([]controller.Lease, error)
+	HostLeases() ([]controller.HostLease, error)
+	HostLeasesForPool(pool string) ([]controller.HostLease, error)
 }
 
 type LeasesIndex struct {
@@ -23,14 +30,41 @@ type LeasesIndex struct {
 func (l *LeasesIndex) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
 	logger = logger.Session("leases-index")
 
-	leases, err := l.LeaseRepository.RoutableLeases()
+	selector, err := controller.ParseLabelSelector(req.URL.Query().Get("selector"))
+	if err != nil {
+		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("parse-selector: %s", err.Error()))
+		return
+	}
+
+	var leases []controller.HostLease
+	// an empty pool parameter selects the default pool, an absent one selects all pools
+	if pool, ok := req.URL.Query()["pool"]; ok {
+		leases, err = l.LeaseRepository.HostLeasesForPool(pool[0])
+	} else {
+		leases, err = l.LeaseRepository.HostLeases()
+	}
 	if err != nil {
 		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("all-routable-leases: %s", err.Error()))
 		return
 	}
 
+	selected := []controller.HostLease{}
+	for _, lease := range leases {
+		if selector.Matches(lease.Host) {
+			selected = append(selected, lease)
+		}
+	}
+	leases = selected
+
+	etag := leasesETag(leases)
+	w.Header().Set("ETag", etag)
+	if matchesETag(req.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	response := struct {
-		Leases []controller.Lease `json:"leases"`
+		Leases []controller.HostLease `json:"leases"`
 	}{leases}
 	bytes, err := l.Marshaler.Marshal(response)
 	if err != nil {
@@ -40,3 +74,39 @@ func (l *LeasesIndex) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req
 
 	w.Write(bytes)
 }
+
+// leasesETag identifies the set of leases and their hosts, whatever order
+// they come in.
+func leasesETag(leases []controller.HostLease) string {
+	keys := make([]string, 0, len(leases))
+	for _, lease := range leases {
+		host, _ := json.Marshal(lease.Host)
+		keys = append(keys, strings.Join([]string{
+			lease.UnderlayIP,
+			lease.OverlaySubnet,
+			lease.OverlaySubnetV6,
+			lease.OverlayHardwareAddr,
+			lease.Pool,
+			strconv.Itoa(lease.VNI),
+			string(host),
+		}, "\x00"))
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte("\n"))
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+func matchesETag(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 
 	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
 	"code.cloudfoundry.org/lager/v3"
@@ -46,23 +47,33 @@ var _ = Describe("LeasesIndex", func() {
 			ErrorResponse:   fakeErrorResponse,
 		}
 		resp = httptest.NewRecorder()
-		leaseRepository.RoutableLeasesReturns([]controller.Lease{
+		leaseRepository.HostLeasesReturns([]controller.HostLease{
 			{
-				UnderlayIP:          "10.244.5.9",
-				OverlaySubnet:       "10.255.16.0/24",
-				OverlayHardwareAddr: "ee:ee:0a:ff:10:00",
+				Lease: controller.Lease{
+					UnderlayIP:          "10.244.5.9",
+					OverlaySubnet:       "10.255.16.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:ff:10:00",
+				},
+				Host: &controller.HostMetadata{
+					Hostname: "diego-cell-0",
+					AZ:       "z2",
+					Labels:   map[string]string{"stack": "cflinuxfs4"},
+				},
 			},
 			{
-				UnderlayIP:          "10.244.22.33",
-				OverlaySubnet:       "10.255.75.0/32",
-				OverlayHardwareAddr: "ee:ee:0a:ff:4b:00",
+				Lease: controller.Lease{
+					UnderlayIP:          "10.244.22.33",
+					OverlaySubnet:       "10.255.75.0/32",
+					OverlayHardwareAddr: "ee:ee:0a:ff:4b:00",
+				},
 			},
 		}, nil)
 	})
 
-	It("returns the routable leases", func() {
+	It("returns the routable leases with their hosts", func() {
 		expectedResponseJSON := `{ "leases": [
-		{ "underlay_ip": "10.244.5.9", "overlay_subnet": "10.255.16.0/24", "overlay_hardware_addr": "ee:ee:0a:ff:10:00" },
+		{ "underlay_ip": "10.244.5.9", "overlay_subnet": "10.255.16.0/24", "overlay_hardware_addr": "ee:ee:0a:ff:10:00",
+		  "host": { "hostname": "diego-cell-0", "az": "z2", "labels": { "stack": "cflinuxfs4" } } },
 		  { "underlay_ip": "10.244.22.33", "overlay_subnet": "10.255.75.0/32", "overlay_hardware_addr": "ee:ee:0a:ff:4b:00" }
 		] }`
 		request, err := http.NewRequest("GET", "/leases", nil)
@@ -71,14 +82,207 @@ var _ = Describe("LeasesIndex", func() {
 		request.RemoteAddr = "some-host:some-port"
 
 		handler.ServeHTTP(logger, resp, request)
-		Expect(leaseRepository.RoutableLeasesCallCount()).To(Equal(1))
+		Expect(leaseRepository.HostLeasesCallCount()).To(Equal(1))
 		Expect(resp.Code).To(Equal(http.StatusOK))
 		Expect(resp.Body).To(MatchJSON(expectedResponseJSON))
 	})
 
+	Describe("selecting hosts by label", func() {
+		It("returns the leases whose hosts match the selector", func() {
+			request, err := http.NewRequest("GET", "/leases?selector="+url.QueryEscape("az=z2,stack"), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body).To(MatchJSON(`{ "leases": [
+				{ "underlay_ip": "10.244.5.9", "overlay_subnet": "10.255.16.0/24", "overlay_hardware_addr": "ee:ee:0a:ff:10:00",
+				  "host": { "hostname": "diego-cell-0", "az": "z2", "labels": { "stack": "cflinuxfs4" } } }
+			] }`))
+		})
+
+		It("returns an empty list when no host matches", func() {
+			request, err := http.NewRequest("GET", "/leases?selector=az%3Dz9", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body).To(MatchJSON(`{ "leases": [] }`))
+		})
+
+		Context("when the selector is invalid", func() {
+			It("calls the bad request handler", func() {
+				request, err := http.NewRequest("GET", "/leases?selector=%3Dz2", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				handler.ServeHTTP(logger, resp, request)
+
+				Expect(leaseRepository.HostLeasesCallCount()).To(Equal(0))
+				Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+				_, _, err, description := fakeErrorResponse.BadRequestArgsForCall(0)
+				Expect(err).To(MatchError(ContainSubstring("invalid label selector")))
+				Expect(description).To(HavePrefix("parse-selector: invalid label selector"))
+			})
+		})
+	})
+
+	Describe("conditional requests", func() {
+		var etag string
+
+		BeforeEach(func() {
+			request, err := http.NewRequest("GET", "/leases", nil)
+			Expect(err).NotTo(HaveOccurred())
+			handler.ServeHTTP(logger, resp, request)
+
+			etag = resp.Header().Get("ETag")
+			Expect(etag).To(MatchRegexp(`^"[0-9a-f]{32}"$`))
+			resp = httptest.NewRecorder()
+		})
+
+		It("returns not modified without a body when the leases still match the tag", func() {
+			request, err := http.NewRequest("GET", "/leases", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("If-None-Match", etag)
+
+			handler.ServeHTTP(logger, resp, request)
+			Expect(resp.Code).To(Equal(http.StatusNotModified))
+			Expect(resp.Header().Get("ETag")).To(Equal(etag))
+			Expect(resp.Body.Len()).To(Equal(0))
+			Expect(marshaler.MarshalCallCount()).To(Equal(1))
+		})
+
+		It("matches the tag among several", func() {
+			request, err := http.NewRequest("GET", "/leases", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("If-None-Match", `"other", W/`+etag)
+
+			handler.ServeHTTP(logger, resp, request)
+			Expect(resp.Code).To(Equal(http.StatusNotModified))
+		})
+
+		It("tags the same leases the same way in any order", func() {
+			leases, _ := leaseRepository.HostLeases()
+			leaseRepository.HostLeasesReturns([]controller.HostLease{leases[1], leases[0]}, nil)
+
+			request, err := http.NewRequest("GET", "/leases", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("If-None-Match", etag)
+
+			handler.ServeHTTP(logger, resp, request)
+			Expect(resp.Code).To(Equal(http.StatusNotModified))
+		})
+
+		It("tags the leases anew when a host changed", func() {
+			leases, _ := leaseRepository.HostLeases()
+			leases[1].Host = &controller.HostMetadata{Hostname: "diego-cell-1"}
+			leaseRepository.HostLeasesReturns(leases, nil)
+
+			request, err := http.NewRequest("GET", "/leases", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("If-None-Match", etag)
+
+			handler.ServeHTTP(logger, resp, request)
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Header().Get("ETag")).NotTo(Equal(etag))
+		})
+
+		It("tags the leases anew when the vni of a pool changed", func() {
+			leases, _ := leaseRepository.HostLeases()
+			leases[0].VNI = 4097
+			leaseRepository.HostLeasesReturns(leases, nil)
+
+			request, err := http.NewRequest("GET", "/leases", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("If-None-Match", etag)
+
+			handler.ServeHTTP(logger, resp, request)
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Header().Get("ETag")).NotTo(Equal(etag))
+		})
+
+		Context("when the leases changed", func() {
+			BeforeEach(func() {
+				leaseRepository.HostLeasesReturns([]controller.HostLease{{Lease: controller.Lease{
+					UnderlayIP:          "10.244.5.9",
+					OverlaySubnet:       "10.255.16.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:ff:10:00",
+				}}}, nil)
+			})
+
+			It("returns the leases with a new tag", func() {
+				request, err := http.NewRequest("GET", "/leases", nil)
+				Expect(err).NotTo(HaveOccurred())
+				request.Header.Set("If-None-Match", etag)
+
+				handler.ServeHTTP(logger, resp, request)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Header().Get("ETag")).NotTo(BeEmpty())
+				Expect(resp.Header().Get("ETag")).NotTo(Equal(etag))
+				Expect(resp.Body).To(MatchJSON(`{ "leases": [
+					{ "underlay_ip": "10.244.5.9", "overlay_subnet": "10.255.16.0/24", "overlay_hardware_addr": "ee:ee:0a:ff:10:00" }
+				] }`))
+			})
+		})
+	})
+
+	Context("when a pool is requested", func() {
+		BeforeEach(func() {
+			leaseRepository.HostLeasesForPoolReturns([]controller.HostLease{
+				{Lease: controller.Lease{
+					UnderlayIP:          "10.244.5.10",
+					OverlaySubnet:       "10.250.16.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:fa:10:00",
+					Pool:                "blue",
+				}},
+			}, nil)
+		})
+
+		It("returns the routable leases of that pool", func() {
+			request, err := http.NewRequest("GET", "/leases?pool=blue", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+			Expect(leaseRepository.HostLeasesCallCount()).To(Equal(0))
+			Expect(leaseRepository.HostLeasesForPoolCallCount()).To(Equal(1))
+			Expect(leaseRepository.HostLeasesForPoolArgsForCall(0)).To(Equal("blue"))
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body).To(MatchJSON(`{ "leases": [
+				{ "underlay_ip": "10.244.5.10", "overlay_subnet": "10.250.16.0/24", "overlay_hardware_addr": "ee:ee:0a:fa:10:00", "pool": "blue" }
+			] }`))
+		})
+
+		Context("when the pool parameter is empty", func() {
+			It("returns the routable leases of the default pool", func() {
+				request, err := http.NewRequest("GET", "/leases?pool=", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				handler.ServeHTTP(logger, resp, request)
+				Expect(leaseRepository.HostLeasesForPoolCallCount()).To(Equal(1))
+				Expect(leaseRepository.HostLeasesForPoolArgsForCall(0)).To(Equal(controller.DefaultPool))
+			})
+		})
+
+		Context("when getting the routable leases of the pool fails", func() {
+			BeforeEach(func() {
+				leaseRepository.HostLeasesForPoolReturns(nil, errors.New("unknown pool: green"))
+			})
+
+			It("calls the internal server error handler", func() {
+				request, err := http.NewRequest("GET", "/leases?pool=green", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				handler.ServeHTTP(logger, resp, request)
+
+				Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+				_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+				Expect(err).To(MatchError("unknown pool: green"))
+				Expect(description).To(Equal("all-routable-leases: unknown pool: green"))
+			})
+		})
+	})
+
 	Context("when getting the routable leases fails", func() {
 		BeforeEach(func() {
-			leaseRepository.RoutableLeasesReturns(nil, errors.New("butter"))
+			leaseRepository.HostLeasesReturns(nil, errors.New("butter"))
 		})
 
 		It("calls the internal server error handler", func() {
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/lease_watcher.go --fake-name LeaseWatcher . leaseWatcher
+type leaseWatcher interface {
+	Changes(ctx context.Context, since int64, pool *string) (controller.LeaseChanges, error)
+}
+
+// LeasesWatch answers once the routable leases change after the revision in
+// the since parameter, or once the timeout parameter, in seconds, runs out.
+// The timeout defaults to, and is capped at, MaxTimeout.
+//
+// Revisions belong to the controller that answered: another controller, or
+// the same one after a restart, answers them with a reset that holds every
+// routable lease. Behind a load balancer in front of several controllers the
+// watch stays correct but only saves the full list while a client keeps
+// reaching the same controller.
+type LeasesWatch struct {
+	Marshaler     marshal.Marshaler
+	LeaseWatcher  leaseWatcher
+	ErrorResponse errorResponse
+	MaxTimeout    time.Duration
+}
+
+func (l *LeasesWatch) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("leases-watch")
+
+	since, timeout, err := l.parseQuery(req)
+	if err != nil {
+		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("parse-query: %s", err.Error()))
+		return
+	}
+
+	// an empty pool parameter selects the default pool, an absent one selects all pools
+	var pool *string
+	if pools, ok := req.URL.Query()["pool"]; ok {
+		pool = &pools[0]
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+	changes, err := l.LeaseWatcher.Changes(ctx, since, pool)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("lease-changes: %s", err.Error()))
+		return
+	}
+
+	bytes, err := l.Marshaler.Marshal(changes)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	w.Write(bytes)
+}
+
+func (l *LeasesWatch) parseQuery(req *http.Request) (int64, time.Duration, error) {
+	query := req.URL.Query()
+
+	var since int64
+	if value := query.Get("since"); value != "" {
+		var err error
+		since, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || since < 0 {
+			return 0, 0, fmt.Errorf("invalid since: %s", value)
+		}
+	}
+
+	timeout := l.MaxTimeout
+	if value := query.Get("timeout"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			return 0, 0, fmt.Errorf("invalid timeout: %s", value)
+		}
+		if requested := time.Duration(seconds) * time.Second; requested < timeout {
+			timeout = requested
+		}
+	}
+	return since, timeout, nil
+}
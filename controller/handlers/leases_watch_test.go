@@ -0,0 +1,171 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeasesWatch", func() {
+	var (
+		logger            *lagertest.TestLogger
+		expectedLogger    lager.Logger
+		handler           *handlers.LeasesWatch
+		leaseWatcher      *fakes.LeaseWatcher
+		resp              *httptest.ResponseRecorder
+		marshaler         *hfakes.Marshaler
+		fakeErrorResponse *fakes.ErrorResponse
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("leases-watch")
+
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		leaseWatcher = &fakes.LeaseWatcher{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+		handler = &handlers.LeasesWatch{
+			Marshaler:     marshaler,
+			LeaseWatcher:  leaseWatcher,
+			ErrorResponse: fakeErrorResponse,
+			MaxTimeout:    time.Minute,
+		}
+		resp = httptest.NewRecorder()
+		leaseWatcher.ChangesReturns(controller.LeaseChanges{
+			Revision: 43,
+			Added:    []controller.Lease{{UnderlayIP: "10.244.5.9", OverlaySubnet: "10.255.16.0/24", OverlayHardwareAddr: "ee:ee:0a:ff:10:00"}},
+			Removed:  []controller.Lease{},
+		}, nil)
+	})
+
+	It("returns the changes since the revision", func() {
+		request, err := http.NewRequest("GET", "/leases/watch?since=42", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(logger, resp, request)
+		Expect(leaseWatcher.ChangesCallCount()).To(Equal(1))
+		ctx, since, pool := leaseWatcher.ChangesArgsForCall(0)
+		Expect(since).To(Equal(int64(42)))
+		Expect(pool).To(BeNil())
+		deadline, ok := ctx.Deadline()
+		Expect(ok).To(BeTrue())
+		Expect(time.Until(deadline)).To(BeNumerically("~", time.Minute, time.Second))
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(`{
+			"revision": 43,
+			"added": [{ "underlay_ip": "10.244.5.9", "overlay_subnet": "10.255.16.0/24", "overlay_hardware_addr": "ee:ee:0a:ff:10:00" }],
+			"removed": []
+		}`))
+	})
+
+	It("passes the pool and a shorter timeout", func() {
+		request, err := http.NewRequest("GET", "/leases/watch?since=42&pool=&timeout=5", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(logger, resp, request)
+		ctx, _, pool := leaseWatcher.ChangesArgsForCall(0)
+		Expect(pool).To(Equal(new(string)))
+		deadline, _ := ctx.Deadline()
+		Expect(time.Until(deadline)).To(BeNumerically("~", 5*time.Second, time.Second))
+	})
+
+	It("caps the timeout", func() {
+		request, err := http.NewRequest("GET", "/leases/watch?timeout=3600", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(logger, resp, request)
+		ctx, since, _ := leaseWatcher.ChangesArgsForCall(0)
+		Expect(since).To(Equal(int64(0)))
+		deadline, _ := ctx.Deadline()
+		Expect(time.Until(deadline)).To(BeNumerically("~", time.Minute, time.Second))
+	})
+
+	It("stops waiting when the request is cancelled", func() {
+		requestCtx, cancel := context.WithCancel(context.Background())
+		request, err := http.NewRequestWithContext(requestCtx, "GET", "/leases/watch", nil)
+		Expect(err).NotTo(HaveOccurred())
+		cancel()
+
+		handler.ServeHTTP(logger, resp, request)
+		ctx, _, _ := leaseWatcher.ChangesArgsForCall(0)
+		Expect(ctx.Err()).To(HaveOccurred())
+	})
+
+	DescribeTable("when the query is invalid",
+		func(query, description string) {
+			request, err := http.NewRequest("GET", "/leases/watch?"+query, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(leaseWatcher.ChangesCallCount()).To(Equal(0))
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+			l, w, _, desc := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(desc).To(Equal(description))
+		},
+		Entry("bad since", "since=yesterday", "parse-query: invalid since: yesterday"),
+		Entry("negative since", "since=-1", "parse-query: invalid since: -1"),
+		Entry("bad timeout", "timeout=forever", "parse-query: invalid timeout: forever"),
+	)
+
+	Context("when getting the changes fails", func() {
+		BeforeEach(func() {
+			leaseWatcher.ChangesReturns(controller.LeaseChanges{}, errors.New("butter"))
+		})
+
+		It("calls the internal server error handler", func() {
+			request, err := http.NewRequest("GET", "/leases/watch", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			l, w, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("butter"))
+			Expect(description).To(Equal("lease-changes: butter"))
+		})
+	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalStub = func(interface{}) ([]byte, error) {
+				return nil, errors.New("grapes")
+			}
+		})
+
+		It("calls the internal server error handler", func() {
+			request, err := http.NewRequest("GET", "/leases/watch", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("grapes"))
+			Expect(description).To(Equal("marshal-response: grapes"))
+		})
+	})
+})
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/pool_usage_repository.go --fake-name PoolUsageRepository . poolUsageRepository
+type poolUsageRepository interface {
+	PoolUsage() ([]controller.PoolUsage, error)
+}
+
+type PoolsUsage struct {
+	Marshaler           marshal.Marshaler
+	PoolUsageRepository poolUsageRepository
+	ErrorResponse       errorResponse
+}
+
+func (p *PoolsUsage) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("pools-usage")
+
+	usage, err := p.PoolUsageRepository.PoolUsage()
+	if err != nil {
+		p.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("pool-usage: %s", err.Error()))
+		return
+	}
+
+	response := struct {
+		Pools []controller.PoolUsage `json:"pools"`
+	}{usage}
+	bytes, err := p.Marshaler.Marshal(response)
+	if err != nil {
+		p.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	w.Write(bytes)
+}
@@ -0,0 +1,107 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PoolsUsage", func() {
+	var (
+		logger              *lagertest.TestLogger
+		expectedLogger      lager.Logger
+		handler             *handlers.PoolsUsage
+		poolUsageRepository *fakes.PoolUsageRepository
+		resp                *httptest.ResponseRecorder
+		marshaler           *hfakes.Marshaler
+		fakeErrorResponse   *fakes.ErrorResponse
+		request             *http.Request
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("pools-usage")
+
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		poolUsageRepository = &fakes.PoolUsageRepository{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+		handler = &handlers.PoolsUsage{
+			Marshaler:           marshaler,
+			PoolUsageRepository: poolUsageRepository,
+			ErrorResponse:       fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+		poolUsageRepository.PoolUsageReturns([]controller.PoolUsage{
+			{
+				Blocks:    controller.SubnetUsage{Total: 255, Active: 5, Expired: 1, Quarantined: 3, Reserved: 2, Free: 244},
+				SingleIPs: controller.SubnetUsage{Total: 255, Active: 1, Free: 254},
+			},
+		}, nil)
+
+		var err error
+		request, err = http.NewRequest("GET", "/admin/pools", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns the usage of every pool", func() {
+		handler.ServeHTTP(logger, resp, request)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(`{ "pools": [
+			{
+				"pool": "",
+				"blocks": { "total": 255, "active": 5, "expired": 1, "quarantined": 3, "reserved": 2, "free": 244 },
+				"single_ips": { "total": 255, "active": 1, "expired": 0, "quarantined": 0, "reserved": 0, "free": 254 }
+			}
+		] }`))
+	})
+
+	Context("when getting the usage fails", func() {
+		BeforeEach(func() {
+			poolUsageRepository.PoolUsageReturns(nil, errors.New("butter"))
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			l, w, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("butter"))
+			Expect(description).To(Equal("pool-usage: butter"))
+		})
+	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalStub = func(interface{}) ([]byte, error) {
+				return nil, errors.New("grapes")
+			}
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("grapes"))
+			Expect(description).To(Equal("marshal-response: grapes"))
+		})
+	})
+})
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+//go:generate counterfeiter -o fakes/rate_limiter.go --fake-name RateLimiter . rateLimiter
+type rateLimiter interface {
+	Take(key string) (time.Duration, bool)
+}
+
+//go:generate counterfeiter -o fakes/in_flight_limiter.go --fake-name InFlightLimiter . inFlightLimiter
+type inFlightLimiter interface {
+	TryAcquire() bool
+	Release()
+}
+
+// inFlightRetryAfter is how long clients turned away by the in-flight cap
+// are asked to wait.
+const inFlightRetryAfter = time.Second
+
+// RateLimit passes the request on to Handler unless the client is over the
+// rate of Limiter, or InFlight has no room for another request. Either turns
+// the request away with a 429 and a Retry-After. Clients are told apart the
+// way lease events name them. A nil Limiter or InFlight does not apply.
+type RateLimit struct {
+	Marshaler marshal.Marshaler
+	Limiter   rateLimiter
+	InFlight  inFlightLimiter
+	Handler   loggableHandler
+}
+
+func (r *RateLimit) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	if r.Limiter != nil {
+		actor := requestActor(req)
+		if wait, ok := r.Limiter.Take(actor); !ok {
+			r.tooManyRequests(logger.Session("rate-limit"), w, wait, fmt.Errorf("%s is over the rate limit", actor))
+			return
+		}
+	}
+	if r.InFlight != nil {
+		if !r.InFlight.TryAcquire() {
+			r.tooManyRequests(logger.Session("rate-limit"), w, inFlightRetryAfter, errors.New("too many requests in flight"))
+			return
+		}
+		defer r.InFlight.Release()
+	}
+	r.Handler.ServeHTTP(logger, w, req)
+}
+
+// tooManyRequests rounds the wait up to whole seconds, as Retry-After holds.
+// It logs at info, since a storm of rejections is expected while clients
+// back off.
+func (r *RateLimit) tooManyRequests(logger lager.Logger, w http.ResponseWriter, wait time.Duration, err error) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	logger.Info("too-many-requests", lager.Data{"error": err.Error(), "retry_after_seconds": seconds})
+
+	body, marshalErr := r.Marshaler.Marshal(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+	if marshalErr != nil {
+		logger.Error("marshal-response", marshalErr)
+		body = []byte(`{}`)
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(body)
+}
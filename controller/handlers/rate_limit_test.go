@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("RateLimit", func() {
+	var (
+		logger       *lagertest.TestLogger
+		handler      *handlers.RateLimit
+		innerHandler *fakes.LoggableHandler
+		limiter      *fakes.RateLimiter
+		inFlight     *fakes.InFlightLimiter
+		marshaler    *hfakes.Marshaler
+		resp         *httptest.ResponseRecorder
+		request      *http.Request
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		innerHandler = &fakes.LoggableHandler{}
+		limiter = &fakes.RateLimiter{}
+		limiter.TakeReturns(0, true)
+		inFlight = &fakes.InFlightLimiter{}
+		inFlight.TryAcquireReturns(true)
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		handler = &handlers.RateLimit{
+			Marshaler: marshaler,
+			Limiter:   limiter,
+			InFlight:  inFlight,
+			Handler:   innerHandler,
+		}
+		resp = httptest.NewRecorder()
+
+		var err error
+		request, err = http.NewRequest("PUT", "/leases/acquire", nil)
+		Expect(err).NotTo(HaveOccurred())
+		request.RemoteAddr = "10.0.0.1:5555"
+		request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "cell-0"}},
+		}}
+	})
+
+	It("serves a request within the limits and then frees its slot", func() {
+		handler.ServeHTTP(logger, resp, request)
+		Expect(innerHandler.ServeHTTPCallCount()).To(Equal(1))
+		l, w, r := innerHandler.ServeHTTPArgsForCall(0)
+		Expect(l).To(Equal(logger))
+		Expect(w).To(Equal(resp))
+		Expect(r).To(Equal(request))
+
+		Expect(limiter.TakeCallCount()).To(Equal(1))
+		Expect(limiter.TakeArgsForCall(0)).To(Equal("cell-0"))
+		Expect(inFlight.TryAcquireCallCount()).To(Equal(1))
+		Expect(inFlight.ReleaseCallCount()).To(Equal(1))
+	})
+
+	Context("when the client is over the rate limit", func() {
+		BeforeEach(func() {
+			limiter.TakeReturns(1500*time.Millisecond, false)
+		})
+
+		It("returns a 429 with the whole seconds to wait", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(innerHandler.ServeHTTPCallCount()).To(Equal(0))
+			Expect(inFlight.TryAcquireCallCount()).To(Equal(0))
+
+			Expect(resp.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(resp.Header().Get("Retry-After")).To(Equal("2"))
+			Expect(resp.Body).To(MatchJSON(`{"error": "cell-0 is over the rate limit"}`))
+			Expect(logger).To(gbytes.Say("test.rate-limit.too-many-requests"))
+		})
+	})
+
+	Context("when the name of the client needs escaping", func() {
+		BeforeEach(func() {
+			limiter.TakeReturns(time.Second, false)
+			request.TLS.PeerCertificates[0].Subject.CommonName = `cell-"0"\`
+		})
+
+		It("still returns valid json", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(resp.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(resp.Body).To(MatchJSON(`{"error": "cell-\"0\"\\ is over the rate limit"}`))
+		})
+	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			limiter.TakeReturns(time.Second, false)
+			marshaler.MarshalReturns(nil, errors.New("kiwi"))
+		})
+
+		It("still returns a 429", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(resp.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(resp.Header().Get("Retry-After")).To(Equal("1"))
+			Expect(resp.Body).To(MatchJSON(`{}`))
+		})
+	})
+
+	Context("when too many requests are in flight", func() {
+		BeforeEach(func() {
+			inFlight.TryAcquireReturns(false)
+		})
+
+		It("returns a 429 without freeing a slot it did not take", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(innerHandler.ServeHTTPCallCount()).To(Equal(0))
+			Expect(inFlight.ReleaseCallCount()).To(Equal(0))
+
+			Expect(resp.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(resp.Header().Get("Retry-After")).To(Equal("1"))
+			Expect(resp.Body).To(MatchJSON(`{"error": "too many requests in flight"}`))
+		})
+	})
+
+	Context("when there are no limits", func() {
+		BeforeEach(func() {
+			handler = &handlers.RateLimit{Marshaler: marshaler, Handler: innerHandler}
+		})
+
+		It("serves every request", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(innerHandler.ServeHTTPCallCount()).To(Equal(1))
+		})
+	})
+})
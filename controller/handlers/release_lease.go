@@ -11,13 +11,14 @@ import (
 
 //go:generate counterfeiter -o fakes/lease_releaser.go --fake-name LeaseReleaser . leaseReleaser
 type leaseReleaser interface {
-	ReleaseSubnetLease(underlayIP string) error
+	ReleaseSubnetLease(actor, underlayIP string) error
 }
 
 type ReleaseLease struct {
 	Marshaler     marshal.Marshaler
 	Unmarshaler   marshal.Unmarshaler
 	LeaseReleaser leaseReleaser
+	Authorizer    underlayAuthorizer
 	ErrorResponse errorResponse
 }
 
@@ -39,7 +40,13 @@ func (l *ReleaseLease) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req
 		return
 	}
 
-	err = l.LeaseReleaser.ReleaseSubnetLease(payload.UnderlayIP)
+	err = authorize(l.Authorizer, req, payload.UnderlayIP)
+	if err != nil {
+		l.ErrorResponse.Forbidden(logger, w, err, err.Error())
+		return
+	}
+
+	err = l.LeaseReleaser.ReleaseSubnetLease(requestActor(req), payload.UnderlayIP)
 	if err != nil {
 		l.ErrorResponse.InternalServerError(logger, w, err, err.Error())
 		return
@@ -2,6 +2,9 @@ package handlers_test
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
@@ -65,12 +68,73 @@ var _ = Describe("ReleaseLease", func() {
 	It("releases a lease for subnet", func() {
 		handler.ServeHTTP(logger, resp, request)
 		Expect(leaseReleaser.ReleaseSubnetLeaseCallCount()).To(Equal(1))
-		Expect(leaseReleaser.ReleaseSubnetLeaseArgsForCall(0)).To(Equal("10.244.16.11"))
+		actor, underlayIP := leaseReleaser.ReleaseSubnetLeaseArgsForCall(0)
+		Expect(actor).To(Equal("some-host"))
+		Expect(underlayIP).To(Equal("10.244.16.11"))
 
 		Expect(resp.Code).To(Equal(http.StatusOK))
 		Expect(resp.Body.String()).To(MatchJSON(`{}`))
 	})
 
+	Context("when the client presents a certificate", func() {
+		It("names the client by the common name of the certificate", func() {
+			request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+				{Subject: pkix.Name{CommonName: "silk-admin"}, DNSNames: []string{"admin.example.com"}},
+			}}
+
+			handler.ServeHTTP(logger, resp, request)
+			actor, _ := leaseReleaser.ReleaseSubnetLeaseArgsForCall(0)
+			Expect(actor).To(Equal("silk-admin"))
+		})
+
+		It("falls back to the first DNS name of the certificate", func() {
+			request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+				{DNSNames: []string{"admin.example.com"}},
+			}}
+
+			handler.ServeHTTP(logger, resp, request)
+			actor, _ := leaseReleaser.ReleaseSubnetLeaseArgsForCall(0)
+			Expect(actor).To(Equal("admin.example.com"))
+		})
+	})
+
+	Context("when there is an authorizer", func() {
+		var authorizer *fakes.UnderlayAuthorizer
+
+		BeforeEach(func() {
+			authorizer = &fakes.UnderlayAuthorizer{}
+			handler.Authorizer = authorizer
+		})
+
+		It("asks it about the underlay ip of the lease", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(authorizer.AuthorizeCallCount()).To(Equal(1))
+			req, underlayIP := authorizer.AuthorizeArgsForCall(0)
+			Expect(req).To(Equal(request))
+			Expect(underlayIP).To(Equal("10.244.16.11"))
+			Expect(leaseReleaser.ReleaseSubnetLeaseCallCount()).To(Equal(1))
+		})
+
+		Context("when the client may not manage the lease", func() {
+			BeforeEach(func() {
+				authorizer.AuthorizeReturns(errors.New("some-host may not manage the lease of 10.244.16.11"))
+			})
+
+			It("returns a 403 without releasing the lease", func() {
+				handler.ServeHTTP(logger, resp, request)
+
+				Expect(leaseReleaser.ReleaseSubnetLeaseCallCount()).To(Equal(0))
+				Expect(fakeErrorResponse.ForbiddenCallCount()).To(Equal(1))
+				l, w, err, description := fakeErrorResponse.ForbiddenArgsForCall(0)
+				Expect(l).To(Equal(expectedLogger))
+				Expect(w).To(Equal(resp))
+				Expect(err).To(MatchError("some-host may not manage the lease of 10.244.16.11"))
+				Expect(description).To(Equal("some-host may not manage the lease of 10.244.16.11"))
+			})
+		})
+	})
+
 	Context("when there are errors reading the body bytes", func() {
 		BeforeEach(func() {
 			request.Body = ioutil.NopCloser(&testsupport.BadReader{})
@@ -12,7 +12,7 @@ import (
 
 //go:generate counterfeiter -o fakes/lease_renewer.go --fake-name LeaseRenewer . leaseRenewer
 type leaseRenewer interface {
-	RenewSubnetLease(lease controller.Lease) error
+	RenewSubnetLease(actor string, lease controller.Lease, host *controller.HostMetadata) (*controller.Lease, error)
 }
 
 //go:generate counterfeiter -o fakes/error_response.go --fake-name ErrorResponse . errorResponse
@@ -20,11 +20,14 @@ type errorResponse interface {
 	InternalServerError(lager.Logger, http.ResponseWriter, error, string)
 	BadRequest(lager.Logger, http.ResponseWriter, error, string)
 	Conflict(lager.Logger, http.ResponseWriter, error, string)
+	Forbidden(lager.Logger, http.ResponseWriter, error, string)
 }
 
 type RenewLease struct {
+	Marshaler     marshal.Marshaler
 	Unmarshaler   marshal.Unmarshaler
 	LeaseRenewer  leaseRenewer
+	Authorizer    underlayAuthorizer
 	ErrorResponse errorResponse
 }
 
@@ -37,14 +40,20 @@ func (l *RenewLease) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *
 		return
 	}
 
-	var lease controller.Lease
-	err = l.Unmarshaler.Unmarshal(bodyBytes, &lease)
+	var request controller.RenewLeaseRequest
+	err = l.Unmarshaler.Unmarshal(bodyBytes, &request)
 	if err != nil {
 		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("unmarshal-request: %s", err.Error()))
 		return
 	}
 
-	err = l.LeaseRenewer.RenewSubnetLease(lease)
+	err = authorize(l.Authorizer, req, request.Lease.UnderlayIP)
+	if err != nil {
+		l.ErrorResponse.Forbidden(logger, w, err, err.Error())
+		return
+	}
+
+	lease, err := l.LeaseRenewer.RenewSubnetLease(requestActor(req), request.Lease, request.Host)
 	if err != nil {
 		if _, ok := err.(controller.NonRetriableError); ok {
 			l.ErrorResponse.Conflict(logger, w, err, fmt.Sprintf("renew-subnet-lease: %s", err.Error()))
@@ -55,5 +64,11 @@ func (l *RenewLease) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *
 		return
 	}
 
-	w.Write([]byte("{}"))
+	bytes, err := l.Marshaler.Marshal(lease)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	w.Write(bytes)
 }
@@ -27,6 +27,7 @@ var _ = Describe("RenewLease", func() {
 		expectedLogger    lager.Logger
 		handler           *handlers.RenewLease
 		resp              *httptest.ResponseRecorder
+		marshaler         *hfakes.Marshaler
 		unmarshaler       *hfakes.Unmarshaler
 		leaseRenewer      *fakes.LeaseRenewer
 		fakeErrorResponse *fakes.ErrorResponse
@@ -43,12 +44,15 @@ var _ = Describe("RenewLease", func() {
 		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
 
 		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
 		unmarshaler = &hfakes.Unmarshaler{}
 		unmarshaler.UnmarshalStub = json.Unmarshal
 		leaseRenewer = &fakes.LeaseRenewer{}
 		fakeErrorResponse = &fakes.ErrorResponse{}
 
 		handler = &handlers.RenewLease{
+			Marshaler:     marshaler,
 			Unmarshaler:   unmarshaler,
 			LeaseRenewer:  leaseRenewer,
 			ErrorResponse: fakeErrorResponse,
@@ -60,6 +64,9 @@ var _ = Describe("RenewLease", func() {
 			OverlaySubnet:       "10.255.17.0/24",
 			OverlayHardwareAddr: "ee:ee:0a:ff:11:00",
 		}
+		renewedLease := expectedLease
+		renewedLease.VNI = 42
+		leaseRenewer.RenewSubnetLeaseReturns(&renewedLease, nil)
 		requestBody := bytes.NewBuffer([]byte(`{ "underlay_ip": "10.244.16.11", "overlay_subnet": "10.255.17.0/24", "overlay_hardware_addr": "ee:ee:0a:ff:11:00" }`))
 		var err error
 		request, err = http.NewRequest("PUT", "/leases/renew", requestBody)
@@ -70,10 +77,68 @@ var _ = Describe("RenewLease", func() {
 	It("renews a lease for subnet", func() {
 		handler.ServeHTTP(logger, resp, request)
 		Expect(leaseRenewer.RenewSubnetLeaseCallCount()).To(Equal(1))
-		Expect(leaseRenewer.RenewSubnetLeaseArgsForCall(0)).To(Equal(expectedLease))
+		_, lease, host := leaseRenewer.RenewSubnetLeaseArgsForCall(0)
+		Expect(lease).To(Equal(expectedLease))
+		Expect(host).To(BeNil())
 
 		Expect(resp.Code).To(Equal(http.StatusOK))
-		Expect(resp.Body.String()).To(Equal("{}"))
+		Expect(resp.Body.String()).To(MatchJSON(`{
+			"underlay_ip": "10.244.16.11",
+			"overlay_subnet": "10.255.17.0/24",
+			"overlay_hardware_addr": "ee:ee:0a:ff:11:00",
+			"vni": 42
+		}`))
+	})
+
+	It("passes on the metadata of the host", func() {
+		request.Body = ioutil.NopCloser(bytes.NewBufferString(`{ "underlay_ip": "10.244.16.11", "overlay_subnet": "10.255.17.0/24", "overlay_hardware_addr": "ee:ee:0a:ff:11:00",
+			"host": { "hostname": "diego-cell-0", "instance_id": "6f1c1a5e", "labels": { "stack": "cflinuxfs4" } } }`))
+
+		handler.ServeHTTP(logger, resp, request)
+		_, lease, host := leaseRenewer.RenewSubnetLeaseArgsForCall(0)
+		Expect(lease).To(Equal(expectedLease))
+		Expect(host).To(Equal(&controller.HostMetadata{
+			Hostname:   "diego-cell-0",
+			InstanceID: "6f1c1a5e",
+			Labels:     map[string]string{"stack": "cflinuxfs4"},
+		}))
+	})
+
+	Context("when there is an authorizer", func() {
+		var authorizer *fakes.UnderlayAuthorizer
+
+		BeforeEach(func() {
+			authorizer = &fakes.UnderlayAuthorizer{}
+			handler.Authorizer = authorizer
+		})
+
+		It("asks it about the underlay ip of the lease", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(authorizer.AuthorizeCallCount()).To(Equal(1))
+			req, underlayIP := authorizer.AuthorizeArgsForCall(0)
+			Expect(req).To(Equal(request))
+			Expect(underlayIP).To(Equal("10.244.16.11"))
+			Expect(leaseRenewer.RenewSubnetLeaseCallCount()).To(Equal(1))
+		})
+
+		Context("when the client may not manage the lease", func() {
+			BeforeEach(func() {
+				authorizer.AuthorizeReturns(errors.New("some-host may not manage the lease of 10.244.16.11"))
+			})
+
+			It("returns a 403 without renewing the lease", func() {
+				handler.ServeHTTP(logger, resp, request)
+
+				Expect(leaseRenewer.RenewSubnetLeaseCallCount()).To(Equal(0))
+				Expect(fakeErrorResponse.ForbiddenCallCount()).To(Equal(1))
+				l, w, err, description := fakeErrorResponse.ForbiddenArgsForCall(0)
+				Expect(l).To(Equal(expectedLogger))
+				Expect(w).To(Equal(resp))
+				Expect(err).To(MatchError("some-host may not manage the lease of 10.244.16.11"))
+				Expect(description).To(Equal("some-host may not manage the lease of 10.244.16.11"))
+			})
+		})
 	})
 
 	Context("when there are errors reading the body bytes", func() {
@@ -114,7 +179,7 @@ var _ = Describe("RenewLease", func() {
 		var terr controller.NonRetriableError
 		BeforeEach(func() {
 			terr = controller.NonRetriableError("kiwi")
-			leaseRenewer.RenewSubnetLeaseReturns(terr)
+			leaseRenewer.RenewSubnetLeaseReturns(nil, terr)
 		})
 
 		It("calls the Error Response Conflict() handler", func() {
@@ -131,7 +196,7 @@ var _ = Describe("RenewLease", func() {
 
 	Context("when renewing a lease fails due to some other error", func() {
 		BeforeEach(func() {
-			leaseRenewer.RenewSubnetLeaseReturns(errors.New("kiwi"))
+			leaseRenewer.RenewSubnetLeaseReturns(nil, errors.New("kiwi"))
 		})
 
 		It("calls the Error Response InternalServerError() handler", func() {
@@ -145,4 +210,21 @@ var _ = Describe("RenewLease", func() {
 			Expect(description).To(Equal("renew-subnet-lease: kiwi"))
 		})
 	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalReturns(nil, errors.New("grape"))
+		})
+
+		It("calls the Error Response InternalServerError() handler", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			l, w, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("grape"))
+			Expect(description).To(Equal("marshal-response: grape"))
+		})
+	})
 })
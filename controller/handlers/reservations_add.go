@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/subnet_reserver.go --fake-name SubnetReserver . subnetReserver
+type subnetReserver interface {
+	ReserveSubnet(reservation controller.Reservation) error
+}
+
+type ReservationsAdd struct {
+	Unmarshaler    marshal.Unmarshaler
+	SubnetReserver subnetReserver
+	ErrorResponse  errorResponse
+}
+
+func (r *ReservationsAdd) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("reservations-add")
+
+	bodyBytes, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		r.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("read-body: %s", err.Error()))
+		return
+	}
+
+	var reservation controller.Reservation
+	err = r.Unmarshaler.Unmarshal(bodyBytes, &reservation)
+	if err != nil {
+		r.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("unmarshal-request: %s", err.Error()))
+		return
+	}
+
+	err = r.SubnetReserver.ReserveSubnet(reservation)
+	if err != nil {
+		if _, ok := err.(controller.NonRetriableError); ok {
+			r.ErrorResponse.Conflict(logger, w, err, fmt.Sprintf("reserve-subnet: %s", err.Error()))
+			return
+		}
+
+		r.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("reserve-subnet: %s", err.Error()))
+		return
+	}
+
+	w.Write([]byte("{}"))
+}
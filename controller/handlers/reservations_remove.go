@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/reservation_remover.go --fake-name ReservationRemover . reservationRemover
+type reservationRemover interface {
+	RemoveReservation(underlayIP string) error
+}
+
+type ReservationsRemove struct {
+	Unmarshaler        marshal.Unmarshaler
+	ReservationRemover reservationRemover
+	ErrorResponse      errorResponse
+}
+
+func (r *ReservationsRemove) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("reservations-remove")
+
+	bodyBytes, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		r.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("read-body: %s", err.Error()))
+		return
+	}
+
+	var payload controller.RemoveReservationRequest
+	err = r.Unmarshaler.Unmarshal(bodyBytes, &payload)
+	if err != nil {
+		r.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("unmarshal-request: %s", err.Error()))
+		return
+	}
+
+	err = r.ReservationRemover.RemoveReservation(payload.UnderlayIP)
+	if err != nil {
+		r.ErrorResponse.InternalServerError(logger, w, err, err.Error())
+		return
+	}
+
+	w.Write([]byte(`{}`))
+}
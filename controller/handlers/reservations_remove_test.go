@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/cf-networking-helpers/testsupport"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReservationsRemove", func() {
+	var (
+		logger             *lagertest.TestLogger
+		expectedLogger     lager.Logger
+		handler            *handlers.ReservationsRemove
+		resp               *httptest.ResponseRecorder
+		unmarshaler        *hfakes.Unmarshaler
+		reservationRemover *fakes.ReservationRemover
+		fakeErrorResponse  *fakes.ErrorResponse
+
+		request *http.Request
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("reservations-remove")
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		unmarshaler = &hfakes.Unmarshaler{}
+		unmarshaler.UnmarshalStub = json.Unmarshal
+		reservationRemover = &fakes.ReservationRemover{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+
+		handler = &handlers.ReservationsRemove{
+			Unmarshaler:        unmarshaler,
+			ReservationRemover: reservationRemover,
+			ErrorResponse:      fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+
+		requestBody := bytes.NewBuffer([]byte(`{ "underlay_ip": "10.244.16.11" }`))
+		var err error
+		request, err = http.NewRequest("PUT", "/reservations/remove", requestBody)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("removes the reservation", func() {
+		handler.ServeHTTP(logger, resp, request)
+		Expect(reservationRemover.RemoveReservationCallCount()).To(Equal(1))
+		Expect(reservationRemover.RemoveReservationArgsForCall(0)).To(Equal("10.244.16.11"))
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`{}`))
+	})
+
+	Context("when there are errors reading the body bytes", func() {
+		BeforeEach(func() {
+			request.Body = ioutil.NopCloser(&testsupport.BadReader{})
+		})
+
+		It("logs the error and returns a 400", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+			l, w, err, description := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("banana"))
+			Expect(description).To(Equal("read-body: banana"))
+		})
+	})
+
+	Context("when the request cannot be unmarshaled", func() {
+		BeforeEach(func() {
+			unmarshaler.UnmarshalReturns(errors.New("fig"))
+		})
+
+		It("returns a BadRequest error", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(err).To(MatchError("fig"))
+			Expect(description).To(Equal("unmarshal-request: fig"))
+		})
+	})
+
+	Context("when removing the reservation fails", func() {
+		BeforeEach(func() {
+			reservationRemover.RemoveReservationReturns(errors.New("kiwi"))
+		})
+
+		It("calls the Error Response InternalServerError() handler", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("kiwi"))
+			Expect(description).To(Equal("kiwi"))
+		})
+	})
+})
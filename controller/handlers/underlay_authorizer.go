@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+//go:generate counterfeiter -o fakes/underlay_authorizer.go --fake-name UnderlayAuthorizer . underlayAuthorizer
+type underlayAuthorizer interface {
+	Authorize(req *http.Request, underlayIP string) error
+}
+
+// UnderlayAuthorizer decides whether the client that sent a request may
+// manage the lease of an underlay ip. Admins may manage any lease, and other
+// clients only those of the underlay ips of their identities. With no host
+// identities, every client may manage any lease.
+type UnderlayAuthorizer struct {
+	adminIdentities []string
+	hostRanges      map[string][]netip.Prefix
+}
+
+// NewUnderlayAuthorizer maps each host identity, a client certificate common
+// name or DNS name, to the underlay ips or cidrs whose leases it may manage.
+func NewUnderlayAuthorizer(adminIdentities []string, hostIdentities map[string][]string) (*UnderlayAuthorizer, error) {
+	hostRanges := map[string][]netip.Prefix{}
+	for identity, underlayRanges := range hostIdentities {
+		var prefixes []netip.Prefix
+		for _, underlayRange := range underlayRanges {
+			prefix, err := parseUnderlayRange(underlayRange)
+			if err != nil {
+				return nil, fmt.Errorf("underlay range of %s: %s", identity, err)
+			}
+			prefixes = append(prefixes, prefix)
+		}
+		hostRanges[identity] = prefixes
+	}
+	return &UnderlayAuthorizer{
+		adminIdentities: adminIdentities,
+		hostRanges:      hostRanges,
+	}, nil
+}
+
+// parseUnderlayRange parses an ip, as a range of one address, or a cidr.
+func parseUnderlayRange(underlayRange string) (netip.Prefix, error) {
+	if !strings.Contains(underlayRange, "/") {
+		addr, err := netip.ParseAddr(underlayRange)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(underlayRange)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+func (a *UnderlayAuthorizer) Authorize(req *http.Request, underlayIP string) error {
+	if len(a.hostRanges) == 0 {
+		return nil
+	}
+
+	identities := requestIdentities(req)
+	for _, identity := range identities {
+		for _, admin := range a.adminIdentities {
+			if identity == admin {
+				return nil
+			}
+		}
+	}
+
+	addr, err := netip.ParseAddr(underlayIP)
+	if err == nil {
+		for _, identity := range identities {
+			for _, prefix := range a.hostRanges[identity] {
+				if prefix.Contains(addr) {
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("%s may not manage the lease of %s", requestActor(req), underlayIP)
+}
+
+// authorize allows every request when no authorizer is set.
+func authorize(authorizer underlayAuthorizer, req *http.Request, underlayIP string) error {
+	if authorizer == nil {
+		return nil
+	}
+	return authorizer.Authorize(req, underlayIP)
+}
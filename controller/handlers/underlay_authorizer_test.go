@@ -0,0 +1,109 @@
+package handlers_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+
+	"code.cloudfoundry.org/silk/controller/handlers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnderlayAuthorizer", func() {
+	var (
+		authorizer *handlers.UnderlayAuthorizer
+		request    *http.Request
+	)
+
+	BeforeEach(func() {
+		var err error
+		authorizer, err = handlers.NewUnderlayAuthorizer(
+			[]string{"silk-admin"},
+			map[string][]string{
+				"cell-0.example.com": {"10.244.16.11"},
+				"z1-cells":           {"10.244.32.0/20", "fd00:244::/64"},
+				"retired":            nil,
+			},
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		request, err = http.NewRequest("PUT", "/leases/release", nil)
+		Expect(err).NotTo(HaveOccurred())
+		request.RemoteAddr = "10.0.0.1:5555"
+	})
+
+	withCert := func(cert *x509.Certificate) {
+		request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	}
+
+	It("allows a host to manage the lease of its own underlay ip", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "cell-0.example.com"}})
+		Expect(authorizer.Authorize(request, "10.244.16.11")).To(Succeed())
+	})
+
+	It("matches hosts by the DNS names of their certificate", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "diego-cell"}, DNSNames: []string{"cell-0.example.com"}})
+		Expect(authorizer.Authorize(request, "10.244.16.11")).To(Succeed())
+	})
+
+	It("allows a host to manage the leases of underlay ips in its cidrs", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "z1-cells"}})
+		Expect(authorizer.Authorize(request, "10.244.40.7")).To(Succeed())
+		Expect(authorizer.Authorize(request, "fd00:244::7")).To(Succeed())
+	})
+
+	It("forbids a host to manage the lease of another underlay ip", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "cell-0.example.com"}})
+		Expect(authorizer.Authorize(request, "10.244.16.12")).To(MatchError("cell-0.example.com may not manage the lease of 10.244.16.12"))
+
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "z1-cells"}})
+		Expect(authorizer.Authorize(request, "10.244.48.1")).To(MatchError("z1-cells may not manage the lease of 10.244.48.1"))
+	})
+
+	It("forbids a host without underlay ranges to manage any lease", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "retired"}})
+		Expect(authorizer.Authorize(request, "10.244.16.11")).To(MatchError("retired may not manage the lease of 10.244.16.11"))
+	})
+
+	It("forbids clients that are not host identities", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "stranger"}})
+		Expect(authorizer.Authorize(request, "10.244.16.11")).To(MatchError("stranger may not manage the lease of 10.244.16.11"))
+	})
+
+	It("forbids clients without a certificate", func() {
+		Expect(authorizer.Authorize(request, "10.244.16.11")).To(MatchError("10.0.0.1 may not manage the lease of 10.244.16.11"))
+	})
+
+	It("forbids an underlay ip that is not an ip", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "z1-cells"}})
+		Expect(authorizer.Authorize(request, "banana")).To(MatchError("z1-cells may not manage the lease of banana"))
+	})
+
+	It("allows an admin to manage any lease", func() {
+		withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "silk-admin"}})
+		Expect(authorizer.Authorize(request, "10.244.16.12")).To(Succeed())
+	})
+
+	Context("when there are no host identities", func() {
+		BeforeEach(func() {
+			var err error
+			authorizer, err = handlers.NewUnderlayAuthorizer([]string{"silk-admin"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("allows any client to manage any lease", func() {
+			withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "stranger"}})
+			Expect(authorizer.Authorize(request, "10.244.16.11")).To(Succeed())
+		})
+	})
+
+	Context("when an underlay range is invalid", func() {
+		It("returns an error", func() {
+			_, err := handlers.NewUnderlayAuthorizer(nil, map[string][]string{"cell-0": {"banana"}})
+			Expect(err).To(MatchError(ContainSubstring("underlay range of cell-0: ")))
+		})
+	})
+})
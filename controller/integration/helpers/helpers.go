@@ -87,6 +87,16 @@ func TestClient(conf config.Config, fixturesPath string) *controller.Client {
 	return controller.NewClient(lagertest.NewTestLogger("test"), httpClient, baseURL)
 }
 
+func TestAdminClient(conf config.Config, fixturesPath string) *controller.AdminClient {
+	baseURL := fmt.Sprintf("https://%s:%d", conf.ListenHost, conf.ListenPort)
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: makeClientTLSConfig(fixturesPath),
+		},
+	}
+	return controller.NewAdminClient(lagertest.NewTestLogger("test"), httpClient, baseURL)
+}
+
 func makeClientTLSConfig(fixturesPath string) *tls.Config {
 	clientCertPath := filepath.Join(fixturesPath, "client.crt")
 	clientKeyPath := filepath.Join(fixturesPath, "client.key")
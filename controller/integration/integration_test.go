@@ -1,12 +1,15 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"time"
 
 	"code.cloudfoundry.org/cf-networking-helpers/db"
+	"code.cloudfoundry.org/cf-networking-helpers/json_client"
 	"code.cloudfoundry.org/cf-networking-helpers/testsupport"
 	"code.cloudfoundry.org/cf-networking-helpers/testsupport/metrics"
 	"code.cloudfoundry.org/cf-networking-helpers/testsupport/ports"
@@ -14,6 +17,7 @@ import (
 	"code.cloudfoundry.org/silk/controller/config"
 	"code.cloudfoundry.org/silk/controller/integration/helpers"
 	"code.cloudfoundry.org/silk/controller/leaser"
+	silktestsupport "code.cloudfoundry.org/silk/testsupport"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -32,9 +36,8 @@ var (
 
 var _ = BeforeEach(func() {
 	fakeMetron = metrics.NewFakeMetron()
-	dbConfig = testsupport.GetDBConfig()
-	dbConfig.DatabaseName = fmt.Sprintf("test_%d", ports.PickAPort())
-	testsupport.CreateDatabase(dbConfig)
+	dbConfig = silktestsupport.GetDBConfig(fmt.Sprintf("test_%d", ports.PickAPort()))
+	silktestsupport.CreateDatabase(dbConfig)
 
 	conf = helpers.DefaultTestConfig(dbConfig, "fixtures")
 	conf.MetronPort = fakeMetron.Port()
@@ -49,7 +52,7 @@ var _ = Describe("Silk Controller", func() {
 	AfterEach(func() {
 		testClient.JsonClient.CloseIdleConnections()
 		helpers.StopServer(session)
-		testsupport.RemoveDatabase(dbConfig)
+		silktestsupport.RemoveDatabase(dbConfig)
 	})
 
 	It("gracefully terminates when sent an interrupt signal", func() {
@@ -81,6 +84,64 @@ var _ = Describe("Silk Controller", func() {
 		Expect(resp.StatusCode).To(Equal(http.StatusOK))
 	})
 
+	Describe("leader election", func() {
+		var (
+			followerConf    config.Config
+			followerSession *gexec.Session
+		)
+
+		health := func(conf config.Config) func() (map[string]interface{}, error) {
+			return func() (map[string]interface{}, error) {
+				resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/health", conf.HealthCheckPort))
+				if err != nil {
+					return nil, err
+				}
+				defer resp.Body.Close()
+				var body map[string]interface{}
+				err = json.NewDecoder(resp.Body).Decode(&body)
+				return body, err
+			}
+		}
+
+		BeforeEach(func() {
+			helpers.StopServer(session)
+			conf.LeaderElection = config.LeaderElection{Enabled: true, Identity: "controller-0", HeartbeatSeconds: 1, TTLSeconds: 3}
+			session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+
+			followerConf = conf
+			followerConf.ListenPort = ports.PickAPort()
+			followerConf.DebugServerPort = ports.PickAPort()
+			followerConf.HealthCheckPort = ports.PickAPort()
+			followerConf.LeaderElection.Identity = "controller-1"
+			followerSession = helpers.StartAndWaitForServer(controllerBinaryPath, followerConf, helpers.TestClient(followerConf, "fixtures"))
+		})
+
+		AfterEach(func() {
+			helpers.StopServer(followerSession)
+		})
+
+		It("elects one controller and lets the others serve requests", func() {
+			Expect(health(conf)()).To(Equal(map[string]interface{}{"leader": "controller-0", "is_leader": true}))
+			Expect(health(followerConf)()).To(Equal(map[string]interface{}{"leader": "controller-0", "is_leader": false}))
+
+			followerClient := helpers.TestClient(followerConf, "fixtures")
+			lease, err := followerClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+			leases, err := testClient.GetActiveLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease))
+		})
+
+		It("hands leadership over when the leader stops", func() {
+			helpers.StopServer(session)
+			Eventually(health(followerConf), "5s").Should(Equal(map[string]interface{}{"leader": "controller-1", "is_leader": true}))
+			Expect(followerSession.Out).To(gbytes.Say(`leader-changed.*"leader":"controller-1"`))
+
+			session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+			Consistently(health(conf), "2s").Should(HaveKeyWithValue("leader", "controller-1"))
+		})
+	})
+
 	Describe("acquiring", func() {
 		It("provides an endpoint to acquire a subnet leases", func() {
 			lease, err := testClient.AcquireSubnetLease("10.244.4.5")
@@ -131,6 +192,346 @@ var _ = Describe("Silk Controller", func() {
 				})
 			})
 		})
+		Context("when the lowest-free-first allocation strategy is configured", func() {
+			BeforeEach(func() {
+				helpers.StopServer(session)
+				conf.AllocationStrategy = "lowest-free-first"
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+			})
+
+			It("hands out subnets in address order", func() {
+				for i, underlayIP := range []string{"10.244.4.5", "10.244.4.6", "10.244.4.7"} {
+					lease, err := testClient.AcquireSubnetLease(underlayIP)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal(fmt.Sprintf("10.255.%d.0/24", i+1)))
+				}
+			})
+
+			Context("when released subnets are quarantined", func() {
+				BeforeEach(func() {
+					helpers.StopServer(session)
+					conf.QuarantineSeconds = 300
+					session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+				})
+
+				It("does not hand a released subnet to another underlay ip", func() {
+					lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.1.0/24"))
+					Expect(testClient.ReleaseSubnetLease("10.244.4.5")).To(Succeed())
+
+					lease, err = testClient.AcquireSubnetLease("10.244.4.6")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.2.0/24"))
+				})
+			})
+
+			Context("when ranges are excluded", func() {
+				BeforeEach(func() {
+					helpers.StopServer(session)
+					conf.ExcludedRanges = []string{"10.255.1.0/24", "10.255.2.0/23"}
+					session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+				})
+
+				It("never hands out subnets from them", func() {
+					lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.4.0/24"))
+
+					lease.OverlaySubnet = "10.255.2.0/24"
+					_, err = testClient.RenewSubnetLease(lease)
+					Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
+					Expect(err).To(MatchError(ContainSubstring("overlay subnet 10.255.2.0/24 is in an excluded range")))
+				})
+			})
+
+			Context("when the network is partitioned by az", func() {
+				BeforeEach(func() {
+					helpers.StopServer(session)
+					conf.Topology = config.Topology{
+						Key: "az",
+						Partitions: []config.Partition{
+							{Value: "z1", Network: "10.255.16.0/20"},
+							{Value: "z2", Network: "10.255.32.0/20"},
+						},
+					}
+					session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+				})
+
+				It("hands hosts subnets from the partition of their az and records the decision", func() {
+					acquire := func(underlayIP string, host *controller.HostMetadata) controller.Lease {
+						client := helpers.TestClient(conf, "fixtures")
+						client.Host = host
+						lease, err := client.AcquireSubnetLease(underlayIP)
+						Expect(err).NotTo(HaveOccurred())
+						return lease
+					}
+					reason := func(underlayIP string) string {
+						events, err := testClient.GetLeaseEvents(controller.LeaseEventFilter{UnderlayIP: underlayIP})
+						Expect(err).NotTo(HaveOccurred())
+						Expect(events).To(HaveLen(1))
+						return events[0].Reason
+					}
+
+					Expect(acquire("10.244.4.5", &controller.HostMetadata{AZ: "z1"}).OverlaySubnet).To(Equal("10.255.16.0/24"))
+					Expect(acquire("10.244.4.6", &controller.HostMetadata{AZ: "z2"}).OverlaySubnet).To(Equal("10.255.32.0/24"))
+					Expect(acquire("10.244.4.7", &controller.HostMetadata{AZ: "z1"}).OverlaySubnet).To(Equal("10.255.17.0/24"))
+					Expect(acquire("10.244.4.8", &controller.HostMetadata{AZ: "z3"}).OverlaySubnet).To(Equal("10.255.1.0/24"))
+					Expect(acquire("10.244.4.9", nil).OverlaySubnet).To(Equal("10.255.2.0/24"))
+
+					Expect(reason("10.244.4.5")).To(Equal("allocated from partition az=z1"))
+					Expect(reason("10.244.4.6")).To(Equal("allocated from partition az=z2"))
+					Expect(reason("10.244.4.8")).To(Equal("no partition for az=z3, allocated from overflow"))
+					Expect(reason("10.244.4.9")).To(Equal("host has no topology metadata, allocated from overflow"))
+				})
+			})
+		})
+
+		Context("when the network is drained in favour of a new one", func() {
+			It("keeps existing leases and hands out new ones from the new network", func() {
+				oldLease, err := testClient.AcquireSubnetLease("10.244.4.5")
+				Expect(err).NotTo(HaveOccurred())
+
+				helpers.StopServer(session)
+				conf.Network = ""
+				conf.CIDRs = []config.CIDR{
+					{Network: "10.255.0.0/16", State: config.CIDRStateDraining},
+					{Network: "10.253.0.0/16", State: config.CIDRStateActive},
+				}
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+
+				_, err = testClient.RenewSubnetLease(oldLease)
+				Expect(err).NotTo(HaveOccurred())
+				lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease).To(Equal(oldLease))
+
+				newLease, err := testClient.AcquireSubnetLease("10.244.4.6")
+				Expect(err).NotTo(HaveOccurred())
+				_, subnet, err := net.ParseCIDR(newLease.OverlaySubnet)
+				Expect(err).NotTo(HaveOccurred())
+				_, network, err := net.ParseCIDR("10.253.0.0/16")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(network.Contains(subnet.IP)).To(BeTrue())
+			})
+		})
+
+		Context("when an ipv6 overlay network is configured", func() {
+			BeforeEach(func() {
+				helpers.StopServer(session)
+				conf.NetworkV6 = "fd00:255::/48"
+				conf.SubnetPrefixLengthV6 = 64
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+			})
+
+			It("provides dual stack leases that can be listed and renewed", func() {
+				lease, err := testClient.AcquireLease(controller.AcquireLeaseRequest{
+					UnderlayIP: "10.244.4.5",
+					IPFamily:   controller.IPFamilyDual,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, subnet, err := net.ParseCIDR(lease.OverlaySubnet)
+				Expect(err).NotTo(HaveOccurred())
+				_, network, err := net.ParseCIDR(conf.Network)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(network.Contains(subnet.IP)).To(BeTrue())
+
+				_, subnetV6, err := net.ParseCIDR(lease.OverlaySubnetV6)
+				Expect(err).NotTo(HaveOccurred())
+				_, networkV6, err := net.ParseCIDR(conf.NetworkV6)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networkV6.Contains(subnetV6.IP)).To(BeTrue())
+				ones, _ := subnetV6.Mask.Size()
+				Expect(ones).To(Equal(64))
+
+				leases, err := testClient.GetActiveLeases()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(lease))
+
+				_, err = testClient.RenewSubnetLease(lease)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("provides ipv6 only leases to ipv6 underlay addresses", func() {
+				lease, err := testClient.AcquireLease(controller.AcquireLeaseRequest{
+					UnderlayIP: "fd00:244::4:5",
+					IPFamily:   controller.IPFamilyV6,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease.UnderlayIP).To(Equal("fd00:244::4:5"))
+				Expect(lease.OverlaySubnet).To(BeEmpty())
+				Expect(lease.OverlaySubnetV6).NotTo(BeEmpty())
+			})
+		})
+	})
+
+	Describe("named pools", func() {
+		BeforeEach(func() {
+			helpers.StopServer(session)
+			conf.Pools = []config.Pool{
+				{Name: "blue", Network: "10.250.0.0/16", SubnetPrefixLength: 24},
+			}
+			session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+		})
+
+		AfterEach(func() {
+			testClient.Pool = controller.DefaultPool
+		})
+
+		It("acquires leases from the named pool and lists them by pool", func() {
+			defaultLease, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			testClient.Pool = "blue"
+			blueLease, err := testClient.AcquireSubnetLease("10.244.4.6")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blueLease.Pool).To(Equal("blue"))
+			_, subnet, err := net.ParseCIDR(blueLease.OverlaySubnet)
+			Expect(err).NotTo(HaveOccurred())
+			_, network, err := net.ParseCIDR("10.250.0.0/16")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(network.Contains(subnet.IP)).To(BeTrue())
+
+			leases, err := testClient.GetActiveLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(blueLease))
+
+			_, err = testClient.RenewSubnetLease(blueLease)
+			Expect(err).NotTo(HaveOccurred())
+
+			testClient.Pool = controller.DefaultPool
+			leases, err = testClient.GetActiveLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(defaultLease, blueLease))
+		})
+
+		It("serves the usage of every pool", func() {
+			_, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+			testClient.Pool = "blue"
+			_, err = testClient.AcquireSubnetLease("10.244.4.6")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = testClient.AcquireSubnetLease("10.244.4.7")
+			Expect(err).NotTo(HaveOccurred())
+
+			usage, err := testClient.GetPoolUsage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage).To(HaveLen(2))
+			Expect(usage[0].Pool).To(Equal(controller.DefaultPool))
+			Expect(usage[0].Blocks.Active).To(Equal(1))
+			Expect(usage[0].Blocks.Free).To(Equal(usage[0].Blocks.Total - 1))
+			Expect(usage[1].Pool).To(Equal("blue"))
+			Expect(usage[1].Blocks).To(Equal(controller.SubnetUsage{Total: 255, Active: 2, Free: 253}))
+		})
+
+		Context("when the pools have their own vnis", func() {
+			BeforeEach(func() {
+				helpers.StopServer(session)
+				conf.VNI = 1
+				conf.Pools[0].VNI = 7
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+			})
+
+			It("hands out the vni of the pool with its leases", func() {
+				defaultLease, err := testClient.AcquireSubnetLease("10.244.4.5")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(defaultLease.VNI).To(Equal(1))
+
+				testClient.Pool = "blue"
+				blueLease, err := testClient.AcquireSubnetLease("10.244.4.6")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(blueLease.VNI).To(Equal(7))
+				_, err = testClient.RenewSubnetLease(blueLease)
+				Expect(err).NotTo(HaveOccurred())
+
+				testClient.Pool = controller.DefaultPool
+				leases, err := testClient.GetActiveLeases()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(defaultLease, blueLease))
+
+			})
+
+			It("moves the hosts of a pool to its new vni as they renew", func() {
+				testClient.Pool = "blue"
+				blueLease, err := testClient.AcquireSubnetLease("10.244.4.6")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(blueLease.VNI).To(Equal(7))
+
+				helpers.StopServer(session)
+				conf.Pools[0].VNI = 9
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+
+				renewed, err := testClient.RenewSubnetLease(blueLease)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(renewed.VNI).To(Equal(9))
+				blueLease.VNI = 9
+				Expect(renewed).To(Equal(blueLease))
+
+				leases, err := testClient.GetActiveLeases()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ContainElement(blueLease))
+			})
+		})
+
+		It("rejects acquiring from a pool that is not configured", func() {
+			_, err := testClient.AcquireLease(controller.AcquireLeaseRequest{UnderlayIP: "10.244.4.5", Pool: "green"})
+			Expect(err).To(MatchError(ContainSubstring("unknown pool: green")))
+		})
+	})
+
+	Describe("reservations", func() {
+		BeforeEach(func() {
+			helpers.StopServer(session)
+			conf.Network = "10.255.0.0/28"
+			conf.SubnetPrefixLength = 30
+			conf.AdminIdentities = []string{"client"}
+			session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+
+			Expect(testClient.AddReservation(controller.Reservation{
+				UnderlayIP:    "10.244.4.5",
+				OverlaySubnet: "10.255.0.8/30",
+			})).To(Succeed())
+		})
+
+		It("leases the reserved subnet only to its underlay ip", func() {
+			reservations, err := testClient.GetReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(ConsistOf(controller.Reservation{UnderlayIP: "10.244.4.5", OverlaySubnet: "10.255.0.8/30"}))
+
+			for _, underlayIP := range []string{"10.244.4.6", "10.244.4.7"} {
+				lease, err := testClient.AcquireSubnetLease(underlayIP)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease.OverlaySubnet).NotTo(Equal("10.255.0.8/30"))
+			}
+			_, err = testClient.AcquireSubnetLease("10.244.4.8")
+			Expect(err).To(MatchError(ContainSubstring("no lease available")))
+
+			lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lease.OverlaySubnet).To(Equal("10.255.0.8/30"))
+		})
+
+		It("rejects a conflicting reservation", func() {
+			err := testClient.AddReservation(controller.Reservation{
+				UnderlayIP:    "10.244.4.6",
+				OverlaySubnet: "10.255.0.8/30",
+			})
+			Expect(err).To(MatchError(ContainSubstring("overlay subnet 10.255.0.8/30 is already reserved")))
+		})
+
+		It("frees the subnet when the reservation is removed", func() {
+			Expect(testClient.RemoveReservation("10.244.4.5")).To(Succeed())
+
+			reservations, err := testClient.GetReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(BeEmpty())
+
+			for _, underlayIP := range []string{"10.244.4.6", "10.244.4.7", "10.244.4.8"} {
+				_, err := testClient.AcquireSubnetLease(underlayIP)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
 	})
 
 	Describe("releasing", func() {
@@ -192,6 +593,21 @@ var _ = Describe("Silk Controller", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
+
+		It("records the history of the lease", func() {
+			lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testClient.ReleaseSubnetLease("10.244.4.5")).To(Succeed())
+
+			events, err := testClient.GetLeaseEvents(controller.LeaseEventFilter{UnderlayIP: "10.244.4.5"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Type).To(Equal(controller.LeaseEventAcquired))
+			Expect(events[0].OverlaySubnet).To(Equal(lease.OverlaySubnet))
+			Expect(events[0].Actor).NotTo(BeEmpty())
+			Expect(events[1].Type).To(Equal(controller.LeaseEventReleased))
+			Expect(events[1].Reason).To(Equal("released by request"))
+		})
 	})
 
 	Describe("lease expiration", func() {
@@ -217,6 +633,62 @@ var _ = Describe("Silk Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(newLease.OverlaySubnet).To(Equal(oldLease.OverlaySubnet))
 		})
+
+		Context("when the reaper is enabled", func() {
+			BeforeEach(func() {
+				helpers.StopServer(session)
+				conf.Reaper = config.Reaper{
+					Policy:          "after-expiry",
+					IntervalSeconds: 1,
+					ExpiryMultiple:  1,
+				}
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+			})
+
+			It("deletes expired leases in the background", func() {
+				_, err := testClient.AcquireSubnetLease("10.244.4.5")
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session.Out, "10s").Should(gbytes.Say("lease-reaped.*10.244.4.5"))
+				Eventually(fakeMetron.AllEvents, "5s").Should(ContainElement(HaveName("leaseReaped")))
+			})
+		})
+	})
+
+	Describe("keeping leases in memory", func() {
+		BeforeEach(func() {
+			helpers.StopServer(session)
+			conf.Database = db.Config{Type: "memory"}
+			conf.Network = "10.255.0.0/29"
+			conf.SubnetPrefixLength = 30
+			conf.LeaseExpirationSeconds = 3
+			session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+		})
+
+		It("acquires, lists, expires and releases leases without a database", func() {
+			Expect(session.Out).To(gbytes.Say("keeping leases in memory"))
+
+			lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			leases, err := testClient.GetActiveLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease))
+
+			_, err = testClient.AcquireSubnetLease("10.244.4.15")
+			Expect(err).To(MatchError(ContainSubstring("no lease available")))
+
+			time.Sleep(time.Duration(conf.LeaseExpirationSeconds+1) * time.Second)
+
+			newLease, err := testClient.AcquireSubnetLease("10.244.4.15")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newLease.OverlaySubnet).To(Equal(lease.OverlaySubnet))
+
+			Expect(testClient.ReleaseSubnetLease("10.244.4.15")).To(Succeed())
+			leases, err = testClient.GetActiveLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(BeEmpty())
+		})
 	})
 
 	Describe("renewal", func() {
@@ -226,7 +698,7 @@ var _ = Describe("Silk Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			By("attempting to renew it")
-			err = testClient.RenewSubnetLease(lease)
+			_, err = testClient.RenewSubnetLease(lease)
 			Expect(err).NotTo(HaveOccurred())
 
 			By("checking that the lease is present in the list of routable leases")
@@ -247,7 +719,7 @@ var _ = Describe("Silk Controller", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				By("attempting to renew it")
-				err = testClient.RenewSubnetLease(lease)
+				_, err = testClient.RenewSubnetLease(lease)
 				Expect(err).NotTo(HaveOccurred())
 
 				By("checking that the lease is present in the list of routable leases")
@@ -276,7 +748,7 @@ var _ = Describe("Silk Controller", func() {
 				}
 
 				By("attempting to renew it")
-				err = testClient.RenewSubnetLease(invalidLease)
+				_, err = testClient.RenewSubnetLease(invalidLease)
 				Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
 				typedError := err.(controller.NonRetriableError)
 				Expect(typedError.Error()).To(Equal("non-retriable: renew-subnet-lease: lease mismatch"))
@@ -304,7 +776,7 @@ var _ = Describe("Silk Controller", func() {
 					session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
 				})
 				It("renews the same lease in the old network", func() {
-					err := testClient.RenewSubnetLease(existingLease)
+					_, err := testClient.RenewSubnetLease(existingLease)
 					Expect(err).NotTo(HaveOccurred())
 
 					By("checking that the lease is present in the list of routable leases")
@@ -325,7 +797,7 @@ var _ = Describe("Silk Controller", func() {
 				}
 
 				By("attempting to renew something new but ok")
-				err := testClient.RenewSubnetLease(lease)
+				_, err := testClient.RenewSubnetLease(lease)
 				Expect(err).NotTo(HaveOccurred())
 
 				By("checking that the lease is present in the list of routable leases")
@@ -377,7 +849,8 @@ var _ = Describe("Silk Controller", func() {
 				Expect(leases).To(ConsistOf(lease1, lease2))
 
 				renewAndCheck := func() []controller.Lease {
-					Expect(testClient.RenewSubnetLease(lease2)).To(Succeed())
+					_, err = testClient.RenewSubnetLease(lease2)
+					Expect(err).NotTo(HaveOccurred())
 					leases, err := testClient.GetActiveLeases()
 					Expect(err).NotTo(HaveOccurred())
 					return leases
@@ -388,6 +861,40 @@ var _ = Describe("Silk Controller", func() {
 			})
 		})
 
+		Context("when the hosts send their metadata", func() {
+			It("lists the leases with their hosts and selects them by label", func() {
+				z1Client := helpers.TestClient(conf, "fixtures")
+				z1Client.Host = &controller.HostMetadata{Hostname: "diego-cell-0", AZ: "z1", Labels: map[string]string{"stack": "cflinuxfs4"}}
+				z1Lease, err := z1Client.AcquireSubnetLease("10.244.4.5")
+				Expect(err).NotTo(HaveOccurred())
+
+				z2Client := helpers.TestClient(conf, "fixtures")
+				z2Lease, err := z2Client.AcquireSubnetLease("10.244.4.6")
+				Expect(err).NotTo(HaveOccurred())
+				z2Client.Host = &controller.HostMetadata{Hostname: "diego-cell-1", AZ: "z2", Deployment: "cf"}
+				_, err = z2Client.RenewSubnetLease(z2Lease)
+				Expect(err).NotTo(HaveOccurred())
+
+				leases, err := testClient.GetHostLeases("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(
+					controller.HostLease{Lease: z1Lease, Host: z1Client.Host},
+					controller.HostLease{Lease: z2Lease, Host: z2Client.Host},
+				))
+
+				leases, err = testClient.GetHostLeases("az=z2")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(controller.HostLease{Lease: z2Lease, Host: z2Client.Host}))
+
+				leases, err = testClient.GetHostLeases("stack,az!=z2")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(controller.HostLease{Lease: z1Lease, Host: z1Client.Host}))
+
+				_, err = testClient.GetHostLeases("=z2")
+				Expect(err).To(MatchError(ContainSubstring("400")))
+			})
+		})
+
 		Context("when there are leases from different networks", func() {
 			var oldNetworkLease controller.Lease
 			var newNetworkLease controller.Lease
@@ -413,6 +920,29 @@ var _ = Describe("Silk Controller", func() {
 		})
 	})
 
+	Describe("watching leases", func() {
+		It("sends every lease first and then the changes since the revision it returned", func() {
+			lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			var changes controller.LeaseChanges
+			Eventually(func() ([]controller.Lease, error) {
+				changes, err = testClient.WatchLeases(0, 0)
+				return changes.Added, err
+			}, "5s").Should(ConsistOf(lease))
+			Expect(changes.Reset).To(BeTrue())
+
+			newLease, err := testClient.AcquireSubnetLease("10.244.4.6")
+			Expect(err).NotTo(HaveOccurred())
+
+			changes, err = testClient.WatchLeases(changes.Revision, 5*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes.Reset).To(BeFalse())
+			Expect(changes.Added).To(ConsistOf(newLease))
+			Expect(changes.Removed).To(BeEmpty())
+		})
+	})
+
 	It("assigns unique leases from the whole network to multiple clients acquiring subnets concurrently", func() {
 		parallelRunner := &testsupport.ParallelRunner{
 			NumWorkers: 4,
@@ -504,6 +1034,151 @@ var _ = Describe("Silk Controller", func() {
 		return SatisfyAll(withName(name), withValue(value))
 	}
 
+	Describe("admin routes", func() {
+		var adminClient *controller.AdminClient
+
+		BeforeEach(func() {
+			adminClient = helpers.TestAdminClient(conf, "fixtures")
+			_, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("forbids clients that are not admins", func() {
+			_, err := adminClient.PoolUsage()
+			Expect(err).To(BeAssignableToTypeOf(&json_client.HttpResponseCodeError{}))
+			Expect(err.(*json_client.HttpResponseCodeError).StatusCode).To(Equal(http.StatusForbidden))
+		})
+
+		It("forbids clients that are not admins to change reservations", func() {
+			for _, err := range []error{
+				testClient.AddReservation(controller.Reservation{UnderlayIP: "10.244.4.6", OverlaySubnet: "10.255.9.0/24"}),
+				testClient.RemoveReservation("10.244.4.6"),
+			} {
+				Expect(err).To(BeAssignableToTypeOf(&json_client.HttpResponseCodeError{}))
+				Expect(err.(*json_client.HttpResponseCodeError).StatusCode).To(Equal(http.StatusForbidden))
+			}
+
+			reservations, err := testClient.GetReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(BeEmpty())
+		})
+
+		Context("when the client is an admin", func() {
+			BeforeEach(func() {
+				helpers.StopServer(session)
+				conf.AdminIdentities = []string{"client"}
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+			})
+
+			It("lists the leases and the usage of the pool", func() {
+				records, err := adminClient.LeaseRecords("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(records).To(HaveLen(1))
+				Expect(records[0].UnderlayIP).To(Equal("10.244.4.5"))
+				Expect(records[0].Expired).To(BeFalse())
+
+				usage, err := adminClient.PoolUsage()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(usage).To(HaveLen(1))
+				Expect(usage[0].Blocks.Active).To(Equal(1))
+			})
+
+			It("releases leases", func() {
+				Expect(adminClient.ReleaseLease("10.244.4.5")).To(Succeed())
+
+				records, err := adminClient.LeaseRecords("")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(records).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("host identities", func() {
+		var lease controller.Lease
+
+		BeforeEach(func() {
+			var err error
+			lease, err = testClient.AcquireSubnetLease("10.244.5.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			helpers.StopServer(session)
+			conf.HostIdentities = []config.HostIdentity{{Identity: "client", UnderlayRanges: []string{"10.244.4.0/24"}}}
+			session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+		})
+
+		expectForbidden := func(err error) {
+			Expect(err).To(BeAssignableToTypeOf(&json_client.HttpResponseCodeError{}))
+			Expect(err.(*json_client.HttpResponseCodeError).StatusCode).To(Equal(http.StatusForbidden))
+		}
+
+		It("lets the client manage the leases of its underlay ips", func() {
+			ownLease, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = testClient.RenewSubnetLease(ownLease)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testClient.ReleaseSubnetLease("10.244.4.5")).To(Succeed())
+		})
+
+		It("forbids the client to manage the leases of other underlay ips", func() {
+			_, err := testClient.AcquireSubnetLease("10.244.5.6")
+			expectForbidden(err)
+			_, err = testClient.RenewSubnetLease(lease)
+			expectForbidden(err)
+			expectForbidden(testClient.ReleaseSubnetLease("10.244.5.5"))
+
+			leases, err := testClient.GetActiveLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease))
+		})
+
+		Context("when the client is an admin", func() {
+			BeforeEach(func() {
+				helpers.StopServer(session)
+				conf.AdminIdentities = []string{"client"}
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+			})
+
+			It("lets it manage any lease", func() {
+				_, err := testClient.RenewSubnetLease(lease)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(testClient.ReleaseSubnetLease("10.244.5.5")).To(Succeed())
+
+				leases, err := testClient.GetActiveLeases()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("rate limits", func() {
+		BeforeEach(func() {
+			helpers.StopServer(session)
+			conf.RateLimits = map[string]config.RateLimit{
+				"leases-acquire": {RequestsPerSecond: 0.01, Burst: 1},
+			}
+			conf.MaxInFlightAcquires = 5
+			session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+		})
+
+		It("turns away acquisitions over the rate of the client with the time to wait", func() {
+			lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = testClient.AcquireSubnetLease("10.244.4.6")
+			Expect(err).To(BeAssignableToTypeOf(&controller.RateLimitedError{}))
+			rateLimited := err.(*controller.RateLimitedError)
+			Expect(rateLimited.Message).To(Equal("client is over the rate limit"))
+			Expect(rateLimited.RetryAfter).To(BeNumerically("~", 100*time.Second, 2*time.Second))
+
+			By("leaving the other routes alone")
+			_, err = testClient.RenewSubnetLease(lease)
+			Expect(err).NotTo(HaveOccurred())
+			leases, err := testClient.GetActiveLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(ConsistOf(lease))
+		})
+	})
+
 	Describe("metrics", func() {
 		It("emits an uptime metric", func() {
 			Eventually(fakeMetron.AllEvents, "5s").Should(ContainElement(withName("uptime")))
@@ -538,5 +1213,67 @@ var _ = Describe("Silk Controller", func() {
 			})
 		})
 
+		Context("when the prometheus endpoint is enabled", func() {
+			BeforeEach(func() {
+				helpers.StopServer(session)
+				conf.PrometheusPort = ports.PickAPort()
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+
+				_, err := testClient.AcquireSubnetLease("10.244.4.5")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("serves the lease, request and database metrics", func() {
+				resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", conf.PrometheusPort))
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(body)).To(ContainSubstring("silk_controller_total_leases 1\n"))
+				Expect(string(body)).To(ContainSubstring("silk_controller_free_leases 254\n"))
+				Expect(string(body)).To(ContainSubstring(`silk_controller_pool_capacity{pool="",subnets="blocks"} 255`))
+				Expect(string(body)).To(ContainSubstring(`silk_controller_pool_subnets{pool="",state="active",subnets="blocks"} 1`))
+				Expect(string(body)).To(ContainSubstring(`silk_controller_pool_subnets{pool="",state="free",subnets="blocks"} 254`))
+				Expect(string(body)).To(ContainSubstring(`silk_controller_request_duration_seconds_count{code="200",method="put",route="LeasesAcquire"} 1`))
+				Expect(string(body)).To(ContainSubstring("silk_controller_acquire_retry_total 0\n"))
+				Expect(string(body)).To(ContainSubstring("silk_controller_lease_reclaimed_total 0\n"))
+				Expect(string(body)).To(ContainSubstring(`go_sql_open_connections{db_name="silk"}`))
+				Expect(string(body)).To(MatchRegexp(`silk_controller_db_queries_total [1-9]`))
+				Expect(string(body)).To(ContainSubstring("silk_controller_is_leader 1\n"))
+				Expect(string(body)).To(MatchRegexp(`silk_controller_leader{leader=".+:\d+"} 1`))
+			})
+		})
+
+		Context("when the lease cache is enabled", func() {
+			BeforeEach(func() {
+				helpers.StopServer(session)
+				conf.PrometheusPort = ports.PickAPort()
+				conf.LeaseCacheSeconds = 60
+				session = helpers.StartAndWaitForServer(controllerBinaryPath, conf, testClient)
+			})
+
+			It("lists the leases from the cache until the controller changes them", func() {
+				lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(testClient.GetActiveLeases()).To(ConsistOf(lease))
+				Expect(testClient.GetActiveLeases()).To(ConsistOf(lease))
+
+				lease2, err := testClient.AcquireSubnetLease("10.244.4.6")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(testClient.GetActiveLeases()).To(ConsistOf(lease, lease2))
+
+				resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", conf.PrometheusPort))
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(body)).To(MatchRegexp(`silk_controller_lease_cache_queries_saved [1-9]`))
+				Expect(string(body)).To(MatchRegexp(`silk_controller_lease_cache_hit_ratio 0\.\d*[1-9]`))
+			})
+		})
+
 	})
 })
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabelSelector selects hosts by their metadata. It matches a host when every
+// one of its requirements does.
+type LabelSelector []labelRequirement
+
+type labelRequirement struct {
+	key      string
+	operator string
+	value    string
+}
+
+const (
+	selectEquals    = "="
+	selectNotEquals = "!="
+	selectExists    = "exists"
+	selectNotExists = "!exists"
+)
+
+// ParseLabelSelector parses a comma separated list of requirements, each one
+// of key=value, key!=value, key, which requires the key to be set, or !key,
+// which requires it not to be. The keys hostname, az, deployment and
+// instance_id stand for the fields of the host, and any other key for a
+// label. An empty selector matches every host.
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var requirements LabelSelector
+	if strings.TrimSpace(selector) == "" {
+		return requirements, nil
+	}
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		var requirement labelRequirement
+		switch {
+		case strings.Contains(term, selectNotEquals):
+			parts := strings.SplitN(term, selectNotEquals, 2)
+			requirement = labelRequirement{key: parts[0], operator: selectNotEquals, value: parts[1]}
+		case strings.Contains(term, selectEquals):
+			parts := strings.SplitN(term, selectEquals, 2)
+			requirement = labelRequirement{key: parts[0], operator: selectEquals, value: parts[1]}
+		case strings.HasPrefix(term, "!"):
+			requirement = labelRequirement{key: term[1:], operator: selectNotExists}
+		default:
+			requirement = labelRequirement{key: term, operator: selectExists}
+		}
+		requirement.key = strings.TrimSpace(requirement.key)
+		requirement.value = strings.TrimSpace(requirement.value)
+		if requirement.key == "" {
+			return nil, fmt.Errorf("invalid label selector %q: missing key in %q", selector, term)
+		}
+		if strings.ContainsAny(requirement.key, "=!") || strings.ContainsAny(requirement.value, "=!") {
+			return nil, fmt.Errorf("invalid label selector %q: invalid requirement %q", selector, term)
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+// Matches returns whether the host meets every requirement. A nil host has
+// no fields or labels set.
+func (s LabelSelector) Matches(host *HostMetadata) bool {
+	for _, requirement := range s {
+		value, ok := host.Label(requirement.key)
+		switch requirement.operator {
+		case selectEquals:
+			if !ok || value != requirement.value {
+				return false
+			}
+		case selectNotEquals:
+			if ok && value == requirement.value {
+				return false
+			}
+		case selectExists:
+			if !ok {
+				return false
+			}
+		case selectNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Label returns the field or label of the key, as named in a selector, and
+// whether it is set.
+func (h *HostMetadata) Label(key string) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+	var field string
+	switch key {
+	case "hostname":
+		field = h.Hostname
+	case "az":
+		field = h.AZ
+	case "deployment":
+		field = h.Deployment
+	case "instance_id":
+		field = h.InstanceID
+	default:
+		value, ok := h.Labels[key]
+		return value, ok
+	}
+	return field, field != ""
+}
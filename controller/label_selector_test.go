@@ -0,0 +1,55 @@
+package controller_test
+
+import (
+	"code.cloudfoundry.org/silk/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LabelSelector", func() {
+	host := &controller.HostMetadata{
+		Hostname:   "diego-cell-0",
+		AZ:         "z2",
+		Deployment: "cf",
+		InstanceID: "6f1c1a5e",
+		Labels:     map[string]string{"stack": "cflinuxfs4", "isolation_segment": ""},
+	}
+
+	DescribeTable("Matches",
+		func(selector string, h *controller.HostMetadata, matches bool) {
+			s, err := controller.ParseLabelSelector(selector)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Matches(h)).To(Equal(matches))
+		},
+		Entry("an empty selector", "", host, true),
+		Entry("an empty selector without a host", "", nil, true),
+		Entry("a matching field", "az=z2", host, true),
+		Entry("a field with another value", "az=z1", host, false),
+		Entry("every field", "hostname=diego-cell-0, deployment=cf ,instance_id=6f1c1a5e", host, true),
+		Entry("a matching label", "stack=cflinuxfs4", host, true),
+		Entry("a label with another value", "stack=windows", host, false),
+		Entry("a label with an empty value", "isolation_segment=", host, true),
+		Entry("not equal to another value", "az!=z1", host, true),
+		Entry("not equal to its value", "az!=z2", host, false),
+		Entry("not equal without the label", "color!=blue", host, true),
+		Entry("an existing label", "stack", host, true),
+		Entry("a missing label", "color", host, false),
+		Entry("not a missing label", "!color", host, true),
+		Entry("not an existing label", "!stack", host, false),
+		Entry("every requirement", "az=z2,stack=cflinuxfs4,!color", host, true),
+		Entry("some requirements", "az=z2,stack=windows", host, false),
+		Entry("a field without a host", "az=z2", nil, false),
+		Entry("not equal without a host", "az!=z2", nil, true),
+	)
+
+	DescribeTable("invalid selectors",
+		func(selector string) {
+			_, err := controller.ParseLabelSelector(selector)
+			Expect(err).To(MatchError(ContainSubstring("invalid label selector")))
+		},
+		Entry("a missing key", "=z2"),
+		Entry("an empty requirement", "az=z2,"),
+		Entry("a doubled operator", "az==z2"),
+		Entry("a bare negation", "!"),
+	)
+})
@@ -0,0 +1,109 @@
+package leader
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+//go:generate counterfeiter -o fakes/leadership_store.go --fake-name LeadershipStore . leadershipStore
+type leadershipStore interface {
+	ClaimLeadership(string, int) (string, error)
+	ResignLeadership(string) error
+}
+
+// Elector elects one leader among the controllers sharing a database. Every
+// heartbeat it claims leadership for Identity, which succeeds while Identity
+// leads or once the leader has not claimed it for TTLSeconds, and learns who
+// leads either way. On shutdown the leader resigns so that another controller
+// can take over without waiting for the ttl.
+type Elector struct {
+	Logger            lager.Logger
+	Store             leadershipStore
+	Identity          string
+	HeartbeatInterval time.Duration
+	TTLSeconds        int
+
+	lock    sync.Mutex
+	leader  string
+	changed chan struct{}
+}
+
+func (e *Elector) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	e.Heartbeat()
+	close(ready)
+
+	for {
+		select {
+		case <-signals:
+			e.resign()
+			return nil
+		case <-time.After(e.HeartbeatInterval):
+			e.Heartbeat()
+		}
+	}
+}
+
+// Heartbeat claims leadership once. When the claim fails the leader is no
+// longer known: a leader that cannot renew its claim stops leading right
+// away, since another controller takes over once the ttl has passed.
+func (e *Elector) Heartbeat() {
+	leader, err := e.Store.ClaimLeadership(e.Identity, e.TTLSeconds)
+	if err != nil {
+		e.Logger.Error("claim-leadership", err)
+		leader = ""
+	}
+	e.setLeader(leader)
+}
+
+// Leader returns the identity of the leader, or the empty string if it is
+// not known.
+func (e *Elector) Leader() string {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.leader
+}
+
+func (e *Elector) IsLeader() bool {
+	return e.Leader() == e.Identity
+}
+
+func (e *Elector) resign() {
+	if !e.IsLeader() {
+		return
+	}
+	e.setLeader("")
+	err := e.Store.ResignLeadership(e.Identity)
+	if err != nil {
+		e.Logger.Error("resign-leadership", err)
+	}
+}
+
+func (e *Elector) setLeader(leader string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if leader == e.leader {
+		return
+	}
+	e.Logger.Info("leader-changed", lager.Data{"leader": leader, "previous_leader": e.leader, "identity": e.Identity})
+	e.leader = leader
+	if e.changed != nil {
+		close(e.changed)
+		e.changed = nil
+	}
+}
+
+// watch returns whether Identity leads and a channel that is closed once the
+// leader changes.
+func (e *Elector) watch() (bool, <-chan struct{}) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.changed == nil {
+		e.changed = make(chan struct{})
+	}
+	return e.leader == e.Identity, e.changed
+}
@@ -0,0 +1,112 @@
+package leader_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller/leader"
+	"code.cloudfoundry.org/silk/controller/leader/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("Elector", func() {
+	var (
+		logger  *lagertest.TestLogger
+		store   *fakes.LeadershipStore
+		elector *leader.Elector
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		store = &fakes.LeadershipStore{}
+		store.ClaimLeadershipReturns("controller-0", nil)
+		elector = &leader.Elector{
+			Logger:            logger,
+			Store:             store,
+			Identity:          "controller-0",
+			HeartbeatInterval: 10 * time.Millisecond,
+			TTLSeconds:        15,
+		}
+	})
+
+	Describe("Heartbeat", func() {
+		It("claims leadership for its identity with the ttl", func() {
+			elector.Heartbeat()
+
+			Expect(store.ClaimLeadershipCallCount()).To(Equal(1))
+			identity, ttl := store.ClaimLeadershipArgsForCall(0)
+			Expect(identity).To(Equal("controller-0"))
+			Expect(ttl).To(Equal(15))
+
+			Expect(elector.Leader()).To(Equal("controller-0"))
+			Expect(elector.IsLeader()).To(BeTrue())
+			Expect(logger).To(gbytes.Say(`leader-changed.*"leader":"controller-0"`))
+		})
+
+		It("follows another leader", func() {
+			store.ClaimLeadershipReturns("controller-1", nil)
+			elector.Heartbeat()
+
+			Expect(elector.Leader()).To(Equal("controller-1"))
+			Expect(elector.IsLeader()).To(BeFalse())
+		})
+
+		Context("when claiming leadership fails", func() {
+			It("stops leading and logs the error", func() {
+				elector.Heartbeat()
+				store.ClaimLeadershipReturns("", errors.New("pineapple"))
+				elector.Heartbeat()
+
+				Expect(elector.Leader()).To(BeEmpty())
+				Expect(elector.IsLeader()).To(BeFalse())
+				Expect(logger).To(gbytes.Say("claim-leadership.*pineapple"))
+			})
+		})
+	})
+
+	Describe("Run", func() {
+		It("claims leadership before it is ready and again every heartbeat", func() {
+			process := ifrit.Invoke(elector)
+			Expect(elector.IsLeader()).To(BeTrue())
+			Eventually(store.ClaimLeadershipCallCount).Should(BeNumerically(">=", 3))
+
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		})
+
+		It("resigns leadership when it is signaled", func() {
+			process := ifrit.Invoke(elector)
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+
+			Expect(store.ResignLeadershipCallCount()).To(Equal(1))
+			Expect(store.ResignLeadershipArgsForCall(0)).To(Equal("controller-0"))
+			Expect(elector.IsLeader()).To(BeFalse())
+		})
+
+		It("does not resign when it does not lead", func() {
+			store.ClaimLeadershipReturns("controller-1", nil)
+			process := ifrit.Invoke(elector)
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+
+			Expect(store.ResignLeadershipCallCount()).To(Equal(0))
+		})
+
+		Context("when resigning fails", func() {
+			It("logs the error", func() {
+				store.ResignLeadershipReturns(errors.New("pineapple"))
+				process := ifrit.Invoke(elector)
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive(BeNil()))
+
+				Expect(logger).To(gbytes.Say("resign-leadership.*pineapple"))
+			})
+		})
+	})
+})
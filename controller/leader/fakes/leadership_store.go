@@ -0,0 +1,188 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type LeadershipStore struct {
+	ClaimLeadershipStub        func(string, int) (string, error)
+	claimLeadershipMutex       sync.RWMutex
+	claimLeadershipArgsForCall []struct {
+		arg1 string
+		arg2 int
+	}
+	claimLeadershipReturns struct {
+		result1 string
+		result2 error
+	}
+	claimLeadershipReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	ResignLeadershipStub        func(string) error
+	resignLeadershipMutex       sync.RWMutex
+	resignLeadershipArgsForCall []struct {
+		arg1 string
+	}
+	resignLeadershipReturns struct {
+		result1 error
+	}
+	resignLeadershipReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LeadershipStore) ClaimLeadership(arg1 string, arg2 int) (string, error) {
+	fake.claimLeadershipMutex.Lock()
+	ret, specificReturn := fake.claimLeadershipReturnsOnCall[len(fake.claimLeadershipArgsForCall)]
+	fake.claimLeadershipArgsForCall = append(fake.claimLeadershipArgsForCall, struct {
+		arg1 string
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.ClaimLeadershipStub
+	fakeReturns := fake.claimLeadershipReturns
+	fake.recordInvocation("ClaimLeadership", []interface{}{arg1, arg2})
+	fake.claimLeadershipMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *LeadershipStore) ClaimLeadershipCallCount() int {
+	fake.claimLeadershipMutex.RLock()
+	defer fake.claimLeadershipMutex.RUnlock()
+	return len(fake.claimLeadershipArgsForCall)
+}
+
+func (fake *LeadershipStore) ClaimLeadershipCalls(stub func(string, int) (string, error)) {
+	fake.claimLeadershipMutex.Lock()
+	defer fake.claimLeadershipMutex.Unlock()
+	fake.ClaimLeadershipStub = stub
+}
+
+func (fake *LeadershipStore) ClaimLeadershipArgsForCall(i int) (string, int) {
+	fake.claimLeadershipMutex.RLock()
+	defer fake.claimLeadershipMutex.RUnlock()
+	argsForCall := fake.claimLeadershipArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *LeadershipStore) ClaimLeadershipReturns(result1 string, result2 error) {
+	fake.claimLeadershipMutex.Lock()
+	defer fake.claimLeadershipMutex.Unlock()
+	fake.ClaimLeadershipStub = nil
+	fake.claimLeadershipReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeadershipStore) ClaimLeadershipReturnsOnCall(i int, result1 string, result2 error) {
+	fake.claimLeadershipMutex.Lock()
+	defer fake.claimLeadershipMutex.Unlock()
+	fake.ClaimLeadershipStub = nil
+	if fake.claimLeadershipReturnsOnCall == nil {
+		fake.claimLeadershipReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.claimLeadershipReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeadershipStore) ResignLeadership(arg1 string) error {
+	fake.resignLeadershipMutex.Lock()
+	ret, specificReturn := fake.resignLeadershipReturnsOnCall[len(fake.resignLeadershipArgsForCall)]
+	fake.resignLeadershipArgsForCall = append(fake.resignLeadershipArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ResignLeadershipStub
+	fakeReturns := fake.resignLeadershipReturns
+	fake.recordInvocation("ResignLeadership", []interface{}{arg1})
+	fake.resignLeadershipMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LeadershipStore) ResignLeadershipCallCount() int {
+	fake.resignLeadershipMutex.RLock()
+	defer fake.resignLeadershipMutex.RUnlock()
+	return len(fake.resignLeadershipArgsForCall)
+}
+
+func (fake *LeadershipStore) ResignLeadershipCalls(stub func(string) error) {
+	fake.resignLeadershipMutex.Lock()
+	defer fake.resignLeadershipMutex.Unlock()
+	fake.ResignLeadershipStub = stub
+}
+
+func (fake *LeadershipStore) ResignLeadershipArgsForCall(i int) string {
+	fake.resignLeadershipMutex.RLock()
+	defer fake.resignLeadershipMutex.RUnlock()
+	argsForCall := fake.resignLeadershipArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *LeadershipStore) ResignLeadershipReturns(result1 error) {
+	fake.resignLeadershipMutex.Lock()
+	defer fake.resignLeadershipMutex.Unlock()
+	fake.ResignLeadershipStub = nil
+	fake.resignLeadershipReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LeadershipStore) ResignLeadershipReturnsOnCall(i int, result1 error) {
+	fake.resignLeadershipMutex.Lock()
+	defer fake.resignLeadershipMutex.Unlock()
+	fake.ResignLeadershipStub = nil
+	if fake.resignLeadershipReturnsOnCall == nil {
+		fake.resignLeadershipReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.resignLeadershipReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LeadershipStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.claimLeadershipMutex.RLock()
+	defer fake.claimLeadershipMutex.RUnlock()
+	fake.resignLeadershipMutex.RLock()
+	defer fake.resignLeadershipMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LeadershipStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,15 @@
+package leader_test
+
+import (
+	"code.cloudfoundry.org/silk/controller/leader"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sole", func() {
+	It("always leads", func() {
+		sole := leader.Sole{Identity: "controller-0"}
+		Expect(sole.Leader()).To(Equal("controller-0"))
+		Expect(sole.IsLeader()).To(BeTrue())
+	})
+})
@@ -0,0 +1,47 @@
+package leader
+
+import (
+	"os"
+
+	"github.com/tedsuo/ifrit"
+)
+
+// WhileLeading returns a runner that runs runner only while the elector
+// leads: it is started whenever leadership is gained and interrupted whenever
+// it is lost, so it must be restartable. The returned runner is ready at
+// once, whether it leads or not.
+func WhileLeading(elector *Elector, runner ifrit.Runner) ifrit.Runner {
+	return ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+		close(ready)
+
+		for {
+			leading, changed := elector.watch()
+			if !leading {
+				select {
+				case <-signals:
+					return nil
+				case <-changed:
+					continue
+				}
+			}
+
+			process := ifrit.Background(runner)
+			for leading {
+				select {
+				case signal := <-signals:
+					process.Signal(signal)
+					return <-process.Wait()
+				case err := <-process.Wait():
+					return err
+				case <-changed:
+					leading, changed = elector.watch()
+				}
+			}
+			process.Signal(os.Interrupt)
+			err := <-process.Wait()
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
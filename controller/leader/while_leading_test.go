@@ -0,0 +1,107 @@
+package leader_test
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller/leader"
+	"code.cloudfoundry.org/silk/controller/leader/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("WhileLeading", func() {
+	var (
+		store    *fakes.LeadershipStore
+		elector  *leader.Elector
+		running  int32
+		starts   int32
+		exitWith error
+		runner   ifrit.Runner
+		process  ifrit.Process
+	)
+
+	BeforeEach(func() {
+		store = &fakes.LeadershipStore{}
+		store.ClaimLeadershipReturns("controller-1", nil)
+		elector = &leader.Elector{
+			Logger:            lagertest.NewTestLogger("test"),
+			Store:             store,
+			Identity:          "controller-0",
+			HeartbeatInterval: time.Hour,
+			TTLSeconds:        15,
+		}
+		elector.Heartbeat()
+
+		atomic.StoreInt32(&running, 0)
+		atomic.StoreInt32(&starts, 0)
+		exitWith = nil
+		runner = ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+			atomic.AddInt32(&starts, 1)
+			atomic.StoreInt32(&running, 1)
+			defer atomic.StoreInt32(&running, 0)
+			close(ready)
+			if exitWith != nil {
+				return exitWith
+			}
+			<-signals
+			return nil
+		})
+	})
+
+	JustBeforeEach(func() {
+		process = ifrit.Invoke(leader.WhileLeading(elector, runner))
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	isRunning := func() bool { return atomic.LoadInt32(&running) == 1 }
+
+	It("is ready without running the runner while following", func() {
+		Consistently(isRunning, "50ms").Should(BeFalse())
+	})
+
+	It("runs the runner while leading and stops it when leadership is lost", func() {
+		store.ClaimLeadershipReturns("controller-0", nil)
+		elector.Heartbeat()
+		Eventually(isRunning).Should(BeTrue())
+
+		store.ClaimLeadershipReturns("controller-1", nil)
+		elector.Heartbeat()
+		Eventually(isRunning).Should(BeFalse())
+
+		store.ClaimLeadershipReturns("controller-0", nil)
+		elector.Heartbeat()
+		Eventually(isRunning).Should(BeTrue())
+		Expect(atomic.LoadInt32(&starts)).To(Equal(int32(2)))
+	})
+
+	It("stops the runner when it is signaled", func() {
+		store.ClaimLeadershipReturns("controller-0", nil)
+		elector.Heartbeat()
+		Eventually(isRunning).Should(BeTrue())
+
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+		Expect(isRunning()).To(BeFalse())
+	})
+
+	Context("when the runner fails", func() {
+		BeforeEach(func() {
+			exitWith = errors.New("pineapple")
+			store.ClaimLeadershipReturns("controller-0", nil)
+			elector.Heartbeat()
+		})
+
+		It("returns its error", func() {
+			Eventually(process.Wait()).Should(Receive(MatchError("pineapple")))
+		})
+	})
+})
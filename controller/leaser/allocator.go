@@ -0,0 +1,134 @@
+package leaser
+
+import (
+	"fmt"
+	mathRand "math/rand"
+	"sync"
+)
+
+const (
+	AllocationRandom           = "random"
+	AllocationLowestFreeFirst  = "lowest-free-first"
+	AllocationMaximallySpread  = "maximally-spread"
+	AllocationPackNearPrevious = "pack-near-previous"
+)
+
+// allocator picks one of the subnets of a pool, numbered 0 to size-1 in
+// address order, that is not taken. Allocate returns -1 when every subnet is
+// taken.
+//
+//go:generate counterfeiter -o fakes/allocator.go --fake-name Allocator . allocator
+type allocator interface {
+	Allocate(taken *Taken) int
+}
+
+// NewAllocator returns an allocator for the named strategy. An empty name
+// selects the random strategy. Allocators may keep state, so every pool needs
+// its own.
+func NewAllocator(strategy string) (allocator, error) {
+	switch strategy {
+	case "", AllocationRandom:
+		return NewRandomAllocator(getRandomSeed()), nil
+	case AllocationLowestFreeFirst:
+		return &LowestFreeFirstAllocator{}, nil
+	case AllocationMaximallySpread:
+		return &MaximallySpreadAllocator{}, nil
+	case AllocationPackNearPrevious:
+		return &PackNearPreviousAllocator{}, nil
+	}
+	return nil, fmt.Errorf("unknown allocation strategy: %s", strategy)
+}
+
+// RandomAllocator picks any free subnet with equal probability.
+type RandomAllocator struct {
+	lock sync.Mutex
+	rand *mathRand.Rand
+}
+
+func NewRandomAllocator(seed int64) *RandomAllocator {
+	return &RandomAllocator{rand: mathRand.New(mathRand.NewSource(seed))}
+}
+
+func (a *RandomAllocator) Allocate(taken *Taken) int {
+	free := taken.Free()
+	if free <= 0 {
+		return -1
+	}
+
+	a.lock.Lock()
+	n := a.rand.Intn(free)
+	a.lock.Unlock()
+
+	return taken.nthFree(n)
+}
+
+// LowestFreeFirstAllocator picks the free subnet with the lowest address.
+type LowestFreeFirstAllocator struct{}
+
+func (a *LowestFreeFirstAllocator) Allocate(taken *Taken) int {
+	if taken.Free() <= 0 {
+		return -1
+	}
+	return taken.nthFree(0)
+}
+
+// MaximallySpreadAllocator picks the free subnet furthest from any taken one,
+// preferring the lowest address on a tie. The taken subnets keep track of
+// their widest gap, so it costs no more than the other allocators.
+type MaximallySpreadAllocator struct{}
+
+func (a *MaximallySpreadAllocator) Allocate(taken *Taken) int {
+	if taken.Free() <= 0 {
+		return -1
+	}
+	first := taken.first()
+	if first < 0 {
+		return 0
+	}
+
+	best, bestDistance := -1, 0
+	consider := func(candidate, distance int) {
+		if distance > bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	// the free subnets below the lowest taken one, between two taken ones
+	// and above the highest one, in address order
+	consider(0, first)
+	consider(taken.widestGap())
+	last := taken.Size() - 1
+	consider(last, last-taken.last())
+	return best
+}
+
+// PackNearPreviousAllocator picks the free subnet closest to the one it handed
+// out last, preferring the higher address on a tie. Until it has handed one
+// out it packs next to the highest taken subnet.
+type PackNearPreviousAllocator struct {
+	lock     sync.Mutex
+	previous int
+	started  bool
+}
+
+func (a *PackNearPreviousAllocator) Allocate(taken *Taken) int {
+	if taken.Free() <= 0 {
+		return -1
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	anchor := 0
+	if a.started {
+		anchor = a.previous
+	} else if last := taken.last(); last >= 0 {
+		anchor = last
+	}
+
+	i := taken.nearestFree(anchor)
+	if i >= 0 {
+		a.previous, a.started = i, true
+	}
+	return i
+}
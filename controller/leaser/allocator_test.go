@@ -0,0 +1,170 @@
+package leaser_test
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Allocators", func() {
+	allocateAll := func(allocator interface {
+		Allocate(*leaser.Taken) int
+	}, size int, taken []int) []int {
+		var order []int
+		for {
+			i := allocator.Allocate(leaser.NewTaken(size, taken))
+			if i == -1 {
+				return order
+			}
+			order = append(order, i)
+			taken = insertSorted(taken, i)
+		}
+	}
+
+	Describe("NewAllocator", func() {
+		DescribeTable("returns the allocator for the strategy",
+			func(strategy string, expected interface{}) {
+				allocator, err := leaser.NewAllocator(strategy)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(allocator).To(BeAssignableToTypeOf(expected))
+			},
+			Entry("default", "", &leaser.RandomAllocator{}),
+			Entry("random", "random", &leaser.RandomAllocator{}),
+			Entry("lowest free first", "lowest-free-first", &leaser.LowestFreeFirstAllocator{}),
+			Entry("maximally spread", "maximally-spread", &leaser.MaximallySpreadAllocator{}),
+			Entry("pack near previous", "pack-near-previous", &leaser.PackNearPreviousAllocator{}),
+		)
+
+		It("rejects an unknown strategy", func() {
+			_, err := leaser.NewAllocator("banana")
+			Expect(err).To(MatchError("unknown allocation strategy: banana"))
+		})
+	})
+
+	Describe("RandomAllocator", func() {
+		It("hands out every free subnet exactly once", func() {
+			order := allocateAll(leaser.NewRandomAllocator(42), 10, []int{2, 7})
+			Expect(order).To(ConsistOf(0, 1, 3, 4, 5, 6, 8, 9))
+		})
+
+		It("is repeatable for a given seed", func() {
+			first := allocateAll(leaser.NewRandomAllocator(42), 50, nil)
+			second := allocateAll(leaser.NewRandomAllocator(42), 50, nil)
+			Expect(first).To(Equal(second))
+			Expect(first).NotTo(Equal(allocateAll(&leaser.LowestFreeFirstAllocator{}, 50, nil)))
+		})
+
+		It("can be shared between goroutines", func() {
+			allocator := leaser.NewRandomAllocator(42)
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					for j := 0; j < 100; j++ {
+						Expect(allocator.Allocate(leaser.NewTaken(10, []int{1, 2}))).NotTo(BeElementOf(-1, 1, 2))
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	})
+
+	DescribeTable("allocation order",
+		func(newAllocator func() interface{ Allocate(*leaser.Taken) int }, taken []int, expected []int) {
+			Expect(allocateAll(newAllocator(), 8, taken)).To(Equal(expected))
+		},
+		Entry("lowest free first",
+			func() interface{ Allocate(*leaser.Taken) int } { return &leaser.LowestFreeFirstAllocator{} },
+			[]int{0, 3}, []int{1, 2, 4, 5, 6, 7}),
+		Entry("maximally spread from empty",
+			func() interface{ Allocate(*leaser.Taken) int } { return &leaser.MaximallySpreadAllocator{} },
+			nil, []int{0, 7, 3, 5, 1, 2, 4, 6}),
+		Entry("maximally spread around taken subnets",
+			func() interface{ Allocate(*leaser.Taken) int } { return &leaser.MaximallySpreadAllocator{} },
+			[]int{2}, []int{7, 0, 4, 1, 3, 5, 6}),
+		Entry("pack near previous from empty",
+			func() interface{ Allocate(*leaser.Taken) int } { return &leaser.PackNearPreviousAllocator{} },
+			nil, []int{0, 1, 2, 3, 4, 5, 6, 7}),
+		Entry("pack near previous next to the highest taken subnet",
+			func() interface{ Allocate(*leaser.Taken) int } { return &leaser.PackNearPreviousAllocator{} },
+			[]int{1, 4}, []int{5, 6, 7, 3, 2, 0}),
+	)
+
+	Describe("PackNearPreviousAllocator", func() {
+		It("packs next to the subnet it handed out last", func() {
+			allocator := &leaser.PackNearPreviousAllocator{}
+			Expect(allocator.Allocate(leaser.NewTaken(8, []int{6}))).To(Equal(7))
+			Expect(allocator.Allocate(leaser.NewTaken(8, []int{0, 6, 7}))).To(Equal(5))
+			Expect(allocator.Allocate(leaser.NewTaken(8, []int{0, 5, 6, 7}))).To(Equal(4))
+		})
+	})
+
+	DescribeTable("returns -1 when every subnet is taken",
+		func(allocator interface{ Allocate(*leaser.Taken) int }) {
+			Expect(allocator.Allocate(leaser.NewTaken(3, []int{0, 1, 2}))).To(Equal(-1))
+			Expect(allocator.Allocate(leaser.NewTaken(0, nil))).To(Equal(-1))
+		},
+		Entry("random", leaser.NewRandomAllocator(42)),
+		Entry("lowest free first", &leaser.LowestFreeFirstAllocator{}),
+		Entry("maximally spread", &leaser.MaximallySpreadAllocator{}),
+		Entry("pack near previous", &leaser.PackNearPreviousAllocator{}),
+	)
+
+	Describe("CIDRPool with an allocator", func() {
+		var allocator *fakes.Allocator
+
+		BeforeEach(func() {
+			allocator = &fakes.Allocator{}
+		})
+
+		It("passes the taken subnets as sorted, unique positions in the pool", func() {
+			allocator.AllocateStub = func(taken *leaser.Taken) int {
+				Expect(taken.Size()).To(Equal(255))
+				Expect(taken.Count()).To(Equal(2))
+				Expect(taken.Contains(1)).To(BeTrue())
+				Expect(taken.Contains(4)).To(BeTrue())
+				return 2
+			}
+			cidrPool := leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, allocator)
+
+			subnet := cidrPool.GetAvailableBlock([]string{"10.255.5.0/24", "10.255.2.0/24", "10.254.0.0/24", "10.255.5.0/24"})
+			Expect(subnet).To(Equal("10.255.3.0/24"))
+			Expect(allocator.AllocateCallCount()).To(Equal(1))
+		})
+
+		It("returns an empty string when the allocator finds nothing", func() {
+			allocator.AllocateReturns(-1)
+			cidrPool := leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, allocator)
+			Expect(cidrPool.GetAvailableSingleIP(nil)).To(Equal(""))
+		})
+
+		It("hands out blocks in address order with the lowest free first strategy", func() {
+			cidrPool := leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, &leaser.LowestFreeFirstAllocator{})
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.1.0/24"))
+			Expect(cidrPool.GetAvailableBlock([]string{"10.255.1.0/24", "10.255.3.0/24"})).To(Equal("10.255.2.0/24"))
+			Expect(cidrPool.GetAvailableSingleIP([]string{"10.255.0.1/32"})).To(Equal("10.255.0.2/32"))
+		})
+	})
+})
+
+func insertSorted(s []int, v int) []int {
+	out := make([]int, 0, len(s)+1)
+	inserted := false
+	for _, x := range s {
+		if !inserted && v < x {
+			out = append(out, v)
+			inserted = true
+		}
+		out = append(out, x)
+	}
+	if !inserted {
+		out = append(out, v)
+	}
+	return out
+}
@@ -2,105 +2,465 @@ package leaser
 
 import (
 	cryptoRand "crypto/rand"
+	"encoding/binary"
 	"fmt"
-	"github.com/ziutek/utils/netaddr"
 	"math"
 	"math/big"
-	mathRand "math/rand"
 	"net"
+	"net/netip"
+	"sync"
 )
 
+// CIDRPool describes the subnets of an overlay network without storing them:
+// the first block is split into single overlay ips, the rest are handed out as
+// blocks, and both are numbered in address order so that a subnet and its
+// position can be converted with arithmetic alone. It keeps the positions of
+// the leased subnets, which Take, Free and Reset update, so that handing one
+// out does not need every lease.
 type CIDRPool struct {
-	blockPool  map[string]struct{}
-	singlePool map[string]struct{}
+	network       netip.Prefix
+	networkIP     net.IP
+	subnetMask    int
+	firstBlock    int
+	blockSize     *big.Int
+	blockCount    int
+	singleIPCount int
+	allocator     allocator
+
+	// positions of the blocks and single ips that overlap an excluded range
+	excludedRanges    []netip.Prefix
+	excludedBlocks    []Interval
+	excludedSingleIPs []Interval
+
+	// positions of the blocks that stay members but are handed out by a
+	// partition instead of the pool
+	withheldBlocks []Interval
+
+	// positions of the blocks the pool never hands out, excluded or withheld
+	unavailableBlocks []Interval
+
+	// the leased blocks and single ips together with the unavailable ones,
+	// built when first needed
+	lock      sync.Mutex
+	blocks    *Taken
+	singleIPs *Taken
 }
 
 func NewCIDRPool(subnetRange string, subnetMask int) *CIDRPool {
+	return NewCIDRPoolWithAllocator(subnetRange, subnetMask, NewRandomAllocator(getRandomSeed()))
+}
+
+func NewCIDRPoolWithAllocator(subnetRange string, subnetMask int, allocator allocator) *CIDRPool {
 	_, ipCIDR, err := net.ParseCIDR(subnetRange)
 	if err != nil {
 		panic(err)
 	}
-	cidrMask, _ := ipCIDR.Mask.Size()
+	cidrMask, addressBits := ipCIDR.Mask.Size()
 
-	mathRand.Seed(getRandomSeed())
+	pool := &CIDRPool{
+		network:    netip.MustParsePrefix(ipCIDR.String()),
+		networkIP:  ipCIDR.IP,
+		subnetMask: subnetMask,
+		firstBlock: 1,
+		blockSize:  new(big.Int).Lsh(big.NewInt(1), uint(addressBits-subnetMask)),
+		allocator:  allocator,
+	}
+	if subnetMask >= cidrMask {
+		pool.blockCount = countOf(subnetMask-cidrMask) - 1
+	}
+	// single overlay ip leases are only handed out from IPv4 networks
+	if addressBits == 8*net.IPv4len {
+		pool.singleIPCount = countOf(addressBits-subnetMask) - 1
+	}
+	return pool
+}
 
-	return &CIDRPool{
-		blockPool:  generateBlockPool(ipCIDR.IP, uint(cidrMask), uint(subnetMask)),
-		singlePool: generateSingleIPPool(ipCIDR.IP, uint(subnetMask)),
+// Exclude takes every subnet that overlaps the range out of the pool. Ranges
+// of the other address family are ignored. Like Withhold, it forgets the
+// leased subnets, so both belong to setting up the pool.
+func (c *CIDRPool) Exclude(excludedRange string) error {
+	prefix, err := netip.ParsePrefix(excludedRange)
+	if err != nil {
+		return fmt.Errorf("parse excluded range: %s", err)
 	}
+	c.exclude(prefix.Masked())
+	return nil
 }
 
-func (c *CIDRPool) GetBlockPool() map[string] struct{} {
-	return c.blockPool
+func (c *CIDRPool) exclude(prefix netip.Prefix) {
+	if prefix.Addr().Is4() != (c.networkIP.To4() != nil) {
+		return
+	}
+	c.excludedRanges = append(c.excludedRanges, prefix)
+	c.blocks, c.singleIPs = nil, nil
+
+	first, last := c.offsets(prefix)
+	if blocks, ok := c.blocksBetween(first, last); ok {
+		c.excludedBlocks = addInterval(c.excludedBlocks, blocks)
+		c.unavailableBlocks = addInterval(c.unavailableBlocks, blocks)
+	}
+	// single ip i is at offset i+1
+	if singleIPs, ok := positionsBetween(
+		new(big.Int).Sub(first, big.NewInt(1)),
+		new(big.Int).Sub(last, big.NewInt(1)),
+		c.singleIPCount,
+	); ok {
+		c.excludedSingleIPs = addInterval(c.excludedSingleIPs, singleIPs)
+	}
 }
 
-func (c *CIDRPool) GetSinglePool() map[string] struct{} {
-	return c.singlePool
+// Withhold keeps the pool from handing out the blocks that overlap the range,
+// which stay members of the pool. A partition hands them out instead.
+func (c *CIDRPool) Withhold(withheldRange string) error {
+	prefix, err := netip.ParsePrefix(withheldRange)
+	if err != nil {
+		return fmt.Errorf("parse withheld range: %s", err)
+	}
+	prefix = prefix.Masked()
+	if prefix.Addr().Is4() != (c.networkIP.To4() != nil) {
+		return nil
+	}
+	if blocks, ok := c.blocksBetween(c.offsets(prefix)); ok {
+		c.withheldBlocks = addInterval(c.withheldBlocks, blocks)
+		c.unavailableBlocks = addInterval(c.unavailableBlocks, blocks)
+		c.blocks = nil
+	}
+	return nil
 }
 
+// Partition returns the blocks of the pool inside the range as a pool of
+// their own, which leaves out the same ranges and hands out no single ips. The
+// range must lie within the network and hold whole blocks.
+func (c *CIDRPool) Partition(partitionRange string, allocator allocator) (*CIDRPool, error) {
+	prefix, err := netip.ParsePrefix(partitionRange)
+	if err != nil {
+		return nil, fmt.Errorf("parse partition range: %s", err)
+	}
+	prefix = prefix.Masked()
+	if !c.holds(prefix) {
+		return nil, fmt.Errorf("partition range %s does not hold whole blocks of %s", prefix, c.network)
+	}
+
+	partition := &CIDRPool{
+		network:    prefix,
+		networkIP:  net.IP(prefix.Addr().AsSlice()),
+		subnetMask: c.subnetMask,
+		blockSize:  c.blockSize,
+		blockCount: countOf(c.subnetMask - prefix.Bits()),
+		allocator:  allocator,
+	}
+	// the first block of the network holds the single ips
+	if prefix.Addr() == c.network.Addr() {
+		partition.networkIP = c.networkIP
+		partition.firstBlock = 1
+		partition.blockCount--
+	}
+	for _, excludedRange := range c.excludedRanges {
+		partition.exclude(excludedRange)
+	}
+	return partition, nil
+}
+
+// holds reports whether the range lies within the network and is no smaller
+// than a block.
+func (c *CIDRPool) holds(prefix netip.Prefix) bool {
+	return prefix.Addr().Is4() == c.network.Addr().Is4() &&
+		prefix.Bits() >= c.network.Bits() && prefix.Bits() <= c.subnetMask &&
+		c.network.Contains(prefix.Addr())
+}
+
+// offsets returns the offsets from the start of the network of the first and
+// the last address of the range.
+func (c *CIDRPool) offsets(prefix netip.Prefix) (*big.Int, *big.Int) {
+	first := new(big.Int).Sub(new(big.Int).SetBytes(prefix.Addr().AsSlice()), ipToInt(c.networkIP))
+	size := new(big.Int).Lsh(big.NewInt(1), uint(prefix.Addr().BitLen()-prefix.Bits()))
+	last := new(big.Int).Sub(new(big.Int).Add(first, size), big.NewInt(1))
+	return first, last
+}
+
+// blocksBetween returns the positions of the blocks that overlap the offsets
+// from first to last, if any. Block i starts at offset (i+firstBlock)*blockSize.
+func (c *CIDRPool) blocksBetween(first, last *big.Int) (Interval, bool) {
+	firstBlock := big.NewInt(int64(c.firstBlock))
+	return positionsBetween(
+		new(big.Int).Sub(new(big.Int).Div(first, c.blockSize), firstBlock),
+		new(big.Int).Sub(new(big.Int).Div(last, c.blockSize), firstBlock),
+		c.blockCount,
+	)
+}
+
+// BlockPoolSize returns the number of blocks that can be leased, leaving out
+// the excluded ones.
 func (c *CIDRPool) BlockPoolSize() int {
-	return len(c.blockPool)
+	return c.blockCount - intervalsLen(c.excludedBlocks)
 }
 
+// SingleIPPoolSize returns the number of single ips that can be leased,
+// leaving out the excluded ones.
 func (c *CIDRPool) SingleIPPoolSize() int {
-	return len(c.singlePool)
+	return c.singleIPCount - intervalsLen(c.excludedSingleIPs)
 }
 
+func (c *CIDRPool) ExcludedBlockCount() int {
+	return intervalsLen(c.excludedBlocks)
+}
+
+func (c *CIDRPool) ExcludedSingleIPCount() int {
+	return intervalsLen(c.excludedSingleIPs)
+}
+
+// IsExcluded reports whether the subnet overlaps an excluded range.
+func (c *CIDRPool) IsExcluded(subnet string) bool {
+	prefix, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		return false
+	}
+	for _, excludedRange := range c.excludedRanges {
+		if excludedRange.Overlaps(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAvailableBlock hands out a block that is neither leased nor among the
+// taken subnets, which the caller holds back for a single call, such as
+// reserved or quarantined ones.
 func (c *CIDRPool) GetAvailableBlock(taken []string) string {
-	return getAvailable(taken, c.blockPool)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	i := c.allocate(c.takenBlocks(), taken, c.blockIndex)
+	if i < 0 {
+		return ""
+	}
+	return c.block(i)
 }
 
 func (c *CIDRPool) GetAvailableSingleIP(taken []string) string {
-	return getAvailable(taken, c.singlePool)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	i := c.allocate(c.takenSingleIPs(), taken, c.singleIPIndex)
+	if i < 0 {
+		return ""
+	}
+	return c.singleIP(i)
+}
+
+// Take marks the subnet as leased, and reports whether it is a member of the
+// pool that was free.
+func (c *CIDRPool) Take(subnet string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if i, ok := c.blockIndex(subnet); ok {
+		return c.takenBlocks().Add(i)
+	}
+	if i, ok := c.singleIPIndex(subnet); ok {
+		return c.takenSingleIPs().Add(i)
+	}
+	return false
+}
+
+// Free marks the subnet as no longer leased, and reports whether it was.
+func (c *CIDRPool) Free(subnet string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if i, ok := c.blockIndex(subnet); ok {
+		return c.takenBlocks().Remove(i)
+	}
+	if i, ok := c.singleIPIndex(subnet); ok {
+		return c.takenSingleIPs().Remove(i)
+	}
+	return false
+}
+
+// Reset replaces the leased subnets with the given ones. Subnets that are not
+// members of the pool are ignored.
+func (c *CIDRPool) Reset(leased []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var blocks, singleIPs []int
+	for _, subnet := range leased {
+		if i, ok := c.blockIndex(subnet); ok {
+			blocks = append(blocks, i)
+		} else if i, ok := c.singleIPIndex(subnet); ok {
+			singleIPs = append(singleIPs, i)
+		}
+	}
+	c.blocks = newTaken(c.blockCount, c.unavailableBlocks, blocks)
+	c.singleIPs = newTaken(c.singleIPCount, c.excludedSingleIPs, singleIPs)
+}
+
+func (c *CIDRPool) takenBlocks() *Taken {
+	if c.blocks == nil {
+		c.blocks = newTaken(c.blockCount, c.unavailableBlocks, nil)
+	}
+	return c.blocks
+}
+
+func (c *CIDRPool) takenSingleIPs() *Taken {
+	if c.singleIPs == nil {
+		c.singleIPs = newTaken(c.singleIPCount, c.excludedSingleIPs, nil)
+	}
+	return c.singleIPs
 }
 
 func (c *CIDRPool) IsMember(subnet string) bool {
-	_, blockOk := c.blockPool[subnet]
-	_, singleOk := c.singlePool[subnet]
+	_, blockOk := c.blockIndex(subnet)
+	_, singleOk := c.singleIPIndex(subnet)
 	return blockOk || singleOk
 }
 
-func getAvailable(taken []string, pool map[string]struct{}) string {
-	available := make(map[string]struct{})
-	for k, v := range pool {
-		available[k] = v
+func (c *CIDRPool) IsBlockMember(subnet string) bool {
+	_, ok := c.blockIndex(subnet)
+	return ok
+}
+
+// IsActive reports whether the subnet may be handed to a new lease. Every
+// subnet of a single network may.
+func (c *CIDRPool) IsActive(subnet string) bool {
+	return c.IsMember(subnet)
+}
+
+// allocate hands the leased positions, together with the ones the pool never
+// hands out and those of the extra subnets, to the allocator. Only the extra
+// subnets are parsed, and taken only for the call, so the cost depends on
+// their number and not on how full the pool is.
+func (c *CIDRPool) allocate(taken *Taken, extra []string, index func(string) (int, bool)) int {
+	var added []int
+	for _, subnet := range extra {
+		if i, ok := index(subnet); ok && taken.Add(i) {
+			added = append(added, i)
+		}
 	}
-	for _, subnet := range taken {
-		delete(available, subnet)
+
+	i := c.allocator.Allocate(taken)
+	for _, a := range added {
+		taken.Remove(a)
 	}
-	if len(available) == 0 {
-		return ""
+	if i < 0 || i >= taken.Size() {
+		return -1
 	}
-	i := mathRand.Intn(len(available))
-	n := 0
-	for subnet := range available {
-		if i == n {
-			return subnet
+	return i
+}
+
+// the first block of a network is never handed out, it holds the single
+// overlay ips
+func (c *CIDRPool) block(i int) string {
+	offset := new(big.Int).Mul(big.NewInt(int64(i+c.firstBlock)), c.blockSize)
+	return fmt.Sprintf("%s/%d", ipAdd(c.networkIP, offset), c.subnetMask)
+}
+
+func (c *CIDRPool) singleIP(i int) string {
+	return fmt.Sprintf("%s/32", ipAdd(c.networkIP, big.NewInt(int64(i)+1)))
+}
+
+func (c *CIDRPool) blockIndex(subnet string) (int, bool) {
+	n, ok := c.position(subnet, c.subnetMask)
+	if !ok {
+		return 0, false
+	}
+	return inRange(n-int64(c.firstBlock), c.blockCount, c.excludedBlocks)
+}
+
+func (c *CIDRPool) singleIPIndex(subnet string) (int, bool) {
+	n, ok := c.position(subnet, 32)
+	if !ok {
+		return 0, false
+	}
+	return inRange(n-1, c.singleIPCount, c.excludedSingleIPs)
+}
+
+// position returns how many subnets of the given mask fit between the start of
+// the network and the subnet, if the subnet is written the way the pool writes
+// its own subnets.
+func (c *CIDRPool) position(subnet string, mask int) (int64, bool) {
+	prefix, err := netip.ParsePrefix(subnet)
+	if err != nil || prefix.Bits() != mask || prefix.Masked() != prefix {
+		return 0, false
+	}
+	addr := prefix.Addr()
+	shift := uint(addr.BitLen() - mask)
+
+	network4 := c.networkIP.To4()
+	if addr.Is4() != (network4 != nil) {
+		return 0, false
+	}
+	// ipv4 offsets fit in a machine word, which keeps loading the leases of a
+	// pool cheap
+	if addr.Is4() {
+		ip4 := addr.As4()
+		start, end := binary.BigEndian.Uint32(network4), binary.BigEndian.Uint32(ip4[:])
+		if end < start {
+			return 0, false
 		}
-		n++
+		return int64(uint64(end-start) >> shift), true
+	}
+
+	offset := new(big.Int).Sub(new(big.Int).SetBytes(addr.AsSlice()), ipToInt(c.networkIP))
+	if offset.Sign() < 0 {
+		return 0, false
+	}
+	offset.Rsh(offset, shift)
+	if !offset.IsInt64() {
+		return 0, false
 	}
-	return ""
+	return offset.Int64(), true
 }
 
-func generateBlockPool(ipStart net.IP, cidrMask, cidrMaskBlock uint) map[string]struct{} {
-	pool := make(map[string]struct{})
-	fullRange := 1 << (32 - cidrMask)
-	blockSize := 1 << (32 - cidrMaskBlock)
-	for i := blockSize; i < fullRange; i += blockSize {
-		subnet := fmt.Sprintf("%s/%d", netaddr.IPAdd(ipStart, i), cidrMaskBlock)
-		pool[subnet] = struct{}{}
+func inRange(i int64, count int, excluded []Interval) (int, bool) {
+	if i < 0 || i >= int64(count) || containsPosition(excluded, int(i)) {
+		return 0, false
 	}
-	return pool
+	return int(i), true
 }
 
-func generateSingleIPPool(ipStart net.IP, cidrMaskBlock uint) map[string]struct{} {
-	pool := make(map[string]struct{})
-	blockSize := 1 << (32 - cidrMaskBlock)
-	for i := 1; i < blockSize; i++ {
-		singleCIDR := fmt.Sprintf("%s/32", netaddr.IPAdd(ipStart, i))
-		pool[singleCIDR] = struct{}{}
+// positionsBetween returns the positions from first to last, both included,
+// that lie within a pool of count subnets, if any.
+func positionsBetween(first, last *big.Int, count int) (Interval, bool) {
+	if first.Sign() < 0 {
+		first = big.NewInt(0)
 	}
-	return pool
+	if max := big.NewInt(int64(count) - 1); last.Cmp(max) > 0 {
+		last = max
+	}
+	if first.Cmp(last) > 0 {
+		return Interval{}, false
+	}
+	return Interval{int(first.Int64()), int(last.Int64())}, true
+}
+
+// countOf returns 2^bits, capped so that positions always fit in an int.
+func countOf(bits int) int {
+	if bits > 62 {
+		bits = 62
+	}
+	return 1 << uint(bits)
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return new(big.Int).SetBytes(ip)
+}
+
+// ipAdd works on the full width of the address, so that it is correct for
+// IPv6 networks whose offsets do not fit in a machine word.
+func ipAdd(ip net.IP, offset *big.Int) net.IP {
+	ipLen := net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		ipLen = net.IPv4len
+	}
+	sum := new(big.Int).Add(new(big.Int).SetBytes(ip), offset)
+	result := make(net.IP, ipLen)
+	sum.FillBytes(result)
+	return result
 }
 
 func getRandomSeed() int64 {
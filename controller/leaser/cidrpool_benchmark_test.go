@@ -0,0 +1,219 @@
+package leaser_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
+)
+
+// benchmarkTakenPercents are the shares of each pool taken before allocating.
+// The pools keep track of their taken subnets, so the time to hand one out
+// should not grow with them.
+var benchmarkTakenPercents = []int{10, 50, 90}
+
+var benchmarkStrategies = []string{
+	leaser.AllocationRandom,
+	leaser.AllocationLowestFreeFirst,
+	leaser.AllocationMaximallySpread,
+	leaser.AllocationPackNearPrevious,
+}
+
+// takenPositions returns percent of the positions of a pool of size subnets,
+// spread evenly over it.
+func takenPositions(size, percent int) []int {
+	n := size * percent / 100
+	positions := make([]int, n)
+	for i := range positions {
+		positions[i] = int(int64(i) * int64(size) / int64(n))
+	}
+	return positions
+}
+
+// takenSubnets returns percent of the subnets of the pool, spread evenly over
+// it.
+func takenSubnets(subnetRange string, subnetMask, percent int, single bool) []string {
+	allocator := &fakes.Allocator{}
+	cidrPool := leaser.NewCIDRPoolWithAllocator(subnetRange, subnetMask, allocator)
+	size := cidrPool.BlockPoolSize()
+	if single {
+		size = cidrPool.SingleIPPoolSize()
+	}
+
+	var taken []string
+	for _, position := range takenPositions(size, percent) {
+		allocator.AllocateReturns(position)
+		if single {
+			taken = append(taken, cidrPool.GetAvailableSingleIP(nil))
+		} else {
+			taken = append(taken, cidrPool.GetAvailableBlock(nil))
+		}
+	}
+	return taken
+}
+
+func BenchmarkNewCIDRPool(b *testing.B) {
+	for _, network := range []string{"10.255.0.0/16", "10.240.0.0/12", "10.0.0.0/8"} {
+		b.Run(network, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				leaser.NewCIDRPool(network, 32)
+			}
+		})
+	}
+}
+
+func BenchmarkAllocate(b *testing.B) {
+	const size = 1 << 20
+	for _, percent := range benchmarkTakenPercents {
+		taken := leaser.NewTaken(size, takenPositions(size, percent))
+		for _, strategy := range benchmarkStrategies {
+			b.Run(fmt.Sprintf("%d%% taken/%s", percent, strategy), func(b *testing.B) {
+				allocator, err := leaser.NewAllocator(strategy)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					position := allocator.Allocate(taken)
+					if position < 0 {
+						b.Fatal("no position available")
+					}
+					taken.Add(position)
+					taken.Remove(position)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkGetAvailableBlock(b *testing.B) {
+	for _, network := range []string{"10.255.0.0/16", "10.240.0.0/12", "10.0.0.0/8"} {
+		for _, percent := range benchmarkTakenPercents {
+			taken := takenSubnets(network, 28, percent, false)
+			for _, strategy := range benchmarkStrategies {
+				b.Run(fmt.Sprintf("%s/%d%% taken/%s", network, percent, strategy), func(b *testing.B) {
+					allocator, err := leaser.NewAllocator(strategy)
+					if err != nil {
+						b.Fatal(err)
+					}
+					cidrPool := leaser.NewCIDRPoolWithAllocator(network, 28, allocator)
+					cidrPool.Reset(taken)
+
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						block := cidrPool.GetAvailableBlock(nil)
+						if block == "" {
+							b.Fatal("no block available")
+						}
+						cidrPool.Take(block)
+						cidrPool.Free(block)
+					}
+				})
+			}
+		}
+	}
+}
+
+func BenchmarkGetAvailableSingleIP(b *testing.B) {
+	for _, subnetMask := range []int{20, 16, 12} {
+		for _, percent := range benchmarkTakenPercents {
+			b.Run(fmt.Sprintf("10.0.0.0/8 with /%d blocks/%d%% taken", subnetMask, percent), func(b *testing.B) {
+				cidrPool := leaser.NewCIDRPool("10.0.0.0/8", subnetMask)
+				cidrPool.Reset(takenSubnets("10.0.0.0/8", subnetMask, percent, true))
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					singleIP := cidrPool.GetAvailableSingleIP(nil)
+					if singleIP == "" {
+						b.Fatal("no single ip available")
+					}
+					cidrPool.Take(singleIP)
+					cidrPool.Free(singleIP)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkAcquireSubnetLease acquires and releases a lease through a
+// LeaseController whose store already holds the leases of the taken blocks.
+func BenchmarkAcquireSubnetLease(b *testing.B) {
+	const network, subnetMask = "10.0.0.0/8", 24
+	for _, percent := range benchmarkTakenPercents {
+		taken := takenSubnets(network, subnetMask, percent, false)
+		for _, strategy := range benchmarkStrategies {
+			b.Run(fmt.Sprintf("%d%% taken/%s", percent, strategy), func(b *testing.B) {
+				allocator, err := leaser.NewAllocator(strategy)
+				if err != nil {
+					b.Fatal(err)
+				}
+				cidrPools := &leaser.CIDRPools{}
+				cidrPools.AddActive(leaser.NewCIDRPoolWithAllocator(network, subnetMask, allocator))
+
+				store := database.NewMemoryStore(database.ClockFunc(time.Now)).ForPool(controller.DefaultPool)
+				hardwareAddressGenerator := &leaser.HardwareAddressGenerator{}
+				for i, subnet := range taken {
+					vtepIP, _, err := net.ParseCIDR(subnet)
+					if err != nil {
+						b.Fatal(err)
+					}
+					hwAddr, err := hardwareAddressGenerator.GenerateForVTEP(vtepIP)
+					if err != nil {
+						b.Fatal(err)
+					}
+					err = store.AddEntry(controller.Lease{
+						UnderlayIP:          fmt.Sprintf("172.16.%d.%d", i/256, i%256),
+						OverlaySubnet:       subnet,
+						OverlayHardwareAddr: hwAddr.String(),
+						Pool:                controller.DefaultPool,
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				trackedStore := leaser.NewTrackedStore(store)
+				trackedStore.Track(cidrPools)
+
+				leaseController := &leaser.LeaseController{
+					Pool:                       controller.DefaultPool,
+					DatabaseHandler:            trackedStore,
+					HardwareAddressGenerator:   hardwareAddressGenerator,
+					LeaseValidator:             &leaser.LeaseValidator{},
+					AcquireSubnetLeaseAttempts: 1,
+					CIDRPool:                   cidrPools,
+					LeaseExpirationSeconds:     60,
+					Logger:                     lager.NewLogger("benchmark"),
+					MetricSender:               &fakes.MetricSender{},
+				}
+				request := controller.AcquireLeaseRequest{UnderlayIP: "192.168.0.1"}
+				acquireAndRelease := func() {
+					lease, err := leaseController.AcquireSubnetLease("benchmark", request)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if lease == nil {
+						b.Fatal("no lease available")
+					}
+					err = leaseController.ReleaseSubnetLease("benchmark", request.UnderlayIP)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				// the first acquisition loads the leases into the pool
+				acquireAndRelease()
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					acquireAndRelease()
+				}
+			})
+		}
+	}
+}
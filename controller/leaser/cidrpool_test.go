@@ -2,6 +2,7 @@ package leaser_test
 
 import (
 	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"net"
@@ -17,21 +18,32 @@ var _ = Describe("CIDRPool", func() {
 			Entry("when the range is /16 and mask is /24", "10.255.0.0/16", 24, 255),
 			Entry("when the range is /16 and mask is /20", "10.255.0.0/16", 20, 15),
 			Entry("when the range is /16 and mask is /16", "10.255.0.0/16", 16, 0),
+			Entry("when the range is an ipv6 /48 and mask is /64", "fd00:255::/48", 64, 65535),
+			Entry("when the range is an ipv6 /56 and mask is /64", "fd00:255::/56", 64, 255),
 		)
 
 		DescribeTable("produces valid subnets within the correct range",
 			func(overlayCIDR string, subnetMask int) {
 				_, overlayNetwork, _ := net.ParseCIDR(overlayCIDR)
-				cidrPool := leaser.NewCIDRPool(overlayCIDR, subnetMask)
+				allocator := &fakes.Allocator{}
+				cidrPool := leaser.NewCIDRPoolWithAllocator(overlayCIDR, subnetMask, allocator)
 
-				for blockDividedCIDR, _ := range cidrPool.GetBlockPool() {
-					_, blockNetwork, _ := net.ParseCIDR(blockDividedCIDR)
+				seen := map[string]struct{}{}
+				for i := 0; i < cidrPool.BlockPoolSize(); i++ {
+					allocator.AllocateReturns(i)
+					blockDividedCIDR := cidrPool.GetAvailableBlock(nil)
+					_, blockNetwork, err := net.ParseCIDR(blockDividedCIDR)
+					Expect(err).NotTo(HaveOccurred())
 					Expect(overlayNetwork.Contains(blockNetwork.IP)).Should(BeTrue())
+					Expect(cidrPool.IsBlockMember(blockDividedCIDR)).To(BeTrue())
+					seen[blockDividedCIDR] = struct{}{}
 				}
+				Expect(seen).To(HaveLen(cidrPool.BlockPoolSize()))
 			},
 			Entry("when ip is in the start of the cidr range", "10.240.0.0/12", 24),
 			Entry("when ip is in the middle of the cidr range", "10.255.0.0/12", 24),
 			Entry("when ip is in the end of the cidr range", "10.255.255.255/12", 24),
+			Entry("when the range is ipv6", "fd00:255:0:ff00::/56", 64),
 		)
 	})
 
@@ -51,15 +63,38 @@ var _ = Describe("CIDRPool", func() {
 			subnetMask := 24
 			firstSubnet := "10.240.0.0/24"
 
-			cidrPool := leaser.NewCIDRPool(overlayCIDR, subnetMask)
+			allocator := &fakes.Allocator{}
+			cidrPool := leaser.NewCIDRPoolWithAllocator(overlayCIDR, subnetMask, allocator)
 			_, expectedSingleIPNetwork, _ := net.ParseCIDR(firstSubnet)
-			for singleIPCIDR, _ := range cidrPool.GetSinglePool() {
-				_, singleIPNetwork, _ := net.ParseCIDR(singleIPCIDR)
+			for i := 0; i < cidrPool.SingleIPPoolSize(); i++ {
+				allocator.AllocateReturns(i)
+				singleIPCIDR := cidrPool.GetAvailableSingleIP(nil)
+				_, singleIPNetwork, err := net.ParseCIDR(singleIPCIDR)
+				Expect(err).NotTo(HaveOccurred())
 				Expect(expectedSingleIPNetwork.Contains(singleIPNetwork.IP)).Should(BeTrue())
 			}
 		})
 	})
 
+	Context("when the network is ipv6", func() {
+		var cidrPool *leaser.CIDRPool
+
+		BeforeEach(func() {
+			cidrPool = leaser.NewCIDRPool("fd00:255::/62", 64)
+		})
+
+		It("hands out ipv6 blocks but no single ips", func() {
+			Expect(cidrPool.GetAvailableBlock([]string{"fd00:255:0:1::/64", "fd00:255:0:2::/64"})).To(Equal("fd00:255:0:3::/64"))
+			Expect(cidrPool.SingleIPPoolSize()).To(Equal(0))
+			Expect(cidrPool.GetAvailableSingleIP(nil)).To(Equal(""))
+		})
+
+		It("recognises its own blocks", func() {
+			Expect(cidrPool.IsMember("fd00:255:0:3::/64")).To(BeTrue())
+			Expect(cidrPool.IsMember("fd00:255:0:4::/64")).To(BeFalse())
+		})
+	})
+
 	Describe("GetAvailableBlock", func() {
 		It("returns a subnet from the pool that is not taken", func() {
 			subnetRange := "10.255.0.0/16"
@@ -179,6 +214,63 @@ var _ = Describe("CIDRPool", func() {
 		})
 	})
 
+	Describe("Take, Free and Reset", func() {
+		var cidrPool *leaser.CIDRPool
+
+		BeforeEach(func() {
+			cidrPool = leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, &leaser.LowestFreeFirstAllocator{})
+		})
+
+		It("hands out no leased subnet until it is freed", func() {
+			Expect(cidrPool.Take("10.255.1.0/24")).To(BeTrue())
+			Expect(cidrPool.Take("10.255.0.1/32")).To(BeTrue())
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.2.0/24"))
+			Expect(cidrPool.GetAvailableSingleIP(nil)).To(Equal("10.255.0.2/32"))
+
+			Expect(cidrPool.Free("10.255.1.0/24")).To(BeTrue())
+			Expect(cidrPool.Free("10.255.0.1/32")).To(BeTrue())
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.1.0/24"))
+			Expect(cidrPool.GetAvailableSingleIP(nil)).To(Equal("10.255.0.1/32"))
+		})
+
+		It("reports whether the subnet was free or leased", func() {
+			Expect(cidrPool.Take("10.255.1.0/24")).To(BeTrue())
+			Expect(cidrPool.Take("10.255.1.0/24")).To(BeFalse())
+			Expect(cidrPool.Free("10.255.1.0/24")).To(BeTrue())
+			Expect(cidrPool.Free("10.255.1.0/24")).To(BeFalse())
+		})
+
+		It("ignores subnets that are not members", func() {
+			Expect(cidrPool.Take("10.254.1.0/24")).To(BeFalse())
+			Expect(cidrPool.Take("10.255.1.0/25")).To(BeFalse())
+			Expect(cidrPool.Free("10.254.1.0/24")).To(BeFalse())
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.1.0/24"))
+		})
+
+		It("keeps withheld blocks from being handed out once freed", func() {
+			Expect(cidrPool.Withhold("10.255.0.0/23")).To(Succeed())
+			Expect(cidrPool.Take("10.255.1.0/24")).To(BeFalse())
+			Expect(cidrPool.Free("10.255.1.0/24")).To(BeFalse())
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.2.0/24"))
+		})
+
+		It("takes the subnets passed to GetAvailableBlock only for the call", func() {
+			Expect(cidrPool.Take("10.255.2.0/24")).To(BeTrue())
+			Expect(cidrPool.GetAvailableBlock([]string{"10.255.1.0/24", "10.255.2.0/24"})).To(Equal("10.255.3.0/24"))
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.1.0/24"))
+			Expect(cidrPool.Take("10.255.2.0/24")).To(BeFalse())
+		})
+
+		It("replaces the leased subnets on Reset", func() {
+			Expect(cidrPool.Take("10.255.1.0/24")).To(BeTrue())
+			cidrPool.Reset([]string{"10.255.2.0/24", "10.255.0.1/32", "fd00::/64", "banana"})
+
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.1.0/24"))
+			Expect(cidrPool.GetAvailableSingleIP(nil)).To(Equal("10.255.0.2/32"))
+			Expect(cidrPool.Take("10.255.2.0/24")).To(BeFalse())
+		})
+	})
+
 	Describe("IsMember", func() {
 		var cidrPool *leaser.CIDRPool
 		BeforeEach(func() {
@@ -210,4 +302,240 @@ var _ = Describe("CIDRPool", func() {
 			})
 		})
 	})
+
+	Describe("IsBlockMember", func() {
+		var cidrPool *leaser.CIDRPool
+		BeforeEach(func() {
+			cidrPool = leaser.NewCIDRPool("10.255.0.0/16", 24)
+		})
+
+		It("returns true for a block of the pool", func() {
+			Expect(cidrPool.IsBlockMember("10.255.30.0/24")).To(BeTrue())
+		})
+
+		It("returns false for a single ip of the pool", func() {
+			Expect(cidrPool.IsBlockMember("10.255.0.5/32")).To(BeFalse())
+		})
+
+		It("returns false for a block outside the pool", func() {
+			Expect(cidrPool.IsBlockMember("10.254.30.0/24")).To(BeFalse())
+		})
+	})
+
+	Context("when the network is a /8 split into /32s", func() {
+		var cidrPool *leaser.CIDRPool
+
+		BeforeEach(func() {
+			cidrPool = leaser.NewCIDRPoolWithAllocator("10.0.0.0/8", 32, &leaser.LowestFreeFirstAllocator{})
+		})
+
+		It("describes every block without generating them", func() {
+			Expect(cidrPool.BlockPoolSize()).To(Equal(1<<24 - 1))
+			Expect(cidrPool.SingleIPPoolSize()).To(Equal(0))
+			Expect(cidrPool.IsBlockMember("10.0.0.1/32")).To(BeTrue())
+			Expect(cidrPool.IsBlockMember("10.255.255.255/32")).To(BeTrue())
+			Expect(cidrPool.IsBlockMember("10.0.0.0/32")).To(BeFalse())
+			Expect(cidrPool.IsBlockMember("11.0.0.0/32")).To(BeFalse())
+			Expect(cidrPool.IsBlockMember("9.255.255.255/32")).To(BeFalse())
+		})
+
+		It("skips taken blocks", func() {
+			Expect(cidrPool.GetAvailableBlock([]string{"10.0.0.1/32", "10.0.0.2/32", "10.0.0.4/32"})).To(Equal("10.0.0.3/32"))
+		})
+	})
+
+	Context("when the network is a /8 with a large single ip block", func() {
+		It("hands out single ips from the first block", func() {
+			cidrPool := leaser.NewCIDRPoolWithAllocator("10.0.0.0/8", 9, &leaser.MaximallySpreadAllocator{})
+			Expect(cidrPool.SingleIPPoolSize()).To(Equal(1<<23 - 1))
+			Expect(cidrPool.GetAvailableSingleIP(nil)).To(Equal("10.0.0.1/32"))
+			Expect(cidrPool.GetAvailableSingleIP([]string{"10.0.0.1/32"})).To(Equal("10.127.255.255/32"))
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.128.0.0/9"))
+		})
+	})
+
+	Describe("Exclude", func() {
+		var cidrPool *leaser.CIDRPool
+
+		BeforeEach(func() {
+			cidrPool = leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, &leaser.LowestFreeFirstAllocator{})
+		})
+
+		It("never hands out blocks that overlap an excluded range", func() {
+			Expect(cidrPool.Exclude("10.255.1.0/24")).To(Succeed())
+			Expect(cidrPool.Exclude("10.255.2.128/25")).To(Succeed())
+
+			Expect(cidrPool.BlockPoolSize()).To(Equal(253))
+			Expect(cidrPool.ExcludedBlockCount()).To(Equal(2))
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.3.0/24"))
+			Expect(cidrPool.IsMember("10.255.2.0/24")).To(BeFalse())
+			Expect(cidrPool.IsBlockMember("10.255.1.0/24")).To(BeFalse())
+			Expect(cidrPool.IsExcluded("10.255.2.0/24")).To(BeTrue())
+			Expect(cidrPool.IsExcluded("10.255.3.0/24")).To(BeFalse())
+		})
+
+		It("never hands out single ips inside an excluded range", func() {
+			Expect(cidrPool.Exclude("10.255.0.0/30")).To(Succeed())
+
+			Expect(cidrPool.SingleIPPoolSize()).To(Equal(252))
+			Expect(cidrPool.ExcludedSingleIPCount()).To(Equal(3))
+			Expect(cidrPool.ExcludedBlockCount()).To(Equal(0))
+			Expect(cidrPool.GetAvailableSingleIP(nil)).To(Equal("10.255.0.4/32"))
+			Expect(cidrPool.IsMember("10.255.0.3/32")).To(BeFalse())
+			Expect(cidrPool.IsExcluded("10.255.0.3/32")).To(BeTrue())
+		})
+
+		It("only excludes the part of a range that lies within the network", func() {
+			Expect(cidrPool.Exclude("10.254.0.0/15")).To(Succeed())
+			Expect(cidrPool.BlockPoolSize()).To(Equal(0))
+			Expect(cidrPool.SingleIPPoolSize()).To(Equal(0))
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal(""))
+
+			otherPool := leaser.NewCIDRPool("10.255.0.0/16", 24)
+			Expect(otherPool.Exclude("10.254.0.0/16")).To(Succeed())
+			Expect(otherPool.Exclude("fd00::/8")).To(Succeed())
+			Expect(otherPool.BlockPoolSize()).To(Equal(255))
+		})
+
+		It("passes the excluded subnets to the allocator together with the taken ones", func() {
+			allocator := &fakes.Allocator{}
+			allocator.AllocateStub = func(taken *leaser.Taken) int {
+				Expect(taken.Count()).To(Equal(4))
+				for _, i := range []int{1, 3, 4, 8} {
+					Expect(taken.Contains(i)).To(BeTrue())
+				}
+				return -1
+			}
+			cidrPool = leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, allocator)
+			Expect(cidrPool.Exclude("10.255.4.0/23")).To(Succeed())
+
+			cidrPool.GetAvailableBlock([]string{"10.255.9.0/24", "10.255.2.0/24", "10.255.4.0/24"})
+			Expect(allocator.AllocateCallCount()).To(Equal(1))
+		})
+
+		It("steps over an excluded range at once", func() {
+			cidrPool = leaser.NewCIDRPoolWithAllocator("10.0.0.0/8", 32, &leaser.LowestFreeFirstAllocator{})
+			Expect(cidrPool.Exclude("10.0.0.0/9")).To(Succeed())
+
+			Expect(cidrPool.ExcludedBlockCount()).To(Equal(1<<23 - 1))
+			Expect(cidrPool.GetAvailableBlock([]string{"10.128.0.0/32"})).To(Equal("10.128.0.1/32"))
+		})
+
+		It("excludes ranges of an ipv6 pool", func() {
+			cidrPool = leaser.NewCIDRPoolWithAllocator("fd00:255::/48", 64, &leaser.LowestFreeFirstAllocator{})
+			Expect(cidrPool.Exclude("fd00:255:0:2::/63")).To(Succeed())
+			Expect(cidrPool.BlockPoolSize()).To(Equal(65533))
+			Expect(cidrPool.GetAvailableBlock([]string{"fd00:255:0:1::/64"})).To(Equal("fd00:255:0:4::/64"))
+		})
+
+		It("rejects a range that is not a cidr", func() {
+			Expect(cidrPool.Exclude("banana")).To(MatchError(ContainSubstring("parse excluded range")))
+		})
+	})
+
+	Describe("Withhold", func() {
+		It("no longer hands out the blocks of the range, which stay members", func() {
+			cidrPool := leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, &leaser.LowestFreeFirstAllocator{})
+			Expect(cidrPool.Withhold("10.255.0.0/23")).To(Succeed())
+
+			Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.2.0/24"))
+			Expect(cidrPool.GetAvailableSingleIP(nil)).To(Equal("10.255.0.1/32"))
+			Expect(cidrPool.IsBlockMember("10.255.1.0/24")).To(BeTrue())
+			Expect(cidrPool.IsExcluded("10.255.1.0/24")).To(BeFalse())
+			Expect(cidrPool.BlockPoolSize()).To(Equal(255))
+		})
+
+		It("counts a withheld block leased by a partition once", func() {
+			allocator := &fakes.Allocator{}
+			allocator.AllocateStub = func(taken *leaser.Taken) int {
+				Expect(taken.Count()).To(Equal(4))
+				Expect(taken.Contains(8)).To(BeTrue())
+				Expect(taken.Contains(3)).To(BeFalse())
+				return -1
+			}
+			cidrPool := leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, allocator)
+			Expect(cidrPool.Exclude("10.255.0.0/23")).To(Succeed())
+			Expect(cidrPool.Withhold("10.255.0.0/22")).To(Succeed())
+
+			cidrPool.GetAvailableBlock([]string{"10.255.2.0/24", "10.255.9.0/24"})
+			Expect(allocator.AllocateCallCount()).To(Equal(1))
+		})
+
+		It("rejects a range that is not a cidr", func() {
+			cidrPool := leaser.NewCIDRPool("10.255.0.0/16", 24)
+			Expect(cidrPool.Withhold("banana")).To(MatchError(ContainSubstring("parse withheld range")))
+		})
+	})
+
+	Describe("Partition", func() {
+		var cidrPool *leaser.CIDRPool
+
+		BeforeEach(func() {
+			cidrPool = leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, &leaser.LowestFreeFirstAllocator{})
+		})
+
+		It("hands out the blocks inside the range only", func() {
+			partition, err := cidrPool.Partition("10.255.4.0/22", &leaser.LowestFreeFirstAllocator{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(partition.BlockPoolSize()).To(Equal(4))
+			Expect(partition.SingleIPPoolSize()).To(Equal(0))
+			Expect(partition.GetAvailableBlock([]string{"10.255.4.0/24", "10.255.9.0/24"})).To(Equal("10.255.5.0/24"))
+			Expect(partition.GetAvailableBlock([]string{"10.255.4.0/24", "10.255.5.0/24", "10.255.6.0/24", "10.255.7.0/24"})).To(Equal(""))
+			Expect(partition.GetAvailableSingleIP(nil)).To(Equal(""))
+			Expect(partition.IsBlockMember("10.255.7.0/24")).To(BeTrue())
+			Expect(partition.IsBlockMember("10.255.8.0/24")).To(BeFalse())
+			Expect(partition.IsMember("10.255.4.1/32")).To(BeFalse())
+		})
+
+		It("leaves out the first block of the network, which holds the single ips", func() {
+			partition, err := cidrPool.Partition("10.255.0.0/22", &leaser.LowestFreeFirstAllocator{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(partition.BlockPoolSize()).To(Equal(3))
+			Expect(partition.GetAvailableBlock(nil)).To(Equal("10.255.1.0/24"))
+			Expect(partition.IsBlockMember("10.255.0.0/24")).To(BeFalse())
+		})
+
+		It("leaves out the excluded ranges of the pool", func() {
+			Expect(cidrPool.Exclude("10.255.3.0/24")).To(Succeed())
+			Expect(cidrPool.Exclude("10.254.0.0/16")).To(Succeed())
+
+			partition, err := cidrPool.Partition("10.255.2.0/23", &leaser.LowestFreeFirstAllocator{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(partition.BlockPoolSize()).To(Equal(1))
+			Expect(partition.IsExcluded("10.255.3.0/24")).To(BeTrue())
+			Expect(partition.GetAvailableBlock([]string{"10.255.2.0/24"})).To(Equal(""))
+		})
+
+		DescribeTable("rejects a range that does not hold whole blocks of the network",
+			func(partitionRange string) {
+				_, err := cidrPool.Partition(partitionRange, &leaser.LowestFreeFirstAllocator{})
+				Expect(err).To(MatchError(ContainSubstring("does not hold whole blocks of 10.255.0.0/16")))
+			},
+			Entry("outside the network", "10.254.0.0/20"),
+			Entry("larger than the network", "10.254.0.0/15"),
+			Entry("smaller than a block", "10.255.4.0/25"),
+			Entry("of the other address family", "fd00::/120"),
+		)
+
+		It("rejects a range that is not a cidr", func() {
+			_, err := cidrPool.Partition("banana", &leaser.LowestFreeFirstAllocator{})
+			Expect(err).To(MatchError(ContainSubstring("parse partition range")))
+		})
+	})
+
+	DescribeTable("does not recognise subnets written differently from its own",
+		func(subnetRange string, subnetMask int, subnet string) {
+			cidrPool := leaser.NewCIDRPool(subnetRange, subnetMask)
+			Expect(cidrPool.IsMember(subnet)).To(BeFalse())
+		},
+		Entry("host bits set", "10.255.0.0/16", 24, "10.255.3.1/24"),
+		Entry("not a cidr", "10.255.0.0/16", 24, "10.255.3.0"),
+		Entry("the first block", "10.255.0.0/16", 24, "10.255.0.0/24"),
+		Entry("the network address", "10.255.0.0/16", 24, "10.255.0.0/32"),
+		Entry("an ipv6 subnet in an ipv4 pool", "10.255.0.0/16", 24, "fd00:255:0:3::/64"),
+		Entry("an ipv4 subnet in an ipv6 pool", "fd00:255::/48", 64, "10.255.3.0/24"),
+		Entry("an ipv6 subnet outside the pool", "fd00:255::/48", 64, "fd00:256::/64"),
+	)
 })
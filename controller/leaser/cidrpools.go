@@ -0,0 +1,177 @@
+package leaser
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// CIDRPools combines the networks of an overlay pool. New subnets are only
+// handed out from the active networks, in the order they were added, while
+// leases in draining networks stay valid until their hosts give them up. This
+// lets a pool grow or be renumbered without invalidating existing leases.
+type CIDRPools struct {
+	active   []*CIDRPool
+	draining []*CIDRPool
+}
+
+func (p *CIDRPools) AddActive(pool *CIDRPool) {
+	p.active = append(p.active, pool)
+}
+
+func (p *CIDRPools) AddDraining(pool *CIDRPool) {
+	p.draining = append(p.draining, pool)
+}
+
+// Exclude takes the range out of every network of the pool.
+func (p *CIDRPools) Exclude(excludedRange string) error {
+	for _, pool := range p.all() {
+		err := pool.Exclude(excludedRange)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Partition returns the blocks inside the range, which must lie within one
+// of the active networks, as a pool of their own, and withholds them from the
+// networks of the pool.
+func (p *CIDRPools) Partition(partitionRange string, allocator allocator) (*CIDRPool, error) {
+	prefix, err := netip.ParsePrefix(partitionRange)
+	if err != nil {
+		return nil, fmt.Errorf("parse partition range: %s", err)
+	}
+	for _, pool := range p.active {
+		if !pool.holds(prefix.Masked()) {
+			continue
+		}
+		partition, err := pool.Partition(partitionRange, allocator)
+		if err != nil {
+			return nil, err
+		}
+		err = pool.Withhold(partitionRange)
+		if err != nil {
+			return nil, err
+		}
+		return partition, nil
+	}
+	return nil, fmt.Errorf("partition range %s is not inside an active network", partitionRange)
+}
+
+// BlockPoolSize returns the number of blocks in the active networks.
+func (p *CIDRPools) BlockPoolSize() int {
+	size := 0
+	for _, pool := range p.active {
+		size += pool.BlockPoolSize()
+	}
+	return size
+}
+
+// SingleIPPoolSize returns the number of single ips in the active networks.
+func (p *CIDRPools) SingleIPPoolSize() int {
+	size := 0
+	for _, pool := range p.active {
+		size += pool.SingleIPPoolSize()
+	}
+	return size
+}
+
+func (p *CIDRPools) ExcludedBlockCount() int {
+	count := 0
+	for _, pool := range p.active {
+		count += pool.ExcludedBlockCount()
+	}
+	return count
+}
+
+func (p *CIDRPools) ExcludedSingleIPCount() int {
+	count := 0
+	for _, pool := range p.active {
+		count += pool.ExcludedSingleIPCount()
+	}
+	return count
+}
+
+func (p *CIDRPools) GetAvailableBlock(taken []string) string {
+	for _, pool := range p.active {
+		if subnet := pool.GetAvailableBlock(taken); subnet != "" {
+			return subnet
+		}
+	}
+	return ""
+}
+
+func (p *CIDRPools) GetAvailableSingleIP(taken []string) string {
+	for _, pool := range p.active {
+		if subnet := pool.GetAvailableSingleIP(taken); subnet != "" {
+			return subnet
+		}
+	}
+	return ""
+}
+
+// Take marks the subnet as leased in the active networks, which are the only
+// ones that hand subnets out.
+func (p *CIDRPools) Take(subnet string) bool {
+	taken := false
+	for _, pool := range p.active {
+		taken = pool.Take(subnet) || taken
+	}
+	return taken
+}
+
+func (p *CIDRPools) Free(subnet string) bool {
+	freed := false
+	for _, pool := range p.active {
+		freed = pool.Free(subnet) || freed
+	}
+	return freed
+}
+
+func (p *CIDRPools) Reset(leased []string) {
+	for _, pool := range p.active {
+		pool.Reset(leased)
+	}
+}
+
+func (p *CIDRPools) IsMember(subnet string) bool {
+	for _, pool := range p.all() {
+		if pool.IsMember(subnet) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *CIDRPools) IsBlockMember(subnet string) bool {
+	for _, pool := range p.all() {
+		if pool.IsBlockMember(subnet) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *CIDRPools) IsExcluded(subnet string) bool {
+	for _, pool := range p.all() {
+		if pool.IsExcluded(subnet) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether the subnet may be handed to a new lease, that is
+// whether it belongs to an active network.
+func (p *CIDRPools) IsActive(subnet string) bool {
+	for _, pool := range p.active {
+		if pool.IsMember(subnet) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *CIDRPools) all() []*CIDRPool {
+	return append(append([]*CIDRPool{}, p.active...), p.draining...)
+}
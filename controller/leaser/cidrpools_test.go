@@ -0,0 +1,91 @@
+package leaser_test
+
+import (
+	"code.cloudfoundry.org/silk/controller/leaser"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CIDRPools", func() {
+	var cidrPools *leaser.CIDRPools
+
+	BeforeEach(func() {
+		cidrPools = &leaser.CIDRPools{}
+		cidrPools.AddDraining(leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, &leaser.LowestFreeFirstAllocator{}))
+		cidrPools.AddActive(leaser.NewCIDRPoolWithAllocator("10.250.0.0/23", 24, &leaser.LowestFreeFirstAllocator{}))
+		cidrPools.AddActive(leaser.NewCIDRPoolWithAllocator("10.251.0.0/22", 24, &leaser.LowestFreeFirstAllocator{}))
+	})
+
+	It("hands out blocks from the active networks in order", func() {
+		Expect(cidrPools.GetAvailableBlock(nil)).To(Equal("10.250.1.0/24"))
+		Expect(cidrPools.GetAvailableBlock([]string{"10.250.1.0/24"})).To(Equal("10.251.1.0/24"))
+		Expect(cidrPools.GetAvailableBlock([]string{"10.250.1.0/24", "10.251.1.0/24", "10.251.2.0/24", "10.251.3.0/24"})).To(Equal(""))
+	})
+
+	It("hands out single ips from the active networks in order", func() {
+		Expect(cidrPools.GetAvailableSingleIP(nil)).To(Equal("10.250.0.1/32"))
+	})
+
+	It("counts the subnets of the active networks only", func() {
+		Expect(cidrPools.BlockPoolSize()).To(Equal(1 + 3))
+		Expect(cidrPools.SingleIPPoolSize()).To(Equal(255 + 255))
+	})
+
+	It("accepts subnets of active and draining networks as members", func() {
+		Expect(cidrPools.IsMember("10.255.4.0/24")).To(BeTrue())
+		Expect(cidrPools.IsMember("10.250.0.9/32")).To(BeTrue())
+		Expect(cidrPools.IsMember("10.252.1.0/24")).To(BeFalse())
+		Expect(cidrPools.IsBlockMember("10.255.4.0/24")).To(BeTrue())
+		Expect(cidrPools.IsBlockMember("10.251.3.0/24")).To(BeTrue())
+		Expect(cidrPools.IsBlockMember("10.250.0.9/32")).To(BeFalse())
+	})
+
+	It("only reports subnets of active networks as active", func() {
+		Expect(cidrPools.IsActive("10.250.1.0/24")).To(BeTrue())
+		Expect(cidrPools.IsActive("10.251.0.7/32")).To(BeTrue())
+		Expect(cidrPools.IsActive("10.255.4.0/24")).To(BeFalse())
+		Expect(cidrPools.IsActive("10.252.1.0/24")).To(BeFalse())
+	})
+
+	It("tracks the leased subnets of the active networks", func() {
+		Expect(cidrPools.Take("10.250.1.0/24")).To(BeTrue())
+		Expect(cidrPools.Take("10.255.4.0/24")).To(BeFalse())
+		Expect(cidrPools.GetAvailableBlock(nil)).To(Equal("10.251.1.0/24"))
+
+		Expect(cidrPools.Free("10.250.1.0/24")).To(BeTrue())
+		Expect(cidrPools.GetAvailableBlock(nil)).To(Equal("10.250.1.0/24"))
+
+		cidrPools.Reset([]string{"10.250.1.0/24", "10.251.1.0/24"})
+		Expect(cidrPools.GetAvailableBlock(nil)).To(Equal("10.251.2.0/24"))
+	})
+
+	It("excludes ranges from every network", func() {
+		Expect(cidrPools.Exclude("10.251.2.0/23")).To(Succeed())
+		Expect(cidrPools.Exclude("10.255.7.0/24")).To(Succeed())
+
+		Expect(cidrPools.BlockPoolSize()).To(Equal(1 + 1))
+		Expect(cidrPools.ExcludedBlockCount()).To(Equal(2))
+		Expect(cidrPools.ExcludedSingleIPCount()).To(Equal(0))
+		Expect(cidrPools.IsExcluded("10.251.3.0/24")).To(BeTrue())
+		Expect(cidrPools.IsExcluded("10.255.7.0/24")).To(BeTrue())
+		Expect(cidrPools.IsExcluded("10.251.1.0/24")).To(BeFalse())
+		Expect(cidrPools.GetAvailableBlock([]string{"10.250.1.0/24", "10.251.1.0/24"})).To(Equal(""))
+	})
+
+	Describe("Partition", func() {
+		It("carves the partition out of the active network that holds it", func() {
+			partition, err := cidrPools.Partition("10.251.2.0/23", &leaser.LowestFreeFirstAllocator{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(partition.GetAvailableBlock(nil)).To(Equal("10.251.2.0/24"))
+
+			Expect(cidrPools.GetAvailableBlock([]string{"10.250.1.0/24", "10.251.1.0/24"})).To(Equal(""))
+			Expect(cidrPools.IsActive("10.251.2.0/24")).To(BeTrue())
+			Expect(cidrPools.BlockPoolSize()).To(Equal(1 + 3))
+		})
+
+		It("rejects a range outside the active networks", func() {
+			_, err := cidrPools.Partition("10.255.4.0/22", &leaser.LowestFreeFirstAllocator{})
+			Expect(err).To(MatchError("partition range 10.255.4.0/22 is not inside an active network"))
+		})
+	})
+})
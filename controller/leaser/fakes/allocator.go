@@ -0,0 +1,109 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller/leaser"
+)
+
+type Allocator struct {
+	AllocateStub        func(*leaser.Taken) int
+	allocateMutex       sync.RWMutex
+	allocateArgsForCall []struct {
+		arg1 *leaser.Taken
+	}
+	allocateReturns struct {
+		result1 int
+	}
+	allocateReturnsOnCall map[int]struct {
+		result1 int
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *Allocator) Allocate(arg1 *leaser.Taken) int {
+	fake.allocateMutex.Lock()
+	ret, specificReturn := fake.allocateReturnsOnCall[len(fake.allocateArgsForCall)]
+	fake.allocateArgsForCall = append(fake.allocateArgsForCall, struct {
+		arg1 *leaser.Taken
+	}{arg1})
+	stub := fake.AllocateStub
+	fakeReturns := fake.allocateReturns
+	fake.recordInvocation("Allocate", []interface{}{arg1})
+	fake.allocateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Allocator) AllocateCallCount() int {
+	fake.allocateMutex.RLock()
+	defer fake.allocateMutex.RUnlock()
+	return len(fake.allocateArgsForCall)
+}
+
+func (fake *Allocator) AllocateCalls(stub func(*leaser.Taken) int) {
+	fake.allocateMutex.Lock()
+	defer fake.allocateMutex.Unlock()
+	fake.AllocateStub = stub
+}
+
+func (fake *Allocator) AllocateArgsForCall(i int) *leaser.Taken {
+	fake.allocateMutex.RLock()
+	defer fake.allocateMutex.RUnlock()
+	argsForCall := fake.allocateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Allocator) AllocateReturns(result1 int) {
+	fake.allocateMutex.Lock()
+	defer fake.allocateMutex.Unlock()
+	fake.AllocateStub = nil
+	fake.allocateReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *Allocator) AllocateReturnsOnCall(i int, result1 int) {
+	fake.allocateMutex.Lock()
+	defer fake.allocateMutex.Unlock()
+	fake.AllocateStub = nil
+	if fake.allocateReturnsOnCall == nil {
+		fake.allocateReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.allocateReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *Allocator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.allocateMutex.RLock()
+	defer fake.allocateMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *Allocator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
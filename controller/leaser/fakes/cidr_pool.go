@@ -6,6 +6,16 @@ import (
 )
 
 type CIDRPool struct {
+	BlockPoolSizeStub        func() int
+	blockPoolSizeMutex       sync.RWMutex
+	blockPoolSizeArgsForCall []struct {
+	}
+	blockPoolSizeReturns struct {
+		result1 int
+	}
+	blockPoolSizeReturnsOnCall map[int]struct {
+		result1 int
+	}
 	GetAvailableBlockStub        func([]string) string
 	getAvailableBlockMutex       sync.RWMutex
 	getAvailableBlockArgsForCall []struct {
@@ -28,6 +38,39 @@ type CIDRPool struct {
 	getAvailableSingleIPReturnsOnCall map[int]struct {
 		result1 string
 	}
+	IsActiveStub        func(string) bool
+	isActiveMutex       sync.RWMutex
+	isActiveArgsForCall []struct {
+		arg1 string
+	}
+	isActiveReturns struct {
+		result1 bool
+	}
+	isActiveReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	IsBlockMemberStub        func(string) bool
+	isBlockMemberMutex       sync.RWMutex
+	isBlockMemberArgsForCall []struct {
+		arg1 string
+	}
+	isBlockMemberReturns struct {
+		result1 bool
+	}
+	isBlockMemberReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	IsExcludedStub        func(string) bool
+	isExcludedMutex       sync.RWMutex
+	isExcludedArgsForCall []struct {
+		arg1 string
+	}
+	isExcludedReturns struct {
+		result1 bool
+	}
+	isExcludedReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	IsMemberStub        func(string) bool
 	isMemberMutex       sync.RWMutex
 	isMemberArgsForCall []struct {
@@ -39,10 +82,73 @@ type CIDRPool struct {
 	isMemberReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	SingleIPPoolSizeStub        func() int
+	singleIPPoolSizeMutex       sync.RWMutex
+	singleIPPoolSizeArgsForCall []struct {
+	}
+	singleIPPoolSizeReturns struct {
+		result1 int
+	}
+	singleIPPoolSizeReturnsOnCall map[int]struct {
+		result1 int
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *CIDRPool) BlockPoolSize() int {
+	fake.blockPoolSizeMutex.Lock()
+	ret, specificReturn := fake.blockPoolSizeReturnsOnCall[len(fake.blockPoolSizeArgsForCall)]
+	fake.blockPoolSizeArgsForCall = append(fake.blockPoolSizeArgsForCall, struct {
+	}{})
+	stub := fake.BlockPoolSizeStub
+	fakeReturns := fake.blockPoolSizeReturns
+	fake.recordInvocation("BlockPoolSize", []interface{}{})
+	fake.blockPoolSizeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) BlockPoolSizeCallCount() int {
+	fake.blockPoolSizeMutex.RLock()
+	defer fake.blockPoolSizeMutex.RUnlock()
+	return len(fake.blockPoolSizeArgsForCall)
+}
+
+func (fake *CIDRPool) BlockPoolSizeCalls(stub func() int) {
+	fake.blockPoolSizeMutex.Lock()
+	defer fake.blockPoolSizeMutex.Unlock()
+	fake.BlockPoolSizeStub = stub
+}
+
+func (fake *CIDRPool) BlockPoolSizeReturns(result1 int) {
+	fake.blockPoolSizeMutex.Lock()
+	defer fake.blockPoolSizeMutex.Unlock()
+	fake.BlockPoolSizeStub = nil
+	fake.blockPoolSizeReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CIDRPool) BlockPoolSizeReturnsOnCall(i int, result1 int) {
+	fake.blockPoolSizeMutex.Lock()
+	defer fake.blockPoolSizeMutex.Unlock()
+	fake.BlockPoolSizeStub = nil
+	if fake.blockPoolSizeReturnsOnCall == nil {
+		fake.blockPoolSizeReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.blockPoolSizeReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
 func (fake *CIDRPool) GetAvailableBlock(arg1 []string) string {
 	var arg1Copy []string
 	if arg1 != nil {
@@ -54,15 +160,17 @@ func (fake *CIDRPool) GetAvailableBlock(arg1 []string) string {
 	fake.getAvailableBlockArgsForCall = append(fake.getAvailableBlockArgsForCall, struct {
 		arg1 []string
 	}{arg1Copy})
+	stub := fake.GetAvailableBlockStub
+	fakeReturns := fake.getAvailableBlockReturns
 	fake.recordInvocation("GetAvailableBlock", []interface{}{arg1Copy})
 	fake.getAvailableBlockMutex.Unlock()
-	if fake.GetAvailableBlockStub != nil {
-		return fake.GetAvailableBlockStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	return fake.getAvailableBlockReturns.result1
+	return fakeReturns.result1
 }
 
 func (fake *CIDRPool) GetAvailableBlockCallCount() int {
@@ -71,13 +179,22 @@ func (fake *CIDRPool) GetAvailableBlockCallCount() int {
 	return len(fake.getAvailableBlockArgsForCall)
 }
 
+func (fake *CIDRPool) GetAvailableBlockCalls(stub func([]string) string) {
+	fake.getAvailableBlockMutex.Lock()
+	defer fake.getAvailableBlockMutex.Unlock()
+	fake.GetAvailableBlockStub = stub
+}
+
 func (fake *CIDRPool) GetAvailableBlockArgsForCall(i int) []string {
 	fake.getAvailableBlockMutex.RLock()
 	defer fake.getAvailableBlockMutex.RUnlock()
-	return fake.getAvailableBlockArgsForCall[i].arg1
+	argsForCall := fake.getAvailableBlockArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *CIDRPool) GetAvailableBlockReturns(result1 string) {
+	fake.getAvailableBlockMutex.Lock()
+	defer fake.getAvailableBlockMutex.Unlock()
 	fake.GetAvailableBlockStub = nil
 	fake.getAvailableBlockReturns = struct {
 		result1 string
@@ -85,6 +202,8 @@ func (fake *CIDRPool) GetAvailableBlockReturns(result1 string) {
 }
 
 func (fake *CIDRPool) GetAvailableBlockReturnsOnCall(i int, result1 string) {
+	fake.getAvailableBlockMutex.Lock()
+	defer fake.getAvailableBlockMutex.Unlock()
 	fake.GetAvailableBlockStub = nil
 	if fake.getAvailableBlockReturnsOnCall == nil {
 		fake.getAvailableBlockReturnsOnCall = make(map[int]struct {
@@ -107,15 +226,17 @@ func (fake *CIDRPool) GetAvailableSingleIP(arg1 []string) string {
 	fake.getAvailableSingleIPArgsForCall = append(fake.getAvailableSingleIPArgsForCall, struct {
 		arg1 []string
 	}{arg1Copy})
+	stub := fake.GetAvailableSingleIPStub
+	fakeReturns := fake.getAvailableSingleIPReturns
 	fake.recordInvocation("GetAvailableSingleIP", []interface{}{arg1Copy})
 	fake.getAvailableSingleIPMutex.Unlock()
-	if fake.GetAvailableSingleIPStub != nil {
-		return fake.GetAvailableSingleIPStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	return fake.getAvailableSingleIPReturns.result1
+	return fakeReturns.result1
 }
 
 func (fake *CIDRPool) GetAvailableSingleIPCallCount() int {
@@ -124,13 +245,22 @@ func (fake *CIDRPool) GetAvailableSingleIPCallCount() int {
 	return len(fake.getAvailableSingleIPArgsForCall)
 }
 
+func (fake *CIDRPool) GetAvailableSingleIPCalls(stub func([]string) string) {
+	fake.getAvailableSingleIPMutex.Lock()
+	defer fake.getAvailableSingleIPMutex.Unlock()
+	fake.GetAvailableSingleIPStub = stub
+}
+
 func (fake *CIDRPool) GetAvailableSingleIPArgsForCall(i int) []string {
 	fake.getAvailableSingleIPMutex.RLock()
 	defer fake.getAvailableSingleIPMutex.RUnlock()
-	return fake.getAvailableSingleIPArgsForCall[i].arg1
+	argsForCall := fake.getAvailableSingleIPArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *CIDRPool) GetAvailableSingleIPReturns(result1 string) {
+	fake.getAvailableSingleIPMutex.Lock()
+	defer fake.getAvailableSingleIPMutex.Unlock()
 	fake.GetAvailableSingleIPStub = nil
 	fake.getAvailableSingleIPReturns = struct {
 		result1 string
@@ -138,6 +268,8 @@ func (fake *CIDRPool) GetAvailableSingleIPReturns(result1 string) {
 }
 
 func (fake *CIDRPool) GetAvailableSingleIPReturnsOnCall(i int, result1 string) {
+	fake.getAvailableSingleIPMutex.Lock()
+	defer fake.getAvailableSingleIPMutex.Unlock()
 	fake.GetAvailableSingleIPStub = nil
 	if fake.getAvailableSingleIPReturnsOnCall == nil {
 		fake.getAvailableSingleIPReturnsOnCall = make(map[int]struct {
@@ -149,21 +281,206 @@ func (fake *CIDRPool) GetAvailableSingleIPReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *CIDRPool) IsActive(arg1 string) bool {
+	fake.isActiveMutex.Lock()
+	ret, specificReturn := fake.isActiveReturnsOnCall[len(fake.isActiveArgsForCall)]
+	fake.isActiveArgsForCall = append(fake.isActiveArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.IsActiveStub
+	fakeReturns := fake.isActiveReturns
+	fake.recordInvocation("IsActive", []interface{}{arg1})
+	fake.isActiveMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) IsActiveCallCount() int {
+	fake.isActiveMutex.RLock()
+	defer fake.isActiveMutex.RUnlock()
+	return len(fake.isActiveArgsForCall)
+}
+
+func (fake *CIDRPool) IsActiveCalls(stub func(string) bool) {
+	fake.isActiveMutex.Lock()
+	defer fake.isActiveMutex.Unlock()
+	fake.IsActiveStub = stub
+}
+
+func (fake *CIDRPool) IsActiveArgsForCall(i int) string {
+	fake.isActiveMutex.RLock()
+	defer fake.isActiveMutex.RUnlock()
+	argsForCall := fake.isActiveArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *CIDRPool) IsActiveReturns(result1 bool) {
+	fake.isActiveMutex.Lock()
+	defer fake.isActiveMutex.Unlock()
+	fake.IsActiveStub = nil
+	fake.isActiveReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *CIDRPool) IsActiveReturnsOnCall(i int, result1 bool) {
+	fake.isActiveMutex.Lock()
+	defer fake.isActiveMutex.Unlock()
+	fake.IsActiveStub = nil
+	if fake.isActiveReturnsOnCall == nil {
+		fake.isActiveReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isActiveReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *CIDRPool) IsBlockMember(arg1 string) bool {
+	fake.isBlockMemberMutex.Lock()
+	ret, specificReturn := fake.isBlockMemberReturnsOnCall[len(fake.isBlockMemberArgsForCall)]
+	fake.isBlockMemberArgsForCall = append(fake.isBlockMemberArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.IsBlockMemberStub
+	fakeReturns := fake.isBlockMemberReturns
+	fake.recordInvocation("IsBlockMember", []interface{}{arg1})
+	fake.isBlockMemberMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) IsBlockMemberCallCount() int {
+	fake.isBlockMemberMutex.RLock()
+	defer fake.isBlockMemberMutex.RUnlock()
+	return len(fake.isBlockMemberArgsForCall)
+}
+
+func (fake *CIDRPool) IsBlockMemberCalls(stub func(string) bool) {
+	fake.isBlockMemberMutex.Lock()
+	defer fake.isBlockMemberMutex.Unlock()
+	fake.IsBlockMemberStub = stub
+}
+
+func (fake *CIDRPool) IsBlockMemberArgsForCall(i int) string {
+	fake.isBlockMemberMutex.RLock()
+	defer fake.isBlockMemberMutex.RUnlock()
+	argsForCall := fake.isBlockMemberArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *CIDRPool) IsBlockMemberReturns(result1 bool) {
+	fake.isBlockMemberMutex.Lock()
+	defer fake.isBlockMemberMutex.Unlock()
+	fake.IsBlockMemberStub = nil
+	fake.isBlockMemberReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *CIDRPool) IsBlockMemberReturnsOnCall(i int, result1 bool) {
+	fake.isBlockMemberMutex.Lock()
+	defer fake.isBlockMemberMutex.Unlock()
+	fake.IsBlockMemberStub = nil
+	if fake.isBlockMemberReturnsOnCall == nil {
+		fake.isBlockMemberReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isBlockMemberReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *CIDRPool) IsExcluded(arg1 string) bool {
+	fake.isExcludedMutex.Lock()
+	ret, specificReturn := fake.isExcludedReturnsOnCall[len(fake.isExcludedArgsForCall)]
+	fake.isExcludedArgsForCall = append(fake.isExcludedArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.IsExcludedStub
+	fakeReturns := fake.isExcludedReturns
+	fake.recordInvocation("IsExcluded", []interface{}{arg1})
+	fake.isExcludedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) IsExcludedCallCount() int {
+	fake.isExcludedMutex.RLock()
+	defer fake.isExcludedMutex.RUnlock()
+	return len(fake.isExcludedArgsForCall)
+}
+
+func (fake *CIDRPool) IsExcludedCalls(stub func(string) bool) {
+	fake.isExcludedMutex.Lock()
+	defer fake.isExcludedMutex.Unlock()
+	fake.IsExcludedStub = stub
+}
+
+func (fake *CIDRPool) IsExcludedArgsForCall(i int) string {
+	fake.isExcludedMutex.RLock()
+	defer fake.isExcludedMutex.RUnlock()
+	argsForCall := fake.isExcludedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *CIDRPool) IsExcludedReturns(result1 bool) {
+	fake.isExcludedMutex.Lock()
+	defer fake.isExcludedMutex.Unlock()
+	fake.IsExcludedStub = nil
+	fake.isExcludedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *CIDRPool) IsExcludedReturnsOnCall(i int, result1 bool) {
+	fake.isExcludedMutex.Lock()
+	defer fake.isExcludedMutex.Unlock()
+	fake.IsExcludedStub = nil
+	if fake.isExcludedReturnsOnCall == nil {
+		fake.isExcludedReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isExcludedReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *CIDRPool) IsMember(arg1 string) bool {
 	fake.isMemberMutex.Lock()
 	ret, specificReturn := fake.isMemberReturnsOnCall[len(fake.isMemberArgsForCall)]
 	fake.isMemberArgsForCall = append(fake.isMemberArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.IsMemberStub
+	fakeReturns := fake.isMemberReturns
 	fake.recordInvocation("IsMember", []interface{}{arg1})
 	fake.isMemberMutex.Unlock()
-	if fake.IsMemberStub != nil {
-		return fake.IsMemberStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	return fake.isMemberReturns.result1
+	return fakeReturns.result1
 }
 
 func (fake *CIDRPool) IsMemberCallCount() int {
@@ -172,13 +489,22 @@ func (fake *CIDRPool) IsMemberCallCount() int {
 	return len(fake.isMemberArgsForCall)
 }
 
+func (fake *CIDRPool) IsMemberCalls(stub func(string) bool) {
+	fake.isMemberMutex.Lock()
+	defer fake.isMemberMutex.Unlock()
+	fake.IsMemberStub = stub
+}
+
 func (fake *CIDRPool) IsMemberArgsForCall(i int) string {
 	fake.isMemberMutex.RLock()
 	defer fake.isMemberMutex.RUnlock()
-	return fake.isMemberArgsForCall[i].arg1
+	argsForCall := fake.isMemberArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *CIDRPool) IsMemberReturns(result1 bool) {
+	fake.isMemberMutex.Lock()
+	defer fake.isMemberMutex.Unlock()
 	fake.IsMemberStub = nil
 	fake.isMemberReturns = struct {
 		result1 bool
@@ -186,6 +512,8 @@ func (fake *CIDRPool) IsMemberReturns(result1 bool) {
 }
 
 func (fake *CIDRPool) IsMemberReturnsOnCall(i int, result1 bool) {
+	fake.isMemberMutex.Lock()
+	defer fake.isMemberMutex.Unlock()
 	fake.IsMemberStub = nil
 	if fake.isMemberReturnsOnCall == nil {
 		fake.isMemberReturnsOnCall = make(map[int]struct {
@@ -197,15 +525,78 @@ func (fake *CIDRPool) IsMemberReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *CIDRPool) SingleIPPoolSize() int {
+	fake.singleIPPoolSizeMutex.Lock()
+	ret, specificReturn := fake.singleIPPoolSizeReturnsOnCall[len(fake.singleIPPoolSizeArgsForCall)]
+	fake.singleIPPoolSizeArgsForCall = append(fake.singleIPPoolSizeArgsForCall, struct {
+	}{})
+	stub := fake.SingleIPPoolSizeStub
+	fakeReturns := fake.singleIPPoolSizeReturns
+	fake.recordInvocation("SingleIPPoolSize", []interface{}{})
+	fake.singleIPPoolSizeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) SingleIPPoolSizeCallCount() int {
+	fake.singleIPPoolSizeMutex.RLock()
+	defer fake.singleIPPoolSizeMutex.RUnlock()
+	return len(fake.singleIPPoolSizeArgsForCall)
+}
+
+func (fake *CIDRPool) SingleIPPoolSizeCalls(stub func() int) {
+	fake.singleIPPoolSizeMutex.Lock()
+	defer fake.singleIPPoolSizeMutex.Unlock()
+	fake.SingleIPPoolSizeStub = stub
+}
+
+func (fake *CIDRPool) SingleIPPoolSizeReturns(result1 int) {
+	fake.singleIPPoolSizeMutex.Lock()
+	defer fake.singleIPPoolSizeMutex.Unlock()
+	fake.SingleIPPoolSizeStub = nil
+	fake.singleIPPoolSizeReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CIDRPool) SingleIPPoolSizeReturnsOnCall(i int, result1 int) {
+	fake.singleIPPoolSizeMutex.Lock()
+	defer fake.singleIPPoolSizeMutex.Unlock()
+	fake.SingleIPPoolSizeStub = nil
+	if fake.singleIPPoolSizeReturnsOnCall == nil {
+		fake.singleIPPoolSizeReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.singleIPPoolSizeReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
 func (fake *CIDRPool) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.blockPoolSizeMutex.RLock()
+	defer fake.blockPoolSizeMutex.RUnlock()
 	fake.getAvailableBlockMutex.RLock()
 	defer fake.getAvailableBlockMutex.RUnlock()
 	fake.getAvailableSingleIPMutex.RLock()
 	defer fake.getAvailableSingleIPMutex.RUnlock()
+	fake.isActiveMutex.RLock()
+	defer fake.isActiveMutex.RUnlock()
+	fake.isBlockMemberMutex.RLock()
+	defer fake.isBlockMemberMutex.RUnlock()
+	fake.isExcludedMutex.RLock()
+	defer fake.isExcludedMutex.RUnlock()
 	fake.isMemberMutex.RLock()
 	defer fake.isMemberMutex.RUnlock()
+	fake.singleIPPoolSizeMutex.RLock()
+	defer fake.singleIPPoolSizeMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
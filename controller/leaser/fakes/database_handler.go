@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
 )
 
 type DatabaseHandler struct {
@@ -19,69 +20,58 @@ type DatabaseHandler struct {
 	addEntryReturnsOnCall map[int]struct {
 		result1 error
 	}
-	DeleteEntryStub        func(string) error
-	deleteEntryMutex       sync.RWMutex
-	deleteEntryArgsForCall []struct {
-		arg1 string
+	AddEventStub        func(controller.LeaseEvent) error
+	addEventMutex       sync.RWMutex
+	addEventArgsForCall []struct {
+		arg1 controller.LeaseEvent
 	}
-	deleteEntryReturns struct {
+	addEventReturns struct {
 		result1 error
 	}
-	deleteEntryReturnsOnCall map[int]struct {
+	addEventReturnsOnCall map[int]struct {
 		result1 error
 	}
-	LeaseForUnderlayIPStub        func(string) (*controller.Lease, error)
-	leaseForUnderlayIPMutex       sync.RWMutex
-	leaseForUnderlayIPArgsForCall []struct {
-		arg1 string
+	AddReservationStub        func(controller.Reservation) error
+	addReservationMutex       sync.RWMutex
+	addReservationArgsForCall []struct {
+		arg1 controller.Reservation
 	}
-	leaseForUnderlayIPReturns struct {
-		result1 *controller.Lease
-		result2 error
+	addReservationReturns struct {
+		result1 error
 	}
-	leaseForUnderlayIPReturnsOnCall map[int]struct {
-		result1 *controller.Lease
-		result2 error
+	addReservationReturnsOnCall map[int]struct {
+		result1 error
 	}
-	LastRenewedAtForUnderlayIPStub        func(string) (int64, error)
-	lastRenewedAtForUnderlayIPMutex       sync.RWMutex
-	lastRenewedAtForUnderlayIPArgsForCall []struct {
-		arg1 string
+	AllStub        func() ([]controller.Lease, error)
+	allMutex       sync.RWMutex
+	allArgsForCall []struct {
 	}
-	lastRenewedAtForUnderlayIPReturns struct {
-		result1 int64
+	allReturns struct {
+		result1 []controller.Lease
 		result2 error
 	}
-	lastRenewedAtForUnderlayIPReturnsOnCall map[int]struct {
-		result1 int64
+	allReturnsOnCall map[int]struct {
+		result1 []controller.Lease
 		result2 error
 	}
-	RenewLeaseForUnderlayIPStub        func(string) error
-	renewLeaseForUnderlayIPMutex       sync.RWMutex
-	renewLeaseForUnderlayIPArgsForCall []struct {
-		arg1 string
-	}
-	renewLeaseForUnderlayIPReturns struct {
-		result1 error
-	}
-	renewLeaseForUnderlayIPReturnsOnCall map[int]struct {
-		result1 error
+	AllActiveStub        func(int) ([]controller.Lease, error)
+	allActiveMutex       sync.RWMutex
+	allActiveArgsForCall []struct {
+		arg1 int
 	}
-	AllStub        func() ([]controller.Lease, error)
-	allMutex       sync.RWMutex
-	allArgsForCall []struct{}
-	allReturns     struct {
+	allActiveReturns struct {
 		result1 []controller.Lease
 		result2 error
 	}
-	allReturnsOnCall map[int]struct {
+	allActiveReturnsOnCall map[int]struct {
 		result1 []controller.Lease
 		result2 error
 	}
 	AllBlockSubnetsStub        func() ([]controller.Lease, error)
 	allBlockSubnetsMutex       sync.RWMutex
-	allBlockSubnetsArgsForCall []struct{}
-	allBlockSubnetsReturns     struct {
+	allBlockSubnetsArgsForCall []struct {
+	}
+	allBlockSubnetsReturns struct {
 		result1 []controller.Lease
 		result2 error
 	}
@@ -89,10 +79,35 @@ type DatabaseHandler struct {
 		result1 []controller.Lease
 		result2 error
 	}
+	AllBlockSubnetsV6Stub        func() ([]controller.Lease, error)
+	allBlockSubnetsV6Mutex       sync.RWMutex
+	allBlockSubnetsV6ArgsForCall []struct {
+	}
+	allBlockSubnetsV6Returns struct {
+		result1 []controller.Lease
+		result2 error
+	}
+	allBlockSubnetsV6ReturnsOnCall map[int]struct {
+		result1 []controller.Lease
+		result2 error
+	}
+	AllReservationsStub        func() ([]controller.Reservation, error)
+	allReservationsMutex       sync.RWMutex
+	allReservationsArgsForCall []struct {
+	}
+	allReservationsReturns struct {
+		result1 []controller.Reservation
+		result2 error
+	}
+	allReservationsReturnsOnCall map[int]struct {
+		result1 []controller.Reservation
+		result2 error
+	}
 	AllSingleIPSubnetsStub        func() ([]controller.Lease, error)
 	allSingleIPSubnetsMutex       sync.RWMutex
-	allSingleIPSubnetsArgsForCall []struct{}
-	allSingleIPSubnetsReturns     struct {
+	allSingleIPSubnetsArgsForCall []struct {
+	}
+	allSingleIPSubnetsReturns struct {
 		result1 []controller.Lease
 		result2 error
 	}
@@ -100,17 +115,89 @@ type DatabaseHandler struct {
 		result1 []controller.Lease
 		result2 error
 	}
-	AllActiveStub        func(int) ([]controller.Lease, error)
-	allActiveMutex       sync.RWMutex
-	allActiveArgsForCall []struct {
+	DeleteEntryStub        func(string) error
+	deleteEntryMutex       sync.RWMutex
+	deleteEntryArgsForCall []struct {
+		arg1 string
+	}
+	deleteEntryReturns struct {
+		result1 error
+	}
+	deleteEntryReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteExpiredEntryStub        func(string, int) error
+	deleteExpiredEntryMutex       sync.RWMutex
+	deleteExpiredEntryArgsForCall []struct {
+		arg1 string
+		arg2 int
+	}
+	deleteExpiredEntryReturns struct {
+		result1 error
+	}
+	deleteExpiredEntryReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteReservationStub        func(string) error
+	deleteReservationMutex       sync.RWMutex
+	deleteReservationArgsForCall []struct {
+		arg1 string
+	}
+	deleteReservationReturns struct {
+		result1 error
+	}
+	deleteReservationReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GenerationStub        func() (int64, error)
+	generationMutex       sync.RWMutex
+	generationArgsForCall []struct {
+	}
+	generationReturns struct {
+		result1 int64
+		result2 error
+	}
+	generationReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
+	LastRenewedAtForUnderlayIPStub        func(string) (int64, error)
+	lastRenewedAtForUnderlayIPMutex       sync.RWMutex
+	lastRenewedAtForUnderlayIPArgsForCall []struct {
+		arg1 string
+	}
+	lastRenewedAtForUnderlayIPReturns struct {
+		result1 int64
+		result2 error
+	}
+	lastRenewedAtForUnderlayIPReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
+	LeaseForUnderlayIPStub        func(string) (*controller.Lease, error)
+	leaseForUnderlayIPMutex       sync.RWMutex
+	leaseForUnderlayIPArgsForCall []struct {
+		arg1 string
+	}
+	leaseForUnderlayIPReturns struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	leaseForUnderlayIPReturnsOnCall map[int]struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	LeaseRecordsStub        func(int) ([]controller.LeaseRecord, error)
+	leaseRecordsMutex       sync.RWMutex
+	leaseRecordsArgsForCall []struct {
 		arg1 int
 	}
-	allActiveReturns struct {
-		result1 []controller.Lease
+	leaseRecordsReturns struct {
+		result1 []controller.LeaseRecord
 		result2 error
 	}
-	allActiveReturnsOnCall map[int]struct {
-		result1 []controller.Lease
+	leaseRecordsReturnsOnCall map[int]struct {
+		result1 []controller.LeaseRecord
 		result2 error
 	}
 	OldestExpiredBlockSubnetStub        func(int) (*controller.Lease, error)
@@ -126,6 +213,19 @@ type DatabaseHandler struct {
 		result1 *controller.Lease
 		result2 error
 	}
+	OldestExpiredBlockSubnetV6Stub        func(int) (*controller.Lease, error)
+	oldestExpiredBlockSubnetV6Mutex       sync.RWMutex
+	oldestExpiredBlockSubnetV6ArgsForCall []struct {
+		arg1 int
+	}
+	oldestExpiredBlockSubnetV6Returns struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	oldestExpiredBlockSubnetV6ReturnsOnCall map[int]struct {
+		result1 *controller.Lease
+		result2 error
+	}
 	OldestExpiredSingleIPStub        func(int) (*controller.Lease, error)
 	oldestExpiredSingleIPMutex       sync.RWMutex
 	oldestExpiredSingleIPArgsForCall []struct {
@@ -139,6 +239,78 @@ type DatabaseHandler struct {
 		result1 *controller.Lease
 		result2 error
 	}
+	QuarantineSubnetStub        func(string, string, int) error
+	quarantineSubnetMutex       sync.RWMutex
+	quarantineSubnetArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 int
+	}
+	quarantineSubnetReturns struct {
+		result1 error
+	}
+	quarantineSubnetReturnsOnCall map[int]struct {
+		result1 error
+	}
+	QuarantinedSubnetsStub        func() ([]controller.QuarantinedSubnet, error)
+	quarantinedSubnetsMutex       sync.RWMutex
+	quarantinedSubnetsArgsForCall []struct {
+	}
+	quarantinedSubnetsReturns struct {
+		result1 []controller.QuarantinedSubnet
+		result2 error
+	}
+	quarantinedSubnetsReturnsOnCall map[int]struct {
+		result1 []controller.QuarantinedSubnet
+		result2 error
+	}
+	RenewLeaseForUnderlayIPStub        func(string) error
+	renewLeaseForUnderlayIPMutex       sync.RWMutex
+	renewLeaseForUnderlayIPArgsForCall []struct {
+		arg1 string
+	}
+	renewLeaseForUnderlayIPReturns struct {
+		result1 error
+	}
+	renewLeaseForUnderlayIPReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ReservationForUnderlayIPStub        func(string) (*controller.Reservation, error)
+	reservationForUnderlayIPMutex       sync.RWMutex
+	reservationForUnderlayIPArgsForCall []struct {
+		arg1 string
+	}
+	reservationForUnderlayIPReturns struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	reservationForUnderlayIPReturnsOnCall map[int]struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	SetHostForUnderlayIPStub        func(string, controller.HostMetadata) error
+	setHostForUnderlayIPMutex       sync.RWMutex
+	setHostForUnderlayIPArgsForCall []struct {
+		arg1 string
+		arg2 controller.HostMetadata
+	}
+	setHostForUnderlayIPReturns struct {
+		result1 error
+	}
+	setHostForUnderlayIPReturnsOnCall map[int]struct {
+		result1 error
+	}
+	WithAllocationLockStub        func(func(database.LeaseStore) error) error
+	withAllocationLockMutex       sync.RWMutex
+	withAllocationLockArgsForCall []struct {
+		arg1 func(database.LeaseStore) error
+	}
+	withAllocationLockReturns struct {
+		result1 error
+	}
+	withAllocationLockReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -149,15 +321,17 @@ func (fake *DatabaseHandler) AddEntry(arg1 controller.Lease) error {
 	fake.addEntryArgsForCall = append(fake.addEntryArgsForCall, struct {
 		arg1 controller.Lease
 	}{arg1})
+	stub := fake.AddEntryStub
+	fakeReturns := fake.addEntryReturns
 	fake.recordInvocation("AddEntry", []interface{}{arg1})
 	fake.addEntryMutex.Unlock()
-	if fake.AddEntryStub != nil {
-		return fake.AddEntryStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	return fake.addEntryReturns.result1
+	return fakeReturns.result1
 }
 
 func (fake *DatabaseHandler) AddEntryCallCount() int {
@@ -166,13 +340,22 @@ func (fake *DatabaseHandler) AddEntryCallCount() int {
 	return len(fake.addEntryArgsForCall)
 }
 
+func (fake *DatabaseHandler) AddEntryCalls(stub func(controller.Lease) error) {
+	fake.addEntryMutex.Lock()
+	defer fake.addEntryMutex.Unlock()
+	fake.AddEntryStub = stub
+}
+
 func (fake *DatabaseHandler) AddEntryArgsForCall(i int) controller.Lease {
 	fake.addEntryMutex.RLock()
 	defer fake.addEntryMutex.RUnlock()
-	return fake.addEntryArgsForCall[i].arg1
+	argsForCall := fake.addEntryArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *DatabaseHandler) AddEntryReturns(result1 error) {
+	fake.addEntryMutex.Lock()
+	defer fake.addEntryMutex.Unlock()
 	fake.AddEntryStub = nil
 	fake.addEntryReturns = struct {
 		result1 error
@@ -180,6 +363,8 @@ func (fake *DatabaseHandler) AddEntryReturns(result1 error) {
 }
 
 func (fake *DatabaseHandler) AddEntryReturnsOnCall(i int, result1 error) {
+	fake.addEntryMutex.Lock()
+	defer fake.addEntryMutex.Unlock()
 	fake.AddEntryStub = nil
 	if fake.addEntryReturnsOnCall == nil {
 		fake.addEntryReturnsOnCall = make(map[int]struct {
@@ -191,242 +376,243 @@ func (fake *DatabaseHandler) AddEntryReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *DatabaseHandler) DeleteEntry(arg1 string) error {
-	fake.deleteEntryMutex.Lock()
-	ret, specificReturn := fake.deleteEntryReturnsOnCall[len(fake.deleteEntryArgsForCall)]
-	fake.deleteEntryArgsForCall = append(fake.deleteEntryArgsForCall, struct {
-		arg1 string
+func (fake *DatabaseHandler) AddEvent(arg1 controller.LeaseEvent) error {
+	fake.addEventMutex.Lock()
+	ret, specificReturn := fake.addEventReturnsOnCall[len(fake.addEventArgsForCall)]
+	fake.addEventArgsForCall = append(fake.addEventArgsForCall, struct {
+		arg1 controller.LeaseEvent
 	}{arg1})
-	fake.recordInvocation("DeleteEntry", []interface{}{arg1})
-	fake.deleteEntryMutex.Unlock()
-	if fake.DeleteEntryStub != nil {
-		return fake.DeleteEntryStub(arg1)
+	stub := fake.AddEventStub
+	fakeReturns := fake.addEventReturns
+	fake.recordInvocation("AddEvent", []interface{}{arg1})
+	fake.addEventMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	return fake.deleteEntryReturns.result1
+	return fakeReturns.result1
 }
 
-func (fake *DatabaseHandler) DeleteEntryCallCount() int {
-	fake.deleteEntryMutex.RLock()
-	defer fake.deleteEntryMutex.RUnlock()
-	return len(fake.deleteEntryArgsForCall)
+func (fake *DatabaseHandler) AddEventCallCount() int {
+	fake.addEventMutex.RLock()
+	defer fake.addEventMutex.RUnlock()
+	return len(fake.addEventArgsForCall)
 }
 
-func (fake *DatabaseHandler) DeleteEntryArgsForCall(i int) string {
-	fake.deleteEntryMutex.RLock()
-	defer fake.deleteEntryMutex.RUnlock()
-	return fake.deleteEntryArgsForCall[i].arg1
+func (fake *DatabaseHandler) AddEventCalls(stub func(controller.LeaseEvent) error) {
+	fake.addEventMutex.Lock()
+	defer fake.addEventMutex.Unlock()
+	fake.AddEventStub = stub
 }
 
-func (fake *DatabaseHandler) DeleteEntryReturns(result1 error) {
-	fake.DeleteEntryStub = nil
-	fake.deleteEntryReturns = struct {
+func (fake *DatabaseHandler) AddEventArgsForCall(i int) controller.LeaseEvent {
+	fake.addEventMutex.RLock()
+	defer fake.addEventMutex.RUnlock()
+	argsForCall := fake.addEventArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) AddEventReturns(result1 error) {
+	fake.addEventMutex.Lock()
+	defer fake.addEventMutex.Unlock()
+	fake.AddEventStub = nil
+	fake.addEventReturns = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *DatabaseHandler) DeleteEntryReturnsOnCall(i int, result1 error) {
-	fake.DeleteEntryStub = nil
-	if fake.deleteEntryReturnsOnCall == nil {
-		fake.deleteEntryReturnsOnCall = make(map[int]struct {
+func (fake *DatabaseHandler) AddEventReturnsOnCall(i int, result1 error) {
+	fake.addEventMutex.Lock()
+	defer fake.addEventMutex.Unlock()
+	fake.AddEventStub = nil
+	if fake.addEventReturnsOnCall == nil {
+		fake.addEventReturnsOnCall = make(map[int]struct {
 			result1 error
 		})
 	}
-	fake.deleteEntryReturnsOnCall[i] = struct {
+	fake.addEventReturnsOnCall[i] = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIP(arg1 string) (*controller.Lease, error) {
-	fake.leaseForUnderlayIPMutex.Lock()
-	ret, specificReturn := fake.leaseForUnderlayIPReturnsOnCall[len(fake.leaseForUnderlayIPArgsForCall)]
-	fake.leaseForUnderlayIPArgsForCall = append(fake.leaseForUnderlayIPArgsForCall, struct {
-		arg1 string
+func (fake *DatabaseHandler) AddReservation(arg1 controller.Reservation) error {
+	fake.addReservationMutex.Lock()
+	ret, specificReturn := fake.addReservationReturnsOnCall[len(fake.addReservationArgsForCall)]
+	fake.addReservationArgsForCall = append(fake.addReservationArgsForCall, struct {
+		arg1 controller.Reservation
 	}{arg1})
-	fake.recordInvocation("LeaseForUnderlayIP", []interface{}{arg1})
-	fake.leaseForUnderlayIPMutex.Unlock()
-	if fake.LeaseForUnderlayIPStub != nil {
-		return fake.LeaseForUnderlayIPStub(arg1)
+	stub := fake.AddReservationStub
+	fakeReturns := fake.addReservationReturns
+	fake.recordInvocation("AddReservation", []interface{}{arg1})
+	fake.addReservationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1
 	}
-	return fake.leaseForUnderlayIPReturns.result1, fake.leaseForUnderlayIPReturns.result2
+	return fakeReturns.result1
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIPCallCount() int {
-	fake.leaseForUnderlayIPMutex.RLock()
-	defer fake.leaseForUnderlayIPMutex.RUnlock()
-	return len(fake.leaseForUnderlayIPArgsForCall)
+func (fake *DatabaseHandler) AddReservationCallCount() int {
+	fake.addReservationMutex.RLock()
+	defer fake.addReservationMutex.RUnlock()
+	return len(fake.addReservationArgsForCall)
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIPArgsForCall(i int) string {
-	fake.leaseForUnderlayIPMutex.RLock()
-	defer fake.leaseForUnderlayIPMutex.RUnlock()
-	return fake.leaseForUnderlayIPArgsForCall[i].arg1
+func (fake *DatabaseHandler) AddReservationCalls(stub func(controller.Reservation) error) {
+	fake.addReservationMutex.Lock()
+	defer fake.addReservationMutex.Unlock()
+	fake.AddReservationStub = stub
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIPReturns(result1 *controller.Lease, result2 error) {
-	fake.LeaseForUnderlayIPStub = nil
-	fake.leaseForUnderlayIPReturns = struct {
-		result1 *controller.Lease
-		result2 error
-	}{result1, result2}
+func (fake *DatabaseHandler) AddReservationArgsForCall(i int) controller.Reservation {
+	fake.addReservationMutex.RLock()
+	defer fake.addReservationMutex.RUnlock()
+	argsForCall := fake.addReservationArgsForCall[i]
+	return argsForCall.arg1
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIPReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
-	fake.LeaseForUnderlayIPStub = nil
-	if fake.leaseForUnderlayIPReturnsOnCall == nil {
-		fake.leaseForUnderlayIPReturnsOnCall = make(map[int]struct {
-			result1 *controller.Lease
-			result2 error
+func (fake *DatabaseHandler) AddReservationReturns(result1 error) {
+	fake.addReservationMutex.Lock()
+	defer fake.addReservationMutex.Unlock()
+	fake.AddReservationStub = nil
+	fake.addReservationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) AddReservationReturnsOnCall(i int, result1 error) {
+	fake.addReservationMutex.Lock()
+	defer fake.addReservationMutex.Unlock()
+	fake.AddReservationStub = nil
+	if fake.addReservationReturnsOnCall == nil {
+		fake.addReservationReturnsOnCall = make(map[int]struct {
+			result1 error
 		})
 	}
-	fake.leaseForUnderlayIPReturnsOnCall[i] = struct {
-		result1 *controller.Lease
-		result2 error
-	}{result1, result2}
+	fake.addReservationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
 }
 
-func (fake *DatabaseHandler) LastRenewedAtForUnderlayIP(arg1 string) (int64, error) {
-	fake.lastRenewedAtForUnderlayIPMutex.Lock()
-	ret, specificReturn := fake.lastRenewedAtForUnderlayIPReturnsOnCall[len(fake.lastRenewedAtForUnderlayIPArgsForCall)]
-	fake.lastRenewedAtForUnderlayIPArgsForCall = append(fake.lastRenewedAtForUnderlayIPArgsForCall, struct {
-		arg1 string
-	}{arg1})
-	fake.recordInvocation("LastRenewedAtForUnderlayIP", []interface{}{arg1})
-	fake.lastRenewedAtForUnderlayIPMutex.Unlock()
-	if fake.LastRenewedAtForUnderlayIPStub != nil {
-		return fake.LastRenewedAtForUnderlayIPStub(arg1)
+func (fake *DatabaseHandler) All() ([]controller.Lease, error) {
+	fake.allMutex.Lock()
+	ret, specificReturn := fake.allReturnsOnCall[len(fake.allArgsForCall)]
+	fake.allArgsForCall = append(fake.allArgsForCall, struct {
+	}{})
+	stub := fake.AllStub
+	fakeReturns := fake.allReturns
+	fake.recordInvocation("All", []interface{}{})
+	fake.allMutex.Unlock()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.lastRenewedAtForUnderlayIPReturns.result1, fake.lastRenewedAtForUnderlayIPReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPCallCount() int {
-	fake.lastRenewedAtForUnderlayIPMutex.RLock()
-	defer fake.lastRenewedAtForUnderlayIPMutex.RUnlock()
-	return len(fake.lastRenewedAtForUnderlayIPArgsForCall)
+func (fake *DatabaseHandler) AllCallCount() int {
+	fake.allMutex.RLock()
+	defer fake.allMutex.RUnlock()
+	return len(fake.allArgsForCall)
 }
 
-func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPArgsForCall(i int) string {
-	fake.lastRenewedAtForUnderlayIPMutex.RLock()
-	defer fake.lastRenewedAtForUnderlayIPMutex.RUnlock()
-	return fake.lastRenewedAtForUnderlayIPArgsForCall[i].arg1
+func (fake *DatabaseHandler) AllCalls(stub func() ([]controller.Lease, error)) {
+	fake.allMutex.Lock()
+	defer fake.allMutex.Unlock()
+	fake.AllStub = stub
 }
 
-func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPReturns(result1 int64, result2 error) {
-	fake.LastRenewedAtForUnderlayIPStub = nil
-	fake.lastRenewedAtForUnderlayIPReturns = struct {
-		result1 int64
+func (fake *DatabaseHandler) AllReturns(result1 []controller.Lease, result2 error) {
+	fake.allMutex.Lock()
+	defer fake.allMutex.Unlock()
+	fake.AllStub = nil
+	fake.allReturns = struct {
+		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPReturnsOnCall(i int, result1 int64, result2 error) {
-	fake.LastRenewedAtForUnderlayIPStub = nil
-	if fake.lastRenewedAtForUnderlayIPReturnsOnCall == nil {
-		fake.lastRenewedAtForUnderlayIPReturnsOnCall = make(map[int]struct {
-			result1 int64
+func (fake *DatabaseHandler) AllReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
+	fake.allMutex.Lock()
+	defer fake.allMutex.Unlock()
+	fake.AllStub = nil
+	if fake.allReturnsOnCall == nil {
+		fake.allReturnsOnCall = make(map[int]struct {
+			result1 []controller.Lease
 			result2 error
 		})
 	}
-	fake.lastRenewedAtForUnderlayIPReturnsOnCall[i] = struct {
-		result1 int64
+	fake.allReturnsOnCall[i] = struct {
+		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) RenewLeaseForUnderlayIP(arg1 string) error {
-	fake.renewLeaseForUnderlayIPMutex.Lock()
-	ret, specificReturn := fake.renewLeaseForUnderlayIPReturnsOnCall[len(fake.renewLeaseForUnderlayIPArgsForCall)]
-	fake.renewLeaseForUnderlayIPArgsForCall = append(fake.renewLeaseForUnderlayIPArgsForCall, struct {
-		arg1 string
+func (fake *DatabaseHandler) AllActive(arg1 int) ([]controller.Lease, error) {
+	fake.allActiveMutex.Lock()
+	ret, specificReturn := fake.allActiveReturnsOnCall[len(fake.allActiveArgsForCall)]
+	fake.allActiveArgsForCall = append(fake.allActiveArgsForCall, struct {
+		arg1 int
 	}{arg1})
-	fake.recordInvocation("RenewLeaseForUnderlayIP", []interface{}{arg1})
-	fake.renewLeaseForUnderlayIPMutex.Unlock()
-	if fake.RenewLeaseForUnderlayIPStub != nil {
-		return fake.RenewLeaseForUnderlayIPStub(arg1)
+	stub := fake.AllActiveStub
+	fakeReturns := fake.allActiveReturns
+	fake.recordInvocation("AllActive", []interface{}{arg1})
+	fake.allActiveMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	return fake.renewLeaseForUnderlayIPReturns.result1
-}
-
-func (fake *DatabaseHandler) RenewLeaseForUnderlayIPCallCount() int {
-	fake.renewLeaseForUnderlayIPMutex.RLock()
-	defer fake.renewLeaseForUnderlayIPMutex.RUnlock()
-	return len(fake.renewLeaseForUnderlayIPArgsForCall)
-}
-
-func (fake *DatabaseHandler) RenewLeaseForUnderlayIPArgsForCall(i int) string {
-	fake.renewLeaseForUnderlayIPMutex.RLock()
-	defer fake.renewLeaseForUnderlayIPMutex.RUnlock()
-	return fake.renewLeaseForUnderlayIPArgsForCall[i].arg1
-}
-
-func (fake *DatabaseHandler) RenewLeaseForUnderlayIPReturns(result1 error) {
-	fake.RenewLeaseForUnderlayIPStub = nil
-	fake.renewLeaseForUnderlayIPReturns = struct {
-		result1 error
-	}{result1}
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *DatabaseHandler) RenewLeaseForUnderlayIPReturnsOnCall(i int, result1 error) {
-	fake.RenewLeaseForUnderlayIPStub = nil
-	if fake.renewLeaseForUnderlayIPReturnsOnCall == nil {
-		fake.renewLeaseForUnderlayIPReturnsOnCall = make(map[int]struct {
-			result1 error
-		})
-	}
-	fake.renewLeaseForUnderlayIPReturnsOnCall[i] = struct {
-		result1 error
-	}{result1}
+func (fake *DatabaseHandler) AllActiveCallCount() int {
+	fake.allActiveMutex.RLock()
+	defer fake.allActiveMutex.RUnlock()
+	return len(fake.allActiveArgsForCall)
 }
 
-func (fake *DatabaseHandler) All() ([]controller.Lease, error) {
-	fake.allMutex.Lock()
-	ret, specificReturn := fake.allReturnsOnCall[len(fake.allArgsForCall)]
-	fake.allArgsForCall = append(fake.allArgsForCall, struct{}{})
-	fake.recordInvocation("All", []interface{}{})
-	fake.allMutex.Unlock()
-	if fake.AllStub != nil {
-		return fake.AllStub()
-	}
-	if specificReturn {
-		return ret.result1, ret.result2
-	}
-	return fake.allReturns.result1, fake.allReturns.result2
+func (fake *DatabaseHandler) AllActiveCalls(stub func(int) ([]controller.Lease, error)) {
+	fake.allActiveMutex.Lock()
+	defer fake.allActiveMutex.Unlock()
+	fake.AllActiveStub = stub
 }
 
-func (fake *DatabaseHandler) AllCallCount() int {
-	fake.allMutex.RLock()
-	defer fake.allMutex.RUnlock()
-	return len(fake.allArgsForCall)
+func (fake *DatabaseHandler) AllActiveArgsForCall(i int) int {
+	fake.allActiveMutex.RLock()
+	defer fake.allActiveMutex.RUnlock()
+	argsForCall := fake.allActiveArgsForCall[i]
+	return argsForCall.arg1
 }
 
-func (fake *DatabaseHandler) AllReturns(result1 []controller.Lease, result2 error) {
-	fake.AllStub = nil
-	fake.allReturns = struct {
+func (fake *DatabaseHandler) AllActiveReturns(result1 []controller.Lease, result2 error) {
+	fake.allActiveMutex.Lock()
+	defer fake.allActiveMutex.Unlock()
+	fake.AllActiveStub = nil
+	fake.allActiveReturns = struct {
 		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) AllReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
-	fake.AllStub = nil
-	if fake.allReturnsOnCall == nil {
-		fake.allReturnsOnCall = make(map[int]struct {
+func (fake *DatabaseHandler) AllActiveReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
+	fake.allActiveMutex.Lock()
+	defer fake.allActiveMutex.Unlock()
+	fake.AllActiveStub = nil
+	if fake.allActiveReturnsOnCall == nil {
+		fake.allActiveReturnsOnCall = make(map[int]struct {
 			result1 []controller.Lease
 			result2 error
 		})
 	}
-	fake.allReturnsOnCall[i] = struct {
+	fake.allActiveReturnsOnCall[i] = struct {
 		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
@@ -435,16 +621,19 @@ func (fake *DatabaseHandler) AllReturnsOnCall(i int, result1 []controller.Lease,
 func (fake *DatabaseHandler) AllBlockSubnets() ([]controller.Lease, error) {
 	fake.allBlockSubnetsMutex.Lock()
 	ret, specificReturn := fake.allBlockSubnetsReturnsOnCall[len(fake.allBlockSubnetsArgsForCall)]
-	fake.allBlockSubnetsArgsForCall = append(fake.allBlockSubnetsArgsForCall, struct{}{})
+	fake.allBlockSubnetsArgsForCall = append(fake.allBlockSubnetsArgsForCall, struct {
+	}{})
+	stub := fake.AllBlockSubnetsStub
+	fakeReturns := fake.allBlockSubnetsReturns
 	fake.recordInvocation("AllBlockSubnets", []interface{}{})
 	fake.allBlockSubnetsMutex.Unlock()
-	if fake.AllBlockSubnetsStub != nil {
-		return fake.AllBlockSubnetsStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.allBlockSubnetsReturns.result1, fake.allBlockSubnetsReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *DatabaseHandler) AllBlockSubnetsCallCount() int {
@@ -453,7 +642,15 @@ func (fake *DatabaseHandler) AllBlockSubnetsCallCount() int {
 	return len(fake.allBlockSubnetsArgsForCall)
 }
 
+func (fake *DatabaseHandler) AllBlockSubnetsCalls(stub func() ([]controller.Lease, error)) {
+	fake.allBlockSubnetsMutex.Lock()
+	defer fake.allBlockSubnetsMutex.Unlock()
+	fake.AllBlockSubnetsStub = stub
+}
+
 func (fake *DatabaseHandler) AllBlockSubnetsReturns(result1 []controller.Lease, result2 error) {
+	fake.allBlockSubnetsMutex.Lock()
+	defer fake.allBlockSubnetsMutex.Unlock()
 	fake.AllBlockSubnetsStub = nil
 	fake.allBlockSubnetsReturns = struct {
 		result1 []controller.Lease
@@ -462,6 +659,8 @@ func (fake *DatabaseHandler) AllBlockSubnetsReturns(result1 []controller.Lease,
 }
 
 func (fake *DatabaseHandler) AllBlockSubnetsReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
+	fake.allBlockSubnetsMutex.Lock()
+	defer fake.allBlockSubnetsMutex.Unlock()
 	fake.AllBlockSubnetsStub = nil
 	if fake.allBlockSubnetsReturnsOnCall == nil {
 		fake.allBlockSubnetsReturnsOnCall = make(map[int]struct {
@@ -475,190 +674,786 @@ func (fake *DatabaseHandler) AllBlockSubnetsReturnsOnCall(i int, result1 []contr
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) AllSingleIPSubnets() ([]controller.Lease, error) {
-	fake.allSingleIPSubnetsMutex.Lock()
-	ret, specificReturn := fake.allSingleIPSubnetsReturnsOnCall[len(fake.allSingleIPSubnetsArgsForCall)]
-	fake.allSingleIPSubnetsArgsForCall = append(fake.allSingleIPSubnetsArgsForCall, struct{}{})
-	fake.recordInvocation("AllSingleIPSubnets", []interface{}{})
-	fake.allSingleIPSubnetsMutex.Unlock()
-	if fake.AllSingleIPSubnetsStub != nil {
-		return fake.AllSingleIPSubnetsStub()
+func (fake *DatabaseHandler) AllBlockSubnetsV6() ([]controller.Lease, error) {
+	fake.allBlockSubnetsV6Mutex.Lock()
+	ret, specificReturn := fake.allBlockSubnetsV6ReturnsOnCall[len(fake.allBlockSubnetsV6ArgsForCall)]
+	fake.allBlockSubnetsV6ArgsForCall = append(fake.allBlockSubnetsV6ArgsForCall, struct {
+	}{})
+	stub := fake.AllBlockSubnetsV6Stub
+	fakeReturns := fake.allBlockSubnetsV6Returns
+	fake.recordInvocation("AllBlockSubnetsV6", []interface{}{})
+	fake.allBlockSubnetsV6Mutex.Unlock()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.allSingleIPSubnetsReturns.result1, fake.allSingleIPSubnetsReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *DatabaseHandler) AllSingleIPSubnetsCallCount() int {
-	fake.allSingleIPSubnetsMutex.RLock()
-	defer fake.allSingleIPSubnetsMutex.RUnlock()
-	return len(fake.allSingleIPSubnetsArgsForCall)
+func (fake *DatabaseHandler) AllBlockSubnetsV6CallCount() int {
+	fake.allBlockSubnetsV6Mutex.RLock()
+	defer fake.allBlockSubnetsV6Mutex.RUnlock()
+	return len(fake.allBlockSubnetsV6ArgsForCall)
 }
 
-func (fake *DatabaseHandler) AllSingleIPSubnetsReturns(result1 []controller.Lease, result2 error) {
-	fake.AllSingleIPSubnetsStub = nil
-	fake.allSingleIPSubnetsReturns = struct {
+func (fake *DatabaseHandler) AllBlockSubnetsV6Calls(stub func() ([]controller.Lease, error)) {
+	fake.allBlockSubnetsV6Mutex.Lock()
+	defer fake.allBlockSubnetsV6Mutex.Unlock()
+	fake.AllBlockSubnetsV6Stub = stub
+}
+
+func (fake *DatabaseHandler) AllBlockSubnetsV6Returns(result1 []controller.Lease, result2 error) {
+	fake.allBlockSubnetsV6Mutex.Lock()
+	defer fake.allBlockSubnetsV6Mutex.Unlock()
+	fake.AllBlockSubnetsV6Stub = nil
+	fake.allBlockSubnetsV6Returns = struct {
 		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) AllSingleIPSubnetsReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
-	fake.AllSingleIPSubnetsStub = nil
-	if fake.allSingleIPSubnetsReturnsOnCall == nil {
-		fake.allSingleIPSubnetsReturnsOnCall = make(map[int]struct {
+func (fake *DatabaseHandler) AllBlockSubnetsV6ReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
+	fake.allBlockSubnetsV6Mutex.Lock()
+	defer fake.allBlockSubnetsV6Mutex.Unlock()
+	fake.AllBlockSubnetsV6Stub = nil
+	if fake.allBlockSubnetsV6ReturnsOnCall == nil {
+		fake.allBlockSubnetsV6ReturnsOnCall = make(map[int]struct {
 			result1 []controller.Lease
 			result2 error
 		})
 	}
-	fake.allSingleIPSubnetsReturnsOnCall[i] = struct {
+	fake.allBlockSubnetsV6ReturnsOnCall[i] = struct {
 		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) AllActive(arg1 int) ([]controller.Lease, error) {
-	fake.allActiveMutex.Lock()
-	ret, specificReturn := fake.allActiveReturnsOnCall[len(fake.allActiveArgsForCall)]
-	fake.allActiveArgsForCall = append(fake.allActiveArgsForCall, struct {
-		arg1 int
-	}{arg1})
-	fake.recordInvocation("AllActive", []interface{}{arg1})
-	fake.allActiveMutex.Unlock()
-	if fake.AllActiveStub != nil {
-		return fake.AllActiveStub(arg1)
+func (fake *DatabaseHandler) AllReservations() ([]controller.Reservation, error) {
+	fake.allReservationsMutex.Lock()
+	ret, specificReturn := fake.allReservationsReturnsOnCall[len(fake.allReservationsArgsForCall)]
+	fake.allReservationsArgsForCall = append(fake.allReservationsArgsForCall, struct {
+	}{})
+	stub := fake.AllReservationsStub
+	fakeReturns := fake.allReservationsReturns
+	fake.recordInvocation("AllReservations", []interface{}{})
+	fake.allReservationsMutex.Unlock()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.allActiveReturns.result1, fake.allActiveReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *DatabaseHandler) AllActiveCallCount() int {
-	fake.allActiveMutex.RLock()
-	defer fake.allActiveMutex.RUnlock()
-	return len(fake.allActiveArgsForCall)
+func (fake *DatabaseHandler) AllReservationsCallCount() int {
+	fake.allReservationsMutex.RLock()
+	defer fake.allReservationsMutex.RUnlock()
+	return len(fake.allReservationsArgsForCall)
 }
 
-func (fake *DatabaseHandler) AllActiveArgsForCall(i int) int {
-	fake.allActiveMutex.RLock()
-	defer fake.allActiveMutex.RUnlock()
-	return fake.allActiveArgsForCall[i].arg1
+func (fake *DatabaseHandler) AllReservationsCalls(stub func() ([]controller.Reservation, error)) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = stub
 }
 
-func (fake *DatabaseHandler) AllActiveReturns(result1 []controller.Lease, result2 error) {
-	fake.AllActiveStub = nil
-	fake.allActiveReturns = struct {
-		result1 []controller.Lease
+func (fake *DatabaseHandler) AllReservationsReturns(result1 []controller.Reservation, result2 error) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = nil
+	fake.allReservationsReturns = struct {
+		result1 []controller.Reservation
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) AllActiveReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
-	fake.AllActiveStub = nil
-	if fake.allActiveReturnsOnCall == nil {
-		fake.allActiveReturnsOnCall = make(map[int]struct {
-			result1 []controller.Lease
+func (fake *DatabaseHandler) AllReservationsReturnsOnCall(i int, result1 []controller.Reservation, result2 error) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = nil
+	if fake.allReservationsReturnsOnCall == nil {
+		fake.allReservationsReturnsOnCall = make(map[int]struct {
+			result1 []controller.Reservation
 			result2 error
 		})
 	}
-	fake.allActiveReturnsOnCall[i] = struct {
-		result1 []controller.Lease
+	fake.allReservationsReturnsOnCall[i] = struct {
+		result1 []controller.Reservation
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnet(arg1 int) (*controller.Lease, error) {
-	fake.oldestExpiredBlockSubnetMutex.Lock()
-	ret, specificReturn := fake.oldestExpiredBlockSubnetReturnsOnCall[len(fake.oldestExpiredBlockSubnetArgsForCall)]
-	fake.oldestExpiredBlockSubnetArgsForCall = append(fake.oldestExpiredBlockSubnetArgsForCall, struct {
-		arg1 int
-	}{arg1})
-	fake.recordInvocation("OldestExpiredBlockSubnet", []interface{}{arg1})
-	fake.oldestExpiredBlockSubnetMutex.Unlock()
-	if fake.OldestExpiredBlockSubnetStub != nil {
-		return fake.OldestExpiredBlockSubnetStub(arg1)
+func (fake *DatabaseHandler) AllSingleIPSubnets() ([]controller.Lease, error) {
+	fake.allSingleIPSubnetsMutex.Lock()
+	ret, specificReturn := fake.allSingleIPSubnetsReturnsOnCall[len(fake.allSingleIPSubnetsArgsForCall)]
+	fake.allSingleIPSubnetsArgsForCall = append(fake.allSingleIPSubnetsArgsForCall, struct {
+	}{})
+	stub := fake.AllSingleIPSubnetsStub
+	fakeReturns := fake.allSingleIPSubnetsReturns
+	fake.recordInvocation("AllSingleIPSubnets", []interface{}{})
+	fake.allSingleIPSubnetsMutex.Unlock()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.oldestExpiredBlockSubnetReturns.result1, fake.oldestExpiredBlockSubnetReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnetCallCount() int {
-	fake.oldestExpiredBlockSubnetMutex.RLock()
-	defer fake.oldestExpiredBlockSubnetMutex.RUnlock()
-	return len(fake.oldestExpiredBlockSubnetArgsForCall)
+func (fake *DatabaseHandler) AllSingleIPSubnetsCallCount() int {
+	fake.allSingleIPSubnetsMutex.RLock()
+	defer fake.allSingleIPSubnetsMutex.RUnlock()
+	return len(fake.allSingleIPSubnetsArgsForCall)
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnetArgsForCall(i int) int {
-	fake.oldestExpiredBlockSubnetMutex.RLock()
-	defer fake.oldestExpiredBlockSubnetMutex.RUnlock()
-	return fake.oldestExpiredBlockSubnetArgsForCall[i].arg1
+func (fake *DatabaseHandler) AllSingleIPSubnetsCalls(stub func() ([]controller.Lease, error)) {
+	fake.allSingleIPSubnetsMutex.Lock()
+	defer fake.allSingleIPSubnetsMutex.Unlock()
+	fake.AllSingleIPSubnetsStub = stub
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnetReturns(result1 *controller.Lease, result2 error) {
-	fake.OldestExpiredBlockSubnetStub = nil
-	fake.oldestExpiredBlockSubnetReturns = struct {
-		result1 *controller.Lease
+func (fake *DatabaseHandler) AllSingleIPSubnetsReturns(result1 []controller.Lease, result2 error) {
+	fake.allSingleIPSubnetsMutex.Lock()
+	defer fake.allSingleIPSubnetsMutex.Unlock()
+	fake.AllSingleIPSubnetsStub = nil
+	fake.allSingleIPSubnetsReturns = struct {
+		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnetReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
-	fake.OldestExpiredBlockSubnetStub = nil
-	if fake.oldestExpiredBlockSubnetReturnsOnCall == nil {
-		fake.oldestExpiredBlockSubnetReturnsOnCall = make(map[int]struct {
-			result1 *controller.Lease
+func (fake *DatabaseHandler) AllSingleIPSubnetsReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
+	fake.allSingleIPSubnetsMutex.Lock()
+	defer fake.allSingleIPSubnetsMutex.Unlock()
+	fake.AllSingleIPSubnetsStub = nil
+	if fake.allSingleIPSubnetsReturnsOnCall == nil {
+		fake.allSingleIPSubnetsReturnsOnCall = make(map[int]struct {
+			result1 []controller.Lease
 			result2 error
 		})
 	}
-	fake.oldestExpiredBlockSubnetReturnsOnCall[i] = struct {
-		result1 *controller.Lease
+	fake.allSingleIPSubnetsReturnsOnCall[i] = struct {
+		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIP(arg1 int) (*controller.Lease, error) {
-	fake.oldestExpiredSingleIPMutex.Lock()
-	ret, specificReturn := fake.oldestExpiredSingleIPReturnsOnCall[len(fake.oldestExpiredSingleIPArgsForCall)]
-	fake.oldestExpiredSingleIPArgsForCall = append(fake.oldestExpiredSingleIPArgsForCall, struct {
-		arg1 int
+func (fake *DatabaseHandler) DeleteEntry(arg1 string) error {
+	fake.deleteEntryMutex.Lock()
+	ret, specificReturn := fake.deleteEntryReturnsOnCall[len(fake.deleteEntryArgsForCall)]
+	fake.deleteEntryArgsForCall = append(fake.deleteEntryArgsForCall, struct {
+		arg1 string
 	}{arg1})
-	fake.recordInvocation("OldestExpiredSingleIP", []interface{}{arg1})
-	fake.oldestExpiredSingleIPMutex.Unlock()
-	if fake.OldestExpiredSingleIPStub != nil {
-		return fake.OldestExpiredSingleIPStub(arg1)
+	stub := fake.DeleteEntryStub
+	fakeReturns := fake.deleteEntryReturns
+	fake.recordInvocation("DeleteEntry", []interface{}{arg1})
+	fake.deleteEntryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1
 	}
-	return fake.oldestExpiredSingleIPReturns.result1, fake.oldestExpiredSingleIPReturns.result2
+	return fakeReturns.result1
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIPCallCount() int {
-	fake.oldestExpiredSingleIPMutex.RLock()
-	defer fake.oldestExpiredSingleIPMutex.RUnlock()
-	return len(fake.oldestExpiredSingleIPArgsForCall)
+func (fake *DatabaseHandler) DeleteEntryCallCount() int {
+	fake.deleteEntryMutex.RLock()
+	defer fake.deleteEntryMutex.RUnlock()
+	return len(fake.deleteEntryArgsForCall)
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIPArgsForCall(i int) int {
-	fake.oldestExpiredSingleIPMutex.RLock()
-	defer fake.oldestExpiredSingleIPMutex.RUnlock()
-	return fake.oldestExpiredSingleIPArgsForCall[i].arg1
+func (fake *DatabaseHandler) DeleteEntryCalls(stub func(string) error) {
+	fake.deleteEntryMutex.Lock()
+	defer fake.deleteEntryMutex.Unlock()
+	fake.DeleteEntryStub = stub
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIPReturns(result1 *controller.Lease, result2 error) {
-	fake.OldestExpiredSingleIPStub = nil
-	fake.oldestExpiredSingleIPReturns = struct {
-		result1 *controller.Lease
-		result2 error
-	}{result1, result2}
+func (fake *DatabaseHandler) DeleteEntryArgsForCall(i int) string {
+	fake.deleteEntryMutex.RLock()
+	defer fake.deleteEntryMutex.RUnlock()
+	argsForCall := fake.deleteEntryArgsForCall[i]
+	return argsForCall.arg1
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIPReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
-	fake.OldestExpiredSingleIPStub = nil
+func (fake *DatabaseHandler) DeleteEntryReturns(result1 error) {
+	fake.deleteEntryMutex.Lock()
+	defer fake.deleteEntryMutex.Unlock()
+	fake.DeleteEntryStub = nil
+	fake.deleteEntryReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) DeleteEntryReturnsOnCall(i int, result1 error) {
+	fake.deleteEntryMutex.Lock()
+	defer fake.deleteEntryMutex.Unlock()
+	fake.DeleteEntryStub = nil
+	if fake.deleteEntryReturnsOnCall == nil {
+		fake.deleteEntryReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteEntryReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) DeleteExpiredEntry(arg1 string, arg2 int) error {
+	fake.deleteExpiredEntryMutex.Lock()
+	ret, specificReturn := fake.deleteExpiredEntryReturnsOnCall[len(fake.deleteExpiredEntryArgsForCall)]
+	fake.deleteExpiredEntryArgsForCall = append(fake.deleteExpiredEntryArgsForCall, struct {
+		arg1 string
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.DeleteExpiredEntryStub
+	fakeReturns := fake.deleteExpiredEntryReturns
+	fake.recordInvocation("DeleteExpiredEntry", []interface{}{arg1, arg2})
+	fake.deleteExpiredEntryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) DeleteExpiredEntryCallCount() int {
+	fake.deleteExpiredEntryMutex.RLock()
+	defer fake.deleteExpiredEntryMutex.RUnlock()
+	return len(fake.deleteExpiredEntryArgsForCall)
+}
+
+func (fake *DatabaseHandler) DeleteExpiredEntryCalls(stub func(string, int) error) {
+	fake.deleteExpiredEntryMutex.Lock()
+	defer fake.deleteExpiredEntryMutex.Unlock()
+	fake.DeleteExpiredEntryStub = stub
+}
+
+func (fake *DatabaseHandler) DeleteExpiredEntryArgsForCall(i int) (string, int) {
+	fake.deleteExpiredEntryMutex.RLock()
+	defer fake.deleteExpiredEntryMutex.RUnlock()
+	argsForCall := fake.deleteExpiredEntryArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *DatabaseHandler) DeleteExpiredEntryReturns(result1 error) {
+	fake.deleteExpiredEntryMutex.Lock()
+	defer fake.deleteExpiredEntryMutex.Unlock()
+	fake.DeleteExpiredEntryStub = nil
+	fake.deleteExpiredEntryReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) DeleteExpiredEntryReturnsOnCall(i int, result1 error) {
+	fake.deleteExpiredEntryMutex.Lock()
+	defer fake.deleteExpiredEntryMutex.Unlock()
+	fake.DeleteExpiredEntryStub = nil
+	if fake.deleteExpiredEntryReturnsOnCall == nil {
+		fake.deleteExpiredEntryReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteExpiredEntryReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) DeleteReservation(arg1 string) error {
+	fake.deleteReservationMutex.Lock()
+	ret, specificReturn := fake.deleteReservationReturnsOnCall[len(fake.deleteReservationArgsForCall)]
+	fake.deleteReservationArgsForCall = append(fake.deleteReservationArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteReservationStub
+	fakeReturns := fake.deleteReservationReturns
+	fake.recordInvocation("DeleteReservation", []interface{}{arg1})
+	fake.deleteReservationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) DeleteReservationCallCount() int {
+	fake.deleteReservationMutex.RLock()
+	defer fake.deleteReservationMutex.RUnlock()
+	return len(fake.deleteReservationArgsForCall)
+}
+
+func (fake *DatabaseHandler) DeleteReservationCalls(stub func(string) error) {
+	fake.deleteReservationMutex.Lock()
+	defer fake.deleteReservationMutex.Unlock()
+	fake.DeleteReservationStub = stub
+}
+
+func (fake *DatabaseHandler) DeleteReservationArgsForCall(i int) string {
+	fake.deleteReservationMutex.RLock()
+	defer fake.deleteReservationMutex.RUnlock()
+	argsForCall := fake.deleteReservationArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) DeleteReservationReturns(result1 error) {
+	fake.deleteReservationMutex.Lock()
+	defer fake.deleteReservationMutex.Unlock()
+	fake.DeleteReservationStub = nil
+	fake.deleteReservationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) DeleteReservationReturnsOnCall(i int, result1 error) {
+	fake.deleteReservationMutex.Lock()
+	defer fake.deleteReservationMutex.Unlock()
+	fake.DeleteReservationStub = nil
+	if fake.deleteReservationReturnsOnCall == nil {
+		fake.deleteReservationReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteReservationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) Generation() (int64, error) {
+	fake.generationMutex.Lock()
+	ret, specificReturn := fake.generationReturnsOnCall[len(fake.generationArgsForCall)]
+	fake.generationArgsForCall = append(fake.generationArgsForCall, struct {
+	}{})
+	stub := fake.GenerationStub
+	fakeReturns := fake.generationReturns
+	fake.recordInvocation("Generation", []interface{}{})
+	fake.generationMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) GenerationCallCount() int {
+	fake.generationMutex.RLock()
+	defer fake.generationMutex.RUnlock()
+	return len(fake.generationArgsForCall)
+}
+
+func (fake *DatabaseHandler) GenerationCalls(stub func() (int64, error)) {
+	fake.generationMutex.Lock()
+	defer fake.generationMutex.Unlock()
+	fake.GenerationStub = stub
+}
+
+func (fake *DatabaseHandler) GenerationReturns(result1 int64, result2 error) {
+	fake.generationMutex.Lock()
+	defer fake.generationMutex.Unlock()
+	fake.GenerationStub = nil
+	fake.generationReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) GenerationReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.generationMutex.Lock()
+	defer fake.generationMutex.Unlock()
+	fake.GenerationStub = nil
+	if fake.generationReturnsOnCall == nil {
+		fake.generationReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.generationReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) LastRenewedAtForUnderlayIP(arg1 string) (int64, error) {
+	fake.lastRenewedAtForUnderlayIPMutex.Lock()
+	ret, specificReturn := fake.lastRenewedAtForUnderlayIPReturnsOnCall[len(fake.lastRenewedAtForUnderlayIPArgsForCall)]
+	fake.lastRenewedAtForUnderlayIPArgsForCall = append(fake.lastRenewedAtForUnderlayIPArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LastRenewedAtForUnderlayIPStub
+	fakeReturns := fake.lastRenewedAtForUnderlayIPReturns
+	fake.recordInvocation("LastRenewedAtForUnderlayIP", []interface{}{arg1})
+	fake.lastRenewedAtForUnderlayIPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPCallCount() int {
+	fake.lastRenewedAtForUnderlayIPMutex.RLock()
+	defer fake.lastRenewedAtForUnderlayIPMutex.RUnlock()
+	return len(fake.lastRenewedAtForUnderlayIPArgsForCall)
+}
+
+func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPCalls(stub func(string) (int64, error)) {
+	fake.lastRenewedAtForUnderlayIPMutex.Lock()
+	defer fake.lastRenewedAtForUnderlayIPMutex.Unlock()
+	fake.LastRenewedAtForUnderlayIPStub = stub
+}
+
+func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPArgsForCall(i int) string {
+	fake.lastRenewedAtForUnderlayIPMutex.RLock()
+	defer fake.lastRenewedAtForUnderlayIPMutex.RUnlock()
+	argsForCall := fake.lastRenewedAtForUnderlayIPArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPReturns(result1 int64, result2 error) {
+	fake.lastRenewedAtForUnderlayIPMutex.Lock()
+	defer fake.lastRenewedAtForUnderlayIPMutex.Unlock()
+	fake.LastRenewedAtForUnderlayIPStub = nil
+	fake.lastRenewedAtForUnderlayIPReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.lastRenewedAtForUnderlayIPMutex.Lock()
+	defer fake.lastRenewedAtForUnderlayIPMutex.Unlock()
+	fake.LastRenewedAtForUnderlayIPStub = nil
+	if fake.lastRenewedAtForUnderlayIPReturnsOnCall == nil {
+		fake.lastRenewedAtForUnderlayIPReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.lastRenewedAtForUnderlayIPReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) LeaseForUnderlayIP(arg1 string) (*controller.Lease, error) {
+	fake.leaseForUnderlayIPMutex.Lock()
+	ret, specificReturn := fake.leaseForUnderlayIPReturnsOnCall[len(fake.leaseForUnderlayIPArgsForCall)]
+	fake.leaseForUnderlayIPArgsForCall = append(fake.leaseForUnderlayIPArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LeaseForUnderlayIPStub
+	fakeReturns := fake.leaseForUnderlayIPReturns
+	fake.recordInvocation("LeaseForUnderlayIP", []interface{}{arg1})
+	fake.leaseForUnderlayIPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) LeaseForUnderlayIPCallCount() int {
+	fake.leaseForUnderlayIPMutex.RLock()
+	defer fake.leaseForUnderlayIPMutex.RUnlock()
+	return len(fake.leaseForUnderlayIPArgsForCall)
+}
+
+func (fake *DatabaseHandler) LeaseForUnderlayIPCalls(stub func(string) (*controller.Lease, error)) {
+	fake.leaseForUnderlayIPMutex.Lock()
+	defer fake.leaseForUnderlayIPMutex.Unlock()
+	fake.LeaseForUnderlayIPStub = stub
+}
+
+func (fake *DatabaseHandler) LeaseForUnderlayIPArgsForCall(i int) string {
+	fake.leaseForUnderlayIPMutex.RLock()
+	defer fake.leaseForUnderlayIPMutex.RUnlock()
+	argsForCall := fake.leaseForUnderlayIPArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) LeaseForUnderlayIPReturns(result1 *controller.Lease, result2 error) {
+	fake.leaseForUnderlayIPMutex.Lock()
+	defer fake.leaseForUnderlayIPMutex.Unlock()
+	fake.LeaseForUnderlayIPStub = nil
+	fake.leaseForUnderlayIPReturns = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) LeaseForUnderlayIPReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.leaseForUnderlayIPMutex.Lock()
+	defer fake.leaseForUnderlayIPMutex.Unlock()
+	fake.LeaseForUnderlayIPStub = nil
+	if fake.leaseForUnderlayIPReturnsOnCall == nil {
+		fake.leaseForUnderlayIPReturnsOnCall = make(map[int]struct {
+			result1 *controller.Lease
+			result2 error
+		})
+	}
+	fake.leaseForUnderlayIPReturnsOnCall[i] = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) LeaseRecords(arg1 int) ([]controller.LeaseRecord, error) {
+	fake.leaseRecordsMutex.Lock()
+	ret, specificReturn := fake.leaseRecordsReturnsOnCall[len(fake.leaseRecordsArgsForCall)]
+	fake.leaseRecordsArgsForCall = append(fake.leaseRecordsArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.LeaseRecordsStub
+	fakeReturns := fake.leaseRecordsReturns
+	fake.recordInvocation("LeaseRecords", []interface{}{arg1})
+	fake.leaseRecordsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) LeaseRecordsCallCount() int {
+	fake.leaseRecordsMutex.RLock()
+	defer fake.leaseRecordsMutex.RUnlock()
+	return len(fake.leaseRecordsArgsForCall)
+}
+
+func (fake *DatabaseHandler) LeaseRecordsCalls(stub func(int) ([]controller.LeaseRecord, error)) {
+	fake.leaseRecordsMutex.Lock()
+	defer fake.leaseRecordsMutex.Unlock()
+	fake.LeaseRecordsStub = stub
+}
+
+func (fake *DatabaseHandler) LeaseRecordsArgsForCall(i int) int {
+	fake.leaseRecordsMutex.RLock()
+	defer fake.leaseRecordsMutex.RUnlock()
+	argsForCall := fake.leaseRecordsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) LeaseRecordsReturns(result1 []controller.LeaseRecord, result2 error) {
+	fake.leaseRecordsMutex.Lock()
+	defer fake.leaseRecordsMutex.Unlock()
+	fake.LeaseRecordsStub = nil
+	fake.leaseRecordsReturns = struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) LeaseRecordsReturnsOnCall(i int, result1 []controller.LeaseRecord, result2 error) {
+	fake.leaseRecordsMutex.Lock()
+	defer fake.leaseRecordsMutex.Unlock()
+	fake.LeaseRecordsStub = nil
+	if fake.leaseRecordsReturnsOnCall == nil {
+		fake.leaseRecordsReturnsOnCall = make(map[int]struct {
+			result1 []controller.LeaseRecord
+			result2 error
+		})
+	}
+	fake.leaseRecordsReturnsOnCall[i] = struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnet(arg1 int) (*controller.Lease, error) {
+	fake.oldestExpiredBlockSubnetMutex.Lock()
+	ret, specificReturn := fake.oldestExpiredBlockSubnetReturnsOnCall[len(fake.oldestExpiredBlockSubnetArgsForCall)]
+	fake.oldestExpiredBlockSubnetArgsForCall = append(fake.oldestExpiredBlockSubnetArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.OldestExpiredBlockSubnetStub
+	fakeReturns := fake.oldestExpiredBlockSubnetReturns
+	fake.recordInvocation("OldestExpiredBlockSubnet", []interface{}{arg1})
+	fake.oldestExpiredBlockSubnetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetCallCount() int {
+	fake.oldestExpiredBlockSubnetMutex.RLock()
+	defer fake.oldestExpiredBlockSubnetMutex.RUnlock()
+	return len(fake.oldestExpiredBlockSubnetArgsForCall)
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetCalls(stub func(int) (*controller.Lease, error)) {
+	fake.oldestExpiredBlockSubnetMutex.Lock()
+	defer fake.oldestExpiredBlockSubnetMutex.Unlock()
+	fake.OldestExpiredBlockSubnetStub = stub
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetArgsForCall(i int) int {
+	fake.oldestExpiredBlockSubnetMutex.RLock()
+	defer fake.oldestExpiredBlockSubnetMutex.RUnlock()
+	argsForCall := fake.oldestExpiredBlockSubnetArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetReturns(result1 *controller.Lease, result2 error) {
+	fake.oldestExpiredBlockSubnetMutex.Lock()
+	defer fake.oldestExpiredBlockSubnetMutex.Unlock()
+	fake.OldestExpiredBlockSubnetStub = nil
+	fake.oldestExpiredBlockSubnetReturns = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.oldestExpiredBlockSubnetMutex.Lock()
+	defer fake.oldestExpiredBlockSubnetMutex.Unlock()
+	fake.OldestExpiredBlockSubnetStub = nil
+	if fake.oldestExpiredBlockSubnetReturnsOnCall == nil {
+		fake.oldestExpiredBlockSubnetReturnsOnCall = make(map[int]struct {
+			result1 *controller.Lease
+			result2 error
+		})
+	}
+	fake.oldestExpiredBlockSubnetReturnsOnCall[i] = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetV6(arg1 int) (*controller.Lease, error) {
+	fake.oldestExpiredBlockSubnetV6Mutex.Lock()
+	ret, specificReturn := fake.oldestExpiredBlockSubnetV6ReturnsOnCall[len(fake.oldestExpiredBlockSubnetV6ArgsForCall)]
+	fake.oldestExpiredBlockSubnetV6ArgsForCall = append(fake.oldestExpiredBlockSubnetV6ArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.OldestExpiredBlockSubnetV6Stub
+	fakeReturns := fake.oldestExpiredBlockSubnetV6Returns
+	fake.recordInvocation("OldestExpiredBlockSubnetV6", []interface{}{arg1})
+	fake.oldestExpiredBlockSubnetV6Mutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetV6CallCount() int {
+	fake.oldestExpiredBlockSubnetV6Mutex.RLock()
+	defer fake.oldestExpiredBlockSubnetV6Mutex.RUnlock()
+	return len(fake.oldestExpiredBlockSubnetV6ArgsForCall)
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetV6Calls(stub func(int) (*controller.Lease, error)) {
+	fake.oldestExpiredBlockSubnetV6Mutex.Lock()
+	defer fake.oldestExpiredBlockSubnetV6Mutex.Unlock()
+	fake.OldestExpiredBlockSubnetV6Stub = stub
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetV6ArgsForCall(i int) int {
+	fake.oldestExpiredBlockSubnetV6Mutex.RLock()
+	defer fake.oldestExpiredBlockSubnetV6Mutex.RUnlock()
+	argsForCall := fake.oldestExpiredBlockSubnetV6ArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetV6Returns(result1 *controller.Lease, result2 error) {
+	fake.oldestExpiredBlockSubnetV6Mutex.Lock()
+	defer fake.oldestExpiredBlockSubnetV6Mutex.Unlock()
+	fake.OldestExpiredBlockSubnetV6Stub = nil
+	fake.oldestExpiredBlockSubnetV6Returns = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) OldestExpiredBlockSubnetV6ReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.oldestExpiredBlockSubnetV6Mutex.Lock()
+	defer fake.oldestExpiredBlockSubnetV6Mutex.Unlock()
+	fake.OldestExpiredBlockSubnetV6Stub = nil
+	if fake.oldestExpiredBlockSubnetV6ReturnsOnCall == nil {
+		fake.oldestExpiredBlockSubnetV6ReturnsOnCall = make(map[int]struct {
+			result1 *controller.Lease
+			result2 error
+		})
+	}
+	fake.oldestExpiredBlockSubnetV6ReturnsOnCall[i] = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) OldestExpiredSingleIP(arg1 int) (*controller.Lease, error) {
+	fake.oldestExpiredSingleIPMutex.Lock()
+	ret, specificReturn := fake.oldestExpiredSingleIPReturnsOnCall[len(fake.oldestExpiredSingleIPArgsForCall)]
+	fake.oldestExpiredSingleIPArgsForCall = append(fake.oldestExpiredSingleIPArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.OldestExpiredSingleIPStub
+	fakeReturns := fake.oldestExpiredSingleIPReturns
+	fake.recordInvocation("OldestExpiredSingleIP", []interface{}{arg1})
+	fake.oldestExpiredSingleIPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) OldestExpiredSingleIPCallCount() int {
+	fake.oldestExpiredSingleIPMutex.RLock()
+	defer fake.oldestExpiredSingleIPMutex.RUnlock()
+	return len(fake.oldestExpiredSingleIPArgsForCall)
+}
+
+func (fake *DatabaseHandler) OldestExpiredSingleIPCalls(stub func(int) (*controller.Lease, error)) {
+	fake.oldestExpiredSingleIPMutex.Lock()
+	defer fake.oldestExpiredSingleIPMutex.Unlock()
+	fake.OldestExpiredSingleIPStub = stub
+}
+
+func (fake *DatabaseHandler) OldestExpiredSingleIPArgsForCall(i int) int {
+	fake.oldestExpiredSingleIPMutex.RLock()
+	defer fake.oldestExpiredSingleIPMutex.RUnlock()
+	argsForCall := fake.oldestExpiredSingleIPArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) OldestExpiredSingleIPReturns(result1 *controller.Lease, result2 error) {
+	fake.oldestExpiredSingleIPMutex.Lock()
+	defer fake.oldestExpiredSingleIPMutex.Unlock()
+	fake.OldestExpiredSingleIPStub = nil
+	fake.oldestExpiredSingleIPReturns = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) OldestExpiredSingleIPReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.oldestExpiredSingleIPMutex.Lock()
+	defer fake.oldestExpiredSingleIPMutex.Unlock()
+	fake.OldestExpiredSingleIPStub = nil
 	if fake.oldestExpiredSingleIPReturnsOnCall == nil {
 		fake.oldestExpiredSingleIPReturnsOnCall = make(map[int]struct {
 			result1 *controller.Lease
@@ -671,31 +1466,426 @@ func (fake *DatabaseHandler) OldestExpiredSingleIPReturnsOnCall(i int, result1 *
 	}{result1, result2}
 }
 
+func (fake *DatabaseHandler) QuarantineSubnet(arg1 string, arg2 string, arg3 int) error {
+	fake.quarantineSubnetMutex.Lock()
+	ret, specificReturn := fake.quarantineSubnetReturnsOnCall[len(fake.quarantineSubnetArgsForCall)]
+	fake.quarantineSubnetArgsForCall = append(fake.quarantineSubnetArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 int
+	}{arg1, arg2, arg3})
+	stub := fake.QuarantineSubnetStub
+	fakeReturns := fake.quarantineSubnetReturns
+	fake.recordInvocation("QuarantineSubnet", []interface{}{arg1, arg2, arg3})
+	fake.quarantineSubnetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) QuarantineSubnetCallCount() int {
+	fake.quarantineSubnetMutex.RLock()
+	defer fake.quarantineSubnetMutex.RUnlock()
+	return len(fake.quarantineSubnetArgsForCall)
+}
+
+func (fake *DatabaseHandler) QuarantineSubnetCalls(stub func(string, string, int) error) {
+	fake.quarantineSubnetMutex.Lock()
+	defer fake.quarantineSubnetMutex.Unlock()
+	fake.QuarantineSubnetStub = stub
+}
+
+func (fake *DatabaseHandler) QuarantineSubnetArgsForCall(i int) (string, string, int) {
+	fake.quarantineSubnetMutex.RLock()
+	defer fake.quarantineSubnetMutex.RUnlock()
+	argsForCall := fake.quarantineSubnetArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *DatabaseHandler) QuarantineSubnetReturns(result1 error) {
+	fake.quarantineSubnetMutex.Lock()
+	defer fake.quarantineSubnetMutex.Unlock()
+	fake.QuarantineSubnetStub = nil
+	fake.quarantineSubnetReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) QuarantineSubnetReturnsOnCall(i int, result1 error) {
+	fake.quarantineSubnetMutex.Lock()
+	defer fake.quarantineSubnetMutex.Unlock()
+	fake.QuarantineSubnetStub = nil
+	if fake.quarantineSubnetReturnsOnCall == nil {
+		fake.quarantineSubnetReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.quarantineSubnetReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) QuarantinedSubnets() ([]controller.QuarantinedSubnet, error) {
+	fake.quarantinedSubnetsMutex.Lock()
+	ret, specificReturn := fake.quarantinedSubnetsReturnsOnCall[len(fake.quarantinedSubnetsArgsForCall)]
+	fake.quarantinedSubnetsArgsForCall = append(fake.quarantinedSubnetsArgsForCall, struct {
+	}{})
+	stub := fake.QuarantinedSubnetsStub
+	fakeReturns := fake.quarantinedSubnetsReturns
+	fake.recordInvocation("QuarantinedSubnets", []interface{}{})
+	fake.quarantinedSubnetsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) QuarantinedSubnetsCallCount() int {
+	fake.quarantinedSubnetsMutex.RLock()
+	defer fake.quarantinedSubnetsMutex.RUnlock()
+	return len(fake.quarantinedSubnetsArgsForCall)
+}
+
+func (fake *DatabaseHandler) QuarantinedSubnetsCalls(stub func() ([]controller.QuarantinedSubnet, error)) {
+	fake.quarantinedSubnetsMutex.Lock()
+	defer fake.quarantinedSubnetsMutex.Unlock()
+	fake.QuarantinedSubnetsStub = stub
+}
+
+func (fake *DatabaseHandler) QuarantinedSubnetsReturns(result1 []controller.QuarantinedSubnet, result2 error) {
+	fake.quarantinedSubnetsMutex.Lock()
+	defer fake.quarantinedSubnetsMutex.Unlock()
+	fake.QuarantinedSubnetsStub = nil
+	fake.quarantinedSubnetsReturns = struct {
+		result1 []controller.QuarantinedSubnet
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) QuarantinedSubnetsReturnsOnCall(i int, result1 []controller.QuarantinedSubnet, result2 error) {
+	fake.quarantinedSubnetsMutex.Lock()
+	defer fake.quarantinedSubnetsMutex.Unlock()
+	fake.QuarantinedSubnetsStub = nil
+	if fake.quarantinedSubnetsReturnsOnCall == nil {
+		fake.quarantinedSubnetsReturnsOnCall = make(map[int]struct {
+			result1 []controller.QuarantinedSubnet
+			result2 error
+		})
+	}
+	fake.quarantinedSubnetsReturnsOnCall[i] = struct {
+		result1 []controller.QuarantinedSubnet
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) RenewLeaseForUnderlayIP(arg1 string) error {
+	fake.renewLeaseForUnderlayIPMutex.Lock()
+	ret, specificReturn := fake.renewLeaseForUnderlayIPReturnsOnCall[len(fake.renewLeaseForUnderlayIPArgsForCall)]
+	fake.renewLeaseForUnderlayIPArgsForCall = append(fake.renewLeaseForUnderlayIPArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.RenewLeaseForUnderlayIPStub
+	fakeReturns := fake.renewLeaseForUnderlayIPReturns
+	fake.recordInvocation("RenewLeaseForUnderlayIP", []interface{}{arg1})
+	fake.renewLeaseForUnderlayIPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) RenewLeaseForUnderlayIPCallCount() int {
+	fake.renewLeaseForUnderlayIPMutex.RLock()
+	defer fake.renewLeaseForUnderlayIPMutex.RUnlock()
+	return len(fake.renewLeaseForUnderlayIPArgsForCall)
+}
+
+func (fake *DatabaseHandler) RenewLeaseForUnderlayIPCalls(stub func(string) error) {
+	fake.renewLeaseForUnderlayIPMutex.Lock()
+	defer fake.renewLeaseForUnderlayIPMutex.Unlock()
+	fake.RenewLeaseForUnderlayIPStub = stub
+}
+
+func (fake *DatabaseHandler) RenewLeaseForUnderlayIPArgsForCall(i int) string {
+	fake.renewLeaseForUnderlayIPMutex.RLock()
+	defer fake.renewLeaseForUnderlayIPMutex.RUnlock()
+	argsForCall := fake.renewLeaseForUnderlayIPArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) RenewLeaseForUnderlayIPReturns(result1 error) {
+	fake.renewLeaseForUnderlayIPMutex.Lock()
+	defer fake.renewLeaseForUnderlayIPMutex.Unlock()
+	fake.RenewLeaseForUnderlayIPStub = nil
+	fake.renewLeaseForUnderlayIPReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) RenewLeaseForUnderlayIPReturnsOnCall(i int, result1 error) {
+	fake.renewLeaseForUnderlayIPMutex.Lock()
+	defer fake.renewLeaseForUnderlayIPMutex.Unlock()
+	fake.RenewLeaseForUnderlayIPStub = nil
+	if fake.renewLeaseForUnderlayIPReturnsOnCall == nil {
+		fake.renewLeaseForUnderlayIPReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.renewLeaseForUnderlayIPReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIP(arg1 string) (*controller.Reservation, error) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	ret, specificReturn := fake.reservationForUnderlayIPReturnsOnCall[len(fake.reservationForUnderlayIPArgsForCall)]
+	fake.reservationForUnderlayIPArgsForCall = append(fake.reservationForUnderlayIPArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReservationForUnderlayIPStub
+	fakeReturns := fake.reservationForUnderlayIPReturns
+	fake.recordInvocation("ReservationForUnderlayIP", []interface{}{arg1})
+	fake.reservationForUnderlayIPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPCallCount() int {
+	fake.reservationForUnderlayIPMutex.RLock()
+	defer fake.reservationForUnderlayIPMutex.RUnlock()
+	return len(fake.reservationForUnderlayIPArgsForCall)
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPCalls(stub func(string) (*controller.Reservation, error)) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	defer fake.reservationForUnderlayIPMutex.Unlock()
+	fake.ReservationForUnderlayIPStub = stub
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPArgsForCall(i int) string {
+	fake.reservationForUnderlayIPMutex.RLock()
+	defer fake.reservationForUnderlayIPMutex.RUnlock()
+	argsForCall := fake.reservationForUnderlayIPArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPReturns(result1 *controller.Reservation, result2 error) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	defer fake.reservationForUnderlayIPMutex.Unlock()
+	fake.ReservationForUnderlayIPStub = nil
+	fake.reservationForUnderlayIPReturns = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPReturnsOnCall(i int, result1 *controller.Reservation, result2 error) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	defer fake.reservationForUnderlayIPMutex.Unlock()
+	fake.ReservationForUnderlayIPStub = nil
+	if fake.reservationForUnderlayIPReturnsOnCall == nil {
+		fake.reservationForUnderlayIPReturnsOnCall = make(map[int]struct {
+			result1 *controller.Reservation
+			result2 error
+		})
+	}
+	fake.reservationForUnderlayIPReturnsOnCall[i] = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) SetHostForUnderlayIP(arg1 string, arg2 controller.HostMetadata) error {
+	fake.setHostForUnderlayIPMutex.Lock()
+	ret, specificReturn := fake.setHostForUnderlayIPReturnsOnCall[len(fake.setHostForUnderlayIPArgsForCall)]
+	fake.setHostForUnderlayIPArgsForCall = append(fake.setHostForUnderlayIPArgsForCall, struct {
+		arg1 string
+		arg2 controller.HostMetadata
+	}{arg1, arg2})
+	stub := fake.SetHostForUnderlayIPStub
+	fakeReturns := fake.setHostForUnderlayIPReturns
+	fake.recordInvocation("SetHostForUnderlayIP", []interface{}{arg1, arg2})
+	fake.setHostForUnderlayIPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) SetHostForUnderlayIPCallCount() int {
+	fake.setHostForUnderlayIPMutex.RLock()
+	defer fake.setHostForUnderlayIPMutex.RUnlock()
+	return len(fake.setHostForUnderlayIPArgsForCall)
+}
+
+func (fake *DatabaseHandler) SetHostForUnderlayIPCalls(stub func(string, controller.HostMetadata) error) {
+	fake.setHostForUnderlayIPMutex.Lock()
+	defer fake.setHostForUnderlayIPMutex.Unlock()
+	fake.SetHostForUnderlayIPStub = stub
+}
+
+func (fake *DatabaseHandler) SetHostForUnderlayIPArgsForCall(i int) (string, controller.HostMetadata) {
+	fake.setHostForUnderlayIPMutex.RLock()
+	defer fake.setHostForUnderlayIPMutex.RUnlock()
+	argsForCall := fake.setHostForUnderlayIPArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *DatabaseHandler) SetHostForUnderlayIPReturns(result1 error) {
+	fake.setHostForUnderlayIPMutex.Lock()
+	defer fake.setHostForUnderlayIPMutex.Unlock()
+	fake.SetHostForUnderlayIPStub = nil
+	fake.setHostForUnderlayIPReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) SetHostForUnderlayIPReturnsOnCall(i int, result1 error) {
+	fake.setHostForUnderlayIPMutex.Lock()
+	defer fake.setHostForUnderlayIPMutex.Unlock()
+	fake.SetHostForUnderlayIPStub = nil
+	if fake.setHostForUnderlayIPReturnsOnCall == nil {
+		fake.setHostForUnderlayIPReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setHostForUnderlayIPReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) WithAllocationLock(arg1 func(database.LeaseStore) error) error {
+	fake.withAllocationLockMutex.Lock()
+	ret, specificReturn := fake.withAllocationLockReturnsOnCall[len(fake.withAllocationLockArgsForCall)]
+	fake.withAllocationLockArgsForCall = append(fake.withAllocationLockArgsForCall, struct {
+		arg1 func(database.LeaseStore) error
+	}{arg1})
+	stub := fake.WithAllocationLockStub
+	fakeReturns := fake.withAllocationLockReturns
+	fake.recordInvocation("WithAllocationLock", []interface{}{arg1})
+	fake.withAllocationLockMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) WithAllocationLockCallCount() int {
+	fake.withAllocationLockMutex.RLock()
+	defer fake.withAllocationLockMutex.RUnlock()
+	return len(fake.withAllocationLockArgsForCall)
+}
+
+func (fake *DatabaseHandler) WithAllocationLockCalls(stub func(func(database.LeaseStore) error) error) {
+	fake.withAllocationLockMutex.Lock()
+	defer fake.withAllocationLockMutex.Unlock()
+	fake.WithAllocationLockStub = stub
+}
+
+func (fake *DatabaseHandler) WithAllocationLockArgsForCall(i int) func(database.LeaseStore) error {
+	fake.withAllocationLockMutex.RLock()
+	defer fake.withAllocationLockMutex.RUnlock()
+	argsForCall := fake.withAllocationLockArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) WithAllocationLockReturns(result1 error) {
+	fake.withAllocationLockMutex.Lock()
+	defer fake.withAllocationLockMutex.Unlock()
+	fake.WithAllocationLockStub = nil
+	fake.withAllocationLockReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) WithAllocationLockReturnsOnCall(i int, result1 error) {
+	fake.withAllocationLockMutex.Lock()
+	defer fake.withAllocationLockMutex.Unlock()
+	fake.WithAllocationLockStub = nil
+	if fake.withAllocationLockReturnsOnCall == nil {
+		fake.withAllocationLockReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.withAllocationLockReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *DatabaseHandler) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.addEntryMutex.RLock()
 	defer fake.addEntryMutex.RUnlock()
-	fake.deleteEntryMutex.RLock()
-	defer fake.deleteEntryMutex.RUnlock()
-	fake.leaseForUnderlayIPMutex.RLock()
-	defer fake.leaseForUnderlayIPMutex.RUnlock()
-	fake.lastRenewedAtForUnderlayIPMutex.RLock()
-	defer fake.lastRenewedAtForUnderlayIPMutex.RUnlock()
-	fake.renewLeaseForUnderlayIPMutex.RLock()
-	defer fake.renewLeaseForUnderlayIPMutex.RUnlock()
+	fake.addEventMutex.RLock()
+	defer fake.addEventMutex.RUnlock()
+	fake.addReservationMutex.RLock()
+	defer fake.addReservationMutex.RUnlock()
 	fake.allMutex.RLock()
 	defer fake.allMutex.RUnlock()
+	fake.allActiveMutex.RLock()
+	defer fake.allActiveMutex.RUnlock()
 	fake.allBlockSubnetsMutex.RLock()
 	defer fake.allBlockSubnetsMutex.RUnlock()
+	fake.allBlockSubnetsV6Mutex.RLock()
+	defer fake.allBlockSubnetsV6Mutex.RUnlock()
+	fake.allReservationsMutex.RLock()
+	defer fake.allReservationsMutex.RUnlock()
 	fake.allSingleIPSubnetsMutex.RLock()
 	defer fake.allSingleIPSubnetsMutex.RUnlock()
-	fake.allActiveMutex.RLock()
-	defer fake.allActiveMutex.RUnlock()
+	fake.deleteEntryMutex.RLock()
+	defer fake.deleteEntryMutex.RUnlock()
+	fake.deleteExpiredEntryMutex.RLock()
+	defer fake.deleteExpiredEntryMutex.RUnlock()
+	fake.deleteReservationMutex.RLock()
+	defer fake.deleteReservationMutex.RUnlock()
+	fake.generationMutex.RLock()
+	defer fake.generationMutex.RUnlock()
+	fake.lastRenewedAtForUnderlayIPMutex.RLock()
+	defer fake.lastRenewedAtForUnderlayIPMutex.RUnlock()
+	fake.leaseForUnderlayIPMutex.RLock()
+	defer fake.leaseForUnderlayIPMutex.RUnlock()
+	fake.leaseRecordsMutex.RLock()
+	defer fake.leaseRecordsMutex.RUnlock()
 	fake.oldestExpiredBlockSubnetMutex.RLock()
 	defer fake.oldestExpiredBlockSubnetMutex.RUnlock()
+	fake.oldestExpiredBlockSubnetV6Mutex.RLock()
+	defer fake.oldestExpiredBlockSubnetV6Mutex.RUnlock()
 	fake.oldestExpiredSingleIPMutex.RLock()
 	defer fake.oldestExpiredSingleIPMutex.RUnlock()
+	fake.quarantineSubnetMutex.RLock()
+	defer fake.quarantineSubnetMutex.RUnlock()
+	fake.quarantinedSubnetsMutex.RLock()
+	defer fake.quarantinedSubnetsMutex.RUnlock()
+	fake.renewLeaseForUnderlayIPMutex.RLock()
+	defer fake.renewLeaseForUnderlayIPMutex.RUnlock()
+	fake.reservationForUnderlayIPMutex.RLock()
+	defer fake.reservationForUnderlayIPMutex.RUnlock()
+	fake.setHostForUnderlayIPMutex.RLock()
+	defer fake.setHostForUnderlayIPMutex.RUnlock()
+	fake.withAllocationLockMutex.RLock()
+	defer fake.withAllocationLockMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -7,10 +7,10 @@ import (
 )
 
 type HardwareAddressGenerator struct {
-	GenerateForVTEPStub        func(containerIP net.IP) (net.HardwareAddr, error)
+	GenerateForVTEPStub        func(net.IP) (net.HardwareAddr, error)
 	generateForVTEPMutex       sync.RWMutex
 	generateForVTEPArgsForCall []struct {
-		containerIP net.IP
+		arg1 net.IP
 	}
 	generateForVTEPReturns struct {
 		result1 net.HardwareAddr
@@ -20,25 +20,40 @@ type HardwareAddressGenerator struct {
 		result1 net.HardwareAddr
 		result2 error
 	}
+	GenerateForVTEPV6Stub        func(*net.IPNet) (net.HardwareAddr, error)
+	generateForVTEPV6Mutex       sync.RWMutex
+	generateForVTEPV6ArgsForCall []struct {
+		arg1 *net.IPNet
+	}
+	generateForVTEPV6Returns struct {
+		result1 net.HardwareAddr
+		result2 error
+	}
+	generateForVTEPV6ReturnsOnCall map[int]struct {
+		result1 net.HardwareAddr
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *HardwareAddressGenerator) GenerateForVTEP(containerIP net.IP) (net.HardwareAddr, error) {
+func (fake *HardwareAddressGenerator) GenerateForVTEP(arg1 net.IP) (net.HardwareAddr, error) {
 	fake.generateForVTEPMutex.Lock()
 	ret, specificReturn := fake.generateForVTEPReturnsOnCall[len(fake.generateForVTEPArgsForCall)]
 	fake.generateForVTEPArgsForCall = append(fake.generateForVTEPArgsForCall, struct {
-		containerIP net.IP
-	}{containerIP})
-	fake.recordInvocation("GenerateForVTEP", []interface{}{containerIP})
+		arg1 net.IP
+	}{arg1})
+	stub := fake.GenerateForVTEPStub
+	fakeReturns := fake.generateForVTEPReturns
+	fake.recordInvocation("GenerateForVTEP", []interface{}{arg1})
 	fake.generateForVTEPMutex.Unlock()
-	if fake.GenerateForVTEPStub != nil {
-		return fake.GenerateForVTEPStub(containerIP)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.generateForVTEPReturns.result1, fake.generateForVTEPReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *HardwareAddressGenerator) GenerateForVTEPCallCount() int {
@@ -47,13 +62,22 @@ func (fake *HardwareAddressGenerator) GenerateForVTEPCallCount() int {
 	return len(fake.generateForVTEPArgsForCall)
 }
 
+func (fake *HardwareAddressGenerator) GenerateForVTEPCalls(stub func(net.IP) (net.HardwareAddr, error)) {
+	fake.generateForVTEPMutex.Lock()
+	defer fake.generateForVTEPMutex.Unlock()
+	fake.GenerateForVTEPStub = stub
+}
+
 func (fake *HardwareAddressGenerator) GenerateForVTEPArgsForCall(i int) net.IP {
 	fake.generateForVTEPMutex.RLock()
 	defer fake.generateForVTEPMutex.RUnlock()
-	return fake.generateForVTEPArgsForCall[i].containerIP
+	argsForCall := fake.generateForVTEPArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *HardwareAddressGenerator) GenerateForVTEPReturns(result1 net.HardwareAddr, result2 error) {
+	fake.generateForVTEPMutex.Lock()
+	defer fake.generateForVTEPMutex.Unlock()
 	fake.GenerateForVTEPStub = nil
 	fake.generateForVTEPReturns = struct {
 		result1 net.HardwareAddr
@@ -62,6 +86,8 @@ func (fake *HardwareAddressGenerator) GenerateForVTEPReturns(result1 net.Hardwar
 }
 
 func (fake *HardwareAddressGenerator) GenerateForVTEPReturnsOnCall(i int, result1 net.HardwareAddr, result2 error) {
+	fake.generateForVTEPMutex.Lock()
+	defer fake.generateForVTEPMutex.Unlock()
 	fake.GenerateForVTEPStub = nil
 	if fake.generateForVTEPReturnsOnCall == nil {
 		fake.generateForVTEPReturnsOnCall = make(map[int]struct {
@@ -75,11 +101,77 @@ func (fake *HardwareAddressGenerator) GenerateForVTEPReturnsOnCall(i int, result
 	}{result1, result2}
 }
 
+func (fake *HardwareAddressGenerator) GenerateForVTEPV6(arg1 *net.IPNet) (net.HardwareAddr, error) {
+	fake.generateForVTEPV6Mutex.Lock()
+	ret, specificReturn := fake.generateForVTEPV6ReturnsOnCall[len(fake.generateForVTEPV6ArgsForCall)]
+	fake.generateForVTEPV6ArgsForCall = append(fake.generateForVTEPV6ArgsForCall, struct {
+		arg1 *net.IPNet
+	}{arg1})
+	stub := fake.GenerateForVTEPV6Stub
+	fakeReturns := fake.generateForVTEPV6Returns
+	fake.recordInvocation("GenerateForVTEPV6", []interface{}{arg1})
+	fake.generateForVTEPV6Mutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *HardwareAddressGenerator) GenerateForVTEPV6CallCount() int {
+	fake.generateForVTEPV6Mutex.RLock()
+	defer fake.generateForVTEPV6Mutex.RUnlock()
+	return len(fake.generateForVTEPV6ArgsForCall)
+}
+
+func (fake *HardwareAddressGenerator) GenerateForVTEPV6Calls(stub func(*net.IPNet) (net.HardwareAddr, error)) {
+	fake.generateForVTEPV6Mutex.Lock()
+	defer fake.generateForVTEPV6Mutex.Unlock()
+	fake.GenerateForVTEPV6Stub = stub
+}
+
+func (fake *HardwareAddressGenerator) GenerateForVTEPV6ArgsForCall(i int) *net.IPNet {
+	fake.generateForVTEPV6Mutex.RLock()
+	defer fake.generateForVTEPV6Mutex.RUnlock()
+	argsForCall := fake.generateForVTEPV6ArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *HardwareAddressGenerator) GenerateForVTEPV6Returns(result1 net.HardwareAddr, result2 error) {
+	fake.generateForVTEPV6Mutex.Lock()
+	defer fake.generateForVTEPV6Mutex.Unlock()
+	fake.GenerateForVTEPV6Stub = nil
+	fake.generateForVTEPV6Returns = struct {
+		result1 net.HardwareAddr
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *HardwareAddressGenerator) GenerateForVTEPV6ReturnsOnCall(i int, result1 net.HardwareAddr, result2 error) {
+	fake.generateForVTEPV6Mutex.Lock()
+	defer fake.generateForVTEPV6Mutex.Unlock()
+	fake.GenerateForVTEPV6Stub = nil
+	if fake.generateForVTEPV6ReturnsOnCall == nil {
+		fake.generateForVTEPV6ReturnsOnCall = make(map[int]struct {
+			result1 net.HardwareAddr
+			result2 error
+		})
+	}
+	fake.generateForVTEPV6ReturnsOnCall[i] = struct {
+		result1 net.HardwareAddr
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *HardwareAddressGenerator) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.generateForVTEPMutex.RLock()
 	defer fake.generateForVTEPMutex.RUnlock()
+	fake.generateForVTEPV6Mutex.RLock()
+	defer fake.generateForVTEPV6Mutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,773 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type PoolLeaser struct {
+	AcquireSubnetLeaseStub        func(string, controller.AcquireLeaseRequest) (*controller.Lease, error)
+	acquireSubnetLeaseMutex       sync.RWMutex
+	acquireSubnetLeaseArgsForCall []struct {
+		arg1 string
+		arg2 controller.AcquireLeaseRequest
+	}
+	acquireSubnetLeaseReturns struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	acquireSubnetLeaseReturnsOnCall map[int]struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	HostLeasesStub        func() ([]controller.HostLease, error)
+	hostLeasesMutex       sync.RWMutex
+	hostLeasesArgsForCall []struct {
+	}
+	hostLeasesReturns struct {
+		result1 []controller.HostLease
+		result2 error
+	}
+	hostLeasesReturnsOnCall map[int]struct {
+		result1 []controller.HostLease
+		result2 error
+	}
+	LeaseRecordsStub        func() ([]controller.LeaseRecord, error)
+	leaseRecordsMutex       sync.RWMutex
+	leaseRecordsArgsForCall []struct {
+	}
+	leaseRecordsReturns struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}
+	leaseRecordsReturnsOnCall map[int]struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}
+	ReleaseSubnetLeaseStub        func(string, string) error
+	releaseSubnetLeaseMutex       sync.RWMutex
+	releaseSubnetLeaseArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	releaseSubnetLeaseReturns struct {
+		result1 error
+	}
+	releaseSubnetLeaseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RemoveReservationStub        func(string) error
+	removeReservationMutex       sync.RWMutex
+	removeReservationArgsForCall []struct {
+		arg1 string
+	}
+	removeReservationReturns struct {
+		result1 error
+	}
+	removeReservationReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RenewSubnetLeaseStub        func(string, controller.Lease, *controller.HostMetadata) (*controller.Lease, error)
+	renewSubnetLeaseMutex       sync.RWMutex
+	renewSubnetLeaseArgsForCall []struct {
+		arg1 string
+		arg2 controller.Lease
+		arg3 *controller.HostMetadata
+	}
+	renewSubnetLeaseReturns struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	renewSubnetLeaseReturnsOnCall map[int]struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	ReservationsStub        func() ([]controller.Reservation, error)
+	reservationsMutex       sync.RWMutex
+	reservationsArgsForCall []struct {
+	}
+	reservationsReturns struct {
+		result1 []controller.Reservation
+		result2 error
+	}
+	reservationsReturnsOnCall map[int]struct {
+		result1 []controller.Reservation
+		result2 error
+	}
+	ReserveSubnetStub        func(controller.Reservation) error
+	reserveSubnetMutex       sync.RWMutex
+	reserveSubnetArgsForCall []struct {
+		arg1 controller.Reservation
+	}
+	reserveSubnetReturns struct {
+		result1 error
+	}
+	reserveSubnetReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RoutableLeasesStub        func() ([]controller.Lease, error)
+	routableLeasesMutex       sync.RWMutex
+	routableLeasesArgsForCall []struct {
+	}
+	routableLeasesReturns struct {
+		result1 []controller.Lease
+		result2 error
+	}
+	routableLeasesReturnsOnCall map[int]struct {
+		result1 []controller.Lease
+		result2 error
+	}
+	UsageStub        func() (controller.PoolUsage, error)
+	usageMutex       sync.RWMutex
+	usageArgsForCall []struct {
+	}
+	usageReturns struct {
+		result1 controller.PoolUsage
+		result2 error
+	}
+	usageReturnsOnCall map[int]struct {
+		result1 controller.PoolUsage
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *PoolLeaser) AcquireSubnetLease(arg1 string, arg2 controller.AcquireLeaseRequest) (*controller.Lease, error) {
+	fake.acquireSubnetLeaseMutex.Lock()
+	ret, specificReturn := fake.acquireSubnetLeaseReturnsOnCall[len(fake.acquireSubnetLeaseArgsForCall)]
+	fake.acquireSubnetLeaseArgsForCall = append(fake.acquireSubnetLeaseArgsForCall, struct {
+		arg1 string
+		arg2 controller.AcquireLeaseRequest
+	}{arg1, arg2})
+	stub := fake.AcquireSubnetLeaseStub
+	fakeReturns := fake.acquireSubnetLeaseReturns
+	fake.recordInvocation("AcquireSubnetLease", []interface{}{arg1, arg2})
+	fake.acquireSubnetLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PoolLeaser) AcquireSubnetLeaseCallCount() int {
+	fake.acquireSubnetLeaseMutex.RLock()
+	defer fake.acquireSubnetLeaseMutex.RUnlock()
+	return len(fake.acquireSubnetLeaseArgsForCall)
+}
+
+func (fake *PoolLeaser) AcquireSubnetLeaseCalls(stub func(string, controller.AcquireLeaseRequest) (*controller.Lease, error)) {
+	fake.acquireSubnetLeaseMutex.Lock()
+	defer fake.acquireSubnetLeaseMutex.Unlock()
+	fake.AcquireSubnetLeaseStub = stub
+}
+
+func (fake *PoolLeaser) AcquireSubnetLeaseArgsForCall(i int) (string, controller.AcquireLeaseRequest) {
+	fake.acquireSubnetLeaseMutex.RLock()
+	defer fake.acquireSubnetLeaseMutex.RUnlock()
+	argsForCall := fake.acquireSubnetLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *PoolLeaser) AcquireSubnetLeaseReturns(result1 *controller.Lease, result2 error) {
+	fake.acquireSubnetLeaseMutex.Lock()
+	defer fake.acquireSubnetLeaseMutex.Unlock()
+	fake.AcquireSubnetLeaseStub = nil
+	fake.acquireSubnetLeaseReturns = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) AcquireSubnetLeaseReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.acquireSubnetLeaseMutex.Lock()
+	defer fake.acquireSubnetLeaseMutex.Unlock()
+	fake.AcquireSubnetLeaseStub = nil
+	if fake.acquireSubnetLeaseReturnsOnCall == nil {
+		fake.acquireSubnetLeaseReturnsOnCall = make(map[int]struct {
+			result1 *controller.Lease
+			result2 error
+		})
+	}
+	fake.acquireSubnetLeaseReturnsOnCall[i] = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) HostLeases() ([]controller.HostLease, error) {
+	fake.hostLeasesMutex.Lock()
+	ret, specificReturn := fake.hostLeasesReturnsOnCall[len(fake.hostLeasesArgsForCall)]
+	fake.hostLeasesArgsForCall = append(fake.hostLeasesArgsForCall, struct {
+	}{})
+	stub := fake.HostLeasesStub
+	fakeReturns := fake.hostLeasesReturns
+	fake.recordInvocation("HostLeases", []interface{}{})
+	fake.hostLeasesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PoolLeaser) HostLeasesCallCount() int {
+	fake.hostLeasesMutex.RLock()
+	defer fake.hostLeasesMutex.RUnlock()
+	return len(fake.hostLeasesArgsForCall)
+}
+
+func (fake *PoolLeaser) HostLeasesCalls(stub func() ([]controller.HostLease, error)) {
+	fake.hostLeasesMutex.Lock()
+	defer fake.hostLeasesMutex.Unlock()
+	fake.HostLeasesStub = stub
+}
+
+func (fake *PoolLeaser) HostLeasesReturns(result1 []controller.HostLease, result2 error) {
+	fake.hostLeasesMutex.Lock()
+	defer fake.hostLeasesMutex.Unlock()
+	fake.HostLeasesStub = nil
+	fake.hostLeasesReturns = struct {
+		result1 []controller.HostLease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) HostLeasesReturnsOnCall(i int, result1 []controller.HostLease, result2 error) {
+	fake.hostLeasesMutex.Lock()
+	defer fake.hostLeasesMutex.Unlock()
+	fake.HostLeasesStub = nil
+	if fake.hostLeasesReturnsOnCall == nil {
+		fake.hostLeasesReturnsOnCall = make(map[int]struct {
+			result1 []controller.HostLease
+			result2 error
+		})
+	}
+	fake.hostLeasesReturnsOnCall[i] = struct {
+		result1 []controller.HostLease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) LeaseRecords() ([]controller.LeaseRecord, error) {
+	fake.leaseRecordsMutex.Lock()
+	ret, specificReturn := fake.leaseRecordsReturnsOnCall[len(fake.leaseRecordsArgsForCall)]
+	fake.leaseRecordsArgsForCall = append(fake.leaseRecordsArgsForCall, struct {
+	}{})
+	stub := fake.LeaseRecordsStub
+	fakeReturns := fake.leaseRecordsReturns
+	fake.recordInvocation("LeaseRecords", []interface{}{})
+	fake.leaseRecordsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PoolLeaser) LeaseRecordsCallCount() int {
+	fake.leaseRecordsMutex.RLock()
+	defer fake.leaseRecordsMutex.RUnlock()
+	return len(fake.leaseRecordsArgsForCall)
+}
+
+func (fake *PoolLeaser) LeaseRecordsCalls(stub func() ([]controller.LeaseRecord, error)) {
+	fake.leaseRecordsMutex.Lock()
+	defer fake.leaseRecordsMutex.Unlock()
+	fake.LeaseRecordsStub = stub
+}
+
+func (fake *PoolLeaser) LeaseRecordsReturns(result1 []controller.LeaseRecord, result2 error) {
+	fake.leaseRecordsMutex.Lock()
+	defer fake.leaseRecordsMutex.Unlock()
+	fake.LeaseRecordsStub = nil
+	fake.leaseRecordsReturns = struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) LeaseRecordsReturnsOnCall(i int, result1 []controller.LeaseRecord, result2 error) {
+	fake.leaseRecordsMutex.Lock()
+	defer fake.leaseRecordsMutex.Unlock()
+	fake.LeaseRecordsStub = nil
+	if fake.leaseRecordsReturnsOnCall == nil {
+		fake.leaseRecordsReturnsOnCall = make(map[int]struct {
+			result1 []controller.LeaseRecord
+			result2 error
+		})
+	}
+	fake.leaseRecordsReturnsOnCall[i] = struct {
+		result1 []controller.LeaseRecord
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) ReleaseSubnetLease(arg1 string, arg2 string) error {
+	fake.releaseSubnetLeaseMutex.Lock()
+	ret, specificReturn := fake.releaseSubnetLeaseReturnsOnCall[len(fake.releaseSubnetLeaseArgsForCall)]
+	fake.releaseSubnetLeaseArgsForCall = append(fake.releaseSubnetLeaseArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ReleaseSubnetLeaseStub
+	fakeReturns := fake.releaseSubnetLeaseReturns
+	fake.recordInvocation("ReleaseSubnetLease", []interface{}{arg1, arg2})
+	fake.releaseSubnetLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *PoolLeaser) ReleaseSubnetLeaseCallCount() int {
+	fake.releaseSubnetLeaseMutex.RLock()
+	defer fake.releaseSubnetLeaseMutex.RUnlock()
+	return len(fake.releaseSubnetLeaseArgsForCall)
+}
+
+func (fake *PoolLeaser) ReleaseSubnetLeaseCalls(stub func(string, string) error) {
+	fake.releaseSubnetLeaseMutex.Lock()
+	defer fake.releaseSubnetLeaseMutex.Unlock()
+	fake.ReleaseSubnetLeaseStub = stub
+}
+
+func (fake *PoolLeaser) ReleaseSubnetLeaseArgsForCall(i int) (string, string) {
+	fake.releaseSubnetLeaseMutex.RLock()
+	defer fake.releaseSubnetLeaseMutex.RUnlock()
+	argsForCall := fake.releaseSubnetLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *PoolLeaser) ReleaseSubnetLeaseReturns(result1 error) {
+	fake.releaseSubnetLeaseMutex.Lock()
+	defer fake.releaseSubnetLeaseMutex.Unlock()
+	fake.ReleaseSubnetLeaseStub = nil
+	fake.releaseSubnetLeaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PoolLeaser) ReleaseSubnetLeaseReturnsOnCall(i int, result1 error) {
+	fake.releaseSubnetLeaseMutex.Lock()
+	defer fake.releaseSubnetLeaseMutex.Unlock()
+	fake.ReleaseSubnetLeaseStub = nil
+	if fake.releaseSubnetLeaseReturnsOnCall == nil {
+		fake.releaseSubnetLeaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.releaseSubnetLeaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PoolLeaser) RemoveReservation(arg1 string) error {
+	fake.removeReservationMutex.Lock()
+	ret, specificReturn := fake.removeReservationReturnsOnCall[len(fake.removeReservationArgsForCall)]
+	fake.removeReservationArgsForCall = append(fake.removeReservationArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.RemoveReservationStub
+	fakeReturns := fake.removeReservationReturns
+	fake.recordInvocation("RemoveReservation", []interface{}{arg1})
+	fake.removeReservationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *PoolLeaser) RemoveReservationCallCount() int {
+	fake.removeReservationMutex.RLock()
+	defer fake.removeReservationMutex.RUnlock()
+	return len(fake.removeReservationArgsForCall)
+}
+
+func (fake *PoolLeaser) RemoveReservationCalls(stub func(string) error) {
+	fake.removeReservationMutex.Lock()
+	defer fake.removeReservationMutex.Unlock()
+	fake.RemoveReservationStub = stub
+}
+
+func (fake *PoolLeaser) RemoveReservationArgsForCall(i int) string {
+	fake.removeReservationMutex.RLock()
+	defer fake.removeReservationMutex.RUnlock()
+	argsForCall := fake.removeReservationArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PoolLeaser) RemoveReservationReturns(result1 error) {
+	fake.removeReservationMutex.Lock()
+	defer fake.removeReservationMutex.Unlock()
+	fake.RemoveReservationStub = nil
+	fake.removeReservationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PoolLeaser) RemoveReservationReturnsOnCall(i int, result1 error) {
+	fake.removeReservationMutex.Lock()
+	defer fake.removeReservationMutex.Unlock()
+	fake.RemoveReservationStub = nil
+	if fake.removeReservationReturnsOnCall == nil {
+		fake.removeReservationReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeReservationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PoolLeaser) RenewSubnetLease(arg1 string, arg2 controller.Lease, arg3 *controller.HostMetadata) (*controller.Lease, error) {
+	fake.renewSubnetLeaseMutex.Lock()
+	ret, specificReturn := fake.renewSubnetLeaseReturnsOnCall[len(fake.renewSubnetLeaseArgsForCall)]
+	fake.renewSubnetLeaseArgsForCall = append(fake.renewSubnetLeaseArgsForCall, struct {
+		arg1 string
+		arg2 controller.Lease
+		arg3 *controller.HostMetadata
+	}{arg1, arg2, arg3})
+	stub := fake.RenewSubnetLeaseStub
+	fakeReturns := fake.renewSubnetLeaseReturns
+	fake.recordInvocation("RenewSubnetLease", []interface{}{arg1, arg2, arg3})
+	fake.renewSubnetLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PoolLeaser) RenewSubnetLeaseCallCount() int {
+	fake.renewSubnetLeaseMutex.RLock()
+	defer fake.renewSubnetLeaseMutex.RUnlock()
+	return len(fake.renewSubnetLeaseArgsForCall)
+}
+
+func (fake *PoolLeaser) RenewSubnetLeaseCalls(stub func(string, controller.Lease, *controller.HostMetadata) (*controller.Lease, error)) {
+	fake.renewSubnetLeaseMutex.Lock()
+	defer fake.renewSubnetLeaseMutex.Unlock()
+	fake.RenewSubnetLeaseStub = stub
+}
+
+func (fake *PoolLeaser) RenewSubnetLeaseArgsForCall(i int) (string, controller.Lease, *controller.HostMetadata) {
+	fake.renewSubnetLeaseMutex.RLock()
+	defer fake.renewSubnetLeaseMutex.RUnlock()
+	argsForCall := fake.renewSubnetLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *PoolLeaser) RenewSubnetLeaseReturns(result1 *controller.Lease, result2 error) {
+	fake.renewSubnetLeaseMutex.Lock()
+	defer fake.renewSubnetLeaseMutex.Unlock()
+	fake.RenewSubnetLeaseStub = nil
+	fake.renewSubnetLeaseReturns = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) RenewSubnetLeaseReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.renewSubnetLeaseMutex.Lock()
+	defer fake.renewSubnetLeaseMutex.Unlock()
+	fake.RenewSubnetLeaseStub = nil
+	if fake.renewSubnetLeaseReturnsOnCall == nil {
+		fake.renewSubnetLeaseReturnsOnCall = make(map[int]struct {
+			result1 *controller.Lease
+			result2 error
+		})
+	}
+	fake.renewSubnetLeaseReturnsOnCall[i] = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) Reservations() ([]controller.Reservation, error) {
+	fake.reservationsMutex.Lock()
+	ret, specificReturn := fake.reservationsReturnsOnCall[len(fake.reservationsArgsForCall)]
+	fake.reservationsArgsForCall = append(fake.reservationsArgsForCall, struct {
+	}{})
+	stub := fake.ReservationsStub
+	fakeReturns := fake.reservationsReturns
+	fake.recordInvocation("Reservations", []interface{}{})
+	fake.reservationsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PoolLeaser) ReservationsCallCount() int {
+	fake.reservationsMutex.RLock()
+	defer fake.reservationsMutex.RUnlock()
+	return len(fake.reservationsArgsForCall)
+}
+
+func (fake *PoolLeaser) ReservationsCalls(stub func() ([]controller.Reservation, error)) {
+	fake.reservationsMutex.Lock()
+	defer fake.reservationsMutex.Unlock()
+	fake.ReservationsStub = stub
+}
+
+func (fake *PoolLeaser) ReservationsReturns(result1 []controller.Reservation, result2 error) {
+	fake.reservationsMutex.Lock()
+	defer fake.reservationsMutex.Unlock()
+	fake.ReservationsStub = nil
+	fake.reservationsReturns = struct {
+		result1 []controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) ReservationsReturnsOnCall(i int, result1 []controller.Reservation, result2 error) {
+	fake.reservationsMutex.Lock()
+	defer fake.reservationsMutex.Unlock()
+	fake.ReservationsStub = nil
+	if fake.reservationsReturnsOnCall == nil {
+		fake.reservationsReturnsOnCall = make(map[int]struct {
+			result1 []controller.Reservation
+			result2 error
+		})
+	}
+	fake.reservationsReturnsOnCall[i] = struct {
+		result1 []controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) ReserveSubnet(arg1 controller.Reservation) error {
+	fake.reserveSubnetMutex.Lock()
+	ret, specificReturn := fake.reserveSubnetReturnsOnCall[len(fake.reserveSubnetArgsForCall)]
+	fake.reserveSubnetArgsForCall = append(fake.reserveSubnetArgsForCall, struct {
+		arg1 controller.Reservation
+	}{arg1})
+	stub := fake.ReserveSubnetStub
+	fakeReturns := fake.reserveSubnetReturns
+	fake.recordInvocation("ReserveSubnet", []interface{}{arg1})
+	fake.reserveSubnetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *PoolLeaser) ReserveSubnetCallCount() int {
+	fake.reserveSubnetMutex.RLock()
+	defer fake.reserveSubnetMutex.RUnlock()
+	return len(fake.reserveSubnetArgsForCall)
+}
+
+func (fake *PoolLeaser) ReserveSubnetCalls(stub func(controller.Reservation) error) {
+	fake.reserveSubnetMutex.Lock()
+	defer fake.reserveSubnetMutex.Unlock()
+	fake.ReserveSubnetStub = stub
+}
+
+func (fake *PoolLeaser) ReserveSubnetArgsForCall(i int) controller.Reservation {
+	fake.reserveSubnetMutex.RLock()
+	defer fake.reserveSubnetMutex.RUnlock()
+	argsForCall := fake.reserveSubnetArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PoolLeaser) ReserveSubnetReturns(result1 error) {
+	fake.reserveSubnetMutex.Lock()
+	defer fake.reserveSubnetMutex.Unlock()
+	fake.ReserveSubnetStub = nil
+	fake.reserveSubnetReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PoolLeaser) ReserveSubnetReturnsOnCall(i int, result1 error) {
+	fake.reserveSubnetMutex.Lock()
+	defer fake.reserveSubnetMutex.Unlock()
+	fake.ReserveSubnetStub = nil
+	if fake.reserveSubnetReturnsOnCall == nil {
+		fake.reserveSubnetReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.reserveSubnetReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PoolLeaser) RoutableLeases() ([]controller.Lease, error) {
+	fake.routableLeasesMutex.Lock()
+	ret, specificReturn := fake.routableLeasesReturnsOnCall[len(fake.routableLeasesArgsForCall)]
+	fake.routableLeasesArgsForCall = append(fake.routableLeasesArgsForCall, struct {
+	}{})
+	stub := fake.RoutableLeasesStub
+	fakeReturns := fake.routableLeasesReturns
+	fake.recordInvocation("RoutableLeases", []interface{}{})
+	fake.routableLeasesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PoolLeaser) RoutableLeasesCallCount() int {
+	fake.routableLeasesMutex.RLock()
+	defer fake.routableLeasesMutex.RUnlock()
+	return len(fake.routableLeasesArgsForCall)
+}
+
+func (fake *PoolLeaser) RoutableLeasesCalls(stub func() ([]controller.Lease, error)) {
+	fake.routableLeasesMutex.Lock()
+	defer fake.routableLeasesMutex.Unlock()
+	fake.RoutableLeasesStub = stub
+}
+
+func (fake *PoolLeaser) RoutableLeasesReturns(result1 []controller.Lease, result2 error) {
+	fake.routableLeasesMutex.Lock()
+	defer fake.routableLeasesMutex.Unlock()
+	fake.RoutableLeasesStub = nil
+	fake.routableLeasesReturns = struct {
+		result1 []controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) RoutableLeasesReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
+	fake.routableLeasesMutex.Lock()
+	defer fake.routableLeasesMutex.Unlock()
+	fake.RoutableLeasesStub = nil
+	if fake.routableLeasesReturnsOnCall == nil {
+		fake.routableLeasesReturnsOnCall = make(map[int]struct {
+			result1 []controller.Lease
+			result2 error
+		})
+	}
+	fake.routableLeasesReturnsOnCall[i] = struct {
+		result1 []controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) Usage() (controller.PoolUsage, error) {
+	fake.usageMutex.Lock()
+	ret, specificReturn := fake.usageReturnsOnCall[len(fake.usageArgsForCall)]
+	fake.usageArgsForCall = append(fake.usageArgsForCall, struct {
+	}{})
+	stub := fake.UsageStub
+	fakeReturns := fake.usageReturns
+	fake.recordInvocation("Usage", []interface{}{})
+	fake.usageMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PoolLeaser) UsageCallCount() int {
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
+	return len(fake.usageArgsForCall)
+}
+
+func (fake *PoolLeaser) UsageCalls(stub func() (controller.PoolUsage, error)) {
+	fake.usageMutex.Lock()
+	defer fake.usageMutex.Unlock()
+	fake.UsageStub = stub
+}
+
+func (fake *PoolLeaser) UsageReturns(result1 controller.PoolUsage, result2 error) {
+	fake.usageMutex.Lock()
+	defer fake.usageMutex.Unlock()
+	fake.UsageStub = nil
+	fake.usageReturns = struct {
+		result1 controller.PoolUsage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) UsageReturnsOnCall(i int, result1 controller.PoolUsage, result2 error) {
+	fake.usageMutex.Lock()
+	defer fake.usageMutex.Unlock()
+	fake.UsageStub = nil
+	if fake.usageReturnsOnCall == nil {
+		fake.usageReturnsOnCall = make(map[int]struct {
+			result1 controller.PoolUsage
+			result2 error
+		})
+	}
+	fake.usageReturnsOnCall[i] = struct {
+		result1 controller.PoolUsage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PoolLeaser) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.acquireSubnetLeaseMutex.RLock()
+	defer fake.acquireSubnetLeaseMutex.RUnlock()
+	fake.hostLeasesMutex.RLock()
+	defer fake.hostLeasesMutex.RUnlock()
+	fake.leaseRecordsMutex.RLock()
+	defer fake.leaseRecordsMutex.RUnlock()
+	fake.releaseSubnetLeaseMutex.RLock()
+	defer fake.releaseSubnetLeaseMutex.RUnlock()
+	fake.removeReservationMutex.RLock()
+	defer fake.removeReservationMutex.RUnlock()
+	fake.renewSubnetLeaseMutex.RLock()
+	defer fake.renewSubnetLeaseMutex.RUnlock()
+	fake.reservationsMutex.RLock()
+	defer fake.reservationsMutex.RUnlock()
+	fake.reserveSubnetMutex.RLock()
+	defer fake.reserveSubnetMutex.RUnlock()
+	fake.routableLeasesMutex.RLock()
+	defer fake.routableLeasesMutex.RUnlock()
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *PoolLeaser) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,269 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type Topology struct {
+	GetAvailableBlockStub        func(*controller.HostMetadata, []string) string
+	getAvailableBlockMutex       sync.RWMutex
+	getAvailableBlockArgsForCall []struct {
+		arg1 *controller.HostMetadata
+		arg2 []string
+	}
+	getAvailableBlockReturns struct {
+		result1 string
+	}
+	getAvailableBlockReturnsOnCall map[int]struct {
+		result1 string
+	}
+	PartitionStub        func(*controller.HostMetadata) (string, bool)
+	partitionMutex       sync.RWMutex
+	partitionArgsForCall []struct {
+		arg1 *controller.HostMetadata
+	}
+	partitionReturns struct {
+		result1 string
+		result2 bool
+	}
+	partitionReturnsOnCall map[int]struct {
+		result1 string
+		result2 bool
+	}
+	PartitionOfStub        func(string) string
+	partitionOfMutex       sync.RWMutex
+	partitionOfArgsForCall []struct {
+		arg1 string
+	}
+	partitionOfReturns struct {
+		result1 string
+	}
+	partitionOfReturnsOnCall map[int]struct {
+		result1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *Topology) GetAvailableBlock(arg1 *controller.HostMetadata, arg2 []string) string {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.getAvailableBlockMutex.Lock()
+	ret, specificReturn := fake.getAvailableBlockReturnsOnCall[len(fake.getAvailableBlockArgsForCall)]
+	fake.getAvailableBlockArgsForCall = append(fake.getAvailableBlockArgsForCall, struct {
+		arg1 *controller.HostMetadata
+		arg2 []string
+	}{arg1, arg2Copy})
+	stub := fake.GetAvailableBlockStub
+	fakeReturns := fake.getAvailableBlockReturns
+	fake.recordInvocation("GetAvailableBlock", []interface{}{arg1, arg2Copy})
+	fake.getAvailableBlockMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Topology) GetAvailableBlockCallCount() int {
+	fake.getAvailableBlockMutex.RLock()
+	defer fake.getAvailableBlockMutex.RUnlock()
+	return len(fake.getAvailableBlockArgsForCall)
+}
+
+func (fake *Topology) GetAvailableBlockCalls(stub func(*controller.HostMetadata, []string) string) {
+	fake.getAvailableBlockMutex.Lock()
+	defer fake.getAvailableBlockMutex.Unlock()
+	fake.GetAvailableBlockStub = stub
+}
+
+func (fake *Topology) GetAvailableBlockArgsForCall(i int) (*controller.HostMetadata, []string) {
+	fake.getAvailableBlockMutex.RLock()
+	defer fake.getAvailableBlockMutex.RUnlock()
+	argsForCall := fake.getAvailableBlockArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Topology) GetAvailableBlockReturns(result1 string) {
+	fake.getAvailableBlockMutex.Lock()
+	defer fake.getAvailableBlockMutex.Unlock()
+	fake.GetAvailableBlockStub = nil
+	fake.getAvailableBlockReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Topology) GetAvailableBlockReturnsOnCall(i int, result1 string) {
+	fake.getAvailableBlockMutex.Lock()
+	defer fake.getAvailableBlockMutex.Unlock()
+	fake.GetAvailableBlockStub = nil
+	if fake.getAvailableBlockReturnsOnCall == nil {
+		fake.getAvailableBlockReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.getAvailableBlockReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Topology) Partition(arg1 *controller.HostMetadata) (string, bool) {
+	fake.partitionMutex.Lock()
+	ret, specificReturn := fake.partitionReturnsOnCall[len(fake.partitionArgsForCall)]
+	fake.partitionArgsForCall = append(fake.partitionArgsForCall, struct {
+		arg1 *controller.HostMetadata
+	}{arg1})
+	stub := fake.PartitionStub
+	fakeReturns := fake.partitionReturns
+	fake.recordInvocation("Partition", []interface{}{arg1})
+	fake.partitionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Topology) PartitionCallCount() int {
+	fake.partitionMutex.RLock()
+	defer fake.partitionMutex.RUnlock()
+	return len(fake.partitionArgsForCall)
+}
+
+func (fake *Topology) PartitionCalls(stub func(*controller.HostMetadata) (string, bool)) {
+	fake.partitionMutex.Lock()
+	defer fake.partitionMutex.Unlock()
+	fake.PartitionStub = stub
+}
+
+func (fake *Topology) PartitionArgsForCall(i int) *controller.HostMetadata {
+	fake.partitionMutex.RLock()
+	defer fake.partitionMutex.RUnlock()
+	argsForCall := fake.partitionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Topology) PartitionReturns(result1 string, result2 bool) {
+	fake.partitionMutex.Lock()
+	defer fake.partitionMutex.Unlock()
+	fake.PartitionStub = nil
+	fake.partitionReturns = struct {
+		result1 string
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *Topology) PartitionReturnsOnCall(i int, result1 string, result2 bool) {
+	fake.partitionMutex.Lock()
+	defer fake.partitionMutex.Unlock()
+	fake.PartitionStub = nil
+	if fake.partitionReturnsOnCall == nil {
+		fake.partitionReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 bool
+		})
+	}
+	fake.partitionReturnsOnCall[i] = struct {
+		result1 string
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *Topology) PartitionOf(arg1 string) string {
+	fake.partitionOfMutex.Lock()
+	ret, specificReturn := fake.partitionOfReturnsOnCall[len(fake.partitionOfArgsForCall)]
+	fake.partitionOfArgsForCall = append(fake.partitionOfArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.PartitionOfStub
+	fakeReturns := fake.partitionOfReturns
+	fake.recordInvocation("PartitionOf", []interface{}{arg1})
+	fake.partitionOfMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Topology) PartitionOfCallCount() int {
+	fake.partitionOfMutex.RLock()
+	defer fake.partitionOfMutex.RUnlock()
+	return len(fake.partitionOfArgsForCall)
+}
+
+func (fake *Topology) PartitionOfCalls(stub func(string) string) {
+	fake.partitionOfMutex.Lock()
+	defer fake.partitionOfMutex.Unlock()
+	fake.PartitionOfStub = stub
+}
+
+func (fake *Topology) PartitionOfArgsForCall(i int) string {
+	fake.partitionOfMutex.RLock()
+	defer fake.partitionOfMutex.RUnlock()
+	argsForCall := fake.partitionOfArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Topology) PartitionOfReturns(result1 string) {
+	fake.partitionOfMutex.Lock()
+	defer fake.partitionOfMutex.Unlock()
+	fake.PartitionOfStub = nil
+	fake.partitionOfReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Topology) PartitionOfReturnsOnCall(i int, result1 string) {
+	fake.partitionOfMutex.Lock()
+	defer fake.partitionOfMutex.Unlock()
+	fake.PartitionOfStub = nil
+	if fake.partitionOfReturnsOnCall == nil {
+		fake.partitionOfReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.partitionOfReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Topology) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getAvailableBlockMutex.RLock()
+	defer fake.getAvailableBlockMutex.RUnlock()
+	fake.partitionMutex.RLock()
+	defer fake.partitionMutex.RUnlock()
+	fake.partitionOfMutex.RLock()
+	defer fake.partitionOfMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *Topology) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
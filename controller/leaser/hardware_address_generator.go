@@ -11,3 +11,7 @@ type HardwareAddressGenerator struct{}
 func (g *HardwareAddressGenerator) GenerateForVTEP(containerIP net.IP) (net.HardwareAddr, error) {
 	return hwaddr.GenerateHardwareAddr4(containerIP, []byte{0xee, 0xee})
 }
+
+func (g *HardwareAddressGenerator) GenerateForVTEPV6(overlaySubnet *net.IPNet) (net.HardwareAddr, error) {
+	return hwaddr.GenerateHardwareAddr6(overlaySubnet, []byte{0xee, 0xee})
+}
@@ -1,6 +1,7 @@
 package leaser
 
 import (
+	"errors"
 	"fmt"
 	"net"
 
@@ -13,17 +14,35 @@ import (
 type databaseHandler interface {
 	AddEntry(controller.Lease) error
 	DeleteEntry(string) error
+	DeleteExpiredEntry(string, int) error
+	Generation() (int64, error)
 	LeaseForUnderlayIP(string) (*controller.Lease, error)
 	LastRenewedAtForUnderlayIP(string) (int64, error)
 	RenewLeaseForUnderlayIP(string) error
 	All() ([]controller.Lease, error)
 	AllBlockSubnets() ([]controller.Lease, error)
+	AllBlockSubnetsV6() ([]controller.Lease, error)
 	AllSingleIPSubnets() ([]controller.Lease, error)
 	AllActive(int) ([]controller.Lease, error)
+	LeaseRecords(int) ([]controller.LeaseRecord, error)
 	OldestExpiredBlockSubnet(int) (*controller.Lease, error)
+	OldestExpiredBlockSubnetV6(int) (*controller.Lease, error)
 	OldestExpiredSingleIP(int) (*controller.Lease, error)
+	AddReservation(controller.Reservation) error
+	DeleteReservation(string) error
+	ReservationForUnderlayIP(string) (*controller.Reservation, error)
+	AllReservations() ([]controller.Reservation, error)
+	AddEvent(controller.LeaseEvent) error
+	SetHostForUnderlayIP(string, controller.HostMetadata) error
+	QuarantineSubnet(string, string, int) error
+	QuarantinedSubnets() ([]controller.QuarantinedSubnet, error)
+	WithAllocationLock(func(database.LeaseStore) error) error
 }
 
+// errNoSubnetAvailable rolls back an acquisition that found the pool full, so
+// that an expired lease reclaimed for one address family is not lost.
+var errNoSubnetAvailable = errors.New("no subnet available")
+
 //go:generate counterfeiter -o fakes/lease_validator.go --fake-name LeaseValidator . leaseValidator
 type leaseValidator interface {
 	Validate(controller.Lease) error
@@ -34,25 +53,80 @@ type cidrPool interface {
 	GetAvailableBlock([]string) string
 	GetAvailableSingleIP([]string) string
 	IsMember(string) bool
+	IsBlockMember(string) bool
+	IsActive(string) bool
+	IsExcluded(string) bool
+	BlockPoolSize() int
+	SingleIPPoolSize() int
+}
+
+//go:generate counterfeiter -o fakes/topology.go --fake-name Topology . topology
+type topology interface {
+	Partition(*controller.HostMetadata) (string, bool)
+	PartitionOf(string) string
+	GetAvailableBlock(*controller.HostMetadata, []string) string
 }
 
 //go:generate counterfeiter -o fakes/hardwareAddressGenerator.go --fake-name HardwareAddressGenerator . hardwareAddressGenerator
 type hardwareAddressGenerator interface {
 	GenerateForVTEP(containerIP net.IP) (net.HardwareAddr, error)
+	GenerateForVTEPV6(overlaySubnet *net.IPNet) (net.HardwareAddr, error)
+}
+
+//go:generate counterfeiter -o fakes/metric_sender.go --fake-name MetricSender . metricSender
+type metricSender interface {
+	IncrementCounter(name string)
 }
 
 type LeaseController struct {
+	Pool string
+	// DatabaseHandler is a TrackedStore over CIDRPool, CIDRPoolV6 and
+	// Topology, which keeps the subnets of the leases taken in them: the
+	// pools only hand out subnets that are not leased if it is.
 	DatabaseHandler            databaseHandler
 	HardwareAddressGenerator   hardwareAddressGenerator
 	AcquireSubnetLeaseAttempts int
 	CIDRPool                   cidrPool
+	CIDRPoolV6                 cidrPool
 	LeaseValidator             leaseValidator
 	LeaseExpirationSeconds     int
 	Logger                     lager.Logger
+	MetricSender               metricSender
+
+	// QuarantineSeconds holds released subnets back from other underlay ips,
+	// so that routes to the old one that linger on other cells do not send
+	// traffic to the wrong host. Zero disables the quarantine.
+	QuarantineSeconds int
+
+	// VNI is handed out with every lease of the pool. It is not stored with
+	// the leases, so that changing it moves every host of the pool to the new
+	// vni as it renews. Zero leaves the vni to the hosts.
+	VNI int
+
+	// Topology, if set, hands hosts blocks from the partition that matches
+	// their metadata before the rest of CIDRPool, which it withholds from.
+	Topology topology
 }
 
-func (c *LeaseController) ReleaseSubnetLease(underlayIP string) error {
-	err := c.DatabaseHandler.DeleteEntry(underlayIP)
+func (c *LeaseController) ReleaseSubnetLease(actor, underlayIP string) error {
+	err := c.DatabaseHandler.WithAllocationLock(func(store database.LeaseStore) error {
+		lease, err := store.LeaseForUnderlayIP(underlayIP)
+		if err != nil {
+			return fmt.Errorf("getting lease for underlay ip: %s", err)
+		}
+		err = store.DeleteEntry(underlayIP)
+		if err != nil {
+			return err
+		}
+		if lease == nil {
+			lease = &controller.Lease{UnderlayIP: underlayIP}
+		}
+		err = c.quarantine(store, *lease)
+		if err != nil {
+			return err
+		}
+		return store.AddEvent(leaseEvent(controller.LeaseEventReleased, *lease, actor, "released by request"))
+	})
 	if err == database.RecordNotAffectedError {
 		c.Logger.Debug("lease-not-found", lager.Data{"underlay_ip": underlayIP})
 		return nil
@@ -65,73 +139,179 @@ func (c *LeaseController) ReleaseSubnetLease(underlayIP string) error {
 	return err
 }
 
-func (c *LeaseController) AcquireSubnetLease(underlayIP string, singleOverlayIP bool) (*controller.Lease, error) {
+func (c *LeaseController) AcquireSubnetLease(actor string, request controller.AcquireLeaseRequest) (*controller.Lease, error) {
 	var err error
 	var lease *controller.Lease
 
-	if net.ParseIP(underlayIP).To4() == nil {
-		return nil, fmt.Errorf("invalid ipv4 address: %s", underlayIP)
+	underlayIP := request.UnderlayIP
+	if net.ParseIP(underlayIP) == nil {
+		return nil, fmt.Errorf("invalid ip address: %s", underlayIP)
 	}
 
-	lease, err = c.DatabaseHandler.LeaseForUnderlayIP(underlayIP)
+	wantV4, wantV6, err := c.requestedFamilies(request)
 	if err != nil {
-		return nil, fmt.Errorf("getting lease for underlay ip: %s", err)
+		return nil, err
 	}
 
-	if lease != nil {
-		if c.CIDRPool.IsMember(lease.OverlaySubnet) {
-			c.Logger.Info("lease-renewed", lager.Data{"lease": lease})
+	for numErrs := 0; numErrs < c.AcquireSubnetLeaseAttempts; numErrs++ {
+		var renewed bool
+		err = c.DatabaseHandler.WithAllocationLock(func(store database.LeaseStore) error {
+			var err error
+			lease, renewed, err = c.acquire(store, actor, request, wantV4, wantV6)
+			if err == nil && lease == nil {
+				return errNoSubnetAvailable
+			}
+			if err != nil {
+				return err
+			}
+			return setHost(store, lease.UnderlayIP, request.Host)
+		})
+		if err == errNoSubnetAvailable {
+			return nil, nil
+		}
+		if err == nil {
+			lease = c.withVNI(lease)
+			if renewed {
+				c.Logger.Info("lease-renewed", lager.Data{"lease": lease})
+			} else {
+				c.Logger.Info("lease-acquired", lager.Data{"lease": lease})
+			}
 			return lease, nil
 		}
-		err := c.DatabaseHandler.DeleteEntry(underlayIP)
-		if err != nil {
-			return nil, fmt.Errorf("deleting lease for underlay ip %s: %s", underlayIP, err)
+		if numErrs+1 < c.AcquireSubnetLeaseAttempts {
+			c.MetricSender.IncrementCounter("acquireRetry")
 		}
-		c.Logger.Info("lease-deleted", lager.Data{"lease": lease})
 	}
 
-	for numErrs := 0; numErrs < c.AcquireSubnetLeaseAttempts; numErrs++ {
-		lease, err = c.tryAcquireLease(underlayIP, singleOverlayIP)
-		if lease != nil {
-			c.Logger.Info("lease-acquired", lager.Data{"lease": lease})
-			return lease, nil
+	return nil, err
+}
+
+// acquire runs while the pool's allocation lock is held, so that the subnets
+// it finds free cannot be taken by another acquisition before it adds its
+// lease. It returns the existing lease, and true, if that lease still fits
+// the request.
+func (c *LeaseController) acquire(store database.LeaseStore, actor string, request controller.AcquireLeaseRequest, wantV4, wantV6 bool) (*controller.Lease, bool, error) {
+	underlayIP := request.UnderlayIP
+	reservedSubnet, err := c.reservedSubnet(store, request, wantV4)
+	if err != nil {
+		return nil, false, err
+	}
+
+	lease, err := store.LeaseForUnderlayIP(underlayIP)
+	if err != nil {
+		return nil, false, fmt.Errorf("getting lease for underlay ip: %s", err)
+	}
+
+	if lease != nil {
+		if lease.Pool == c.Pool && c.isMember(*lease) && (lease.OverlaySubnet != "") == wantV4 && (lease.OverlaySubnetV6 != "") == wantV6 &&
+			(reservedSubnet == "" || lease.OverlaySubnet == reservedSubnet) {
+			err := store.AddEvent(leaseEvent(controller.LeaseEventRenewed, *lease, actor, "acquired again by its underlay ip"))
+			if err != nil {
+				return nil, false, err
+			}
+			return lease, true, nil
 		}
+		err := store.DeleteEntry(underlayIP)
+		if err != nil {
+			return nil, false, fmt.Errorf("deleting lease for underlay ip %s: %s", underlayIP, err)
+		}
+		err = c.quarantine(store, *lease)
+		if err != nil {
+			return nil, false, err
+		}
+		err = store.AddEvent(leaseEvent(controller.LeaseEventReleased, *lease, actor, "replaced by a lease that fits the request"))
+		if err != nil {
+			return nil, false, err
+		}
+		c.Logger.Info("lease-deleted", lager.Data{"lease": lease})
 	}
 
-	return nil, err
+	lease, reason, err := c.tryAcquireLease(store, actor, request, reservedSubnet, wantV4, wantV6)
+	if err != nil || lease == nil {
+		return nil, false, err
+	}
+	err = store.AddEvent(leaseEvent(controller.LeaseEventAcquired, *lease, actor, reason))
+	if err != nil {
+		return nil, false, err
+	}
+	return lease, false, nil
 }
 
-func (c *LeaseController) RenewSubnetLease(lease controller.Lease) error {
+func (c *LeaseController) RenewSubnetLease(actor string, lease controller.Lease, host *controller.HostMetadata) (*controller.Lease, error) {
 	err := c.LeaseValidator.Validate(lease)
 	if err != nil {
-		return controller.NonRetriableError(err.Error())
+		return nil, controller.NonRetriableError(err.Error())
+	}
+	if subnet := c.excludedSubnet(lease); subnet != "" {
+		return nil, controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is in an excluded range", subnet))
 	}
+	// the vni is the pool's, not the host's: a host still in a vni the pool
+	// has moved away from renews, and learns the new vni from the response
+	lease.VNI = 0
 
 	existingLease, err := c.DatabaseHandler.LeaseForUnderlayIP(lease.UnderlayIP)
 	if err != nil {
-		return fmt.Errorf("getting lease for underlay ip: %s", err)
+		return nil, fmt.Errorf("getting lease for underlay ip: %s", err)
 	}
 	if existingLease == nil {
-		err := c.DatabaseHandler.AddEntry(lease)
+		// under the allocation lock, so that the subnet is not reserved for or
+		// handed to another underlay ip between the checks and the insert
+		err := c.DatabaseHandler.WithAllocationLock(func(store database.LeaseStore) error {
+			return c.restore(store, actor, lease)
+		})
 		if err != nil {
-			return controller.NonRetriableError(err.Error())
+			return nil, err
 		}
 	} else if lease != *existingLease {
-		return controller.NonRetriableError("lease mismatch")
+		return nil, controller.NonRetriableError("lease mismatch")
 	}
 
 	err = c.DatabaseHandler.RenewLeaseForUnderlayIP(lease.UnderlayIP)
 	if err != nil {
-		return fmt.Errorf("renewing lease for underlay ip: %s", err)
+		return nil, fmt.Errorf("renewing lease for underlay ip: %s", err)
 	}
 	lastRenewedAt, err := c.DatabaseHandler.LastRenewedAtForUnderlayIP(lease.UnderlayIP)
 	if err != nil {
-		return fmt.Errorf("getting last renewed at: %s", err)
+		return nil, fmt.Errorf("getting last renewed at: %s", err)
+	}
+	err = setHost(c.DatabaseHandler, lease.UnderlayIP, host)
+	if err != nil {
+		return nil, err
 	}
 
 	c.Logger.Debug("lease-renewed", lager.Data{"lease": lease, "last_renewed_at": lastRenewedAt})
 
-	return nil
+	return c.withVNI(&lease), nil
+}
+
+// restore adds back a lease that is renewed after it was deleted, unless its
+// subnet has since been reserved for another underlay ip or is in quarantine.
+func (c *LeaseController) restore(store database.LeaseStore, actor string, lease controller.Lease) error {
+	reservations, err := store.AllReservations()
+	if err != nil {
+		return fmt.Errorf("getting all reservations: %s", err)
+	}
+	for _, reservation := range reservations {
+		if reservation.OverlaySubnet == lease.OverlaySubnet && reservation.UnderlayIP != lease.UnderlayIP {
+			return controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is reserved for %s", lease.OverlaySubnet, reservation.UnderlayIP))
+		}
+	}
+
+	quarantined, err := c.quarantinedSubnets(store)
+	if err != nil {
+		return err
+	}
+	for _, subnet := range quarantined {
+		if subnet == lease.OverlaySubnet || subnet == lease.OverlaySubnetV6 {
+			return controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is in quarantine", subnet))
+		}
+	}
+
+	err = store.AddEntry(lease)
+	if err != nil {
+		return controller.NonRetriableError(err.Error())
+	}
+	return store.AddEvent(leaseEvent(controller.LeaseEventAcquired, lease, actor, "restored by renewal"))
 }
 
 func (c *LeaseController) RoutableLeases() ([]controller.Lease, error) {
@@ -140,73 +320,436 @@ func (c *LeaseController) RoutableLeases() ([]controller.Lease, error) {
 		return nil, fmt.Errorf("getting all leases: %s", err)
 	}
 
+	withVNI := make([]controller.Lease, 0, len(leases))
+	for _, lease := range leases {
+		withVNI = append(withVNI, *c.withVNI(&lease))
+	}
+	return withVNI, nil
+}
+
+// HostLeases are the routable leases with the metadata of their hosts.
+func (c *LeaseController) HostLeases() ([]controller.HostLease, error) {
+	records, err := c.DatabaseHandler.LeaseRecords(c.LeaseExpirationSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("getting lease records: %s", err)
+	}
+
+	leases := []controller.HostLease{}
+	for _, record := range records {
+		if !record.Expired {
+			leases = append(leases, controller.HostLease{Lease: *c.withVNI(&record.Lease), Host: record.Host})
+		}
+	}
 	return leases, nil
 }
 
-func (c *LeaseController) tryAcquireLease(underlayIP string, singleOverlayIP bool) (*controller.Lease, error) {
-	var subnet string
-	if singleOverlayIP {
-		var err error
-		subnet, err = c.tryAcquireAvailableSingleIPSubnet(underlayIP)
+func (c *LeaseController) ReserveSubnet(reservation controller.Reservation) error {
+	if net.ParseIP(reservation.UnderlayIP) == nil {
+		return controller.NonRetriableError(fmt.Sprintf("invalid ip address: %s", reservation.UnderlayIP))
+	}
+	if c.CIDRPool.IsExcluded(reservation.OverlaySubnet) {
+		return controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is in an excluded range", reservation.OverlaySubnet))
+	}
+	if !c.CIDRPool.IsBlockMember(reservation.OverlaySubnet) {
+		return controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is not a block of the pool", reservation.OverlaySubnet))
+	}
+	if !c.CIDRPool.IsActive(reservation.OverlaySubnet) {
+		return controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is in a draining network", reservation.OverlaySubnet))
+	}
+
+	// under the allocation lock, so that no acquisition leases the subnet
+	// between the check that it is free and the insert
+	err := c.DatabaseHandler.WithAllocationLock(func(store database.LeaseStore) error {
+		return reserve(store, reservation)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Info("reservation-added", lager.Data{"reservation": reservation})
+	return nil
+}
+
+// reserve adds the reservation if neither its underlay ip nor its subnet is
+// reserved already, and its subnet is not leased to another underlay ip.
+func reserve(store database.LeaseStore, reservation controller.Reservation) error {
+	existing, err := store.ReservationForUnderlayIP(reservation.UnderlayIP)
+	if err != nil {
+		return fmt.Errorf("getting reservation for underlay ip: %s", err)
+	}
+	if existing != nil {
+		return controller.NonRetriableError(fmt.Sprintf("underlay ip %s already has a reservation", reservation.UnderlayIP))
+	}
+
+	reservations, err := store.AllReservations()
+	if err != nil {
+		return fmt.Errorf("getting all reservations: %s", err)
+	}
+	for _, r := range reservations {
+		if r.OverlaySubnet == reservation.OverlaySubnet {
+			return controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is already reserved", reservation.OverlaySubnet))
+		}
+	}
+
+	leases, err := store.AllBlockSubnets()
+	if err != nil {
+		return fmt.Errorf("getting all subnets: %s", err)
+	}
+	for _, lease := range leases {
+		if lease.OverlaySubnet == reservation.OverlaySubnet && lease.UnderlayIP != reservation.UnderlayIP {
+			return controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is leased to %s", reservation.OverlaySubnet, lease.UnderlayIP))
+		}
+	}
+
+	return store.AddReservation(reservation)
+}
+
+func (c *LeaseController) RemoveReservation(underlayIP string) error {
+	err := c.DatabaseHandler.DeleteReservation(underlayIP)
+	if err == database.RecordNotAffectedError {
+		c.Logger.Debug("reservation-not-found", lager.Data{"underlay_ip": underlayIP})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("remove reservation: %s", err)
+	}
+
+	c.Logger.Info("reservation-removed", lager.Data{"underlay_ip": underlayIP})
+	return nil
+}
+
+func (c *LeaseController) Reservations() ([]controller.Reservation, error) {
+	reservations, err := c.DatabaseHandler.AllReservations()
+	if err != nil {
+		return nil, fmt.Errorf("getting all reservations: %s", err)
+	}
+
+	return reservations, nil
+}
+
+func (c *LeaseController) LeaseRecords() ([]controller.LeaseRecord, error) {
+	records, err := c.DatabaseHandler.LeaseRecords(c.LeaseExpirationSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("getting lease records: %s", err)
+	}
+
+	withVNI := make([]controller.LeaseRecord, 0, len(records))
+	for _, record := range records {
+		record.Lease = *c.withVNI(&record.Lease)
+		withVNI = append(withVNI, record)
+	}
+	return withVNI, nil
+}
+
+// Usage counts the subnets of the active networks by what holds them. A
+// subnet is counted once, as the first of leased, reserved and quarantined
+// that applies. Leases left in draining networks are not counted.
+func (c *LeaseController) Usage() (controller.PoolUsage, error) {
+	records, err := c.DatabaseHandler.LeaseRecords(c.LeaseExpirationSeconds)
+	if err != nil {
+		return controller.PoolUsage{}, fmt.Errorf("getting lease records: %s", err)
+	}
+	reservations, err := c.DatabaseHandler.AllReservations()
+	if err != nil {
+		return controller.PoolUsage{}, fmt.Errorf("getting all reservations: %s", err)
+	}
+	quarantined, err := c.quarantinedSubnets(c.DatabaseHandler)
+	if err != nil {
+		return controller.PoolUsage{}, err
+	}
+
+	usage := controller.PoolUsage{
+		Pool:      c.Pool,
+		Blocks:    controller.SubnetUsage{Total: c.CIDRPool.BlockPoolSize()},
+		SingleIPs: controller.SubnetUsage{Total: c.CIDRPool.SingleIPPoolSize()},
+	}
+	counted := map[string]bool{}
+	usageOf := func(subnet string) *controller.SubnetUsage {
+		if counted[subnet] || !c.CIDRPool.IsActive(subnet) {
+			return nil
+		}
+		counted[subnet] = true
+		if c.CIDRPool.IsBlockMember(subnet) {
+			return &usage.Blocks
+		}
+		return &usage.SingleIPs
+	}
+	for _, record := range records {
+		if u := usageOf(record.OverlaySubnet); u != nil && record.Expired {
+			u.Expired++
+		} else if u != nil {
+			u.Active++
+		}
+	}
+	for _, reservation := range reservations {
+		if u := usageOf(reservation.OverlaySubnet); u != nil {
+			u.Reserved++
+		}
+	}
+	for _, subnet := range quarantined {
+		if u := usageOf(subnet); u != nil {
+			u.Quarantined++
+		}
+	}
+
+	usage.Blocks.Free = freeSubnets(usage.Blocks)
+	usage.SingleIPs.Free = freeSubnets(usage.SingleIPs)
+	return usage, nil
+}
+
+func freeSubnets(usage controller.SubnetUsage) int {
+	free := usage.Total - usage.Active - usage.Expired - usage.Quarantined - usage.Reserved
+	if free < 0 {
+		return 0
+	}
+	return free
+}
+
+// reservedSubnet returns the subnet reserved for the underlay ip in this pool,
+// if the request is for an ipv4 block. Reservations in a network that has
+// since been drained are ignored.
+func (c *LeaseController) reservedSubnet(store database.LeaseStore, request controller.AcquireLeaseRequest, wantV4 bool) (string, error) {
+	if !wantV4 || request.SingleOverlayIP {
+		return "", nil
+	}
+	reservation, err := store.ReservationForUnderlayIP(request.UnderlayIP)
+	if err != nil {
+		return "", fmt.Errorf("getting reservation for underlay ip: %s", err)
+	}
+	if reservation == nil || reservation.Pool != c.Pool || !c.CIDRPool.IsActive(reservation.OverlaySubnet) {
+		return "", nil
+	}
+	return reservation.OverlaySubnet, nil
+}
+
+func (c *LeaseController) requestedFamilies(request controller.AcquireLeaseRequest) (bool, bool, error) {
+	var wantV4, wantV6 bool
+	switch request.IPFamily {
+	case "", controller.IPFamilyV4:
+		wantV4 = true
+	case controller.IPFamilyV6:
+		wantV6 = true
+	case controller.IPFamilyDual:
+		wantV4, wantV6 = true, true
+	default:
+		return false, false, fmt.Errorf("invalid ip family: %s", request.IPFamily)
+	}
+
+	if wantV6 && c.CIDRPoolV6 == nil {
+		return false, false, errors.New("no ipv6 overlay network is configured")
+	}
+	if wantV6 && request.SingleOverlayIP {
+		return false, false, errors.New("single overlay ip leases are only available for ipv4")
+	}
+	return wantV4, wantV6, nil
+}
+
+func (c *LeaseController) isMember(lease controller.Lease) bool {
+	if lease.OverlaySubnet != "" && !c.CIDRPool.IsMember(lease.OverlaySubnet) {
+		return false
+	}
+	if lease.OverlaySubnetV6 != "" && (c.CIDRPoolV6 == nil || !c.CIDRPoolV6.IsMember(lease.OverlaySubnetV6)) {
+		return false
+	}
+	return true
+}
+
+// excludedSubnet returns the subnet of the lease that lies in an excluded
+// range, if any.
+func (c *LeaseController) excludedSubnet(lease controller.Lease) string {
+	if lease.OverlaySubnet != "" && c.CIDRPool.IsExcluded(lease.OverlaySubnet) {
+		return lease.OverlaySubnet
+	}
+	if lease.OverlaySubnetV6 != "" && c.CIDRPoolV6 != nil && c.CIDRPoolV6.IsExcluded(lease.OverlaySubnetV6) {
+		return lease.OverlaySubnetV6
+	}
+	return ""
+}
+
+// tryAcquireLease returns the lease it added and where its block came from,
+// if the pool has a topology.
+func (c *LeaseController) tryAcquireLease(store database.LeaseStore, actor string, request controller.AcquireLeaseRequest, reservedSubnet string, wantV4, wantV6 bool) (*controller.Lease, string, error) {
+	var subnet, subnetV6, reason string
+	var err error
+	underlayIP := request.UnderlayIP
+	if reservedSubnet != "" {
+		subnet = reservedSubnet
+	} else if wantV4 && request.SingleOverlayIP {
+		subnet, err = c.tryAcquireAvailableSingleIPSubnet(store, actor, underlayIP)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-	} else {
-		var err error
-		subnet, err = c.tryAcquireAvailableBlockSubnet(underlayIP)
+	} else if wantV4 {
+		subnet, err = c.tryAcquireAvailableBlockSubnet(store, actor, underlayIP, request.Host)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
+		reason = c.placement(request.Host, subnet)
 	}
-	if subnet == "" {
-		return nil, nil
+	if wantV4 && subnet == "" {
+		return nil, "", nil
 	}
 
-	vtepIP, _, err := net.ParseCIDR(subnet)
-	if err != nil {
-		return nil, fmt.Errorf("parse subnet: %s", err)
+	if wantV6 {
+		subnetV6, err = c.tryAcquireAvailableBlockSubnetV6(store, actor, underlayIP)
+		if err != nil {
+			return nil, "", err
+		}
+		if subnetV6 == "" {
+			return nil, "", nil
+		}
 	}
-	hwAddr, err := c.HardwareAddressGenerator.GenerateForVTEP(vtepIP)
+
+	hwAddr, err := c.generateHardwareAddr(subnet, subnetV6)
 	if err != nil {
-		return nil, fmt.Errorf("generate hardware address: %s", err)
+		return nil, "", err
 	}
 
 	lease := controller.Lease{
 		UnderlayIP:          underlayIP,
 		OverlaySubnet:       subnet,
+		OverlaySubnetV6:     subnetV6,
 		OverlayHardwareAddr: hwAddr.String(),
+		Pool:                c.Pool,
 	}
 
-	err = c.DatabaseHandler.AddEntry(lease)
+	err = store.AddEntry(lease)
 	if err != nil {
-		return nil, fmt.Errorf("adding lease entry: %s", err)
+		return nil, "", fmt.Errorf("adding lease entry: %s", err)
 	}
-	return &lease, nil
+	return &lease, reason, nil
 }
 
-func (c *LeaseController) tryAcquireAvailableSingleIPSubnet(underlayIP string) (string, error) {
-	var subnet string
-	leases, err := c.DatabaseHandler.AllSingleIPSubnets()
+// placement describes where the block handed to the host came from, for the
+// lease event log.
+func (c *LeaseController) placement(host *controller.HostMetadata, subnet string) string {
+	if c.Topology == nil || subnet == "" {
+		return ""
+	}
+	wanted, partitioned := c.Topology.Partition(host)
+	got := c.Topology.PartitionOf(subnet)
+	switch {
+	case got != "" && got == wanted:
+		return fmt.Sprintf("allocated from partition %s", got)
+	case got != "":
+		return fmt.Sprintf("allocated from partition %s, the only free block in the pool", got)
+	case partitioned:
+		return fmt.Sprintf("partition %s is full, allocated from overflow", wanted)
+	case wanted != "":
+		return fmt.Sprintf("no partition for %s, allocated from overflow", wanted)
+	}
+	return "host has no topology metadata, allocated from overflow"
+}
+
+// reclaim deletes an expired lease. It returns true if the subnet of the lease
+// can be handed to the underlay ip, and false if it is in a draining network
+// or reserved, by subnet, for another underlay ip, in which case the caller
+// moves on to the next expired lease.
+func (c *LeaseController) reclaim(store database.LeaseStore, actor, underlayIP string, expired controller.Lease, pool cidrPool, subnet string, reserved map[string]string) (bool, error) {
+	err := store.DeleteEntry(expired.UnderlayIP)
 	if err != nil {
-		return "", fmt.Errorf("getting all single ip subnets: %s", err)
+		return false, fmt.Errorf("delete expired subnet: %s", err)
 	}
-	var taken []string
-	for _, lease := range leases {
-		taken = append(taken, lease.OverlaySubnet)
+	reason := fmt.Sprintf("expired, reassigned to %s", underlayIP)
+	reusable := pool.IsActive(subnet)
+	if !reusable {
+		reason = "expired in a draining network"
+	} else if owner, ok := reserved[subnet]; ok && owner != underlayIP {
+		reusable = false
+		reason = fmt.Sprintf("expired, kept for the reservation of %s", owner)
 	}
+	err = store.AddEvent(leaseEvent(controller.LeaseEventReclaimed, expired, actor, reason))
+	if err != nil {
+		return false, err
+	}
+	c.MetricSender.IncrementCounter("leaseReclaimed")
+	return reusable, nil
+}
 
-	subnet = c.CIDRPool.GetAvailableSingleIP(taken)
+// quarantine holds the subnets of a deleted lease back for QuarantineSeconds.
+func (c *LeaseController) quarantine(store database.LeaseStore, lease controller.Lease) error {
+	if c.QuarantineSeconds == 0 {
+		return nil
+	}
+	for _, subnet := range []string{lease.OverlaySubnet, lease.OverlaySubnetV6} {
+		if subnet == "" {
+			continue
+		}
+		err := store.QuarantineSubnet(subnet, lease.Pool, c.QuarantineSeconds)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *LeaseController) quarantinedSubnets(store database.LeaseStore) ([]string, error) {
+	if c.QuarantineSeconds == 0 {
+		return nil, nil
+	}
+	quarantined, err := store.QuarantinedSubnets()
+	if err != nil {
+		return nil, fmt.Errorf("getting quarantined subnets: %s", err)
+	}
+	var subnets []string
+	for _, q := range quarantined {
+		subnets = append(subnets, q.OverlaySubnet)
+	}
+	return subnets, nil
+}
+
+// fromQuarantine hands out a quarantined subnet once every other subnet of
+// the pool is taken and no expired lease is left to reclaim.
+func fromQuarantine(getAvailable func([]string) string, taken, quarantined []string) string {
+	if len(quarantined) == 0 {
+		return ""
+	}
+	return getAvailable(taken)
+}
+
+func (c *LeaseController) generateHardwareAddr(subnet, subnetV6 string) (net.HardwareAddr, error) {
 	if subnet == "" {
-		lease, err := c.DatabaseHandler.OldestExpiredSingleIP(c.LeaseExpirationSeconds)
+		_, overlaySubnet, err := net.ParseCIDR(subnetV6)
+		if err != nil {
+			return nil, fmt.Errorf("parse subnet: %s", err)
+		}
+		hwAddr, err := c.HardwareAddressGenerator.GenerateForVTEPV6(overlaySubnet)
+		if err != nil {
+			return nil, fmt.Errorf("generate hardware address: %s", err)
+		}
+		return hwAddr, nil
+	}
+
+	vtepIP, _, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("parse subnet: %s", err)
+	}
+	hwAddr, err := c.HardwareAddressGenerator.GenerateForVTEP(vtepIP)
+	if err != nil {
+		return nil, fmt.Errorf("generate hardware address: %s", err)
+	}
+	return hwAddr, nil
+}
+
+func (c *LeaseController) tryAcquireAvailableSingleIPSubnet(store database.LeaseStore, actor, underlayIP string) (string, error) {
+	quarantined, err := c.quarantinedSubnets(store)
+	if err != nil {
+		return "", err
+	}
+
+	subnet := c.CIDRPool.GetAvailableSingleIP(quarantined)
+	for subnet == "" {
+		lease, err := store.OldestExpiredSingleIP(c.LeaseExpirationSeconds)
 		if err != nil {
 			return "", fmt.Errorf("get oldest expired single ip: %s", err)
 		} else if lease == nil {
-			return "", nil
-		} else {
-			err := c.DatabaseHandler.DeleteEntry(lease.UnderlayIP)
-			if err != nil {
-				return "", fmt.Errorf("delete expired subnet: %s", err)
-			}
+			return fromQuarantine(c.CIDRPool.GetAvailableSingleIP, nil, quarantined), nil
+		}
+		reusable, err := c.reclaim(store, actor, underlayIP, *lease, c.CIDRPool, lease.OverlaySubnet, nil)
+		if err != nil {
+			return "", err
+		}
+		if reusable {
 			subnet = lease.OverlaySubnet
 		}
 	}
@@ -214,32 +757,103 @@ func (c *LeaseController) tryAcquireAvailableSingleIPSubnet(underlayIP string) (
 	return subnet, nil
 }
 
-func (c *LeaseController) tryAcquireAvailableBlockSubnet(underlayIP string) (string, error) {
+func (c *LeaseController) tryAcquireAvailableBlockSubnet(store database.LeaseStore, actor, underlayIP string, host *controller.HostMetadata) (string, error) {
 	var subnet string
-	leases, err := c.DatabaseHandler.AllBlockSubnets()
+	reservations, err := store.AllReservations()
 	if err != nil {
-		return "", fmt.Errorf("getting all subnets: %s", err)
+		return "", fmt.Errorf("getting all reservations: %s", err)
 	}
+	// reserved subnets that are not leased yet are held back like leased ones
 	var taken []string
-	for _, lease := range leases {
-		taken = append(taken, lease.OverlaySubnet)
+	reserved := make(map[string]string, len(reservations))
+	for _, reservation := range reservations {
+		taken = append(taken, reservation.OverlaySubnet)
+		reserved[reservation.OverlaySubnet] = reservation.UnderlayIP
+	}
+	quarantined, err := c.quarantinedSubnets(store)
+	if err != nil {
+		return "", err
 	}
 
-	subnet = c.CIDRPool.GetAvailableBlock(taken)
+	if c.Topology != nil {
+		subnet = c.Topology.GetAvailableBlock(host, append(quarantined, taken...))
+	}
 	if subnet == "" {
-		lease, err := c.DatabaseHandler.OldestExpiredBlockSubnet(c.LeaseExpirationSeconds)
+		subnet = c.CIDRPool.GetAvailableBlock(append(quarantined, taken...))
+	}
+	for subnet == "" {
+		lease, err := store.OldestExpiredBlockSubnet(c.LeaseExpirationSeconds)
 		if err != nil {
 			return "", fmt.Errorf("get oldest expired: %s", err)
 		} else if lease == nil {
-			return "", nil
-		} else {
-			err := c.DatabaseHandler.DeleteEntry(lease.UnderlayIP)
-			if err != nil {
-				return "", fmt.Errorf("delete expired subnet: %s", err) // test
-			}
+			return fromQuarantine(c.CIDRPool.GetAvailableBlock, taken, quarantined), nil
+		}
+		reusable, err := c.reclaim(store, actor, underlayIP, *lease, c.CIDRPool, lease.OverlaySubnet, reserved)
+		if err != nil {
+			return "", err
+		}
+		if reusable {
 			subnet = lease.OverlaySubnet
 		}
 	}
 
 	return subnet, nil
 }
+
+func (c *LeaseController) tryAcquireAvailableBlockSubnetV6(store database.LeaseStore, actor, underlayIP string) (string, error) {
+	quarantined, err := c.quarantinedSubnets(store)
+	if err != nil {
+		return "", err
+	}
+
+	subnet := c.CIDRPoolV6.GetAvailableBlock(quarantined)
+	for subnet == "" {
+		lease, err := store.OldestExpiredBlockSubnetV6(c.LeaseExpirationSeconds)
+		if err != nil {
+			return "", fmt.Errorf("get oldest expired ipv6 subnet: %s", err)
+		} else if lease == nil {
+			return fromQuarantine(c.CIDRPoolV6.GetAvailableBlock, nil, quarantined), nil
+		}
+		reusable, err := c.reclaim(store, actor, underlayIP, *lease, c.CIDRPoolV6, lease.OverlaySubnetV6, nil)
+		if err != nil {
+			return "", err
+		}
+		if reusable {
+			subnet = lease.OverlaySubnetV6
+		}
+	}
+
+	return subnet, nil
+}
+
+// withVNI returns a copy of the lease with the vni of the pool.
+func (c *LeaseController) withVNI(lease *controller.Lease) *controller.Lease {
+	withVNI := *lease
+	withVNI.VNI = c.VNI
+	return &withVNI
+}
+
+// setHost stores the metadata the host sent, if any. Metadata that has not
+// changed is not an error.
+func setHost(store database.LeaseStore, underlayIP string, host *controller.HostMetadata) error {
+	if host == nil {
+		return nil
+	}
+	err := store.SetHostForUnderlayIP(underlayIP, *host)
+	if err != nil && err != database.RecordNotAffectedError {
+		return fmt.Errorf("setting host for underlay ip: %s", err)
+	}
+	return nil
+}
+
+func leaseEvent(eventType string, lease controller.Lease, actor, reason string) controller.LeaseEvent {
+	return controller.LeaseEvent{
+		Type:            eventType,
+		UnderlayIP:      lease.UnderlayIP,
+		OverlaySubnet:   lease.OverlaySubnet,
+		OverlaySubnetV6: lease.OverlaySubnetV6,
+		Pool:            lease.Pool,
+		Actor:           actor,
+		Reason:          reason,
+	}
+}
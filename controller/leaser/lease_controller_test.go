@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
 
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagertest"
@@ -26,58 +27,53 @@ var _ = Describe("LeaseController", func() {
 		validator                *fakes.LeaseValidator
 		cidrPool                 *fakes.CIDRPool
 		hardwareAddressGenerator *fakes.HardwareAddressGenerator
+		metricSender             *fakes.MetricSender
 	)
 	BeforeEach(func() {
 		logger = lagertest.NewTestLogger("test")
 		databaseHandler = &fakes.DatabaseHandler{}
 		cidrPool = &fakes.CIDRPool{}
+		cidrPool.IsActiveReturns(true)
 		hardwareAddressGenerator = &fakes.HardwareAddressGenerator{}
 		validator = &fakes.LeaseValidator{}
+		metricSender = &fakes.MetricSender{}
 		leaseController = leaser.LeaseController{
 			DatabaseHandler:          databaseHandler,
 			HardwareAddressGenerator: hardwareAddressGenerator,
 			LeaseValidator:           validator,
 			Logger:                   logger,
+			MetricSender:             metricSender,
 			LeaseExpirationSeconds:   42,
 		}
 		hardwareAddressGenerator.GenerateForVTEPReturns(
 			net.HardwareAddr{0xee, 0xee, 0x0a, 0xff, 0x4c, 0x00}, nil,
 		)
+		databaseHandler.WithAllocationLockStub = func(f func(database.LeaseStore) error) error {
+			return f(databaseHandler)
+		}
 	})
 
 	Describe("AcquireSubnetLease", func() {
 		BeforeEach(func() {
 			leaseController.AcquireSubnetLeaseAttempts = 10
 			leaseController.CIDRPool = cidrPool
-			databaseHandler.AllBlockSubnetsReturns([]controller.Lease{
-				{UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.33.0/24"},
-				{UnderlayIP: "10.244.22.33", OverlaySubnet: "10.255.44.0/24"},
-			}, nil)
-			databaseHandler.AllSingleIPSubnetsReturns([]controller.Lease{
-				{UnderlayIP: "10.244.33.44", OverlaySubnet: "10.255.0.11/32"},
-				{UnderlayIP: "10.244.44.55", OverlaySubnet: "10.255.0.12/32"},
-			}, nil)
 			cidrPool.GetAvailableBlockReturns("10.255.76.0/24")
 			cidrPool.GetAvailableSingleIPReturns("10.255.0.13/32")
 		})
 
 		Context("when acquiring a single ip lease", func() {
 			It("acquires a lease successfully and logs the result", func() {
-				lease, err := leaseController.AcquireSubnetLease("10.244.55.66", true)
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.55.66", SingleOverlayIP: true})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(lease.OverlaySubnet).To(Equal("10.255.0.13/32"))
 			})
 
-			Context("when retrieving all single ip subnets fails", func() {
-				It("returns an error", func() {
-					databaseHandler.AllSingleIPSubnetsReturns(nil, errors.New("guava"))
-
-					_, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
-					Expect(err).To(MatchError("getting all single ip subnets: guava"))
+			It("leaves the leased single ips to the pool, which tracks them", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.55.66", SingleOverlayIP: true})
+				Expect(err).NotTo(HaveOccurred())
 
-					Expect(databaseHandler.AllSingleIPSubnetsCallCount()).To(Equal(10))
-					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
-				})
+				Expect(databaseHandler.AllSingleIPSubnetsCallCount()).To(Equal(0))
+				Expect(cidrPool.GetAvailableSingleIPArgsForCall(0)).To(BeEmpty())
 			})
 
 			Context("when no single ip subnets are free", func() {
@@ -86,15 +82,15 @@ var _ = Describe("LeaseController", func() {
 				})
 
 				Context("when there are no single ip expired leases", func() {
-					It("eventually returns an error after failing to find a free subnet", func() {
-						lease, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
+					It("returns no lease without retrying", func() {
+						lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", SingleOverlayIP: true})
 						Expect(err).NotTo(HaveOccurred())
 						Expect(lease).To(BeNil())
 
-						Expect(databaseHandler.AllSingleIPSubnetsCallCount()).To(Equal(10))
+						Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(1))
 						Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
 
-						Expect(databaseHandler.OldestExpiredSingleIPCallCount()).To(Equal(10))
+						Expect(databaseHandler.OldestExpiredSingleIPCallCount()).To(Equal(1))
 						Expect(databaseHandler.OldestExpiredSingleIPArgsForCall(0)).To(Equal(42))
 					})
 				})
@@ -112,7 +108,7 @@ var _ = Describe("LeaseController", func() {
 					})
 
 					It("deletes the expired lease and assigns that lease's subnet", func() {
-						lease, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
+						lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", SingleOverlayIP: true})
 						Expect(err).NotTo(HaveOccurred())
 						Expect(lease).To(Equal(&controller.Lease{
 							UnderlayIP:          "10.244.5.6",
@@ -120,7 +116,6 @@ var _ = Describe("LeaseController", func() {
 							OverlayHardwareAddr: expiredLease.OverlayHardwareAddr,
 						}))
 
-						Expect(databaseHandler.AllSingleIPSubnetsCallCount()).To(Equal(1))
 						Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
 						Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
 						Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal(expiredLease.UnderlayIP))
@@ -135,7 +130,7 @@ var _ = Describe("LeaseController", func() {
 						})
 
 						It("returns an error", func() {
-							_, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
+							_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", SingleOverlayIP: true})
 							Expect(err).To(MatchError("get oldest expired single ip: guava"))
 						})
 					})
@@ -146,7 +141,7 @@ var _ = Describe("LeaseController", func() {
 						})
 
 						It("returns an error", func() {
-							_, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
+							_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", SingleOverlayIP: true})
 							Expect(err).To(MatchError("delete expired subnet: guava"))
 						})
 					})
@@ -155,7 +150,7 @@ var _ = Describe("LeaseController", func() {
 		})
 
 		It("acquires a lease and logs the success", func() {
-			lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+			lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(lease.UnderlayIP).To(Equal("10.244.5.6"))
 			Expect(lease.OverlaySubnet).To(Equal("10.255.76.0/24"))
@@ -166,9 +161,9 @@ var _ = Describe("LeaseController", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(loggedLease).To(MatchJSON(`{"underlay_ip":"10.244.5.6","overlay_subnet":"10.255.76.0/24","overlay_hardware_addr":"ee:ee:0a:ff:4c:00"}`))
 
-			Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(1))
+			Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(0))
 			Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(1))
-			Expect(cidrPool.GetAvailableBlockArgsForCall(0)).To(Equal([]string{"10.255.33.0/24", "10.255.44.0/24"}))
+			Expect(cidrPool.GetAvailableBlockArgsForCall(0)).To(BeEmpty())
 			Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
 
 			savedLease := databaseHandler.AddEntryArgsForCall(0)
@@ -177,15 +172,151 @@ var _ = Describe("LeaseController", func() {
 			Expect(savedLease.OverlayHardwareAddr).To(Equal("ee:ee:0a:ff:4c:00"))
 		})
 
-		Context("when getting all taken subnets returns an error", func() {
-			It("returns an error", func() {
-				databaseHandler.AllBlockSubnetsReturns(nil, errors.New("guava"))
+		It("records an acquired event", func() {
+			_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(databaseHandler.AddEventCallCount()).To(Equal(1))
+			Expect(databaseHandler.AddEventArgsForCall(0)).To(Equal(controller.LeaseEvent{
+				Type:          controller.LeaseEventAcquired,
+				UnderlayIP:    "10.244.5.6",
+				OverlaySubnet: "10.255.76.0/24",
+				Actor:         "some-actor",
+			}))
+		})
 
-				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).To(MatchError("getting all subnets: guava"))
+		Context("when the pool has a vni", func() {
+			BeforeEach(func() {
+				leaseController.VNI = 7
+			})
+
+			It("hands it out with the lease without storing it", func() {
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease.VNI).To(Equal(7))
+				Expect(databaseHandler.AddEntryArgsForCall(0).VNI).To(BeZero())
+			})
+
+			It("hands it out with an existing lease", func() {
+				existing := controller.Lease{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.76.0/24", OverlayHardwareAddr: "ee:ee:0a:ff:4c:00"}
+				databaseHandler.LeaseForUnderlayIPReturns(&existing, nil)
+				cidrPool.IsMemberReturns(true)
 
-				Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(10))
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
 				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				Expect(lease.VNI).To(Equal(7))
+			})
+		})
+
+		Context("when the pool has a topology", func() {
+			var (
+				topology *fakes.Topology
+				host     *controller.HostMetadata
+			)
+
+			BeforeEach(func() {
+				topology = &fakes.Topology{}
+				topology.PartitionReturns("az=z1", true)
+				topology.GetAvailableBlockReturns("10.255.1.0/24")
+				topology.PartitionOfReturns("az=z1")
+				leaseController.Topology = topology
+				host = &controller.HostMetadata{AZ: "z1"}
+			})
+
+			It("hands out a block of the partition of the host and records it", func() {
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Host: host})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease.OverlaySubnet).To(Equal("10.255.1.0/24"))
+
+				Expect(topology.GetAvailableBlockCallCount()).To(Equal(1))
+				requestHost, taken := topology.GetAvailableBlockArgsForCall(0)
+				Expect(requestHost).To(Equal(host))
+				Expect(taken).To(BeEmpty())
+				Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(0))
+				Expect(topology.PartitionOfArgsForCall(0)).To(Equal("10.255.1.0/24"))
+
+				Expect(databaseHandler.AddEventArgsForCall(0).Reason).To(Equal("allocated from partition az=z1"))
+			})
+
+			Context("when the partition of the host is full", func() {
+				BeforeEach(func() {
+					topology.GetAvailableBlockReturns("")
+					topology.PartitionOfReturns("")
+				})
+
+				It("falls back to the overflow", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Host: host})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.76.0/24"))
+					Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(1))
+
+					Expect(databaseHandler.AddEventArgsForCall(0).Reason).To(Equal("partition az=z1 is full, allocated from overflow"))
+				})
+			})
+
+			Context("when the value of the host has no partition", func() {
+				BeforeEach(func() {
+					topology.PartitionReturns("az=z3", false)
+					topology.GetAvailableBlockReturns("")
+					topology.PartitionOfReturns("")
+				})
+
+				It("allocates from the overflow", func() {
+					_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Host: host})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(databaseHandler.AddEventArgsForCall(0).Reason).To(Equal("no partition for az=z3, allocated from overflow"))
+				})
+			})
+
+			Context("when the host sends no metadata", func() {
+				BeforeEach(func() {
+					topology.PartitionReturns("", false)
+					topology.GetAvailableBlockReturns("")
+					topology.PartitionOfReturns("")
+				})
+
+				It("allocates from the overflow", func() {
+					_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(databaseHandler.AddEventArgsForCall(0).Reason).To(Equal("host has no topology metadata, allocated from overflow"))
+				})
+			})
+
+			Context("when the only free block is an expired one of another partition", func() {
+				BeforeEach(func() {
+					topology.GetAvailableBlockReturns("")
+					topology.PartitionOfReturns("az=z2")
+					cidrPool.GetAvailableBlockReturns("")
+					databaseHandler.OldestExpiredBlockSubnetReturns(&controller.Lease{UnderlayIP: "10.244.7.8", OverlaySubnet: "10.255.2.0/24"}, nil)
+				})
+
+				It("reclaims it and records where it came from", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Host: host})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.2.0/24"))
+
+					Expect(databaseHandler.AddEventCallCount()).To(Equal(2))
+					Expect(databaseHandler.AddEventArgsForCall(1).Reason).To(Equal("allocated from partition az=z2, the only free block in the pool"))
+				})
+			})
+
+			It("does not consult it for single ip leases", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", SingleOverlayIP: true, Host: host})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(topology.GetAvailableBlockCallCount()).To(Equal(0))
+				Expect(databaseHandler.AddEventArgsForCall(0).Reason).To(BeEmpty())
+			})
+		})
+
+		Context("when recording the event fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AddEventReturns(errors.New("adding event: kiwi"))
+			})
+
+			It("returns the error", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).To(MatchError("adding event: kiwi"))
 			})
 		})
 
@@ -195,19 +326,55 @@ var _ = Describe("LeaseController", func() {
 			})
 
 			Context("when there are no expired leases", func() {
-				It("eventually returns an error after failing to find a free subnet", func() {
-					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+				It("returns no lease without retrying", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
 					Expect(err).NotTo(HaveOccurred())
 					Expect(lease).To(BeNil())
 
-					Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(10))
+					Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(1))
 					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
 
-					Expect(databaseHandler.OldestExpiredBlockSubnetCallCount()).To(Equal(10))
+					Expect(databaseHandler.OldestExpiredBlockSubnetCallCount()).To(Equal(1))
 					Expect(databaseHandler.OldestExpiredBlockSubnetArgsForCall(0)).To(Equal(42))
 				})
 			})
 
+			Context("when the oldest expired lease is in a draining network", func() {
+				BeforeEach(func() {
+					databaseHandler.OldestExpiredBlockSubnetReturnsOnCall(0, &controller.Lease{
+						UnderlayIP:    "10.244.5.60",
+						OverlaySubnet: "10.254.76.0/24",
+					}, nil)
+					cidrPool.IsActiveStub = func(subnet string) bool {
+						return subnet != "10.254.76.0/24"
+					}
+				})
+
+				It("deletes it without reusing its subnet and moves on to the next one", func() {
+					databaseHandler.OldestExpiredBlockSubnetReturnsOnCall(1, &controller.Lease{
+						UnderlayIP:    "10.244.5.61",
+						OverlaySubnet: "10.255.77.0/24",
+					}, nil)
+
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.77.0/24"))
+
+					Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(2))
+					Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal("10.244.5.60"))
+					Expect(databaseHandler.DeleteEntryArgsForCall(1)).To(Equal("10.244.5.61"))
+					Expect(databaseHandler.AddEventArgsForCall(0).Reason).To(Equal("expired in a draining network"))
+					Expect(databaseHandler.AddEventArgsForCall(1).Reason).To(Equal("expired, reassigned to 10.244.5.6"))
+				})
+
+				It("returns no lease when no expired lease in an active network is left", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease).To(BeNil())
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				})
+			})
+
 			Context("when there is an expired lease", func() {
 				var expiredLease *controller.Lease
 
@@ -221,7 +388,7 @@ var _ = Describe("LeaseController", func() {
 				})
 
 				It("Deletes the expired lease and assigns that lease's subnet", func() {
-					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
 					Expect(err).NotTo(HaveOccurred())
 					Expect(lease).To(Equal(&controller.Lease{
 						UnderlayIP:          "10.244.5.6",
@@ -229,7 +396,6 @@ var _ = Describe("LeaseController", func() {
 						OverlayHardwareAddr: expiredLease.OverlayHardwareAddr,
 					}))
 
-					Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(1))
 					Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
 					Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
 					Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal(expiredLease.UnderlayIP))
@@ -238,12 +404,30 @@ var _ = Describe("LeaseController", func() {
 					Expect(databaseHandler.OldestExpiredBlockSubnetArgsForCall(0)).To(Equal(42))
 				})
 
+				It("records that the expired lease was reclaimed", func() {
+					_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(databaseHandler.AddEventCallCount()).To(Equal(2))
+					Expect(databaseHandler.AddEventArgsForCall(0)).To(Equal(controller.LeaseEvent{
+						Type:          controller.LeaseEventReclaimed,
+						UnderlayIP:    "10.244.5.60",
+						OverlaySubnet: "10.255.76.0/24",
+						Actor:         "some-actor",
+						Reason:        "expired, reassigned to 10.244.5.6",
+					}))
+					Expect(databaseHandler.AddEventArgsForCall(1).Type).To(Equal(controller.LeaseEventAcquired))
+
+					Expect(metricSender.IncrementCounterCallCount()).To(Equal(1))
+					Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("leaseReclaimed"))
+				})
+
 				Context("when getting the oldest expired lease returns an error", func() {
 					BeforeEach(func() {
 						databaseHandler.OldestExpiredBlockSubnetReturns(nil, errors.New("guava"))
 					})
 					It("returns an error", func() {
-						_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+						_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
 						Expect(err).To(MatchError("get oldest expired: guava"))
 					})
 				})
@@ -253,134 +437,698 @@ var _ = Describe("LeaseController", func() {
 						databaseHandler.DeleteEntryReturns(errors.New("guava"))
 					})
 					It("returns an error", func() {
-						_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+						_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
 						Expect(err).To(MatchError("delete expired subnet: guava"))
 					})
 				})
 			})
 		})
 
-		Context("when the underlay ip is not an IPv4 addr", func() {
-			It("returns an error", func() {
-				_, err := leaseController.AcquireSubnetLease("banana", false)
-				Expect(err).To(MatchError("invalid ipv4 address: banana"))
+		Context("when released subnets are quarantined", func() {
+			BeforeEach(func() {
+				leaseController.QuarantineSeconds = 300
+				databaseHandler.QuarantinedSubnetsReturns([]controller.QuarantinedSubnet{
+					{OverlaySubnet: "10.255.55.0/24", QuarantinedUntil: 1000},
+					{OverlaySubnet: "10.255.0.14/32", QuarantinedUntil: 2000},
+				}, nil)
 			})
-		})
 
-		Context("when the subnet is an invalid CIDR", func() {
-			BeforeEach(func() {
-				cidrPool.GetAvailableBlockReturns("foo")
+			It("does not hand out the quarantined subnets", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(1))
+				Expect(cidrPool.GetAvailableBlockArgsForCall(0)).To(ConsistOf("10.255.55.0/24", "10.255.0.14/32"))
 			})
-			It("eventually returns an error after failing to find a free subnet", func() {
-				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).To(MatchError("parse subnet: invalid CIDR address: foo"))
 
-				Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(10))
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+			It("does not hand out the quarantined single ips", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", SingleOverlayIP: true})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cidrPool.GetAvailableSingleIPCallCount()).To(Equal(1))
+				Expect(cidrPool.GetAvailableSingleIPArgsForCall(0)).To(ConsistOf("10.255.55.0/24", "10.255.0.14/32"))
 			})
-		})
 
-		Context("when generating the hardware address fails", func() {
-			BeforeEach(func() {
-				hardwareAddressGenerator.GenerateForVTEPReturns(nil, errors.New("guava"))
+			Context("when every other subnet is taken", func() {
+				BeforeEach(func() {
+					cidrPool.GetAvailableBlockReturnsOnCall(0, "")
+					cidrPool.GetAvailableBlockReturnsOnCall(1, "10.255.55.0/24")
+				})
+
+				It("reclaims an expired lease first", func() {
+					databaseHandler.OldestExpiredBlockSubnetReturns(&controller.Lease{UnderlayIP: "10.244.5.60", OverlaySubnet: "10.255.76.0/24"}, nil)
+
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.76.0/24"))
+					Expect(databaseHandler.QuarantineSubnetCallCount()).To(Equal(0))
+				})
+
+				It("hands out a quarantined subnet when no expired lease is left", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.55.0/24"))
+
+					Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(2))
+					Expect(cidrPool.GetAvailableBlockArgsForCall(1)).To(BeEmpty())
+				})
 			})
-			It("eventually returns an error after failing to find a free subnet", func() {
-				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).To(MatchError("generate hardware address: guava"))
 
-				Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(10))
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+			Context("when nothing is quarantined and every subnet is taken", func() {
+				BeforeEach(func() {
+					databaseHandler.QuarantinedSubnetsReturns(nil, nil)
+					cidrPool.GetAvailableBlockReturns("")
+				})
+
+				It("returns no lease", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease).To(BeNil())
+					Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(1))
+				})
 			})
-		})
 
-		Context("when adding the lease entry fails", func() {
-			It("returns an error", func() {
-				databaseHandler.AddEntryReturns(errors.New("guava"))
+			Context("when the underlay ip holds a lease that no longer fits", func() {
+				BeforeEach(func() {
+					databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{
+						UnderlayIP:      "10.244.5.6",
+						OverlaySubnet:   "10.254.76.0/24",
+						OverlaySubnetV6: "fd00:254::/64",
+						Pool:            "blue",
+					}, nil)
+					cidrPool.IsMemberReturns(false)
+				})
 
-				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).To(MatchError("adding lease entry: guava"))
+				It("quarantines the subnets of the old lease in its pool", func() {
+					_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
 
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(10))
+					Expect(databaseHandler.QuarantineSubnetCallCount()).To(Equal(2))
+					subnet, pool, seconds := databaseHandler.QuarantineSubnetArgsForCall(0)
+					Expect([]interface{}{subnet, pool, seconds}).To(Equal([]interface{}{"10.254.76.0/24", "blue", 300}))
+					subnet, pool, seconds = databaseHandler.QuarantineSubnetArgsForCall(1)
+					Expect([]interface{}{subnet, pool, seconds}).To(Equal([]interface{}{"fd00:254::/64", "blue", 300}))
+				})
+
+				Context("when quarantining fails", func() {
+					BeforeEach(func() {
+						databaseHandler.QuarantineSubnetReturns(errors.New("quarantining subnet: guava"))
+					})
+					It("returns an error", func() {
+						_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+						Expect(err).To(MatchError("quarantining subnet: guava"))
+						Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			Context("when getting the quarantined subnets fails", func() {
+				BeforeEach(func() {
+					databaseHandler.QuarantinedSubnetsReturns(nil, errors.New("guava"))
+				})
+				It("returns an error", func() {
+					_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).To(MatchError("getting quarantined subnets: guava"))
+				})
 			})
 		})
 
-		Context("when a lease has already been assigned", func() {
-			var existingLease *controller.Lease
+		Context("when no quarantine is configured", func() {
+			It("neither looks up nor quarantines subnets", func() {
+				databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.254.76.0/24"}, nil)
+				cidrPool.IsMemberReturns(false)
+
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(databaseHandler.QuarantinedSubnetsCallCount()).To(Equal(0))
+				Expect(databaseHandler.QuarantineSubnetCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when acquiring a dual stack lease", func() {
+			var cidrPoolV6 *fakes.CIDRPool
+
 			BeforeEach(func() {
-				existingLease = &controller.Lease{
+				cidrPoolV6 = &fakes.CIDRPool{}
+				cidrPoolV6.IsActiveReturns(true)
+				cidrPoolV6.GetAvailableBlockReturns("fd00:255:0:4c::/64")
+				leaseController.CIDRPoolV6 = cidrPoolV6
+			})
+
+			It("acquires a subnet from each family", func() {
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", IPFamily: controller.IPFamilyDual})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease).To(Equal(&controller.Lease{
 					UnderlayIP:          "10.244.5.6",
 					OverlaySubnet:       "10.255.76.0/24",
+					OverlaySubnetV6:     "fd00:255:0:4c::/64",
 					OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
-				}
-				databaseHandler.LeaseForUnderlayIPReturns(existingLease, nil)
-				cidrPool.IsMemberReturns(true)
+				}))
+
+				Expect(databaseHandler.AllBlockSubnetsV6CallCount()).To(Equal(0))
+				Expect(cidrPoolV6.GetAvailableBlockArgsForCall(0)).To(BeEmpty())
+				Expect(databaseHandler.AddEntryArgsForCall(0)).To(Equal(*lease))
+				Expect(hardwareAddressGenerator.GenerateForVTEPV6CallCount()).To(Equal(0))
 			})
 
-			It("gets the previously assigned lease", func() {
-				lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(lease).To(Equal(existingLease))
+			Context("when only an ipv6 subnet is requested", func() {
+				BeforeEach(func() {
+					hardwareAddressGenerator.GenerateForVTEPV6Returns(
+						net.HardwareAddr{0xee, 0xee, 0x00, 0x00, 0x00, 0x4c}, nil,
+					)
+				})
 
-				Expect(logger.Logs()[0].Message).To(Equal("test.lease-renewed"))
-				loggedLease, err := json.Marshal(logger.Logs()[0].Data["lease"])
-				Expect(err).NotTo(HaveOccurred())
-				Expect(loggedLease).To(MatchJSON(`{"underlay_ip":"10.244.5.6","overlay_subnet":"10.255.76.0/24","overlay_hardware_addr":"ee:ee:0a:ff:4c:00"}`))
+				It("does not allocate an ipv4 subnet", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "fd00:244::5:6", IPFamily: controller.IPFamilyV6})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease).To(Equal(&controller.Lease{
+						UnderlayIP:          "fd00:244::5:6",
+						OverlaySubnetV6:     "fd00:255:0:4c::/64",
+						OverlayHardwareAddr: "ee:ee:00:00:00:4c",
+					}))
 
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(0))
+					Expect(hardwareAddressGenerator.GenerateForVTEPV6CallCount()).To(Equal(1))
+					Expect(hardwareAddressGenerator.GenerateForVTEPV6ArgsForCall(0).String()).To(Equal("fd00:255:0:4c::/64"))
+				})
 			})
-		})
 
-		Context("when a lease has already been assigned in a different network", func() {
-			var existingLease *controller.Lease
-			BeforeEach(func() {
-				existingLease = &controller.Lease{
-					UnderlayIP:          "10.244.5.6",
-					OverlaySubnet:       "10.254.76.0/24",
-					OverlayHardwareAddr: "ee:ee:0a:fe:4c:00",
-				}
-				databaseHandler.LeaseForUnderlayIPReturns(existingLease, nil)
-				cidrPool.IsMemberReturns(false)
-			})
+			Context("when no ipv6 subnets are free", func() {
+				BeforeEach(func() {
+					cidrPoolV6.GetAvailableBlockReturns("")
+				})
 
-			It("deletes the previously assigned lease and assigns a new one", func() {
-				lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(lease).NotTo(Equal(existingLease))
+				Context("when there is an expired ipv6 lease", func() {
+					BeforeEach(func() {
+						databaseHandler.OldestExpiredBlockSubnetV6Returns(&controller.Lease{
+							UnderlayIP:      "10.244.11.22",
+							OverlaySubnetV6: "fd00:255:0:21::/64",
+						}, nil)
+					})
 
-				Expect(logger.Logs()[0].Message).To(Equal("test.lease-deleted"))
-				deletedLease, err := json.Marshal(logger.Logs()[0].Data["lease"])
-				Expect(err).NotTo(HaveOccurred())
-				Expect(deletedLease).To(MatchJSON(`{"underlay_ip":"10.244.5.6","overlay_subnet":"10.254.76.0/24","overlay_hardware_addr":"ee:ee:0a:fe:4c:00"}`))
+					It("deletes the expired lease and assigns that lease's subnet", func() {
+						lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", IPFamily: controller.IPFamilyDual})
+						Expect(err).NotTo(HaveOccurred())
+						Expect(lease.OverlaySubnetV6).To(Equal("fd00:255:0:21::/64"))
 
-				Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
+						Expect(databaseHandler.OldestExpiredBlockSubnetV6ArgsForCall(0)).To(Equal(42))
+						Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal("10.244.11.22"))
+					})
+				})
+
+				Context("when there are no expired ipv6 leases", func() {
+					It("eventually returns an error after failing to find a free subnet", func() {
+						lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", IPFamily: controller.IPFamilyDual})
+						Expect(err).NotTo(HaveOccurred())
+						Expect(lease).To(BeNil())
+						Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					})
+
+					It("rolls back the transaction so that a reclaimed ipv4 subnet is not lost", func() {
+						cidrPool.GetAvailableBlockReturns("")
+						databaseHandler.OldestExpiredBlockSubnetReturns(&controller.Lease{
+							UnderlayIP:    "10.244.11.22",
+							OverlaySubnet: "10.255.33.0/24",
+						}, nil)
+						var lockErr error
+						databaseHandler.WithAllocationLockStub = func(f func(database.LeaseStore) error) error {
+							lockErr = f(databaseHandler)
+							return lockErr
+						}
+
+						lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", IPFamily: controller.IPFamilyDual})
+						Expect(err).NotTo(HaveOccurred())
+						Expect(lease).To(BeNil())
+						Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal("10.244.11.22"))
+						Expect(lockErr).To(HaveOccurred())
+					})
+				})
+
+				Context("when getting the oldest expired ipv6 lease returns an error", func() {
+					BeforeEach(func() {
+						databaseHandler.OldestExpiredBlockSubnetV6Returns(nil, errors.New("guava"))
+					})
+
+					It("returns an error", func() {
+						_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", IPFamily: controller.IPFamilyDual})
+						Expect(err).To(MatchError("get oldest expired ipv6 subnet: guava"))
+					})
+				})
 			})
 
-			Context("when deleting the existing entry fails", func() {
+			Context("when an ipv4 only lease has already been assigned", func() {
 				BeforeEach(func() {
-					databaseHandler.DeleteEntryReturns(fmt.Errorf("peanut"))
+					databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{
+						UnderlayIP:          "10.244.5.6",
+						OverlaySubnet:       "10.255.76.0/24",
+						OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+					}, nil)
+					cidrPool.IsMemberReturns(true)
+					cidrPoolV6.IsMemberReturns(true)
+				})
+
+				It("replaces it with a dual stack lease", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", IPFamily: controller.IPFamilyDual})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnetV6).To(Equal("fd00:255:0:4c::/64"))
+
+					Expect(logger.Logs()[0].Message).To(Equal("test.lease-deleted"))
+					Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
 				})
+			})
+
+			Context("when a single overlay ip is requested", func() {
 				It("returns an error", func() {
-					_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-					Expect(err).To(MatchError("deleting lease for underlay ip 10.244.5.6: peanut"))
-					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", SingleOverlayIP: true, IPFamily: controller.IPFamilyDual})
+					Expect(err).To(MatchError("single overlay ip leases are only available for ipv4"))
 				})
 			})
 		})
 
-		Context("when checking for an existing lease fails", func() {
-			BeforeEach(func() {
-				databaseHandler.LeaseForUnderlayIPReturns(nil, fmt.Errorf("fruit"))
-			})
+		Context("when an ipv6 subnet is requested but no ipv6 network is configured", func() {
 			It("returns an error", func() {
-				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).To(MatchError("getting lease for underlay ip: fruit"))
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", IPFamily: controller.IPFamilyV6})
+				Expect(err).To(MatchError("no ipv6 overlay network is configured"))
+				Expect(databaseHandler.LeaseForUnderlayIPCallCount()).To(Equal(0))
 			})
 		})
-	})
 
-	Describe("RenewSubnetLease", func() {
+		Context("when the ip family is not recognised", func() {
+			It("returns an error", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", IPFamily: "ipx"})
+				Expect(err).To(MatchError("invalid ip family: ipx"))
+			})
+		})
+
+		Context("when the underlay ip is not an IP addr", func() {
+			It("returns an error", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "banana"})
+				Expect(err).To(MatchError("invalid ip address: banana"))
+			})
+		})
+
+		Context("when the subnet is an invalid CIDR", func() {
+			BeforeEach(func() {
+				cidrPool.GetAvailableBlockReturns("foo")
+			})
+			It("eventually returns an error after failing to find a free subnet", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).To(MatchError("parse subnet: invalid CIDR address: foo"))
+
+				Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(10))
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when generating the hardware address fails", func() {
+			BeforeEach(func() {
+				hardwareAddressGenerator.GenerateForVTEPReturns(nil, errors.New("guava"))
+			})
+			It("eventually returns an error after failing to find a free subnet", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).To(MatchError("generate hardware address: guava"))
+
+				Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(10))
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+			})
+		})
+
+		It("acquires the lease while holding the allocation lock", func() {
+			databaseHandler.WithAllocationLockStub = func(f func(database.LeaseStore) error) error {
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				err := f(databaseHandler)
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
+				return err
+			}
+
+			lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lease.OverlaySubnet).To(Equal("10.255.76.0/24"))
+			Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(1))
+			Expect(logger.Logs()[0].Message).To(Equal("test.lease-acquired"))
+		})
+
+		Context("when the request has host metadata", func() {
+			var host *controller.HostMetadata
+			BeforeEach(func() {
+				host = &controller.HostMetadata{Hostname: "diego-cell-0", AZ: "z2"}
+			})
+
+			It("stores it with the lease while holding the allocation lock", func() {
+				databaseHandler.WithAllocationLockStub = func(f func(database.LeaseStore) error) error {
+					err := f(databaseHandler)
+					Expect(databaseHandler.SetHostForUnderlayIPCallCount()).To(Equal(1))
+					return err
+				}
+
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Host: host})
+				Expect(err).NotTo(HaveOccurred())
+
+				underlayIP, stored := databaseHandler.SetHostForUnderlayIPArgsForCall(0)
+				Expect(underlayIP).To(Equal("10.244.5.6"))
+				Expect(stored).To(Equal(*host))
+			})
+
+			It("does not mind when the lease already has it", func() {
+				databaseHandler.SetHostForUnderlayIPReturns(database.RecordNotAffectedError)
+
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Host: host})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("when storing it fails", func() {
+				It("retries and returns the error", func() {
+					databaseHandler.SetHostForUnderlayIPReturns(errors.New("guava"))
+
+					_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Host: host})
+					Expect(err).To(MatchError("setting host for underlay ip: guava"))
+					Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(10))
+				})
+			})
+		})
+
+		It("does not store host metadata the request does not have", func() {
+			_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.SetHostForUnderlayIPCallCount()).To(Equal(0))
+		})
+
+		Context("when taking the allocation lock fails", func() {
+			It("retries and returns the error", func() {
+				databaseHandler.WithAllocationLockStub = nil
+				databaseHandler.WithAllocationLockReturns(errors.New("beginning transaction: guava"))
+
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).To(MatchError("beginning transaction: guava"))
+
+				Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(10))
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+			})
+
+			It("counts every retry", func() {
+				databaseHandler.WithAllocationLockStub = nil
+				databaseHandler.WithAllocationLockReturns(errors.New("beginning transaction: guava"))
+
+				leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+
+				Expect(metricSender.IncrementCounterCallCount()).To(Equal(9))
+				Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("acquireRetry"))
+			})
+		})
+
+		Context("when adding the lease entry fails", func() {
+			It("returns an error", func() {
+				databaseHandler.AddEntryReturns(errors.New("guava"))
+
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).To(MatchError("adding lease entry: guava"))
+
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(10))
+			})
+		})
+
+		Context("when a lease has already been assigned", func() {
+			var existingLease *controller.Lease
+			BeforeEach(func() {
+				existingLease = &controller.Lease{
+					UnderlayIP:          "10.244.5.6",
+					OverlaySubnet:       "10.255.76.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+				}
+				databaseHandler.LeaseForUnderlayIPReturns(existingLease, nil)
+				cidrPool.IsMemberReturns(true)
+			})
+
+			It("gets the previously assigned lease", func() {
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease).To(Equal(existingLease))
+
+				Expect(logger.Logs()[0].Message).To(Equal("test.lease-renewed"))
+				loggedLease, err := json.Marshal(logger.Logs()[0].Data["lease"])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(loggedLease).To(MatchJSON(`{"underlay_ip":"10.244.5.6","overlay_subnet":"10.255.76.0/24","overlay_hardware_addr":"ee:ee:0a:ff:4c:00"}`))
+
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+			})
+
+			It("records a renewed event", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(databaseHandler.AddEventCallCount()).To(Equal(1))
+				event := databaseHandler.AddEventArgsForCall(0)
+				Expect(event.Type).To(Equal(controller.LeaseEventRenewed))
+				Expect(event.OverlaySubnet).To(Equal("10.255.76.0/24"))
+				Expect(event.Reason).To(Equal("acquired again by its underlay ip"))
+			})
+		})
+
+		Context("when a lease has already been assigned in a different network", func() {
+			var existingLease *controller.Lease
+			BeforeEach(func() {
+				existingLease = &controller.Lease{
+					UnderlayIP:          "10.244.5.6",
+					OverlaySubnet:       "10.254.76.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:fe:4c:00",
+				}
+				databaseHandler.LeaseForUnderlayIPReturns(existingLease, nil)
+				cidrPool.IsMemberReturns(false)
+			})
+
+			It("deletes the previously assigned lease and assigns a new one", func() {
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease).NotTo(Equal(existingLease))
+
+				Expect(logger.Logs()[0].Message).To(Equal("test.lease-deleted"))
+				deletedLease, err := json.Marshal(logger.Logs()[0].Data["lease"])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deletedLease).To(MatchJSON(`{"underlay_ip":"10.244.5.6","overlay_subnet":"10.254.76.0/24","overlay_hardware_addr":"ee:ee:0a:fe:4c:00"}`))
+
+				Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
+			})
+
+			It("records that the previous lease was released before the new one was acquired", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(databaseHandler.AddEventCallCount()).To(Equal(2))
+				released := databaseHandler.AddEventArgsForCall(0)
+				Expect(released.Type).To(Equal(controller.LeaseEventReleased))
+				Expect(released.OverlaySubnet).To(Equal("10.254.76.0/24"))
+				Expect(released.Reason).To(Equal("replaced by a lease that fits the request"))
+				Expect(databaseHandler.AddEventArgsForCall(1).Type).To(Equal(controller.LeaseEventAcquired))
+			})
+
+			Context("when deleting the existing entry fails", func() {
+				BeforeEach(func() {
+					databaseHandler.DeleteEntryReturns(fmt.Errorf("peanut"))
+				})
+				It("returns an error", func() {
+					_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).To(MatchError("deleting lease for underlay ip 10.244.5.6: peanut"))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when the controller serves a named pool", func() {
+			BeforeEach(func() {
+				leaseController.Pool = "blue"
+			})
+
+			It("records the pool on the lease", func() {
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Pool: "blue"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease.Pool).To(Equal("blue"))
+				Expect(databaseHandler.AddEntryArgsForCall(0).Pool).To(Equal("blue"))
+			})
+
+			Context("when the underlay ip already holds a lease from another pool", func() {
+				BeforeEach(func() {
+					databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{
+						UnderlayIP:          "10.244.5.6",
+						OverlaySubnet:       "10.255.76.0/24",
+						OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+					}, nil)
+					cidrPool.IsMemberReturns(true)
+				})
+
+				It("deletes the previous lease and assigns a new one", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Pool: "blue"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.Pool).To(Equal("blue"))
+
+					Expect(logger.Logs()[0].Message).To(Equal("test.lease-deleted"))
+					Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal("10.244.5.6"))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Context("when the underlay ip has a reservation", func() {
+			BeforeEach(func() {
+				databaseHandler.ReservationForUnderlayIPReturns(&controller.Reservation{
+					UnderlayIP:    "10.244.5.6",
+					OverlaySubnet: "10.255.90.0/24",
+				}, nil)
+			})
+
+			It("leases the reserved subnet", func() {
+				lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease.OverlaySubnet).To(Equal("10.255.90.0/24"))
+
+				Expect(databaseHandler.ReservationForUnderlayIPArgsForCall(0)).To(Equal("10.244.5.6"))
+				Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(0))
+				Expect(databaseHandler.AddEntryArgsForCall(0).OverlaySubnet).To(Equal("10.255.90.0/24"))
+			})
+
+			Context("when the underlay ip holds a lease on a different subnet", func() {
+				BeforeEach(func() {
+					databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{
+						UnderlayIP:          "10.244.5.6",
+						OverlaySubnet:       "10.255.76.0/24",
+						OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+					}, nil)
+					cidrPool.IsMemberReturns(true)
+				})
+
+				It("replaces the lease with the reserved subnet", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.90.0/24"))
+
+					Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal("10.244.5.6"))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the reservation belongs to another pool", func() {
+				BeforeEach(func() {
+					databaseHandler.ReservationForUnderlayIPReturns(&controller.Reservation{
+						UnderlayIP:    "10.244.5.6",
+						OverlaySubnet: "10.250.90.0/24",
+						Pool:          "blue",
+					}, nil)
+				})
+
+				It("ignores the reservation", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.76.0/24"))
+				})
+			})
+
+			Context("when the reserved subnet is in a draining network", func() {
+				BeforeEach(func() {
+					cidrPool.IsActiveReturns(false)
+				})
+
+				It("ignores the reservation", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.76.0/24"))
+					Expect(cidrPool.IsActiveArgsForCall(0)).To(Equal("10.255.90.0/24"))
+				})
+			})
+
+			Context("when a single overlay ip is requested", func() {
+				It("ignores the reservation", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", SingleOverlayIP: true})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.0.13/32"))
+					Expect(databaseHandler.ReservationForUnderlayIPCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when other underlay ips have reservations", func() {
+			BeforeEach(func() {
+				databaseHandler.AllReservationsReturns([]controller.Reservation{
+					{UnderlayIP: "10.244.9.9", OverlaySubnet: "10.255.90.0/24"},
+				}, nil)
+			})
+
+			It("does not hand out the reserved subnets", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cidrPool.GetAvailableBlockArgsForCall(0)).To(Equal([]string{"10.255.90.0/24"}))
+			})
+
+			Context("when the lease of a reserved subnet has expired and no subnets are free", func() {
+				BeforeEach(func() {
+					cidrPool.GetAvailableBlockReturns("")
+					databaseHandler.OldestExpiredBlockSubnetReturnsOnCall(0, &controller.Lease{
+						UnderlayIP:    "10.244.9.9",
+						OverlaySubnet: "10.255.90.0/24",
+					}, nil)
+				})
+
+				It("deletes the lease but keeps its subnet for the reservation", func() {
+					databaseHandler.OldestExpiredBlockSubnetReturnsOnCall(1, &controller.Lease{
+						UnderlayIP:    "10.244.5.61",
+						OverlaySubnet: "10.255.77.0/24",
+					}, nil)
+
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.77.0/24"))
+
+					Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(2))
+					Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal("10.244.9.9"))
+					Expect(databaseHandler.AddEventArgsForCall(0).Reason).To(Equal("expired, kept for the reservation of 10.244.9.9"))
+					Expect(databaseHandler.AddEventArgsForCall(1).Reason).To(Equal("expired, reassigned to 10.244.5.6"))
+				})
+
+				It("returns no lease when no other expired lease is left", func() {
+					lease, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease).To(BeNil())
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when getting all reservations fails", func() {
+				BeforeEach(func() {
+					databaseHandler.AllReservationsReturns(nil, errors.New("plum"))
+				})
+
+				It("returns an error", func() {
+					_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+					Expect(err).To(MatchError("getting all reservations: plum"))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when getting the reservation fails", func() {
+			BeforeEach(func() {
+				databaseHandler.ReservationForUnderlayIPReturns(nil, errors.New("quince"))
+			})
+
+			It("returns an error", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).To(MatchError("getting reservation for underlay ip: quince"))
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when checking for an existing lease fails", func() {
+			BeforeEach(func() {
+				databaseHandler.LeaseForUnderlayIPReturns(nil, fmt.Errorf("fruit"))
+			})
+			It("returns an error", func() {
+				_, err := leaseController.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+				Expect(err).To(MatchError("getting lease for underlay ip: fruit"))
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("RenewSubnetLease", func() {
 		var leaseToRenew controller.Lease
 		var lastRenewedAt int64
 		BeforeEach(func() {
@@ -392,10 +1140,11 @@ var _ = Describe("LeaseController", func() {
 			databaseHandler.LeaseForUnderlayIPReturns(&leaseToRenew, nil)
 			lastRenewedAt = 42
 			databaseHandler.LastRenewedAtForUnderlayIPReturns(lastRenewedAt, nil)
+			leaseController.CIDRPool = cidrPool
 		})
 
 		It("renews a lease and logs the success", func() {
-			err := leaseController.RenewSubnetLease(leaseToRenew)
+			_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(databaseHandler.LeaseForUnderlayIPCallCount()).To(Equal(1))
@@ -413,6 +1162,127 @@ var _ = Describe("LeaseController", func() {
 			Expect(int64(logger.Logs()[0].Data["last_renewed_at"].(float64))).To(Equal(lastRenewedAt))
 		})
 
+		It("stores the host metadata it is given", func() {
+			host := &controller.HostMetadata{Hostname: "diego-cell-0", Labels: map[string]string{"stack": "cflinuxfs4"}}
+			_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, host)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(databaseHandler.SetHostForUnderlayIPCallCount()).To(Equal(1))
+			underlayIP, stored := databaseHandler.SetHostForUnderlayIPArgsForCall(0)
+			Expect(underlayIP).To(Equal("10.244.11.22"))
+			Expect(stored).To(Equal(*host))
+		})
+
+		It("does not store host metadata it is not given", func() {
+			_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.SetHostForUnderlayIPCallCount()).To(Equal(0))
+		})
+
+		Context("when the host metadata has not changed", func() {
+			It("renews the lease", func() {
+				databaseHandler.SetHostForUnderlayIPReturns(database.RecordNotAffectedError)
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, &controller.HostMetadata{Hostname: "diego-cell-0"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when storing the host metadata fails", func() {
+			It("returns the error", func() {
+				databaseHandler.SetHostForUnderlayIPReturns(errors.New("guava"))
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, &controller.HostMetadata{Hostname: "diego-cell-0"})
+				Expect(err).To(MatchError("setting host for underlay ip: guava"))
+			})
+		})
+
+		Context("when the subnet of the lease is in an excluded range", func() {
+			BeforeEach(func() {
+				cidrPool.IsExcludedReturns(true)
+			})
+
+			It("returns a non-retriable error without renewing", func() {
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+				Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.33.0/24 is in an excluded range")))
+				Expect(cidrPool.IsExcludedArgsForCall(0)).To(Equal("10.255.33.0/24"))
+				Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(0))
+				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the ipv6 subnet of the lease is in an excluded range", func() {
+			BeforeEach(func() {
+				cidrPoolV6 := &fakes.CIDRPool{}
+				cidrPoolV6.IsExcludedReturns(true)
+				leaseController.CIDRPoolV6 = cidrPoolV6
+				leaseToRenew.OverlaySubnetV6 = "fd00:255:0:21::/64"
+			})
+
+			It("returns a non-retriable error", func() {
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+				Expect(err).To(Equal(controller.NonRetriableError("overlay subnet fd00:255:0:21::/64 is in an excluded range")))
+			})
+		})
+
+		It("returns the renewed lease", func() {
+			renewed, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(renewed).To(Equal(&leaseToRenew))
+		})
+
+		Context("when the pool has a vni", func() {
+			var withVNI controller.Lease
+
+			BeforeEach(func() {
+				leaseController.VNI = 7
+				withVNI = leaseToRenew
+				withVNI.VNI = 7
+			})
+
+			It("renews a lease with its vni or with none, and returns it with the vni", func() {
+				renewed, err := leaseController.RenewSubnetLease("some-actor", withVNI, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(renewed).To(Equal(&withVNI))
+				renewed, err = leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(renewed).To(Equal(&withVNI))
+				Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(2))
+			})
+
+			Context("when the vni of the pool has changed since the host acquired the lease", func() {
+				var oldVNI controller.Lease
+
+				BeforeEach(func() {
+					oldVNI = leaseToRenew
+					oldVNI.VNI = 5
+				})
+
+				It("renews the lease and returns it with the new vni, so that the host moves to it", func() {
+					renewed, err := leaseController.RenewSubnetLease("some-actor", oldVNI, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(renewed).To(Equal(&withVNI))
+					Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(1))
+					Expect(databaseHandler.RenewLeaseForUnderlayIPArgsForCall(0)).To(Equal("10.244.11.22"))
+				})
+			})
+
+			It("restores a missing lease without its vni", func() {
+				databaseHandler.LeaseForUnderlayIPReturns(nil, nil)
+				_, err := leaseController.RenewSubnetLease("some-actor", withVNI, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(databaseHandler.AddEntryArgsForCall(0)).To(Equal(leaseToRenew))
+			})
+		})
+
+		Context("when the pool has no vni", func() {
+			It("renews a lease with any vni, and returns it without one", func() {
+				withVNI := leaseToRenew
+				withVNI.VNI = 5
+				renewed, err := leaseController.RenewSubnetLease("some-actor", withVNI, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(renewed).To(Equal(&leaseToRenew))
+			})
+		})
+
 		Context("when the existing lease does not equal the one we are renewing", func() {
 			BeforeEach(func() {
 				existingLease := &controller.Lease{
@@ -423,7 +1293,7 @@ var _ = Describe("LeaseController", func() {
 				databaseHandler.LeaseForUnderlayIPReturns(existingLease, nil)
 			})
 			It("returns a non-retriable error", func() {
-				err := leaseController.RenewSubnetLease(leaseToRenew)
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
 				Expect(err).To(MatchError("lease mismatch"))
@@ -435,7 +1305,7 @@ var _ = Describe("LeaseController", func() {
 				databaseHandler.LeaseForUnderlayIPReturns(nil, nil)
 			})
 			It("adds the entry and logs the success", func() {
-				err := leaseController.RenewSubnetLease(leaseToRenew)
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(databaseHandler.LeaseForUnderlayIPCallCount()).To(Equal(1))
@@ -448,13 +1318,141 @@ var _ = Describe("LeaseController", func() {
 				Expect(databaseHandler.RenewLeaseForUnderlayIPArgsForCall(0)).To(Equal("10.244.11.22"))
 				Expect(databaseHandler.LastRenewedAtForUnderlayIPCallCount()).To(Equal(1))
 
-				Expect(logger.Logs()).To(HaveLen(1))
-				Expect(logger.Logs()[0].Message).To(Equal("test.lease-renewed"))
-				Expect(logger.Logs()[0].LogLevel).To(Equal(lager.DEBUG))
-				loggedLease, err := json.Marshal(logger.Logs()[0].Data["lease"])
-				Expect(err).NotTo(HaveOccurred())
-				Expect(loggedLease).To(MatchJSON(`{"underlay_ip":"10.244.11.22","overlay_subnet":"10.255.33.0/24","overlay_hardware_addr":"ee:ee:0a:ff:21:00"}`))
-				Expect(int64(logger.Logs()[0].Data["last_renewed_at"].(float64))).To(Equal(lastRenewedAt))
+				Expect(logger.Logs()).To(HaveLen(1))
+				Expect(logger.Logs()[0].Message).To(Equal("test.lease-renewed"))
+				Expect(logger.Logs()[0].LogLevel).To(Equal(lager.DEBUG))
+				loggedLease, err := json.Marshal(logger.Logs()[0].Data["lease"])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(loggedLease).To(MatchJSON(`{"underlay_ip":"10.244.11.22","overlay_subnet":"10.255.33.0/24","overlay_hardware_addr":"ee:ee:0a:ff:21:00"}`))
+				Expect(int64(logger.Logs()[0].Data["last_renewed_at"].(float64))).To(Equal(lastRenewedAt))
+			})
+
+			It("records that the lease was restored", func() {
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(databaseHandler.AddEventCallCount()).To(Equal(1))
+				Expect(databaseHandler.AddEventArgsForCall(0)).To(Equal(controller.LeaseEvent{
+					Type:          controller.LeaseEventAcquired,
+					UnderlayIP:    "10.244.11.22",
+					OverlaySubnet: "10.255.33.0/24",
+					Actor:         "some-actor",
+					Reason:        "restored by renewal",
+				}))
+			})
+
+			It("checks the subnet and adds the entry under the allocation lock", func() {
+				databaseHandler.WithAllocationLockStub = func(f func(database.LeaseStore) error) error {
+					Expect(databaseHandler.AllReservationsCallCount()).To(Equal(0))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					err := f(databaseHandler)
+					Expect(databaseHandler.AllReservationsCallCount()).To(Equal(1))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
+					return err
+				}
+
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(1))
+			})
+
+			Context("when the subnet is reserved for its underlay ip", func() {
+				BeforeEach(func() {
+					databaseHandler.AllReservationsReturns([]controller.Reservation{
+						{UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.33.0/24"},
+					}, nil)
+				})
+
+				It("restores the lease", func() {
+					_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the subnet has since been reserved for another underlay ip", func() {
+				BeforeEach(func() {
+					databaseHandler.AllReservationsReturns([]controller.Reservation{
+						{UnderlayIP: "10.244.99.99", OverlaySubnet: "10.255.33.0/24"},
+					}, nil)
+				})
+
+				It("returns a non-retriable error without restoring the lease", func() {
+					_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+					Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.33.0/24 is reserved for 10.244.99.99")))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					Expect(databaseHandler.AddEventCallCount()).To(Equal(0))
+					Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when getting the reservations fails", func() {
+				BeforeEach(func() {
+					databaseHandler.AllReservationsReturns(nil, errors.New("plum"))
+				})
+
+				It("returns the error", func() {
+					_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+					Expect(err).To(MatchError("getting all reservations: plum"))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the subnet is in quarantine", func() {
+				BeforeEach(func() {
+					leaseController.QuarantineSeconds = 300
+					databaseHandler.QuarantinedSubnetsReturns([]controller.QuarantinedSubnet{
+						{OverlaySubnet: "10.255.33.0/24", QuarantinedUntil: 1000},
+					}, nil)
+				})
+
+				It("returns a non-retriable error without restoring the lease", func() {
+					_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+					Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.33.0/24 is in quarantine")))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(0))
+				})
+
+				Context("when getting the quarantined subnets fails", func() {
+					BeforeEach(func() {
+						databaseHandler.QuarantinedSubnetsReturns(nil, errors.New("guava"))
+					})
+
+					It("returns the error", func() {
+						_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+						Expect(err).To(MatchError("getting quarantined subnets: guava"))
+						Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			Context("when the ipv6 subnet is in quarantine", func() {
+				BeforeEach(func() {
+					leaseToRenew.OverlaySubnetV6 = "fd00:255:0:21::/64"
+					leaseController.QuarantineSeconds = 300
+					databaseHandler.QuarantinedSubnetsReturns([]controller.QuarantinedSubnet{
+						{OverlaySubnet: "fd00:255:0:21::/64", QuarantinedUntil: 1000},
+					}, nil)
+				})
+
+				It("returns a non-retriable error", func() {
+					_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+					Expect(err).To(Equal(controller.NonRetriableError("overlay subnet fd00:255:0:21::/64 is in quarantine")))
+				})
+			})
+
+			Context("when the allocation lock cannot be taken", func() {
+				BeforeEach(func() {
+					databaseHandler.WithAllocationLockStub = nil
+					databaseHandler.WithAllocationLockReturns(errors.New("taking allocation lock: banana"))
+				})
+
+				It("returns the error without restoring the lease", func() {
+					_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
+					Expect(err).To(MatchError("taking allocation lock: banana"))
+					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(0))
+				})
 			})
 
 			Context("when adding the entry fails", func() {
@@ -462,7 +1460,7 @@ var _ = Describe("LeaseController", func() {
 					databaseHandler.AddEntryReturns(errors.New("pineapple"))
 				})
 				It("returns a non-retriable error", func() {
-					err := leaseController.RenewSubnetLease(leaseToRenew)
+					_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
 					Expect(err).To(HaveOccurred())
 					Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
 					Expect(err).To(MatchError("pineapple"))
@@ -475,7 +1473,7 @@ var _ = Describe("LeaseController", func() {
 				validator.ValidateReturns(errors.New("banana"))
 			})
 			It("returns a non-retriable error", func() {
-				err := leaseController.RenewSubnetLease(leaseToRenew)
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
 				Expect(err).To(MatchError("banana"))
@@ -487,7 +1485,7 @@ var _ = Describe("LeaseController", func() {
 				databaseHandler.LeaseForUnderlayIPReturns(nil, errors.New("banana"))
 			})
 			It("returns an error", func() {
-				err := leaseController.RenewSubnetLease(leaseToRenew)
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
 				Expect(err).To(MatchError("getting lease for underlay ip: banana"))
 			})
 		})
@@ -497,7 +1495,7 @@ var _ = Describe("LeaseController", func() {
 				databaseHandler.RenewLeaseForUnderlayIPReturns(errors.New("banana"))
 			})
 			It("returns an error", func() {
-				err := leaseController.RenewSubnetLease(leaseToRenew)
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
 				Expect(err).To(MatchError("renewing lease for underlay ip: banana"))
 			})
 		})
@@ -507,7 +1505,7 @@ var _ = Describe("LeaseController", func() {
 				databaseHandler.LastRenewedAtForUnderlayIPReturns(0, errors.New("banana"))
 			})
 			It("returns an error", func() {
-				err := leaseController.RenewSubnetLease(leaseToRenew)
+				_, err := leaseController.RenewSubnetLease("some-actor", leaseToRenew, nil)
 				Expect(err).To(MatchError("getting last renewed at: banana"))
 			})
 		})
@@ -521,7 +1519,7 @@ var _ = Describe("LeaseController", func() {
 			underlayIP = "10.244.5.0"
 		})
 		It("releases the lease", func() {
-			err := leaseController.ReleaseSubnetLease(underlayIP)
+			err := leaseController.ReleaseSubnetLease("some-actor", underlayIP)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
@@ -532,18 +1530,82 @@ var _ = Describe("LeaseController", func() {
 			Expect(logger.Logs()[0].Message).To(Equal("test.lease-released"))
 		})
 
+		It("records a released event for the lease", func() {
+			databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{
+				UnderlayIP:    "10.244.5.0",
+				OverlaySubnet: "10.255.5.0/24",
+			}, nil)
+
+			err := leaseController.ReleaseSubnetLease("some-actor", underlayIP)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(1))
+			Expect(databaseHandler.AddEventCallCount()).To(Equal(1))
+			Expect(databaseHandler.AddEventArgsForCall(0)).To(Equal(controller.LeaseEvent{
+				Type:          controller.LeaseEventReleased,
+				UnderlayIP:    "10.244.5.0",
+				OverlaySubnet: "10.255.5.0/24",
+				Actor:         "some-actor",
+				Reason:        "released by request",
+			}))
+		})
+
+		Context("when released subnets are quarantined", func() {
+			BeforeEach(func() {
+				leaseController.QuarantineSeconds = 300
+				databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{
+					UnderlayIP:    "10.244.5.0",
+					OverlaySubnet: "10.255.5.0/24",
+				}, nil)
+			})
+
+			It("quarantines the subnet of the lease", func() {
+				err := leaseController.ReleaseSubnetLease("some-actor", underlayIP)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(databaseHandler.QuarantineSubnetCallCount()).To(Equal(1))
+				subnet, pool, seconds := databaseHandler.QuarantineSubnetArgsForCall(0)
+				Expect(subnet).To(Equal("10.255.5.0/24"))
+				Expect(pool).To(Equal(""))
+				Expect(seconds).To(Equal(300))
+			})
+
+			Context("when quarantining fails", func() {
+				BeforeEach(func() {
+					databaseHandler.QuarantineSubnetReturns(errors.New("quarantining subnet: kiwi"))
+				})
+				It("returns the error", func() {
+					err := leaseController.ReleaseSubnetLease("some-actor", underlayIP)
+					Expect(err).To(MatchError("release lease: quarantining subnet: kiwi"))
+					Expect(databaseHandler.AddEventCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when recording the event fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AddEventReturns(errors.New("adding event: kiwi"))
+			})
+			It("returns the error", func() {
+				err := leaseController.ReleaseSubnetLease("some-actor", underlayIP)
+				Expect(err).To(MatchError("release lease: adding event: kiwi"))
+			})
+		})
+
 		Context("when the database returns RecordNotAffectedError", func() {
 			BeforeEach(func() {
 				databaseHandler.DeleteEntryReturns(database.RecordNotAffectedError)
 			})
 			It("swallows the error and logs it at DEBUG level", func() {
-				err := leaseController.ReleaseSubnetLease(underlayIP)
+				err := leaseController.ReleaseSubnetLease("some-actor", underlayIP)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(logger.Logs()).To(HaveLen(1))
 				Expect(logger.Logs()[0].Message).To(Equal("test.lease-not-found"))
 				Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("underlay_ip", "10.244.5.0"))
 				Expect(logger.Logs()[0].LogLevel).To(Equal(lager.DEBUG))
+
+				Expect(databaseHandler.AddEventCallCount()).To(Equal(0))
 			})
 		})
 
@@ -552,7 +1614,7 @@ var _ = Describe("LeaseController", func() {
 				databaseHandler.DeleteEntryReturns(errors.New("banana"))
 			})
 			It("wraps the error from the database handler", func() {
-				err := leaseController.ReleaseSubnetLease(underlayIP)
+				err := leaseController.ReleaseSubnetLease("some-actor", underlayIP)
 				Expect(err).To(MatchError("release lease: banana"))
 			})
 		})
@@ -580,6 +1642,17 @@ var _ = Describe("LeaseController", func() {
 			Expect(leases).To(Equal(activeLeases))
 		})
 
+		It("hands out the vni of the pool with the leases", func() {
+			leaseController.VNI = 7
+			leases, err := leaseController.RoutableLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(HaveLen(2))
+			for _, lease := range leases {
+				Expect(lease.VNI).To(Equal(7))
+			}
+			Expect(activeLeases[0].VNI).To(BeZero())
+		})
+
 		Context("when getting the leases fails", func() {
 			BeforeEach(func() {
 				databaseHandler.AllActiveReturns(nil, errors.New("cupcake"))
@@ -590,4 +1663,416 @@ var _ = Describe("LeaseController", func() {
 			})
 		})
 	})
+
+	Describe("HostLeases", func() {
+		host := &controller.HostMetadata{Hostname: "diego-cell-0"}
+		BeforeEach(func() {
+			databaseHandler.LeaseRecordsReturns([]controller.LeaseRecord{
+				{Lease: controller.Lease{UnderlayIP: "10.244.5.9", OverlaySubnet: "10.255.16.0/24"}, Host: host},
+				{Lease: controller.Lease{UnderlayIP: "10.244.5.10", OverlaySubnet: "10.255.17.0/24"}, Expired: true, Host: host},
+				{Lease: controller.Lease{UnderlayIP: "10.244.22.33", OverlaySubnet: "10.255.75.0/32"}},
+			}, nil)
+		})
+
+		It("returns the active leases with their hosts", func() {
+			leases, err := leaseController.HostLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.LeaseRecordsArgsForCall(0)).To(Equal(42))
+			Expect(leases).To(Equal([]controller.HostLease{
+				{Lease: controller.Lease{UnderlayIP: "10.244.5.9", OverlaySubnet: "10.255.16.0/24"}, Host: host},
+				{Lease: controller.Lease{UnderlayIP: "10.244.22.33", OverlaySubnet: "10.255.75.0/32"}},
+			}))
+		})
+
+		It("hands out the vni of the pool with the leases", func() {
+			leaseController.VNI = 7
+			leases, err := leaseController.HostLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases[0].VNI).To(Equal(7))
+			Expect(leases[1].VNI).To(Equal(7))
+		})
+
+		Context("when getting the lease records fails", func() {
+			BeforeEach(func() {
+				databaseHandler.LeaseRecordsReturns(nil, errors.New("cupcake"))
+			})
+			It("wraps the error from the database handler", func() {
+				_, err := leaseController.HostLeases()
+				Expect(err).To(MatchError("getting lease records: cupcake"))
+			})
+		})
+	})
+
+	Describe("ReserveSubnet", func() {
+		var reservation controller.Reservation
+		BeforeEach(func() {
+			leaseController.CIDRPool = cidrPool
+			cidrPool.IsBlockMemberReturns(true)
+			reservation = controller.Reservation{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.90.0/24"}
+		})
+
+		It("adds the reservation", func() {
+			Expect(leaseController.ReserveSubnet(reservation)).To(Succeed())
+
+			Expect(cidrPool.IsBlockMemberArgsForCall(0)).To(Equal("10.255.90.0/24"))
+			Expect(databaseHandler.AddReservationCallCount()).To(Equal(1))
+			Expect(databaseHandler.AddReservationArgsForCall(0)).To(Equal(reservation))
+
+			Expect(logger.Logs()[0].Message).To(Equal("test.reservation-added"))
+			loggedReservation, err := json.Marshal(logger.Logs()[0].Data["reservation"])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loggedReservation).To(MatchJSON(`{"underlay_ip":"10.244.5.6","overlay_subnet":"10.255.90.0/24"}`))
+		})
+
+		It("checks that the subnet is free and adds the reservation under the allocation lock", func() {
+			databaseHandler.WithAllocationLockStub = func(f func(database.LeaseStore) error) error {
+				Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(0))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(0))
+				err := f(databaseHandler)
+				Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(1))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(1))
+				return err
+			}
+
+			Expect(leaseController.ReserveSubnet(reservation)).To(Succeed())
+			Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(1))
+		})
+
+		Context("when the allocation lock cannot be taken", func() {
+			BeforeEach(func() {
+				databaseHandler.WithAllocationLockStub = nil
+				databaseHandler.WithAllocationLockReturns(errors.New("taking allocation lock: banana"))
+			})
+
+			It("returns the error without adding the reservation", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(MatchError("taking allocation lock: banana"))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(0))
+			})
+		})
+
+		It("allows reserving the subnet the underlay ip already leases", func() {
+			databaseHandler.AllBlockSubnetsReturns([]controller.Lease{
+				{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.90.0/24"},
+			}, nil)
+			Expect(leaseController.ReserveSubnet(reservation)).To(Succeed())
+		})
+
+		Context("when the underlay ip is not an IP addr", func() {
+			It("returns a non-retriable error", func() {
+				reservation.UnderlayIP = "banana"
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("invalid ip address: banana")))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the subnet is not a block of the pool", func() {
+			BeforeEach(func() {
+				cidrPool.IsBlockMemberReturns(false)
+			})
+
+			It("returns a non-retriable error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.90.0/24 is not a block of the pool")))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the subnet is in an excluded range", func() {
+			BeforeEach(func() {
+				cidrPool.IsExcludedReturns(true)
+			})
+
+			It("returns a non-retriable error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.90.0/24 is in an excluded range")))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the subnet is in a draining network", func() {
+			BeforeEach(func() {
+				cidrPool.IsActiveReturns(false)
+			})
+
+			It("returns a non-retriable error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.90.0/24 is in a draining network")))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the underlay ip already has a reservation", func() {
+			BeforeEach(func() {
+				databaseHandler.ReservationForUnderlayIPReturns(&controller.Reservation{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.91.0/24"}, nil)
+			})
+
+			It("returns a non-retriable error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("underlay ip 10.244.5.6 already has a reservation")))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the subnet is already reserved", func() {
+			BeforeEach(func() {
+				databaseHandler.AllReservationsReturns([]controller.Reservation{{UnderlayIP: "10.244.9.9", OverlaySubnet: "10.255.90.0/24"}}, nil)
+			})
+
+			It("returns a non-retriable error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.90.0/24 is already reserved")))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the subnet is leased to another underlay ip", func() {
+			BeforeEach(func() {
+				databaseHandler.AllBlockSubnetsReturns([]controller.Lease{{UnderlayIP: "10.244.9.9", OverlaySubnet: "10.255.90.0/24"}}, nil)
+			})
+
+			It("returns a non-retriable error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.90.0/24 is leased to 10.244.9.9")))
+				Expect(databaseHandler.AddReservationCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when getting the reservation for the underlay ip fails", func() {
+			BeforeEach(func() {
+				databaseHandler.ReservationForUnderlayIPReturns(nil, errors.New("apple"))
+			})
+
+			It("returns an error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(MatchError("getting reservation for underlay ip: apple"))
+			})
+		})
+
+		Context("when getting all reservations fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AllReservationsReturns(nil, errors.New("pear"))
+			})
+
+			It("returns an error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(MatchError("getting all reservations: pear"))
+			})
+		})
+
+		Context("when getting all subnets fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AllBlockSubnetsReturns(nil, errors.New("grape"))
+			})
+
+			It("returns an error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(MatchError("getting all subnets: grape"))
+			})
+		})
+
+		Context("when adding the reservation fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AddReservationReturns(errors.New("adding reservation: lime"))
+			})
+
+			It("returns the error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(MatchError("adding reservation: lime"))
+			})
+		})
+	})
+
+	Describe("RemoveReservation", func() {
+		It("removes the reservation", func() {
+			Expect(leaseController.RemoveReservation("10.244.5.6")).To(Succeed())
+			Expect(databaseHandler.DeleteReservationArgsForCall(0)).To(Equal("10.244.5.6"))
+
+			Expect(logger.Logs()).To(HaveLen(1))
+			Expect(logger.Logs()[0].Message).To(Equal("test.reservation-removed"))
+			Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("underlay_ip", "10.244.5.6"))
+		})
+
+		Context("when the database returns RecordNotAffectedError", func() {
+			BeforeEach(func() {
+				databaseHandler.DeleteReservationReturns(database.RecordNotAffectedError)
+			})
+
+			It("swallows the error and logs it at DEBUG level", func() {
+				Expect(leaseController.RemoveReservation("10.244.5.6")).To(Succeed())
+
+				Expect(logger.Logs()).To(HaveLen(1))
+				Expect(logger.Logs()[0].Message).To(Equal("test.reservation-not-found"))
+				Expect(logger.Logs()[0].LogLevel).To(Equal(lager.DEBUG))
+			})
+		})
+
+		Context("when the database returns some other error", func() {
+			BeforeEach(func() {
+				databaseHandler.DeleteReservationReturns(errors.New("banana"))
+			})
+
+			It("wraps the error from the database handler", func() {
+				err := leaseController.RemoveReservation("10.244.5.6")
+				Expect(err).To(MatchError("remove reservation: banana"))
+			})
+		})
+	})
+
+	Describe("Reservations", func() {
+		It("returns all the reservations", func() {
+			databaseHandler.AllReservationsReturns([]controller.Reservation{{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.90.0/24"}}, nil)
+
+			reservations, err := leaseController.Reservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(Equal([]controller.Reservation{{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.90.0/24"}}))
+		})
+
+		Context("when getting the reservations fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AllReservationsReturns(nil, errors.New("cupcake"))
+			})
+
+			It("wraps the error from the database handler", func() {
+				_, err := leaseController.Reservations()
+				Expect(err).To(MatchError("getting all reservations: cupcake"))
+			})
+		})
+	})
+
+	Describe("LeaseRecords", func() {
+		It("returns the lease records, expired after the lease expiration", func() {
+			records := []controller.LeaseRecord{{Lease: controller.Lease{UnderlayIP: "10.244.5.6"}, LastRenewedAt: 1000, Expired: true}}
+			databaseHandler.LeaseRecordsReturns(records, nil)
+
+			found, err := leaseController.LeaseRecords()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(records))
+			Expect(databaseHandler.LeaseRecordsArgsForCall(0)).To(Equal(42))
+		})
+
+		It("hands out the vni of the pool with the leases", func() {
+			leaseController.VNI = 7
+			databaseHandler.LeaseRecordsReturns([]controller.LeaseRecord{{Lease: controller.Lease{UnderlayIP: "10.244.5.6"}}}, nil)
+
+			found, err := leaseController.LeaseRecords()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found[0].VNI).To(Equal(7))
+		})
+
+		Context("when getting the records fails", func() {
+			BeforeEach(func() {
+				databaseHandler.LeaseRecordsReturns(nil, errors.New("cupcake"))
+			})
+
+			It("wraps the error from the database handler", func() {
+				_, err := leaseController.LeaseRecords()
+				Expect(err).To(MatchError("getting lease records: cupcake"))
+			})
+		})
+	})
+
+	Describe("Usage", func() {
+		BeforeEach(func() {
+			leaseController.Pool = "blue"
+			leaseController.CIDRPool = cidrPool
+			leaseController.QuarantineSeconds = 300
+			cidrPool.BlockPoolSizeReturns(255)
+			cidrPool.SingleIPPoolSizeReturns(4)
+			cidrPool.IsActiveStub = func(subnet string) bool {
+				return subnet != "10.254.1.0/24"
+			}
+			cidrPool.IsBlockMemberStub = func(subnet string) bool {
+				return strings.HasSuffix(subnet, "/24")
+			}
+			databaseHandler.LeaseRecordsReturns([]controller.LeaseRecord{
+				{Lease: controller.Lease{UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.33.0/24"}},
+				{Lease: controller.Lease{UnderlayIP: "10.244.11.26", OverlaySubnet: "10.255.34.0/24"}, Expired: true},
+				{Lease: controller.Lease{UnderlayIP: "10.244.22.33", OverlaySubnet: "10.254.1.0/24"}},
+				{Lease: controller.Lease{UnderlayIP: "10.244.11.23", OverlaySubnet: "10.255.0.1/32"}},
+				{Lease: controller.Lease{UnderlayIP: "10.244.11.24", OverlaySubnet: "10.255.0.2/32"}, Expired: true},
+				{Lease: controller.Lease{UnderlayIP: "10.244.11.25", OverlaySubnet: "10.255.0.3/32"}},
+			}, nil)
+			databaseHandler.AllReservationsReturns([]controller.Reservation{
+				{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.90.0/24"},
+				{UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.33.0/24"},
+			}, nil)
+			databaseHandler.QuarantinedSubnetsReturns([]controller.QuarantinedSubnet{
+				{OverlaySubnet: "10.255.91.0/24"},
+				{OverlaySubnet: "10.255.90.0/24"},
+				{OverlaySubnet: "10.255.0.4/32"},
+			}, nil)
+		})
+
+		It("counts the subnets of the active networks by what holds them", func() {
+			usage, err := leaseController.Usage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage).To(Equal(controller.PoolUsage{
+				Pool:      "blue",
+				Blocks:    controller.SubnetUsage{Total: 255, Active: 1, Expired: 1, Reserved: 1, Quarantined: 1, Free: 251},
+				SingleIPs: controller.SubnetUsage{Total: 4, Active: 2, Expired: 1, Quarantined: 1, Free: 0},
+			}))
+			Expect(databaseHandler.LeaseRecordsArgsForCall(0)).To(Equal(42))
+		})
+
+		Context("when the pool has no quarantine", func() {
+			BeforeEach(func() {
+				leaseController.QuarantineSeconds = 0
+			})
+
+			It("does not count quarantined subnets", func() {
+				usage, err := leaseController.Usage()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(usage.Blocks.Quarantined).To(Equal(0))
+				Expect(usage.Blocks.Free).To(Equal(252))
+				Expect(databaseHandler.QuarantinedSubnetsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when more subnets are held than the networks have", func() {
+			BeforeEach(func() {
+				cidrPool.SingleIPPoolSizeReturns(2)
+			})
+
+			It("reports none free", func() {
+				usage, err := leaseController.Usage()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(usage.SingleIPs.Free).To(Equal(0))
+			})
+		})
+
+		Context("when getting the lease records fails", func() {
+			BeforeEach(func() {
+				databaseHandler.LeaseRecordsReturns(nil, errors.New("cupcake"))
+			})
+
+			It("wraps the error from the database handler", func() {
+				_, err := leaseController.Usage()
+				Expect(err).To(MatchError("getting lease records: cupcake"))
+			})
+		})
+
+		Context("when getting the reservations fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AllReservationsReturns(nil, errors.New("cupcake"))
+			})
+
+			It("wraps the error from the database handler", func() {
+				_, err := leaseController.Usage()
+				Expect(err).To(MatchError("getting all reservations: cupcake"))
+			})
+		})
+
+		Context("when getting the quarantined subnets fails", func() {
+			BeforeEach(func() {
+				databaseHandler.QuarantinedSubnetsReturns(nil, errors.New("cupcake"))
+			})
+
+			It("wraps the error from the database handler", func() {
+				_, err := leaseController.Usage()
+				Expect(err).To(MatchError("getting quarantined subnets: cupcake"))
+			})
+		})
+	})
 })
@@ -0,0 +1,156 @@
+package leaser
+
+import (
+	"fmt"
+	"sort"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/pool_leaser.go --fake-name PoolLeaser . poolLeaser
+type poolLeaser interface {
+	AcquireSubnetLease(actor string, request controller.AcquireLeaseRequest) (*controller.Lease, error)
+	RenewSubnetLease(actor string, lease controller.Lease, host *controller.HostMetadata) (*controller.Lease, error)
+	ReleaseSubnetLease(actor, underlayIP string) error
+	RoutableLeases() ([]controller.Lease, error)
+	HostLeases() ([]controller.HostLease, error)
+	ReserveSubnet(reservation controller.Reservation) error
+	RemoveReservation(underlayIP string) error
+	Reservations() ([]controller.Reservation, error)
+	LeaseRecords() ([]controller.LeaseRecord, error)
+	Usage() (controller.PoolUsage, error)
+}
+
+// PoolRouter hands each request to the lease controller of the pool it names.
+// The default pool must always be added.
+type PoolRouter struct {
+	pools map[string]poolLeaser
+}
+
+func (p *PoolRouter) AddPool(name string, pool poolLeaser) {
+	if p.pools == nil {
+		p.pools = map[string]poolLeaser{}
+	}
+	p.pools[name] = pool
+}
+
+func (p *PoolRouter) AcquireSubnetLease(actor string, request controller.AcquireLeaseRequest) (*controller.Lease, error) {
+	pool, ok := p.pools[request.Pool]
+	if !ok {
+		return nil, fmt.Errorf("unknown pool: %s", request.Pool)
+	}
+	return pool.AcquireSubnetLease(actor, request)
+}
+
+func (p *PoolRouter) RenewSubnetLease(actor string, lease controller.Lease, host *controller.HostMetadata) (*controller.Lease, error) {
+	pool, ok := p.pools[lease.Pool]
+	if !ok {
+		return nil, controller.NonRetriableError(fmt.Sprintf("unknown pool: %s", lease.Pool))
+	}
+	return pool.RenewSubnetLease(actor, lease, host)
+}
+
+// ReleaseSubnetLease is not scoped to a pool: an underlay ip holds at most one
+// lease, whichever pool it came from.
+func (p *PoolRouter) ReleaseSubnetLease(actor, underlayIP string) error {
+	return p.pools[controller.DefaultPool].ReleaseSubnetLease(actor, underlayIP)
+}
+
+func (p *PoolRouter) RoutableLeases() ([]controller.Lease, error) {
+	leases := []controller.Lease{}
+	for _, name := range p.names() {
+		poolLeases, err := p.pools[name].RoutableLeases()
+		if err != nil {
+			return nil, err
+		}
+		leases = append(leases, poolLeases...)
+	}
+	return leases, nil
+}
+
+func (p *PoolRouter) RoutableLeasesForPool(name string) ([]controller.Lease, error) {
+	pool, ok := p.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown pool: %s", name)
+	}
+	return pool.RoutableLeases()
+}
+
+func (p *PoolRouter) HostLeases() ([]controller.HostLease, error) {
+	leases := []controller.HostLease{}
+	for _, name := range p.names() {
+		poolLeases, err := p.pools[name].HostLeases()
+		if err != nil {
+			return nil, err
+		}
+		leases = append(leases, poolLeases...)
+	}
+	return leases, nil
+}
+
+func (p *PoolRouter) HostLeasesForPool(name string) ([]controller.HostLease, error) {
+	pool, ok := p.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown pool: %s", name)
+	}
+	return pool.HostLeases()
+}
+
+func (p *PoolRouter) ReserveSubnet(reservation controller.Reservation) error {
+	pool, ok := p.pools[reservation.Pool]
+	if !ok {
+		return controller.NonRetriableError(fmt.Sprintf("unknown pool: %s", reservation.Pool))
+	}
+	return pool.ReserveSubnet(reservation)
+}
+
+// RemoveReservation is not scoped to a pool: an underlay ip holds at most one
+// reservation, whichever pool it is in.
+func (p *PoolRouter) RemoveReservation(underlayIP string) error {
+	return p.pools[controller.DefaultPool].RemoveReservation(underlayIP)
+}
+
+func (p *PoolRouter) Reservations() ([]controller.Reservation, error) {
+	reservations := []controller.Reservation{}
+	for _, name := range p.names() {
+		poolReservations, err := p.pools[name].Reservations()
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, poolReservations...)
+	}
+	return reservations, nil
+}
+
+func (p *PoolRouter) LeaseRecords() ([]controller.LeaseRecord, error) {
+	records := []controller.LeaseRecord{}
+	for _, name := range p.names() {
+		poolRecords, err := p.pools[name].LeaseRecords()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, poolRecords...)
+	}
+	return records, nil
+}
+
+func (p *PoolRouter) PoolUsage() ([]controller.PoolUsage, error) {
+	usage := []controller.PoolUsage{}
+	for _, name := range p.names() {
+		poolUsage, err := p.pools[name].Usage()
+		if err != nil {
+			return nil, fmt.Errorf("pool %q: %s", name, err)
+		}
+		usage = append(usage, poolUsage)
+	}
+	return usage, nil
+}
+
+func (p *PoolRouter) names() []string {
+	var names []string
+	for name := range p.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
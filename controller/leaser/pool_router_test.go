@@ -0,0 +1,276 @@
+package leaser_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PoolRouter", func() {
+	var (
+		router      *leaser.PoolRouter
+		defaultPool *fakes.PoolLeaser
+		bluePool    *fakes.PoolLeaser
+	)
+
+	BeforeEach(func() {
+		defaultPool = &fakes.PoolLeaser{}
+		bluePool = &fakes.PoolLeaser{}
+		router = &leaser.PoolRouter{}
+		router.AddPool(controller.DefaultPool, defaultPool)
+		router.AddPool("blue", bluePool)
+	})
+
+	Describe("AcquireSubnetLease", func() {
+		It("acquires the lease from the requested pool", func() {
+			bluePool.AcquireSubnetLeaseReturns(&controller.Lease{UnderlayIP: "10.244.5.6", Pool: "blue"}, nil)
+
+			lease, err := router.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Pool: "blue"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lease).To(Equal(&controller.Lease{UnderlayIP: "10.244.5.6", Pool: "blue"}))
+
+			actor, request := bluePool.AcquireSubnetLeaseArgsForCall(0)
+			Expect(actor).To(Equal("some-actor"))
+			Expect(request).To(Equal(controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Pool: "blue"}))
+			Expect(defaultPool.AcquireSubnetLeaseCallCount()).To(Equal(0))
+		})
+
+		It("uses the default pool when no pool is named", func() {
+			_, err := router.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(defaultPool.AcquireSubnetLeaseCallCount()).To(Equal(1))
+		})
+
+		Context("when the pool does not exist", func() {
+			It("returns an error", func() {
+				_, err := router.AcquireSubnetLease("some-actor", controller.AcquireLeaseRequest{UnderlayIP: "10.244.5.6", Pool: "green"})
+				Expect(err).To(MatchError("unknown pool: green"))
+			})
+		})
+	})
+
+	Describe("RenewSubnetLease", func() {
+		It("renews the lease in the pool it belongs to", func() {
+			lease := controller.Lease{UnderlayIP: "10.244.5.6", Pool: "blue"}
+			host := &controller.HostMetadata{Hostname: "diego-cell-0"}
+			withVNI := lease
+			withVNI.VNI = 7
+			bluePool.RenewSubnetLeaseReturns(&withVNI, nil)
+
+			renewed, err := router.RenewSubnetLease("some-actor", lease, host)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(renewed).To(Equal(&withVNI))
+			actor, renewedLease, renewedHost := bluePool.RenewSubnetLeaseArgsForCall(0)
+			Expect(actor).To(Equal("some-actor"))
+			Expect(renewedLease).To(Equal(lease))
+			Expect(renewedHost).To(Equal(host))
+		})
+
+		Context("when the pool does not exist", func() {
+			It("returns a non-retriable error", func() {
+				_, err := router.RenewSubnetLease("some-actor", controller.Lease{UnderlayIP: "10.244.5.6", Pool: "green"}, nil)
+				Expect(err).To(Equal(controller.NonRetriableError("unknown pool: green")))
+			})
+		})
+	})
+
+	Describe("ReleaseSubnetLease", func() {
+		It("releases the lease of the underlay ip", func() {
+			Expect(router.ReleaseSubnetLease("some-actor", "10.244.5.6")).To(Succeed())
+			actor, underlayIP := defaultPool.ReleaseSubnetLeaseArgsForCall(0)
+			Expect(actor).To(Equal("some-actor"))
+			Expect(underlayIP).To(Equal("10.244.5.6"))
+		})
+	})
+
+	Describe("RoutableLeases", func() {
+		BeforeEach(func() {
+			defaultPool.RoutableLeasesReturns([]controller.Lease{{UnderlayIP: "10.244.5.6"}}, nil)
+			bluePool.RoutableLeasesReturns([]controller.Lease{{UnderlayIP: "10.244.5.7", Pool: "blue"}}, nil)
+		})
+
+		It("returns the routable leases of every pool", func() {
+			leases, err := router.RoutableLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(Equal([]controller.Lease{
+				{UnderlayIP: "10.244.5.6"},
+				{UnderlayIP: "10.244.5.7", Pool: "blue"},
+			}))
+		})
+
+		It("returns the routable leases of a single pool", func() {
+			leases, err := router.RoutableLeasesForPool("blue")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(Equal([]controller.Lease{{UnderlayIP: "10.244.5.7", Pool: "blue"}}))
+			Expect(defaultPool.RoutableLeasesCallCount()).To(Equal(0))
+		})
+
+		Context("when a pool fails", func() {
+			BeforeEach(func() {
+				bluePool.RoutableLeasesReturns(nil, errors.New("pineapple"))
+			})
+
+			It("returns the error", func() {
+				_, err := router.RoutableLeases()
+				Expect(err).To(MatchError("pineapple"))
+			})
+		})
+
+		Context("when the pool does not exist", func() {
+			It("returns an error", func() {
+				_, err := router.RoutableLeasesForPool("green")
+				Expect(err).To(MatchError("unknown pool: green"))
+			})
+		})
+	})
+
+	Describe("HostLeases", func() {
+		BeforeEach(func() {
+			defaultPool.HostLeasesReturns([]controller.HostLease{{Lease: controller.Lease{UnderlayIP: "10.244.5.6"}}}, nil)
+			bluePool.HostLeasesReturns([]controller.HostLease{{Lease: controller.Lease{UnderlayIP: "10.244.5.7", Pool: "blue"}}}, nil)
+		})
+
+		It("returns the leases with their hosts of every pool", func() {
+			leases, err := router.HostLeases()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(Equal([]controller.HostLease{
+				{Lease: controller.Lease{UnderlayIP: "10.244.5.6"}},
+				{Lease: controller.Lease{UnderlayIP: "10.244.5.7", Pool: "blue"}},
+			}))
+		})
+
+		It("returns the leases with their hosts of a single pool", func() {
+			leases, err := router.HostLeasesForPool("blue")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(leases).To(Equal([]controller.HostLease{{Lease: controller.Lease{UnderlayIP: "10.244.5.7", Pool: "blue"}}}))
+			Expect(defaultPool.HostLeasesCallCount()).To(Equal(0))
+		})
+
+		Context("when a pool fails", func() {
+			BeforeEach(func() {
+				bluePool.HostLeasesReturns(nil, errors.New("pineapple"))
+			})
+
+			It("returns the error", func() {
+				_, err := router.HostLeases()
+				Expect(err).To(MatchError("pineapple"))
+			})
+		})
+
+		Context("when the pool does not exist", func() {
+			It("returns an error", func() {
+				_, err := router.HostLeasesForPool("green")
+				Expect(err).To(MatchError("unknown pool: green"))
+			})
+		})
+	})
+
+	Describe("ReserveSubnet", func() {
+		It("reserves the subnet in the named pool", func() {
+			reservation := controller.Reservation{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.250.1.0/24", Pool: "blue"}
+			Expect(router.ReserveSubnet(reservation)).To(Succeed())
+			Expect(bluePool.ReserveSubnetArgsForCall(0)).To(Equal(reservation))
+			Expect(defaultPool.ReserveSubnetCallCount()).To(Equal(0))
+		})
+
+		Context("when the pool does not exist", func() {
+			It("returns a non-retriable error", func() {
+				err := router.ReserveSubnet(controller.Reservation{UnderlayIP: "10.244.5.6", Pool: "green"})
+				Expect(err).To(Equal(controller.NonRetriableError("unknown pool: green")))
+			})
+		})
+	})
+
+	Describe("RemoveReservation", func() {
+		It("removes the reservation of the underlay ip", func() {
+			Expect(router.RemoveReservation("10.244.5.6")).To(Succeed())
+			Expect(defaultPool.RemoveReservationArgsForCall(0)).To(Equal("10.244.5.6"))
+		})
+	})
+
+	Describe("Reservations", func() {
+		BeforeEach(func() {
+			defaultPool.ReservationsReturns([]controller.Reservation{{UnderlayIP: "10.244.5.6"}}, nil)
+			bluePool.ReservationsReturns([]controller.Reservation{{UnderlayIP: "10.244.5.7", Pool: "blue"}}, nil)
+		})
+
+		It("returns the reservations of every pool", func() {
+			reservations, err := router.Reservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(Equal([]controller.Reservation{
+				{UnderlayIP: "10.244.5.6"},
+				{UnderlayIP: "10.244.5.7", Pool: "blue"},
+			}))
+		})
+
+		Context("when a pool fails", func() {
+			BeforeEach(func() {
+				bluePool.ReservationsReturns(nil, errors.New("pineapple"))
+			})
+
+			It("returns the error", func() {
+				_, err := router.Reservations()
+				Expect(err).To(MatchError("pineapple"))
+			})
+		})
+	})
+
+	Describe("LeaseRecords", func() {
+		BeforeEach(func() {
+			defaultPool.LeaseRecordsReturns([]controller.LeaseRecord{{Lease: controller.Lease{UnderlayIP: "10.244.5.6"}}}, nil)
+			bluePool.LeaseRecordsReturns([]controller.LeaseRecord{{Lease: controller.Lease{UnderlayIP: "10.244.5.7", Pool: "blue"}, Expired: true}}, nil)
+		})
+
+		It("returns the lease records of every pool", func() {
+			records, err := router.LeaseRecords()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(Equal([]controller.LeaseRecord{
+				{Lease: controller.Lease{UnderlayIP: "10.244.5.6"}},
+				{Lease: controller.Lease{UnderlayIP: "10.244.5.7", Pool: "blue"}, Expired: true},
+			}))
+		})
+
+		Context("when a pool fails", func() {
+			BeforeEach(func() {
+				bluePool.LeaseRecordsReturns(nil, errors.New("pineapple"))
+			})
+
+			It("returns the error", func() {
+				_, err := router.LeaseRecords()
+				Expect(err).To(MatchError("pineapple"))
+			})
+		})
+	})
+
+	Describe("PoolUsage", func() {
+		BeforeEach(func() {
+			defaultPool.UsageReturns(controller.PoolUsage{Blocks: controller.SubnetUsage{Total: 255}}, nil)
+			bluePool.UsageReturns(controller.PoolUsage{Pool: "blue", SingleIPs: controller.SubnetUsage{Reserved: 2}}, nil)
+		})
+
+		It("returns the usage of every pool", func() {
+			usage, err := router.PoolUsage()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage).To(Equal([]controller.PoolUsage{
+				{Blocks: controller.SubnetUsage{Total: 255}},
+				{Pool: "blue", SingleIPs: controller.SubnetUsage{Reserved: 2}},
+			}))
+		})
+
+		Context("when a pool fails", func() {
+			BeforeEach(func() {
+				bluePool.UsageReturns(controller.PoolUsage{}, errors.New("pineapple"))
+			})
+
+			It("names the pool in the error", func() {
+				_, err := router.PoolUsage()
+				Expect(err).To(MatchError(`pool "blue": pineapple`))
+			})
+		})
+	})
+})
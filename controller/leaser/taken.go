@@ -0,0 +1,468 @@
+package leaser
+
+import "sort"
+
+// Interval holds the positions from First to Last, both included.
+type Interval struct {
+	First, Last int
+}
+
+func (i Interval) len() int {
+	return i.Last - i.First + 1
+}
+
+// Taken is the set of positions of a pool that may not be handed out: the
+// taken subnets together with the excluded and withheld ones. It keeps them as
+// maximal runs of consecutive positions in a treap, whose nodes also hold the
+// number of positions and the widest free gap below them. Finding the n-th
+// free position, the one nearest to another or the middle of the widest gap,
+// and taking or freeing one, all cost the logarithm of the number of runs,
+// however large the pool and however full it is.
+type Taken struct {
+	size        int
+	unavailable []Interval
+	root        *takenRun
+	seed        uint64
+}
+
+// takenRun is a node of the treap, ordered by position and heap ordered by
+// priority.
+type takenRun struct {
+	run         Interval
+	priority    uint64
+	left, right *takenRun
+
+	// gap is the number of free positions up to the next run, or 0 for the
+	// last one
+	gap int
+	// count and widest are the number of positions in the runs of the subtree
+	// and the largest gap among them
+	count  int
+	widest int
+}
+
+// NewTaken returns the positions, in any order and with duplicates, as taken
+// in a pool of size subnets.
+func NewTaken(size int, positions []int) *Taken {
+	return newTaken(size, nil, positions)
+}
+
+// newTaken adds the positions to the sorted intervals of the pool that are
+// never handed out.
+func newTaken(size int, unavailable []Interval, positions []int) *Taken {
+	sorted := make([]int, 0, len(positions))
+	for _, p := range positions {
+		if p >= 0 && p < size && !containsPosition(unavailable, p) {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Ints(sorted)
+
+	runs := make([]Interval, 0, len(unavailable))
+	j := 0
+	for _, p := range sorted {
+		for ; j < len(unavailable) && unavailable[j].First <= p; j++ {
+			runs = appendInterval(runs, unavailable[j])
+		}
+		runs = appendInterval(runs, Interval{p, p})
+	}
+	for ; j < len(unavailable); j++ {
+		runs = appendInterval(runs, unavailable[j])
+	}
+
+	t := &Taken{size: size, unavailable: unavailable}
+	t.root = t.build(runs)
+	return t
+}
+
+// build returns the treap of the sorted runs, which neither overlap nor touch,
+// built bottom up with a stack of the nodes on its right spine.
+func (t *Taken) build(runs []Interval) *takenRun {
+	var spine []*takenRun
+	for k, run := range runs {
+		n := t.newRun(run)
+		if k+1 < len(runs) {
+			n.gap = runs[k+1].First - run.Last - 1
+		}
+		var left *takenRun
+		for len(spine) > 0 && spine[len(spine)-1].priority < n.priority {
+			left = spine[len(spine)-1]
+			spine = spine[:len(spine)-1]
+		}
+		n.left = left
+		if len(spine) > 0 {
+			spine[len(spine)-1].right = n
+		}
+		spine = append(spine, n)
+	}
+	if len(spine) == 0 {
+		return nil
+	}
+	spine[0].refresh()
+	return spine[0]
+}
+
+func (t *Taken) newRun(run Interval) *takenRun {
+	// splitmix64, so that the same positions always make the same tree
+	t.seed += 0x9e3779b97f4a7c15
+	z := t.seed
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return &takenRun{run: run, priority: z ^ z>>31}
+}
+
+// Size returns the number of subnets in the pool.
+func (t *Taken) Size() int {
+	return t.size
+}
+
+// Count returns the number of positions that may not be handed out.
+func (t *Taken) Count() int {
+	return t.root.total()
+}
+
+// Free returns the number of positions that may be handed out.
+func (t *Taken) Free() int {
+	return t.size - t.Count()
+}
+
+func (t *Taken) Contains(i int) bool {
+	return t.runAt(i) != nil
+}
+
+// Add takes the position, and reports whether it was free.
+func (t *Taken) Add(p int) bool {
+	if p < 0 || p >= t.size {
+		return false
+	}
+	before, after := split(t.root, p+1)
+	before, prev := splitLast(before)
+	next, after := splitFirst(after)
+	if prev != nil && prev.run.Last >= p {
+		t.root = join(before, after, prev, next)
+		return false
+	}
+
+	switch {
+	case prev != nil && prev.run.Last == p-1 && next != nil && next.run.First == p+1:
+		prev.run.Last, prev.gap = next.run.Last, next.gap
+		next = nil
+	case prev != nil && prev.run.Last == p-1:
+		prev.run.Last = p
+		if next != nil {
+			prev.gap--
+		}
+	case next != nil && next.run.First == p+1:
+		next.run.First = p
+		if prev != nil {
+			prev.gap--
+		}
+	default:
+		run := t.newRun(Interval{p, p})
+		if next != nil {
+			run.gap = next.run.First - p - 1
+		}
+		if prev != nil {
+			prev.gap = p - prev.run.Last - 1
+		}
+		t.root = join(before, after, prev, run, next)
+		return true
+	}
+	t.root = join(before, after, prev, next)
+	return true
+}
+
+// Remove frees the position, unless it is excluded or withheld, and reports
+// whether it was taken.
+func (t *Taken) Remove(p int) bool {
+	if p < 0 || p >= t.size || containsPosition(t.unavailable, p) {
+		return false
+	}
+	before, after := split(t.root, p+1)
+	before, n := splitLast(before)
+	if n == nil || n.run.Last < p {
+		t.root = join(before, after, n)
+		return false
+	}
+	before, prev := splitLast(before)
+
+	run, gap := n.run, n.gap
+	switch {
+	case run.First < p && p < run.Last:
+		upper := t.newRun(Interval{p + 1, run.Last})
+		upper.gap = gap
+		n.run.Last, n.gap = p-1, 1
+		t.root = join(before, after, prev, n, upper)
+		return true
+	case run.First < p:
+		n.run.Last = p - 1
+		if gap > 0 {
+			n.gap++
+		}
+	case p < run.Last:
+		n.run.First = p + 1
+		if prev != nil {
+			prev.gap++
+		}
+	default:
+		if prev != nil && gap > 0 {
+			prev.gap += gap + 1
+		} else if prev != nil {
+			prev.gap = 0
+		}
+		n = nil
+	}
+	t.root = join(before, after, prev, n)
+	return true
+}
+
+// runAt returns the run holding the position, or nil if it is free.
+func (t *Taken) runAt(p int) *takenRun {
+	n := t.root
+	for n != nil {
+		switch {
+		case p < n.run.First:
+			n = n.left
+		case p > n.run.Last:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// nthFree returns the n-th (from zero) free position.
+func (t *Taken) nthFree(n int) int {
+	node, takenBefore := t.root, 0
+	for node != nil {
+		// the free positions before the run
+		freeBefore := node.run.First - takenBefore - node.left.total()
+		if n < freeBefore {
+			node = node.left
+			continue
+		}
+		takenBefore += node.left.total() + node.run.len()
+		node = node.right
+	}
+	return n + takenBefore
+}
+
+// nearestFree returns the free position closest to the anchor, preferring the
+// higher one on a tie, or -1 if there is none.
+func (t *Taken) nearestFree(anchor int) int {
+	if anchor >= t.size {
+		anchor = t.size - 1
+	}
+	if anchor < 0 {
+		anchor = 0
+	}
+	n := t.runAt(anchor)
+	if n == nil {
+		return anchor
+	}
+	above, below := n.run.Last+1, n.run.First-1
+	if above < t.size && (below < 0 || above-anchor <= anchor-below) {
+		return above
+	}
+	return below
+}
+
+// first returns the lowest position that may not be handed out, or -1.
+func (t *Taken) first() int {
+	n := t.root
+	if n == nil {
+		return -1
+	}
+	for n.left != nil {
+		n = n.left
+	}
+	return n.run.First
+}
+
+// last returns the highest position that may not be handed out, or -1.
+func (t *Taken) last() int {
+	n := t.root
+	if n == nil {
+		return -1
+	}
+	for n.right != nil {
+		n = n.right
+	}
+	return n.run.Last
+}
+
+// widestGap returns the middle of the lowest of the widest gaps between two
+// runs and its distance from them, or -1 and 0 if there is no gap.
+func (t *Taken) widestGap() (int, int) {
+	if t.root.widestGap() == 0 {
+		return -1, 0
+	}
+	distance := (t.root.widest + 1) / 2
+	n := t.root
+	for {
+		switch {
+		case n.left.widestGap() >= 2*distance-1:
+			n = n.left
+		case n.gap >= 2*distance-1:
+			return n.run.Last + distance, distance
+		default:
+			n = n.right
+		}
+	}
+}
+
+func (n *takenRun) total() int {
+	if n == nil {
+		return 0
+	}
+	return n.count
+}
+
+func (n *takenRun) widestGap() int {
+	if n == nil {
+		return 0
+	}
+	return n.widest
+}
+
+func (n *takenRun) update() {
+	n.count = n.left.total() + n.run.len() + n.right.total()
+	n.widest = n.gap
+	if w := n.left.widestGap(); w > n.widest {
+		n.widest = w
+	}
+	if w := n.right.widestGap(); w > n.widest {
+		n.widest = w
+	}
+}
+
+// refresh updates the whole subtree, children first.
+func (n *takenRun) refresh() {
+	if n == nil {
+		return
+	}
+	n.left.refresh()
+	n.right.refresh()
+	n.update()
+}
+
+// split returns the runs starting before first, and the others.
+func split(n *takenRun, first int) (*takenRun, *takenRun) {
+	if n == nil {
+		return nil, nil
+	}
+	if n.run.First < first {
+		var right *takenRun
+		n.right, right = split(n.right, first)
+		n.update()
+		return n, right
+	}
+	var left *takenRun
+	left, n.left = split(n.left, first)
+	n.update()
+	return left, n
+}
+
+// splitFirst returns the lowest run on its own, and the others.
+func splitFirst(n *takenRun) (*takenRun, *takenRun) {
+	if n == nil {
+		return nil, nil
+	}
+	if n.left == nil {
+		rest := n.right
+		n.right = nil
+		return n, rest
+	}
+	var first *takenRun
+	first, n.left = splitFirst(n.left)
+	n.update()
+	return first, n
+}
+
+// splitLast returns the runs but the highest one, and the highest on its own.
+func splitLast(n *takenRun) (*takenRun, *takenRun) {
+	if n == nil {
+		return nil, nil
+	}
+	if n.right == nil {
+		rest := n.left
+		n.left = nil
+		return rest, n
+	}
+	var last *takenRun
+	n.right, last = splitLast(n.right)
+	n.update()
+	return n, last
+}
+
+// merge returns the runs of both trees, all of left lower than all of right.
+func merge(left, right *takenRun) *takenRun {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case left.priority > right.priority:
+		left.right = merge(left.right, right)
+		left.update()
+		return left
+	default:
+		right.left = merge(left, right.left)
+		right.update()
+		return right
+	}
+}
+
+// join merges the trees before and after around the runs between them, which
+// are single nodes given in order, skipping the nil ones.
+func join(before, after *takenRun, runs ...*takenRun) *takenRun {
+	root := before
+	for _, n := range runs {
+		if n != nil {
+			n.update()
+			root = merge(root, n)
+		}
+	}
+	return merge(root, after)
+}
+
+// addInterval returns the sorted intervals with another one added, merged with
+// the intervals it overlaps or touches.
+func addInterval(intervals []Interval, added Interval) []Interval {
+	merged := make([]Interval, 0, len(intervals)+1)
+	i := 0
+	for ; i < len(intervals) && intervals[i].First <= added.First; i++ {
+		merged = appendInterval(merged, intervals[i])
+	}
+	merged = appendInterval(merged, added)
+	for ; i < len(intervals); i++ {
+		merged = appendInterval(merged, intervals[i])
+	}
+	return merged
+}
+
+// appendInterval appends an interval that starts no lower than the last one,
+// merging the two if they overlap or touch.
+func appendInterval(intervals []Interval, next Interval) []Interval {
+	if n := len(intervals); n > 0 && next.First <= intervals[n-1].Last+1 {
+		if next.Last > intervals[n-1].Last {
+			intervals[n-1].Last = next.Last
+		}
+		return intervals
+	}
+	return append(intervals, next)
+}
+
+func containsPosition(intervals []Interval, i int) bool {
+	j := sort.Search(len(intervals), func(j int) bool { return intervals[j].Last >= i })
+	return j < len(intervals) && intervals[j].First <= i
+}
+
+func intervalsLen(intervals []Interval) int {
+	n := 0
+	for _, interval := range intervals {
+		n += interval.len()
+	}
+	return n
+}
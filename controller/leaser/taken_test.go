@@ -0,0 +1,138 @@
+package leaser_test
+
+import (
+	mathRand "math/rand"
+
+	"code.cloudfoundry.org/silk/controller/leaser"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Taken", func() {
+	It("counts each taken position once", func() {
+		taken := leaser.NewTaken(10, []int{4, 2, 3, 2, 9})
+
+		Expect(taken.Size()).To(Equal(10))
+		Expect(taken.Count()).To(Equal(4))
+		Expect(taken.Free()).To(Equal(6))
+		Expect(taken.Contains(3)).To(BeTrue())
+		Expect(taken.Contains(5)).To(BeFalse())
+		Expect(taken.Contains(9)).To(BeTrue())
+	})
+
+	It("has nothing taken without positions", func() {
+		taken := leaser.NewTaken(10, nil)
+		Expect(taken.Count()).To(Equal(0))
+		Expect(taken.Contains(0)).To(BeFalse())
+	})
+
+	DescribeTable("agrees with a plain list of the positions",
+		func(size, n int) {
+			rand := mathRand.New(mathRand.NewSource(42))
+			positions := make([]int, n)
+			isTaken := map[int]bool{}
+			for i := range positions {
+				positions[i] = rand.Intn(size)
+				isTaken[positions[i]] = true
+			}
+			taken := leaser.NewTaken(size, positions)
+
+			expectAgrees(taken, size, isTaken)
+		},
+		Entry("when most positions are taken", 1000, 5000),
+		Entry("when half the positions are taken", 1000, 700),
+		Entry("when few positions are taken", 100000, 50),
+	)
+
+	DescribeTable("agrees with a plain list of the positions as they are added and removed",
+		func(size, n int) {
+			rand := mathRand.New(mathRand.NewSource(42))
+			taken := leaser.NewTaken(size, nil)
+			isTaken := map[int]bool{}
+			for i := 0; i < n; i++ {
+				p := rand.Intn(size)
+				if rand.Intn(3) == 0 {
+					Expect(taken.Remove(p)).To(Equal(isTaken[p]))
+					delete(isTaken, p)
+				} else {
+					Expect(taken.Add(p)).To(Equal(!isTaken[p]))
+					isTaken[p] = true
+				}
+				if i%50 == 0 && len(isTaken) < size {
+					expectAgrees(taken, size, isTaken)
+				}
+			}
+		},
+		Entry("when most positions are taken", 300, 3000),
+		Entry("when few positions are taken", 100000, 2000),
+	)
+
+	It("ignores positions outside the pool", func() {
+		taken := leaser.NewTaken(10, nil)
+		Expect(taken.Add(-1)).To(BeFalse())
+		Expect(taken.Add(10)).To(BeFalse())
+		Expect(taken.Remove(10)).To(BeFalse())
+		Expect(taken.Count()).To(Equal(0))
+	})
+
+	It("finds free positions in a pool too large to hold every position", func() {
+		taken := leaser.NewTaken(1<<62, []int{0, 1, 2, 1<<62 - 1})
+
+		Expect(taken.Free()).To(Equal(1<<62 - 4))
+		Expect((&leaser.LowestFreeFirstAllocator{}).Allocate(taken)).To(Equal(3))
+		Expect((&leaser.PackNearPreviousAllocator{}).Allocate(taken)).To(Equal(1<<62 - 2))
+		Expect((&leaser.MaximallySpreadAllocator{}).Allocate(taken)).To(Equal(2 + (1<<62-3)/2))
+	})
+})
+
+// expectAgrees checks the taken positions, and what the allocators make of
+// them, against a plain map of the positions.
+func expectAgrees(taken *leaser.Taken, size int, isTaken map[int]bool) {
+	Expect(taken.Count()).To(Equal(len(isTaken)))
+	lowest := -1
+	for i := 0; i < size; i++ {
+		Expect(taken.Contains(i)).To(Equal(isTaken[i]))
+		if lowest < 0 && !isTaken[i] {
+			lowest = i
+		}
+	}
+	Expect((&leaser.LowestFreeFirstAllocator{}).Allocate(taken)).To(Equal(lowest))
+	for seed := int64(0); seed < 5; seed++ {
+		random := leaser.NewRandomAllocator(seed).Allocate(taken)
+		Expect(random).To(BeNumerically("<", size))
+		Expect(isTaken[random]).To(BeFalse())
+	}
+
+	highest := -1
+	widest, widestDistance := -1, 0
+	previous := -1
+	for i := 0; i <= size; i++ {
+		if i < size && !isTaken[i] {
+			continue
+		}
+		distance, candidate := (i-previous)/2, previous+(i-previous)/2
+		if previous == -1 {
+			distance, candidate = i, 0
+		} else if i == size {
+			distance, candidate = size-1-previous, size-1
+		}
+		if distance > widestDistance {
+			widest, widestDistance = candidate, distance
+		}
+		if i < size {
+			previous, highest = i, i
+		}
+	}
+	Expect((&leaser.MaximallySpreadAllocator{}).Allocate(taken)).To(Equal(widest))
+
+	nearest := -1
+	for distance := 0; nearest < 0; distance++ {
+		if above := highest + distance; above < size && !isTaken[above] {
+			nearest = above
+		} else if below := highest - distance; below >= 0 && !isTaken[below] {
+			nearest = below
+		}
+	}
+	Expect((&leaser.PackNearPreviousAllocator{}).Allocate(taken)).To(Equal(nearest))
+}
@@ -0,0 +1,110 @@
+package leaser
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+// Topology carves the blocks of a pool into partitions, one for each value of
+// a key of the host metadata such as az, so that routers can summarise the
+// blocks of the hosts that share a value with a single route. Hosts without a
+// partition, or whose partition is full, get blocks from the overflow: the
+// blocks of the pool outside every partition.
+type Topology struct {
+	key        string
+	pools      *CIDRPools
+	partitions []topologyPartition
+}
+
+type topologyPartition struct {
+	value string
+	pool  *CIDRPool
+}
+
+// NewTopology partitions the pools by the key, which is named the way a label
+// selector names it.
+func NewTopology(key string, pools *CIDRPools) *Topology {
+	return &Topology{key: key, pools: pools}
+}
+
+// AddPartition hands the blocks inside the range to the hosts with the value,
+// and no longer to the other hosts.
+func (t *Topology) AddPartition(value, partitionRange string, allocator allocator) error {
+	if t.partition(value) != nil {
+		return fmt.Errorf("duplicate partition for %s=%s", t.key, value)
+	}
+	pool, err := t.pools.Partition(partitionRange, allocator)
+	if err != nil {
+		return err
+	}
+	t.partitions = append(t.partitions, topologyPartition{value: value, pool: pool})
+	return nil
+}
+
+// Partition returns the name of the partition of the host, and whether the
+// value of the host has one. The name is empty for a host without a value.
+func (t *Topology) Partition(host *controller.HostMetadata) (string, bool) {
+	value, ok := host.Label(t.key)
+	if !ok {
+		return "", false
+	}
+	name := fmt.Sprintf("%s=%s", t.key, value)
+	return name, t.partition(value) != nil
+}
+
+// GetAvailableBlock returns a free block of the partition of the host, or an
+// empty string if it has none or it is full.
+func (t *Topology) GetAvailableBlock(host *controller.HostMetadata, taken []string) string {
+	value, ok := host.Label(t.key)
+	if !ok {
+		return ""
+	}
+	if pool := t.partition(value); pool != nil {
+		return pool.GetAvailableBlock(taken)
+	}
+	return ""
+}
+
+// Take marks the block as leased in its partition.
+func (t *Topology) Take(subnet string) bool {
+	taken := false
+	for _, partition := range t.partitions {
+		taken = partition.pool.Take(subnet) || taken
+	}
+	return taken
+}
+
+func (t *Topology) Free(subnet string) bool {
+	freed := false
+	for _, partition := range t.partitions {
+		freed = partition.pool.Free(subnet) || freed
+	}
+	return freed
+}
+
+func (t *Topology) Reset(leased []string) {
+	for _, partition := range t.partitions {
+		partition.pool.Reset(leased)
+	}
+}
+
+// PartitionOf returns the name of the partition of the block, or an empty
+// string for a block of the overflow.
+func (t *Topology) PartitionOf(subnet string) string {
+	for _, partition := range t.partitions {
+		if partition.pool.IsBlockMember(subnet) {
+			return fmt.Sprintf("%s=%s", t.key, partition.value)
+		}
+	}
+	return ""
+}
+
+func (t *Topology) partition(value string) *CIDRPool {
+	for _, partition := range t.partitions {
+		if partition.value == value {
+			return partition.pool
+		}
+	}
+	return nil
+}
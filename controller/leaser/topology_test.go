@@ -0,0 +1,90 @@
+package leaser_test
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Topology", func() {
+	var (
+		cidrPools *leaser.CIDRPools
+		topology  *leaser.Topology
+		z1, z3    *controller.HostMetadata
+	)
+
+	BeforeEach(func() {
+		cidrPools = &leaser.CIDRPools{}
+		cidrPools.AddActive(leaser.NewCIDRPoolWithAllocator("10.255.0.0/16", 24, &leaser.LowestFreeFirstAllocator{}))
+		topology = leaser.NewTopology("az", cidrPools)
+		Expect(topology.AddPartition("z1", "10.255.16.0/20", &leaser.LowestFreeFirstAllocator{})).To(Succeed())
+		Expect(topology.AddPartition("z2", "10.255.32.0/20", &leaser.LowestFreeFirstAllocator{})).To(Succeed())
+
+		z1 = &controller.HostMetadata{AZ: "z1"}
+		z3 = &controller.HostMetadata{AZ: "z3"}
+	})
+
+	It("hands hosts blocks of the partition of their value", func() {
+		Expect(topology.GetAvailableBlock(z1, nil)).To(Equal("10.255.16.0/24"))
+		Expect(topology.GetAvailableBlock(&controller.HostMetadata{AZ: "z2"}, []string{"10.255.32.0/24"})).To(Equal("10.255.33.0/24"))
+	})
+
+	It("hands out nothing to hosts without a partition", func() {
+		Expect(topology.GetAvailableBlock(z3, nil)).To(Equal(""))
+		Expect(topology.GetAvailableBlock(nil, nil)).To(Equal(""))
+	})
+
+	It("tracks the leased blocks of the partitions", func() {
+		Expect(topology.Take("10.255.16.0/24")).To(BeTrue())
+		Expect(topology.Take("10.255.1.0/24")).To(BeFalse())
+		Expect(topology.GetAvailableBlock(z1, nil)).To(Equal("10.255.17.0/24"))
+
+		Expect(topology.Free("10.255.16.0/24")).To(BeTrue())
+		Expect(topology.GetAvailableBlock(z1, nil)).To(Equal("10.255.16.0/24"))
+
+		topology.Reset([]string{"10.255.16.0/24", "10.255.32.0/24"})
+		Expect(topology.GetAvailableBlock(z1, nil)).To(Equal("10.255.17.0/24"))
+		Expect(topology.GetAvailableBlock(&controller.HostMetadata{AZ: "z2"}, nil)).To(Equal("10.255.33.0/24"))
+	})
+
+	It("withholds the partitions from the rest of the pool", func() {
+		var taken []string
+		for i := 1; i < 16; i++ {
+			taken = append(taken, fmt.Sprintf("10.255.%d.0/24", i))
+		}
+		Expect(cidrPools.GetAvailableBlock(taken)).To(Equal("10.255.48.0/24"))
+	})
+
+	It("names the partition of a host", func() {
+		name, ok := topology.Partition(z1)
+		Expect(name).To(Equal("az=z1"))
+		Expect(ok).To(BeTrue())
+
+		name, ok = topology.Partition(z3)
+		Expect(name).To(Equal("az=z3"))
+		Expect(ok).To(BeFalse())
+
+		name, ok = topology.Partition(nil)
+		Expect(name).To(BeEmpty())
+		Expect(ok).To(BeFalse())
+	})
+
+	It("names the partition of a block", func() {
+		Expect(topology.PartitionOf("10.255.33.0/24")).To(Equal("az=z2"))
+		Expect(topology.PartitionOf("10.255.48.0/24")).To(BeEmpty())
+	})
+
+	It("partitions by a label", func() {
+		topology = leaser.NewTopology("rack", cidrPools)
+		Expect(topology.AddPartition("r7", "10.255.64.0/20", &leaser.LowestFreeFirstAllocator{})).To(Succeed())
+		Expect(topology.GetAvailableBlock(&controller.HostMetadata{Labels: map[string]string{"rack": "r7"}}, nil)).To(Equal("10.255.64.0/24"))
+	})
+
+	It("rejects a second partition for a value", func() {
+		err := topology.AddPartition("z1", "10.255.64.0/20", &leaser.LowestFreeFirstAllocator{})
+		Expect(err).To(MatchError("duplicate partition for az=z1"))
+	})
+})
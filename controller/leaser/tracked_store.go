@@ -0,0 +1,191 @@
+package leaser
+
+import (
+	"fmt"
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+)
+
+// trackedPool keeps the subnets of the leases taken, so that it hands out
+// only the others.
+type trackedPool interface {
+	Take(string) bool
+	Free(string) bool
+	Reset([]string)
+}
+
+// TrackedStore is the store of a pool that keeps the subnets of its leases
+// taken in the pool's networks, so that an acquisition neither reads nor
+// parses every lease. WithAllocationLock loads every lease into the networks
+// when the generation of the pool's leases in the store differs from the one
+// they hold, which happens when another controller changed them. Otherwise
+// the networks only follow the leases that the transaction adds and deletes,
+// and drop those changes again if it fails.
+type TrackedStore struct {
+	database.Store
+	pools []trackedPool
+
+	lock       sync.Mutex
+	loaded     bool
+	generation int64
+}
+
+func NewTrackedStore(store database.Store) *TrackedStore {
+	return &TrackedStore{Store: store}
+}
+
+// Track keeps the subnets of the leases taken in the pool as well. Pools are
+// tracked before the store is first used.
+func (s *TrackedStore) Track(pool trackedPool) {
+	s.pools = append(s.pools, pool)
+	s.loaded = false
+}
+
+func (s *TrackedStore) WithAllocationLock(f func(database.LeaseStore) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tracked := &trackedLeaseStore{pools: s.pools}
+	var generation int64
+	err := s.Store.WithAllocationLock(func(store database.LeaseStore) error {
+		tracked.LeaseStore = store
+		err := s.load(store)
+		if err != nil {
+			return err
+		}
+		err = f(tracked)
+		if err != nil {
+			return err
+		}
+		generation, err = store.Generation()
+		if err != nil {
+			return fmt.Errorf("getting generation of leases: %s", err)
+		}
+		return nil
+	})
+	if err != nil {
+		tracked.undo()
+		return err
+	}
+	s.generation = generation
+	return nil
+}
+
+// load takes the subnets of every lease of the pool in the networks, unless
+// they already hold the generation in the store.
+func (s *TrackedStore) load(store database.LeaseStore) error {
+	generation, err := store.Generation()
+	if err != nil {
+		return fmt.Errorf("getting generation of leases: %s", err)
+	}
+	if s.loaded && generation == s.generation {
+		return nil
+	}
+
+	s.loaded = false
+	var leased []string
+	for _, all := range []func() ([]controller.Lease, error){store.AllBlockSubnets, store.AllSingleIPSubnets, store.AllBlockSubnetsV6} {
+		leases, err := all()
+		if err != nil {
+			return fmt.Errorf("getting all subnets: %s", err)
+		}
+		for _, lease := range leases {
+			leased = append(leased, subnetsOf(lease)...)
+		}
+	}
+	for _, pool := range s.pools {
+		pool.Reset(leased)
+	}
+	s.loaded, s.generation = true, generation
+	return nil
+}
+
+// trackedLeaseStore is the store a TrackedStore hands to the transaction. It
+// takes and frees the subnets of the leases it adds and deletes.
+type trackedLeaseStore struct {
+	database.LeaseStore
+	pools   []trackedPool
+	changes []trackedChange
+}
+
+type trackedChange struct {
+	pool   trackedPool
+	subnet string
+	taken  bool
+}
+
+func (s *trackedLeaseStore) AddEntry(lease controller.Lease) error {
+	err := s.LeaseStore.AddEntry(lease)
+	if err != nil {
+		return err
+	}
+	s.change(lease, true)
+	return nil
+}
+
+func (s *trackedLeaseStore) DeleteEntry(underlayIP string) error {
+	return s.deleteEntry(underlayIP, func() error {
+		return s.LeaseStore.DeleteEntry(underlayIP)
+	})
+}
+
+func (s *trackedLeaseStore) DeleteExpiredEntry(underlayIP string, expirationTime int) error {
+	return s.deleteEntry(underlayIP, func() error {
+		return s.LeaseStore.DeleteExpiredEntry(underlayIP, expirationTime)
+	})
+}
+
+// deleteEntry looks the lease up first, since deleting it does not tell its
+// subnets.
+func (s *trackedLeaseStore) deleteEntry(underlayIP string, deleteEntry func() error) error {
+	lease, err := s.LeaseStore.LeaseForUnderlayIP(underlayIP)
+	if err != nil {
+		return fmt.Errorf("getting lease for underlay ip: %s", err)
+	}
+	err = deleteEntry()
+	if err != nil {
+		return err
+	}
+	if lease != nil {
+		s.change(*lease, false)
+	}
+	return nil
+}
+
+func (s *trackedLeaseStore) change(lease controller.Lease, taken bool) {
+	for _, subnet := range subnetsOf(lease) {
+		for _, pool := range s.pools {
+			changed := pool.Free(subnet)
+			if taken {
+				changed = pool.Take(subnet)
+			}
+			if changed {
+				s.changes = append(s.changes, trackedChange{pool: pool, subnet: subnet, taken: taken})
+			}
+		}
+	}
+}
+
+// undo reverts the changes, latest first, when the transaction is rolled back.
+func (s *trackedLeaseStore) undo() {
+	for i := len(s.changes) - 1; i >= 0; i-- {
+		change := s.changes[i]
+		if change.taken {
+			change.pool.Free(change.subnet)
+		} else {
+			change.pool.Take(change.subnet)
+		}
+	}
+}
+
+func subnetsOf(lease controller.Lease) []string {
+	var subnets []string
+	for _, subnet := range []string{lease.OverlaySubnet, lease.OverlaySubnetV6} {
+		if subnet != "" {
+			subnets = append(subnets, subnet)
+		}
+	}
+	return subnets
+}
@@ -0,0 +1,142 @@
+package leaser_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type failingLeaseStore struct {
+	database.LeaseStore
+	err error
+}
+
+func (s failingLeaseStore) AllBlockSubnets() ([]controller.Lease, error) {
+	return nil, s.err
+}
+
+type failingStore struct {
+	database.Store
+	err error
+}
+
+func (s failingStore) WithAllocationLock(f func(database.LeaseStore) error) error {
+	return s.Store.WithAllocationLock(func(store database.LeaseStore) error {
+		return f(failingLeaseStore{LeaseStore: store, err: s.err})
+	})
+}
+
+var _ = Describe("TrackedStore", func() {
+	var (
+		store        database.Store
+		trackedStore *leaser.TrackedStore
+		cidrPool     *leaser.CIDRPool
+		lease        controller.Lease
+	)
+
+	acquire := func() string {
+		var subnet string
+		Expect(trackedStore.WithAllocationLock(func(database.LeaseStore) error {
+			subnet = cidrPool.GetAvailableBlock(nil)
+			return nil
+		})).To(Succeed())
+		return subnet
+	}
+
+	BeforeEach(func() {
+		store = database.NewMemoryStore(database.ClockFunc(time.Now)).ForPool(controller.DefaultPool)
+		cidrPool = leaser.NewCIDRPoolWithAllocator("10.255.0.0/22", 24, &leaser.LowestFreeFirstAllocator{})
+		trackedStore = leaser.NewTrackedStore(store)
+		trackedStore.Track(cidrPool)
+		lease = controller.Lease{
+			UnderlayIP:          "10.244.11.22",
+			OverlaySubnet:       "10.255.1.0/24",
+			OverlayHardwareAddr: "ee:ee:0a:ff:01:00",
+		}
+	})
+
+	It("takes the subnets of the leases in the store", func() {
+		Expect(store.AddEntry(lease)).To(Succeed())
+		Expect(acquire()).To(Equal("10.255.2.0/24"))
+	})
+
+	It("takes and frees the subnets of the leases it adds and deletes", func() {
+		Expect(trackedStore.WithAllocationLock(func(s database.LeaseStore) error {
+			return s.AddEntry(lease)
+		})).To(Succeed())
+		Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.2.0/24"))
+
+		Expect(trackedStore.WithAllocationLock(func(s database.LeaseStore) error {
+			return s.DeleteEntry(lease.UnderlayIP)
+		})).To(Succeed())
+		Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.1.0/24"))
+	})
+
+	It("frees the subnets of the expired leases it deletes", func() {
+		Expect(store.AddEntry(lease)).To(Succeed())
+		Expect(acquire()).To(Equal("10.255.2.0/24"))
+
+		Expect(trackedStore.WithAllocationLock(func(s database.LeaseStore) error {
+			return s.DeleteExpiredEntry(lease.UnderlayIP, -1)
+		})).To(Succeed())
+		Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.1.0/24"))
+	})
+
+	It("reloads the leases when another writer changed them", func() {
+		Expect(acquire()).To(Equal("10.255.1.0/24"))
+
+		Expect(store.AddEntry(lease)).To(Succeed())
+		Expect(acquire()).To(Equal("10.255.2.0/24"))
+
+		Expect(store.DeleteEntry(lease.UnderlayIP)).To(Succeed())
+		Expect(acquire()).To(Equal("10.255.1.0/24"))
+	})
+
+	It("drops the changes of a transaction that fails", func() {
+		err := trackedStore.WithAllocationLock(func(s database.LeaseStore) error {
+			Expect(s.AddEntry(lease)).To(Succeed())
+			return errors.New("banana")
+		})
+		Expect(err).To(MatchError("banana"))
+
+		Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.1.0/24"))
+		Expect(store.All()).To(BeEmpty())
+	})
+
+	It("does not take the subnet of a lease it failed to add", func() {
+		Expect(store.AddEntry(lease)).To(Succeed())
+		Expect(acquire()).To(Equal("10.255.2.0/24"))
+
+		err := trackedStore.WithAllocationLock(func(s database.LeaseStore) error {
+			return s.AddEntry(controller.Lease{
+				UnderlayIP:          "10.244.11.22",
+				OverlaySubnet:       "10.255.2.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:ff:02:00",
+			})
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(cidrPool.GetAvailableBlock(nil)).To(Equal("10.255.2.0/24"))
+	})
+
+	Context("when getting the leases fails", func() {
+		BeforeEach(func() {
+			trackedStore = leaser.NewTrackedStore(failingStore{Store: store, err: errors.New("strawberry")})
+			trackedStore.Track(cidrPool)
+		})
+
+		It("returns an error and does not run the transaction", func() {
+			ran := false
+			err := trackedStore.WithAllocationLock(func(database.LeaseStore) error {
+				ran = true
+				return nil
+			})
+			Expect(err).To(MatchError("getting all subnets: strawberry"))
+			Expect(ran).To(BeFalse())
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package leaser
 
 import (
+	"errors"
 	"fmt"
 	"net"
 
@@ -14,12 +15,31 @@ func (v *LeaseValidator) Validate(lease controller.Lease) error {
 		return fmt.Errorf("invalid underlay ip: %s", lease.UnderlayIP)
 	}
 
-	_, _, err := net.ParseCIDR(lease.OverlaySubnet)
-	if err != nil {
-		return err
+	if lease.OverlaySubnet == "" && lease.OverlaySubnetV6 == "" {
+		return errors.New("missing overlay subnet")
+	}
+
+	if lease.OverlaySubnet != "" {
+		ip, _, err := net.ParseCIDR(lease.OverlaySubnet)
+		if err != nil {
+			return err
+		}
+		if ip.To4() == nil {
+			return fmt.Errorf("overlay subnet is not ipv4: %s", lease.OverlaySubnet)
+		}
+	}
+
+	if lease.OverlaySubnetV6 != "" {
+		ip, _, err := net.ParseCIDR(lease.OverlaySubnetV6)
+		if err != nil {
+			return err
+		}
+		if ip.To4() != nil {
+			return fmt.Errorf("overlay subnet is not ipv6: %s", lease.OverlaySubnetV6)
+		}
 	}
 
-	_, err = net.ParseMAC(lease.OverlayHardwareAddr)
+	_, err := net.ParseMAC(lease.OverlayHardwareAddr)
 	if err != nil {
 		return err
 	}
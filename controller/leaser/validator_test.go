@@ -48,6 +48,65 @@ var _ = Describe("Validator", func() {
 		})
 	})
 
+	Context("when the lease has no overlay subnet", func() {
+		BeforeEach(func() {
+			lease.OverlaySubnet = ""
+		})
+		It("returns an error", func() {
+			err := validator.Validate(lease)
+			Expect(err).To(MatchError("missing overlay subnet"))
+		})
+	})
+
+	Context("when the overlay subnet is not ipv4", func() {
+		BeforeEach(func() {
+			lease.OverlaySubnet = "fd00::/64"
+		})
+		It("returns an error", func() {
+			err := validator.Validate(lease)
+			Expect(err).To(MatchError("overlay subnet is not ipv4: fd00::/64"))
+		})
+	})
+
+	Context("when the lease has an ipv6 overlay subnet", func() {
+		BeforeEach(func() {
+			lease.OverlaySubnetV6 = "fd00:0:0:2a::/64"
+		})
+
+		It("checks that the lease is valid", func() {
+			Expect(validator.Validate(lease)).To(Succeed())
+		})
+
+		Context("and no ipv4 overlay subnet", func() {
+			BeforeEach(func() {
+				lease.OverlaySubnet = ""
+			})
+			It("checks that the lease is valid", func() {
+				Expect(validator.Validate(lease)).To(Succeed())
+			})
+		})
+
+		Context("when the ipv6 overlay subnet is invalid", func() {
+			BeforeEach(func() {
+				lease.OverlaySubnetV6 = "not-a-subnet"
+			})
+			It("returns an error", func() {
+				err := validator.Validate(lease)
+				Expect(err).To(MatchError("invalid CIDR address: not-a-subnet"))
+			})
+		})
+
+		Context("when the ipv6 overlay subnet is not ipv6", func() {
+			BeforeEach(func() {
+				lease.OverlaySubnetV6 = "10.255.0.0/24"
+			})
+			It("returns an error", func() {
+				err := validator.Validate(lease)
+				Expect(err).To(MatchError("overlay subnet is not ipv6: 10.255.0.0/24"))
+			})
+		})
+	})
+
 	Context("when the hardware addr is invalid is invalid", func() {
 		BeforeEach(func() {
 			lease.OverlayHardwareAddr = "not-a-mac"
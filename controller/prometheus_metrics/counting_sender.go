@@ -0,0 +1,76 @@
+package prometheus_metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//go:generate counterfeiter -o fakes/metrics_sender.go --fake-name MetricsSender . metricsSender
+type metricsSender interface {
+	SendValue(name string, value float64, units string)
+	SendDuration(name string, duration time.Duration)
+	IncrementCounter(name string)
+}
+
+// CountingSender passes every metric on to the next sender, and also counts
+// the counter increments in Prometheus counters named after them.
+type CountingSender struct {
+	next       metricsSender
+	registerer prometheus.Registerer
+
+	lock     sync.Mutex
+	counters map[string]prometheus.Counter
+}
+
+// NewCountingSender registers the counters of the names up front, so that they
+// are scraped as zero before the first increment. Other names are registered
+// when they are first incremented.
+func NewCountingSender(registerer prometheus.Registerer, next metricsSender, names ...string) (*CountingSender, error) {
+	sender := &CountingSender{
+		next:       next,
+		registerer: registerer,
+		counters:   map[string]prometheus.Counter{},
+	}
+	for _, name := range names {
+		if _, err := sender.counter(name); err != nil {
+			return nil, err
+		}
+	}
+	return sender, nil
+}
+
+func (c *CountingSender) SendValue(name string, value float64, units string) {
+	c.next.SendValue(name, value, units)
+}
+
+func (c *CountingSender) SendDuration(name string, duration time.Duration) {
+	c.next.SendDuration(name, duration)
+}
+
+func (c *CountingSender) IncrementCounter(name string) {
+	c.next.IncrementCounter(name)
+
+	c.lock.Lock()
+	counter, err := c.counter(name)
+	c.lock.Unlock()
+	if err == nil {
+		counter.Inc()
+	}
+}
+
+func (c *CountingSender) counter(name string) (prometheus.Counter, error) {
+	if counter, ok := c.counters[name]; ok {
+		return counter, nil
+	}
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: Name(name) + "_total",
+		Help: "Number of times " + name + " was counted.",
+	})
+	if err := c.registerer.Register(counter); err != nil {
+		return nil, err
+	}
+	c.counters[name] = counter
+	return counter, nil
+}
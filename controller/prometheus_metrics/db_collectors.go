@@ -0,0 +1,33 @@
+package prometheus_metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+//go:generate counterfeiter -o fakes/query_monitor.go --fake-name QueryMonitor . queryMonitor
+type queryMonitor interface {
+	Total() int64
+	Succeeded() int64
+	Failed() int64
+}
+
+// NewDBCollectors exports the stats of the connection pool and the number of
+// queries the monitor has seen.
+func NewDBCollectors(db *sql.DB, monitor queryMonitor) []prometheus.Collector {
+	queries := func(name, help string, count func() int64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(count()) })
+	}
+	return []prometheus.Collector{
+		collectors.NewDBStatsCollector(db, "silk"),
+		queries("db_queries_total", "Number of database queries run.", monitor.Total),
+		queries("db_queries_succeeded_total", "Number of database queries that succeeded.", monitor.Succeeded),
+		queries("db_queries_failed_total", "Number of database queries that failed.", monitor.Failed),
+	}
+}
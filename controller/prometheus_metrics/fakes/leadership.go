@@ -0,0 +1,98 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type Leadership struct {
+	LeaderStub        func() string
+	leaderMutex       sync.RWMutex
+	leaderArgsForCall []struct {
+	}
+	leaderReturns struct {
+		result1 string
+	}
+	leaderReturnsOnCall map[int]struct {
+		result1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *Leadership) Leader() string {
+	fake.leaderMutex.Lock()
+	ret, specificReturn := fake.leaderReturnsOnCall[len(fake.leaderArgsForCall)]
+	fake.leaderArgsForCall = append(fake.leaderArgsForCall, struct {
+	}{})
+	stub := fake.LeaderStub
+	fakeReturns := fake.leaderReturns
+	fake.recordInvocation("Leader", []interface{}{})
+	fake.leaderMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Leadership) LeaderCallCount() int {
+	fake.leaderMutex.RLock()
+	defer fake.leaderMutex.RUnlock()
+	return len(fake.leaderArgsForCall)
+}
+
+func (fake *Leadership) LeaderCalls(stub func() string) {
+	fake.leaderMutex.Lock()
+	defer fake.leaderMutex.Unlock()
+	fake.LeaderStub = stub
+}
+
+func (fake *Leadership) LeaderReturns(result1 string) {
+	fake.leaderMutex.Lock()
+	defer fake.leaderMutex.Unlock()
+	fake.LeaderStub = nil
+	fake.leaderReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Leadership) LeaderReturnsOnCall(i int, result1 string) {
+	fake.leaderMutex.Lock()
+	defer fake.leaderMutex.Unlock()
+	fake.LeaderStub = nil
+	if fake.leaderReturnsOnCall == nil {
+		fake.leaderReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.leaderReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *Leadership) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.leaderMutex.RLock()
+	defer fake.leaderMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *Leadership) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,228 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type QueryMonitor struct {
+	FailedStub        func() int64
+	failedMutex       sync.RWMutex
+	failedArgsForCall []struct {
+	}
+	failedReturns struct {
+		result1 int64
+	}
+	failedReturnsOnCall map[int]struct {
+		result1 int64
+	}
+	SucceededStub        func() int64
+	succeededMutex       sync.RWMutex
+	succeededArgsForCall []struct {
+	}
+	succeededReturns struct {
+		result1 int64
+	}
+	succeededReturnsOnCall map[int]struct {
+		result1 int64
+	}
+	TotalStub        func() int64
+	totalMutex       sync.RWMutex
+	totalArgsForCall []struct {
+	}
+	totalReturns struct {
+		result1 int64
+	}
+	totalReturnsOnCall map[int]struct {
+		result1 int64
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *QueryMonitor) Failed() int64 {
+	fake.failedMutex.Lock()
+	ret, specificReturn := fake.failedReturnsOnCall[len(fake.failedArgsForCall)]
+	fake.failedArgsForCall = append(fake.failedArgsForCall, struct {
+	}{})
+	stub := fake.FailedStub
+	fakeReturns := fake.failedReturns
+	fake.recordInvocation("Failed", []interface{}{})
+	fake.failedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *QueryMonitor) FailedCallCount() int {
+	fake.failedMutex.RLock()
+	defer fake.failedMutex.RUnlock()
+	return len(fake.failedArgsForCall)
+}
+
+func (fake *QueryMonitor) FailedCalls(stub func() int64) {
+	fake.failedMutex.Lock()
+	defer fake.failedMutex.Unlock()
+	fake.FailedStub = stub
+}
+
+func (fake *QueryMonitor) FailedReturns(result1 int64) {
+	fake.failedMutex.Lock()
+	defer fake.failedMutex.Unlock()
+	fake.FailedStub = nil
+	fake.failedReturns = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *QueryMonitor) FailedReturnsOnCall(i int, result1 int64) {
+	fake.failedMutex.Lock()
+	defer fake.failedMutex.Unlock()
+	fake.FailedStub = nil
+	if fake.failedReturnsOnCall == nil {
+		fake.failedReturnsOnCall = make(map[int]struct {
+			result1 int64
+		})
+	}
+	fake.failedReturnsOnCall[i] = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *QueryMonitor) Succeeded() int64 {
+	fake.succeededMutex.Lock()
+	ret, specificReturn := fake.succeededReturnsOnCall[len(fake.succeededArgsForCall)]
+	fake.succeededArgsForCall = append(fake.succeededArgsForCall, struct {
+	}{})
+	stub := fake.SucceededStub
+	fakeReturns := fake.succeededReturns
+	fake.recordInvocation("Succeeded", []interface{}{})
+	fake.succeededMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *QueryMonitor) SucceededCallCount() int {
+	fake.succeededMutex.RLock()
+	defer fake.succeededMutex.RUnlock()
+	return len(fake.succeededArgsForCall)
+}
+
+func (fake *QueryMonitor) SucceededCalls(stub func() int64) {
+	fake.succeededMutex.Lock()
+	defer fake.succeededMutex.Unlock()
+	fake.SucceededStub = stub
+}
+
+func (fake *QueryMonitor) SucceededReturns(result1 int64) {
+	fake.succeededMutex.Lock()
+	defer fake.succeededMutex.Unlock()
+	fake.SucceededStub = nil
+	fake.succeededReturns = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *QueryMonitor) SucceededReturnsOnCall(i int, result1 int64) {
+	fake.succeededMutex.Lock()
+	defer fake.succeededMutex.Unlock()
+	fake.SucceededStub = nil
+	if fake.succeededReturnsOnCall == nil {
+		fake.succeededReturnsOnCall = make(map[int]struct {
+			result1 int64
+		})
+	}
+	fake.succeededReturnsOnCall[i] = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *QueryMonitor) Total() int64 {
+	fake.totalMutex.Lock()
+	ret, specificReturn := fake.totalReturnsOnCall[len(fake.totalArgsForCall)]
+	fake.totalArgsForCall = append(fake.totalArgsForCall, struct {
+	}{})
+	stub := fake.TotalStub
+	fakeReturns := fake.totalReturns
+	fake.recordInvocation("Total", []interface{}{})
+	fake.totalMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *QueryMonitor) TotalCallCount() int {
+	fake.totalMutex.RLock()
+	defer fake.totalMutex.RUnlock()
+	return len(fake.totalArgsForCall)
+}
+
+func (fake *QueryMonitor) TotalCalls(stub func() int64) {
+	fake.totalMutex.Lock()
+	defer fake.totalMutex.Unlock()
+	fake.TotalStub = stub
+}
+
+func (fake *QueryMonitor) TotalReturns(result1 int64) {
+	fake.totalMutex.Lock()
+	defer fake.totalMutex.Unlock()
+	fake.TotalStub = nil
+	fake.totalReturns = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *QueryMonitor) TotalReturnsOnCall(i int, result1 int64) {
+	fake.totalMutex.Lock()
+	defer fake.totalMutex.Unlock()
+	fake.TotalStub = nil
+	if fake.totalReturnsOnCall == nil {
+		fake.totalReturnsOnCall = make(map[int]struct {
+			result1 int64
+		})
+	}
+	fake.totalReturnsOnCall[i] = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *QueryMonitor) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.failedMutex.RLock()
+	defer fake.failedMutex.RUnlock()
+	fake.succeededMutex.RLock()
+	defer fake.succeededMutex.RUnlock()
+	fake.totalMutex.RLock()
+	defer fake.totalMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *QueryMonitor) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
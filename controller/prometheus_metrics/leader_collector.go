@@ -0,0 +1,38 @@
+package prometheus_metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//go:generate counterfeiter -o fakes/leadership.go --fake-name Leadership . leadership
+type leadership interface {
+	Leader() string
+}
+
+// LeaderCollector exports the identity of the leader, as the label of a gauge
+// that is always 1. Nothing is exported while the leader is not known.
+type LeaderCollector struct {
+	leadership leadership
+	desc       *prometheus.Desc
+}
+
+func NewLeaderCollector(leadership leadership) *LeaderCollector {
+	return &LeaderCollector{
+		leadership: leadership,
+		desc: prometheus.NewDesc(namespace+"_leader",
+			"Identity of the controller that leads, as seen by this controller.",
+			[]string{"leader"}, nil),
+	}
+}
+
+func (l *LeaderCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- l.desc
+}
+
+func (l *LeaderCollector) Collect(ch chan<- prometheus.Metric) {
+	leader := l.leadership.Leader()
+	if leader == "" {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(l.desc, prometheus.GaugeValue, 1, leader)
+}
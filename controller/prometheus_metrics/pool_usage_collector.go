@@ -0,0 +1,64 @@
+package prometheus_metrics
+
+import (
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//go:generate counterfeiter -o fakes/pool_usage_repository.go --fake-name PoolUsageRepository . poolUsageRepository
+type poolUsageRepository interface {
+	PoolUsage() ([]controller.PoolUsage, error)
+}
+
+// PoolUsageCollector exports the capacity of every pool and how many of its
+// subnets are in each state, for blocks and single ips separately.
+type PoolUsageCollector struct {
+	logger     lager.Logger
+	repository poolUsageRepository
+	capacity   *prometheus.Desc
+	subnets    *prometheus.Desc
+}
+
+func NewPoolUsageCollector(logger lager.Logger, repository poolUsageRepository) *PoolUsageCollector {
+	return &PoolUsageCollector{
+		logger:     logger,
+		repository: repository,
+		capacity: prometheus.NewDesc(namespace+"_pool_capacity",
+			"Number of subnets in the active networks of the pool.",
+			[]string{"pool", "subnets"}, nil),
+		subnets: prometheus.NewDesc(namespace+"_pool_subnets",
+			"Number of subnets of the pool by state: active, expired, quarantined, reserved or free.",
+			[]string{"pool", "subnets", "state"}, nil),
+	}
+}
+
+func (p *PoolUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.capacity
+	ch <- p.subnets
+}
+
+func (p *PoolUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	usage, err := p.repository.PoolUsage()
+	if err != nil {
+		p.logger.Error("pool-usage", err)
+		return
+	}
+	for _, pool := range usage {
+		p.collect(ch, pool.Pool, "blocks", pool.Blocks)
+		p.collect(ch, pool.Pool, "single_ips", pool.SingleIPs)
+	}
+}
+
+func (p *PoolUsageCollector) collect(ch chan<- prometheus.Metric, pool, subnets string, usage controller.SubnetUsage) {
+	ch <- prometheus.MustNewConstMetric(p.capacity, prometheus.GaugeValue, float64(usage.Total), pool, subnets)
+	for state, count := range map[string]int{
+		"active":      usage.Active,
+		"expired":     usage.Expired,
+		"quarantined": usage.Quarantined,
+		"reserved":    usage.Reserved,
+		"free":        usage.Free,
+	} {
+		ch <- prometheus.MustNewConstMetric(p.subnets, prometheus.GaugeValue, float64(count), pool, subnets, state)
+	}
+}
@@ -0,0 +1,32 @@
+package prometheus_metrics
+
+import (
+	"strings"
+	"unicode"
+)
+
+const namespace = "silk_controller"
+
+// Name turns a metron metric name like totalLeases or DBOpenConnections into
+// a Prometheus one like silk_controller_total_leases or
+// silk_controller_db_open_connections.
+func Name(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	b.WriteString(namespace)
+	b.WriteRune('_')
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				b.WriteRune('_')
+			}
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			r = '_'
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,13 @@
+package prometheus_metrics_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PrometheusMetrics Suite")
+}
@@ -0,0 +1,250 @@
+package prometheus_metrics_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/metrics"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/prometheus_metrics"
+	"code.cloudfoundry.org/silk/controller/prometheus_metrics/fakes"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+type unreachableDatabase struct{}
+
+func (unreachableDatabase) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("unreachable")
+}
+
+func (unreachableDatabase) Driver() driver.Driver {
+	return nil
+}
+
+var _ = Describe("PrometheusMetrics", func() {
+	var registry *prometheus.Registry
+
+	scrape := func() string {
+		server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return string(body)
+	}
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+	})
+
+	DescribeTable("Name",
+		func(name, expected string) {
+			Expect(prometheus_metrics.Name(name)).To(Equal(expected))
+		},
+		Entry("camel case", "totalLeases", "silk_controller_total_leases"),
+		Entry("leading acronym", "DBOpenConnections", "silk_controller_db_open_connections"),
+		Entry("snake case", "http_error", "silk_controller_http_error"),
+		Entry("one word", "uptime", "silk_controller_uptime"),
+	)
+
+	Describe("SourceCollector", func() {
+		var logger *lagertest.TestLogger
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			registry.MustRegister(prometheus_metrics.NewSourceCollector(logger,
+				metrics.MetricSource{Name: "totalLeases", Getter: func() (float64, error) { return 3, nil }},
+				metrics.MetricSource{Name: "freeLeases", Getter: func() (float64, error) { return 252, nil }},
+				metrics.MetricSource{Name: "staleLeases", Getter: func() (float64, error) { return 0, errors.New("banana") }},
+			))
+		})
+
+		It("exports each source as a gauge when scraped", func() {
+			body := scrape()
+			Expect(body).To(ContainSubstring("# TYPE silk_controller_total_leases gauge\nsilk_controller_total_leases 3\n"))
+			Expect(body).To(ContainSubstring("silk_controller_free_leases 252\n"))
+		})
+
+		It("leaves out and logs the sources that fail", func() {
+			Expect(scrape()).NotTo(ContainSubstring("silk_controller_stale_leases"))
+			Expect(logger).To(gbytes.Say("metric-getter.*banana.*staleLeases"))
+		})
+	})
+
+	Describe("CountingSender", func() {
+		var (
+			next   *fakes.MetricsSender
+			sender *prometheus_metrics.CountingSender
+		)
+
+		BeforeEach(func() {
+			next = &fakes.MetricsSender{}
+			var err error
+			sender, err = prometheus_metrics.NewCountingSender(registry, next, "leaseReaped", "acquireRetry")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("exports the named counters before they are incremented", func() {
+			body := scrape()
+			Expect(body).To(ContainSubstring("silk_controller_lease_reaped_total 0\n"))
+			Expect(body).To(ContainSubstring("silk_controller_acquire_retry_total 0\n"))
+		})
+
+		It("counts increments and passes them on", func() {
+			sender.IncrementCounter("leaseReaped")
+			sender.IncrementCounter("leaseReaped")
+			sender.IncrementCounter("http_error")
+
+			body := scrape()
+			Expect(body).To(ContainSubstring("silk_controller_lease_reaped_total 2\n"))
+			Expect(body).To(ContainSubstring("silk_controller_http_error_total 1\n"))
+
+			Expect(next.IncrementCounterCallCount()).To(Equal(3))
+			Expect(next.IncrementCounterArgsForCall(2)).To(Equal("http_error"))
+		})
+
+		It("passes values and durations on", func() {
+			sender.SendValue("numberLeases", 4, "")
+			sender.SendDuration("LeasesIndexRequestTime", time.Second)
+
+			name, value, unit := next.SendValueArgsForCall(0)
+			Expect([]interface{}{name, value, unit}).To(Equal([]interface{}{"numberLeases", 4.0, ""}))
+			name, duration := next.SendDurationArgsForCall(0)
+			Expect(name).To(Equal("LeasesIndexRequestTime"))
+			Expect(duration).To(Equal(time.Second))
+		})
+
+		Context("when a counter cannot be registered", func() {
+			It("returns the error", func() {
+				_, err := prometheus_metrics.NewCountingSender(registry, next, "leaseReaped")
+				Expect(err).To(BeAssignableToTypeOf(prometheus.AlreadyRegisteredError{}))
+			})
+		})
+	})
+
+	Describe("RouteMetrics", func() {
+		It("times the requests of each route", func() {
+			routeMetrics, err := prometheus_metrics.NewRouteMetrics(registry)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler := routeMetrics.Wrap("LeasesIndex", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))
+			for i := 0; i < 2; i++ {
+				req, err := http.NewRequest("GET", "/leases", nil)
+				Expect(err).NotTo(HaveOccurred())
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}
+
+			body := scrape()
+			Expect(body).To(ContainSubstring("# TYPE silk_controller_request_duration_seconds histogram"))
+			Expect(body).To(ContainSubstring(`silk_controller_request_duration_seconds_count{code="418",method="get",route="LeasesIndex"} 2`))
+		})
+	})
+
+	Describe("PoolUsageCollector", func() {
+		var (
+			logger     *lagertest.TestLogger
+			repository *fakes.PoolUsageRepository
+		)
+
+		BeforeEach(func() {
+			logger = lagertest.NewTestLogger("test")
+			repository = &fakes.PoolUsageRepository{}
+			repository.PoolUsageReturns([]controller.PoolUsage{
+				{
+					Blocks:    controller.SubnetUsage{Total: 255, Active: 3, Expired: 1, Quarantined: 2, Reserved: 1, Free: 248},
+					SingleIPs: controller.SubnetUsage{Total: 255, Active: 1, Free: 254},
+				},
+				{
+					Pool:   "blue",
+					Blocks: controller.SubnetUsage{Total: 15, Active: 15},
+				},
+			}, nil)
+			registry.MustRegister(prometheus_metrics.NewPoolUsageCollector(logger, repository))
+		})
+
+		It("exports the capacity and the subnets of every pool by state", func() {
+			body := scrape()
+			Expect(body).To(ContainSubstring(`silk_controller_pool_capacity{pool="",subnets="blocks"} 255`))
+			Expect(body).To(ContainSubstring(`silk_controller_pool_capacity{pool="blue",subnets="blocks"} 15`))
+			Expect(body).To(ContainSubstring(`silk_controller_pool_subnets{pool="",state="active",subnets="blocks"} 3`))
+			Expect(body).To(ContainSubstring(`silk_controller_pool_subnets{pool="",state="expired",subnets="blocks"} 1`))
+			Expect(body).To(ContainSubstring(`silk_controller_pool_subnets{pool="",state="quarantined",subnets="blocks"} 2`))
+			Expect(body).To(ContainSubstring(`silk_controller_pool_subnets{pool="",state="reserved",subnets="blocks"} 1`))
+			Expect(body).To(ContainSubstring(`silk_controller_pool_subnets{pool="",state="free",subnets="blocks"} 248`))
+			Expect(body).To(ContainSubstring(`silk_controller_pool_subnets{pool="",state="free",subnets="single_ips"} 254`))
+			Expect(body).To(ContainSubstring(`silk_controller_pool_subnets{pool="blue",state="free",subnets="blocks"} 0`))
+			Expect(repository.PoolUsageCallCount()).To(Equal(1))
+		})
+
+		Context("when getting the usage fails", func() {
+			BeforeEach(func() {
+				repository.PoolUsageReturns(nil, errors.New("banana"))
+			})
+
+			It("leaves the pools out and logs the error", func() {
+				Expect(scrape()).NotTo(ContainSubstring("silk_controller_pool"))
+				Expect(logger).To(gbytes.Say("pool-usage.*banana"))
+			})
+		})
+	})
+
+	Describe("LeaderCollector", func() {
+		var leadership *fakes.Leadership
+
+		BeforeEach(func() {
+			leadership = &fakes.Leadership{}
+			leadership.LeaderReturns("controller-0")
+			registry.MustRegister(prometheus_metrics.NewLeaderCollector(leadership))
+		})
+
+		It("exports the identity of the leader", func() {
+			Expect(scrape()).To(ContainSubstring(`silk_controller_leader{leader="controller-0"} 1`))
+		})
+
+		Context("when the leader is not known", func() {
+			BeforeEach(func() {
+				leadership.LeaderReturns("")
+			})
+
+			It("exports nothing", func() {
+				Expect(scrape()).NotTo(ContainSubstring("silk_controller_leader"))
+			})
+		})
+	})
+
+	Describe("NewDBCollectors", func() {
+		It("exports the connection pool stats and query counts", func() {
+			monitor := &fakes.QueryMonitor{}
+			monitor.TotalReturns(10)
+			monitor.SucceededReturns(8)
+			monitor.FailedReturns(2)
+
+			db := sql.OpenDB(unreachableDatabase{})
+			registry.MustRegister(prometheus_metrics.NewDBCollectors(db, monitor)...)
+
+			body := scrape()
+			Expect(body).To(ContainSubstring(`go_sql_open_connections{db_name="silk"} 0`))
+			Expect(body).To(ContainSubstring("silk_controller_db_queries_total 10\n"))
+			Expect(body).To(ContainSubstring("silk_controller_db_queries_succeeded_total 8\n"))
+			Expect(body).To(ContainSubstring("silk_controller_db_queries_failed_total 2\n"))
+		})
+	})
+})
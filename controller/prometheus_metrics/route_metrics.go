@@ -0,0 +1,31 @@
+package prometheus_metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RouteMetrics times the requests of every route in one histogram, labeled
+// with the route, the method and the status code.
+type RouteMetrics struct {
+	duration *prometheus.HistogramVec
+}
+
+func NewRouteMetrics(registerer prometheus.Registerer) (*RouteMetrics, error) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to serve requests, by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+	if err := registerer.Register(duration); err != nil {
+		return nil, err
+	}
+	return &RouteMetrics{duration: duration}, nil
+}
+
+func (r *RouteMetrics) Wrap(route string, handler http.Handler) http.Handler {
+	return promhttp.InstrumentHandlerDuration(r.duration.MustCurryWith(prometheus.Labels{"route": route}), handler)
+}
@@ -0,0 +1,47 @@
+package prometheus_metrics
+
+import (
+	"code.cloudfoundry.org/cf-networking-helpers/metrics"
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SourceCollector reads the metric sources on every scrape and exports each
+// as a gauge. A source that fails is logged and left out of that scrape.
+type SourceCollector struct {
+	logger  lager.Logger
+	sources []metrics.MetricSource
+	descs   []*prometheus.Desc
+}
+
+func NewSourceCollector(logger lager.Logger, sources ...metrics.MetricSource) *SourceCollector {
+	collector := &SourceCollector{
+		logger:  logger,
+		sources: sources,
+	}
+	for _, source := range sources {
+		help := source.Name
+		if source.Unit != "" {
+			help += " in " + source.Unit
+		}
+		collector.descs = append(collector.descs, prometheus.NewDesc(Name(source.Name), help, nil, nil))
+	}
+	return collector
+}
+
+func (s *SourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range s.descs {
+		ch <- desc
+	}
+}
+
+func (s *SourceCollector) Collect(ch chan<- prometheus.Metric) {
+	for i, source := range s.sources {
+		value, err := source.Getter()
+		if err != nil {
+			s.logger.Error("metric-getter", err, lager.Data{"source": source.Name})
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(s.descs[i], prometheus.GaugeValue, value)
+	}
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type clock interface {
+	Now() time.Time
+}
+
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// Buckets keeps a token bucket for every key, such as a client identity. Each
+// bucket holds up to burst tokens and refills at the rate, in tokens per
+// second. Buckets that have refilled are forgotten from time to time, so that
+// clients that come and go do not grow the map.
+type Buckets struct {
+	rate  float64
+	burst float64
+	clock clock
+
+	lock      sync.Mutex
+	buckets   map[string]*bucket
+	pruneSize int
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+const minPruneSize = 1024
+
+func NewBuckets(rate float64, burst int, clock clock) *Buckets {
+	return &Buckets{
+		rate:      rate,
+		burst:     float64(burst),
+		clock:     clock,
+		buckets:   map[string]*bucket{},
+		pruneSize: minPruneSize,
+	}
+}
+
+// Take takes a token from the bucket of the key. When the bucket is empty it
+// takes none, and returns how long until the bucket holds a token again.
+func (b *Buckets) Take(key string) (time.Duration, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.clock.Now()
+	if len(b.buckets) >= b.pruneSize {
+		b.prune(now)
+	}
+
+	bkt, ok := b.buckets[key]
+	if !ok {
+		bkt = &bucket{tokens: b.burst, updated: now}
+		b.buckets[key] = bkt
+	}
+	bkt.tokens = b.refill(bkt, now)
+	bkt.updated = now
+
+	if bkt.tokens < 1 {
+		wait := (1 - bkt.tokens) / b.rate
+		return time.Duration(math.Ceil(wait * float64(time.Second))), false
+	}
+	bkt.tokens--
+	return 0, true
+}
+
+func (b *Buckets) refill(bkt *bucket, now time.Time) float64 {
+	elapsed := now.Sub(bkt.updated).Seconds()
+	if elapsed <= 0 {
+		return bkt.tokens
+	}
+	return math.Min(b.burst, bkt.tokens+elapsed*b.rate)
+}
+
+// prune forgets the full buckets, which are the same as new ones.
+func (b *Buckets) prune(now time.Time) {
+	for key, bkt := range b.buckets {
+		if b.refill(bkt, now) >= b.burst {
+			delete(b.buckets, key)
+		}
+	}
+	b.pruneSize = 2 * len(b.buckets)
+	if b.pruneSize < minPruneSize {
+		b.pruneSize = minPruneSize
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit_test
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/silk/controller/ratelimit"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Buckets", func() {
+	var (
+		now     time.Time
+		buckets *ratelimit.Buckets
+	)
+
+	BeforeEach(func() {
+		now = time.Unix(1700000000, 0)
+		buckets = ratelimit.NewBuckets(2, 3, ratelimit.ClockFunc(func() time.Time { return now }))
+	})
+
+	It("allows a burst and then makes the key wait for the next token", func() {
+		for i := 0; i < 3; i++ {
+			_, ok := buckets.Take("cell-0")
+			Expect(ok).To(BeTrue())
+		}
+
+		wait, ok := buckets.Take("cell-0")
+		Expect(ok).To(BeFalse())
+		Expect(wait).To(Equal(500 * time.Millisecond))
+
+		now = now.Add(250 * time.Millisecond)
+		wait, ok = buckets.Take("cell-0")
+		Expect(ok).To(BeFalse())
+		Expect(wait).To(Equal(250 * time.Millisecond))
+
+		now = now.Add(250 * time.Millisecond)
+		_, ok = buckets.Take("cell-0")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("keeps a bucket for each key", func() {
+		for i := 0; i < 3; i++ {
+			buckets.Take("cell-0")
+		}
+		_, ok := buckets.Take("cell-0")
+		Expect(ok).To(BeFalse())
+
+		_, ok = buckets.Take("cell-1")
+		Expect(ok).To(BeTrue())
+	})
+
+	It("refills no more than the burst", func() {
+		now = now.Add(time.Hour)
+		for i := 0; i < 3; i++ {
+			_, ok := buckets.Take("cell-0")
+			Expect(ok).To(BeTrue())
+		}
+		_, ok := buckets.Take("cell-0")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("keeps limiting keys while it forgets the full buckets of many keys", func() {
+		for i := 0; i < 3; i++ {
+			buckets.Take("cell-0")
+		}
+		for i := 0; i < 2048; i++ {
+			buckets.Take(fmt.Sprintf("other-%d", i))
+		}
+		now = now.Add(time.Hour)
+		for i := 0; i < 3; i++ {
+			buckets.Take("cell-0")
+		}
+		for i := 0; i < 2048; i++ {
+			buckets.Take(fmt.Sprintf("another-%d", i))
+		}
+
+		_, ok := buckets.Take("cell-0")
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,27 @@
+package ratelimit
+
+// InFlight caps the number of requests served at once. Requests over the cap
+// are turned away rather than queued, since a queue would only hold them
+// until the clients time out.
+type InFlight struct {
+	slots chan struct{}
+}
+
+func NewInFlight(max int) *InFlight {
+	return &InFlight{slots: make(chan struct{}, max)}
+}
+
+// TryAcquire takes a slot if one is free. A slot that was taken must be
+// given back with Release.
+func (f *InFlight) TryAcquire() bool {
+	select {
+	case f.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *InFlight) Release() {
+	<-f.slots
+}
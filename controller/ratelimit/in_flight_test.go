@@ -0,0 +1,21 @@
+package ratelimit_test
+
+import (
+	"code.cloudfoundry.org/silk/controller/ratelimit"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InFlight", func() {
+	It("turns requests away while the cap is reached", func() {
+		inFlight := ratelimit.NewInFlight(2)
+		Expect(inFlight.TryAcquire()).To(BeTrue())
+		Expect(inFlight.TryAcquire()).To(BeTrue())
+		Expect(inFlight.TryAcquire()).To(BeFalse())
+
+		inFlight.Release()
+		Expect(inFlight.TryAcquire()).To(BeTrue())
+		Expect(inFlight.TryAcquire()).To(BeFalse())
+	})
+})
@@ -0,0 +1,163 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type CIDRPool struct {
+	BlockPoolSizeStub        func() int
+	blockPoolSizeMutex       sync.RWMutex
+	blockPoolSizeArgsForCall []struct {
+	}
+	blockPoolSizeReturns struct {
+		result1 int
+	}
+	blockPoolSizeReturnsOnCall map[int]struct {
+		result1 int
+	}
+	SingleIPPoolSizeStub        func() int
+	singleIPPoolSizeMutex       sync.RWMutex
+	singleIPPoolSizeArgsForCall []struct {
+	}
+	singleIPPoolSizeReturns struct {
+		result1 int
+	}
+	singleIPPoolSizeReturnsOnCall map[int]struct {
+		result1 int
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *CIDRPool) BlockPoolSize() int {
+	fake.blockPoolSizeMutex.Lock()
+	ret, specificReturn := fake.blockPoolSizeReturnsOnCall[len(fake.blockPoolSizeArgsForCall)]
+	fake.blockPoolSizeArgsForCall = append(fake.blockPoolSizeArgsForCall, struct {
+	}{})
+	stub := fake.BlockPoolSizeStub
+	fakeReturns := fake.blockPoolSizeReturns
+	fake.recordInvocation("BlockPoolSize", []interface{}{})
+	fake.blockPoolSizeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) BlockPoolSizeCallCount() int {
+	fake.blockPoolSizeMutex.RLock()
+	defer fake.blockPoolSizeMutex.RUnlock()
+	return len(fake.blockPoolSizeArgsForCall)
+}
+
+func (fake *CIDRPool) BlockPoolSizeCalls(stub func() int) {
+	fake.blockPoolSizeMutex.Lock()
+	defer fake.blockPoolSizeMutex.Unlock()
+	fake.BlockPoolSizeStub = stub
+}
+
+func (fake *CIDRPool) BlockPoolSizeReturns(result1 int) {
+	fake.blockPoolSizeMutex.Lock()
+	defer fake.blockPoolSizeMutex.Unlock()
+	fake.BlockPoolSizeStub = nil
+	fake.blockPoolSizeReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CIDRPool) BlockPoolSizeReturnsOnCall(i int, result1 int) {
+	fake.blockPoolSizeMutex.Lock()
+	defer fake.blockPoolSizeMutex.Unlock()
+	fake.BlockPoolSizeStub = nil
+	if fake.blockPoolSizeReturnsOnCall == nil {
+		fake.blockPoolSizeReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.blockPoolSizeReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CIDRPool) SingleIPPoolSize() int {
+	fake.singleIPPoolSizeMutex.Lock()
+	ret, specificReturn := fake.singleIPPoolSizeReturnsOnCall[len(fake.singleIPPoolSizeArgsForCall)]
+	fake.singleIPPoolSizeArgsForCall = append(fake.singleIPPoolSizeArgsForCall, struct {
+	}{})
+	stub := fake.SingleIPPoolSizeStub
+	fakeReturns := fake.singleIPPoolSizeReturns
+	fake.recordInvocation("SingleIPPoolSize", []interface{}{})
+	fake.singleIPPoolSizeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) SingleIPPoolSizeCallCount() int {
+	fake.singleIPPoolSizeMutex.RLock()
+	defer fake.singleIPPoolSizeMutex.RUnlock()
+	return len(fake.singleIPPoolSizeArgsForCall)
+}
+
+func (fake *CIDRPool) SingleIPPoolSizeCalls(stub func() int) {
+	fake.singleIPPoolSizeMutex.Lock()
+	defer fake.singleIPPoolSizeMutex.Unlock()
+	fake.SingleIPPoolSizeStub = stub
+}
+
+func (fake *CIDRPool) SingleIPPoolSizeReturns(result1 int) {
+	fake.singleIPPoolSizeMutex.Lock()
+	defer fake.singleIPPoolSizeMutex.Unlock()
+	fake.SingleIPPoolSizeStub = nil
+	fake.singleIPPoolSizeReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CIDRPool) SingleIPPoolSizeReturnsOnCall(i int, result1 int) {
+	fake.singleIPPoolSizeMutex.Lock()
+	defer fake.singleIPPoolSizeMutex.Unlock()
+	fake.SingleIPPoolSizeStub = nil
+	if fake.singleIPPoolSizeReturnsOnCall == nil {
+		fake.singleIPPoolSizeReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.singleIPPoolSizeReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *CIDRPool) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.blockPoolSizeMutex.RLock()
+	defer fake.blockPoolSizeMutex.RUnlock()
+	fake.singleIPPoolSizeMutex.RLock()
+	defer fake.singleIPPoolSizeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *CIDRPool) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,225 @@
+package reaper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+)
+
+const (
+	PolicyNever            = "never"
+	PolicyAfterExpiry      = "after-expiry"
+	PolicyAboveUtilization = "above-utilization"
+)
+
+//go:generate counterfeiter -o fakes/database_handler.go --fake-name DatabaseHandler . databaseHandler
+type databaseHandler interface {
+	database.LeaseStore
+	AllExpired(int) ([]controller.Lease, error)
+	WithAllocationLock(func(database.LeaseStore) error) error
+}
+
+//go:generate counterfeiter -o fakes/cidr_pool.go --fake-name CIDRPool . cidrPool
+type cidrPool interface {
+	BlockPoolSize() int
+	SingleIPPoolSize() int
+}
+
+//go:generate counterfeiter -o fakes/metric_sender.go --fake-name MetricSender . metricSender
+type metricSender interface {
+	IncrementCounter(name string)
+}
+
+// Policy decides which expired leases the reaper deletes. Leases are reaped
+// once they have gone unrenewed for ExpiryMultiple times the lease expiration
+// of their pool. With the above-utilization policy a pool is only reaped while
+// more than UtilizationThresholdPercent of its subnets are leased.
+type Policy struct {
+	Name                        string
+	ExpiryMultiple              int
+	UtilizationThresholdPercent int
+}
+
+// NewPolicy returns the named policy. An empty name selects the never policy.
+func NewPolicy(name string, expiryMultiple, utilizationThresholdPercent int) (Policy, error) {
+	switch name {
+	case "", PolicyNever:
+		return Policy{Name: PolicyNever}, nil
+	case PolicyAfterExpiry, PolicyAboveUtilization:
+	default:
+		return Policy{}, fmt.Errorf("unknown reaper policy: %s", name)
+	}
+
+	if expiryMultiple < 1 {
+		return Policy{}, errors.New("reaper expiry multiple must be at least 1")
+	}
+	if name == PolicyAboveUtilization && (utilizationThresholdPercent < 0 || utilizationThresholdPercent > 100) {
+		return Policy{}, errors.New("reaper utilization threshold must be between 0 and 100 percent")
+	}
+	return Policy{
+		Name:                        name,
+		ExpiryMultiple:              expiryMultiple,
+		UtilizationThresholdPercent: utilizationThresholdPercent,
+	}, nil
+}
+
+type Pool struct {
+	Name                   string
+	DatabaseHandler        databaseHandler
+	CIDRPool               cidrPool
+	LeaseExpirationSeconds int
+	QuarantineSeconds      int
+}
+
+// Reaper periodically deletes leases that cells stopped renewing, instead of
+// waiting for an acquisition to find the pool full.
+type Reaper struct {
+	Logger       lager.Logger
+	Pools        []Pool
+	Policy       Policy
+	Interval     time.Duration
+	MetricSender metricSender
+
+	// StartupDelay holds off the first cycle, so that after an outage of the
+	// controller cells get a chance to renew before their leases look stale.
+	StartupDelay time.Duration
+}
+
+func (r *Reaper) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	wait := r.StartupDelay
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-time.After(wait):
+			r.ReapCycle()
+			wait = r.Interval
+		}
+	}
+}
+
+// ReapCycle reaps every pool once. Failures are logged and counted, and the
+// next cycle tries again.
+func (r *Reaper) ReapCycle() {
+	if r.Policy.Name == PolicyNever {
+		return
+	}
+	for _, pool := range r.Pools {
+		err := r.reapPool(pool)
+		if err != nil {
+			r.MetricSender.IncrementCounter("reapFailure")
+			r.Logger.Error("reap-pool", err, lager.Data{"pool": pool.Name})
+		}
+	}
+}
+
+func (r *Reaper) reapPool(pool Pool) error {
+	if r.Policy.Name == PolicyAboveUtilization {
+		utilization, err := utilizationPercent(pool)
+		if err != nil {
+			return err
+		}
+		if utilization <= float64(r.Policy.UtilizationThresholdPercent) {
+			r.Logger.Debug("pool-below-threshold", lager.Data{"pool": pool.Name, "utilization_percent": utilization})
+			return nil
+		}
+	}
+
+	reapAge := r.Policy.ExpiryMultiple * pool.LeaseExpirationSeconds
+	leases, err := pool.DatabaseHandler.AllExpired(reapAge)
+	if err != nil {
+		return fmt.Errorf("getting expired leases: %s", err)
+	}
+
+	for _, lease := range leases {
+		// under the allocation lock of the pool, so that an acquisition
+		// cannot hand out the subnet between its deletion and its quarantine
+		err := pool.DatabaseHandler.WithAllocationLock(func(store database.LeaseStore) error {
+			return reapLease(store, lease, reapAge, pool.QuarantineSeconds)
+		})
+		if err == database.RecordNotAffectedError {
+			// renewed or released since it was found expired
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		r.MetricSender.IncrementCounter("leaseReaped")
+		r.Logger.Info("lease-reaped", lager.Data{
+			"lease":              lease,
+			"pool":               pool.Name,
+			"policy":             r.Policy.Name,
+			"reap_after_seconds": reapAge,
+		})
+	}
+	return nil
+}
+
+// reapLease deletes the lease if it is still expired, quarantines its subnets
+// and records the reclaim, all in the transaction of store.
+func reapLease(store database.LeaseStore, lease controller.Lease, reapAge, quarantineSeconds int) error {
+	err := store.DeleteExpiredEntry(lease.UnderlayIP, reapAge)
+	if err == database.RecordNotAffectedError {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("deleting expired lease for underlay ip %s: %s", lease.UnderlayIP, err)
+	}
+	if quarantineSeconds > 0 {
+		for _, subnet := range []string{lease.OverlaySubnet, lease.OverlaySubnetV6} {
+			if subnet == "" {
+				continue
+			}
+			err = store.QuarantineSubnet(subnet, lease.Pool, quarantineSeconds)
+			if err != nil {
+				return fmt.Errorf("quarantining subnet of underlay ip %s: %s", lease.UnderlayIP, err)
+			}
+		}
+	}
+	err = store.AddEvent(controller.LeaseEvent{
+		Type:            controller.LeaseEventReclaimed,
+		UnderlayIP:      lease.UnderlayIP,
+		OverlaySubnet:   lease.OverlaySubnet,
+		OverlaySubnetV6: lease.OverlaySubnetV6,
+		Pool:            lease.Pool,
+		Actor:           "lease-reaper",
+		Reason:          fmt.Sprintf("not renewed for %d seconds", reapAge),
+	})
+	if err != nil {
+		return fmt.Errorf("recording reclaim of underlay ip %s: %s", lease.UnderlayIP, err)
+	}
+	return nil
+}
+
+// utilizationPercent returns the share of the pool's block or single ip
+// subnets that are leased, whichever is higher.
+func utilizationPercent(pool Pool) (float64, error) {
+	blocks, err := pool.DatabaseHandler.AllBlockSubnets()
+	if err != nil {
+		return 0, fmt.Errorf("getting all subnets: %s", err)
+	}
+	singleIPs, err := pool.DatabaseHandler.AllSingleIPSubnets()
+	if err != nil {
+		return 0, fmt.Errorf("getting all single ip subnets: %s", err)
+	}
+
+	utilization := percentOf(len(blocks), pool.CIDRPool.BlockPoolSize())
+	if singleIPUtilization := percentOf(len(singleIPs), pool.CIDRPool.SingleIPPoolSize()); singleIPUtilization > utilization {
+		utilization = singleIPUtilization
+	}
+	return utilization, nil
+}
+
+func percentOf(n, size int) float64 {
+	if size == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(size)
+}
@@ -0,0 +1,13 @@
+package reaper_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestReaper(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Reaper Suite")
+}
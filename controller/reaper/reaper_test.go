@@ -0,0 +1,370 @@
+package reaper_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+	"code.cloudfoundry.org/silk/controller/reaper"
+	"code.cloudfoundry.org/silk/controller/reaper/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Reaper", func() {
+	var (
+		logger          *lagertest.TestLogger
+		databaseHandler *fakes.DatabaseHandler
+		cidrPool        *fakes.CIDRPool
+		metricSender    *fakes.MetricSender
+		leaseReaper     *reaper.Reaper
+		expiredLease    controller.Lease
+		expiredLease2   controller.Lease
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		databaseHandler = &fakes.DatabaseHandler{}
+		cidrPool = &fakes.CIDRPool{}
+		metricSender = &fakes.MetricSender{}
+
+		expiredLease = controller.Lease{UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.33.0/24", Pool: "blue"}
+		expiredLease2 = controller.Lease{UnderlayIP: "10.244.22.33", OverlaySubnet: "10.255.0.12/32", Pool: "blue"}
+		databaseHandler.AllExpiredReturns([]controller.Lease{expiredLease, expiredLease2}, nil)
+		databaseHandler.WithAllocationLockStub = func(f func(database.LeaseStore) error) error {
+			return f(databaseHandler)
+		}
+
+		leaseReaper = &reaper.Reaper{
+			Logger: logger,
+			Pools: []reaper.Pool{{
+				Name:                   "blue",
+				DatabaseHandler:        databaseHandler,
+				CIDRPool:               cidrPool,
+				LeaseExpirationSeconds: 60,
+			}},
+			Policy:       reaper.Policy{Name: reaper.PolicyAfterExpiry, ExpiryMultiple: 3},
+			Interval:     time.Second,
+			MetricSender: metricSender,
+		}
+	})
+
+	Describe("NewPolicy", func() {
+		It("defaults to never reaping", func() {
+			policy, err := reaper.NewPolicy("", 0, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(reaper.Policy{Name: reaper.PolicyNever}))
+		})
+
+		It("returns the configured policy", func() {
+			policy, err := reaper.NewPolicy("above-utilization", 2, 80)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(reaper.Policy{Name: reaper.PolicyAboveUtilization, ExpiryMultiple: 2, UtilizationThresholdPercent: 80}))
+		})
+
+		DescribeTable("rejects invalid settings",
+			func(name string, expiryMultiple, threshold int, expectedErr string) {
+				_, err := reaper.NewPolicy(name, expiryMultiple, threshold)
+				Expect(err).To(MatchError(expectedErr))
+			},
+			Entry("unknown policy", "banana", 1, 0, "unknown reaper policy: banana"),
+			Entry("missing expiry multiple", "after-expiry", 0, 0, "reaper expiry multiple must be at least 1"),
+			Entry("threshold out of range", "above-utilization", 1, 101, "reaper utilization threshold must be between 0 and 100 percent"),
+		)
+	})
+
+	Describe("ReapCycle", func() {
+		It("deletes the leases unrenewed for the expiry multiple and logs and counts each one", func() {
+			leaseReaper.ReapCycle()
+
+			Expect(databaseHandler.AllExpiredArgsForCall(0)).To(Equal(180))
+			Expect(databaseHandler.DeleteExpiredEntryCallCount()).To(Equal(2))
+			underlayIP, expiration := databaseHandler.DeleteExpiredEntryArgsForCall(0)
+			Expect(underlayIP).To(Equal("10.244.11.22"))
+			Expect(expiration).To(Equal(180))
+			underlayIP, _ = databaseHandler.DeleteExpiredEntryArgsForCall(1)
+			Expect(underlayIP).To(Equal("10.244.22.33"))
+
+			Expect(metricSender.IncrementCounterCallCount()).To(Equal(2))
+			Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("leaseReaped"))
+
+			Expect(logger.Logs()).To(HaveLen(2))
+			Expect(logger.Logs()[0].Message).To(Equal("test.lease-reaped"))
+			Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("pool", "blue"))
+			Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("policy", "after-expiry"))
+			Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("lease", HaveKeyWithValue("underlay_ip", "10.244.11.22")))
+		})
+
+		It("records a reclaimed event for each reaped lease", func() {
+			leaseReaper.ReapCycle()
+
+			Expect(databaseHandler.AddEventCallCount()).To(Equal(2))
+			Expect(databaseHandler.AddEventArgsForCall(0)).To(Equal(controller.LeaseEvent{
+				Type:          controller.LeaseEventReclaimed,
+				UnderlayIP:    "10.244.11.22",
+				OverlaySubnet: "10.255.33.0/24",
+				Pool:          "blue",
+				Actor:         "lease-reaper",
+				Reason:        "not renewed for 180 seconds",
+			}))
+			Expect(databaseHandler.AddEventArgsForCall(1).UnderlayIP).To(Equal("10.244.22.33"))
+		})
+
+		It("deletes, quarantines and records each lease under the allocation lock of the pool", func() {
+			leaseReaper.Pools[0].QuarantineSeconds = 300
+			databaseHandler.WithAllocationLockStub = func(f func(database.LeaseStore) error) error {
+				deletes := databaseHandler.DeleteExpiredEntryCallCount()
+				err := f(databaseHandler)
+				Expect(databaseHandler.DeleteExpiredEntryCallCount()).To(Equal(deletes + 1))
+				Expect(databaseHandler.QuarantineSubnetCallCount()).To(Equal(deletes + 1))
+				Expect(databaseHandler.AddEventCallCount()).To(Equal(deletes + 1))
+				return err
+			}
+
+			leaseReaper.ReapCycle()
+
+			Expect(databaseHandler.WithAllocationLockCallCount()).To(Equal(2))
+			Expect(databaseHandler.DeleteExpiredEntryCallCount()).To(Equal(2))
+		})
+
+		Context("when the allocation lock cannot be taken", func() {
+			BeforeEach(func() {
+				databaseHandler.WithAllocationLockStub = nil
+				databaseHandler.WithAllocationLockReturns(errors.New("taking allocation lock: kiwi"))
+			})
+
+			It("logs and counts the failure without touching the lease", func() {
+				leaseReaper.ReapCycle()
+
+				Expect(databaseHandler.DeleteExpiredEntryCallCount()).To(Equal(0))
+				Expect(metricSender.IncrementCounterCallCount()).To(Equal(1))
+				Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("reapFailure"))
+				Expect(logger).To(gbytes.Say("reap-pool.*taking allocation lock: kiwi"))
+			})
+		})
+
+		Context("when recording the event fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AddEventReturns(errors.New("kiwi"))
+			})
+
+			It("fails the reap, so that the deletion is rolled back with it", func() {
+				leaseReaper.ReapCycle()
+
+				Expect(metricSender.IncrementCounterCallCount()).To(Equal(1))
+				Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("reapFailure"))
+				Expect(logger).To(gbytes.Say("reap-pool.*recording reclaim of underlay ip 10.244.11.22: kiwi"))
+			})
+		})
+
+		It("does not quarantine the subnets when no quarantine is configured", func() {
+			leaseReaper.ReapCycle()
+			Expect(databaseHandler.QuarantineSubnetCallCount()).To(Equal(0))
+		})
+
+		Context("when reaped subnets are quarantined", func() {
+			BeforeEach(func() {
+				leaseReaper.Pools[0].QuarantineSeconds = 300
+			})
+
+			It("quarantines the subnet of each reaped lease", func() {
+				leaseReaper.ReapCycle()
+
+				Expect(databaseHandler.QuarantineSubnetCallCount()).To(Equal(2))
+				subnet, pool, seconds := databaseHandler.QuarantineSubnetArgsForCall(0)
+				Expect(subnet).To(Equal("10.255.33.0/24"))
+				Expect(pool).To(Equal("blue"))
+				Expect(seconds).To(Equal(300))
+				subnet, _, _ = databaseHandler.QuarantineSubnetArgsForCall(1)
+				Expect(subnet).To(Equal("10.255.0.12/32"))
+			})
+
+			Context("when quarantining fails", func() {
+				BeforeEach(func() {
+					databaseHandler.QuarantineSubnetReturns(errors.New("guava"))
+				})
+
+				It("logs and counts the failure", func() {
+					leaseReaper.ReapCycle()
+
+					Expect(metricSender.IncrementCounterCallCount()).To(Equal(1))
+					Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("reapFailure"))
+					Expect(logger).To(gbytes.Say("reap-pool.*quarantining subnet of underlay ip 10.244.11.22: guava"))
+				})
+			})
+		})
+
+		Context("when a lease was renewed after it was found expired", func() {
+			BeforeEach(func() {
+				databaseHandler.DeleteExpiredEntryReturnsOnCall(0, database.RecordNotAffectedError)
+			})
+
+			It("keeps it and reaps the others", func() {
+				leaseReaper.ReapCycle()
+
+				Expect(databaseHandler.DeleteExpiredEntryCallCount()).To(Equal(2))
+				Expect(metricSender.IncrementCounterCallCount()).To(Equal(1))
+				Expect(logger.Logs()).To(HaveLen(1))
+				Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("lease", HaveKeyWithValue("underlay_ip", "10.244.22.33")))
+			})
+		})
+
+		Context("when the policy is never", func() {
+			BeforeEach(func() {
+				leaseReaper.Policy = reaper.Policy{Name: reaper.PolicyNever}
+			})
+
+			It("does not touch the database", func() {
+				leaseReaper.ReapCycle()
+
+				Expect(databaseHandler.Invocations()).To(BeEmpty())
+				Expect(metricSender.IncrementCounterCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the policy depends on the utilization of the pool", func() {
+			BeforeEach(func() {
+				leaseReaper.Policy = reaper.Policy{Name: reaper.PolicyAboveUtilization, ExpiryMultiple: 1, UtilizationThresholdPercent: 50}
+				cidrPool.BlockPoolSizeReturns(4)
+				cidrPool.SingleIPPoolSizeReturns(10)
+				databaseHandler.AllSingleIPSubnetsReturns([]controller.Lease{expiredLease2}, nil)
+			})
+
+			It("reaps the pool once more of its subnets are leased than the threshold", func() {
+				databaseHandler.AllBlockSubnetsReturns(make([]controller.Lease, 3), nil)
+
+				leaseReaper.ReapCycle()
+
+				Expect(databaseHandler.AllExpiredArgsForCall(0)).To(Equal(60))
+				Expect(databaseHandler.DeleteExpiredEntryCallCount()).To(Equal(2))
+			})
+
+			It("leaves the pool alone at or below the threshold", func() {
+				databaseHandler.AllBlockSubnetsReturns(make([]controller.Lease, 2), nil)
+
+				leaseReaper.ReapCycle()
+
+				Expect(databaseHandler.AllExpiredCallCount()).To(Equal(0))
+				Expect(databaseHandler.DeleteExpiredEntryCallCount()).To(Equal(0))
+			})
+
+			It("counts single ip leases against the single ip pool", func() {
+				databaseHandler.AllSingleIPSubnetsReturns(make([]controller.Lease, 6), nil)
+
+				leaseReaper.ReapCycle()
+
+				Expect(databaseHandler.DeleteExpiredEntryCallCount()).To(Equal(2))
+			})
+
+			Context("when getting the leases of the pool fails", func() {
+				BeforeEach(func() {
+					databaseHandler.AllBlockSubnetsReturns(nil, errors.New("guava"))
+				})
+
+				It("logs and counts the failure", func() {
+					leaseReaper.ReapCycle()
+
+					Expect(databaseHandler.AllExpiredCallCount()).To(Equal(0))
+					Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("reapFailure"))
+					Expect(logger).To(gbytes.Say("reap-pool.*getting all subnets: guava"))
+				})
+			})
+		})
+
+		Context("when several pools are configured", func() {
+			var greenDatabaseHandler *fakes.DatabaseHandler
+
+			BeforeEach(func() {
+				greenDatabaseHandler = &fakes.DatabaseHandler{}
+				leaseReaper.Pools = append(leaseReaper.Pools, reaper.Pool{
+					Name:                   "green",
+					DatabaseHandler:        greenDatabaseHandler,
+					CIDRPool:               cidrPool,
+					LeaseExpirationSeconds: 10,
+				})
+			})
+
+			It("reaps each pool by its own lease expiration", func() {
+				leaseReaper.ReapCycle()
+
+				Expect(databaseHandler.AllExpiredArgsForCall(0)).To(Equal(180))
+				Expect(greenDatabaseHandler.AllExpiredArgsForCall(0)).To(Equal(30))
+			})
+
+			Context("when reaping one pool fails", func() {
+				BeforeEach(func() {
+					databaseHandler.AllExpiredReturns(nil, errors.New("guava"))
+				})
+
+				It("still reaps the other pools", func() {
+					leaseReaper.ReapCycle()
+
+					Expect(greenDatabaseHandler.AllExpiredCallCount()).To(Equal(1))
+					Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("reapFailure"))
+					Expect(logger).To(gbytes.Say("reap-pool.*getting expired leases: guava.*blue"))
+				})
+			})
+		})
+
+		Context("when deleting a lease fails", func() {
+			BeforeEach(func() {
+				databaseHandler.DeleteExpiredEntryReturns(errors.New("guava"))
+			})
+
+			It("logs and counts the failure", func() {
+				leaseReaper.ReapCycle()
+
+				Expect(databaseHandler.DeleteExpiredEntryCallCount()).To(Equal(1))
+				Expect(metricSender.IncrementCounterCallCount()).To(Equal(1))
+				Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("reapFailure"))
+				Expect(logger).To(gbytes.Say("reap-pool.*deleting expired lease for underlay ip 10.244.11.22: guava"))
+			})
+		})
+	})
+
+	Describe("Run", func() {
+		var (
+			signals chan os.Signal
+			ready   chan struct{}
+			retChan chan error
+		)
+
+		BeforeEach(func() {
+			signals = make(chan os.Signal)
+			ready = make(chan struct{})
+			retChan = make(chan error)
+			leaseReaper.Interval = 100 * time.Millisecond
+		})
+
+		It("reaps periodically until signalled", func() {
+			go func() {
+				retChan <- leaseReaper.Run(signals, ready)
+			}()
+
+			Eventually(ready).Should(BeClosed())
+			Eventually(databaseHandler.AllExpiredCallCount).Should(BeNumerically(">", 1))
+			Consistently(retChan).ShouldNot(Receive())
+
+			signals <- os.Interrupt
+			Eventually(retChan).Should(Receive(nil))
+		})
+
+		It("waits for the startup delay before the first cycle", func() {
+			leaseReaper.StartupDelay = time.Hour
+
+			go func() {
+				retChan <- leaseReaper.Run(signals, ready)
+			}()
+
+			Eventually(ready).Should(BeClosed())
+			Consistently(databaseHandler.AllExpiredCallCount).Should(Equal(0))
+
+			signals <- os.Interrupt
+			Eventually(retChan).Should(Receive(nil))
+		})
+	})
+})
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/json_client"
+)
+
+// defaultRetryAfter is how long to wait after a 429 without a Retry-After
+// that can be read.
+const defaultRetryAfter = time.Second
+
+// RateLimitedError is returned for a request the controller turned away for
+// being over a rate limit. It asked not to call the route again before
+// RetryAfter has passed.
+type RateLimitedError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (r *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s: %s", r.RetryAfter, r.Message)
+}
+
+// RetryAfter remembers, for each method and path, until when the controller
+// asked with the Retry-After of a 429 not to be called again. Until then it
+// answers requests to the route with a 429 itself, without calling the
+// controller.
+type RetryAfter struct {
+	HttpClient json_client.HttpClient
+
+	lock  sync.Mutex
+	until map[string]time.Time
+}
+
+func (r *RetryAfter) Do(req *http.Request) (*http.Response, error) {
+	if wait := r.Wait(req.Method, req.URL.Path); wait > 0 {
+		return tooManyRequests(req, wait), nil
+	}
+
+	resp, err := r.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		until := time.Now().Add(parseRetryAfter(resp.Header.Get("Retry-After")))
+		r.lock.Lock()
+		if r.until == nil {
+			r.until = map[string]time.Time{}
+		}
+		r.until[retryAfterKey(req.Method, req.URL.Path)] = until
+		r.lock.Unlock()
+	}
+	return resp, nil
+}
+
+func (r *RetryAfter) CloseIdleConnections() {
+	r.HttpClient.CloseIdleConnections()
+}
+
+// Wait returns how much longer the controller asked not to be called on the
+// route.
+func (r *RetryAfter) Wait(method, path string) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := retryAfterKey(method, path)
+	until, ok := r.until[key]
+	if !ok {
+		return 0
+	}
+	wait := time.Until(until)
+	if wait <= 0 {
+		delete(r.until, key)
+		return 0
+	}
+	return wait
+}
+
+func retryAfterKey(method, path string) string {
+	return method + " " + path
+}
+
+// parseRetryAfter reads a Retry-After of either seconds or an http date.
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return defaultRetryAfter
+}
+
+func tooManyRequests(req *http.Request, wait time.Duration) *http.Response {
+	body := []byte(fmt.Sprintf(`{"error": "waiting %s before calling %s %s again"}`, wait.Round(time.Millisecond), req.Method, req.URL.Path))
+	header := http.Header{}
+	header.Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	return &http.Response{
+		StatusCode:    http.StatusTooManyRequests,
+		Status:        http.StatusText(http.StatusTooManyRequests),
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
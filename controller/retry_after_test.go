@@ -0,0 +1,168 @@
+package controller_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RetryAfter", func() {
+	var (
+		httpClient *fakes.HTTPClient
+		retryAfter *controller.RetryAfter
+	)
+
+	response := func(code int, retryAfter string) *http.Response {
+		resp := &http.Response{
+			StatusCode: code,
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+		}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+
+	do := func(method, url string) *http.Response {
+		req, err := http.NewRequest(method, url, nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := retryAfter.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	BeforeEach(func() {
+		httpClient = &fakes.HTTPClient{}
+		retryAfter = &controller.RetryAfter{HttpClient: httpClient}
+		httpClient.DoReturns(response(http.StatusOK, ""), nil)
+	})
+
+	It("passes requests on while the controller has not asked to wait", func() {
+		Expect(do("PUT", "https://controller/leases/renew").StatusCode).To(Equal(http.StatusOK))
+		Expect(do("PUT", "https://controller/leases/renew").StatusCode).To(Equal(http.StatusOK))
+		Expect(httpClient.DoCallCount()).To(Equal(2))
+		Expect(retryAfter.Wait("PUT", "/leases/renew")).To(BeZero())
+	})
+
+	Context("when the controller answers a 429", func() {
+		BeforeEach(func() {
+			httpClient.DoReturnsOnCall(0, response(http.StatusTooManyRequests, "30"), nil)
+		})
+
+		It("answers later requests to the route with a 429 itself until the Retry-After has passed", func() {
+			Expect(do("PUT", "https://controller/leases/acquire").StatusCode).To(Equal(http.StatusTooManyRequests))
+			Expect(retryAfter.Wait("PUT", "/leases/acquire")).To(BeNumerically("~", 30*time.Second, time.Second))
+
+			resp := do("PUT", "https://controller/leases/acquire")
+			Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+			Expect(resp.Header.Get("Retry-After")).To(Or(Equal("30"), Equal("29")))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(ContainSubstring("before calling PUT /leases/acquire again"))
+			Expect(httpClient.DoCallCount()).To(Equal(1))
+		})
+
+		It("still calls the other routes", func() {
+			do("PUT", "https://controller/leases/acquire")
+
+			Expect(do("PUT", "https://controller/leases/renew").StatusCode).To(Equal(http.StatusOK))
+			Expect(do("GET", "https://controller/leases/acquire").StatusCode).To(Equal(http.StatusOK))
+			Expect(httpClient.DoCallCount()).To(Equal(3))
+		})
+	})
+
+	Context("when the Retry-After has already passed", func() {
+		BeforeEach(func() {
+			httpClient.DoReturnsOnCall(0, response(http.StatusTooManyRequests, "0"), nil)
+		})
+
+		It("calls the controller again", func() {
+			do("PUT", "https://controller/leases/acquire")
+			Expect(retryAfter.Wait("PUT", "/leases/acquire")).To(BeZero())
+
+			Expect(do("PUT", "https://controller/leases/acquire").StatusCode).To(Equal(http.StatusOK))
+			Expect(httpClient.DoCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when the Retry-After is a date", func() {
+		BeforeEach(func() {
+			date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+			httpClient.DoReturnsOnCall(0, response(http.StatusTooManyRequests, date), nil)
+		})
+
+		It("waits until then", func() {
+			do("PUT", "https://controller/leases/acquire")
+			Expect(retryAfter.Wait("PUT", "/leases/acquire")).To(BeNumerically("~", time.Minute, 2*time.Second))
+		})
+	})
+
+	Context("when there is no Retry-After", func() {
+		BeforeEach(func() {
+			httpClient.DoReturnsOnCall(0, response(http.StatusTooManyRequests, ""), nil)
+		})
+
+		It("waits a second", func() {
+			do("PUT", "https://controller/leases/acquire")
+			Expect(retryAfter.Wait("PUT", "/leases/acquire")).To(BeNumerically("~", time.Second, 100*time.Millisecond))
+		})
+	})
+
+	Context("when the request fails", func() {
+		BeforeEach(func() {
+			httpClient.DoReturns(nil, errors.New("potato"))
+		})
+
+		It("returns the error", func() {
+			req, err := http.NewRequest("PUT", "https://controller/leases/acquire", nil)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = retryAfter.Do(req)
+			Expect(err).To(MatchError("potato"))
+		})
+	})
+
+	Describe("through a client", func() {
+		var (
+			server   *httptest.Server
+			requests int
+		)
+
+		BeforeEach(func() {
+			requests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requests++
+				w.Header().Set("Retry-After", "5")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error": "cell-0 is over the rate limit"}`))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("returns a rate limited error with the time to wait", func() {
+			client := controller.NewClient(lagertest.NewTestLogger("test"), server.Client(), server.URL)
+
+			_, err := client.AcquireSubnetLease("10.0.3.1")
+			Expect(err).To(BeAssignableToTypeOf(&controller.RateLimitedError{}))
+			rateLimited := err.(*controller.RateLimitedError)
+			Expect(rateLimited.Message).To(Equal("cell-0 is over the rate limit"))
+			Expect(rateLimited.RetryAfter).To(BeNumerically("~", 5*time.Second, time.Second))
+
+			_, err = client.AcquireSubnetLease("10.0.3.1")
+			Expect(err).To(BeAssignableToTypeOf(&controller.RateLimitedError{}))
+			Expect(requests).To(Equal(1))
+		})
+	})
+})
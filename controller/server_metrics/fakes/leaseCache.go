@@ -0,0 +1,163 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type LeaseCache struct {
+	HitRatioStub        func() float64
+	hitRatioMutex       sync.RWMutex
+	hitRatioArgsForCall []struct {
+	}
+	hitRatioReturns struct {
+		result1 float64
+	}
+	hitRatioReturnsOnCall map[int]struct {
+		result1 float64
+	}
+	HitsStub        func() int64
+	hitsMutex       sync.RWMutex
+	hitsArgsForCall []struct {
+	}
+	hitsReturns struct {
+		result1 int64
+	}
+	hitsReturnsOnCall map[int]struct {
+		result1 int64
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LeaseCache) HitRatio() float64 {
+	fake.hitRatioMutex.Lock()
+	ret, specificReturn := fake.hitRatioReturnsOnCall[len(fake.hitRatioArgsForCall)]
+	fake.hitRatioArgsForCall = append(fake.hitRatioArgsForCall, struct {
+	}{})
+	stub := fake.HitRatioStub
+	fakeReturns := fake.hitRatioReturns
+	fake.recordInvocation("HitRatio", []interface{}{})
+	fake.hitRatioMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LeaseCache) HitRatioCallCount() int {
+	fake.hitRatioMutex.RLock()
+	defer fake.hitRatioMutex.RUnlock()
+	return len(fake.hitRatioArgsForCall)
+}
+
+func (fake *LeaseCache) HitRatioCalls(stub func() float64) {
+	fake.hitRatioMutex.Lock()
+	defer fake.hitRatioMutex.Unlock()
+	fake.HitRatioStub = stub
+}
+
+func (fake *LeaseCache) HitRatioReturns(result1 float64) {
+	fake.hitRatioMutex.Lock()
+	defer fake.hitRatioMutex.Unlock()
+	fake.HitRatioStub = nil
+	fake.hitRatioReturns = struct {
+		result1 float64
+	}{result1}
+}
+
+func (fake *LeaseCache) HitRatioReturnsOnCall(i int, result1 float64) {
+	fake.hitRatioMutex.Lock()
+	defer fake.hitRatioMutex.Unlock()
+	fake.HitRatioStub = nil
+	if fake.hitRatioReturnsOnCall == nil {
+		fake.hitRatioReturnsOnCall = make(map[int]struct {
+			result1 float64
+		})
+	}
+	fake.hitRatioReturnsOnCall[i] = struct {
+		result1 float64
+	}{result1}
+}
+
+func (fake *LeaseCache) Hits() int64 {
+	fake.hitsMutex.Lock()
+	ret, specificReturn := fake.hitsReturnsOnCall[len(fake.hitsArgsForCall)]
+	fake.hitsArgsForCall = append(fake.hitsArgsForCall, struct {
+	}{})
+	stub := fake.HitsStub
+	fakeReturns := fake.hitsReturns
+	fake.recordInvocation("Hits", []interface{}{})
+	fake.hitsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LeaseCache) HitsCallCount() int {
+	fake.hitsMutex.RLock()
+	defer fake.hitsMutex.RUnlock()
+	return len(fake.hitsArgsForCall)
+}
+
+func (fake *LeaseCache) HitsCalls(stub func() int64) {
+	fake.hitsMutex.Lock()
+	defer fake.hitsMutex.Unlock()
+	fake.HitsStub = stub
+}
+
+func (fake *LeaseCache) HitsReturns(result1 int64) {
+	fake.hitsMutex.Lock()
+	defer fake.hitsMutex.Unlock()
+	fake.HitsStub = nil
+	fake.hitsReturns = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *LeaseCache) HitsReturnsOnCall(i int, result1 int64) {
+	fake.hitsMutex.Lock()
+	defer fake.hitsMutex.Unlock()
+	fake.HitsStub = nil
+	if fake.hitsReturnsOnCall == nil {
+		fake.hitsReturnsOnCall = make(map[int]struct {
+			result1 int64
+		})
+	}
+	fake.hitsReturnsOnCall[i] = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *LeaseCache) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.hitRatioMutex.RLock()
+	defer fake.hitRatioMutex.RUnlock()
+	fake.hitsMutex.RLock()
+	defer fake.hitsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LeaseCache) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
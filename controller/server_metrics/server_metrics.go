@@ -11,9 +11,20 @@ type databaseHandler interface {
 	AllActive(int) ([]controller.Lease, error)
 }
 
-//go:generate counterfeiter -o fakes/cidrPool.go --fake-name CIDRPool . cidrPool
-type cidrPool interface {
-	BlockPoolSize() int
+//go:generate counterfeiter -o fakes/poolUsageRepository.go --fake-name PoolUsageRepository . poolUsageRepository
+type poolUsageRepository interface {
+	Usage() (controller.PoolUsage, error)
+}
+
+//go:generate counterfeiter -o fakes/leadership.go --fake-name Leadership . leadership
+type leadership interface {
+	IsLeader() bool
+}
+
+//go:generate counterfeiter -o fakes/leaseCache.go --fake-name LeaseCache . leaseCache
+type leaseCache interface {
+	Hits() int64
+	HitRatio() float64
 }
 
 func NewTotalLeasesSource(lister databaseHandler) metrics.MetricSource {
@@ -27,14 +38,28 @@ func NewTotalLeasesSource(lister databaseHandler) metrics.MetricSource {
 	}
 }
 
-func NewFreeLeasesSource(lister databaseHandler, pool cidrPool) metrics.MetricSource {
+// NewFreeLeasesSource counts the blocks of the pool that no lease,
+// reservation or quarantine holds.
+func NewFreeLeasesSource(pool poolUsageRepository) metrics.MetricSource {
 	return metrics.MetricSource{
 		Name: "freeLeases",
 		Unit: "",
 		Getter: func() (float64, error) {
-			allLeases, err := lister.All()
-			size := pool.BlockPoolSize()
-			return float64(size - len(allLeases)), err
+			usage, err := pool.Usage()
+			return float64(usage.Blocks.Free), err
+		},
+	}
+}
+
+// NewFreeSingleIPLeasesSource counts the single overlay ips of the pool that
+// no lease or quarantine holds.
+func NewFreeSingleIPLeasesSource(pool poolUsageRepository) metrics.MetricSource {
+	return metrics.MetricSource{
+		Name: "freeSingleIPLeases",
+		Unit: "",
+		Getter: func() (float64, error) {
+			usage, err := pool.Usage()
+			return float64(usage.SingleIPs.Free), err
 		},
 	}
 }
@@ -51,3 +76,41 @@ func NewStaleLeasesSource(lister databaseHandler, seconds int) metrics.MetricSou
 		},
 	}
 }
+
+// NewIsLeaderSource is 1 on the controller that leads and 0 on the others.
+func NewIsLeaderSource(leadership leadership) metrics.MetricSource {
+	return metrics.MetricSource{
+		Name: "isLeader",
+		Unit: "",
+		Getter: func() (float64, error) {
+			if leadership.IsLeader() {
+				return 1, nil
+			}
+			return 0, nil
+		},
+	}
+}
+
+// NewLeaseCacheHitRatioSource is the share of the lease queries answered from
+// the lease cache.
+func NewLeaseCacheHitRatioSource(cache leaseCache) metrics.MetricSource {
+	return metrics.MetricSource{
+		Name: "leaseCacheHitRatio",
+		Unit: "",
+		Getter: func() (float64, error) {
+			return cache.HitRatio(), nil
+		},
+	}
+}
+
+// NewLeaseCacheQueriesSavedSource counts the lease queries answered from the
+// lease cache instead of the database.
+func NewLeaseCacheQueriesSavedSource(cache leaseCache) metrics.MetricSource {
+	return metrics.MetricSource{
+		Name: "leaseCacheQueriesSaved",
+		Unit: "",
+		Getter: func() (float64, error) {
+			return float64(cache.Hits()), nil
+		},
+	}
+}
@@ -1,6 +1,8 @@
 package server_metrics_test
 
 import (
+	"errors"
+
 	"code.cloudfoundry.org/silk/controller"
 	"code.cloudfoundry.org/silk/controller/server_metrics"
 	"code.cloudfoundry.org/silk/controller/server_metrics/fakes"
@@ -12,7 +14,7 @@ import (
 var _ = Describe("ServerMetrics", func() {
 	var allLeases []controller.Lease
 	var fakeDatabaseHandler *fakes.DatabaseHandler
-	var fakeCIDRPool *fakes.CIDRPool
+	var fakePoolUsageRepository *fakes.PoolUsageRepository
 
 	BeforeEach(func() {
 		allLeases = []controller.Lease{
@@ -31,8 +33,11 @@ var _ = Describe("ServerMetrics", func() {
 		fakeDatabaseHandler.AllReturns(allLeases, nil)
 		fakeDatabaseHandler.AllActiveReturns([]controller.Lease{allLeases[0]}, nil)
 
-		fakeCIDRPool = &fakes.CIDRPool{}
-		fakeCIDRPool.BlockPoolSizeReturns(100)
+		fakePoolUsageRepository = &fakes.PoolUsageRepository{}
+		fakePoolUsageRepository.UsageReturns(controller.PoolUsage{
+			Blocks:    controller.SubnetUsage{Total: 100, Active: 1, Reserved: 1, Free: 98},
+			SingleIPs: controller.SubnetUsage{Total: 255, Active: 1, Free: 254},
+		}, nil)
 	})
 
 	Describe("totalLeases", func() {
@@ -51,8 +56,8 @@ var _ = Describe("ServerMetrics", func() {
 	})
 
 	Describe("freeLeases", func() {
-		It("returns the total number of free leases", func() {
-			source := server_metrics.NewFreeLeasesSource(fakeDatabaseHandler, fakeCIDRPool)
+		It("returns the number of free blocks", func() {
+			source := server_metrics.NewFreeLeasesSource(fakePoolUsageRepository)
 
 			Expect(source.Name).To(Equal("freeLeases"))
 			Expect(source.Unit).To(Equal(""))
@@ -60,10 +65,31 @@ var _ = Describe("ServerMetrics", func() {
 			value, err := source.Getter()
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(fakeDatabaseHandler.AllCallCount()).To(Equal(1))
-			Expect(fakeCIDRPool.BlockPoolSizeCallCount()).To(Equal(1))
+			Expect(fakePoolUsageRepository.UsageCallCount()).To(Equal(1))
 			Expect(value).To(Equal(98.0))
 		})
+
+		Context("when getting the usage fails", func() {
+			It("returns the error", func() {
+				fakePoolUsageRepository.UsageReturns(controller.PoolUsage{}, errors.New("potato"))
+
+				_, err := server_metrics.NewFreeLeasesSource(fakePoolUsageRepository).Getter()
+				Expect(err).To(MatchError("potato"))
+			})
+		})
+	})
+
+	Describe("freeSingleIPLeases", func() {
+		It("returns the number of free single ips", func() {
+			source := server_metrics.NewFreeSingleIPLeasesSource(fakePoolUsageRepository)
+
+			Expect(source.Name).To(Equal("freeSingleIPLeases"))
+			Expect(source.Unit).To(Equal(""))
+
+			value, err := source.Getter()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal(254.0))
+		})
 	})
 
 	Describe("staleLeases", func() {
@@ -83,4 +109,37 @@ var _ = Describe("ServerMetrics", func() {
 		})
 	})
 
+	Describe("isLeader", func() {
+		It("returns whether the controller leads", func() {
+			leadership := &fakes.Leadership{}
+			source := server_metrics.NewIsLeaderSource(leadership)
+			Expect(source.Name).To(Equal("isLeader"))
+
+			Expect(source.Getter()).To(Equal(0.0))
+			leadership.IsLeaderReturns(true)
+			Expect(source.Getter()).To(Equal(1.0))
+		})
+	})
+
+	Describe("leaseCacheHitRatio", func() {
+		It("returns the hit ratio of the lease cache", func() {
+			cache := &fakes.LeaseCache{}
+			cache.HitRatioReturns(0.75)
+			source := server_metrics.NewLeaseCacheHitRatioSource(cache)
+			Expect(source.Name).To(Equal("leaseCacheHitRatio"))
+
+			Expect(source.Getter()).To(Equal(0.75))
+		})
+	})
+
+	Describe("leaseCacheQueriesSaved", func() {
+		It("returns the number of lease queries answered by the lease cache", func() {
+			cache := &fakes.LeaseCache{}
+			cache.HitsReturns(42)
+			source := server_metrics.NewLeaseCacheQueriesSavedSource(cache)
+			Expect(source.Name).To(Equal("leaseCacheQueriesSaved"))
+
+			Expect(source.Getter()).To(Equal(42.0))
+		})
+	})
 })
@@ -0,0 +1,210 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/lease_repository.go --fake-name LeaseRepository . leaseRepository
+type leaseRepository interface {
+	RoutableLeases() ([]controller.Lease, error)
+}
+
+// Watcher numbers the states of the routable leases of every pool, so that
+// clients can wait for them to change and fetch only what changed since the
+// revision they know. Revisions are not shared between controllers: they
+// start from the time the watcher first loads the leases, in nanoseconds, so
+// that a revision from another controller, or from before a restart, falls
+// outside the history and is answered with a reset. They cannot come from the
+// database instead, since leases also stop being routable by expiring, which
+// writes nothing there.
+type Watcher struct {
+	Logger          lager.Logger
+	LeaseRepository leaseRepository
+	Interval        time.Duration
+	HistorySize     int
+
+	lock     sync.Mutex
+	revision int64
+	leases   map[controller.Lease]struct{}
+	history  []change
+	changed  chan struct{}
+}
+
+type change struct {
+	added   []controller.Lease
+	removed []controller.Lease
+}
+
+func (w *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	w.refreshAndLog()
+	close(ready)
+
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-time.After(w.Interval):
+			w.refreshAndLog()
+		}
+	}
+}
+
+func (w *Watcher) refreshAndLog() {
+	err := w.Refresh()
+	if err != nil {
+		w.Logger.Error("refresh-leases", err)
+	}
+}
+
+// Refresh loads the routable leases and, if they changed, moves on to the
+// next revision and wakes up the waiting watches.
+func (w *Watcher) Refresh() error {
+	leases, err := w.LeaseRepository.RoutableLeases()
+	if err != nil {
+		return fmt.Errorf("getting routable leases: %s", err)
+	}
+	current := map[controller.Lease]struct{}{}
+	for _, lease := range leases {
+		current[lease] = struct{}{}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.revision == 0 {
+		w.revision = time.Now().UnixNano()
+		w.leases = current
+		w.notify()
+		return nil
+	}
+
+	var c change
+	for lease := range w.leases {
+		if _, ok := current[lease]; !ok {
+			c.removed = append(c.removed, lease)
+		}
+	}
+	for lease := range current {
+		if _, ok := w.leases[lease]; !ok {
+			c.added = append(c.added, lease)
+		}
+	}
+	if len(c.added) == 0 && len(c.removed) == 0 {
+		return nil
+	}
+
+	w.revision++
+	w.leases = current
+	w.history = append(w.history, c)
+	if len(w.history) > w.HistorySize {
+		w.history = append([]change(nil), w.history[len(w.history)-w.HistorySize:]...)
+	}
+	w.notify()
+	return nil
+}
+
+// Changes returns the changes to the routable leases after the revision,
+// waiting for one if there are none yet, until the context is done. Only the
+// leases of the pool are returned, or those of every pool if pool is nil. A
+// revision outside the history gets a reset.
+func (w *Watcher) Changes(ctx context.Context, since int64, pool *string) (controller.LeaseChanges, error) {
+	for {
+		w.lock.Lock()
+		if w.revision == 0 {
+			w.lock.Unlock()
+			return controller.LeaseChanges{}, errors.New("routable leases have not been loaded yet")
+		}
+		changes := w.changesSince(since, pool)
+		changed := w.changed
+		w.lock.Unlock()
+
+		if changes.Reset || len(changes.Added) > 0 || len(changes.Removed) > 0 {
+			return changes, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return changes, nil
+		}
+	}
+}
+
+func (w *Watcher) changesSince(since int64, pool *string) controller.LeaseChanges {
+	changes := controller.LeaseChanges{
+		Revision: w.revision,
+		Added:    []controller.Lease{},
+		Removed:  []controller.Lease{},
+	}
+
+	oldest := w.revision - int64(len(w.history))
+	if since < oldest || since > w.revision {
+		changes.Reset = true
+		for lease := range w.leases {
+			changes.Added = appendInPool(changes.Added, lease, pool)
+		}
+		sortLeases(changes.Added)
+		return changes
+	}
+
+	// a lease removed and added again, or the other way round, has not changed
+	added := map[controller.Lease]bool{}
+	removed := map[controller.Lease]bool{}
+	for _, c := range w.history[len(w.history)-int(w.revision-since):] {
+		for _, lease := range c.removed {
+			if added[lease] {
+				delete(added, lease)
+			} else {
+				removed[lease] = true
+			}
+		}
+		for _, lease := range c.added {
+			if removed[lease] {
+				delete(removed, lease)
+			} else {
+				added[lease] = true
+			}
+		}
+	}
+	for lease := range added {
+		changes.Added = appendInPool(changes.Added, lease, pool)
+	}
+	for lease := range removed {
+		changes.Removed = appendInPool(changes.Removed, lease, pool)
+	}
+	sortLeases(changes.Added)
+	sortLeases(changes.Removed)
+	return changes
+}
+
+// notify wakes up every watch waiting on the current channel.
+func (w *Watcher) notify() {
+	if w.changed != nil {
+		close(w.changed)
+	}
+	w.changed = make(chan struct{})
+}
+
+func appendInPool(leases []controller.Lease, lease controller.Lease, pool *string) []controller.Lease {
+	if pool != nil && lease.Pool != *pool {
+		return leases
+	}
+	return append(leases, lease)
+}
+
+func sortLeases(leases []controller.Lease) {
+	sort.Slice(leases, func(i, j int) bool {
+		if leases[i].UnderlayIP != leases[j].UnderlayIP {
+			return leases[i].UnderlayIP < leases[j].UnderlayIP
+		}
+		return leases[i].OverlaySubnet < leases[j].OverlaySubnet
+	})
+}
@@ -0,0 +1,271 @@
+package watcher_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/watcher"
+	"code.cloudfoundry.org/silk/controller/watcher/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Watcher", func() {
+	var (
+		logger          *lagertest.TestLogger
+		leaseRepository *fakes.LeaseRepository
+		leaseWatcher    *watcher.Watcher
+		lease1          controller.Lease
+		lease2          controller.Lease
+		lease3          controller.Lease
+		ctx             context.Context
+		cancel          context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		leaseRepository = &fakes.LeaseRepository{}
+		leaseWatcher = &watcher.Watcher{
+			Logger:          logger,
+			LeaseRepository: leaseRepository,
+			Interval:        time.Second,
+			HistorySize:     10,
+		}
+
+		lease1 = controller.Lease{UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.33.0/24"}
+		lease2 = controller.Lease{UnderlayIP: "10.244.22.33", OverlaySubnet: "10.255.44.0/24"}
+		lease3 = controller.Lease{UnderlayIP: "10.244.33.44", OverlaySubnet: "10.250.55.0/24", Pool: "blue"}
+		leaseRepository.RoutableLeasesReturns([]controller.Lease{lease1, lease2}, nil)
+		Expect(leaseWatcher.Refresh()).To(Succeed())
+
+		ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	currentRevision := func() int64 {
+		changes, err := leaseWatcher.Changes(ctx, 0, nil)
+		Expect(err).NotTo(HaveOccurred())
+		return changes.Revision
+	}
+
+	It("returns every lease with a reset for revision zero", func() {
+		changes, err := leaseWatcher.Changes(ctx, 0, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes.Reset).To(BeTrue())
+		Expect(changes.Revision).To(BeNumerically(">", 0))
+		Expect(changes.Added).To(Equal([]controller.Lease{lease1, lease2}))
+		Expect(changes.Removed).To(BeEmpty())
+	})
+
+	It("returns the leases added and removed since the revision", func() {
+		revision := currentRevision()
+
+		leaseRepository.RoutableLeasesReturns([]controller.Lease{lease2, lease3}, nil)
+		Expect(leaseWatcher.Refresh()).To(Succeed())
+
+		changes, err := leaseWatcher.Changes(ctx, revision, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal(controller.LeaseChanges{
+			Revision: revision + 1,
+			Added:    []controller.Lease{lease3},
+			Removed:  []controller.Lease{lease1},
+		}))
+	})
+
+	It("does not move to a new revision when nothing changed", func() {
+		revision := currentRevision()
+		Expect(leaseWatcher.Refresh()).To(Succeed())
+		Expect(currentRevision()).To(Equal(revision))
+	})
+
+	It("nets out the changes of several revisions", func() {
+		revision := currentRevision()
+
+		leaseRepository.RoutableLeasesReturns([]controller.Lease{lease2}, nil)
+		Expect(leaseWatcher.Refresh()).To(Succeed())
+		leaseRepository.RoutableLeasesReturns([]controller.Lease{lease1, lease2, lease3}, nil)
+		Expect(leaseWatcher.Refresh()).To(Succeed())
+		leaseRepository.RoutableLeasesReturns([]controller.Lease{lease1}, nil)
+		Expect(leaseWatcher.Refresh()).To(Succeed())
+
+		changes, err := leaseWatcher.Changes(ctx, revision, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal(controller.LeaseChanges{
+			Revision: revision + 3,
+			Added:    []controller.Lease{},
+			Removed:  []controller.Lease{lease2},
+		}))
+
+		changes, err = leaseWatcher.Changes(ctx, revision+1, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes.Added).To(Equal([]controller.Lease{lease1}))
+		Expect(changes.Removed).To(Equal([]controller.Lease{lease2}))
+	})
+
+	It("only returns the changes to the leases of the pool", func() {
+		revision := currentRevision()
+
+		leaseRepository.RoutableLeasesReturns([]controller.Lease{lease2, lease3}, nil)
+		Expect(leaseWatcher.Refresh()).To(Succeed())
+
+		blue := "blue"
+		changes, err := leaseWatcher.Changes(ctx, revision, &blue)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes.Added).To(Equal([]controller.Lease{lease3}))
+		Expect(changes.Removed).To(BeEmpty())
+
+		defaultPool := ""
+		changes, err = leaseWatcher.Changes(ctx, 0, &defaultPool)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes.Added).To(Equal([]controller.Lease{lease2}))
+	})
+
+	It("waits for a change", func() {
+		revision := currentRevision()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		result := make(chan controller.LeaseChanges)
+		go func() {
+			defer GinkgoRecover()
+			changes, err := leaseWatcher.Changes(ctx, revision, nil)
+			Expect(err).NotTo(HaveOccurred())
+			result <- changes
+		}()
+		Consistently(result, "200ms").ShouldNot(Receive())
+
+		leaseRepository.RoutableLeasesReturns([]controller.Lease{lease1}, nil)
+		Expect(leaseWatcher.Refresh()).To(Succeed())
+
+		var changes controller.LeaseChanges
+		Eventually(result).Should(Receive(&changes))
+		Expect(changes.Revision).To(Equal(revision + 1))
+		Expect(changes.Removed).To(Equal([]controller.Lease{lease2}))
+	})
+
+	It("keeps waiting while only other pools change", func() {
+		revision := currentRevision()
+		defaultPool := ""
+
+		leaseRepository.RoutableLeasesReturns([]controller.Lease{lease1, lease2, lease3}, nil)
+		Expect(leaseWatcher.Refresh()).To(Succeed())
+
+		changes, err := leaseWatcher.Changes(ctx, revision, &defaultPool)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal(controller.LeaseChanges{
+			Revision: revision + 1,
+			Added:    []controller.Lease{},
+			Removed:  []controller.Lease{},
+		}))
+	})
+
+	It("returns no changes and the current revision when the context is done", func() {
+		revision := currentRevision()
+
+		changes, err := leaseWatcher.Changes(ctx, revision, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal(controller.LeaseChanges{
+			Revision: revision,
+			Added:    []controller.Lease{},
+			Removed:  []controller.Lease{},
+		}))
+	})
+
+	Context("when the revision is older than the history", func() {
+		It("returns a reset", func() {
+			leaseWatcher.HistorySize = 1
+			revision := currentRevision()
+
+			leaseRepository.RoutableLeasesReturns([]controller.Lease{lease1}, nil)
+			Expect(leaseWatcher.Refresh()).To(Succeed())
+			leaseRepository.RoutableLeasesReturns([]controller.Lease{lease3}, nil)
+			Expect(leaseWatcher.Refresh()).To(Succeed())
+
+			changes, err := leaseWatcher.Changes(ctx, revision, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes.Reset).To(BeTrue())
+			Expect(changes.Added).To(Equal([]controller.Lease{lease3}))
+
+			changes, err = leaseWatcher.Changes(ctx, revision+1, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes.Reset).To(BeFalse())
+			Expect(changes.Added).To(Equal([]controller.Lease{lease3}))
+			Expect(changes.Removed).To(Equal([]controller.Lease{lease1}))
+		})
+	})
+
+	Context("when the revision is newer than the current one", func() {
+		It("returns a reset", func() {
+			changes, err := leaseWatcher.Changes(ctx, currentRevision()+1, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes.Reset).To(BeTrue())
+			Expect(changes.Added).To(HaveLen(2))
+		})
+	})
+
+	Context("when the leases have not been loaded", func() {
+		It("returns an error", func() {
+			leaseWatcher = &watcher.Watcher{LeaseRepository: leaseRepository}
+			_, err := leaseWatcher.Changes(ctx, 0, nil)
+			Expect(err).To(MatchError("routable leases have not been loaded yet"))
+		})
+	})
+
+	Context("when getting the leases fails", func() {
+		It("returns an error and keeps the revision", func() {
+			revision := currentRevision()
+			leaseRepository.RoutableLeasesReturns(nil, errors.New("guava"))
+
+			Expect(leaseWatcher.Refresh()).To(MatchError("getting routable leases: guava"))
+			Expect(currentRevision()).To(Equal(revision))
+		})
+	})
+
+	Describe("Run", func() {
+		var (
+			signals chan os.Signal
+			ready   chan struct{}
+			retChan chan error
+		)
+
+		BeforeEach(func() {
+			signals = make(chan os.Signal)
+			ready = make(chan struct{})
+			retChan = make(chan error)
+			leaseWatcher.Interval = 50 * time.Millisecond
+		})
+
+		It("refreshes periodically until signalled", func() {
+			go func() {
+				retChan <- leaseWatcher.Run(signals, ready)
+			}()
+			Eventually(ready).Should(BeClosed())
+			Expect(leaseRepository.RoutableLeasesCallCount()).To(BeNumerically(">=", 2))
+			Eventually(leaseRepository.RoutableLeasesCallCount).Should(BeNumerically(">=", 4))
+
+			signals <- os.Interrupt
+			Eventually(retChan).Should(Receive(BeNil()))
+		})
+
+		It("logs refresh failures", func() {
+			leaseRepository.RoutableLeasesReturns(nil, errors.New("guava"))
+			go func() {
+				retChan <- leaseWatcher.Run(signals, ready)
+			}()
+			Eventually(ready).Should(BeClosed())
+			Expect(logger).To(gbytes.Say("refresh-leases.*getting routable leases: guava"))
+
+			signals <- os.Interrupt
+			Eventually(retChan).Should(Receive(BeNil()))
+		})
+	})
+})
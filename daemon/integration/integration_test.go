@@ -254,6 +254,43 @@ var _ = Describe("Daemon Integration", func() {
 		Eventually(fakeMetron.AllEvents, "5s").Should(ContainElement(withName("renewFailure")))
 	})
 
+	It("moves the vtep to the vni the controller renews the lease with", func() {
+		By("stopping the daemon")
+		stopDaemon()
+
+		By("setting up the renew handler to hand out another vni")
+		movedLease := daemonLease
+		movedLease.VNI = vni + 100
+		fakeServer.SetHandler("/leases/renew", &testsupport.FakeHandler{
+			ResponseCode: 200,
+			ResponseBody: movedLease,
+		})
+
+		By("restarting the daemon")
+		startAndWaitForDaemon()
+
+		By("checking that the vtep was rebuilt in the new vni")
+		Eventually(func() int {
+			link, err := netlink.LinkByName(vtepName)
+			if err != nil {
+				return 0
+			}
+			return link.(*netlink.Vxlan).VxlanId
+		}, "5s").Should(Equal(vni + 100))
+		Expect(session.Out).To(gbytes.Say("rebuilt-vtep"))
+
+		link, err := netlink.LinkByName(vtepName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link.Attrs().HardwareAddr.String()).To(Equal("ee:ee:0a:ff:1e:00"))
+		addresses, err := netlink.AddrList(link, netlink.FAMILY_V4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addresses).To(HaveLen(1))
+		Expect(addresses[0].IP.String()).To(Equal(overlayVtepIP.String()))
+
+		By("checking the daemon's healthcheck")
+		doHealthCheck()
+	})
+
 	Context("when single ip only is true", func() {
 		BeforeEach(func() {
 			fakeServer.SetHandlerFunc("/leases/acquire", func(w http.ResponseWriter, req *http.Request) {
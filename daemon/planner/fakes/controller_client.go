@@ -1,4 +1,4 @@
-// This file was generated by counterfeiter
+// Code generated by counterfeiter. DO NOT EDIT.
 package fakes
 
 import (
@@ -8,90 +8,108 @@ import (
 )
 
 type ControllerClient struct {
-	GetActiveLeasesStub          func() ([]controller.Lease, error)
-	getRoutableLeasesMutex       sync.RWMutex
-	getRoutableLeasesArgsForCall []struct{}
-	getRoutableLeasesReturns     struct {
+	GetActiveLeasesStub        func() ([]controller.Lease, error)
+	getActiveLeasesMutex       sync.RWMutex
+	getActiveLeasesArgsForCall []struct {
+	}
+	getActiveLeasesReturns struct {
 		result1 []controller.Lease
 		result2 error
 	}
-	getRoutableLeasesReturnsOnCall map[int]struct {
+	getActiveLeasesReturnsOnCall map[int]struct {
 		result1 []controller.Lease
 		result2 error
 	}
-	RenewSubnetLeaseStub        func(controller.Lease) error
+	RenewSubnetLeaseStub        func(controller.Lease) (controller.Lease, error)
 	renewSubnetLeaseMutex       sync.RWMutex
 	renewSubnetLeaseArgsForCall []struct {
 		arg1 controller.Lease
 	}
 	renewSubnetLeaseReturns struct {
-		result1 error
+		result1 controller.Lease
+		result2 error
 	}
 	renewSubnetLeaseReturnsOnCall map[int]struct {
-		result1 error
+		result1 controller.Lease
+		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
 func (fake *ControllerClient) GetActiveLeases() ([]controller.Lease, error) {
-	fake.getRoutableLeasesMutex.Lock()
-	ret, specificReturn := fake.getRoutableLeasesReturnsOnCall[len(fake.getRoutableLeasesArgsForCall)]
-	fake.getRoutableLeasesArgsForCall = append(fake.getRoutableLeasesArgsForCall, struct{}{})
+	fake.getActiveLeasesMutex.Lock()
+	ret, specificReturn := fake.getActiveLeasesReturnsOnCall[len(fake.getActiveLeasesArgsForCall)]
+	fake.getActiveLeasesArgsForCall = append(fake.getActiveLeasesArgsForCall, struct {
+	}{})
+	stub := fake.GetActiveLeasesStub
+	fakeReturns := fake.getActiveLeasesReturns
 	fake.recordInvocation("GetActiveLeases", []interface{}{})
-	fake.getRoutableLeasesMutex.Unlock()
-	if fake.GetActiveLeasesStub != nil {
-		return fake.GetActiveLeasesStub()
+	fake.getActiveLeasesMutex.Unlock()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.getRoutableLeasesReturns.result1, fake.getRoutableLeasesReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *ControllerClient) GetActiveLeasesCallCount() int {
-	fake.getRoutableLeasesMutex.RLock()
-	defer fake.getRoutableLeasesMutex.RUnlock()
-	return len(fake.getRoutableLeasesArgsForCall)
+	fake.getActiveLeasesMutex.RLock()
+	defer fake.getActiveLeasesMutex.RUnlock()
+	return len(fake.getActiveLeasesArgsForCall)
+}
+
+func (fake *ControllerClient) GetActiveLeasesCalls(stub func() ([]controller.Lease, error)) {
+	fake.getActiveLeasesMutex.Lock()
+	defer fake.getActiveLeasesMutex.Unlock()
+	fake.GetActiveLeasesStub = stub
 }
 
 func (fake *ControllerClient) GetActiveLeasesReturns(result1 []controller.Lease, result2 error) {
+	fake.getActiveLeasesMutex.Lock()
+	defer fake.getActiveLeasesMutex.Unlock()
 	fake.GetActiveLeasesStub = nil
-	fake.getRoutableLeasesReturns = struct {
+	fake.getActiveLeasesReturns = struct {
 		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
 func (fake *ControllerClient) GetActiveLeasesReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
+	fake.getActiveLeasesMutex.Lock()
+	defer fake.getActiveLeasesMutex.Unlock()
 	fake.GetActiveLeasesStub = nil
-	if fake.getRoutableLeasesReturnsOnCall == nil {
-		fake.getRoutableLeasesReturnsOnCall = make(map[int]struct {
+	if fake.getActiveLeasesReturnsOnCall == nil {
+		fake.getActiveLeasesReturnsOnCall = make(map[int]struct {
 			result1 []controller.Lease
 			result2 error
 		})
 	}
-	fake.getRoutableLeasesReturnsOnCall[i] = struct {
+	fake.getActiveLeasesReturnsOnCall[i] = struct {
 		result1 []controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *ControllerClient) RenewSubnetLease(arg1 controller.Lease) error {
+func (fake *ControllerClient) RenewSubnetLease(arg1 controller.Lease) (controller.Lease, error) {
 	fake.renewSubnetLeaseMutex.Lock()
 	ret, specificReturn := fake.renewSubnetLeaseReturnsOnCall[len(fake.renewSubnetLeaseArgsForCall)]
 	fake.renewSubnetLeaseArgsForCall = append(fake.renewSubnetLeaseArgsForCall, struct {
 		arg1 controller.Lease
 	}{arg1})
+	stub := fake.RenewSubnetLeaseStub
+	fakeReturns := fake.renewSubnetLeaseReturns
 	fake.recordInvocation("RenewSubnetLease", []interface{}{arg1})
 	fake.renewSubnetLeaseMutex.Unlock()
-	if fake.RenewSubnetLeaseStub != nil {
-		return fake.RenewSubnetLeaseStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	return fake.renewSubnetLeaseReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *ControllerClient) RenewSubnetLeaseCallCount() int {
@@ -100,39 +118,57 @@ func (fake *ControllerClient) RenewSubnetLeaseCallCount() int {
 	return len(fake.renewSubnetLeaseArgsForCall)
 }
 
+func (fake *ControllerClient) RenewSubnetLeaseCalls(stub func(controller.Lease) (controller.Lease, error)) {
+	fake.renewSubnetLeaseMutex.Lock()
+	defer fake.renewSubnetLeaseMutex.Unlock()
+	fake.RenewSubnetLeaseStub = stub
+}
+
 func (fake *ControllerClient) RenewSubnetLeaseArgsForCall(i int) controller.Lease {
 	fake.renewSubnetLeaseMutex.RLock()
 	defer fake.renewSubnetLeaseMutex.RUnlock()
-	return fake.renewSubnetLeaseArgsForCall[i].arg1
+	argsForCall := fake.renewSubnetLeaseArgsForCall[i]
+	return argsForCall.arg1
 }
 
-func (fake *ControllerClient) RenewSubnetLeaseReturns(result1 error) {
+func (fake *ControllerClient) RenewSubnetLeaseReturns(result1 controller.Lease, result2 error) {
+	fake.renewSubnetLeaseMutex.Lock()
+	defer fake.renewSubnetLeaseMutex.Unlock()
 	fake.RenewSubnetLeaseStub = nil
 	fake.renewSubnetLeaseReturns = struct {
-		result1 error
-	}{result1}
+		result1 controller.Lease
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *ControllerClient) RenewSubnetLeaseReturnsOnCall(i int, result1 error) {
+func (fake *ControllerClient) RenewSubnetLeaseReturnsOnCall(i int, result1 controller.Lease, result2 error) {
+	fake.renewSubnetLeaseMutex.Lock()
+	defer fake.renewSubnetLeaseMutex.Unlock()
 	fake.RenewSubnetLeaseStub = nil
 	if fake.renewSubnetLeaseReturnsOnCall == nil {
 		fake.renewSubnetLeaseReturnsOnCall = make(map[int]struct {
-			result1 error
+			result1 controller.Lease
+			result2 error
 		})
 	}
 	fake.renewSubnetLeaseReturnsOnCall[i] = struct {
-		result1 error
-	}{result1}
+		result1 controller.Lease
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *ControllerClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.getRoutableLeasesMutex.RLock()
-	defer fake.getRoutableLeasesMutex.RUnlock()
+	fake.getActiveLeasesMutex.RLock()
+	defer fake.getActiveLeasesMutex.RUnlock()
 	fake.renewSubnetLeaseMutex.RLock()
 	defer fake.renewSubnetLeaseMutex.RUnlock()
-	return fake.invocations
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
 }
 
 func (fake *ControllerClient) recordInvocation(key string, args []interface{}) {
@@ -1,7 +1,8 @@
-// This file was generated by counterfeiter
+// Code generated by counterfeiter. DO NOT EDIT.
 package fakes
 
 import (
+	"net"
 	"sync"
 
 	"code.cloudfoundry.org/silk/controller"
@@ -19,6 +20,12 @@ type Converger struct {
 	convergeReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetLocalVTEPStub        func(net.Interface, int)
+	setLocalVTEPMutex       sync.RWMutex
+	setLocalVTEPArgsForCall []struct {
+		arg1 net.Interface
+		arg2 int
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -34,15 +41,17 @@ func (fake *Converger) Converge(arg1 []controller.Lease) error {
 	fake.convergeArgsForCall = append(fake.convergeArgsForCall, struct {
 		arg1 []controller.Lease
 	}{arg1Copy})
+	stub := fake.ConvergeStub
+	fakeReturns := fake.convergeReturns
 	fake.recordInvocation("Converge", []interface{}{arg1Copy})
 	fake.convergeMutex.Unlock()
-	if fake.ConvergeStub != nil {
-		return fake.ConvergeStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	return fake.convergeReturns.result1
+	return fakeReturns.result1
 }
 
 func (fake *Converger) ConvergeCallCount() int {
@@ -51,13 +60,22 @@ func (fake *Converger) ConvergeCallCount() int {
 	return len(fake.convergeArgsForCall)
 }
 
+func (fake *Converger) ConvergeCalls(stub func([]controller.Lease) error) {
+	fake.convergeMutex.Lock()
+	defer fake.convergeMutex.Unlock()
+	fake.ConvergeStub = stub
+}
+
 func (fake *Converger) ConvergeArgsForCall(i int) []controller.Lease {
 	fake.convergeMutex.RLock()
 	defer fake.convergeMutex.RUnlock()
-	return fake.convergeArgsForCall[i].arg1
+	argsForCall := fake.convergeArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *Converger) ConvergeReturns(result1 error) {
+	fake.convergeMutex.Lock()
+	defer fake.convergeMutex.Unlock()
 	fake.ConvergeStub = nil
 	fake.convergeReturns = struct {
 		result1 error
@@ -65,6 +83,8 @@ func (fake *Converger) ConvergeReturns(result1 error) {
 }
 
 func (fake *Converger) ConvergeReturnsOnCall(i int, result1 error) {
+	fake.convergeMutex.Lock()
+	defer fake.convergeMutex.Unlock()
 	fake.ConvergeStub = nil
 	if fake.convergeReturnsOnCall == nil {
 		fake.convergeReturnsOnCall = make(map[int]struct {
@@ -76,12 +96,51 @@ func (fake *Converger) ConvergeReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *Converger) SetLocalVTEP(arg1 net.Interface, arg2 int) {
+	fake.setLocalVTEPMutex.Lock()
+	fake.setLocalVTEPArgsForCall = append(fake.setLocalVTEPArgsForCall, struct {
+		arg1 net.Interface
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.SetLocalVTEPStub
+	fake.recordInvocation("SetLocalVTEP", []interface{}{arg1, arg2})
+	fake.setLocalVTEPMutex.Unlock()
+	if stub != nil {
+		fake.SetLocalVTEPStub(arg1, arg2)
+	}
+}
+
+func (fake *Converger) SetLocalVTEPCallCount() int {
+	fake.setLocalVTEPMutex.RLock()
+	defer fake.setLocalVTEPMutex.RUnlock()
+	return len(fake.setLocalVTEPArgsForCall)
+}
+
+func (fake *Converger) SetLocalVTEPCalls(stub func(net.Interface, int)) {
+	fake.setLocalVTEPMutex.Lock()
+	defer fake.setLocalVTEPMutex.Unlock()
+	fake.SetLocalVTEPStub = stub
+}
+
+func (fake *Converger) SetLocalVTEPArgsForCall(i int) (net.Interface, int) {
+	fake.setLocalVTEPMutex.RLock()
+	defer fake.setLocalVTEPMutex.RUnlock()
+	argsForCall := fake.setLocalVTEPArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *Converger) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.convergeMutex.RLock()
 	defer fake.convergeMutex.RUnlock()
-	return fake.invocations
+	fake.setLocalVTEPMutex.RLock()
+	defer fake.setLocalVTEPMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
 }
 
 func (fake *Converger) recordInvocation(key string, args []interface{}) {
@@ -0,0 +1,117 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type LeaseWatcher struct {
+	WatchLeasesStub        func(int64, time.Duration) (controller.LeaseChanges, error)
+	watchLeasesMutex       sync.RWMutex
+	watchLeasesArgsForCall []struct {
+		arg1 int64
+		arg2 time.Duration
+	}
+	watchLeasesReturns struct {
+		result1 controller.LeaseChanges
+		result2 error
+	}
+	watchLeasesReturnsOnCall map[int]struct {
+		result1 controller.LeaseChanges
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LeaseWatcher) WatchLeases(arg1 int64, arg2 time.Duration) (controller.LeaseChanges, error) {
+	fake.watchLeasesMutex.Lock()
+	ret, specificReturn := fake.watchLeasesReturnsOnCall[len(fake.watchLeasesArgsForCall)]
+	fake.watchLeasesArgsForCall = append(fake.watchLeasesArgsForCall, struct {
+		arg1 int64
+		arg2 time.Duration
+	}{arg1, arg2})
+	stub := fake.WatchLeasesStub
+	fakeReturns := fake.watchLeasesReturns
+	fake.recordInvocation("WatchLeases", []interface{}{arg1, arg2})
+	fake.watchLeasesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *LeaseWatcher) WatchLeasesCallCount() int {
+	fake.watchLeasesMutex.RLock()
+	defer fake.watchLeasesMutex.RUnlock()
+	return len(fake.watchLeasesArgsForCall)
+}
+
+func (fake *LeaseWatcher) WatchLeasesCalls(stub func(int64, time.Duration) (controller.LeaseChanges, error)) {
+	fake.watchLeasesMutex.Lock()
+	defer fake.watchLeasesMutex.Unlock()
+	fake.WatchLeasesStub = stub
+}
+
+func (fake *LeaseWatcher) WatchLeasesArgsForCall(i int) (int64, time.Duration) {
+	fake.watchLeasesMutex.RLock()
+	defer fake.watchLeasesMutex.RUnlock()
+	argsForCall := fake.watchLeasesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *LeaseWatcher) WatchLeasesReturns(result1 controller.LeaseChanges, result2 error) {
+	fake.watchLeasesMutex.Lock()
+	defer fake.watchLeasesMutex.Unlock()
+	fake.WatchLeasesStub = nil
+	fake.watchLeasesReturns = struct {
+		result1 controller.LeaseChanges
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseWatcher) WatchLeasesReturnsOnCall(i int, result1 controller.LeaseChanges, result2 error) {
+	fake.watchLeasesMutex.Lock()
+	defer fake.watchLeasesMutex.Unlock()
+	fake.WatchLeasesStub = nil
+	if fake.watchLeasesReturnsOnCall == nil {
+		fake.watchLeasesReturnsOnCall = make(map[int]struct {
+			result1 controller.LeaseChanges
+			result2 error
+		})
+	}
+	fake.watchLeasesReturnsOnCall[i] = struct {
+		result1 controller.LeaseChanges
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseWatcher) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.watchLeasesMutex.RLock()
+	defer fake.watchLeasesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LeaseWatcher) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
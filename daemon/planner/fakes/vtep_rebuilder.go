@@ -0,0 +1,120 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"net"
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type VTEPRebuilder struct {
+	RebuildStub        func(controller.Lease) (net.Interface, int, error)
+	rebuildMutex       sync.RWMutex
+	rebuildArgsForCall []struct {
+		arg1 controller.Lease
+	}
+	rebuildReturns struct {
+		result1 net.Interface
+		result2 int
+		result3 error
+	}
+	rebuildReturnsOnCall map[int]struct {
+		result1 net.Interface
+		result2 int
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *VTEPRebuilder) Rebuild(arg1 controller.Lease) (net.Interface, int, error) {
+	fake.rebuildMutex.Lock()
+	ret, specificReturn := fake.rebuildReturnsOnCall[len(fake.rebuildArgsForCall)]
+	fake.rebuildArgsForCall = append(fake.rebuildArgsForCall, struct {
+		arg1 controller.Lease
+	}{arg1})
+	stub := fake.RebuildStub
+	fakeReturns := fake.rebuildReturns
+	fake.recordInvocation("Rebuild", []interface{}{arg1})
+	fake.rebuildMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *VTEPRebuilder) RebuildCallCount() int {
+	fake.rebuildMutex.RLock()
+	defer fake.rebuildMutex.RUnlock()
+	return len(fake.rebuildArgsForCall)
+}
+
+func (fake *VTEPRebuilder) RebuildCalls(stub func(controller.Lease) (net.Interface, int, error)) {
+	fake.rebuildMutex.Lock()
+	defer fake.rebuildMutex.Unlock()
+	fake.RebuildStub = stub
+}
+
+func (fake *VTEPRebuilder) RebuildArgsForCall(i int) controller.Lease {
+	fake.rebuildMutex.RLock()
+	defer fake.rebuildMutex.RUnlock()
+	argsForCall := fake.rebuildArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *VTEPRebuilder) RebuildReturns(result1 net.Interface, result2 int, result3 error) {
+	fake.rebuildMutex.Lock()
+	defer fake.rebuildMutex.Unlock()
+	fake.RebuildStub = nil
+	fake.rebuildReturns = struct {
+		result1 net.Interface
+		result2 int
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *VTEPRebuilder) RebuildReturnsOnCall(i int, result1 net.Interface, result2 int, result3 error) {
+	fake.rebuildMutex.Lock()
+	defer fake.rebuildMutex.Unlock()
+	fake.RebuildStub = nil
+	if fake.rebuildReturnsOnCall == nil {
+		fake.rebuildReturnsOnCall = make(map[int]struct {
+			result1 net.Interface
+			result2 int
+			result3 error
+		})
+	}
+	fake.rebuildReturnsOnCall[i] = struct {
+		result1 net.Interface
+		result2 int
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *VTEPRebuilder) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.rebuildMutex.RLock()
+	defer fake.rebuildMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *VTEPRebuilder) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
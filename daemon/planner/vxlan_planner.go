@@ -2,6 +2,10 @@ package planner
 
 import (
 	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
 
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/silk/controller"
@@ -11,12 +15,23 @@ import (
 //go:generate counterfeiter -o fakes/controller_client.go --fake-name ControllerClient . controllerClient
 type controllerClient interface {
 	GetActiveLeases() ([]controller.Lease, error)
-	RenewSubnetLease(controller.Lease) error
+	RenewSubnetLease(controller.Lease) (controller.Lease, error)
+}
+
+//go:generate counterfeiter -o fakes/lease_watcher.go --fake-name LeaseWatcher . leaseWatcher
+type leaseWatcher interface {
+	WatchLeases(since int64, timeout time.Duration) (controller.LeaseChanges, error)
 }
 
 //go:generate counterfeiter -o fakes/converger.go --fake-name Converger . converger
 type converger interface {
 	Converge([]controller.Lease) error
+	SetLocalVTEP(vtep net.Interface, vni int)
+}
+
+//go:generate counterfeiter -o fakes/vtep_rebuilder.go --fake-name VTEPRebuilder . vtepRebuilder
+type vtepRebuilder interface {
+	Rebuild(controller.Lease) (net.Interface, int, error)
 }
 
 //go:generate counterfeiter -o fakes/metricSender.go --fake-name MetricSender . metricSender
@@ -32,10 +47,62 @@ type VXLANPlanner struct {
 	Lease            controller.Lease
 	ErrorDetector    FatalErrorDetector
 	MetricSender     metricSender
+
+	// ConvergeEvery is how many cycles DoCycle may go without converging
+	// while the leases have not changed. It still converges that often, so
+	// that routes and neighbors changed behind its back are repaired. Zero
+	// converges every cycle.
+	ConvergeEvery int
+
+	// LeaseWatcher and WatchTimeout are only used by WatchCycle. The watcher's
+	// requests must be allowed to outlast WatchTimeout.
+	LeaseWatcher leaseWatcher
+	WatchTimeout time.Duration
+
+	// VTEPRebuilder moves the local vtep, which is in VNI, to the vni the
+	// controller renews the lease with. Leases without a vni are in
+	// DefaultVNI. Without a VTEPRebuilder the vtep stays where it is.
+	VTEPRebuilder vtepRebuilder
+	VNI           int
+	DefaultVNI    int
+
+	lock                sync.Mutex
+	revision            int64
+	leases              map[controller.Lease]struct{}
+	mustConverge        bool
+	cyclesSinceConverge int
+
+	// cycleRetryAt and watchRetryAt are when DoCycle and WatchCycle may call
+	// the controller again after it turned them away with a 429. Each is only
+	// used by its own cycle, as is renewRateLimited, the last renewal turned
+	// away since the last one that succeeded.
+	cycleRetryAt     time.Time
+	watchRetryAt     time.Time
+	renewRateLimited error
 }
 
+// DoCycle renews the lease and converges on the routable leases. While the
+// controller has asked it to wait, it does not call it. A renewal turned away
+// counts against the partition tolerance like any other failed renewal, but
+// is not logged as an error until it is fatal.
 func (v *VXLANPlanner) DoCycle() error {
-	err := v.ControllerClient.RenewSubnetLease(v.Lease)
+	if v.waitingOut(v.cycleRetryAt) {
+		if v.renewRateLimited != nil && v.ErrorDetector.IsFatal(v.renewRateLimited) {
+			return daemon.FatalError(fmt.Sprintf("renew lease: %s", v.renewRateLimited))
+		}
+		return nil
+	}
+
+	renewed, err := v.ControllerClient.RenewSubnetLease(v.Lease)
+	if rateLimited, ok := err.(*controller.RateLimitedError); ok {
+		v.cycleRetryAt = v.rateLimited("renew-lease", rateLimited)
+		v.renewRateLimited = err
+		v.MetricSender.IncrementCounter("renewRateLimited")
+		if v.ErrorDetector.IsFatal(err) {
+			return daemon.FatalError(fmt.Sprintf("renew lease: %s", err))
+		}
+		return nil
+	}
 	if err != nil {
 		v.MetricSender.IncrementCounter("renewFailure")
 		if v.ErrorDetector.IsFatal(err) {
@@ -44,18 +111,130 @@ func (v *VXLANPlanner) DoCycle() error {
 		return fmt.Errorf("renew lease: %s", err)
 	}
 	v.ErrorDetector.GotSuccess()
+	v.renewRateLimited = nil
+	v.Lease = renewed
 	v.Logger.Debug("renew-lease", lager.Data{"lease": v.Lease})
 
 	v.MetricSender.IncrementCounter("renewSuccess")
 
+	err = v.followVNI()
+	if err != nil {
+		return err
+	}
+
 	leases, err := v.ControllerClient.GetActiveLeases()
+	if rateLimited, ok := err.(*controller.RateLimitedError); ok {
+		v.cycleRetryAt = v.rateLimited("get-routable-leases", rateLimited)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("get routable leases: %s", err)
 	}
 
 	v.MetricSender.SendValue("numberLeases", float64(len(leases)), "")
 
-	err = v.Converger.Converge(leases)
+	current := make(map[controller.Lease]struct{}, len(leases))
+	for _, lease := range leases {
+		current[lease] = struct{}{}
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.cyclesSinceConverge++
+	if !v.mustConverge && sameLeases(current, v.leases) && v.cyclesSinceConverge < v.ConvergeEvery {
+		v.Logger.Debug("leases-unchanged", lager.Data{"count": len(leases)})
+		return nil
+	}
+	v.leases = current
+	return v.converge(leases)
+}
+
+// WatchCycle waits for the routable leases to change and converges as soon as
+// they do, instead of at the next DoCycle.
+func (v *VXLANPlanner) WatchCycle() error {
+	if v.waitingOut(v.watchRetryAt) {
+		return nil
+	}
+
+	v.lock.Lock()
+	since := v.revision
+	v.lock.Unlock()
+
+	changes, err := v.LeaseWatcher.WatchLeases(since, v.WatchTimeout)
+	if rateLimited, ok := err.(*controller.RateLimitedError); ok {
+		v.watchRetryAt = v.rateLimited("watch-leases", rateLimited)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("watch leases: %s", err)
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.revision = changes.Revision
+	leases := make(map[controller.Lease]struct{}, len(v.leases)+len(changes.Added))
+	if !changes.Reset {
+		for lease := range v.leases {
+			leases[lease] = struct{}{}
+		}
+	}
+	for _, lease := range changes.Removed {
+		delete(leases, lease)
+	}
+	for _, lease := range changes.Added {
+		leases[lease] = struct{}{}
+	}
+	if !v.mustConverge && sameLeases(leases, v.leases) {
+		return nil
+	}
+	v.leases = leases
+
+	sorted := make([]controller.Lease, 0, len(leases))
+	for lease := range leases {
+		sorted = append(sorted, lease)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].UnderlayIP < sorted[j].UnderlayIP
+	})
+	v.MetricSender.SendValue("numberLeases", float64(len(sorted)), "")
+	return v.converge(sorted)
+}
+
+// followVNI rebuilds the local vtep when the lease was renewed with another
+// vni than the one it is in. The routes of the old vtep go with it, so the
+// next cycle converges even if the leases have not changed.
+func (v *VXLANPlanner) followVNI() error {
+	vni := v.Lease.VNI
+	if vni == 0 {
+		vni = v.DefaultVNI
+	}
+	if v.VTEPRebuilder == nil || vni == v.VNI {
+		return nil
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	vtep, vtepVNI, err := v.VTEPRebuilder.Rebuild(v.Lease)
+	if err != nil {
+		v.MetricSender.IncrementCounter("rebuildVTEPFailure")
+		return fmt.Errorf("rebuild vtep in vni %d: %s", vni, err)
+	}
+	v.Logger.Info("rebuilt-vtep", lager.Data{"old_vni": v.VNI, "vni": vtepVNI})
+	v.Converger.SetLocalVTEP(vtep, vtepVNI)
+	v.VNI = vtepVNI
+	v.mustConverge = true
+	return nil
+}
+
+// converge must be called with the lock held. A failure makes the next cycle
+// converge even if the leases have not changed.
+func (v *VXLANPlanner) converge(leases []controller.Lease) error {
+	err := v.Converger.Converge(leases)
+	v.mustConverge = err != nil
+	v.cyclesSinceConverge = 0
 	if err != nil {
 		v.MetricSender.IncrementCounter("convergeFailure")
 		return fmt.Errorf("converge leases: %s", err)
@@ -65,3 +244,30 @@ func (v *VXLANPlanner) DoCycle() error {
 	v.Logger.Debug("converge-leases", lager.Data{"leases": leases})
 	return nil
 }
+
+// rateLimited logs that the controller turned a call away, and returns when
+// to call it again.
+func (v *VXLANPlanner) rateLimited(action string, err *controller.RateLimitedError) time.Time {
+	v.Logger.Info("rate-limited", lager.Data{"action": action, "error": err.Message, "retry_after": err.RetryAfter.String()})
+	return time.Now().Add(err.RetryAfter)
+}
+
+func (v *VXLANPlanner) waitingOut(retryAt time.Time) bool {
+	if time.Now().Before(retryAt) {
+		v.Logger.Debug("waiting-out-rate-limit", lager.Data{"retry_at": retryAt})
+		return true
+	}
+	return false
+}
+
+func sameLeases(a, b map[controller.Lease]struct{}) bool {
+	if a == nil || b == nil || len(a) != len(b) {
+		return false
+	}
+	for lease := range a {
+		if _, ok := b[lease]; !ok {
+			return false
+		}
+	}
+	return true
+}
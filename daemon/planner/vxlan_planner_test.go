@@ -2,6 +2,8 @@ package planner_test
 
 import (
 	"errors"
+	"net"
+	"time"
 
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagertest"
@@ -12,6 +14,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/types"
 )
 
@@ -40,6 +43,7 @@ var _ = Describe("VxlanPlanner", func() {
 		converger        *fakes.Converger
 		errorDetector    *fakes.FatalErrorDetector
 		metricSender     *fakes.MetricSender
+		leaseWatcher     *fakes.LeaseWatcher
 	)
 
 	BeforeEach(func() {
@@ -48,6 +52,10 @@ var _ = Describe("VxlanPlanner", func() {
 		converger = &fakes.Converger{}
 		metricSender = &fakes.MetricSender{}
 		errorDetector = &fakes.FatalErrorDetector{}
+		leaseWatcher = &fakes.LeaseWatcher{}
+		controllerClient.RenewSubnetLeaseStub = func(lease controller.Lease) (controller.Lease, error) {
+			return lease, nil
+		}
 		vxlanPlanner = &planner.VXLANPlanner{
 			Logger:           logger,
 			ControllerClient: controllerClient,
@@ -59,6 +67,9 @@ var _ = Describe("VxlanPlanner", func() {
 			},
 			ErrorDetector: errorDetector,
 			MetricSender:  metricSender,
+			ConvergeEvery: 10,
+			LeaseWatcher:  leaseWatcher,
+			WatchTimeout:  30 * time.Second,
 		}
 	})
 
@@ -163,10 +174,162 @@ var _ = Describe("VxlanPlanner", func() {
 			Expect(metricSender.IncrementCounterArgsForCall(1)).To(Equal("convergeSuccess"))
 		})
 
+		Context("when the leases have not changed since the last cycle", func() {
+			BeforeEach(func() {
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				controllerClient.GetActiveLeasesReturns([]controller.Lease{leases[1], leases[0]}, nil)
+			})
+
+			It("does not converge again but still reports the number of leases", func() {
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(converger.ConvergeCallCount()).To(Equal(1))
+
+				Expect(metricSender.SendValueCallCount()).To(Equal(2))
+				name, value, _ := metricSender.SendValueArgsForCall(1)
+				Expect(name).To(Equal("numberLeases"))
+				Expect(value).To(BeEquivalentTo(2))
+			})
+
+			It("converges once they change", func() {
+				changed := append([]controller.Lease{{
+					UnderlayIP:          "172.244.18.0",
+					OverlaySubnet:       "10.244.18.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:f4:12:00",
+				}}, leases...)
+				controllerClient.GetActiveLeasesReturns(changed, nil)
+
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(converger.ConvergeCallCount()).To(Equal(2))
+				Expect(converger.ConvergeArgsForCall(1)).To(Equal(changed))
+			})
+
+			It("still converges every so many cycles, to repair what changed behind its back", func() {
+				vxlanPlanner.ConvergeEvery = 3
+
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(converger.ConvergeCallCount()).To(Equal(1))
+
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(converger.ConvergeCallCount()).To(Equal(2))
+				Expect(converger.ConvergeArgsForCall(1)).To(Equal([]controller.Lease{leases[1], leases[0]}))
+
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(converger.ConvergeCallCount()).To(Equal(2))
+			})
+
+			Context("when the planner converges every cycle", func() {
+				BeforeEach(func() {
+					vxlanPlanner.ConvergeEvery = 0
+				})
+
+				It("converges even though the leases have not changed", func() {
+					Expect(vxlanPlanner.DoCycle()).To(Succeed())
+					Expect(converger.ConvergeCallCount()).To(Equal(2))
+				})
+			})
+		})
+
+		Context("when the last converge failed", func() {
+			BeforeEach(func() {
+				converger.ConvergeReturnsOnCall(0, errors.New("banana"))
+				Expect(vxlanPlanner.DoCycle()).To(HaveOccurred())
+			})
+
+			It("converges again even though the leases have not changed", func() {
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(converger.ConvergeCallCount()).To(Equal(2))
+
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(converger.ConvergeCallCount()).To(Equal(2))
+			})
+		})
+
+		Context("when the local vtep can be rebuilt", func() {
+			var vtepRebuilder *fakes.VTEPRebuilder
+
+			BeforeEach(func() {
+				vtepRebuilder = &fakes.VTEPRebuilder{}
+				vtepRebuilder.RebuildReturns(net.Interface{Index: 43, Name: "silk-vtep"}, 7, nil)
+				vxlanPlanner.VTEPRebuilder = vtepRebuilder
+				vxlanPlanner.VNI = 1
+				vxlanPlanner.DefaultVNI = 1
+			})
+
+			It("leaves the vtep alone while the lease is renewed in its vni", func() {
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(vtepRebuilder.RebuildCallCount()).To(Equal(0))
+				Expect(converger.SetLocalVTEPCallCount()).To(Equal(0))
+			})
+
+			Context("when the controller renews the lease with another vni", func() {
+				var renewed controller.Lease
+
+				BeforeEach(func() {
+					renewed = vxlanPlanner.Lease
+					renewed.VNI = 7
+					controllerClient.RenewSubnetLeaseReturns(renewed, nil)
+					Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				})
+
+				It("rebuilds the vtep in the new vni and converges on it", func() {
+					Expect(vtepRebuilder.RebuildCallCount()).To(Equal(1))
+					Expect(vtepRebuilder.RebuildArgsForCall(0)).To(Equal(renewed))
+
+					Expect(converger.SetLocalVTEPCallCount()).To(Equal(1))
+					vtep, vni := converger.SetLocalVTEPArgsForCall(0)
+					Expect(vtep).To(Equal(net.Interface{Index: 43, Name: "silk-vtep"}))
+					Expect(vni).To(Equal(7))
+					Expect(converger.ConvergeCallCount()).To(Equal(1))
+					Expect(logger).To(gbytes.Say("rebuilt-vtep.*old_vni.*1.*vni.*7"))
+				})
+
+				It("renews with the new vni and does not rebuild again", func() {
+					Expect(vxlanPlanner.DoCycle()).To(Succeed())
+					Expect(controllerClient.RenewSubnetLeaseArgsForCall(1)).To(Equal(renewed))
+					Expect(vtepRebuilder.RebuildCallCount()).To(Equal(1))
+				})
+
+				It("moves back to the default vni, converging again even though the leases have not changed", func() {
+					Expect(converger.ConvergeCallCount()).To(Equal(1))
+
+					vtepRebuilder.RebuildReturns(net.Interface{Index: 44, Name: "silk-vtep"}, 1, nil)
+					vxlanPlanner.Lease.VNI = 0
+					controllerClient.RenewSubnetLeaseReturns(vxlanPlanner.Lease, nil)
+					Expect(vxlanPlanner.DoCycle()).To(Succeed())
+
+					Expect(vtepRebuilder.RebuildCallCount()).To(Equal(2))
+					Expect(converger.ConvergeCallCount()).To(Equal(2))
+				})
+			})
+
+			Context("when rebuilding the vtep fails", func() {
+				BeforeEach(func() {
+					renewed := vxlanPlanner.Lease
+					renewed.VNI = 7
+					controllerClient.RenewSubnetLeaseReturns(renewed, nil)
+					vtepRebuilder.RebuildReturnsOnCall(0, net.Interface{}, 0, errors.New("banana"))
+				})
+
+				It("returns the error and tries again the next cycle", func() {
+					err := vxlanPlanner.DoCycle()
+					Expect(err).To(MatchError("rebuild vtep in vni 7: banana"))
+					_, ok := err.(daemon.FatalError)
+					Expect(ok).To(BeFalse())
+					Expect(converger.SetLocalVTEPCallCount()).To(Equal(0))
+					Expect(metricSender.IncrementCounterArgsForCall(1)).To(Equal("rebuildVTEPFailure"))
+
+					Expect(vxlanPlanner.DoCycle()).To(Succeed())
+					Expect(vtepRebuilder.RebuildCallCount()).To(Equal(2))
+					Expect(converger.SetLocalVTEPCallCount()).To(Equal(1))
+				})
+			})
+		})
+
 		Context("when renewing the subnet lease fails", func() {
 			Context("when the error is detected as non-fatal", func() {
 				BeforeEach(func() {
-					controllerClient.RenewSubnetLeaseReturns(errors.New("guava"))
+					controllerClient.RenewSubnetLeaseReturns(controller.Lease{}, errors.New("guava"))
 					errorDetector.IsFatalReturns(false)
 				})
 				It("returns the error as non-fatal and emits a failure metric", func() {
@@ -186,7 +349,7 @@ var _ = Describe("VxlanPlanner", func() {
 
 			Context("when the error is detected as fatal", func() {
 				BeforeEach(func() {
-					controllerClient.RenewSubnetLeaseReturns(errors.New("guava"))
+					controllerClient.RenewSubnetLeaseReturns(controller.Lease{}, errors.New("guava"))
 					errorDetector.IsFatalReturns(true)
 				})
 				It("returns the error as a fatal error and emits a failure metric", func() {
@@ -205,6 +368,91 @@ var _ = Describe("VxlanPlanner", func() {
 			})
 		})
 
+		Context("when the controller turns the renewal away for being over its rate limit", func() {
+			BeforeEach(func() {
+				controllerClient.RenewSubnetLeaseReturnsOnCall(0, controller.Lease{}, &controller.RateLimitedError{Message: "over the rate limit", RetryAfter: time.Hour})
+			})
+
+			It("counts it against the partition tolerance and waits out the retry after", func() {
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(errorDetector.IsFatalCallCount()).To(Equal(1))
+				Expect(errorDetector.IsFatalArgsForCall(0)).To(BeAssignableToTypeOf(&controller.RateLimitedError{}))
+				Expect(errorDetector.GotSuccessCallCount()).To(Equal(0))
+				Expect(metricSender.IncrementCounterCallCount()).To(Equal(1))
+				Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("renewRateLimited"))
+				Expect(controllerClient.GetActiveLeasesCallCount()).To(Equal(0))
+				Expect(logger).To(gbytes.Say("rate-limited.*renew-lease"))
+
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(controllerClient.RenewSubnetLeaseCallCount()).To(Equal(1))
+				Expect(converger.ConvergeCallCount()).To(Equal(0))
+			})
+
+			Context("when the retry after has passed", func() {
+				BeforeEach(func() {
+					controllerClient.RenewSubnetLeaseReturnsOnCall(0, controller.Lease{}, &controller.RateLimitedError{Message: "over the rate limit", RetryAfter: time.Millisecond})
+				})
+
+				It("renews again", func() {
+					Expect(vxlanPlanner.DoCycle()).To(Succeed())
+					Eventually(func() int {
+						vxlanPlanner.DoCycle()
+						return controllerClient.RenewSubnetLeaseCallCount()
+					}).Should(Equal(2))
+					Expect(converger.ConvergeCallCount()).To(Equal(1))
+					Expect(errorDetector.GotSuccessCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the throttling outlasts the partition tolerance", func() {
+				BeforeEach(func() {
+					vxlanPlanner.ErrorDetector = planner.NewGracefulDetector(50 * time.Millisecond)
+				})
+
+				It("returns a fatal error while it is still waiting out the retry after", func() {
+					Expect(vxlanPlanner.DoCycle()).To(Succeed())
+					Expect(vxlanPlanner.DoCycle()).To(Succeed())
+
+					var err error
+					Eventually(func() error {
+						err = vxlanPlanner.DoCycle()
+						return err
+					}).Should(HaveOccurred())
+					Expect(err).To(BeAssignableToTypeOf(daemon.FatalError("")))
+					Expect(err).To(MatchError(ContainSubstring("fatal: renew lease: rate limited")))
+					Expect(controllerClient.RenewSubnetLeaseCallCount()).To(Equal(1))
+				})
+
+				It("returns a fatal error when the controller keeps turning renewals away", func() {
+					rateLimited := &controller.RateLimitedError{Message: "over the rate limit", RetryAfter: time.Millisecond}
+					controllerClient.RenewSubnetLeaseReturnsOnCall(0, controller.Lease{}, rateLimited)
+					controllerClient.RenewSubnetLeaseReturns(controller.Lease{}, rateLimited)
+
+					var err error
+					Eventually(func() error {
+						err = vxlanPlanner.DoCycle()
+						return err
+					}).Should(HaveOccurred())
+					Expect(err).To(BeAssignableToTypeOf(daemon.FatalError("")))
+					Expect(controllerClient.RenewSubnetLeaseCallCount()).To(BeNumerically(">", 1))
+				})
+			})
+		})
+
+		Context("when the controller turns getting the routable leases away for being over its rate limit", func() {
+			BeforeEach(func() {
+				controllerClient.GetActiveLeasesReturns(nil, &controller.RateLimitedError{Message: "over the rate limit", RetryAfter: time.Hour})
+			})
+
+			It("waits out the retry after", func() {
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(controllerClient.RenewSubnetLeaseCallCount()).To(Equal(1))
+				Expect(controllerClient.GetActiveLeasesCallCount()).To(Equal(1))
+				Expect(converger.ConvergeCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when getting the routable releases fails", func() {
 			BeforeEach(func() {
 				controllerClient.GetActiveLeasesReturns(nil, errors.New("guava"))
@@ -229,4 +477,114 @@ var _ = Describe("VxlanPlanner", func() {
 			})
 		})
 	})
+
+	Describe("WatchCycle", func() {
+		var leaseA, leaseB, leaseC controller.Lease
+
+		BeforeEach(func() {
+			leaseA = controller.Lease{UnderlayIP: "172.244.15.0", OverlaySubnet: "10.244.15.0/24", OverlayHardwareAddr: "ee:ee:0a:f4:0f:00"}
+			leaseB = controller.Lease{UnderlayIP: "172.244.16.0", OverlaySubnet: "10.244.16.0/24", OverlayHardwareAddr: "ee:ee:0a:f4:10:00"}
+			leaseC = controller.Lease{UnderlayIP: "172.244.14.0", OverlaySubnet: "10.244.14.0/24", OverlayHardwareAddr: "ee:ee:0a:f4:0e:00"}
+			leaseWatcher.WatchLeasesReturns(controller.LeaseChanges{Revision: 7, Reset: true, Added: []controller.Lease{leaseB, leaseA}}, nil)
+		})
+
+		It("watches from the start and converges on the leases it is sent", func() {
+			err := vxlanPlanner.WatchCycle()
+			Expect(err).NotTo(HaveOccurred())
+
+			since, timeout := leaseWatcher.WatchLeasesArgsForCall(0)
+			Expect(since).To(BeEquivalentTo(0))
+			Expect(timeout).To(Equal(30 * time.Second))
+
+			Expect(converger.ConvergeCallCount()).To(Equal(1))
+			Expect(converger.ConvergeArgsForCall(0)).To(Equal([]controller.Lease{leaseA, leaseB}))
+
+			name, value, _ := metricSender.SendValueArgsForCall(0)
+			Expect(name).To(Equal("numberLeases"))
+			Expect(value).To(BeEquivalentTo(2))
+			Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("convergeSuccess"))
+		})
+
+		It("applies the changes since the last revision to the leases it knows", func() {
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+
+			leaseWatcher.WatchLeasesReturns(controller.LeaseChanges{
+				Revision: 9,
+				Added:    []controller.Lease{leaseC},
+				Removed:  []controller.Lease{leaseB},
+			}, nil)
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+
+			since, _ := leaseWatcher.WatchLeasesArgsForCall(1)
+			Expect(since).To(BeEquivalentTo(7))
+			Expect(converger.ConvergeCallCount()).To(Equal(2))
+			Expect(converger.ConvergeArgsForCall(1)).To(Equal([]controller.Lease{leaseC, leaseA}))
+		})
+
+		It("does not converge when the watch times out without changes", func() {
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+
+			leaseWatcher.WatchLeasesReturns(controller.LeaseChanges{Revision: 7}, nil)
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+			Expect(converger.ConvergeCallCount()).To(Equal(1))
+		})
+
+		It("does not converge again when a reset matches the leases from the last DoCycle", func() {
+			controllerClient.GetActiveLeasesReturns([]controller.Lease{leaseA, leaseB}, nil)
+			Expect(vxlanPlanner.DoCycle()).To(Succeed())
+
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+			Expect(converger.ConvergeCallCount()).To(Equal(1))
+
+			leaseWatcher.WatchLeasesReturns(controller.LeaseChanges{Revision: 8, Added: []controller.Lease{leaseC}}, nil)
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+
+			since, _ := leaseWatcher.WatchLeasesArgsForCall(1)
+			Expect(since).To(BeEquivalentTo(7))
+			Expect(converger.ConvergeCallCount()).To(Equal(2))
+			Expect(converger.ConvergeArgsForCall(1)).To(Equal([]controller.Lease{leaseC, leaseA, leaseB}))
+		})
+
+		Context("when watching fails", func() {
+			BeforeEach(func() {
+				leaseWatcher.WatchLeasesReturns(controller.LeaseChanges{}, errors.New("guava"))
+			})
+			It("returns the error", func() {
+				err := vxlanPlanner.WatchCycle()
+				Expect(err).To(MatchError("watch leases: guava"))
+				Expect(converger.ConvergeCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the controller turns the watch away for being over its rate limit", func() {
+			BeforeEach(func() {
+				leaseWatcher.WatchLeasesReturnsOnCall(0, controller.LeaseChanges{}, &controller.RateLimitedError{Message: "over the rate limit", RetryAfter: time.Hour})
+			})
+
+			It("waits out the retry after without an error", func() {
+				Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+				Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+				Expect(leaseWatcher.WatchLeasesCallCount()).To(Equal(1))
+				Expect(converger.ConvergeCallCount()).To(Equal(0))
+				Expect(logger).To(gbytes.Say("rate-limited.*watch-leases"))
+			})
+
+			It("still renews in DoCycle", func() {
+				Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+				Expect(vxlanPlanner.DoCycle()).To(Succeed())
+				Expect(controllerClient.RenewSubnetLeaseCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the converger fails", func() {
+			BeforeEach(func() {
+				converger.ConvergeReturns(errors.New("banana"))
+			})
+			It("returns an error and emits a failure metric", func() {
+				err := vxlanPlanner.WatchCycle()
+				Expect(err).To(MatchError("converge leases: banana"))
+				Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("convergeFailure"))
+			})
+		})
+	})
 })
@@ -74,6 +74,16 @@ func (c *ConfigCreator) Create(clientConf clientConfig.Config, lease controller.
 	if err != nil {
 		return nil, fmt.Errorf("determine overlay network: %s", err)
 	}
+	// the vtep address carries the prefix of the network the lease is in
+	for _, network := range clientConf.AdditionalOverlayNetworks {
+		_, additionalNetwork, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, fmt.Errorf("determine overlay network: %s", err)
+		}
+		if !overlayNetwork.Contains(overlayIP) && additionalNetwork.Contains(overlayIP) {
+			overlayNetwork = additionalNetwork
+		}
+	}
 
 	overlayNetworkPrefixLength, _ := overlayNetwork.Mask.Size()
 
@@ -82,13 +92,20 @@ func (c *ConfigCreator) Create(clientConf clientConfig.Config, lease controller.
 			overlayNetworkPrefixLength, clientConf.SubnetPrefixLength)
 	}
 
+	// the controller hands out the vni of the pool with the lease, if the
+	// pool has one
+	vni := clientConf.VNI
+	if lease.VNI != 0 {
+		vni = lease.VNI
+	}
+
 	return &Config{
 		VTEPName:            clientConf.VTEPName,
 		UnderlayInterface:   underlayInterface,
 		UnderlayIP:          underlayIP,
 		OverlayIP:           overlayIP,
 		OverlayHardwareAddr: overlayHardwareAddr,
-		VNI:                 clientConf.VNI,
+		VNI:                 vni,
 		OverlayNetworkPrefixLength: overlayNetworkPrefixLength,
 		VTEPPort:                   clientConf.VTEPPort,
 	}, nil
@@ -68,6 +68,15 @@ var _ = Describe("ConfigCreator", func() {
 			Expect(fakeNetAdapter.InterfaceByNameCallCount()).To(Equal(0))
 		})
 
+		Context("when the lease has a vni", func() {
+			It("uses it instead of the configured one", func() {
+				lease.VNI = 7
+				conf, err := creator.Create(clientConf, lease)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.VNI).To(Equal(7))
+			})
+		})
+
 		Context("when VxlanInterfaceName is set", func() {
 			BeforeEach(func() {
 				clientConf.VxlanInterfaceName = "eth1"
@@ -105,6 +114,18 @@ var _ = Describe("ConfigCreator", func() {
 			})
 		})
 
+		Context("when the lease is in one of the additional overlay networks", func() {
+			BeforeEach(func() {
+				clientConf.OverlayNetwork = "10.240.0.0/16"
+				clientConf.AdditionalOverlayNetworks = []string{"10.250.0.0/16", "10.255.0.0/19"}
+			})
+			It("uses the prefix length of that network", func() {
+				conf, err := creator.Create(clientConf, lease)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.OverlayNetworkPrefixLength).To(Equal(19))
+			})
+		})
+
 		Context("when the overlay network is not set", func() {
 			BeforeEach(func() {
 				clientConf.OverlayNetwork = ""
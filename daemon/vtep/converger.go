@@ -16,6 +16,17 @@ type Converger struct {
 	LocalVTEP      net.Interface
 	NetlinkAdapter netlinkAdapter
 	Logger         lager.Logger
+
+	// AdditionalOverlayNetworks are the other networks of the overlay pool.
+	// The address of the vtep only covers the network of the local subnet, so
+	// routes into the other networks are added on-link.
+	AdditionalOverlayNetworks []*net.IPNet
+
+	// VNI is the vni of the local vtep. Leases in other vnis are not
+	// programmed, since their hosts are in another segment. Leases without a
+	// vni are in DefaultVNI. Zero programs the leases of every vni.
+	VNI        int
+	DefaultVNI int
 }
 
 func (c *Converger) Converge(leases []controller.Lease) error {
@@ -25,9 +36,20 @@ func (c *Converger) Converge(leases []controller.Lease) error {
 	}
 
 	nonRoutableLeaseCount := 0
+	otherVNILeaseCount := 0
 	var currentRoutes []netlink.Route
 	var currentNeighs []netlink.Neigh
 	for _, lease := range leases {
+		if lease.OverlaySubnet == "" {
+			nonRoutableLeaseCount++
+			continue
+		}
+
+		if !c.sharesVNI(lease) {
+			otherVNILeaseCount++
+			continue
+		}
+
 		destAddr, destNet, err := net.ParseCIDR(lease.OverlaySubnet)
 		if err != nil {
 			return fmt.Errorf("parse lease: %s", err)
@@ -37,7 +59,7 @@ func (c *Converger) Converge(leases []controller.Lease) error {
 			continue
 		}
 
-		if !c.OverlayNetwork.Contains(destNet.IP) {
+		if !c.inOverlay(destNet.IP) {
 			nonRoutableLeaseCount++
 			continue
 		}
@@ -67,7 +89,7 @@ func (c *Converger) Converge(leases []controller.Lease) error {
 
 	routesForDeletion := getDeletedRoutes(previousRoutes, currentRoutes)
 	for _, route := range routesForDeletion {
-		if route.LinkIndex == c.LocalVTEP.Index && c.OverlayNetwork.Contains(route.Gw) {
+		if route.LinkIndex == c.LocalVTEP.Index && c.inOverlay(route.Gw) {
 			err = c.NetlinkAdapter.RouteDel(&route)
 			if err != nil {
 				return fmt.Errorf("del route: %s", err)
@@ -88,14 +110,48 @@ func (c *Converger) Converge(leases []controller.Lease) error {
 	if nonRoutableLeaseCount > 0 {
 		c.Logger.Info("converger", lager.Data{"non-routable-lease-count": nonRoutableLeaseCount})
 	}
+	if otherVNILeaseCount > 0 {
+		c.Logger.Debug("converger", lager.Data{"other-vni-lease-count": otherVNILeaseCount})
+	}
 
 	return nil
 }
 
+// SetLocalVTEP points the converger at a vtep that was rebuilt, possibly in
+// another vni. It must not be called while Converge runs.
+func (c *Converger) SetLocalVTEP(vtep net.Interface, vni int) {
+	c.LocalVTEP = vtep
+	c.VNI = vni
+}
+
+func (c *Converger) sharesVNI(lease controller.Lease) bool {
+	if c.VNI == 0 {
+		return true
+	}
+	vni := lease.VNI
+	if vni == 0 {
+		vni = c.DefaultVNI
+	}
+	return vni == c.VNI
+}
+
 func (c *Converger) isLocal(destNet *net.IPNet) bool {
 	return destNet.String() == c.LocalSubnet.String()
 }
 
+func (c *Converger) inOverlay(ip net.IP) bool {
+	return c.overlayNetworkOf(ip) != nil
+}
+
+func (c *Converger) overlayNetworkOf(ip net.IP) *net.IPNet {
+	for _, network := range append([]*net.IPNet{c.OverlayNetwork}, c.AdditionalOverlayNetworks...) {
+		if network.Contains(ip) {
+			return network
+		}
+	}
+	return nil
+}
+
 func getDeletedRoutes(previous, current []netlink.Route) []netlink.Route {
 	var deletedRoutes []netlink.Route
 	for _, previousRoute := range previous {
@@ -163,6 +219,9 @@ func (c *Converger) addRoute(destNet *net.IPNet, destAddr net.IP) (netlink.Route
 		Gw:        destAddr,
 		Src:       c.LocalSubnet.IP,
 	}
+	if c.overlayNetworkOf(destAddr) != c.overlayNetworkOf(c.LocalSubnet.IP) {
+		route.Flags = int(netlink.FLAG_ONLINK)
+	}
 
 	err := c.NetlinkAdapter.RouteReplace(&route)
 	if err != nil {
@@ -420,6 +420,163 @@ var _ = Describe("Converger", func() {
 			})
 		})
 
+		Context("when additional overlay networks are configured", func() {
+			BeforeEach(func() {
+				_, additionalNet, _ := net.ParseCIDR("10.254.0.0/16")
+				converger.AdditionalOverlayNetworks = []*net.IPNet{additionalNet}
+				leases = append(leases, controller.Lease{
+					UnderlayIP:          "10.10.0.6",
+					OverlaySubnet:       "10.254.11.0/24",
+					OverlayHardwareAddr: "aa:aa:00:00:00:01",
+				})
+			})
+
+			It("routes leases in those networks on-link", func() {
+				err := converger.Converge(leases)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(2))
+				sameNetworkRoute := fakeNetlink.RouteReplaceArgsForCall(0)
+				Expect(sameNetworkRoute.Dst.String()).To(Equal("10.255.19.0/24"))
+				Expect(sameNetworkRoute.Flags).To(Equal(0))
+				otherNetworkRoute := fakeNetlink.RouteReplaceArgsForCall(1)
+				Expect(otherNetworkRoute.Dst.String()).To(Equal("10.254.11.0/24"))
+				Expect(otherNetworkRoute.Gw.String()).To(Equal("10.254.11.0"))
+				Expect(otherNetworkRoute.Flags).To(Equal(int(netlink.FLAG_ONLINK)))
+				Expect(logger.Logs()).To(BeEmpty())
+			})
+
+			It("deletes routes into those networks once their leases are gone", func() {
+				_, dst, _ := net.ParseCIDR("10.254.12.0/24")
+				fakeNetlink.RouteListReturns([]netlink.Route{{
+					LinkIndex: 42,
+					Dst:       dst,
+					Gw:        net.ParseIP("10.254.12.0"),
+				}}, nil)
+
+				err := converger.Converge(leases)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNetlink.RouteDelCallCount()).To(Equal(1))
+				Expect(fakeNetlink.RouteDelArgsForCall(0).Dst.String()).To(Equal("10.254.12.0/24"))
+			})
+		})
+
+		Context("when there are remote leases without an ipv4 overlay subnet", func() {
+			BeforeEach(func() {
+				leases = []controller.Lease{
+					{ // ipv6 only, skipped
+						UnderlayIP:          "10.10.0.3",
+						OverlaySubnetV6:     "fd00:255:0:b::/64",
+						OverlayHardwareAddr: "ee:ee:00:00:00:0b",
+					},
+					{ // in overlay
+						UnderlayIP:          "10.10.0.5",
+						OverlaySubnet:       "10.255.19.0/24",
+						OverlaySubnetV6:     "fd00:255:0:13::/64",
+						OverlayHardwareAddr: "aa:aa:00:00:00:03",
+					},
+				}
+			})
+			It("skips them and counts them as non-routable", func() {
+				err := converger.Converge(leases)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(1))
+				addedRoute := fakeNetlink.RouteReplaceArgsForCall(0)
+				Expect(addedRoute.Dst.IP).To(Equal(net.ParseIP("10.255.19.0").To4()))
+
+				Expect(logger.Logs()).To(HaveLen(1))
+				Expect(logger.Logs()[0].ToJSON()).To(MatchRegexp("converger.*non-routable-lease-count.*1"))
+			})
+		})
+
+		Context("when the local vtep has a vni", func() {
+			BeforeEach(func() {
+				converger.VNI = 7
+				converger.DefaultVNI = 1
+				leases = []controller.Lease{
+					{ // local, skipped
+						UnderlayIP:          "10.10.0.2",
+						OverlaySubnet:       "10.255.32.0/24",
+						OverlayHardwareAddr: "aa:aa:00:00:00:00",
+						VNI:                 7,
+					},
+					{ // another segment, skipped
+						UnderlayIP:          "10.10.0.3",
+						OverlaySubnet:       "10.255.11.0/24",
+						OverlayHardwareAddr: "aa:aa:00:00:00:01",
+						VNI:                 5,
+					},
+					{ // the default segment, skipped
+						UnderlayIP:          "10.10.0.4",
+						OverlaySubnet:       "10.255.12.0/24",
+						OverlayHardwareAddr: "aa:aa:00:00:00:02",
+					},
+					{ // the same segment
+						UnderlayIP:          "10.10.0.5",
+						OverlaySubnet:       "10.255.19.0/24",
+						OverlayHardwareAddr: "aa:aa:00:00:00:03",
+						VNI:                 7,
+					},
+				}
+			})
+
+			It("only programs the leases that share it", func() {
+				err := converger.Converge(leases)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(1))
+				Expect(fakeNetlink.RouteReplaceArgsForCall(0).Dst.String()).To(Equal("10.255.19.0/24"))
+				Expect(fakeNetlink.NeighSetCallCount()).To(Equal(2))
+				Expect(fakeNetlink.NeighSetArgsForCall(1).IP).To(Equal(net.ParseIP("10.10.0.5")))
+
+				Expect(logger.Logs()).To(HaveLen(1))
+				Expect(logger.Logs()[0].ToJSON()).To(MatchRegexp("converger.*other-vni-lease-count.*2"))
+			})
+
+			It("treats leases without a vni as in the default vni", func() {
+				converger.VNI = 1
+				err := converger.Converge(leases)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(1))
+				Expect(fakeNetlink.RouteReplaceArgsForCall(0).Dst.String()).To(Equal("10.255.12.0/24"))
+			})
+
+			It("removes the routes of leases that moved to another vni", func() {
+				_, dst, _ := net.ParseCIDR("10.255.11.0/24")
+				fakeNetlink.RouteListReturns([]netlink.Route{{
+					LinkIndex: 42,
+					Dst:       dst,
+					Gw:        net.ParseIP("10.255.11.0"),
+				}}, nil)
+
+				err := converger.Converge(leases)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNetlink.RouteDelCallCount()).To(Equal(1))
+				Expect(fakeNetlink.RouteDelArgsForCall(0).Dst.String()).To(Equal("10.255.11.0/24"))
+			})
+
+			Context("when the vtep was rebuilt in another vni", func() {
+				BeforeEach(func() {
+					converger.SetLocalVTEP(net.Interface{Index: 43, Name: "silk-vtep"}, 5)
+				})
+
+				It("programs the leases of the new vni on the new vtep", func() {
+					err := converger.Converge(leases)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeNetlink.LinkByIndexArgsForCall(0)).To(Equal(43))
+					Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(1))
+					route := fakeNetlink.RouteReplaceArgsForCall(0)
+					Expect(route.Dst.String()).To(Equal("10.255.11.0/24"))
+					Expect(route.LinkIndex).To(Equal(43))
+				})
+			})
+		})
+
 		Context("when a lease has an invalid MAC", func() {
 			BeforeEach(func() {
 				leases = []controller.Lease{
@@ -109,3 +109,17 @@ func (f *Factory) GetVTEPState(vtepName string) (net.HardwareAddr, net.IP, int,
 	}
 	return link.Attrs().HardwareAddr, addresses[0].IP, link.Attrs().MTU, nil
 }
+
+// GetVTEPVNI returns the vni of the vtep, so that a lease discovered from it
+// is renewed in the segment it is in.
+func (f *Factory) GetVTEPVNI(vtepName string) (int, error) {
+	link, err := f.NetlinkAdapter.LinkByName(vtepName)
+	if err != nil {
+		return 0, fmt.Errorf("find link: %s", err)
+	}
+	vxlan, ok := link.(*netlink.Vxlan)
+	if !ok {
+		return 0, fmt.Errorf("link %s is not a vxlan device", vtepName)
+	}
+	return vxlan.VxlanId, nil
+}
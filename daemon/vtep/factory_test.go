@@ -2,6 +2,7 @@ package vtep_test
 
 import (
 	"errors"
+	"fmt"
 	"net"
 
 	"code.cloudfoundry.org/lager/v3/lagertest"
@@ -189,6 +190,32 @@ var _ = Describe("Factory", func() {
 		})
 	})
 
+	Describe("GetVTEPVNI", func() {
+		It("returns the vni of the vtep", func() {
+			fakeNetlinkAdapter.LinkByNameReturns(&netlink.Vxlan{VxlanId: 7}, nil)
+			vni, err := factory.GetVTEPVNI(vtepConfig.VTEPName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vni).To(Equal(7))
+			Expect(fakeNetlinkAdapter.LinkByNameArgsForCall(0)).To(Equal(vtepConfig.VTEPName))
+		})
+
+		Context("when finding the link errors", func() {
+			It("returns an error", func() {
+				fakeNetlinkAdapter.LinkByNameReturns(nil, errors.New("potato"))
+				_, err := factory.GetVTEPVNI(vtepConfig.VTEPName)
+				Expect(err).To(MatchError("find link: potato"))
+			})
+		})
+
+		Context("when the link is not a vxlan device", func() {
+			It("returns an error", func() {
+				fakeNetlinkAdapter.LinkByNameReturns(&netlink.Dummy{}, nil)
+				_, err := factory.GetVTEPVNI(vtepConfig.VTEPName)
+				Expect(err).To(MatchError(fmt.Sprintf("link %s is not a vxlan device", vtepConfig.VTEPName)))
+			})
+		})
+	})
+
 	Describe("DeleteVTEP", func() {
 		BeforeEach(func() {
 			fakeNetlinkAdapter.LinkByNameReturns(&netlink.Vxlan{
@@ -0,0 +1,42 @@
+package vtep
+
+import (
+	"fmt"
+	"net"
+
+	clientConfig "code.cloudfoundry.org/silk/client/config"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+// Rebuilder replaces the vtep with one built from a lease, so that it moves to
+// the vni the lease carries. The routes and neighbors of the old vtep go with
+// it, and must be programmed anew on the vtep it returns.
+type Rebuilder struct {
+	ClientConfig  clientConfig.Config
+	ConfigCreator *ConfigCreator
+	Factory       *Factory
+	NetAdapter    netAdapter
+}
+
+func (r *Rebuilder) Rebuild(lease controller.Lease) (net.Interface, int, error) {
+	vtepConf, err := r.ConfigCreator.Create(r.ClientConfig, lease)
+	if err != nil {
+		return net.Interface{}, 0, fmt.Errorf("create vtep config: %s", err)
+	}
+
+	err = r.Factory.DeleteVTEP(vtepConf.VTEPName)
+	if err != nil {
+		return net.Interface{}, 0, fmt.Errorf("delete vtep: %s", err)
+	}
+
+	err = r.Factory.CreateVTEP(vtepConf)
+	if err != nil {
+		return net.Interface{}, 0, fmt.Errorf("create vtep: %s", err)
+	}
+
+	iface, err := r.NetAdapter.InterfaceByName(vtepConf.VTEPName)
+	if err != nil {
+		return net.Interface{}, 0, fmt.Errorf("find vtep: %s", err)
+	}
+	return *iface, vtepConf.VNI, nil
+}
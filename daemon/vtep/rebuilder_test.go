@@ -0,0 +1,139 @@
+package vtep_test
+
+import (
+	"errors"
+	"net"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	clientConfig "code.cloudfoundry.org/silk/client/config"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/daemon/vtep"
+	"code.cloudfoundry.org/silk/daemon/vtep/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+)
+
+var _ = Describe("Rebuilder", func() {
+	var (
+		fakeNetlinkAdapter *fakes.NetlinkAdapter
+		fakeNetAdapter     *fakes.NetAdapter
+		rebuilder          *vtep.Rebuilder
+		lease              controller.Lease
+	)
+
+	BeforeEach(func() {
+		fakeNetlinkAdapter = &fakes.NetlinkAdapter{}
+		fakeNetAdapter = &fakes.NetAdapter{}
+		rebuilder = &vtep.Rebuilder{
+			ClientConfig: clientConfig.Config{
+				UnderlayIP:         "172.255.30.2",
+				SubnetPrefixLength: 24,
+				VTEPName:           "some-vtep-name",
+				VNI:                99,
+				OverlayNetwork:     "10.255.0.0/16",
+				VTEPPort:           12225,
+			},
+			ConfigCreator: &vtep.ConfigCreator{NetAdapter: fakeNetAdapter},
+			Factory: &vtep.Factory{
+				NetlinkAdapter: fakeNetlinkAdapter,
+				Logger:         lagertest.NewTestLogger("test"),
+			},
+			NetAdapter: fakeNetAdapter,
+		}
+		lease = controller.Lease{
+			UnderlayIP:          "172.255.30.2",
+			OverlaySubnet:       "10.255.30.0/24",
+			OverlayHardwareAddr: "ee:ee:0a:ff:1e:00",
+			VNI:                 7,
+		}
+
+		fakeNetAdapter.InterfacesReturns([]net.Interface{{Index: 42}}, nil)
+		fakeNetAdapter.InterfaceAddrsReturns([]net.Addr{
+			&net.IPNet{IP: net.IP{172, 255, 30, 2}, Mask: net.IPMask{255, 255, 255, 255}},
+		}, nil)
+		fakeNetAdapter.InterfaceByNameReturns(&net.Interface{Index: 43, Name: "some-vtep-name"}, nil)
+		fakeNetlinkAdapter.LinkByNameReturns(&netlink.Vxlan{
+			LinkAttrs: netlink.LinkAttrs{Name: "some-vtep-name"},
+			VxlanId:   99,
+		}, nil)
+	})
+
+	It("replaces the vtep with one in the vni of the lease", func() {
+		iface, vni, err := rebuilder.Rebuild(lease)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(iface).To(Equal(net.Interface{Index: 43, Name: "some-vtep-name"}))
+		Expect(vni).To(Equal(7))
+
+		Expect(fakeNetlinkAdapter.LinkDelCallCount()).To(Equal(1))
+		Expect(fakeNetlinkAdapter.LinkDelArgsForCall(0).Attrs().Name).To(Equal("some-vtep-name"))
+
+		Expect(fakeNetlinkAdapter.LinkAddCallCount()).To(Equal(1))
+		created := fakeNetlinkAdapter.LinkAddArgsForCall(0).(*netlink.Vxlan)
+		Expect(created.Name).To(Equal("some-vtep-name"))
+		Expect(created.VxlanId).To(Equal(7))
+		Expect(created.VtepDevIndex).To(Equal(42))
+
+		Expect(fakeNetAdapter.InterfaceByNameArgsForCall(0)).To(Equal("some-vtep-name"))
+	})
+
+	Context("when the lease has no vni", func() {
+		BeforeEach(func() {
+			lease.VNI = 0
+		})
+
+		It("rebuilds the vtep in the vni of the config", func() {
+			_, vni, err := rebuilder.Rebuild(lease)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vni).To(Equal(99))
+			Expect(fakeNetlinkAdapter.LinkAddArgsForCall(0).(*netlink.Vxlan).VxlanId).To(Equal(99))
+		})
+	})
+
+	Context("when the lease is not valid", func() {
+		BeforeEach(func() {
+			lease.OverlaySubnet = "banana"
+		})
+
+		It("leaves the vtep alone", func() {
+			_, _, err := rebuilder.Rebuild(lease)
+			Expect(err).To(MatchError(HavePrefix("create vtep config: ")))
+			Expect(fakeNetlinkAdapter.LinkDelCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the vtep cannot be deleted", func() {
+		BeforeEach(func() {
+			fakeNetlinkAdapter.LinkDelReturns(errors.New("banana"))
+		})
+
+		It("returns an error without creating another", func() {
+			_, _, err := rebuilder.Rebuild(lease)
+			Expect(err).To(MatchError("delete vtep: delete link some-vtep-name: banana"))
+			Expect(fakeNetlinkAdapter.LinkAddCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the vtep cannot be created", func() {
+		BeforeEach(func() {
+			fakeNetlinkAdapter.LinkAddReturns(errors.New("banana"))
+		})
+
+		It("returns an error", func() {
+			_, _, err := rebuilder.Rebuild(lease)
+			Expect(err).To(MatchError("create vtep: create link some-vtep-name: banana"))
+		})
+	})
+
+	Context("when the new vtep cannot be found", func() {
+		BeforeEach(func() {
+			fakeNetAdapter.InterfaceByNameReturns(nil, errors.New("banana"))
+		})
+
+		It("returns an error", func() {
+			_, _, err := rebuilder.Rebuild(lease)
+			Expect(err).To(MatchError("find vtep: banana"))
+		})
+	})
+})
@@ -2,6 +2,7 @@ package hwaddr
 
 import (
 	"fmt"
+	"math/big"
 	"net"
 )
 
@@ -24,3 +25,27 @@ func GenerateHardwareAddr4(ip net.IP, prefix []byte) (net.HardwareAddr, error) {
 			ip[ipByteLen-4:ipByteLen]...),
 	), nil
 }
+
+func GenerateHardwareAddr6(subnet *net.IPNet, prefix []byte) (net.HardwareAddr, error) {
+	ones, bits := subnet.Mask.Size()
+	switch {
+
+	case subnet.IP.To4() != nil || bits != 8*net.IPv6len:
+		return nil, fmt.Errorf("%s is not an IPv6 subnet", subnet)
+
+	case len(prefix) != 2:
+		return nil, fmt.Errorf("Prefix length should be 2 bytes, but received %d bytes", len(prefix))
+	}
+
+	// The low bits of an IPv6 block are all zero, so use the 32 bits just
+	// above the host part instead. They identify the block within its network.
+	blockNumber := new(big.Int).Rsh(new(big.Int).SetBytes(subnet.IP.To16()), uint(bits-ones))
+	blockBytes := make([]byte, net.IPv6len)
+	blockNumber.FillBytes(blockBytes)
+
+	return (net.HardwareAddr)(
+		append(
+			append([]byte{}, prefix...),
+			blockBytes[net.IPv6len-4:]...),
+	), nil
+}
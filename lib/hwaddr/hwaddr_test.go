@@ -38,4 +38,28 @@ var _ = Describe("Hwaddr", func() {
 			Expect(addr.String()).To(Equal(fmt.Sprintf("aa:bb:%02x:%02x:%02x:%02x", ipV4Addr[12], ipV4Addr[13], ipV4Addr[14], ipV4Addr[15])))
 		})
 	})
+
+	Describe("GenerateHardwareAddr6", func() {
+		validPrefix := []byte{0xaa, 0xbb}
+		_, ipV6Subnet, _ := net.ParseCIDR("2001:db8:0:2a01::/64")
+		_, ipV4Subnet, _ := net.ParseCIDR("10.255.1.0/24")
+
+		Context("when the provided subnet isn't ipv6", func() {
+			It("returns an error", func() {
+				_, err := hwaddr.GenerateHardwareAddr6(ipV4Subnet, validPrefix)
+				Expect(err).To(MatchError("10.255.1.0/24 is not an IPv6 subnet"))
+			})
+		})
+
+		It("returns an error when the prefix isn't 2 bytes", func() {
+			_, err := hwaddr.GenerateHardwareAddr6(ipV6Subnet, []byte{0xaa})
+			Expect(err).To(MatchError("Prefix length should be 2 bytes, but received 1 bytes"))
+		})
+
+		It("returns a MAC addr with the given prefix, based on the block number of the subnet", func() {
+			addr, err := hwaddr.GenerateHardwareAddr6(ipV6Subnet, validPrefix)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(addr.String()).To(Equal("aa:bb:00:00:2a:01"))
+		})
+	})
 })
@@ -0,0 +1,49 @@
+package testsupport
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/cf-networking-helpers/db"
+	helpers "code.cloudfoundry.org/cf-networking-helpers/testsupport"
+	"code.cloudfoundry.org/silk/controller/database"
+
+	. "github.com/onsi/gomega"
+)
+
+// GetDBConfig returns the config of a database named name on the server of
+// the DB environment variable, or of a file in the temporary directory when
+// DB is sqlite3, so that tests need no database server.
+func GetDBConfig(name string) db.Config {
+	if strings.HasPrefix(os.Getenv("DB"), database.SQLite) {
+		return db.Config{
+			Type:         database.SQLite,
+			Timeout:      120,
+			DatabaseName: filepath.Join(os.TempDir(), name+".db"),
+		}
+	}
+	dbConfig := helpers.GetDBConfig()
+	dbConfig.DatabaseName = name
+	return dbConfig
+}
+
+func CreateDatabase(dbConfig db.Config) {
+	if dbConfig.Type == database.SQLite {
+		return
+	}
+	helpers.CreateDatabase(dbConfig)
+}
+
+func RemoveDatabase(dbConfig db.Config) {
+	if dbConfig.Type == database.SQLite {
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			err := os.Remove(dbConfig.DatabaseName + suffix)
+			if !os.IsNotExist(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+		return
+	}
+	helpers.RemoveDatabase(dbConfig)
+}